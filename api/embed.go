@@ -0,0 +1,10 @@
+// Package api содержит описание HTTP API в формате OpenAPI 3, встроенное в бинарник.
+package api
+
+import _ "embed"
+
+// OpenAPI спецификация API сервиса (OpenAPI 3.0, JSON). При изменении обработчиков
+// обновляйте ее вместе с ними.
+//
+//go:embed openapi.json
+var OpenAPI []byte
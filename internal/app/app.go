@@ -3,26 +3,40 @@ package app
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	cfgman "DelayedNotifier/internal/config"
 	"DelayedNotifier/internal/delivery/handlers"
 	"DelayedNotifier/internal/delivery/middleware"
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/egress"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
 	"DelayedNotifier/internal/migrator"
+	"DelayedNotifier/internal/precheck"
+	"DelayedNotifier/internal/repository/cache"
 	"DelayedNotifier/internal/repository/pg"
 	"DelayedNotifier/internal/repository/rabbit"
+	"DelayedNotifier/internal/sender"
 	emailsender "DelayedNotifier/internal/sender/email"
+	smssender "DelayedNotifier/internal/sender/sms"
+	telegramsender "DelayedNotifier/internal/sender/telegram"
 	"DelayedNotifier/internal/service"
 	"DelayedNotifier/internal/worker"
+	"DelayedNotifier/migrations"
+	"DelayedNotifier/pkg/idgen"
 	"DelayedNotifier/pkg/rabbitmq"
 	"DelayedNotifier/pkg/retry"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/redis"
@@ -37,8 +51,10 @@ type Application struct {
 	redis     *redis.Client
 	rabbit    *rabbitmq.RabbitClient
 	publisher *rabbit.Publisher
+	topology  *rabbit.Topology
 	consumer  *worker.Consumer
 	service   *service.NotificationService
+	logSinks  io.Closer
 }
 
 // New создает новое приложение.
@@ -50,13 +66,16 @@ func New() (*Application, error) {
 	}
 
 	// Инициализируем логгер
-	if err := initLogger(cfg.Logging.Level); err != nil {
+	logSinks, err := initLogger(cfg.Logging)
+	if err != nil {
 		return nil, fmt.Errorf("failed to init logger: %w", err)
 	}
 
 	app := &Application{
-		config: cfg,
+		config:   cfg,
+		logSinks: logSinks,
 	}
+	metrics.Namespace.Set(cfg.Namespace)
 
 	return app, nil
 }
@@ -77,6 +96,14 @@ func (a *Application) Run() error {
 		return a.runMigrate()
 	case "health":
 		return a.runHealthCheck()
+	case "topology":
+		return a.runTopology()
+	case "seed":
+		return a.runSeed()
+	case "selftest":
+		return a.runSelfTest()
+	case "secret":
+		return a.runSecret()
 	default:
 		a.printUsage()
 		return fmt.Errorf("unknown command: %s", command)
@@ -91,123 +118,85 @@ func (a *Application) printUsage() {
 	fmt.Println("  runserver    - запуск HTTP сервера и воркеров")
 	fmt.Println("  migrate up   - накат миграций")
 	fmt.Println("  migrate down - откат миграций")
-	fmt.Println("  health       - проверка состояния сервисов")
+	fmt.Println("  migrate repair - снятие dirty после прерванной миграции, если схема это позволяет (--dry-run)")
+	fmt.Println("  health       - проверка состояния сервисов (--format json, --wait 30s, --interval 2s)")
+	fmt.Println("  topology sync  - объявление exchange, очередей и DLX в RabbitMQ")
+	fmt.Println("  topology check - проверка топологии RabbitMQ без изменений")
+	fmt.Println("  seed         - демо-данные: уведомления по всем каналам и статусам (--count 100)")
+	fmt.Println("  selftest     - проверка после деплоя: отправка на адреса-приемники (--dry-run, --timeout 2m)")
+	fmt.Println("  secret keygen  - новый ключ для зашифрованных значений конфигурации")
+	fmt.Println("  secret encrypt - шифрование значения из stdin в формат enc: (ключ DELAYED_NOTIFIER_CONFIG_KEY)")
 	fmt.Println()
 	fmt.Println("Примеры:")
 	fmt.Println("  <appname> runserver")
 	fmt.Println("  <appname> migrate up")
 	fmt.Println("  <appname> migrate down")
 	fmt.Println("  <appname> health")
+	fmt.Println("  <appname> health --format json --wait 60s")
+	fmt.Println("  <appname> topology sync")
+	fmt.Println("  <appname> seed --count 500")
+	fmt.Println("  <appname> selftest --timeout 5m")
+	fmt.Println("  echo -n 'smtp-password' | <appname> secret encrypt")
 }
 
-// runHealthCheck проверяет состояние всех подключений.
-func (a *Application) runHealthCheck() error {
-	fmt.Println("Running health check...")
-
-	// Проверяем подключение к базе данных
-	if err := a.checkDatabase(); err != nil {
-		return fmt.Errorf("database check failed: %w", err)
-	}
-	fmt.Println("✅ Database connection: OK")
-
-	// Проверяем подключение к Redis
-	if err := a.checkRedis(); err != nil {
-		return fmt.Errorf("redis check failed: %w", err)
-	}
-	fmt.Println("✅ Redis connection: OK")
-
-	// Проверяем подключение к RabbitMQ
-	if err := a.checkRabbitMQ(); err != nil {
-		return fmt.Errorf("rabbitmq check failed: %w", err)
+// runTopology синхронизирует или проверяет топологию RabbitMQ.
+func (a *Application) runTopology() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("topology command requires action (sync/check)")
 	}
-	fmt.Println("✅ RabbitMQ connection: OK")
 
-	fmt.Println("🎉 All health checks passed!")
-	return nil
-}
-
-// checkDatabase проверяет подключение к базе данных.
-func (a *Application) checkDatabase() error {
-	cfg, err := cfgman.LoadConfig()
+	client, err := newRabbitClient(a.config.RabbitMQ)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return fmt.Errorf("failed to connect to rabbitmq: %w", err)
 	}
-
-	opts := &dbpg.Options{
-		MaxOpenConns: cfg.Database.MaxOpenConns,
-		MaxIdleConns: cfg.Database.MaxIdleConns,
-	}
-
-	db, err := dbpg.New(cfg.Database.DSN, nil, opts)
-	if err != nil {
-		return err
+	defer func() {
+		_ = client.Close()
+	}()
+	topology := rabbit.NewTopology(client, a.rabbitNames())
+
+	var report domain.TopologyReport
+	switch action := os.Args[2]; action {
+	case "sync":
+		report, err = topology.Sync(context.Background())
+	case "check":
+		report, err = topology.Check(context.Background())
+	default:
+		return fmt.Errorf("unknown topology action: %s (use sync/check)", action)
 	}
-	defer func(Master *sql.DB) {
-		_ = Master.Close()
-	}(db.Master)
-
-	return db.Master.Ping()
-}
 
-// checkRedis проверяет подключение к Redis.
-func (a *Application) checkRedis() error {
-	cfg, err := cfgman.LoadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	for _, item := range report.Items {
+		mark := "✅"
+		if item.Error != "" {
+			mark = "❌"
+		}
+		fmt.Printf("%s %s %s: %s %s\n", mark, item.Kind, item.Name, item.State, item.Error)
 	}
-
-	client := redis.New(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	return client.Ping(ctx).Err()
-}
-
-// checkRabbitMQ проверяет подключение к RabbitMQ.
-func (a *Application) checkRabbitMQ() error {
-	cfg, err := cfgman.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	publishStrategy := retry.Strategy{
-		Attempts: cfg.RabbitMQ.PublishRetry.Attempts,
-		Delay:    cfg.RabbitMQ.PublishRetry.Delay,
-		Backoff:  float64(cfg.RabbitMQ.PublishRetry.Backoff),
-	}
-
-	clientConfig := rabbitmq.ClientConfig{
-		URL:            cfg.RabbitMQ.URL,
-		ConnectionName: cfg.RabbitMQ.ConnectionName + "-health",
-		ConnectTimeout: 5 * time.Second,
-		Heartbeat:      5 * time.Second,
-		PublishRetry:   publishStrategy,
+		return fmt.Errorf("topology %s failed: %w", os.Args[2], err)
 	}
-
-	client, err := rabbitmq.NewClient(clientConfig)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-
-	// Простая проверка - попытка подключения
-	return client.Ping()
+	fmt.Println("🎉 RabbitMQ topology is in sync!")
+	return nil
 }
 
-// initLogger инициализирует логгер.
-func initLogger(level string) error {
-	zlog.Init()
-
-	zerologLevel, err := zerolog.ParseLevel(level)
-	if err != nil {
-		return err
-	}
-	err = zlog.SetLevel(zerologLevel.String())
-	if err != nil {
-		return err
-	}
-
-	return nil
+// initLogger инициализирует логгер: формат, приемники и выборку debug сообщений.
+func initLogger(cfg cfgman.LoggingConfig) (io.Closer, error) {
+	return logger.Setup(logger.Options{
+		Level:        cfg.Level,
+		Format:       cfg.Format,
+		Outputs:      strings.Split(cfg.Outputs, ","),
+		DebugSampleN: cfg.DebugSampleN,
+		File: logger.FileOptions{
+			Path:       cfg.File.Path,
+			MaxSizeMB:  cfg.File.MaxSizeMB,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxAge,
+		},
+		Syslog: logger.SyslogOptions{
+			Network: cfg.Syslog.Network,
+			Addr:    cfg.Syslog.Addr,
+			Tag:     cfg.Syslog.Tag,
+		},
+	})
 }
 
 // runServer запускает приложение в режиме сервера.
@@ -217,6 +206,11 @@ func (a *Application) runServer() error {
 	ctx, cancel := signal.NotifyContext(context.Background(),
 		os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	if a.config.Database.AutoMigrate {
+		if err := a.autoMigrate(ctx); err != nil {
+			return fmt.Errorf("auto migrate failed: %w", err)
+		}
+	}
 	if err := a.initConnections(); err != nil {
 		return fmt.Errorf("failed to init connections: %w", err)
 	}
@@ -245,7 +239,7 @@ func (a *Application) runServer() error {
 // runMigrate запускает приложение в режиме миграций.
 func (a *Application) runMigrate() error {
 	if len(os.Args) < 3 {
-		return fmt.Errorf("migrate command requires direction (up/down)")
+		return fmt.Errorf("migrate command requires direction (up/down/repair)")
 	}
 
 	direction := os.Args[2]
@@ -255,8 +249,10 @@ func (a *Application) runMigrate() error {
 		return a.runMigrateUp()
 	case "down":
 		return a.runMigrateDown()
+	case "repair":
+		return a.runMigrateRepair()
 	default:
-		return fmt.Errorf("unknown migrate direction: %s (use up/down)", direction)
+		return fmt.Errorf("unknown migrate direction: %s (use up/down/repair)", direction)
 	}
 }
 
@@ -270,7 +266,7 @@ func (a *Application) runMigrateUp() error {
 	defer func(Master *sql.DB) {
 		_ = Master.Close()
 	}(db.Master)
-	m, err := migrator.NewMigrator(db.Master, a.config.Migrations.Path)
+	m, err := a.newMigrator(db.Master)
 	if err != nil {
 		return fmt.Errorf("failed to create migrator: %w", err)
 	}
@@ -294,7 +290,7 @@ func (a *Application) runMigrateDown() error {
 		_ = Master.Close()
 	}(db.Master)
 
-	m, err := migrator.NewMigrator(db.Master, a.config.Migrations.Path)
+	m, err := a.newMigrator(db.Master)
 	if err != nil {
 		return fmt.Errorf("failed to create migrator: %w", err)
 	}
@@ -306,6 +302,106 @@ func (a *Application) runMigrateDown() error {
 	return nil
 }
 
+// runMigrateRepair снимает dirty после прерванной миграции. Схема сверяется
+// с проверками миграции: применена целиком — фиксируется ее версия, не применена —
+// предыдущая; частично примененную нужно исправить вручную. --dry-run только печатает план.
+func (a *Application) runMigrateRepair() error {
+	fs := flag.NewFlagSet("migrate repair", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the plan without changing schema_migrations")
+	if err := fs.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+
+	db, err := initDatabase(a.config.Database)
+	if err != nil {
+		return fmt.Errorf("failed to init database: %w", err)
+	}
+	defer func(Master *sql.DB) {
+		_ = Master.Close()
+	}(db.Master)
+
+	m, err := a.newMigrator(db.Master)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	version, dirty, err := m.State()
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	plan, err := migrator.PlanRepair(context.Background(), db.Master, version, dirty, migrations.Checks)
+	if err != nil {
+		return fmt.Errorf("failed to check schema: %w", err)
+	}
+	if !plan.Dirty {
+		fmt.Printf("version %d is clean, nothing to repair\n", plan.Version)
+		return nil
+	}
+
+	fmt.Printf("version %d is dirty\n", plan.Version)
+	for _, name := range plan.Applied {
+		fmt.Printf("  present: %s\n", name)
+	}
+	for _, name := range plan.Missing {
+		fmt.Printf("  missing: %s\n", name)
+	}
+	if !plan.Safe {
+		return fmt.Errorf("migration %d is partially applied: finish or revert it by hand, then run repair again",
+			plan.Version)
+	}
+	fmt.Printf("force version %d\n", plan.ForceTo)
+	if *dryRun {
+		return nil
+	}
+	if err := m.Force(plan.ForceTo); err != nil {
+		return fmt.Errorf("failed to force version: %w", err)
+	}
+	zlog.Logger.Info().Uint("dirty", plan.Version).Int("forced", plan.ForceTo).Msg("Migration state repaired")
+	return nil
+}
+
+// autoMigrate применяет непримененные миграции при старте сервера.
+// Одновременный запуск нескольких экземпляров безопасен: Up выполняется под advisory lock.
+func (a *Application) autoMigrate(ctx context.Context) error {
+	db, err := initDatabase(a.config.Database)
+	if err != nil {
+		return fmt.Errorf("failed to init database: %w", err)
+	}
+	defer func(Master *sql.DB) {
+		_ = Master.Close()
+	}(db.Master)
+
+	m, err := a.newMigrator(db.Master)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	before, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if err := m.UpContext(ctx); err != nil {
+		return fmt.Errorf("migration up failed: %w", err)
+	}
+	after, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	zlog.Logger.Info().Uint("from", before).Uint("to", after).Msg("Auto migration finished")
+	return nil
+}
+
+// newMigrator создает мигратор из встроенных миграций или с диска,
+// в зависимости от настройки migrations.embedded.
+func (a *Application) newMigrator(db *sql.DB) (*migrator.Migrator, error) {
+	lock := migrator.WithLockOptions(
+		migrator.WithLockTimeout(a.config.Migrations.LockTimeout),
+		migrator.WithStaleAfter(a.config.Migrations.StaleLockAfter))
+	if a.config.Migrations.Embedded {
+		return migrator.NewMigratorFS(db, migrations.FS, lock)
+	}
+	return migrator.NewMigrator(db, a.config.Migrations.Path, lock)
+}
+
 // initConnections инициализирует все подключения.
 func (a *Application) initConnections() error {
 	var err error
@@ -320,7 +416,7 @@ func (a *Application) initConnections() error {
 		return fmt.Errorf("failed to init redis: %w", err)
 	}
 
-	a.rabbit, err = initRabbitMQ(a.config.RabbitMQ)
+	a.rabbit, err = initRabbitMQ(a.config.RabbitMQ, a.rabbitNames())
 	if err != nil {
 		return fmt.Errorf("failed to init rabbitmq: %w", err)
 	}
@@ -367,8 +463,29 @@ func initRedis(cfg cfgman.RedisConfig) (*redis.Client, error) {
 	return client, nil
 }
 
-// initRabbitMQ инициализирует подключение к RabbitMQ.
-func initRabbitMQ(cfg cfgman.RabbitMQConfig) (*rabbitmq.RabbitClient, error) {
+// initRabbitMQ инициализирует подключение к RabbitMQ и синхронизирует топологию.
+func initRabbitMQ(cfg cfgman.RabbitMQConfig, names rabbit.Names) (*rabbitmq.RabbitClient, error) {
+	client, err := newRabbitClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	_, err = rabbit.NewTopology(client, names).Sync(context.Background())
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("Failed to sync topology")
+		_ = client.Close()
+		return nil, err
+	}
+	zlog.Logger.Info().Msg("RabbitMQ connection established")
+	return client, nil
+}
+
+// rabbitNames возвращает имена exchange и очередей с учетом пространства имен окружения.
+func (a *Application) rabbitNames() rabbit.Names {
+	return rabbit.NewNames(a.config.Namespace, a.config.RabbitMQ.ExchangeName, a.config.RabbitMQ.QueueName)
+}
+
+// newRabbitClient создает клиента RabbitMQ без объявления топологии.
+func newRabbitClient(cfg cfgman.RabbitMQConfig) (*rabbitmq.RabbitClient, error) {
 	publishStrategy := retry.Strategy{
 		Attempts: cfg.PublishRetry.Attempts,
 		Delay:    cfg.PublishRetry.Delay,
@@ -383,65 +500,193 @@ func initRabbitMQ(cfg cfgman.RabbitMQConfig) (*rabbitmq.RabbitClient, error) {
 		PublishRetry:   publishStrategy,
 	}
 
-	client, err := rabbitmq.NewClient(clientConfig)
-	if err != nil {
-		return nil, err
-	}
-	err = client.DeclareQueue(cfg.QueueName, cfg.ExchangeName, cfg.QueueName, false, false, false, nil)
-	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Failed to declare queue")
-		return nil, err
-	}
-	zlog.Logger.Info().Msg("RabbitMQ connection established")
-	return client, nil
+	return rabbitmq.NewClient(clientConfig)
 }
 
 // initServices инициализирует сервисы приложения.
 func (a *Application) initServices() error {
-	pgRepo := pg.NewPostgresRepo(a.db)
+	newID, err := idgen.New(a.config.IDs.Generator)
+	if err != nil {
+		return fmt.Errorf("failed to init id generator: %w", err)
+	}
+	pgRepo := pg.NewPostgresRepo(a.db,
+		pg.WithIDGenerator(newID),
+		pg.WithQueryTimeout(a.config.Database.QueryTimeout),
+		pg.WithSlowQueryThreshold(a.config.Database.SlowQueryThreshold),
+		pg.WithLargePayloadThreshold(a.config.Database.LargePayloadThreshold))
 
-	a.publisher = rabbit.NewPublisher(
-		a.rabbit,
-		a.config.RabbitMQ.ExchangeName,
-		"application/json",
-		a.config.RabbitMQ.QueueName)
+	a.publisher = rabbit.NewPublisher(a.rabbit, a.rabbitNames(), "application/json")
+	a.topology = rabbit.NewTopology(a.rabbit, a.rabbitNames())
 
-	a.service = service.NewNotificationService(pgRepo, a.publisher, a.redis, 24*time.Hour)
+	cacheCodec, err := service.NewCacheCodec(a.config.Redis.Codec)
+	if err != nil {
+		return fmt.Errorf("failed to init cache codec: %w", err)
+	}
+	slaTargets, err := domain.ParseSLATargets(a.config.SLA.Targets)
+	if err != nil {
+		return fmt.Errorf("invalid DELAYED_NOTIFIER_SLA_TARGETS: %w", err)
+	}
+
+	redisRepo := cache.NewRedisRepo(a.redis, cache.WithNamespace(a.config.Namespace))
+	opts := append(sender.ServiceOptions(a.config.Email.CheckMX),
+		service.WithCacheCodec(cacheCodec),
+		service.WithCacheWriteThrough(a.config.Redis.WriteThrough),
+		service.WithScheduleLimits(a.config.Schedule.MaxPast, a.config.Schedule.MaxFuture),
+		service.WithSmoothing(a.config.Schedule.SmoothWindow),
+		service.WithAdmission(a.config.Admission.LowBacklog, a.config.Admission.NormalBacklog,
+			a.config.Admission.Refresh),
+		service.WithSavedViews(pgRepo),
+		service.WithTemplates(pgRepo),
+		service.WithSchemas(pgRepo),
+		service.WithFailureLog(pgRepo),
+		service.WithEventLog(pgRepo),
+		service.WithChannelDefaults(a.channelDefaults()),
+		service.WithCacheInspector(redisRepo),
+		service.WithMaxRetries(a.config.RabbitMQ.MaxRetries),
+		service.WithMaxBatch(a.config.HTTP.MaxBatch),
+		service.WithStatusLinks([]byte(a.config.StatusPage.Secret), a.config.StatusPage.TTL),
+		service.WithReceiptDedup(a.config.Receipts.DedupTTL),
+		service.WithSLA(slaTargets, a.config.SLA.Window, a.config.SLA.MinSamples))
+	a.service = service.NewNotificationService(pgRepo, a.publisher, redisRepo, a.config.Redis.Expiration, opts...)
 
 	return nil
 }
 
+// channelDefaults собирает значения payload по умолчанию для каналов из конфигурации.
+func (a *Application) channelDefaults() domain.ChannelDefaults {
+	d := domain.ChannelDefaults{}
+	set := func(ch domain.Channel, key, value string) {
+		if value == "" {
+			return
+		}
+		if d[ch] == nil {
+			d[ch] = map[string]interface{}{}
+		}
+		d[ch][key] = value
+	}
+	set(domain.ChannelEmail, domain.PayloadFromName, a.config.Email.FromName)
+	set(domain.ChannelTelegram, domain.PayloadParseMode, a.config.Telegram.ParseMode)
+	set(domain.ChannelSMS, domain.PayloadSenderID, a.config.SMS.SenderID)
+	return d
+}
+
 // setupHTTPServer настраивает HTTP сервер.
 func (a *Application) setupHTTPServer() error {
 	a.server = ginext.New(gin.ReleaseMode)
 	//a.server.Use(middleware.CORSMiddleware())
 	a.server.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "X-IJT"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-IJT", "Idempotency-Key", "If-None-Match", "If-Match"},
+		ExposeHeaders:    []string{"ETag"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowCredentials: true,
 	}))
 
 	a.server.Use(middleware.RequestIDMiddleware())
+	a.server.Use(middleware.ActorMiddleware(domain.ActorAPI))
 	a.server.Use(middleware.LoggingMiddleware())
+	debugRecorder := middleware.NewDebugRecorder(a.config.Debug.BufferSize, a.config.Debug.SampleRate,
+		a.config.Admin.APIKey)
+	a.server.Use(debugRecorder.Middleware())
+	a.server.Use(middleware.RecoveryMiddleware())
+	maintenance := middleware.NewMaintenance(a.config.HTTP.ReadOnly, a.config.HTTP.ReadOnlyMessage,
+		"/admin/maintenance")
+	a.server.Use(maintenance.Middleware())
+	if a.config.HTTP.ReadOnly {
+		zlog.Logger.Warn().Msg("HTTP API started in read-only maintenance mode")
+	}
 	a.server.Static("/web", "./web")
 	a.server.LoadHTMLGlob("web/*.html")
-	h := handlers.NewHandlersSet(a.service)
+	h := handlers.NewHandlersSet(a.service, handlers.WithPublicBaseURL(a.config.StatusPage.BaseURL),
+		handlers.WithRequireIfMatch(a.config.HTTP.RequireIfMatch))
 	a.server.GET("/", func(c *gin.Context) {
 		c.HTML(200, "index.html", gin.H{
 			"title": "Главная страница",
 		})
 	})
+	a.server.GET("/swagger/*any", handlers.SwaggerHandler)
+
 	group := a.server.RouterGroup.Group("notify")
 	group.POST("/", h.CreateNotificationHandler)
+	group.POST("/batch", h.CreateBatchHandler)
+	group.GET("/group/:group_id", h.GroupStatusHandler)
+	group.GET("/", h.ListNotificationsHandler)
 	group.GET("/:id", h.GetNotificationHandler)
+	group.PATCH("/:id", h.UpdateDraftHandler)
+	group.POST("/:id/schedule", h.ScheduleDraftHandler)
+	group.GET("/:id/preview", h.PreviewNotificationHandler)
+	group.POST("/:id/clone", h.CloneNotificationHandler)
+	group.GET("/:id/related", h.GetRelatedNotificationsHandler)
+	group.GET("/:id/history", h.HistoryHandler)
+	group.PUT("/:id/confirm", h.ConfirmNotificationHandler)
+	group.POST("/:id/approve", h.ApproveNotificationHandler)
+	group.POST("/:id/receipt", middleware.WebhookSignatureMiddleware(a.config.Receipts.Secret,
+		a.config.Receipts.Tolerance), h.ReceiptNotificationHandler)
+	group.POST("/:id/status-link", h.StatusLinkHandler)
 	group.DELETE("/:id", h.DeleteNotificationHandler)
 
+	a.server.RouterGroup.POST("/schedule/preview", h.PreviewScheduleHandler)
+	a.server.RouterGroup.GET("/s/:token", h.PublicStatusHandler)
+
+	templates := a.server.RouterGroup.Group("templates")
+	templates.POST("/", h.CreateTemplateHandler)
+	templates.GET("/", h.ListTemplatesHandler)
+	templates.GET("/:id", h.GetTemplateHandler)
+	templates.PUT("/:id", h.UpdateTemplateHandler)
+	templates.DELETE("/:id", h.DeleteTemplateHandler)
+
+	recipients := a.server.RouterGroup.Group("recipients")
+	recipients.PUT("/:channel/:recipient/snooze", h.SnoozeRecipientHandler)
+	recipients.GET("/:channel/:recipient/snooze", h.GetRecipientSnoozeHandler)
+	recipients.DELETE("/:channel/:recipient/snooze", h.UnsnoozeRecipientHandler)
+
+	ah := handlers.NewAdminHandlersSet(a.service, a.topology)
+	admin := a.server.RouterGroup.Group("admin", middleware.AdminAuthMiddleware(a.config.Admin.APIKey),
+		middleware.ActorMiddleware(domain.ActorAdmin))
+	admin.GET("/topology", ah.CheckTopologyHandler)
+	admin.POST("/topology/sync", ah.SyncTopologyHandler)
+	admin.GET("/notify", ah.ListNotificationsHandler)
+	admin.GET("/notify/:id", ah.GetNotificationHandler)
+	admin.DELETE("/notify/:id", ah.DeleteNotificationHandler)
+	admin.GET("/notify/:id/failures", ah.ListFailuresHandler)
+	admin.POST("/notify/reschedule", ah.RescheduleHandler)
+	admin.GET("/cache/:id", ah.InspectCacheHandler)
+	admin.DELETE("/cache/:id", ah.EvictCacheHandler)
+	admin.DELETE("/cache", ah.FlushCacheHandler)
+	admin.GET("/reports/duplicates", ah.DuplicatesReportHandler)
+	admin.GET("/search", ah.SearchNotificationsHandler)
+	admin.GET("/sla", ah.SLAHandler)
+	admin.GET("/views", ah.ListViewsHandler)
+	admin.PUT("/views/:name", ah.SaveViewHandler)
+	admin.DELETE("/views/:name", ah.DeleteViewHandler)
+	admin.GET("/views/:name/results", ah.RunViewHandler)
+	admin.GET("/schemas", ah.ListSchemasHandler)
+	admin.GET("/schemas/:source/:channel", ah.GetSchemaHandler)
+	admin.PUT("/schemas/:source/:channel", ah.SaveSchemaHandler)
+	admin.DELETE("/schemas/:source/:channel", ah.DeleteSchemaHandler)
+	admin.GET("/maintenance", maintenance.Handler())
+	admin.PUT("/maintenance", maintenance.UpdateHandler())
+	admin.GET("/debug/requests", debugRecorder.Handler())
+	admin.GET("/debug/vars", handlers.VarsHandler)
+	admin.GET("/debug/pprof/*name", handlers.PprofHandler)
+
 	return nil
 }
 
+// highWorkers число воркеров очереди приоритета high.
+func (a *Application) highWorkers() int {
+	if a.config.RabbitMQ.HighWorkers <= 0 {
+		return a.config.RabbitMQ.Workers
+	}
+	return a.config.RabbitMQ.HighWorkers
+}
+
 // startWorkers запускает воркеры для обработки сообщений.
 func (a *Application) startWorkers(ctx context.Context) error {
+	smtpPool := a.config.Email.PoolSize
+	if smtpPool <= 0 {
+		smtpPool = a.config.RabbitMQ.Workers + a.highWorkers()
+	}
 	emailSender, err := emailsender.NewSMTPSender(
 		a.config.Email.Host,
 		a.config.Email.Port,
@@ -449,6 +694,7 @@ func (a *Application) startWorkers(ctx context.Context) error {
 		a.config.Email.Password,
 		a.config.Email.From,
 		a.config.Email.UseTLS,
+		emailsender.WithPoolSize(smtpPool),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to init email sender: %w", err)
@@ -460,17 +706,133 @@ func (a *Application) startWorkers(ctx context.Context) error {
 		Backoff:  float64(a.config.RabbitMQ.ConsumerRetry.Backoff),
 	}
 
-	a.consumer, err = worker.NewConsumer(a.service, a.rabbit, emailSender, retryStrategy)
+	// один набор транспортов на все исходящие HTTP-вызовы: общий пул соединений и кеш DNS
+	transports := egress.NewTransports(egress.Tuning{
+		MaxIdleConnsPerHost:   a.config.Egress.MaxIdleConnsPerHost,
+		IdleConnTimeout:       a.config.Egress.IdleConnTimeout,
+		DialTimeout:           a.config.Egress.DialTimeout,
+		TLSHandshakeTimeout:   a.config.Egress.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: a.config.Egress.ResponseHeaderTimeout,
+		DNSTTL:                a.config.Egress.DNSTTL,
+	})
+	webhookProxy, err := a.egressProxy(a.config.Proxy.Webhook)
+	if err != nil {
+		return fmt.Errorf("invalid webhook proxy: %w", err)
+	}
+	webhookTransport := transports.For(webhookProxy)
+
+	consumerOpts := []worker.ConsumerOption{
+		worker.WithPreSendChecker(precheck.NewHTTPChecker(a.config.PreSend.Timeout, a.config.PreSend.FailOpen,
+			precheck.WithTransport(transports.Guarded()),
+			precheck.WithAllowedHosts(strings.Split(a.config.PreSend.AllowedHosts, ",")))),
+		worker.WithThrottleDelay(a.config.RabbitMQ.ThrottleDelay),
+		worker.WithRateLimiter(worker.NewRateLimiter(map[domain.Channel]float64{
+			domain.ChannelEmail:    a.config.Email.RateLimit,
+			domain.ChannelTelegram: a.config.Telegram.RateLimit,
+			domain.ChannelSMS:      a.config.SMS.RateLimit,
+		}, nil)),
+		worker.WithAdaptivePrefetch(rabbitmq.AdaptivePrefetch{
+			Min:           a.config.RabbitMQ.AdaptivePrefetch.Min,
+			Max:           a.config.RabbitMQ.AdaptivePrefetch.Max,
+			TargetLatency: a.config.RabbitMQ.AdaptivePrefetch.TargetLatency,
+			MaxErrorRate:  a.config.RabbitMQ.AdaptivePrefetch.MaxErrorRate,
+			Interval:      a.config.RabbitMQ.AdaptivePrefetch.Interval,
+		}),
+	}
+
+	telegramProxy, err := a.egressProxy(a.config.Proxy.Telegram)
+	if err != nil {
+		return fmt.Errorf("invalid telegram proxy: %w", err)
+	}
+	if a.config.Telegram.Token != "" {
+		tgSender, err := telegramsender.NewBotSender(a.config.Telegram.Token,
+			telegramsender.WithAPIURL(a.config.Telegram.APIURL),
+			telegramsender.WithTimeout(a.config.Telegram.Timeout),
+			telegramsender.WithChatInterval(a.config.Telegram.ChatInterval),
+			telegramsender.WithTransport(transports.For(telegramProxy)))
+		if err != nil {
+			return fmt.Errorf("failed to init telegram sender: %w", err)
+		}
+		consumerOpts = append(consumerOpts, worker.WithTelegramSender(tgSender))
+	} else {
+		zlog.Logger.Warn().Msg("telegram token is not set, telegram notifications will fail")
+	}
+
+	smsProxy, err := a.egressProxy(a.config.Proxy.SMS)
+	if err != nil {
+		return fmt.Errorf("invalid sms proxy: %w", err)
+	}
+	if a.config.SMS.AccountSID != "" {
+		smsSender, err := a.newSMSSender(transports.For(smsProxy))
+		if err != nil {
+			return fmt.Errorf("failed to init sms sender: %w", err)
+		}
+		consumerOpts = append(consumerOpts, worker.WithSMSSender(smsSender))
+	} else {
+		zlog.Logger.Warn().Msg("sms credentials are not set, sms notifications will fail")
+	}
+
+	a.consumer, err = worker.NewConsumer(a.service, a.rabbit, emailSender, retryStrategy, consumerOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create consumer: %w", err)
 	}
 
-	go a.consumer.Start(ctx, a.config.RabbitMQ.QueueName, 10, 5)
+	go a.consumer.Start(ctx, a.rabbitNames(), a.config.RabbitMQ.Workers, a.config.RabbitMQ.Prefetch)
+	go a.consumer.StartHigh(ctx, a.rabbitNames(), a.highWorkers(), a.config.RabbitMQ.Prefetch)
+
+	purger := worker.NewPurger(a.service, a.config.Purge.Interval, a.config.Purge.Retention)
+	go purger.Start(ctx)
+
+	warmer := worker.NewWarmer(a.service, a.config.Warm.Interval, a.config.Warm.Horizon, a.config.Warm.BatchSize)
+	go warmer.Start(ctx)
+
+	stale := worker.NewStaleDetector(a.service, a.config.Stale.Interval, a.config.Stale.Threshold, worker.StaleAlert{
+		Recipient: a.config.Stale.AlertRecipient,
+		Channel:   domain.Channel(a.config.Stale.AlertChannel),
+		Cooldown:  a.config.Stale.AlertCooldown,
+	})
+	go stale.Start(ctx)
+
+	failureRate := worker.NewFailureRateDetector(a.service, a.config.FailureRate.Interval, worker.FailureRatePolicy{
+		Window:     a.config.FailureRate.Window,
+		Baseline:   a.config.FailureRate.Baseline,
+		Factor:     a.config.FailureRate.Factor,
+		MinRate:    a.config.FailureRate.MinRate,
+		MinSamples: a.config.FailureRate.MinSamples,
+	}, worker.StaleAlert{
+		Recipient: a.config.FailureRate.AlertRecipient,
+		Channel:   domain.Channel(a.config.FailureRate.AlertChannel),
+		Cooldown:  a.config.FailureRate.AlertCooldown,
+	})
+	go failureRate.Start(ctx)
+
+	slaMonitor := worker.NewSLAMonitor(a.service, a.config.SLA.Interval, a.config.SLA.WebhookURL,
+		worker.WithWebhookTransport(webhookTransport))
+	go slaMonitor.Start(ctx)
+
+	reprocessor := worker.NewReprocessor(a.service, a.config.Reprocess.Interval, a.config.Reprocess.MaxCycles,
+		a.config.Reprocess.BatchSize)
+	go reprocessor.Start(ctx)
+
+	reaper := worker.NewReaper(a.service, a.config.Reaper.Interval, worker.ReaperPolicy{
+		Grace:      a.config.Reaper.Grace,
+		MaxAge:     a.config.Reaper.MaxAge,
+		BatchSize:  a.config.Reaper.BatchSize,
+		BatchPause: a.config.Reaper.BatchPause,
+		Budget:     a.config.Reaper.Budget,
+	})
+	go reaper.Start(ctx)
 
 	zlog.Logger.Info().Msg("Workers started successfully")
 	return nil
 }
 
+// egressProxy возвращает исходящий прокси с переопределением override для канала.
+func (a *Application) egressProxy(override string) (egress.Proxy, error) {
+	p := egress.Proxy{URL: a.config.Proxy.URL, NoProxy: a.config.Proxy.NoProxy}.Override(override)
+	return p, p.Validate()
+}
+
 // cleanup освобождает ресурсы.
 func (a *Application) cleanup() {
 	zlog.Logger.Info().Msg("Cleaning up resources...")
@@ -484,4 +846,21 @@ func (a *Application) cleanup() {
 	}
 
 	zlog.Logger.Info().Msg("Cleanup completed")
+
+	if a.logSinks != nil {
+		_ = a.logSinks.Close()
+	}
+}
+
+// newSMSSender создает отправщика SMS настроенного провайдера.
+func (a *Application) newSMSSender(rt http.RoundTripper) (domain.SMSSender, error) {
+	switch a.config.SMS.Provider {
+	case smssender.ProviderTwilio:
+		return smssender.NewTwilioSender(a.config.SMS.AccountSID, a.config.SMS.AuthToken, a.config.SMS.From,
+			smssender.WithAPIURL(a.config.SMS.APIURL),
+			smssender.WithTimeout(a.config.SMS.Timeout),
+			smssender.WithTransport(rt))
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", a.config.SMS.Provider)
+	}
 }
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"DelayedNotifier/pkg/rabbitmq"
+	"DelayedNotifier/pkg/retry"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/redis"
+)
+
+// healthCheckTimeout ограничение времени одной проверки зависимости.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheckResult результат проверки одной зависимости.
+type HealthCheckResult struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport результат команды health.
+type HealthReport struct {
+	OK       bool                `json:"ok"`
+	Attempts int                 `json:"attempts"`
+	Checks   []HealthCheckResult `json:"checks"`
+}
+
+// runHealthCheck проверяет состояние всех подключений.
+// Флаги: --format text|json, --wait <duration> (повторять до успеха или дедлайна),
+// --interval <duration> (пауза между попытками).
+func (a *Application) runHealthCheck() error {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	wait := fs.Duration("wait", 0, "retry until healthy or the deadline expires")
+	interval := fs.Duration("interval", 2*time.Second, "delay between attempts with --wait")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+
+	ctx := context.Background()
+	deadline := time.Now().Add(*wait)
+	var report HealthReport
+	for {
+		report.Attempts++
+		report.Checks = a.healthChecks(ctx)
+		report.OK = true
+		for _, c := range report.Checks {
+			report.OK = report.OK && c.OK
+		}
+		if report.OK || time.Now().Add(*interval).After(deadline) {
+			break
+		}
+		if *format == "text" {
+			fmt.Printf("Attempt %d failed, retrying in %s...\n", report.Attempts, *interval)
+		}
+		time.Sleep(*interval)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printHealthReport(report)
+	}
+
+	if !report.OK {
+		return errors.New("health check failed")
+	}
+	return nil
+}
+
+func printHealthReport(report HealthReport) {
+	fmt.Println("Running health check...")
+	for _, c := range report.Checks {
+		if c.OK {
+			fmt.Printf("✅ %s: OK (%.1f ms)\n", c.Name, c.LatencyMs)
+		} else {
+			fmt.Printf("❌ %s: %s (%.1f ms)\n", c.Name, c.Error, c.LatencyMs)
+		}
+	}
+	if report.OK {
+		fmt.Println("🎉 All health checks passed!")
+	}
+}
+
+// healthChecks выполняет все проверки, замеряя время каждой.
+func (a *Application) healthChecks(ctx context.Context) []HealthCheckResult {
+	checks := []struct {
+		name string
+		fn   func(ctx context.Context) error
+	}{
+		{"database", a.checkDatabase},
+		{"redis", a.checkRedis},
+		{"rabbitmq", a.checkRabbitMQ},
+	}
+	results := make([]HealthCheckResult, 0, len(checks))
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		start := time.Now()
+		err := c.fn(checkCtx)
+		cancel()
+		res := HealthCheckResult{
+			Name:      c.name,
+			OK:        err == nil,
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// checkDatabase проверяет подключение к базе данных.
+func (a *Application) checkDatabase(ctx context.Context) error {
+	opts := &dbpg.Options{
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}
+
+	db, err := dbpg.New(a.config.Database.DSN, nil, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Master.Close()
+	}()
+
+	return db.Master.PingContext(ctx)
+}
+
+// checkRedis проверяет подключение к Redis.
+func (a *Application) checkRedis(ctx context.Context) error {
+	client := redis.New(a.config.Redis.Addr, a.config.Redis.Password, a.config.Redis.DB)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	return client.Ping(ctx).Err()
+}
+
+// checkRabbitMQ проверяет подключение к RabbitMQ.
+func (a *Application) checkRabbitMQ(_ context.Context) error {
+	cfg := a.config.RabbitMQ
+	clientConfig := rabbitmq.ClientConfig{
+		URL:            cfg.URL,
+		ConnectionName: cfg.ConnectionName + "-health",
+		ConnectTimeout: healthCheckTimeout,
+		Heartbeat:      5 * time.Second,
+		PublishRetry: retry.Strategy{
+			Attempts: cfg.PublishRetry.Attempts,
+			Delay:    cfg.PublishRetry.Delay,
+			Backoff:  float64(cfg.PublishRetry.Backoff),
+		},
+	}
+
+	client, err := rabbitmq.NewClient(clientConfig)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Простая проверка - попытка подключения
+	return client.Ping()
+}
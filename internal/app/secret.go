@@ -0,0 +1,48 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	cfgman "DelayedNotifier/internal/config"
+)
+
+// runSecret шифрует значения для конфигурации.
+// Действия: keygen (новый ключ), encrypt (значение из stdin ключом DELAYED_NOTIFIER_CONFIG_KEY).
+func (a *Application) runSecret() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("secret command requires action (keygen/encrypt)")
+	}
+
+	switch action := os.Args[2]; action {
+	case "keygen":
+		key, err := cfgman.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		fmt.Println(key)
+		return nil
+	case "encrypt":
+		key, err := cfgman.LoadKey()
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return cfgman.ErrNoConfigKey
+		}
+		plaintext, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && plaintext == "" {
+			return fmt.Errorf("failed to read value from stdin: %w", err)
+		}
+		value, err := cfgman.EncryptValue(key, strings.TrimRight(plaintext, "\r\n"))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt value: %w", err)
+		}
+		fmt.Println(value)
+		return nil
+	default:
+		return fmt.Errorf("unknown secret action: %s (use keygen/encrypt)", action)
+	}
+}
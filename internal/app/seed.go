@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/repository/pg"
+	"DelayedNotifier/pkg/idgen"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// seedSpread интервал вокруг текущего времени, в котором раскладываются демо-уведомления.
+const seedSpread = 7 * 24 * time.Hour
+
+// runSeed заполняет базу демонстрационными уведомлениями.
+// Флаги: --count <n> (количество уведомлений).
+func (a *Application) runSeed() error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	count := fs.Int("count", 100, "number of notifications to insert")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *count <= 0 {
+		return fmt.Errorf("count must be positive, got %d", *count)
+	}
+
+	db, err := initDatabase(a.config.Database)
+	if err != nil {
+		return fmt.Errorf("failed to init database: %w", err)
+	}
+	defer func(Master *sql.DB) {
+		_ = Master.Close()
+	}(db.Master)
+
+	newID, err := idgen.New(a.config.IDs.Generator)
+	if err != nil {
+		return fmt.Errorf("failed to init id generator: %w", err)
+	}
+	repo := pg.NewPostgresRepo(db, pg.WithIDGenerator(newID))
+	ctx := context.Background()
+	now := time.Now().UTC()
+	byStatus := make(map[domain.Status]int)
+
+	for i := 0; i < *count; i++ {
+		params, status, retries := seedNotification(i, now)
+		n, err := repo.Create(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to insert notification %d: %w", i, err)
+		}
+		if status != domain.StatusPending {
+			if err := repo.Update(ctx, n.ID, domain.WithStatus(status)); err != nil {
+				return fmt.Errorf("failed to update notification %s: %w", n.ID, err)
+			}
+		}
+		for r := 0; r < retries; r++ {
+			if err := repo.IncRetryCount(ctx, n.ID); err != nil {
+				return fmt.Errorf("failed to update notification %s: %w", n.ID, err)
+			}
+		}
+		byStatus[status]++
+	}
+
+	zlog.Logger.Info().Int("count", *count).Interface("by_status", byStatus).Msg("Seed data inserted")
+	fmt.Printf("🌱 Inserted %d notifications: %v\n", *count, byStatus)
+	return nil
+}
+
+// seedSources демонстрационные системы-источники для метрик по source.
+var seedSources = []string{"billing", "auth", "marketing"}
+
+// seedNotification строит i-е демо-уведомление: каналы чередуются, время
+// раскладывается в пределах ±seedSpread, прошедшие получают конечные статусы.
+func seedNotification(i int, now time.Time) (domain.CreateParams, domain.Status, int) {
+	scheduledAt := now.Add(time.Duration(rand.Int64N(int64(2*seedSpread))) - seedSpread).Truncate(time.Second)
+
+	params := domain.CreateParams{
+		Status:      domain.StatusPending,
+		ScheduledAt: scheduledAt,
+		Source:      seedSources[i%len(seedSources)],
+	}
+	if i%3 == 2 {
+		params.Channel = domain.ChannelTelegram
+		params.Recipient = fmt.Sprintf("%d", 100000000+i)
+		params.Payload = map[string]interface{}{
+			"body": fmt.Sprintf("Демо-сообщение #%d", i+1),
+		}
+	} else {
+		params.Channel = domain.ChannelEmail
+		params.Recipient = fmt.Sprintf("demo+%d@example.com", i+1)
+		params.Payload = map[string]interface{}{
+			"subject": fmt.Sprintf("Демо-уведомление #%d", i+1),
+			"body":    "Это тестовое уведомление, созданное командой seed.",
+		}
+	}
+
+	if scheduledAt.After(now) {
+		if i%10 == 0 {
+			return params, domain.StatusCancelled, 0
+		}
+		return params, domain.StatusPending, 0
+	}
+	switch {
+	case i%7 == 0:
+		return params, domain.StatusFailed, 3
+	case i%5 == 0:
+		return params, domain.StatusSent, 1
+	default:
+		return params, domain.StatusSent, 0
+	}
+}
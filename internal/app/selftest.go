@@ -0,0 +1,195 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// selfTestPollInterval пауза между запросами статуса синтетического уведомления.
+const selfTestPollInterval = 2 * time.Second
+
+// selfTestSource источник синтетических уведомлений, по нему их видно в метриках.
+const selfTestSource = "selftest"
+
+// selfTestOutcome конечные статусы синтетического уведомления: true — успех.
+// Статусов, которых здесь нет, уведомление еще не покинуло.
+var selfTestOutcome = map[domain.Status]bool{
+	domain.StatusSent:       true,
+	domain.StatusDelivered:  true,
+	domain.StatusRead:       true,
+	domain.StatusFailed:     false,
+	domain.StatusBounced:    false,
+	domain.StatusSuppressed: false,
+	domain.StatusExpired:    false,
+	domain.StatusCancelled:  false,
+}
+
+// runSelfTest проверяет развернутый сервис через его API: по каждому каналу с настроенным
+// приемником создает уведомление и ждет конечного статуса. Ненулевой код выхода означает провал.
+// Флаги: --dry-run (черновик создается, читается и отменяется, ничего не отправляется),
+// --timeout <duration> (ожидание по всем каналам).
+func (a *Application) runSelfTest() error {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "create and cancel a draft instead of sending")
+	timeout := fs.Duration("timeout", a.config.SelfTest.Timeout, "how long to wait for terminal statuses")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	baseURL := a.config.SelfTest.URL
+	if baseURL == "" {
+		baseURL = "http://" + a.config.HTTP.GetConnectionString()
+	}
+	sinks := []struct {
+		channel   domain.Channel
+		recipient string
+	}{
+		{domain.ChannelEmail, a.config.SelfTest.EmailSink},
+		{domain.ChannelTelegram, a.config.SelfTest.TelegramSink},
+		{domain.ChannelSMS, a.config.SelfTest.SMSSink},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	client := &http.Client{Timeout: 10 * time.Second}
+	ran, ok := 0, true
+	for _, s := range sinks {
+		if s.recipient == "" {
+			fmt.Printf("⏭️  %s: no sink configured, skipped\n", s.channel)
+			continue
+		}
+		ran++
+		status, err := selfTestChannel(ctx, client, baseURL, s.channel, s.recipient, *dryRun)
+		if err != nil {
+			ok = false
+			fmt.Printf("❌ %s: %v\n", s.channel, err)
+			continue
+		}
+		fmt.Printf("✅ %s: %s\n", s.channel, status)
+	}
+
+	if ran == 0 {
+		return errors.New("no sink configured: set DELAYED_NOTIFIER_SELFTEST_EMAILSINK or _TELEGRAMSINK")
+	}
+	if !ok {
+		return errors.New("selftest failed")
+	}
+	return nil
+}
+
+// selfTestChannel создает синтетическое уведомление в канале и возвращает его конечный статус.
+func selfTestChannel(ctx context.Context, client *http.Client, baseURL string, ch domain.Channel,
+	recipient string, dryRun bool) (domain.Status, error) {
+	payload, _ := json.Marshal(map[string]string{
+		"subject": "DelayedNotifier selftest",
+		"body":    "Synthetic notification sent after deploy, no action needed.",
+	})
+	created, err := selfTestCall(ctx, client, http.MethodPost, baseURL+"/notify/", map[string]any{
+		"recipient":    recipient,
+		"channel":      ch,
+		"source":       selfTestSource,
+		"payload":      string(payload),
+		"scheduled_at": time.Now().UTC().Format(time.RFC3339),
+		"immediate":    true,
+		"draft":        dryRun,
+	})
+	if err != nil {
+		return "", fmt.Errorf("create: %w", err)
+	}
+	notifyURL := baseURL + "/notify/" + created.ID
+
+	if dryRun {
+		got, err := selfTestCall(ctx, client, http.MethodGet, notifyURL, nil)
+		if err != nil {
+			return "", fmt.Errorf("read draft %s: %w", created.ID, err)
+		}
+		if _, err := selfTestCall(ctx, client, http.MethodDelete, notifyURL, nil); err != nil {
+			return got.Status, fmt.Errorf("cancel draft %s: %w", created.ID, err)
+		}
+		return got.Status, nil
+	}
+
+	status := created.Status
+	for {
+		if success, done := selfTestOutcome[status]; done {
+			if !success {
+				return status, fmt.Errorf("notification %s ended with status %s", created.ID, status)
+			}
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return status, fmt.Errorf("notification %s still %s after timeout", created.ID, status)
+		case <-time.After(selfTestPollInterval):
+		}
+		got, err := selfTestCall(ctx, client, http.MethodGet, notifyURL, nil)
+		if err != nil {
+			return status, fmt.Errorf("read %s: %w", created.ID, err)
+		}
+		status = got.Status
+	}
+}
+
+// selfTestNotification поля ответа API, нужные selftest. Создание возвращает
+// уведомление без json-тегов (ID, Status), поэтому используется нечувствительность
+// encoding/json к регистру.
+type selfTestNotification struct {
+	ID     string        `json:"id"`
+	Status domain.Status `json:"status"`
+}
+
+// selfTestCall выполняет запрос к API и разбирает {"result": ...}.
+func selfTestCall(ctx context.Context, client *http.Client, method, url string,
+	body any) (selfTestNotification, error) {
+	var res selfTestNotification
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return res, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return res, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// статус читается из базы, а не из кеша
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return res, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return res, fmt.Errorf("%s %s: %d %s", method, url, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if method == http.MethodDelete {
+		return res, nil
+	}
+	var envelope struct {
+		Result selfTestNotification `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return res, fmt.Errorf("decode response: %w", err)
+	}
+	return envelope.Result, nil
+}
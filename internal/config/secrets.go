@@ -0,0 +1,147 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// EncryptedPrefix префикс зашифрованного значения в конфигурации: enc:<base64(nonce|шифртекст)>.
+const EncryptedPrefix = "enc:"
+
+// Переменные окружения с ключом расшифровки. Ключ — 32 байта в base64 (AES-256-GCM),
+// файл удобен, когда ключ монтирует KMS или менеджер секретов.
+const (
+	KeyEnv     = "DELAYED_NOTIFIER_CONFIG_KEY"
+	KeyFileEnv = "DELAYED_NOTIFIER_CONFIG_KEYFILE"
+)
+
+// ErrNoConfigKey в конфигурации есть зашифрованные значения, но ключ не задан.
+var ErrNoConfigKey = errors.New("config key is not set: " + KeyEnv + " or " + KeyFileEnv)
+
+// ErrInvalidConfigKey ключ не является 32 байтами в base64.
+var ErrInvalidConfigKey = errors.New("config key must be 32 bytes encoded in base64")
+
+// LoadKey читает ключ расшифровки из DELAYED_NOTIFIER_CONFIG_KEY или файла
+// DELAYED_NOTIFIER_CONFIG_KEYFILE. Без ключа возвращает nil без ошибки.
+func LoadKey() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if encoded == "" {
+		path := os.Getenv(KeyFileEnv)
+		if path == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config key file: %w", err)
+		}
+		encoded = string(data)
+	}
+	return ParseKey(encoded)
+}
+
+// ParseKey разбирает ключ из base64.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidConfigKey
+	}
+	return key, nil
+}
+
+// GenerateKey создает новый случайный ключ в base64.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue шифрует значение для конфигурации, результат начинается с enc:.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue расшифровывает значение с префиксом enc:, остальные возвращает как есть.
+func DecryptValue(key []byte, value string) (string, error) {
+	if !strings.HasPrefix(value, EncryptedPrefix) {
+		return value, nil
+	}
+	if key == nil {
+		return "", ErrNoConfigKey
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt value: wrong key or corrupted data")
+	}
+	return string(plaintext), nil
+}
+
+// DecryptSecrets заменяет все строковые поля конфигурации с префиксом enc: расшифрованными значениями.
+// В ошибке указывается ключ конфигурации, но не само значение.
+func DecryptSecrets(cfg *Config, key []byte) error {
+	return decryptFields(reflect.ValueOf(cfg).Elem(), "", key)
+}
+
+func decryptFields(v reflect.Value, path string, key []byte) error {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		field := v.Field(i)
+		name := t.Field(i).Tag.Get("config")
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		switch field.Kind() {
+		case reflect.Struct:
+			if err := decryptFields(field, name, key); err != nil {
+				return err
+			}
+		case reflect.String:
+			plain, err := DecryptValue(key, field.String())
+			if err != nil {
+				return fmt.Errorf("config %s: %w", name, err)
+			}
+			field.SetString(plain)
+		}
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrInvalidConfigKey
+	}
+	return cipher.NewGCM(block)
+}
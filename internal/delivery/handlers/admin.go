@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// AdminHandler обработчики административного API.
+type AdminHandler struct {
+	service  domain.NotificationService
+	topology domain.TopologyManager
+}
+
+// NewAdminHandlersSet создает набор административных обработчиков.
+func NewAdminHandlersSet(service domain.NotificationService, topology domain.TopologyManager) *AdminHandler {
+	return &AdminHandler{
+		service:  service,
+		topology: topology,
+	}
+}
+
+// GetNotificationHandler получает уведомление, с ?include_deleted=true
+// включая мягко удаленные.
+func (h *AdminHandler) GetNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	var n *domain.Notification
+	if c.Query("include_deleted") == "true" {
+		n, err = h.service.GetNotificationByIDWithDeleted(c.Request.Context(), id)
+	} else {
+		n, err = h.service.GetNotificationByID(c.Request.Context(), id)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationResponse(n)})
+}
+
+// ListNotificationsHandler список уведомлений с теми же фильтрами, что GET /notify;
+// с ?include_deleted=true в него попадают и мягко удаленные.
+func (h *AdminHandler) ListNotificationsHandler(c *gin.Context) {
+	listNotifications(c, h.service, c.Query("include_deleted") == "true")
+}
+
+// DeleteNotificationHandler удаляет уведомление: по умолчанию мягко (deleted_at),
+// с ?hard=true физически. В отличие от DELETE /notify/:id статус не меняется.
+func (h *AdminHandler) DeleteNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	hard := c.Query("hard") == "true"
+	if hard {
+		err = h.service.Delete(c.Request.Context(), id)
+	} else {
+		err = h.service.SoftDelete(c.Request.Context(), id)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": id.String() + " deleted"})
+}
+
+// ListFailuresHandler возвращает записи журнала неуспешных для уведомления, исчерпавшего
+// предел попыток: последняя ошибка, число попыток, время.
+func (h *AdminHandler) ListFailuresHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	failures, err := h.service.ListFailures(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := make([]NotificationFailureResponse, 0, len(failures))
+	for i := range failures {
+		resp = append(resp, toNotificationFailureResponse(&failures[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// Ограничения очистки кэша по шаблону.
+const (
+	defaultCacheFlushLimit = 1000
+	maxCacheFlushLimit     = 10000
+)
+
+// InspectCacheHandler показывает запись кэша уведомления как она лежит в Redis, с TTL.
+func (h *AdminHandler) InspectCacheHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	entry, err := h.service.InspectCache(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrCacheMiss) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toCacheEntryResponse(entry)})
+}
+
+// EvictCacheHandler удаляет запись кэша уведомления, следующее чтение возьмет его из базы.
+func (h *AdminHandler) EvictCacheHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	if err := h.service.EvictCache(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": id.String() + " evicted"})
+}
+
+// FlushCacheHandler удаляет ключи кэша по ?pattern= (например, notification:*). Без ?confirm=true
+// только показывает, что было бы удалено. ?limit= ограничивает число ключей за вызов
+// (по умолчанию 1000, не больше 10000); truncated=true значит, что вызов нужно повторить.
+func (h *AdminHandler) FlushCacheHandler(c *gin.Context) {
+	pattern := c.Query("pattern")
+	limit := defaultCacheFlushLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxCacheFlushLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Limit": "целое число от 1 до 10000"},
+			})
+			return
+		}
+		limit = n
+	}
+	dryRun := c.Query("confirm") != "true"
+
+	res, err := h.service.FlushCache(c.Request.Context(), pattern, limit, dryRun)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCachePattern) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Pattern": "шаблон должен начинаться с notification: или snooze:"},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toCacheFlushResponse(pattern, dryRun, res)})
+}
+
+// defaultRescheduleLimit сколько уведомлений переносит один вызов, если limit не задан.
+const defaultRescheduleLimit = 1000
+
+// RescheduleHandler переносит ожидающие уведомления по фильтру на новое время или на сдвиг
+// и заново публикует их. truncated=true значит, что вызов нужно повторить для оставшихся.
+func (h *AdminHandler) RescheduleHandler(c *gin.Context) {
+	var req RescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	params := domain.RescheduleParams{
+		Filter: domain.ListFilter{
+			Channel:   domain.Channel(req.Filter.Channel),
+			Recipient: req.Filter.Recipient,
+		},
+		Limit: req.Limit,
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultRescheduleLimit
+	}
+	// форматы времени уже проверены валидатором
+	if req.Filter.ScheduledFrom != "" {
+		params.Filter.ScheduledFrom, _ = time.Parse(time.RFC3339, req.Filter.ScheduledFrom)
+	}
+	if req.Filter.ScheduledTo != "" {
+		params.Filter.ScheduledTo, _ = time.Parse(time.RFC3339, req.Filter.ScheduledTo)
+	}
+	if req.ScheduledAt != "" {
+		at, _ := time.Parse(time.RFC3339, req.ScheduledAt)
+		params.At = &at
+	}
+	if req.Shift != "" {
+		shift, err := time.ParseDuration(req.Shift)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Shift": "длительность в формате Go, например +2h или -30m"},
+			})
+			return
+		}
+		params.Shift = shift
+	}
+
+	res, err := h.service.RescheduleNotifications(c.Request.Context(), params)
+	if err != nil {
+		field, msg, ok := createValidationError(err)
+		switch {
+		case errors.Is(err, domain.ErrInvalidReschedule):
+			field, msg, ok = "ScheduledAt", "укажите ровно одно из scheduled_at и shift", true
+		case errors.Is(err, domain.ErrEmptyRescheduleFilter):
+			field, msg, ok = "Filter", "нужен хотя бы один фильтр", true
+		}
+		if ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": RescheduleResponse{
+		Rescheduled: res.Rescheduled,
+		Republished: res.Republished,
+		Truncated:   res.Truncated,
+		IDs:         res.IDs,
+	}})
+}
+
+// Ограничения отчета о повторных доставках.
+const (
+	defaultDuplicateWindow = 24 * time.Hour
+	maxDuplicateWindow     = 7 * 24 * time.Hour
+	defaultDuplicateLimit  = 100
+	maxDuplicateLimit      = 1000
+)
+
+// DuplicatesReportHandler отчет о вероятных повторных доставках: группы уведомлений
+// одному получателю с одинаковым payload, отправленных больше одного раза за ?window=
+// (по умолчанию 24h, не больше 7 суток). ?limit= ограничивает число групп.
+func (h *AdminHandler) DuplicatesReportHandler(c *gin.Context) {
+	window := defaultDuplicateWindow
+	if raw := c.Query("window"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > maxDuplicateWindow {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Window": "положительная длительность не больше 168h, например 1h"},
+			})
+			return
+		}
+		window = d
+	}
+	limit := defaultDuplicateLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxDuplicateLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Limit": "целое число от 1 до 1000"},
+			})
+			return
+		}
+		limit = n
+	}
+
+	groups, err := h.service.DuplicateReport(c.Request.Context(), window, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := DuplicateReportResponse{Window: window.String(), Groups: make([]DuplicateGroupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, toDuplicateGroupResponse(g))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// maxSearchLimit наибольшее число результатов поиска за запрос.
+const maxSearchLimit = 100
+
+// SearchNotificationsHandler ищет уведомления по тексту: ?q= слова из получателя, темы
+// и текстовых полей payload (или фрагмент адреса) и фильтры ?channel=, ?status=,
+// ?last= (созданные за последние, например 24h), ?limit=. Нужен q или хотя бы один фильтр.
+func (h *AdminHandler) SearchNotificationsHandler(c *gin.Context) {
+	filter, errs := parseViewFilter(c.Query("q"), c.Query("channel"), c.Query("status"),
+		c.Query("last"), c.Query("limit"))
+	if errs != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Ошибка валидации", "errors": errs})
+		return
+	}
+	hits, err := h.service.SearchNotifications(c.Request.Context(), filter.Params(time.Now()))
+	h.writeSearchHits(c, hits, err)
+}
+
+// writeSearchHits отвечает результатами поиска или ошибкой.
+func (h *AdminHandler) writeSearchHits(c *gin.Context, hits []domain.SearchHit, err error) {
+	if err != nil {
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrViewNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := make([]SearchHitResponse, 0, len(hits))
+	for i := range hits {
+		resp = append(resp, SearchHitResponse{
+			NotificationResponse: toNotificationResponse(&hits[i].Notification),
+			Rank:                 hits[i].Rank,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// parseViewFilter разбирает параметры поиска из строк запроса или тела сохраненного представления.
+// Канал и статус проверяет сервис; здесь только формат last и limit.
+func parseViewFilter(q, channel, status, last, limit string) (domain.ViewFilter, map[string]string) {
+	f := domain.ViewFilter{Query: q, Channel: domain.Channel(channel), Status: domain.Status(status)}
+	errs := map[string]string{}
+	if last != "" {
+		d, err := time.ParseDuration(last)
+		if err != nil || d <= 0 {
+			errs["Last"] = "положительная длительность, например 24h"
+		}
+		f.Last = d
+	}
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			errs["Limit"] = "целое число от 1 до 100"
+		}
+		f.Limit = n
+	}
+	if len(errs) > 0 {
+		return f, errs
+	}
+	return f, nil
+}
+
+// SaveViewHandler сохраняет набор фильтров поиска под именем :name, заменяя прежний.
+func (h *AdminHandler) SaveViewHandler(c *gin.Context) {
+	var req SaveViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	limit := ""
+	if req.Limit != 0 {
+		limit = strconv.Itoa(req.Limit)
+	}
+	filter, errs := parseViewFilter(req.Q, req.Channel, req.Status, req.Last, limit)
+	if errs != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Ошибка валидации", "errors": errs})
+		return
+	}
+
+	v, err := h.service.SaveView(c.Request.Context(), c.Param("name"), filter)
+	if err != nil {
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toSavedViewResponse(v)})
+}
+
+// ListViewsHandler возвращает сохраненные представления со ссылками на поиск.
+func (h *AdminHandler) ListViewsHandler(c *gin.Context) {
+	views, err := h.service.ListViews(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := make([]SavedViewResponse, 0, len(views))
+	for i := range views {
+		resp = append(resp, toSavedViewResponse(&views[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// DeleteViewHandler удаляет сохраненное представление.
+func (h *AdminHandler) DeleteViewHandler(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.service.DeleteView(c.Request.Context(), name); err != nil {
+		if errors.Is(err, domain.ErrViewNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": name + " deleted"})
+}
+
+// RunViewHandler выполняет поиск по сохраненному представлению.
+func (h *AdminHandler) RunViewHandler(c *gin.Context) {
+	hits, err := h.service.RunView(c.Request.Context(), c.Param("name"))
+	h.writeSearchHits(c, hits, err)
+}
+
+// SLAHandler возвращает соблюдение настроенных целей SLA за окно, заканчивающееся сейчас.
+func (h *AdminHandler) SLAHandler(c *gin.Context) {
+	reports, err := h.service.SLACompliance(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := make([]SLAReportResponse, 0, len(reports))
+	for _, r := range reports {
+		resp = append(resp, toSLAReportResponse(r))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// SyncTopologyHandler идемпотентно объявляет exchange, очереди и DLX-привязки.
+func (h *AdminHandler) SyncTopologyHandler(c *gin.Context) {
+	report, err := h.topology.Sync(c.Request.Context())
+	writeTopologyReport(c, report, err)
+}
+
+// CheckTopologyHandler проверяет топологию брокера, ничего не создавая.
+func (h *AdminHandler) CheckTopologyHandler(c *gin.Context) {
+	report, err := h.topology.Check(c.Request.Context())
+	writeTopologyReport(c, report, err)
+}
+
+func writeTopologyReport(c *gin.Context, report domain.TopologyReport, err error) {
+	resp := TopologyResponse{OK: report.OK, Items: make([]TopologyItemResponse, 0, len(report.Items))}
+	for _, item := range report.Items {
+		resp.Items = append(resp.Items, TopologyItemResponse(item))
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"result": resp})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"strings"
+
+	_ "DelayedNotifier/internal/metrics" // регистрирует метрики приложения в expvar
+	"github.com/gin-gonic/gin"
+)
+
+// VarsHandler отдает метрики expvar: время работы, статистику GC и
+// количество обрабатываемых доставок.
+func VarsHandler(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+// PprofHandler отдает профили net/http/pprof, маршрут должен заканчиваться на /*name.
+func PprofHandler(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("name"), "/") {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(strings.TrimPrefix(c.Param("name"), "/")).ServeHTTP(c.Writer, c.Request)
+	}
+}
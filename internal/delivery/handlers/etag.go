@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// notificationETag слабый ETag уведомления: меняется вместе с updated_at.
+func notificationETag(n *domain.Notification) string {
+	return `W/"` + strconv.FormatInt(n.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+// groupETag слабый ETag группы по составу и updated_at ее уведомлений.
+func groupETag(g *domain.GroupStatus) string {
+	h := sha256.New()
+	var buf [8]byte
+	for _, n := range g.Notifications {
+		h.Write(n.ID[:])
+		binary.BigEndian.PutUint64(buf[:], uint64(n.UpdatedAt.UnixNano()))
+		h.Write(buf[:])
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// notModified выставляет ETag и, если он совпал с If-None-Match, отвечает 304 без тела.
+// Возвращает true, когда ответ уже отправлен.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// preconditionContext переносит If-Match запроса в контекст сервиса: изменение выполняется,
+// только если уведомление все еще имеет один из перечисленных ETag. Без заголовка при
+// обязательном If-Match отвечает 428; false — ответ уже отправлен.
+func (h *Handler) preconditionContext(c *gin.Context) (context.Context, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		if h.requireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+			return nil, false
+		}
+		return c.Request.Context(), true
+	}
+	return domain.WithPrecondition(c.Request.Context(), func(n *domain.Notification) bool {
+		return etagMatches(ifMatch, notificationETag(n))
+	}), true
+}
+
+// etagMatches сравнивает If-None-Match или If-Match со значением ETag слабым сравнением (RFC 9110, 13.1.2):
+// ETag уведомления всегда слабый, поэтому и для If-Match сравнение слабое.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// createGroup создает уведомление для нескольких получателей (POST /notify с recipients).
+// Ошибки по отдельным получателям возвращаются в элементах ответа, как в POST /notify/batch.
+func (h *Handler) createGroup(c *gin.Context, params domain.CreateNotificationParams, recipients []string) {
+	res, err := h.service.CreateGroup(c.Request.Context(), params, recipients)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrBatchTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrEmptyRecipient):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Recipients": "обязательное поле"},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	items := make([]BatchItemResponse, len(res.Results))
+	for i, r := range res.Results {
+		items[i] = BatchItemResponse{Index: i, Recipient: res.Recipients[i]}
+		if r.Err == nil {
+			n := toNotificationResponse(r.Notification)
+			items[i].Notification = &n
+		} else if field, msg, ok := createValidationError(r.Err); ok {
+			items[i].Errors = map[string]string{field: msg}
+		} else {
+			items[i].Error = r.Err.Error()
+		}
+	}
+	batch := toBatchCreateResponse(items)
+	c.JSON(http.StatusOK, gin.H{"result": GroupCreateResponse{
+		GroupID: res.GroupID,
+		Items:   batch.Items,
+		Created: batch.Created,
+		Failed:  batch.Failed,
+	}})
+}
+
+// GroupStatusHandler возвращает сводное состояние уведомлений группы (GET /notify/group/:group_id).
+func (h *Handler) GroupStatusHandler(c *gin.Context) {
+	groupID, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_id is invalid"})
+		return
+	}
+	g, err := h.service.GroupStatus(c.Request.Context(), groupID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if notModified(c, groupETag(g)) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toGroupStatusResponse(g)})
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
+	"io"
 	"net/http"
+	"reflect"
+	"strings"
 	"time"
 
 	"DelayedNotifier/internal/domain"
@@ -15,24 +20,160 @@ import (
 
 type Handler struct {
 	service domain.NotificationService
+	// publicBaseURL префикс публичных ссылок (страница статуса), пустой — относительные ссылки
+	publicBaseURL string
+	// requireIfMatch отклонять изменение и отмену уведомления без If-Match
+	requireIfMatch bool
 }
 
-func NewHandlersSet(service domain.NotificationService) *Handler {
-	return &Handler{
+// HandlerOption функция настройки Handler.
+type HandlerOption func(*Handler)
+
+// WithPublicBaseURL задает внешний адрес сервиса для публичных ссылок, например https://notify.example.com.
+func WithPublicBaseURL(u string) HandlerOption {
+	return func(h *Handler) {
+		h.publicBaseURL = strings.TrimRight(u, "/")
+	}
+}
+
+// WithRequireIfMatch требует заголовок If-Match в PATCH /notify/:id и DELETE /notify/:id.
+func WithRequireIfMatch(require bool) HandlerOption {
+	return func(h *Handler) {
+		h.requireIfMatch = require
+	}
+}
+
+func NewHandlersSet(service domain.NotificationService, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		service: service,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 type CreateRequest struct {
-	Recipient   string `json:"recipient" validate:"required"`
-	Channel     string `json:"channel" validate:"required"`
-	Payload     string `json:"payload" validate:"required,jsonstr"`
-	ScheduledAt string `json:"scheduled_at" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	Recipient string `json:"recipient" validate:"required_without=Recipients"`
+	// Recipients несколько получателей вместо Recipient: на каждого создается отдельное
+	// уведомление, все они связаны общим group_id
+	Recipients  []string `json:"recipients" validate:"omitempty,excluded_with=Recipient,dive,required"`
+	Channel     string   `json:"channel" validate:"required"`
+	Payload     string   `json:"payload" validate:"required_without=TemplateID,omitempty,jsonstr"`
+	ScheduledAt string   `json:"scheduled_at" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	// Source имя сервиса-источника, по нему строятся метрики и статистика
+	Source string `json:"source" validate:"required,max=64"`
+	// Immediate разрешает scheduled_at в прошлом: уведомление отправляется сразу
+	Immediate bool `json:"immediate"`
+	// ParentID исходное уведомление (повтор, эскалация), необязательно
+	ParentID string `json:"parent_id" validate:"omitempty,uuid"`
+	// CancelOnConfirm отменить уведомление, если до scheduled_at придет PUT /notify/:id/confirm
+	CancelOnConfirm bool `json:"cancel_on_confirm"`
+	// PreSendCheck URL, который вызывается перед отправкой; ответ {"send": false} отменяет отправку
+	PreSendCheck string `json:"pre_send_check" validate:"omitempty,http_url"`
+	// Smooth разрешает сдвинуть отправку внутри окна сглаживания (массовые рассылки)
+	Smooth bool `json:"smooth"`
+	// RequiresApproval не отправлять до POST /notify/:id/approve
+	RequiresApproval bool `json:"requires_approval"`
+	// Priority high, normal (по умолчанию) или low; при перегрузке low отклоняется первым
+	Priority string `json:"priority" validate:"omitempty,oneof=high normal low"`
+	// Draft создать черновик, который отправится только после POST /notify/:id/schedule
+	Draft bool `json:"draft"`
+	// IdempotencyKey ключ идемпотентности, то же, что заголовок Idempotency-Key: повтор запроса
+	// с тем же ключом и source возвращает уже созданное уведомление
+	IdempotencyKey string `json:"idempotency_key" validate:"max=255"`
+	// TemplateID шаблон, по которому subject и body собираются в момент отправки; с ним payload
+	// необязателен и дополняется Variables
+	TemplateID string `json:"template_id" validate:"omitempty,uuid"`
+	// Variables значения подстановок шаблона, сохраняются в payload уведомления
+	Variables map[string]interface{} `json:"variables" validate:"excluded_without=TemplateID"`
 }
 
+// BatchCreateRequest тело POST /notify/batch, каждый элемент проверяется отдельно.
+type BatchCreateRequest struct {
+	Notifications []CreateRequest `json:"notifications" validate:"required,min=1"`
+}
+
+// DraftUpdateRequest тело PATCH /notify/:id, отсутствующие поля не меняются.
+type DraftUpdateRequest struct {
+	Recipient   *string `json:"recipient"`
+	Channel     *string `json:"channel" validate:"omitempty,oneof=email telegram sms"`
+	Payload     *string `json:"payload" validate:"omitempty,jsonstr"`
+	ScheduledAt *string `json:"scheduled_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// ScheduleRequest тело POST /notify/:id/schedule, может отсутствовать.
+type ScheduleRequest struct {
+	// ScheduledAt новое время отправки, пустое — время из черновика
+	ScheduledAt string `json:"scheduled_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Immediate   bool   `json:"immediate"`
+	Smooth      bool   `json:"smooth"`
+}
+
+// ListRequest параметры запроса GET /notify.
+type ListRequest struct {
+	Status    string `form:"status"`
+	Channel   string `form:"channel"`
+	Recipient string `form:"recipient" validate:"max=320"`
+	// ScheduledFrom и ScheduledTo границы scheduled_at [from, to)
+	ScheduledFrom string `form:"scheduled_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ScheduledTo   string `form:"scheduled_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Limit         int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset        int    `form:"offset" validate:"min=0"`
+}
+
+// SchedulePreviewRequest тело POST /schedule/preview.
+type SchedulePreviewRequest struct {
+	// ScheduledAt RFC3339 или местное время без смещения (2006-01-02T15:04:05) в поясе Timezone
+	ScheduledAt string `json:"scheduled_at" validate:"required"`
+	// Timezone часовой пояс IANA, в котором возвращаются времена
+	Timezone  string `json:"timezone" validate:"omitempty,timezone"`
+	Immediate bool   `json:"immediate"`
+	Smooth    bool   `json:"smooth"`
+	// Channel и Recipient необязательны: с ними учитывается пауза получателя
+	Channel   string `json:"channel" validate:"omitempty,oneof=email telegram sms"`
+	Recipient string `json:"recipient" validate:"max=320"`
+}
+
+// localDateTime формат scheduled_at без смещения, трактуется в поясе timezone.
+const localDateTime = "2006-01-02T15:04:05"
+
 var validate = validator.New()
 var ErrResponceMessage = gin.H{"error": ""}
 
+// overloadRetryAfter значение Retry-After (секунды) для отклоненных из-за перегрузки созданий.
+const overloadRetryAfter = "5"
+
+// idempotencyKeyHeader заголовок с ключом идемпотентности POST /notify.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// ApproveRequest тело POST /notify/:id/approve.
+type ApproveRequest struct {
+	// Approver кто одобряет отправку, сохраняется в уведомлении
+	Approver string `json:"approver" validate:"required,max=128"`
+}
+
+// ReceiptRequest тело POST /notify/:id/receipt.
+type ReceiptRequest struct {
+	// Status о чем сообщает квитанция канала
+	Status string `json:"status" validate:"required,oneof=delivered read bounced"`
+	// EventID идентификатор события у провайдера: повторная доставка того же события игнорируется
+	EventID string `json:"event_id" validate:"omitempty,max=256"`
+}
+
+// SnoozeRequest тело PUT /recipients/:channel/:recipient/snooze.
+type SnoozeRequest struct {
+	// Duration длительность паузы в формате Go (30m, 2h)
+	Duration string `json:"duration"`
+}
+
+// CloneRequest необязательные переопределения для POST /notify/:id/clone.
+type CloneRequest struct {
+	Recipient   string `json:"recipient"`
+	ScheduledAt string `json:"scheduled_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Immediate   bool   `json:"immediate"`
+}
+
 func jsonStringValidator(fl validator.FieldLevel) bool {
 	value := fl.Field().String()
 	var js map[string]interface{}
@@ -41,17 +182,76 @@ func jsonStringValidator(fl validator.FieldLevel) bool {
 
 func validationMessage(e validator.FieldError) string {
 	switch e.Tag() {
-	case "required":
+	case "required", "required_without":
 		return "обязательное поле"
+	case "excluded_with":
+		return "нельзя указывать вместе с " + e.Param()
+	case "excluded_without":
+		return "указывается только вместе с " + e.Param()
 	case "jsonstr":
 		return "должно быть корректным JSON-объектом"
 	case "datetime":
 		return "некорректный формат даты (ожидается RFC3339)"
+	case "uuid":
+		return "должно быть UUID"
+	case "http_url":
+		return "должно быть http(s) URL"
+	case "max":
+		if e.Kind() == reflect.Int {
+			return "не больше " + e.Param()
+		}
+		return "не длиннее " + e.Param() + " символов"
+	case "min":
+		return "не меньше " + e.Param()
+	case "timezone":
+		return "часовой пояс IANA, например Europe/Moscow"
+	case "oneof":
+		return "допустимые значения: " + strings.ReplaceAll(e.Param(), " ", ", ")
 	default:
 		return "некорректное значение"
 	}
 }
 
+// createValidationError сопоставляет ошибку валидации сервиса с полем запроса.
+func createValidationError(err error) (field, msg string, ok bool) {
+	switch {
+	case errors.Is(err, domain.ErrScheduledTooFarInPast):
+		return "ScheduledAt", "время отправки слишком далеко в прошлом (укажите immediate=true для отправки сразу)", true
+	case errors.Is(err, domain.ErrScheduledTooFarInFuture):
+		return "ScheduledAt", "время отправки дальше допустимого горизонта планирования", true
+	case errors.Is(err, domain.ErrEmptyRecipient):
+		return "Recipient", "обязательное поле", true
+	case errors.Is(err, domain.ErrInvalidRecipient):
+		return "Recipient", "неверный формат получателя для канала: " + err.Error(), true
+	case errors.Is(err, domain.ErrInvalidPayload):
+		return "Payload", "недопустимое содержимое для канала: " + err.Error(), true
+	case errors.Is(err, domain.ErrInvalidChannel):
+		return "Channel", "канал отправки не поддерживается", true
+	case errors.Is(err, domain.ErrParentNotFound):
+		return "ParentID", "исходное уведомление не найдено", true
+	case errors.Is(err, domain.ErrInvalidPriority):
+		return "Priority", "допустимые значения: high, normal, low", true
+	case errors.Is(err, domain.ErrInvalidStatus):
+		return "Status", "неизвестный статус уведомления", true
+	case errors.Is(err, domain.ErrInvalidTimeRange):
+		return "ScheduledTo", "должно быть позже scheduled_from", true
+	case errors.Is(err, domain.ErrEmptySearchQuery):
+		return "Q", "обязательный параметр, если не задан ни один фильтр", true
+	case errors.Is(err, domain.ErrInvalidViewName):
+		return "Name", "от 1 до 64 символов: a-z, 0-9, '-' и '_'", true
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		return "TemplateID", "шаблон не найден", true
+	case errors.Is(err, domain.ErrInvalidTemplate):
+		return "TemplateID", "шаблон не разбирается: " + err.Error(), true
+	case errors.Is(err, domain.ErrTemplateRender):
+		return "Variables", "шаблон не собирается с этими переменными: " + err.Error(), true
+	case errors.Is(err, domain.ErrSchemaMismatch):
+		return "Payload", "не соответствует схеме источника: " + err.Error(), true
+	default:
+		return "", "", false
+	}
+}
+
 func init() {
 	_ = validate.RegisterValidation("jsonstr", jsonStringValidator)
 }
@@ -63,6 +263,16 @@ func (h *Handler) CreateNotificationHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
 		return
 	}
+	if key := c.GetHeader(idempotencyKeyHeader); key != "" {
+		if req.IdempotencyKey != "" && req.IdempotencyKey != key {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"IdempotencyKey": "не совпадает с заголовком " + idempotencyKeyHeader},
+			})
+			return
+		}
+		req.IdempotencyKey = key
+	}
 
 	if err := validate.Struct(req); err != nil {
 		var verrs validator.ValidationErrors
@@ -88,7 +298,7 @@ func (h *Handler) CreateNotificationHandler(c *gin.Context) {
 	}
 
 	var params domain.CreateNotificationParams
-	if err = json.Unmarshal([]byte(req.Payload), &params.Payload); err != nil {
+	if params.Payload, err = createPayload(req); err != nil {
 		ErrResponceMessage["error"] = "Ошибка сериализации payload"
 		c.JSON(http.StatusBadRequest, ErrResponceMessage)
 		return
@@ -100,12 +310,35 @@ func (h *Handler) CreateNotificationHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, ErrResponceMessage)
 		return
 	}
-	params.Channel = ch
-	params.Recipient = req.Recipient
-	params.ScheduledAt = sheduledAt
+	fillCreateParams(&params, req, sheduledAt)
+	if len(req.Recipients) > 0 {
+		h.createGroup(c, params, req.Recipients)
+		return
+	}
 
 	n, err := h.service.CreateNotification(c.Request.Context(), params)
 	if err != nil {
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrOverloaded) {
+			c.Header("Retry-After", overloadRetryAfter)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			// ключ занят удаленным уведомлением
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrTemplatesDisabled) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -115,6 +348,207 @@ func (h *Handler) CreateNotificationHandler(c *gin.Context) {
 	})
 }
 
+// fillCreateParams переносит в params поля проверенного запроса, кроме payload.
+func fillCreateParams(params *domain.CreateNotificationParams, req CreateRequest, scheduledAt time.Time) {
+	params.Channel = domain.Channel(req.Channel)
+	params.Recipient = req.Recipient
+	params.ScheduledAt = scheduledAt
+	params.Immediate = req.Immediate
+	params.CancelOnConfirm = req.CancelOnConfirm
+	params.PreSendCheck = req.PreSendCheck
+	params.Smooth = req.Smooth
+	params.Source = req.Source
+	params.RequiresApproval = req.RequiresApproval
+	params.Priority = domain.Priority(req.Priority)
+	params.Draft = req.Draft
+	params.IdempotencyKey = req.IdempotencyKey
+	if req.ParentID != "" {
+		parentID := uuid.MustParse(req.ParentID)
+		params.ParentID = &parentID
+	}
+	if req.TemplateID != "" {
+		templateID := uuid.MustParse(req.TemplateID)
+		params.TemplateID = &templateID
+	}
+}
+
+// createPayload разбирает payload проверенного запроса и дополняет его переменными шаблона,
+// которые при совпадении ключей имеют приоритет.
+func createPayload(req CreateRequest) (map[string]interface{}, error) {
+	payload := make(map[string]interface{}, len(req.Variables))
+	if req.Payload != "" {
+		if err := json.Unmarshal([]byte(req.Payload), &payload); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range req.Variables {
+		payload[k] = v
+	}
+	return payload, nil
+}
+
+// batchItemParams проверяет элемент пакета и строит параметры создания;
+// при ошибках возвращает их по полям, как в ответе POST /notify.
+func batchItemParams(req CreateRequest) (domain.CreateNotificationParams, map[string]string) {
+	var params domain.CreateNotificationParams
+	if len(req.Recipients) > 0 {
+		return params, map[string]string{"Recipients": "в пакете не поддерживается, укажите recipient"}
+	}
+	if err := validate.Struct(req); err != nil {
+		errorsMap := make(map[string]string)
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+		}
+		return params, errorsMap
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, req.ScheduledAt)
+	if err != nil {
+		return params, map[string]string{"ScheduledAt": "некорректный формат даты (ожидается RFC3339)"}
+	}
+	if params.Payload, err = createPayload(req); err != nil {
+		return params, map[string]string{"Payload": "должно быть корректным JSON-объектом"}
+	}
+	if !domain.Channel(req.Channel).IsValid() {
+		return params, map[string]string{"Channel": "канал отправки не поддерживается"}
+	}
+	fillCreateParams(&params, req, scheduledAt)
+	return params, nil
+}
+
+// CreateBatchHandler создает несколько уведомлений за один запрос. Каждый элемент проверяется
+// отдельно: прошедшие проверку создаются в одной транзакции и публикуются, по остальным
+// в ответе возвращаются ошибки с индексом элемента.
+func (h *Handler) CreateBatchHandler(c *gin.Context) {
+	var req BatchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	items := make([]BatchItemResponse, len(req.Notifications))
+	var (
+		params  []domain.CreateNotificationParams
+		indexes []int
+	)
+	for i, item := range req.Notifications {
+		items[i].Index = i
+		p, errs := batchItemParams(item)
+		if errs != nil {
+			items[i].Errors = errs
+			continue
+		}
+		params = append(params, p)
+		indexes = append(indexes, i)
+	}
+
+	if len(params) > 0 {
+		results, err := h.service.CreateNotifications(c.Request.Context(), params)
+		if err != nil {
+			if errors.Is(err, domain.ErrBatchTooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for j, res := range results {
+			item := &items[indexes[j]]
+			if res.Err == nil {
+				n := toNotificationResponse(res.Notification)
+				item.Notification = &n
+			} else if field, msg, ok := createValidationError(res.Err); ok {
+				item.Errors = map[string]string{field: msg}
+			} else {
+				item.Error = res.Err.Error()
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": toBatchCreateResponse(items)})
+}
+
+// defaultListLimit размер страницы GET /notify, если limit не задан.
+const defaultListLimit = 20
+
+// ListNotificationsHandler возвращает страницу уведомлений, новые по scheduled_at первыми.
+// Фильтры: ?status=, ?channel=, ?recipient=, ?scheduled_from= и ?scheduled_to= (RFC3339);
+// страница: ?limit= (1–100, по умолчанию 20) и ?offset=.
+func (h *Handler) ListNotificationsHandler(c *gin.Context) {
+	listNotifications(c, h.service, false)
+}
+
+// listNotifications отдает страницу уведомлений; includeDeleted добавляет мягко удаленные.
+func listNotifications(c *gin.Context, service domain.NotificationService, includeDeleted bool) {
+	var req ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные параметры запроса: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+	filter := domain.ListFilter{
+		Status:         domain.Status(req.Status),
+		Channel:        domain.Channel(req.Channel),
+		Recipient:      req.Recipient,
+		IncludeDeleted: includeDeleted,
+	}
+	// формат уже проверен валидатором
+	if req.ScheduledFrom != "" {
+		filter.ScheduledFrom, _ = time.Parse(time.RFC3339, req.ScheduledFrom)
+	}
+	if req.ScheduledTo != "" {
+		filter.ScheduledTo, _ = time.Parse(time.RFC3339, req.ScheduledTo)
+	}
+
+	ns, hasMore, err := service.ListNotifications(c.Request.Context(), filter, req.Limit, req.Offset)
+	if err != nil {
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationListResponse(ns, req.Limit, req.Offset, hasMore)})
+}
+
 func (h *Handler) GetNotificationHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
@@ -128,23 +562,22 @@ func (h *Handler) GetNotificationHandler(c *gin.Context) {
 		return
 	}
 
-	n, err := h.service.GetNotificationByID(c.Request.Context(), id)
+	// Cache-Control: no-cache читает уведомление из базы и обновляет кеш
+	get := h.service.GetNotificationByID
+	if strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache") {
+		get = h.service.RefreshNotificationByID
+	}
+
+	n, err := get(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if notModified(c, notificationETag(n)) {
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"result": NotificationResponse{
-		ID:          n.ID,
-		Recipient:   n.Recipient,
-		Channel:     n.Channel.String(),
-		Payload:     n.Payload,
-		ScheduledAt: n.ScheduledAt,
-		Status:      n.Status.String(),
-		RetryCount:  n.RetryCount,
-		CreatedAt:   n.CreatedAt,
-		UpdatedAt:   n.UpdatedAt,
-	}})
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationResponse(n)})
 }
 
 func (h *Handler) DeleteNotificationHandler(c *gin.Context) {
@@ -160,10 +593,565 @@ func (h *Handler) DeleteNotificationHandler(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Cancel(c.Request.Context(), id)
+	ctx, ok := h.preconditionContext(c)
+	if !ok {
+		return
+	}
+	err = h.service.Cancel(ctx, id)
 	if err != nil {
+		if errors.Is(err, domain.ErrPreconditionFailed) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"result": idStr + " cancelled"})
 }
+
+// ConfirmNotificationHandler принимает подтверждение и отменяет уведомление,
+// созданное с cancel_on_confirm, если оно еще не отправлено.
+func (h *Handler) ConfirmNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	if err := h.service.Confirm(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrConfirmNotExpected), errors.Is(err, domain.ErrConfirmTooLate):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": id.String() + " confirmed"})
+}
+
+// ApproveNotificationHandler одобряет уведомление, созданное с requires_approval,
+// после чего оно публикуется в очередь.
+func (h *Handler) ApproveNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	var req ApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	n, err := h.service.Approve(c.Request.Context(), id, req.Approver)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrApprovalNotRequired), errors.Is(err, domain.ErrNotAwaitingApproval):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationResponse(n)})
+}
+
+// UpdateDraftHandler изменяет черновик уведомления.
+func (h *Handler) UpdateDraftHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	var req DraftUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	params := domain.DraftUpdateParams{Recipient: req.Recipient}
+	if req.Channel != nil {
+		ch := domain.Channel(*req.Channel)
+		params.Channel = &ch
+	}
+	if req.Payload != nil {
+		if err := json.Unmarshal([]byte(*req.Payload), &params.Payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка сериализации payload"})
+			return
+		}
+	}
+	if req.ScheduledAt != nil {
+		t, err := time.Parse(time.RFC3339, *req.ScheduledAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Время указано некорректно"})
+			return
+		}
+		params.ScheduledAt = &t
+	}
+
+	ctx, ok := h.preconditionContext(c)
+	if !ok {
+		return
+	}
+	n, err := h.service.UpdateDraft(ctx, id, params)
+	if err != nil {
+		h.draftError(c, err)
+		return
+	}
+	c.Header("ETag", notificationETag(n))
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationResponse(n)})
+}
+
+// ScheduleDraftHandler переводит черновик в отправку.
+func (h *Handler) ScheduleDraftHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	params := domain.ScheduleParams{Immediate: req.Immediate, Smooth: req.Smooth}
+	if req.ScheduledAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ScheduledAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Время указано некорректно"})
+			return
+		}
+		params.ScheduledAt = &t
+	}
+
+	n, err := h.service.ScheduleDraft(c.Request.Context(), id, params)
+	if err != nil {
+		if errors.Is(err, domain.ErrOverloaded) {
+			c.Header("Retry-After", overloadRetryAfter)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		h.draftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationResponse(n)})
+}
+
+// draftError отвечает на ошибку изменения или планирования черновика.
+func (h *Handler) draftError(c *gin.Context, err error) {
+	if field, msg, ok := createValidationError(err); ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Ошибка валидации",
+			"errors":  map[string]string{field: msg},
+		})
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotDraft):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ReceiptNotificationHandler принимает квитанцию о доставке, прочтении или возврате
+// от канала (вебхук провайдера) и обновляет статус отправленного уведомления.
+func (h *Handler) ReceiptNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	var req ReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	n, err := h.service.RecordReceipt(c.Request.Context(), id, domain.Status(req.Status), req.EventID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDuplicateEvent):
+			// 2xx, чтобы провайдер перестал доставлять событие повторно
+			c.JSON(http.StatusOK, gin.H{"result": "event " + req.EventID + " already processed"})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toNotificationResponse(n)})
+}
+
+// CloneNotificationHandler создает копию уведомления, при необходимости
+// с другим получателем и временем отправки.
+func (h *Handler) CloneNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	var req CloneRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	params := domain.CloneNotificationParams{
+		Recipient: req.Recipient,
+		Immediate: req.Immediate,
+	}
+	if req.ScheduledAt != "" {
+		scheduledAt, err := time.Parse(time.RFC3339, req.ScheduledAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Время указано некорректно"})
+			return
+		}
+		params.ScheduledAt = &scheduledAt
+	}
+
+	n, err := h.service.CloneNotification(c.Request.Context(), id, params)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": n})
+}
+
+// GetRelatedNotificationsHandler возвращает дерево связанных уведомлений
+// (клоны, повторы, эскалации) от корня цепочки.
+func (h *Handler) GetRelatedNotificationsHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	related, err := h.service.GetRelatedNotifications(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": toRelatedTree(related)})
+}
+
+// HistoryHandler возвращает историю статусов уведомления: переходы с временем, инициатором и ошибкой.
+func (h *Handler) HistoryHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	events, err := h.service.History(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrEventLogDisabled):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	resp := make([]NotificationEventResponse, 0, len(events))
+	for i := range events {
+		resp = append(resp, toNotificationEventResponse(&events[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// previewPage HTML-страница предпросмотра; поля экранируются html/template. HTML-тело письма
+// показывается в iframe с sandbox без скриптов: тело пишет клиент, и на странице сервиса оно не
+// должно исполняться, даже если обойдет очистку.
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body>
+<p><b>{{.Channel}}</b> → {{.Recipient}}</p>
+<h3>{{.Subject}}</h3>
+{{if .HTML}}<iframe sandbox srcdoc="{{.Body}}" width="100%" height="600"></iframe>{{else}}<pre>{{.Body}}</pre>{{end}}
+</body></html>
+`))
+
+// previewCSP политика страницы предпросмотра и ее iframe: без скриптов, форм и подключаемых ресурсов,
+// кроме картинок и встроенных стилей письма.
+const previewCSP = "default-src 'none'; img-src http: https:; style-src 'unsafe-inline'; frame-src 'self'; " +
+	"form-action 'none'; base-uri 'none'"
+
+// PreviewNotificationHandler показывает итоговое сообщение уведомления
+// в формате ?format=json (по умолчанию), html или text.
+func (h *Handler) PreviewNotificationHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+
+	msg, err := h.service.PreviewNotification(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidChannel):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"result": msg})
+	case "text":
+		text := msg.Body
+		if msg.Subject != "" && msg.Channel == domain.ChannelEmail {
+			text = "Subject: " + msg.Subject + "\n\n" + msg.Body
+		}
+		c.String(http.StatusOK, text)
+	case "html":
+		var buf bytes.Buffer
+		err := previewPage.Execute(&buf, struct {
+			*domain.RenderedMessage
+			HTML bool
+		}{msg, strings.HasPrefix(msg.ContentType, "text/html")})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Security-Policy", previewCSP)
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown format: " + format})
+	}
+}
+
+// SnoozeRecipientHandler приостанавливает отправку получателю: уведомления,
+// срок которых наступит во время паузы, переносятся на ее окончание.
+func (h *Handler) SnoozeRecipientHandler(c *gin.Context) {
+	var req SnoozeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Ошибка валидации",
+			"errors":  map[string]string{"Duration": "положительная длительность, например 30m или 2h"},
+		})
+		return
+	}
+
+	until, err := h.service.SnoozeRecipient(c.Request.Context(), domain.Channel(c.Param("channel")),
+		c.Param("recipient"), d)
+	if err != nil {
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": gin.H{"snoozed_until": until}})
+}
+
+// GetRecipientSnoozeHandler возвращает окончание паузы получателя или 404, если паузы нет.
+func (h *Handler) GetRecipientSnoozeHandler(c *gin.Context) {
+	until, ok, err := h.service.RecipientSnoozedUntil(c.Request.Context(), domain.Channel(c.Param("channel")),
+		c.Param("recipient"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recipient is not snoozed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": gin.H{"snoozed_until": until}})
+}
+
+// UnsnoozeRecipientHandler снимает паузу получателя.
+func (h *Handler) UnsnoozeRecipientHandler(c *gin.Context) {
+	if err := h.service.UnsnoozeRecipient(c.Request.Context(), domain.Channel(c.Param("channel")),
+		c.Param("recipient")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": c.Param("recipient") + " unsnoozed"})
+}
+
+// PreviewScheduleHandler показывает, когда было бы отправлено уведомление с такими параметрами,
+// ничего не создавая.
+func (h *Handler) PreviewScheduleHandler(c *gin.Context) {
+	var req SchedulePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			errorsMap := make(map[string]string)
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  errorsMap,
+			})
+			return
+		}
+	}
+
+	params := domain.SchedulePreviewParams{
+		Immediate: req.Immediate,
+		Smooth:    req.Smooth,
+		Channel:   domain.Channel(req.Channel),
+		Recipient: req.Recipient,
+	}
+	loc := time.UTC
+	if req.Timezone != "" {
+		// имя уже проверено валидатором
+		loc, _ = time.LoadLocation(req.Timezone)
+		params.Location = loc
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, req.ScheduledAt)
+	if err != nil {
+		scheduledAt, err = time.ParseInLocation(localDateTime, req.ScheduledAt, loc)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Ошибка валидации",
+			"errors":  map[string]string{"ScheduledAt": "некорректный формат даты (ожидается RFC3339 или 2006-01-02T15:04:05)"},
+		})
+		return
+	}
+	params.ScheduledAt = scheduledAt
+
+	preview, err := h.service.PreviewSchedule(c.Request.Context(), params)
+	if err != nil {
+		if field, msg, ok := createValidationError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{field: msg},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toSchedulePreviewResponse(preview)})
+}
@@ -1,19 +1,488 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
+	"DelayedNotifier/internal/domain"
 	"github.com/google/uuid"
 )
 
 type NotificationResponse struct {
-	ID          uuid.UUID              `json:"id"`
-	Recipient   string                 `json:"recipient"`
-	Channel     string                 `json:"channel"`
-	Payload     map[string]interface{} `json:"payload"`
-	ScheduledAt time.Time              `json:"scheduled_at"`
-	Status      string                 `json:"status"`
-	RetryCount  int                    `json:"retry_count"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	ID                   uuid.UUID              `json:"id"`
+	Recipient            string                 `json:"recipient"`
+	Channel              string                 `json:"channel"`
+	Payload              map[string]interface{} `json:"payload"`
+	ScheduledAt          time.Time              `json:"scheduled_at"`
+	Status               string                 `json:"status"`
+	RetryCount           int                    `json:"retry_count"`
+	CreatedAt            time.Time              `json:"created_at"`
+	UpdatedAt            time.Time              `json:"updated_at"`
+	DeletedAt            *time.Time             `json:"deleted_at,omitempty"`
+	ParentID             *uuid.UUID             `json:"parent_id,omitempty"`
+	CorrelationID        *uuid.UUID             `json:"correlation_id,omitempty"`
+	CancelOnConfirm      bool                   `json:"cancel_on_confirm,omitempty"`
+	PreSendCheck         string                 `json:"pre_send_check,omitempty"`
+	EffectiveScheduledAt time.Time              `json:"effective_scheduled_at"`
+	Source               string                 `json:"source,omitempty"`
+	ReprocessCount       int                    `json:"reprocess_count,omitempty"`
+	RequiresApproval     bool                   `json:"requires_approval,omitempty"`
+	ApprovedBy           string                 `json:"approved_by,omitempty"`
+	ApprovedAt           *time.Time             `json:"approved_at,omitempty"`
+	GroupID              *uuid.UUID             `json:"group_id,omitempty"`
+	TemplateID           *uuid.UUID             `json:"template_id,omitempty"`
+	Priority             string                 `json:"priority"`
+}
+
+func toNotificationResponse(n *domain.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:                   n.ID,
+		Recipient:            n.Recipient,
+		Channel:              n.Channel.String(),
+		Payload:              n.Payload,
+		ScheduledAt:          n.ScheduledAt,
+		Status:               n.Status.String(),
+		RetryCount:           n.RetryCount,
+		CreatedAt:            n.CreatedAt,
+		UpdatedAt:            n.UpdatedAt,
+		DeletedAt:            n.DeletedAt,
+		ParentID:             n.ParentID,
+		CorrelationID:        n.CorrelationID,
+		CancelOnConfirm:      n.CancelOnConfirm,
+		PreSendCheck:         n.PreSendCheck,
+		EffectiveScheduledAt: n.EffectiveScheduledAt,
+		Source:               n.Source,
+		ReprocessCount:       n.ReprocessCount,
+		RequiresApproval:     n.RequiresApproval,
+		ApprovedBy:           n.ApprovedBy,
+		ApprovedAt:           n.ApprovedAt,
+		GroupID:              n.GroupID,
+		TemplateID:           n.TemplateID,
+		Priority:             n.EffectivePriority().String(),
+	}
+}
+
+// BatchItemResponse итог одного элемента POST /notify/batch: созданное уведомление
+// или ошибки проверки по полям (Errors) либо ошибка создания (Error).
+type BatchItemResponse struct {
+	Index int `json:"index"`
+	// Recipient получатель элемента при создании для нескольких получателей
+	Recipient    string                `json:"recipient,omitempty"`
+	Notification *NotificationResponse `json:"notification,omitempty"`
+	Errors       map[string]string     `json:"errors,omitempty"`
+	Error        string                `json:"error,omitempty"`
+}
+
+// BatchCreateResponse результат POST /notify/batch.
+type BatchCreateResponse struct {
+	Items   []BatchItemResponse `json:"items"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+}
+
+func toBatchCreateResponse(items []BatchItemResponse) BatchCreateResponse {
+	resp := BatchCreateResponse{Items: items}
+	for _, item := range items {
+		if item.Notification != nil {
+			resp.Created++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp
+}
+
+// GroupCreateResponse результат POST /notify с recipients: по элементу на получателя.
+type GroupCreateResponse struct {
+	GroupID uuid.UUID           `json:"group_id"`
+	Items   []BatchItemResponse `json:"items"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+}
+
+// GroupItemResponse уведомление группы в сводке GET /notify/group/:group_id.
+type GroupItemResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Recipient string    `json:"recipient"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GroupStatusResponse сводное состояние группы: число уведомлений по статусам и по тому,
+// ожидают ли они отправки (scheduled), отправлены, не отправлены или отменены.
+type GroupStatusResponse struct {
+	GroupID   uuid.UUID           `json:"group_id"`
+	Total     int                 `json:"total"`
+	Scheduled int                 `json:"scheduled"`
+	Sent      int                 `json:"sent"`
+	Failed    int                 `json:"failed"`
+	Cancelled int                 `json:"cancelled"`
+	Completed bool                `json:"completed"`
+	ByStatus  map[string]int      `json:"by_status"`
+	Items     []GroupItemResponse `json:"items"`
+}
+
+func toGroupStatusResponse(g *domain.GroupStatus) GroupStatusResponse {
+	resp := GroupStatusResponse{
+		GroupID:   g.GroupID,
+		Total:     g.Total(),
+		Scheduled: g.ByState[domain.PublicStateScheduled],
+		Sent:      g.ByState[domain.PublicStateSent],
+		Failed:    g.ByState[domain.PublicStateFailed],
+		Cancelled: g.ByState[domain.PublicStateCancelled],
+		Completed: g.Completed(),
+		ByStatus:  make(map[string]int, len(g.ByStatus)),
+		Items:     make([]GroupItemResponse, 0, len(g.Notifications)),
+	}
+	for st, count := range g.ByStatus {
+		resp.ByStatus[st.String()] = count
+	}
+	for _, n := range g.Notifications {
+		resp.Items = append(resp.Items, GroupItemResponse{
+			ID:        n.ID,
+			Recipient: n.Recipient,
+			Status:    n.Status.String(),
+			UpdatedAt: n.UpdatedAt,
+		})
+	}
+	return resp
+}
+
+// NotificationListResponse страница списка уведомлений.
+type NotificationListResponse struct {
+	Items  []NotificationResponse `json:"items"`
+	Limit  int                    `json:"limit"`
+	Offset int                    `json:"offset"`
+	// HasMore есть ли уведомления после этой страницы (следующая — offset+limit)
+	HasMore bool `json:"has_more"`
+}
+
+func toNotificationListResponse(ns []domain.Notification, limit, offset int, hasMore bool) NotificationListResponse {
+	resp := NotificationListResponse{
+		Items:   make([]NotificationResponse, 0, len(ns)),
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: hasMore,
+	}
+	for i := range ns {
+		resp.Items = append(resp.Items, toNotificationResponse(&ns[i]))
+	}
+	return resp
+}
+
+// SchedulePreviewResponse результат POST /schedule/preview.
+type SchedulePreviewResponse struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	// EarliestAt и LatestAt границы effective_scheduled_at, при сглаживании отправка случайна внутри них
+	EarliestAt   time.Time  `json:"earliest_at"`
+	LatestAt     time.Time  `json:"latest_at"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	SmoothWindow string     `json:"smooth_window,omitempty"`
+}
+
+func toSchedulePreviewResponse(p *domain.SchedulePreview) SchedulePreviewResponse {
+	resp := SchedulePreviewResponse{
+		ScheduledAt:  p.ScheduledAt,
+		EarliestAt:   p.EarliestAt,
+		LatestAt:     p.LatestAt,
+		SnoozedUntil: p.SnoozedUntil,
+	}
+	if p.SmoothWindow > 0 {
+		resp.SmoothWindow = p.SmoothWindow.String()
+	}
+	return resp
+}
+
+// RelatedNode узел дерева связанных уведомлений.
+type RelatedNode struct {
+	NotificationResponse
+	Children []*RelatedNode `json:"children,omitempty"`
+}
+
+// toRelatedTree строит дерево по parent_id. Узлы, чей родитель не попал в выборку
+// (например, удален), подвешиваются к корню.
+func toRelatedTree(ns []domain.Notification) *RelatedNode {
+	if len(ns) == 0 {
+		return nil
+	}
+	nodes := make(map[uuid.UUID]*RelatedNode, len(ns))
+	for i := range ns {
+		nodes[ns[i].ID] = &RelatedNode{NotificationResponse: toNotificationResponse(&ns[i])}
+	}
+
+	var root *RelatedNode
+	for i := range ns {
+		if ns[i].CorrelationID == nil {
+			root = nodes[ns[i].ID]
+		}
+	}
+	if root == nil {
+		root = nodes[ns[0].ID]
+	}
+	for i := range ns {
+		node := nodes[ns[i].ID]
+		if node == root {
+			continue
+		}
+		parent := root
+		if ns[i].ParentID != nil {
+			if p, ok := nodes[*ns[i].ParentID]; ok {
+				parent = p
+			}
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return root
+}
+
+type TopologyItemResponse struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+type TopologyResponse struct {
+	OK    bool                   `json:"ok"`
+	Items []TopologyItemResponse `json:"items"`
+	Error string                 `json:"error,omitempty"`
+}
+
+// SearchHitResponse найденное уведомление и его релевантность.
+type SearchHitResponse struct {
+	NotificationResponse
+	Rank float64 `json:"rank"`
+}
+
+// RescheduleRequest тело POST /admin/notify/reschedule: фильтр ожидающих уведомлений
+// и ровно одно из нового времени scheduled_at и сдвига shift.
+type RescheduleRequest struct {
+	Filter RescheduleFilter `json:"filter"`
+	// ScheduledAt новое время отправки (RFC3339)
+	ScheduledAt string `json:"scheduled_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// Shift сдвиг в формате Go, например +2h или -30m
+	Shift string `json:"shift"`
+	// Limit максимум уведомлений за вызов, по умолчанию 1000
+	Limit int `json:"limit" validate:"omitempty,min=1,max=10000"`
+}
+
+// RescheduleFilter отбор уведомлений для переноса, поля как параметры GET /notify.
+type RescheduleFilter struct {
+	Channel       string `json:"channel" validate:"omitempty,oneof=email telegram sms"`
+	Recipient     string `json:"recipient" validate:"max=320"`
+	ScheduledFrom string `json:"scheduled_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ScheduledTo   string `json:"scheduled_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// RescheduleResponse итог массового переноса.
+type RescheduleResponse struct {
+	Rescheduled int         `json:"rescheduled"`
+	Republished int         `json:"republished"`
+	Truncated   bool        `json:"truncated"`
+	IDs         []uuid.UUID `json:"ids"`
+}
+
+// SaveViewRequest тело PUT /admin/views/:name, поля как параметры GET /admin/search.
+type SaveViewRequest struct {
+	Q       string `json:"q"`
+	Channel string `json:"channel"`
+	Status  string `json:"status"`
+	Last    string `json:"last"`
+	Limit   int    `json:"limit"`
+}
+
+// NotificationFailureResponse запись журнала неуспешных уведомлений.
+type NotificationFailureResponse struct {
+	ID             int64     `json:"id"`
+	NotificationID string    `json:"notification_id"`
+	Error          string    `json:"error"`
+	Attempts       int       `json:"attempts"`
+	ScheduledAt    time.Time `json:"scheduled_at"`
+	FailedAt       time.Time `json:"failed_at"`
+}
+
+func toNotificationFailureResponse(f *domain.NotificationFailure) NotificationFailureResponse {
+	return NotificationFailureResponse{
+		ID:             f.ID,
+		NotificationID: f.NotificationID.String(),
+		Error:          f.Error,
+		Attempts:       f.Attempts,
+		ScheduledAt:    f.ScheduledAt,
+		FailedAt:       f.FailedAt,
+	}
+}
+
+// NotificationEventResponse переход статуса из истории уведомления.
+type NotificationEventResponse struct {
+	// From пустой у записи о создании
+	From  string    `json:"from,omitempty"`
+	To    string    `json:"to"`
+	Actor string    `json:"actor"`
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+func toNotificationEventResponse(e *domain.NotificationEvent) NotificationEventResponse {
+	return NotificationEventResponse{
+		From:  string(e.FromStatus),
+		To:    string(e.ToStatus),
+		Actor: string(e.Actor),
+		Error: e.Error,
+		At:    e.At,
+	}
+}
+
+// CacheEntryResponse запись кэша. Значение отдается строкой, если это UTF-8 (кодек json),
+// иначе в base64 (msgpack). TTL пустой у ключа без срока жизни.
+type CacheEntryResponse struct {
+	Key         string `json:"key"`
+	Value       string `json:"value,omitempty"`
+	ValueBase64 string `json:"value_base64,omitempty"`
+	TTL         string `json:"ttl,omitempty"`
+	Persistent  bool   `json:"persistent"`
+}
+
+func toCacheEntryResponse(e *domain.CacheEntry) CacheEntryResponse {
+	resp := CacheEntryResponse{Key: e.Key, Persistent: e.TTL < 0}
+	if utf8.Valid(e.Value) {
+		resp.Value = string(e.Value)
+	} else {
+		resp.ValueBase64 = base64.StdEncoding.EncodeToString(e.Value)
+	}
+	if e.TTL >= 0 {
+		resp.TTL = e.TTL.String()
+	}
+	return resp
+}
+
+// cacheFlushSample сколько найденных ключей показывать в ответе очистки кэша.
+const cacheFlushSample = 100
+
+// CacheFlushResponse итог очистки кэша по шаблону; Keys — первые найденные ключи.
+type CacheFlushResponse struct {
+	Pattern   string   `json:"pattern"`
+	DryRun    bool     `json:"dry_run"`
+	Matched   int      `json:"matched"`
+	Deleted   int      `json:"deleted"`
+	Truncated bool     `json:"truncated"`
+	Keys      []string `json:"keys"`
+}
+
+func toCacheFlushResponse(pattern string, dryRun bool, res domain.CacheFlushResult) CacheFlushResponse {
+	keys := res.Keys
+	if len(keys) > cacheFlushSample {
+		keys = keys[:cacheFlushSample]
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	return CacheFlushResponse{
+		Pattern:   pattern,
+		DryRun:    dryRun,
+		Matched:   len(res.Keys),
+		Deleted:   res.Deleted,
+		Truncated: res.Truncated,
+		Keys:      keys,
+	}
+}
+
+// SavedViewResponse сохраненное представление; Link — готовый запрос GET /admin/search.
+type SavedViewResponse struct {
+	Name      string    `json:"name"`
+	Q         string    `json:"q,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Last      string    `json:"last,omitempty"`
+	Limit     int       `json:"limit,omitempty"`
+	Link      string    `json:"link"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toSavedViewResponse(v *domain.SavedView) SavedViewResponse {
+	resp := SavedViewResponse{
+		Name:      v.Name,
+		Q:         v.Filter.Query,
+		Channel:   v.Filter.Channel.String(),
+		Status:    v.Filter.Status.String(),
+		Limit:     v.Filter.Limit,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+	}
+	if v.Filter.Last > 0 {
+		resp.Last = v.Filter.Last.String()
+	}
+	query := url.Values{}
+	for key, val := range map[string]string{"q": resp.Q, "channel": resp.Channel, "status": resp.Status,
+		"last": resp.Last} {
+		if val != "" {
+			query.Set(key, val)
+		}
+	}
+	if resp.Limit > 0 {
+		query.Set("limit", strconv.Itoa(resp.Limit))
+	}
+	resp.Link = "/admin/search?" + query.Encode()
+	return resp
+}
+
+// SLAReportResponse соблюдение цели SLA за окно.
+type SLAReportResponse struct {
+	Target     string  `json:"target"`
+	Source     string  `json:"source,omitempty"`
+	Channel    string  `json:"channel"`
+	Objective  float64 `json:"objective"`
+	Within     string  `json:"within"`
+	Window     string  `json:"window"`
+	Compliance float64 `json:"compliance"`
+	Met        int     `json:"met"`
+	Total      int     `json:"total"`
+	Breached   bool    `json:"breached"`
+}
+
+func toSLAReportResponse(r domain.SLAReport) SLAReportResponse {
+	return SLAReportResponse{
+		Target:     r.Target.Name(),
+		Source:     r.Target.Source,
+		Channel:    r.Target.Channel.String(),
+		Objective:  r.Target.Objective,
+		Within:     r.Target.Within.String(),
+		Window:     r.Window.String(),
+		Compliance: r.Compliance(),
+		Met:        r.Met,
+		Total:      r.Total,
+		Breached:   r.Breached,
+	}
+}
+
+// DuplicateGroupResponse группа вероятных повторных доставок.
+type DuplicateGroupResponse struct {
+	Recipient   string      `json:"recipient"`
+	Channel     string      `json:"channel"`
+	PayloadHash string      `json:"payload_hash"`
+	Count       int         `json:"count"`
+	FirstAt     time.Time   `json:"first_at"`
+	LastAt      time.Time   `json:"last_at"`
+	IDs         []uuid.UUID `json:"ids"`
+}
+
+// DuplicateReportResponse отчет о повторных доставках за окно.
+type DuplicateReportResponse struct {
+	Window string                   `json:"window"`
+	Groups []DuplicateGroupResponse `json:"groups"`
+}
+
+func toDuplicateGroupResponse(g domain.DuplicateGroup) DuplicateGroupResponse {
+	return DuplicateGroupResponse{
+		Recipient:   g.Recipient,
+		Channel:     g.Channel.String(),
+		PayloadHash: g.PayloadHash,
+		Count:       len(g.IDs),
+		FirstAt:     g.FirstAt,
+		LastAt:      g.LastAt,
+		IDs:         g.IDs,
+	}
 }
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// SaveSchemaRequest тело PUT /admin/schemas/:source/:channel.
+type SaveSchemaRequest struct {
+	// Schema документ JSON Schema для payload уведомлений источника в канале
+	Schema map[string]interface{} `json:"schema" validate:"required"`
+}
+
+// PayloadSchemaResponse схема payload источника и канала.
+type PayloadSchemaResponse struct {
+	Source    string                 `json:"source"`
+	Channel   string                 `json:"channel"`
+	Schema    map[string]interface{} `json:"schema"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+func toPayloadSchemaResponse(s *domain.PayloadSchema) PayloadSchemaResponse {
+	return PayloadSchemaResponse{
+		Source:    s.Source,
+		Channel:   s.Channel.String(),
+		Schema:    s.Schema,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// SaveSchemaHandler регистрирует схему payload для источника :source в канале :channel, заменяя прежнюю.
+func (h *AdminHandler) SaveSchemaHandler(c *gin.Context) {
+	var req SaveSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return
+	}
+	if req.Schema == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Ошибка валидации",
+			"errors":  map[string]string{"Schema": "обязательное поле"},
+		})
+		return
+	}
+	s, err := h.service.SaveSchema(c.Request.Context(), c.Param("source"), domain.Channel(c.Param("channel")),
+		req.Schema)
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toPayloadSchemaResponse(s)})
+}
+
+// ListSchemasHandler возвращает все зарегистрированные схемы payload.
+func (h *AdminHandler) ListSchemasHandler(c *gin.Context) {
+	schemas, err := h.service.ListSchemas(c.Request.Context())
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+	resp := make([]PayloadSchemaResponse, 0, len(schemas))
+	for i := range schemas {
+		resp = append(resp, toPayloadSchemaResponse(&schemas[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// GetSchemaHandler возвращает схему payload источника :source в канале :channel.
+func (h *AdminHandler) GetSchemaHandler(c *gin.Context) {
+	s, err := h.service.GetSchema(c.Request.Context(), c.Param("source"), domain.Channel(c.Param("channel")))
+	if err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toPayloadSchemaResponse(s)})
+}
+
+// DeleteSchemaHandler снимает схему payload источника :source в канале :channel.
+func (h *AdminHandler) DeleteSchemaHandler(c *gin.Context) {
+	source, ch := c.Param("source"), c.Param("channel")
+	if err := h.service.DeleteSchema(c.Request.Context(), source, domain.Channel(ch)); err != nil {
+		writeSchemaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": source + "/" + ch + " deleted"})
+}
+
+func writeSchemaError(c *gin.Context, err error) {
+	field := ""
+	switch {
+	case errors.Is(err, domain.ErrInvalidSchema):
+		field = "Schema"
+	case errors.Is(err, domain.ErrInvalidSchemaSource):
+		field = "Source"
+	case errors.Is(err, domain.ErrInvalidChannel):
+		field = "Channel"
+	case errors.Is(err, domain.ErrSchemaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domain.ErrSchemasDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"message": "Ошибка валидации",
+		"errors":  map[string]string{field: err.Error()},
+	})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StatusLinkRequest необязательное тело POST /notify/:id/status-link.
+type StatusLinkRequest struct {
+	// TTL срок действия ссылки в формате Go (24h), пустой — срок по умолчанию
+	TTL string `json:"ttl"`
+}
+
+// StatusLinkResponse ссылка на публичную страницу статуса.
+type StatusLinkResponse struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PublicStatusResponse публичный статус уведомления (GET /s/:token?format=json).
+type PublicStatusResponse struct {
+	State       string    `json:"state"`
+	Channel     string    `json:"channel"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// publicStateTitles подписи состояний на странице статуса.
+var publicStateTitles = map[domain.PublicState]string{
+	domain.PublicStateScheduled: "Запланировано",
+	domain.PublicStateSent:      "Отправлено",
+	domain.PublicStateFailed:    "Не удалось отправить",
+	domain.PublicStateCancelled: "Отменено",
+}
+
+// statusPage минимальная страница статуса для встраивания (iframe) в клиентские приложения.
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>body{font-family:sans-serif;margin:1em}.state{font-size:1.4em;font-weight:bold}.scheduled{color:#8a6d00}
+.sent{color:#1a7f37}.failed{color:#c62828}.cancelled{color:#666}.muted{color:#888;font-size:.9em}</style>
+</head>
+<body>
+{{if .Status}}<p class="state {{.Status.State}}">{{.Title}}</p>
+<p>Время отправки: {{.Status.ScheduledAt.UTC.Format "02.01.2006 15:04 MST"}}</p>
+<p class="muted">Обновлено {{.Status.UpdatedAt.UTC.Format "02.01.2006 15:04 MST"}}</p>
+{{else}}<p class="state">{{.Title}}</p>{{end}}
+</body></html>
+`))
+
+// StatusLinkHandler выдает подписанную ссылку на публичную страницу статуса уведомления.
+func (h *Handler) StatusLinkHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+	var req StatusLinkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+			return
+		}
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"TTL": "длительность в формате Go, например 24h"},
+			})
+			return
+		}
+	}
+
+	link, err := h.service.StatusLink(c.Request.Context(), id, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidStatusLinkTTL):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"TTL": "должно быть больше нуля и не больше допустимого срока"},
+			})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrStatusLinksDisabled):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": StatusLinkResponse{
+		URL:       h.publicBaseURL + "/s/" + link.Token,
+		Token:     link.Token,
+		ExpiresAt: link.ExpiresAt,
+	}})
+}
+
+// PublicStatusHandler показывает статус уведомления по подписанной ссылке без авторизации:
+// HTML-страницу или JSON с ?format=json. Получатель и содержимое не раскрываются.
+func (h *Handler) PublicStatusHandler(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	st, err := h.service.PublicStatus(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		code, title := http.StatusInternalServerError, "Статус временно недоступен"
+		switch {
+		case errors.Is(err, domain.ErrStatusTokenExpired):
+			code, title = http.StatusGone, "Срок действия ссылки истек"
+		case errors.Is(err, domain.ErrInvalidStatusToken), errors.Is(err, domain.ErrNotFound),
+			errors.Is(err, domain.ErrStatusLinksDisabled):
+			code, title = http.StatusNotFound, "Ссылка недействительна"
+		}
+		if c.Query("format") == "json" {
+			c.JSON(code, gin.H{"error": title})
+			return
+		}
+		renderStatusPage(c, code, title, nil)
+		return
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, gin.H{"result": PublicStatusResponse{
+			State:       string(st.State),
+			Channel:     st.Channel.String(),
+			ScheduledAt: st.ScheduledAt,
+			UpdatedAt:   st.UpdatedAt,
+			ExpiresAt:   st.ExpiresAt,
+		}})
+		return
+	}
+	renderStatusPage(c, http.StatusOK, publicStateTitles[st.State], st)
+}
+
+func renderStatusPage(c *gin.Context, code int, title string, st *domain.PublicStatus) {
+	var buf bytes.Buffer
+	if err := statusPage.Execute(&buf, struct {
+		Title  string
+		Status *domain.PublicStatus
+	}{title, st}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(code, "text/html; charset=utf-8", buf.Bytes())
+}
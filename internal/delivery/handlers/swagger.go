@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"DelayedNotifier/api"
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerSpecPath путь спецификации внутри /swagger.
+const swaggerSpecPath = "openapi.json"
+
+// swaggerPage страница Swagger UI; сам интерфейс загружается с CDN, спецификация — из сервиса.
+const swaggerPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>DelayedNotifier API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>window.ui = SwaggerUIBundle({url: "` + swaggerSpecPath + `", dom_id: "#swagger-ui"});</script>
+</body></html>
+`
+
+// SwaggerHandler отдает описание API: /swagger/openapi.json — спецификацию OpenAPI 3,
+// /swagger/ и /swagger/index.html — Swagger UI. Маршрут должен заканчиваться на /*any.
+func SwaggerHandler(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("any"), "/") {
+	case swaggerSpecPath:
+		c.Data(http.StatusOK, "application/json; charset=utf-8", api.OpenAPI)
+	case "", "index.html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerPage))
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	}
+}
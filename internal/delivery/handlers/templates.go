@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// TemplateRequest тело POST /templates и PUT /templates/:id.
+type TemplateRequest struct {
+	Name string `json:"name" validate:"required,max=128"`
+	// Subject шаблон темы, необязателен
+	Subject string `json:"subject"`
+	// Body шаблон текста в синтаксисе text/template: "Здравствуйте, {{.name}}"
+	Body string `json:"body" validate:"required"`
+}
+
+// TemplateResponse шаблон уведомления.
+type TemplateResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject,omitempty"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toTemplateResponse(t *domain.Template) TemplateResponse {
+	return TemplateResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Subject:   t.Subject,
+		Body:      t.Body,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+// CreateTemplateHandler создает шаблон после проверки синтаксиса.
+func (h *Handler) CreateTemplateHandler(c *gin.Context) {
+	params, ok := bindTemplateRequest(c)
+	if !ok {
+		return
+	}
+	t, err := h.service.CreateTemplate(c.Request.Context(), params)
+	if err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toTemplateResponse(t)})
+}
+
+// ListTemplatesHandler возвращает все шаблоны в порядке имен.
+func (h *Handler) ListTemplatesHandler(c *gin.Context) {
+	ts, err := h.service.ListTemplates(c.Request.Context())
+	if err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+	resp := make([]TemplateResponse, 0, len(ts))
+	for i := range ts {
+		resp = append(resp, toTemplateResponse(&ts[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"result": resp})
+}
+
+// GetTemplateHandler возвращает шаблон по id.
+func (h *Handler) GetTemplateHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+	t, err := h.service.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toTemplateResponse(t)})
+}
+
+// UpdateTemplateHandler заменяет шаблон; запланированные уведомления получат новый текст при отправке.
+func (h *Handler) UpdateTemplateHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+	params, ok := bindTemplateRequest(c)
+	if !ok {
+		return
+	}
+	t, err := h.service.UpdateTemplate(c.Request.Context(), id, params)
+	if err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": toTemplateResponse(t)})
+}
+
+// DeleteTemplateHandler удаляет шаблон, который не ждут неотправленные уведомления.
+func (h *Handler) DeleteTemplateHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is invalid"})
+		return
+	}
+	if err := h.service.DeleteTemplate(c.Request.Context(), id); err != nil {
+		writeTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": id.String() + " deleted"})
+}
+
+func bindTemplateRequest(c *gin.Context) (domain.TemplateParams, bool) {
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+		return domain.TemplateParams{}, false
+	}
+	if err := validate.Struct(req); err != nil {
+		errorsMap := make(map[string]string)
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			for _, e := range verrs {
+				errorsMap[e.Field()] = validationMessage(e)
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Ошибка валидации", "errors": errorsMap})
+		return domain.TemplateParams{}, false
+	}
+	return domain.TemplateParams{Name: req.Name, Subject: req.Subject, Body: req.Body}, true
+}
+
+func writeTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Ошибка валидации",
+			"errors":  map[string]string{"Template": err.Error()},
+		})
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDuplicateTemplateName), errors.Is(err, domain.ErrTemplateInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTemplatesDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDebugBodySize максимальный размер сохраняемого тела запроса или ответа.
+const maxDebugBodySize = 4 << 10
+
+// maxDebugCaptureSize сколько тела читается для очистки: очищается все тело целиком и только
+// потом обрезается. Тело больше этого размера не сохраняется.
+const maxDebugCaptureSize = 1 << 20
+
+// redacted значение, которым заменяются чувствительные данные.
+const redacted = "***"
+
+// sensitiveKeys подстроки имен полей и заголовков, значения которых не сохраняются.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "apikey", "api_key", "admin-key"}
+
+// DebugEntry сохраненные запрос и ответ.
+type DebugEntry struct {
+	Time         time.Time         `json:"time"`
+	RequestID    string            `json:"request_id"`
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	Query        string            `json:"query,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	RequestBody  string            `json:"request_body,omitempty"`
+	Status       int               `json:"status"`
+	ResponseBody string            `json:"response_body,omitempty"`
+	Duration     string            `json:"duration"`
+}
+
+// DebugRecorder сохраняет очищенные тела запросов и ответов в кольцевой буфер
+// для выборки запросов (sampleRate) или по заголовку X-Debug с ключом администратора.
+type DebugRecorder struct {
+	mu         sync.Mutex
+	entries    []DebugEntry
+	next       int
+	full       bool
+	sampleRate float64
+	adminKey   string
+}
+
+// NewDebugRecorder создает буфер на size записей.
+func NewDebugRecorder(size int, sampleRate float64, adminKey string) *DebugRecorder {
+	if size <= 0 {
+		size = 1
+	}
+	return &DebugRecorder{
+		entries:    make([]DebugEntry, size),
+		sampleRate: sampleRate,
+		adminKey:   adminKey,
+	}
+}
+
+// Middleware сохраняет запрос и ответ, если запрос попал в выборку.
+func (r *DebugRecorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !r.shouldRecord(c) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var reqBody []byte
+		if c.Request.Body != nil {
+			// читаем не больше лимита, обработчик получает тело целиком: прочитанное и остаток
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxDebugCaptureSize+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+		}
+		writer := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		requestID, _ := c.Get("request_id")
+		id, _ := requestID.(string)
+		r.add(DebugEntry{
+			Time:         start,
+			RequestID:    id,
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.Path,
+			Query:        c.Request.URL.RawQuery,
+			Headers:      sanitizeHeaders(c.Request.Header),
+			RequestBody:  sanitizeBody(reqBody, len(reqBody) > maxDebugCaptureSize),
+			Status:       c.Writer.Status(),
+			ResponseBody: sanitizeBody(writer.body.Bytes(), writer.overflow),
+			Duration:     time.Since(start).String(),
+		})
+	}
+}
+
+// Entries возвращает сохраненные записи, начиная с самой новой.
+func (r *DebugRecorder) Entries() []DebugEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.next
+	if r.full {
+		n = len(r.entries)
+	}
+	result := make([]DebugEntry, 0, n)
+	for i := 1; i <= n; i++ {
+		result = append(result, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return result
+}
+
+// Handler отдает сохраненные записи, предназначен для /admin/debug/requests.
+func (r *DebugRecorder) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": r.Entries()})
+	}
+}
+
+func (r *DebugRecorder) shouldRecord(c *gin.Context) bool {
+	if c.GetHeader("X-Debug") != "" && r.adminKey != "" &&
+		subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Key")), []byte(r.adminKey)) == 1 {
+		return true
+	}
+	return r.sampleRate > 0 && rand.Float64() < r.sampleRate
+}
+
+func (r *DebugRecorder) add(e DebugEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// bodyWriter копирует тело ответа (не более maxDebugCaptureSize) для отладочного журнала.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	// overflow тело ответа больше maxDebugCaptureSize
+	overflow bool
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(b) > maxDebugCaptureSize {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, key := range sensitiveKeys {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeHeaders(h http.Header) map[string]string {
+	result := make(map[string]string, len(h))
+	for name := range h {
+		if isSensitive(name) {
+			result[name] = redacted
+			continue
+		}
+		result[name] = h.Get(name)
+	}
+	return result
+}
+
+// sanitizeBody скрывает значения чувствительных полей JSON и обрезает уже очищенное тело.
+// Тело, которое не разбирается как JSON (в том числе прочитанное не целиком), скрывается полностью:
+// найти в нем чувствительные поля нельзя.
+func sanitizeBody(body []byte, overflow bool) string {
+	if overflow {
+		return redacted + " (body is too large)"
+	}
+	if len(body) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("%s (%d bytes, not JSON)", redacted, len(body))
+	}
+	body, err := json.Marshal(sanitizeValue(v))
+	if err != nil {
+		return redacted
+	}
+	if len(body) > maxDebugBodySize {
+		return string(body[:maxDebugBodySize]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func sanitizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if isSensitive(k) {
+				val[k] = redacted
+				continue
+			}
+			val[k] = sanitizeValue(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = sanitizeValue(item)
+		}
+		return val
+	case string:
+		return sanitizeEmbedded(val)
+	default:
+		return v
+	}
+}
+
+// sanitizeEmbedded очищает JSON, переданный строкой (payload уведомления). Строка, которая
+// похожа на объект или массив, но не разбирается, скрывается целиком.
+func sanitizeEmbedded(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return s
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return redacted
+	}
+	data, err := json.Marshal(sanitizeValue(v))
+	if err != nil {
+		return redacted
+	}
+	return string(data)
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// DefaultMaintenanceMessage сообщение ответа 503, если при включении режима оно не задано.
+const DefaultMaintenanceMessage = "service is in read-only maintenance mode, try again later"
+
+// maintenanceRetryAfter значение Retry-After в ответе 503 в режиме обслуживания.
+const maintenanceRetryAfter = 30 * time.Second
+
+// MaintenanceState состояние режима обслуживания (GET и PUT /admin/maintenance).
+type MaintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// Maintenance режим только для чтения: пока он включен, GET, HEAD и OPTIONS обрабатываются
+// как обычно, а остальные запросы отклоняются с 503. Состояние хранится в памяти экземпляра.
+type Maintenance struct {
+	mu    sync.RWMutex
+	state MaintenanceState
+	// exempt маршруты (FullPath), которые работают и в режиме обслуживания
+	exempt map[string]bool
+}
+
+// NewMaintenance создает режим обслуживания, включенный сразу, если enabled.
+// exempt — маршруты gin, изменения через которые разрешены всегда, например сам переключатель.
+func NewMaintenance(enabled bool, message string, exempt ...string) *Maintenance {
+	m := &Maintenance{exempt: make(map[string]bool, len(exempt))}
+	for _, p := range exempt {
+		m.exempt[p] = true
+	}
+	m.Set(enabled, message)
+	return m
+}
+
+// Set включает или выключает режим; пустое message — DefaultMaintenanceMessage.
+func (m *Maintenance) Set(enabled bool, message string) MaintenanceState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !enabled {
+		m.state = MaintenanceState{}
+		return m.state
+	}
+	if message == "" {
+		message = DefaultMaintenanceMessage
+	}
+	since := m.state.Since
+	if since == nil {
+		now := time.Now().UTC()
+		since = &now
+	}
+	m.state = MaintenanceState{Enabled: true, Message: message, Since: since}
+	return m.state
+}
+
+// State возвращает текущее состояние режима.
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Middleware отклоняет изменяющие запросы, пока режим включен.
+func (m *Maintenance) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		st := m.State()
+		if !st.Enabled || m.exempt[c.FullPath()] {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": st.Message, "maintenance": true})
+	}
+}
+
+// maintenanceRequest тело PUT /admin/maintenance.
+type maintenanceRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// Handler возвращает текущее состояние режима (GET).
+func (m *Maintenance) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": m.State()})
+	}
+}
+
+// UpdateHandler включает или выключает режим (PUT с {"enabled": true, "message": "..."}).
+func (m *Maintenance) UpdateHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req maintenanceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректный JSON: " + err.Error()})
+			return
+		}
+		if req.Enabled == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Ошибка валидации",
+				"errors":  map[string]string{"Enabled": "обязательное поле"},
+			})
+			return
+		}
+		st := m.Set(*req.Enabled, req.Message)
+		zlog.Logger.Warn().Bool("enabled", st.Enabled).Str("message", st.Message).
+			Str("request_id", c.GetString("request_id")).Msg("maintenance mode changed")
+		c.JSON(http.StatusOK, gin.H{"result": st})
+	}
+}
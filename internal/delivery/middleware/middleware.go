@@ -1,8 +1,14 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
 	"time"
 
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/wb-go/wbf/zlog"
@@ -17,6 +23,7 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		}
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
@@ -87,3 +94,69 @@ func LoggingMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// ActorMiddleware помечает запрос инициатором actor для истории статусов уведомлений.
+func ActorMiddleware(actor domain.Actor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// AdminAuthMiddleware пропускает запрос только при совпадении заголовка X-Admin-Key
+// с ключом из конфигурации. Пустой ключ полностью отключает административный API.
+func AdminAuthMiddleware(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin api is disabled"})
+			return
+		}
+		key := c.GetHeader("X-Admin-Key")
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin key"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// problemDetails тело ответа об ошибке в формате RFC 7807 (application/problem+json).
+type problemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// RecoveryMiddleware перехватывает панику в обработчике, логирует ее со стеком
+// и отвечает 500 в формате problem+json с ID запроса.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			requestID := c.GetString("request_id")
+			zlog.Logger.Error().
+				Str("request_id", requestID).
+				Str("method", c.Request.Method).
+				Str("path", c.Request.URL.Path).
+				Interface("panic", rec).
+				Bytes("stack", debug.Stack()).
+				Msg("HTTP handler panic recovered")
+
+			body, _ := json.Marshal(problemDetails{
+				Type:      "about:blank",
+				Title:     http.StatusText(http.StatusInternalServerError),
+				Status:    http.StatusInternalServerError,
+				Detail:    "internal server error",
+				RequestID: requestID,
+			})
+			c.Abort()
+			c.Data(http.StatusInternalServerError, "application/problem+json", body)
+		}()
+		c.Next()
+	}
+}
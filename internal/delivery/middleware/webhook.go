@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBody наибольшее тело вебхука, которое читается для проверки подписи.
+const maxWebhookBody = 1 << 20
+
+// WebhookSignature возвращает подпись вебхука: HMAC-SHA256 в hex от "<timestamp>.<тело>".
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSignatureMiddleware пропускает вебхук провайдера, только если X-Signature совпадает с
+// WebhookSignature ключом secret, а X-Signature-Timestamp (unix, секунды) отличается от текущего
+// времени не больше чем на tolerance: перехваченный запрос нельзя повторить позже.
+// Пустой secret отключает проверку.
+func WebhookSignatureMiddleware(secret string, tolerance time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" {
+			c.Next()
+			return
+		}
+		timestamp := c.GetHeader("X-Signature-Timestamp")
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature timestamp"})
+			return
+		}
+		if skew := time.Since(time.Unix(sec, 0)); skew > tolerance || skew < -tolerance {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "signature timestamp is outside the window"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "webhook body is too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		got := strings.TrimPrefix(c.GetHeader("X-Signature"), "sha256=")
+		want := WebhookSignature(secret, timestamp, body)
+		if !hmac.Equal([]byte(got), []byte(want)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+		c.Next()
+	}
+}
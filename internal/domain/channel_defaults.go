@@ -0,0 +1,41 @@
+package domain
+
+// Параметры отправки в payload: отправщики читают их отдельно и не включают в текст сообщения.
+const (
+	// PayloadFromName имя отправителя письма (email)
+	PayloadFromName = "from_name"
+	// PayloadParseMode режим разметки сообщения: HTML, MarkdownV2 (telegram)
+	PayloadParseMode = "parse_mode"
+	// PayloadSenderID буквенное имя или номер отправителя SMS вместо номера по умолчанию (sms)
+	PayloadSenderID = "sender_id"
+)
+
+// IsSendOption сообщает, является ли ключ payload параметром отправки, а не содержимым.
+func IsSendOption(key string) bool {
+	switch key {
+	case PayloadFromName, PayloadParseMode, PayloadSenderID:
+		return true
+	}
+	return false
+}
+
+// ChannelDefaults значения payload по умолчанию для каналов: подставляются в payload
+// уведомления, если в нем нет такого ключа.
+type ChannelDefaults map[Channel]map[string]interface{}
+
+// Apply возвращает payload с подставленными значениями по умолчанию канала ch.
+// Значения самого payload имеют приоритет; исходный payload не изменяется.
+func (d ChannelDefaults) Apply(ch Channel, payload map[string]interface{}) map[string]interface{} {
+	defaults := d[ch]
+	if len(defaults) == 0 {
+		return payload
+	}
+	merged := make(map[string]interface{}, len(defaults)+len(payload))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range payload {
+		merged[k] = v
+	}
+	return merged
+}
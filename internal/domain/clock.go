@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Clock источник текущего времени. Сервис, воркеры и отправщики берут время из него,
+// а не из time.Now, чтобы планирование проверялось с фиксированным или сдвигаемым временем.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock системное время.
+type SystemClock struct{}
+
+// Now возвращает time.Now().
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClockFunc адаптер функции к Clock.
+type ClockFunc func() time.Time
+
+// Now вызывает f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
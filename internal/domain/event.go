@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actor инициатор перехода статуса уведомления.
+type Actor string
+
+const (
+	// ActorAPI запрос клиента через HTTP API
+	ActorAPI Actor = "api"
+	// ActorAdmin запрос через административное API
+	ActorAdmin Actor = "admin"
+	// ActorWorker консьюмер очереди при отправке
+	ActorWorker Actor = "worker"
+	// ActorSystem фоновые задачи сервиса: восстановление зависших, повторная обработка
+	ActorSystem Actor = "system"
+)
+
+type (
+	actorKey           struct{}
+	transitionErrorKey struct{}
+	preconditionKey    struct{}
+)
+
+// Precondition условие, которому должна удовлетворять прочитанная версия уведомления,
+// чтобы запрос мог его изменить, например совпадение ETag из If-Match.
+type Precondition func(n *Notification) bool
+
+// WithActor возвращает контекст, переходы статусов в котором записываются от имени actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает инициатора из контекста, по умолчанию ActorSystem.
+func ActorFromContext(ctx context.Context) Actor {
+	if a, ok := ctx.Value(actorKey{}).(Actor); ok && a != "" {
+		return a
+	}
+	return ActorSystem
+}
+
+// WithTransitionError возвращает контекст, в котором следующий переход статуса записывается
+// с причиной err, например ошибкой провайдера при переводе в failed.
+func WithTransitionError(ctx context.Context, err error) context.Context {
+	return context.WithValue(ctx, transitionErrorKey{}, err)
+}
+
+// TransitionErrorFromContext возвращает текст причины перехода из контекста или пустую строку.
+func TransitionErrorFromContext(ctx context.Context) string {
+	if err, ok := ctx.Value(transitionErrorKey{}).(error); ok && err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// WithPrecondition возвращает контекст, изменения уведомления в котором выполняются, только если
+// уведомление удовлетворяет match и не меняется до записи; иначе ErrPreconditionFailed.
+func WithPrecondition(ctx context.Context, match Precondition) context.Context {
+	return context.WithValue(ctx, preconditionKey{}, match)
+}
+
+// PreconditionFromContext возвращает условие изменения из контекста или nil.
+func PreconditionFromContext(ctx context.Context) Precondition {
+	match, _ := ctx.Value(preconditionKey{}).(Precondition)
+	return match
+}
+
+// NotificationEvent запись истории статусов: переход FromStatus -> ToStatus.
+// У записи о создании FromStatus пустой.
+type NotificationEvent struct {
+	ID             int64
+	NotificationID uuid.UUID
+	FromStatus     Status
+	ToStatus       Status
+	Actor          Actor
+	// Error причина перехода, если она известна
+	Error string
+	At    time.Time
+}
+
+// EventRepository хранилище истории статусов уведомлений.
+type EventRepository interface {
+	// RecordEvent сохраняет переход, ID и At заполняет база
+	RecordEvent(ctx context.Context, e NotificationEvent) error
+	// ListEvents получает историю уведомления в порядке переходов
+	ListEvents(ctx context.Context, notificationID uuid.UUID) ([]NotificationEvent, error)
+}
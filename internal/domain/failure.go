@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationFailure запись о уведомлении, исчерпавшем предел попыток отправки (max_retries),
+// для последующего разбора.
+type NotificationFailure struct {
+	ID             int64
+	NotificationID uuid.UUID
+	// Error последняя ошибка отправки
+	Error string
+	// Attempts сколько попыток было сделано
+	Attempts    int
+	ScheduledAt time.Time
+	FailedAt    time.Time
+}
+
+// FailureRepository хранилище записей о неуспешных уведомлениях.
+type FailureRepository interface {
+	// RecordFailure сохраняет запись, ID и FailedAt заполняет база
+	RecordFailure(ctx context.Context, f NotificationFailure) (*NotificationFailure, error)
+	// ListFailures получает записи уведомления, последние первыми
+	ListFailures(ctx context.Context, notificationID uuid.UUID) ([]NotificationFailure, error)
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "github.com/google/uuid"
+
+// GroupCreateResult итог создания уведомления для нескольких получателей: идентификатор группы
+// и результат по каждому получателю.
+type GroupCreateResult struct {
+	GroupID uuid.UUID
+	// Recipients получатели без повторов в порядке запроса, Results[i] относится к Recipients[i]
+	Recipients []string
+	Results    []BatchCreateResult
+}
+
+// GroupStatus сводное состояние группы уведомлений, созданных одним запросом.
+type GroupStatus struct {
+	GroupID uuid.UUID
+	// ByStatus число уведомлений группы по статусам
+	ByStatus map[Status]int
+	// ByState число уведомлений по состояниям PublicState: scheduled, sent, failed, cancelled
+	ByState       map[PublicState]int
+	Notifications []Notification
+}
+
+// NewGroupStatus сводит состояние группы по ее уведомлениям.
+func NewGroupStatus(groupID uuid.UUID, ns []Notification) *GroupStatus {
+	g := &GroupStatus{
+		GroupID:       groupID,
+		ByStatus:      make(map[Status]int),
+		ByState:       make(map[PublicState]int),
+		Notifications: ns,
+	}
+	for i := range ns {
+		g.ByStatus[ns[i].Status]++
+		g.ByState[ns[i].Status.PublicState()]++
+	}
+	return g
+}
+
+// Total число уведомлений группы.
+func (g *GroupStatus) Total() int {
+	return len(g.Notifications)
+}
+
+// Completed сообщает, что ни одно уведомление группы больше не ожидает отправки.
+func (g *GroupStatus) Completed() bool {
+	return g.ByState[PublicStateScheduled] == 0
+}
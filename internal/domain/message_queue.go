@@ -2,13 +2,95 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// JobSchemaVersion версия формата сообщения очереди, которую публикует сервис.
+const JobSchemaVersion = 1
+
+// Job сообщение очереди: какое уведомление обработать. Сообщения без schema_version
+// ({"notification_id": "..."}) публиковались до появления версий и читаются как версия 0.
+type Job struct {
+	SchemaVersion  int    `json:"schema_version"`
+	NotificationID string `json:"notification_id"`
+}
+
+// NewJob создает сообщение текущей версии для уведомления id.
+func NewJob(id uuid.UUID) Job {
+	return Job{SchemaVersion: JobSchemaVersion, NotificationID: id.String()}
+}
+
+// Marshal возвращает тело сообщения для публикации.
+func (j Job) Marshal() ([]byte, error) {
+	return json.Marshal(j)
+}
+
+// ParseJob разбирает и проверяет тело сообщения очереди. Ошибки оборачивают ErrInvalidJob.
+func ParseJob(body []byte) (Job, uuid.UUID, error) {
+	var j Job
+	if err := json.Unmarshal(body, &j); err != nil {
+		return j, uuid.Nil, fmt.Errorf("%w: malformed JSON: %v", ErrInvalidJob, err)
+	}
+	if j.SchemaVersion < 0 || j.SchemaVersion > JobSchemaVersion {
+		return j, uuid.Nil, fmt.Errorf("%w: unsupported schema_version %d (supported up to %d)",
+			ErrInvalidJob, j.SchemaVersion, JobSchemaVersion)
+	}
+	if j.NotificationID == "" {
+		return j, uuid.Nil, fmt.Errorf("%w: notification_id is missing", ErrInvalidJob)
+	}
+	id, err := uuid.Parse(j.NotificationID)
+	if err != nil {
+		return j, uuid.Nil, fmt.Errorf("%w: notification_id %q is not a UUID", ErrInvalidJob, j.NotificationID)
+	}
+	return j, id, nil
+}
+
 // MessageQueuePublisher интерфейс для публикации сообщений в очередь.
 type MessageQueuePublisher interface {
 	// Publish публикует сообщение в очередь с указанным TTL
 	Publish(ctx context.Context, id uuid.UUID, ttl time.Duration) error
 }
+
+// MessageQueueRepublisher публикатор, который умеет заменить уже опубликованное сообщение уведомления.
+// Без него перенос публикует второе сообщение, а раннее отбрасывает консьюмер.
+type MessageQueueRepublisher interface {
+	// Republish удаляет ожидающее сообщение уведомления, если оно есть, и публикует новое с TTL
+	Republish(ctx context.Context, id uuid.UUID, ttl time.Duration) error
+}
+
+// MessageQueuePriorityPublisher публикатор, который отправляет уведомления приоритета high
+// через отдельную очередь со своими воркерами, чтобы срочные не ждали за массовыми рассылками.
+// Без него все уведомления идут через одну очередь.
+type MessageQueuePriorityPublisher interface {
+	// PublishPriority публикует сообщение в очередь приоритета p с указанным TTL
+	PublishPriority(ctx context.Context, id uuid.UUID, p Priority, ttl time.Duration) error
+	// RepublishPriority заменяет ожидающее сообщение уведомления, как Republish
+	RepublishPriority(ctx context.Context, id uuid.UUID, p Priority, ttl time.Duration) error
+}
+
+// TopologyManager интерфейс для синхронизации топологии брокера сообщений
+// (exchange, очереди, DLX-привязки).
+type TopologyManager interface {
+	// Sync идемпотентно объявляет все необходимые exchange, очереди и привязки
+	Sync(ctx context.Context) (TopologyReport, error)
+	// Check проверяет наличие топологии, ничего не создавая
+	Check(ctx context.Context) (TopologyReport, error)
+}
+
+// TopologyItem состояние одного элемента топологии.
+type TopologyItem struct {
+	Kind  string
+	Name  string
+	State string
+	Error string
+}
+
+// TopologyReport результат синхронизации или проверки топологии.
+type TopologyReport struct {
+	OK    bool
+	Items []TopologyItem
+}
@@ -0,0 +1,10 @@
+package domain
+
+// Namespaced добавляет к имени exchange, очереди или ключа Redis префикс окружения
+// ("staging" -> "staging.notification"). Пустое пространство имен оставляет имя как есть.
+func Namespaced(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
@@ -12,16 +12,195 @@ type NotificationService interface {
 	// CreateNotification создает новое уведомление
 	CreateNotification(ctx context.Context,
 		params CreateNotificationParams) (*Notification, error)
+	// CreateNotifications проверяет каждое уведомление пакета так же, как CreateNotification,
+	// создает прошедшие проверку в одной транзакции и публикует их; результат по каждому
+	// элементу в порядке params. Ошибка возвращается, если пакет не принят целиком
+	CreateNotifications(ctx context.Context, params []CreateNotificationParams) ([]BatchCreateResult, error)
+	// CloneNotification создает новое уведомление с payload и каналом исходного,
+	// заменяя получателя и время отправки, если они заданы
+	CloneNotification(ctx context.Context, id uuid.UUID, params CloneNotificationParams) (*Notification, error)
+	// GetRelatedNotifications получает все уведомления цепочки, к которой относится id,
+	// начиная с корня
+	GetRelatedNotifications(ctx context.Context, id uuid.UUID) ([]Notification, error)
+	// CreateGroup создает по уведомлению с параметрами params на каждого получателя из recipients,
+	// связывая их общим GroupID; получатели проверяются по отдельности, как элементы CreateNotifications
+	CreateGroup(ctx context.Context, params CreateNotificationParams, recipients []string) (*GroupCreateResult, error)
+	// GroupStatus возвращает сводное состояние группы; ErrNotFound, если в группе нет уведомлений
+	GroupStatus(ctx context.Context, groupID uuid.UUID) (*GroupStatus, error)
 	// UpdateNotification обновляет уведомление с указанными параметрами
 	UpdateNotification(ctx context.Context, n *Notification, opts ...UpdateOption) error
 	// GetNotificationByID получает уведомление по ID
 	GetNotificationByID(ctx context.Context, id uuid.UUID) (*Notification, error)
-	// Cancel отменяет уведомление (статус pending -> cancelled)
+	// RefreshNotificationByID получает уведомление из базы в обход кеша и обновляет кеш
+	RefreshNotificationByID(ctx context.Context, id uuid.UUID) (*Notification, error)
+	// PreviewNotification строит сообщение в том виде, в котором оно будет отправлено
+	PreviewNotification(ctx context.Context, id uuid.UUID) (*RenderedMessage, error)
+	// ValidatePayload повторно проверяет payload уведомления по правилам его канала;
+	// ошибка оборачивает ErrInvalidPayload
+	ValidatePayload(n *Notification) error
+	// DeferNotification откладывает отправку на delay: уведомление возвращается в pending
+	// с новым effective_scheduled_at и публикуется повторно; счетчик попыток не меняется
+	DeferNotification(ctx context.Context, n *Notification, delay time.Duration) error
+	// Cancel отменяет уведомление (статус pending или draft -> cancelled)
 	Cancel(ctx context.Context, id uuid.UUID) error
+	// Confirm принимает подтверждение для уведомления с CancelOnConfirm и отменяет его,
+	// если время отправки еще не наступило; повторное подтверждение не является ошибкой
+	Confirm(ctx context.Context, id uuid.UUID) error
+	// Approve одобряет уведомление с RequiresApproval от имени approver и публикует его в очередь;
+	// повторное одобрение возвращает уведомление без изменений
+	Approve(ctx context.Context, id uuid.UUID, approver string) (*Notification, error)
+	// UpdateDraft изменяет черновик; для остальных статусов ErrNotDraft
+	UpdateDraft(ctx context.Context, id uuid.UUID, params DraftUpdateParams) (*Notification, error)
+	// ScheduleDraft проверяет время отправки черновика и публикует его в очередь
+	// (или переводит в awaiting_approval, если нужно одобрение)
+	ScheduleDraft(ctx context.Context, id uuid.UUID, params ScheduleParams) (*Notification, error)
+	// RecordReceipt отмечает отправленное уведомление доставленным, прочитанным или возвращенным
+	// по квитанции канала; повторная квитанция с тем же статусом ничего не меняет. Непустой eventID
+	// защищает от повторной доставки вебхука: уже обработанное событие возвращает ErrDuplicateEvent
+	RecordReceipt(ctx context.Context, id uuid.UUID, status Status, eventID string) (*Notification, error)
+	// SnoozeRecipient приостанавливает отправку получателю в канале на d и возвращает окончание паузы;
+	// уведомления, срок которых наступает во время паузы, переносятся на ее окончание
+	SnoozeRecipient(ctx context.Context, ch Channel, recipient string, d time.Duration) (time.Time, error)
+	// UnsnoozeRecipient снимает паузу получателя
+	UnsnoozeRecipient(ctx context.Context, ch Channel, recipient string) error
+	// ClaimSend закрепляет отправку уведомления за вызывающим: pending переводится в processing
+	// условным обновлением, на время отправки берется аренда. false — уведомление уже не ждет
+	// отправки или его отправляет другой обработчик; release нужно вызвать после обработки
+	ClaimSend(ctx context.Context, n *Notification) (release func(), ok bool, err error)
+	// RecipientSnoozedUntil возвращает окончание паузы получателя; false, если паузы нет
+	RecipientSnoozedUntil(ctx context.Context, ch Channel, recipient string) (time.Time, bool, error)
+	// PreviewSchedule рассчитывает, когда система отправила бы уведомление с такими параметрами,
+	// ничего не создавая: те же проверки времени, сглаживание и пауза получателя
+	PreviewSchedule(ctx context.Context, params SchedulePreviewParams) (*SchedulePreview, error)
+	// StatusLink выдает подписанный токен публичной страницы статуса уведомления, действующий ttl
+	// (0 — срок по умолчанию)
+	StatusLink(ctx context.Context, id uuid.UUID, ttl time.Duration) (*StatusLink, error)
+	// PublicStatus проверяет подпись и срок токена и возвращает сведения для страницы статуса
+	PublicStatus(ctx context.Context, token string) (*PublicStatus, error)
+	// RescheduleNotifications переносит ожидающие уведомления по фильтру в одной транзакции
+	// и заново публикует их в очередь
+	RescheduleNotifications(ctx context.Context, params RescheduleParams) (RescheduleResult, error)
 	// Failed помечает уведомление как неуспешное (статус processing -> failed)
 	Failed(ctx context.Context, id uuid.UUID) error
+	// Bounced помечает уведомление, окончательно отвергнутое провайдером (статус processing -> bounced)
+	Bounced(ctx context.Context, id uuid.UUID) error
 	// IncRetryCount увеличивает счетчик попыток для уведомления
 	IncRetryCount(ctx context.Context, n *Notification) error
+	// RetriesExhausted сообщает, исчерпало ли уведомление предел попыток отправки (max_retries)
+	RetriesExhausted(n *Notification) bool
+	// FailExhausted помечает уведомление, исчерпавшее попытки, как failed и сохраняет
+	// последнюю ошибку и число попыток в журнал неуспешных
+	FailExhausted(ctx context.Context, n *Notification, cause error) error
+	// ListFailures возвращает записи журнала неуспешных для уведомления
+	ListFailures(ctx context.Context, id uuid.UUID) ([]NotificationFailure, error)
+	// History возвращает историю статусов уведомления в порядке переходов
+	History(ctx context.Context, id uuid.UUID) ([]NotificationEvent, error)
+	// Delete физически удаляет уведомление из базы и кеша (в отличие от Cancel)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// SoftDelete помечает уведомление удаленным и убирает его из кеша
+	SoftDelete(ctx context.Context, id uuid.UUID) error
+	// GetNotificationByIDWithDeleted получает уведомление из базы, включая мягко удаленные
+	GetNotificationByIDWithDeleted(ctx context.Context, id uuid.UUID) (*Notification, error)
+	// WarmCache загружает в кеш ожидающие уведомления, запланированные на ближайшие horizon,
+	// не более limit штук; возвращает количество загруженных
+	WarmCache(ctx context.Context, horizon time.Duration, limit int) (int, error)
+	// ReprocessFailed возвращает в отправку неуспешные уведомления, пролежавшие в failed дольше after,
+	// если они возвращались меньше maxCycles раз; обрабатывает не более limit штук,
+	// возвращает количество переотправленных
+	ReprocessFailed(ctx context.Context, after time.Duration, maxCycles, limit int) (int, error)
+	// ReconcileOrphans находит уведомления, сообщение которых так и не дошло до консьюмера
+	// (очередь истекла, публикация не удалась): pending, срок отправки которых прошел больше grace назад,
+	// заново публикуются, а старше maxAge — истекают; processing без обновлений — завершаются failed.
+	// Обрабатывает одну страницу не более limit штук после after (nil — с начала);
+	// продолжение — OrphanReport.Next
+	ReconcileOrphans(ctx context.Context, grace, maxAge time.Duration, after *OrphanCursor,
+		limit int) (OrphanReport, error)
+	// CountStaleProcessing считает уведомления, зависшие в processing дольше olderThan
+	CountStaleProcessing(ctx context.Context, olderThan time.Duration) (int, error)
+	// PurgeDeleted физически удаляет уведомления, мягко удаленные раньше retention назад
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	// DuplicateReport находит вероятные повторные доставки за последние window:
+	// уведомления одному получателю с одинаковым payload, отправленные больше одного раза
+	DuplicateReport(ctx context.Context, window time.Duration, limit int) ([]DuplicateGroup, error)
+	// SearchNotifications ищет уведомления по тексту получателя, темы и payload и по фильтрам;
+	// нужна строка поиска или хотя бы один фильтр
+	SearchNotifications(ctx context.Context, params SearchParams) ([]SearchHit, error)
+	// ListNotifications возвращает страницу уведомлений по фильтрам, новые по scheduled_at первыми,
+	// и есть ли за ней еще уведомления
+	ListNotifications(ctx context.Context, filter ListFilter, limit, offset int) ([]Notification, bool, error)
+	// CountOutcomes считает по каналам успешные и неуспешные отправки, завершившиеся в [from, to)
+	CountOutcomes(ctx context.Context, from, to time.Time) (map[Channel]OutcomeCounts, error)
+	// SLACompliance оценивает соблюдение настроенных целей SLA за окно, заканчивающееся сейчас
+	SLACompliance(ctx context.Context) ([]SLAReport, error)
+	// SaveView проверяет фильтры и сохраняет их под именем name, заменяя прежние
+	SaveView(ctx context.Context, name string, filter ViewFilter) (*SavedView, error)
+	// ListViews возвращает сохраненные представления в порядке имен
+	ListViews(ctx context.Context) ([]SavedView, error)
+	// DeleteView удаляет сохраненное представление
+	DeleteView(ctx context.Context, name string) error
+	// RunView выполняет поиск по фильтрам сохраненного представления
+	RunView(ctx context.Context, name string) ([]SearchHit, error)
+	// InspectCache возвращает запись кэша уведомления как она лежит в Redis, с TTL
+	InspectCache(ctx context.Context, id uuid.UUID) (*CacheEntry, error)
+	// EvictCache удаляет запись кэша уведомления
+	EvictCache(ctx context.Context, id uuid.UUID) error
+	// FlushCache находит ключи кэша по шаблону, не больше limit, и удаляет их, если это не пробный запуск
+	FlushCache(ctx context.Context, pattern string, limit int, dryRun bool) (CacheFlushResult, error)
+	// CreateTemplate проверяет синтаксис шаблона и сохраняет его
+	CreateTemplate(ctx context.Context, params TemplateParams) (*Template, error)
+	// GetTemplate возвращает шаблон по id
+	GetTemplate(ctx context.Context, id uuid.UUID) (*Template, error)
+	// ListTemplates возвращает шаблоны в порядке имен
+	ListTemplates(ctx context.Context) ([]Template, error)
+	// UpdateTemplate заменяет шаблон; запланированные уведомления получат новый текст при отправке
+	UpdateTemplate(ctx context.Context, id uuid.UUID, params TemplateParams) (*Template, error)
+	// DeleteTemplate удаляет шаблон, если его не ждут неотправленные уведомления
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	// ResolveTemplate возвращает копию уведомления с subject и body из его шаблона,
+	// уведомление без шаблона — как есть
+	ResolveTemplate(ctx context.Context, n *Notification) (*Notification, error)
+	// SaveSchema регистрирует схему payload для источника и канала, заменяя прежнюю;
+	// ErrInvalidSchema, если схема не разбирается
+	SaveSchema(ctx context.Context, source string, ch Channel, schema map[string]interface{}) (*PayloadSchema, error)
+	// GetSchema возвращает схему payload источника и канала, ErrSchemaNotFound, если ее нет
+	GetSchema(ctx context.Context, source string, ch Channel) (*PayloadSchema, error)
+	// ListSchemas возвращает все схемы payload
+	ListSchemas(ctx context.Context) ([]PayloadSchema, error)
+	// DeleteSchema удаляет схему payload источника и канала
+	DeleteSchema(ctx context.Context, source string, ch Channel) error
+}
+
+// OrphanReport итог одного прохода сверки потерянных уведомлений.
+type OrphanReport struct {
+	// Found сколько найдено уведомлений без сообщения в очереди
+	Found int
+	// Republished pending, опубликованные заново
+	Republished int
+	// Expired pending старше допустимого, переведенные в expired
+	Expired int
+	// Failed зависшие processing, переведенные в failed (их подберет переотправка)
+	Failed int
+	// Next позиция следующей страницы, nil — страница последняя
+	Next *OrphanCursor
+}
+
+// RescheduleResult итог массового переноса.
+type RescheduleResult struct {
+	// Rescheduled сколько уведомлений перенесено
+	Rescheduled int
+	// Republished сколько из них опубликовано заново; остальные подберет сверка потерянных
+	Republished int
+	// IDs перенесенные уведомления в порядке отправки
+	IDs []uuid.UUID
+	// Truncated перенесено ровно Limit уведомлений, возможно, подходят и другие
+	Truncated bool
+}
+
+// OrphanCursor позиция постраничного обхода зависших уведомлений: последнее
+// обработанное по порядку (effective_scheduled_at, id).
+type OrphanCursor struct {
+	EffectiveScheduledAt time.Time
+	ID                   uuid.UUID
 }
 
 // CreateNotificationParams параметры для создания уведомления.
@@ -30,4 +209,90 @@ type CreateNotificationParams struct {
 	Channel     Channel
 	Payload     map[string]interface{}
 	ScheduledAt time.Time
+	// Immediate разрешает время отправки в прошлом: уведомление отправляется сразу
+	Immediate bool
+	// ParentID исходное уведомление; новое попадает в его цепочку
+	ParentID *uuid.UUID
+	// CancelOnConfirm отменить уведомление, если до ScheduledAt придет подтверждение
+	CancelOnConfirm bool
+	// PreSendCheck URL, который вызывается перед отправкой, см. PreSendChecker
+	PreSendCheck string
+	// Smooth разрешает сдвинуть отправку на случайное время внутри окна сглаживания,
+	// чтобы массовые рассылки на один момент не били по провайдерам одновременно
+	Smooth bool
+	// Source система-источник (имя сервиса) для статистики и метрик
+	Source string
+	// RequiresApproval не отправлять до одобрения (POST /notify/:id/approve)
+	RequiresApproval bool
+	// Priority приоритет при перегрузке: low отклоняется первым, high принимается всегда.
+	// Уведомления high отправляются через отдельную очередь. Пустой означает normal
+	Priority Priority
+	// Draft создать черновик: без проверки времени отправки и без публикации в очередь
+	Draft bool
+	// IdempotencyKey ключ идемпотентности: повторное создание с тем же ключом и Source
+	// возвращает уже созданное уведомление вместо нового
+	IdempotencyKey string
+	// GroupID группа уведомлений одного запроса; задается CreateGroup
+	GroupID *uuid.UUID
+	// TemplateID шаблон subject и body; Payload содержит его переменные и параметры отправки
+	TemplateID *uuid.UUID
+}
+
+// BatchCreateResult итог создания одного уведомления пакета: созданное уведомление или ошибка проверки.
+type BatchCreateResult struct {
+	Notification *Notification
+	Err          error
+}
+
+// DraftUpdateParams изменения черновика, nil поля не меняются.
+type DraftUpdateParams struct {
+	Recipient   *string
+	Channel     *Channel
+	Payload     map[string]interface{}
+	ScheduledAt *time.Time
+}
+
+// ScheduleParams параметры перевода черновика в отправку.
+type ScheduleParams struct {
+	// ScheduledAt новое время отправки, nil — время из черновика
+	ScheduledAt *time.Time
+	// Immediate разрешает время отправки в прошлом: уведомление отправляется сразу
+	Immediate bool
+	// Smooth см. CreateNotificationParams
+	Smooth bool
+}
+
+// SchedulePreviewParams параметры расчета времени отправки.
+type SchedulePreviewParams struct {
+	ScheduledAt time.Time
+	// Immediate и Smooth см. CreateNotificationParams
+	Immediate bool
+	Smooth    bool
+	// Channel и Recipient необязательны; если заданы, учитывается пауза получателя
+	Channel   Channel
+	Recipient string
+	// Location часовой пояс, в котором возвращаются времена; nil — как в ScheduledAt
+	Location *time.Location
+}
+
+// SchedulePreview время отправки, которое система использовала бы для уведомления.
+type SchedulePreview struct {
+	ScheduledAt time.Time
+	// EarliestAt и LatestAt границы effective_scheduled_at: при сглаживании момент отправки
+	// выбирается случайно внутри них, без сглаживания они совпадают
+	EarliestAt time.Time
+	LatestAt   time.Time
+	// SnoozedUntil окончание паузы получателя, на которое переносится отправка
+	SnoozedUntil *time.Time
+	// SmoothWindow окно сглаживания, которое было применено
+	SmoothWindow time.Duration
+}
+
+// CloneNotificationParams переопределения при клонировании уведомления.
+// Пустые поля берутся из исходного уведомления.
+type CloneNotificationParams struct {
+	Recipient   string
+	ScheduledAt *time.Time
+	// Immediate разрешает время отправки в прошлом: уведомление отправляется сразу
+	Immediate bool
 }
@@ -16,13 +16,36 @@ func (s Status) String() string {
 // IsValid проверяет, является ли статус валидным.
 func (s Status) IsValid() bool {
 	switch s {
-	case StatusPending, StatusProcessing, StatusSent, StatusFailed, StatusCancelled:
+	case StatusPending, StatusProcessing, StatusSent, StatusFailed, StatusCancelled, StatusAwaitingApproval,
+		StatusDelivered, StatusRead, StatusBounced, StatusExpired, StatusSuppressed, StatusDraft:
 		return true
 	default:
 		return false
 	}
 }
 
+// statusTransitions допустимые переходы между статусами. Повторная установка
+// того же статуса (например, перенос pending) переходом не считается.
+var statusTransitions = map[Status][]Status{
+	StatusDraft:            {StatusPending, StatusProcessing, StatusAwaitingApproval, StatusCancelled},
+	StatusAwaitingApproval: {StatusPending, StatusProcessing, StatusCancelled, StatusExpired},
+	StatusPending:          {StatusProcessing, StatusCancelled, StatusSuppressed, StatusExpired},
+	StatusProcessing:       {StatusPending, StatusSent, StatusFailed, StatusBounced, StatusSuppressed, StatusExpired},
+	StatusFailed:           {StatusProcessing, StatusExpired},
+	StatusSent:             {StatusDelivered, StatusRead, StatusBounced},
+	StatusDelivered:        {StatusRead},
+}
+
+// CanTransitionTo проверяет, допустим ли переход из статуса s в статус to.
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range statusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Channel string
 
 // String возвращает строковое представление канала.
@@ -33,7 +56,26 @@ func (c Channel) String() string {
 // IsValid проверяет, является ли канал валидным.
 func (c Channel) IsValid() bool {
 	switch c {
-	case ChannelEmail, ChannelTelegram:
+	case ChannelEmail, ChannelTelegram, ChannelSMS:
+		return true
+	default:
+		return false
+	}
+}
+
+// Priority приоритет уведомления: при приеме запросов под нагрузкой и при отправке —
+// уведомления high идут через отдельную очередь со своими воркерами.
+type Priority string
+
+// String возвращает строковое представление приоритета.
+func (p Priority) String() string {
+	return string(p)
+}
+
+// IsValid проверяет, является ли приоритет валидным.
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityHigh, PriorityNormal, PriorityLow:
 		return true
 	default:
 		return false
@@ -46,11 +88,32 @@ const (
 	StatusSent       Status = "sent"
 	StatusFailed     Status = "failed"
 	StatusCancelled  Status = "cancelled"
+	// StatusAwaitingApproval уведомление ждет одобрения и не публикуется в очередь
+	StatusAwaitingApproval Status = "awaiting_approval"
+	// StatusDelivered провайдер подтвердил доставку получателю
+	StatusDelivered Status = "delivered"
+	// StatusRead получатель прочитал уведомление
+	StatusRead Status = "read"
+	// StatusBounced провайдер окончательно отверг получателя (несуществующий адрес, DSN о недоставке)
+	StatusBounced Status = "bounced"
+	// StatusExpired время, когда уведомление имело смысл, прошло до успешной отправки
+	StatusExpired Status = "expired"
+	// StatusSuppressed отправка отклонена политикой (проверка перед отправкой), а не ошибкой
+	StatusSuppressed Status = "suppressed"
+	// StatusDraft черновик: редактируется и не публикуется до POST /notify/:id/schedule
+	StatusDraft Status = "draft"
 )
 
 const (
 	ChannelEmail    Channel = "email"
 	ChannelTelegram Channel = "telegram"
+	ChannelSMS      Channel = "sms"
+)
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
 )
 
 // Notification представляет структуру уведомления.
@@ -64,9 +127,47 @@ type Notification struct {
 	RetryCount  int
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+	// ParentID уведомление, из которого создано это (клон, повтор, эскалация)
+	ParentID *uuid.UUID
+	// CorrelationID корень цепочки связанных уведомлений, nil у самого корня
+	CorrelationID *uuid.UUID
+	// CancelOnConfirm уведомление отменяется, если до scheduled_at пришло подтверждение
+	CancelOnConfirm bool
+	// PreSendCheck URL проверки перед отправкой, пустой — без проверки
+	PreSendCheck string
+	// EffectiveScheduledAt фактическое время отправки с учетом сглаживания, иначе равно ScheduledAt
+	EffectiveScheduledAt time.Time
+	// Source система-источник (имя сервиса), создавшая уведомление
+	Source string
+	// ReprocessCount сколько раз неуспешное уведомление автоматически возвращалось в отправку
+	ReprocessCount int
+	// RequiresApproval уведомление отправляется только после одобрения
+	RequiresApproval bool
+	// ApprovedBy кто одобрил отправку, пустой — не одобрено
+	ApprovedBy string
+	// ApprovedAt когда одобрена отправка
+	ApprovedAt *time.Time
+	// GroupID группа уведомлений, созданных одним запросом для нескольких получателей
+	GroupID *uuid.UUID
+	// TemplateID шаблон subject и body; Payload в этом случае содержит переменные шаблона
+	TemplateID *uuid.UUID
+	// Priority приоритет отправки, пустой означает normal (см. EffectivePriority)
+	Priority Priority
 }
 
-// Job представляет структуру задачи для обработки уведомлений.
-type Job struct {
-	NotificationID string `json:"notification_id"`
+// EffectivePriority возвращает приоритет уведомления; пустой (записи из кэша до появления поля) — normal.
+func (n *Notification) EffectivePriority() Priority {
+	if n.Priority == "" {
+		return PriorityNormal
+	}
+	return n.Priority
+}
+
+// RootID возвращает корень цепочки связанных уведомлений.
+func (n *Notification) RootID() uuid.UUID {
+	if n.CorrelationID != nil {
+		return *n.CorrelationID
+	}
+	return n.ID
 }
@@ -0,0 +1,22 @@
+package domain
+
+import "errors"
+
+// ErrInvalidPayload ошибка содержимого payload, недопустимого для канала.
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// PayloadValidator проверяет payload уведомления для конкретного канала. Проверка выполняется
+// при создании и повторно перед отправкой: payload мог быть сохранен до ужесточения правил.
+// Реализации располагаются рядом с отправщиками соответствующих каналов.
+type PayloadValidator interface {
+	// ValidatePayload возвращает ошибку, обернутую в ErrInvalidPayload, если payload недопустим
+	ValidatePayload(payload map[string]interface{}) error
+}
+
+// PayloadValidatorFunc функция, реализующая PayloadValidator.
+type PayloadValidatorFunc func(payload map[string]interface{}) error
+
+// ValidatePayload вызывает f(payload).
+func (f PayloadValidatorFunc) ValidatePayload(payload map[string]interface{}) error {
+	return f(payload)
+}
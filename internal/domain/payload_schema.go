@@ -0,0 +1,351 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// PayloadSchema контракт payload уведомлений, которые источник Source создает в канале Channel.
+// Уведомление, не подходящее под схему, отклоняется при создании, а не падает при отправке.
+type PayloadSchema struct {
+	Source  string
+	Channel Channel
+	// Schema документ JSON Schema (поддерживаемое подмножество, см. CompileSchema)
+	Schema    map[string]interface{}
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SchemaRepository хранилище схем payload.
+type SchemaRepository interface {
+	// SaveSchema создает схему пары источник-канал или заменяет существующую
+	SaveSchema(ctx context.Context, source string, ch Channel, schema map[string]interface{}) (*PayloadSchema, error)
+	// GetSchema получает схему пары, ErrSchemaNotFound, если ее нет
+	GetSchema(ctx context.Context, source string, ch Channel) (*PayloadSchema, error)
+	// ListSchemas получает все схемы в порядке источников и каналов
+	ListSchemas(ctx context.Context) ([]PayloadSchema, error)
+	// DeleteSchema удаляет схему пары, ErrSchemaNotFound, если ее нет
+	DeleteSchema(ctx context.Context, source string, ch Channel) error
+}
+
+// JSONSchema разобранная схема payload. Поддерживается подмножество JSON Schema, которого хватает
+// для контракта payload: type, enum, const, required, properties, additionalProperties, items,
+// minLength, maxLength, pattern (синтаксис RE2), minimum, maximum, minItems, maxItems.
+// Аннотации ($schema, $id, title, description, default, examples) пропускаются, остальные ключевые
+// слова — ошибка: схема, часть которой молча не проверяется, хуже отказа при регистрации.
+type JSONSchema struct {
+	types        []string
+	enum         []interface{}
+	constant     interface{}
+	hasConst     bool
+	required     []string
+	properties   map[string]*JSONSchema
+	additional   *JSONSchema
+	noAdditional bool
+	items        *JSONSchema
+	minLength    *int
+	maxLength    *int
+	pattern      *regexp.Regexp
+	minimum      *float64
+	maximum      *float64
+	minItems     *int
+	maxItems     *int
+}
+
+var schemaAnnotations = []string{"$schema", "$id", "$comment", "title", "description", "default", "examples"}
+
+var schemaTypes = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+// CompileSchema разбирает документ JSON Schema; ошибка оборачивает ErrInvalidSchema и указывает место.
+func CompileSchema(doc map[string]interface{}) (*JSONSchema, error) {
+	return compileSchema(doc, "schema")
+}
+
+func compileSchema(doc map[string]interface{}, path string) (*JSONSchema, error) {
+	s := &JSONSchema{}
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		raw := doc[key]
+		at := path + "." + key
+		var err error
+		switch key {
+		case "type":
+			s.types, err = compileTypes(raw)
+		case "enum":
+			values, ok := raw.([]interface{})
+			if !ok || len(values) == 0 {
+				err = fmt.Errorf("must be a non-empty array")
+			}
+			s.enum = values
+		case "const":
+			s.constant, s.hasConst = raw, true
+		case "required":
+			s.required, err = stringList(raw)
+		case "properties":
+			props, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: must be an object", ErrInvalidSchema, at)
+			}
+			s.properties = make(map[string]*JSONSchema, len(props))
+			for name, sub := range props {
+				if s.properties[name], err = compileSubschema(sub, at+"."+name); err != nil {
+					return nil, err
+				}
+			}
+		case "additionalProperties":
+			if allowed, ok := raw.(bool); ok {
+				s.noAdditional = !allowed
+				break
+			}
+			if s.additional, err = compileSubschema(raw, at); err != nil {
+				return nil, err
+			}
+		case "items":
+			if s.items, err = compileSubschema(raw, at); err != nil {
+				return nil, err
+			}
+		case "minLength":
+			s.minLength, err = nonNegativeInt(raw)
+		case "maxLength":
+			s.maxLength, err = nonNegativeInt(raw)
+		case "minItems":
+			s.minItems, err = nonNegativeInt(raw)
+		case "maxItems":
+			s.maxItems, err = nonNegativeInt(raw)
+		case "minimum":
+			s.minimum, err = number(raw)
+		case "maximum":
+			s.maximum, err = number(raw)
+		case "pattern":
+			str, ok := raw.(string)
+			if !ok {
+				err = fmt.Errorf("must be a string")
+				break
+			}
+			s.pattern, err = regexp.Compile(str)
+		default:
+			if !slices.Contains(schemaAnnotations, key) {
+				err = fmt.Errorf("keyword is not supported")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSchema, at, err)
+		}
+	}
+	return s, nil
+}
+
+func compileSubschema(raw interface{}, path string) (*JSONSchema, error) {
+	doc, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: must be an object", ErrInvalidSchema, path)
+	}
+	return compileSchema(doc, path)
+}
+
+func compileTypes(raw interface{}) ([]string, error) {
+	if str, ok := raw.(string); ok {
+		raw = []interface{}{str}
+	}
+	types, err := stringList(raw)
+	if err != nil || len(types) == 0 {
+		return nil, fmt.Errorf("must be a type name or an array of them")
+	}
+	for _, t := range types {
+		if !slices.Contains(schemaTypes, t) {
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+	}
+	return types, nil
+}
+
+func stringList(raw interface{}) ([]string, error) {
+	values, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be an array of strings")
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+		out = append(out, str)
+	}
+	return out, nil
+}
+
+func nonNegativeInt(raw interface{}) (*int, error) {
+	f, ok := toFloat(raw)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func number(raw interface{}) (*float64, error) {
+	f, ok := toFloat(raw)
+	if !ok {
+		return nil, fmt.Errorf("must be a number")
+	}
+	return &f, nil
+}
+
+// Validate проверяет значение по схеме и возвращает первое расхождение, обернутое в ErrSchemaMismatch.
+func (s *JSONSchema) Validate(v interface{}) error {
+	return s.validate(v, "payload")
+}
+
+func (s *JSONSchema) validate(v interface{}, path string) error {
+	mismatch := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s: %s", ErrSchemaMismatch, path, fmt.Sprintf(format, args...))
+	}
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasJSONType(v, t) }) {
+		return mismatch("expected %s, got %s", joinTypes(s.types), jsonType(v))
+	}
+	if s.hasConst && !jsonEqual(v, s.constant) {
+		return mismatch("must be %v", s.constant)
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e interface{}) bool { return jsonEqual(v, e) }) {
+		return mismatch("must be one of %v", s.enum)
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.minLength != nil && n < *s.minLength {
+			return mismatch("shorter than %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return mismatch("longer than %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			return mismatch("does not match pattern %s", s.pattern)
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%w: %s.%s: required", ErrSchemaMismatch, path, name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, known := s.properties[name]
+			switch {
+			case known:
+			case s.noAdditional:
+				return fmt.Errorf("%w: %s.%s: property is not allowed", ErrSchemaMismatch, path, name)
+			case s.additional != nil:
+				sub = s.additional
+			default:
+				continue
+			}
+			if err := sub.validate(val[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(val) < *s.minItems {
+			return mismatch("fewer than %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(val) > *s.maxItems {
+			return mismatch("more than %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range val {
+				if err := s.items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		if f, ok := toFloat(v); ok {
+			if s.minimum != nil && f < *s.minimum {
+				return mismatch("less than %v", *s.minimum)
+			}
+			if s.maximum != nil && f > *s.maximum {
+				return mismatch("greater than %v", *s.maximum)
+			}
+		}
+	}
+	return nil
+}
+
+func hasJSONType(v interface{}, t string) bool {
+	if t == "integer" {
+		f, ok := toFloat(v)
+		return ok && f == math.Trunc(f)
+	}
+	return jsonType(v) == t
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return fmt.Sprint(types)
+}
+
+// jsonEqual сравнивает значения JSON; числа — по значению, независимо от Go-типа.
+func jsonEqual(a, b interface{}) bool {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA || okB {
+		return okA && okB && fa == fb
+	}
+	ea, errA := json.Marshal(a)
+	eb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ea) == string(eb)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
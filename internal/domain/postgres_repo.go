@@ -9,20 +9,162 @@ import (
 
 // NotificationRepository интерфейс для работы с уведомлениями в базе данных.
 type NotificationRepository interface {
-	// Create создает новое уведомление
+	// Create создает новое уведомление; если ключ идемпотентности уже занят, возвращает
+	// ErrDuplicateIdempotencyKey
 	Create(ctx context.Context, n CreateParams) (*Notification, error)
+	// CreateBatch создает уведомления одним запросом: либо все, либо ни одного;
+	// результат в том же порядке, что и params. Уведомление с уже занятым ключом
+	// идемпотентности не создается, на его месте в результате nil
+	CreateBatch(ctx context.Context, params []CreateParams) ([]*Notification, error)
 	// GetByID получает уведомление по ID
 	GetByID(ctx context.Context, id uuid.UUID) (*Notification, error)
+	// GetByIdempotencyKey получает уведомление, созданное системой source с ключом идемпотентности key
+	GetByIdempotencyKey(ctx context.Context, source, key string) (*Notification, error)
 	// Update обновляет уведомление с указанными параметрами
 	Update(ctx context.Context, id uuid.UUID, opts ...UpdateOption) error
 	// ListPendingAndProcessingBefore получает список зависших уведомлений
-	// (статус pending или processing, обновленных до указанного времени)
+	// (pending с effective_scheduled_at до t или processing, не обновлявшихся дольше 10 минут)
 	// Если limit или offset равны 0, они не включаются в запрос
 	ListPendingAndProcessingBefore(ctx context.Context, t time.Time, limit, offset int) ([]Notification, error)
+	// ListPendingAndProcessingAfter получает страницу тех же зависших уведомлений, что и
+	// ListPendingAndProcessingBefore, по ключу (effective_scheduled_at, id) строго после after
+	// (nil — с начала), не более limit штук; пустая страница не является ошибкой
+	ListPendingAndProcessingAfter(ctx context.Context, t time.Time, after *OrphanCursor,
+		limit int) ([]Notification, error)
+	// ListPendingScheduledBetween получает ожидающие уведомления с effective_scheduled_at
+	// в интервале [from, to), не более limit штук в порядке отправки
+	ListPendingScheduledBetween(ctx context.Context, from, to time.Time, limit int) ([]Notification, error)
+	// CountBacklog считает уведомления, время отправки которых наступило до t,
+	// но которые еще не отправлены (pending и processing)
+	CountBacklog(ctx context.Context, t time.Time) (int, error)
+	// ListFailedBefore получает неуспешные уведомления, не обновлявшиеся с t,
+	// которые возвращались в отправку меньше maxCycles раз, не более limit штук
+	ListFailedBefore(ctx context.Context, t time.Time, maxCycles, limit int) ([]Notification, error)
+	// FailedToProcess изменяет статус с failed на processing, увеличивая reprocess_count
+	// и сбрасывая retry_count; false, если уведомление уже не failed
+	FailedToProcess(ctx context.Context, id uuid.UUID) (bool, error)
+	// CountProcessingBefore считает уведомления в processing, не обновлявшиеся с t
+	CountProcessingBefore(ctx context.Context, t time.Time) (int, error)
+	// ListRelated получает неудаленные уведомления цепочки с корнем rootID, включая сам корень,
+	// в порядке created_at
+	ListRelated(ctx context.Context, rootID uuid.UUID) ([]Notification, error)
+	// ListGroup получает неудаленные уведомления группы в порядке создания
+	ListGroup(ctx context.Context, groupID uuid.UUID) ([]Notification, error)
+	// Approve записывает одобрившего и переводит уведомление из awaiting_approval в status;
+	// false, если уведомление уже не ждет одобрения
+	Approve(ctx context.Context, id uuid.UUID, approver string, status Status) (bool, error)
 	// PendingToProcess изменяет статус уведомления с pending на processing
 	PendingToProcess(ctx context.Context, id uuid.UUID) (bool, error)
 	// IncRetryCount увеличивает счетчик попыток для уведомления
 	IncRetryCount(ctx context.Context, id uuid.UUID) error
+	// Reschedule переносит ожидающие (pending) уведомления по фильтру одним запросом
+	// и возвращает перенесенные
+	Reschedule(ctx context.Context, params RescheduleParams) ([]Notification, error)
+	// Delete физически удаляет уведомление и все связанные с ним записи
+	Delete(ctx context.Context, id uuid.UUID) error
+	// SoftDelete помечает уведомление удаленным (deleted_at), строка остается в базе
+	SoftDelete(ctx context.Context, id uuid.UUID) error
+	// GetByIDWithDeleted получает уведомление по ID, включая мягко удаленные
+	GetByIDWithDeleted(ctx context.Context, id uuid.UUID) (*Notification, error)
+	// PurgeDeletedBefore физически удаляет уведомления, мягко удаленные до указанного времени
+	PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error)
+	// ListDuplicateDeliveries группирует уведомления, ушедшие провайдеру после since,
+	// по получателю, каналу и хешу payload и возвращает группы из двух и более уведомлений,
+	// самые крупные первыми, не более limit штук
+	ListDuplicateDeliveries(ctx context.Context, since time.Time, limit int) ([]DuplicateGroup, error)
+	// Search ищет неудаленные уведомления по получателю и текстовым полям payload,
+	// самые релевантные первыми, среди равных — более новые
+	Search(ctx context.Context, params SearchParams) ([]SearchHit, error)
+	// CountOutcomesByChannel считает по каналам уведомления, завершившие отправку в [from, to)
+	// по updated_at: успешные (sent, delivered, read) и неуспешные (failed, bounced)
+	CountOutcomesByChannel(ctx context.Context, from, to time.Time) (map[Channel]OutcomeCounts, error)
+	// CountSLA считает уведомления цели, срок SLA которых (effective_scheduled_at + Within) истек в [from, to),
+	// и сколько из них отправлено вовремя; отмененные, черновики, ждущие одобрения и подавленные не учитываются
+	CountSLA(ctx context.Context, target SLATarget, from, to time.Time) (SLACounts, error)
+	// List получает неудаленные уведомления по фильтрам, новые по scheduled_at первыми.
+	// Если limit или offset равны 0, они не включаются в запрос
+	List(ctx context.Context, filter ListFilter, limit, offset int) ([]Notification, error)
+}
+
+// ListFilter фильтры списка уведомлений, пустые поля не фильтруют.
+type ListFilter struct {
+	Status  Status
+	Channel Channel
+	// Recipient точное совпадение получателя
+	Recipient string
+	// ScheduledFrom и ScheduledTo границы scheduled_at [from, to), нулевые — без границы
+	ScheduledFrom time.Time
+	ScheduledTo   time.Time
+	// IncludeDeleted показывать и мягко удаленные (только в административном списке)
+	IncludeDeleted bool
+}
+
+// RescheduleParams массовый перенос ожидающих уведомлений: задается ровно одно из At и Shift.
+type RescheduleParams struct {
+	// Filter отбор уведомлений, статус всегда pending
+	Filter ListFilter
+	// At новое время отправки; сглаживание при этом сбрасывается
+	At *time.Time
+	// Shift сдвиг scheduled_at и effective_scheduled_at, может быть отрицательным
+	Shift time.Duration
+	// Limit максимум уведомлений за вызов, 0 — без ограничения
+	Limit int
+}
+
+// OutcomeCounts итоги отправки канала за период.
+type OutcomeCounts struct {
+	Sent   int
+	Failed int
+}
+
+// Total количество завершенных отправок.
+func (c OutcomeCounts) Total() int {
+	return c.Sent + c.Failed
+}
+
+// FailureRate доля неуспешных отправок, 0 если отправок не было.
+func (c OutcomeCounts) FailureRate() float64 {
+	if c.Total() == 0 {
+		return 0
+	}
+	return float64(c.Failed) / float64(c.Total())
+}
+
+// SearchParams параметры поиска уведомлений по тексту.
+type SearchParams struct {
+	// Query слова для поиска (синтаксис websearch: "фраза", or, -исключение)
+	// или фрагмент получателя; пустой — только фильтры, новые первыми
+	Query string
+	// Channel и Status сужают поиск, пустые — без фильтра
+	Channel Channel
+	Status  Status
+	// Since только уведомления, созданные не раньше, нулевое — без фильтра
+	Since time.Time
+	Limit int
+}
+
+// IsEmpty сообщает, что не задано ни строки поиска, ни фильтров.
+func (p SearchParams) IsEmpty() bool {
+	return p.Query == "" && p.Channel == "" && p.Status == "" && p.Since.IsZero()
+}
+
+// SearchHit найденное уведомление и его релевантность.
+type SearchHit struct {
+	Notification
+	Rank float64
+}
+
+// DuplicateGroup уведомления с одинаковыми получателем, каналом и payload,
+// отправленные несколько раз за окно отчета.
+type DuplicateGroup struct {
+	Recipient   string
+	Channel     Channel
+	PayloadHash string
+	// FirstAt и LastAt границы отправок группы (updated_at уведомлений)
+	FirstAt time.Time
+	LastAt  time.Time
+	// IDs уведомления группы в порядке отправки
+	IDs []uuid.UUID
 }
 
 // CreateParams параметры для создания уведомления.
@@ -32,6 +174,26 @@ type CreateParams struct {
 	Status      Status
 	Payload     map[string]interface{}
 	ScheduledAt time.Time
+	// ParentID и CorrelationID связывают уведомление с исходным, см. Notification
+	ParentID      *uuid.UUID
+	CorrelationID *uuid.UUID
+	// CancelOnConfirm см. Notification
+	CancelOnConfirm bool
+	PreSendCheck    string
+	// EffectiveScheduledAt фактическое время отправки, нулевое значение означает ScheduledAt
+	EffectiveScheduledAt time.Time
+	// Source система-источник уведомления
+	Source string
+	// RequiresApproval уведомление отправляется только после одобрения
+	RequiresApproval bool
+	// IdempotencyKey ключ идемпотентности, уникальный в пределах Source; пустой — без ключа
+	IdempotencyKey string
+	// GroupID группа уведомлений одного запроса, см. Notification
+	GroupID *uuid.UUID
+	// TemplateID шаблон уведомления, см. Notification
+	TemplateID *uuid.UUID
+	// Priority приоритет отправки, пустой означает normal
+	Priority Priority
 }
 
 // UpdateOption функция для обновления параметров уведомления.
@@ -49,7 +211,14 @@ type UpdateParams struct {
 	RetryCountInc *bool
 	ScheduledAt   *time.Time
 	Channel       *Channel
+	Recipient     *string
 	Payload       *OptionalPayload
+	// EffectiveScheduledAt фактическое время отправки без изменения запрошенного scheduled_at
+	EffectiveScheduledAt *time.Time
+	// UpdatedAt новое значение updated_at вместо времени базы, чтобы кеш и база совпадали
+	UpdatedAt *time.Time
+	// IfUpdatedAt обновить, только если updated_at не изменился с момента чтения
+	IfUpdatedAt *time.Time
 }
 
 // WithStatus создает опцию для установки статуса уведомления.
@@ -74,6 +243,13 @@ func WithScheduledAt(scheduleAt time.Time) UpdateOption {
 	}
 }
 
+// WithEffectiveScheduledAt создает опцию для переноса фактического времени отправки.
+func WithEffectiveScheduledAt(t time.Time) UpdateOption {
+	return func(p *UpdateParams) {
+		p.EffectiveScheduledAt = &t
+	}
+}
+
 // WithChannel создает опцию для установки канала уведомления.
 func WithChannel(channel Channel) UpdateOption {
 	return func(p *UpdateParams) {
@@ -81,6 +257,28 @@ func WithChannel(channel Channel) UpdateOption {
 	}
 }
 
+// WithRecipient создает опцию для установки получателя уведомления.
+func WithRecipient(recipient string) UpdateOption {
+	return func(p *UpdateParams) {
+		p.Recipient = &recipient
+	}
+}
+
+// WithUpdatedAt создает опцию для установки updated_at.
+func WithUpdatedAt(t time.Time) UpdateOption {
+	return func(p *UpdateParams) {
+		p.UpdatedAt = &t
+	}
+}
+
+// WithIfUpdatedAt создает опцию оптимистичной блокировки: если updated_at уже не равен t,
+// уведомление не обновляется и Update возвращает ErrNoRowAffected.
+func WithIfUpdatedAt(t time.Time) UpdateOption {
+	return func(p *UpdateParams) {
+		p.IfUpdatedAt = &t
+	}
+}
+
 // WithPayload создает опцию для установки payload уведомления.
 func WithPayload(payload map[string]interface{}) UpdateOption {
 	return func(p *UpdateParams) {
@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// PreSendChecker проверяет непосредственно перед отправкой, актуально ли еще уведомление.
+type PreSendChecker interface {
+	// ShouldSend возвращает false, если отправку нужно пропустить. Ошибка сообщает о сбое
+	// проверки; решение при сбое уже учитывает политику fail-open/fail-closed.
+	ShouldSend(ctx context.Context, n *Notification) (bool, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidRecipient ошибка неверного формата получателя для канала.
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+// RecipientValidator проверяет формат получателя для конкретного канала.
+// Реализации располагаются рядом с отправщиками соответствующих каналов.
+type RecipientValidator interface {
+	// ValidateRecipient возвращает ошибку, обернутую в ErrInvalidRecipient, если адрес неверен
+	ValidateRecipient(ctx context.Context, recipient string) error
+}
+
+// RecipientValidatorFunc функция, реализующая RecipientValidator.
+type RecipientValidatorFunc func(ctx context.Context, recipient string) error
+
+// ValidateRecipient вызывает f(ctx, recipient).
+func (f RecipientValidatorFunc) ValidateRecipient(ctx context.Context, recipient string) error {
+	return f(ctx, recipient)
+}
@@ -11,4 +11,42 @@ type RedisRepository interface {
 	Get(ctx context.Context, key string) (string, error)
 	// SetWithExpiration устанавливает значение с временем жизни.
 	SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	// SetNXWithExpiration устанавливает значение с временем жизни, только если ключа нет;
+	// false — ключ уже был.
+	SetNXWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	// Del удаляет значение по ключу.
+	Del(ctx context.Context, key string) error
+	// MGet получает значения нескольких ключей за один запрос, возвращает только найденные ключи.
+	MGet(ctx context.Context, keys []string) (map[string]string, error)
+	// MSetNXWithExpiration устанавливает несколько значений с временем жизни за один запрос,
+	// только для ключей, которых еще нет; возвращает число установленных.
+	MSetNXWithExpiration(ctx context.Context, values map[string]interface{}, expiration time.Duration) (int, error)
+}
+
+// CacheEntry запись кэша как она лежит в Redis.
+type CacheEntry struct {
+	Key   string
+	Value []byte
+	// TTL оставшееся время жизни, отрицательное — ключ без срока
+	TTL time.Duration
+}
+
+// CacheFlushResult итог удаления ключей кэша по шаблону.
+type CacheFlushResult struct {
+	// Keys найденные ключи (удаленные, если это не пробный запуск)
+	Keys []string
+	// Deleted сколько ключей удалено
+	Deleted int
+	// Truncated найдено больше ключей, чем разрешено за один вызов
+	Truncated bool
+}
+
+// CacheInspector диагностика кэша для административного API.
+type CacheInspector interface {
+	// Inspect получает сырое значение и TTL ключа, ErrCacheMiss, если ключа нет
+	Inspect(ctx context.Context, key string) (*CacheEntry, error)
+	// ScanKeys находит ключи по шаблону SCAN MATCH, не больше limit; true, если найдены не все
+	ScanKeys(ctx context.Context, pattern string, limit int) ([]string, bool, error)
+	// DeleteKeys удаляет ключи и возвращает, сколько их было
+	DeleteKeys(ctx context.Context, keys []string) (int, error)
 }
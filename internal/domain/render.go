@@ -0,0 +1,25 @@
+package domain
+
+// RenderedMessage сообщение в том виде, в котором оно уходит получателю.
+type RenderedMessage struct {
+	Channel     Channel `json:"channel"`
+	Recipient   string  `json:"recipient"`
+	Subject     string  `json:"subject,omitempty"`
+	Body        string  `json:"body"`
+	ContentType string  `json:"content_type"`
+}
+
+// MessageRenderer строит итоговое сообщение канала из уведомления.
+// Реализации располагаются рядом с отправщиками соответствующих каналов.
+type MessageRenderer interface {
+	// Render возвращает сообщение, которое будет отправлено для n
+	Render(n *Notification) (*RenderedMessage, error)
+}
+
+// MessageRendererFunc функция, реализующая MessageRenderer.
+type MessageRendererFunc func(n *Notification) (*RenderedMessage, error)
+
+// Render вызывает f(n).
+func (f MessageRendererFunc) Render(n *Notification) (*RenderedMessage, error) {
+	return f(n)
+}
@@ -7,4 +7,18 @@ var (
 	ErrNoRowAffected = errors.New("no row affected")
 	// ErrNotFound ошибка, когда уведомление не найдено.
 	ErrNotFound = errors.New("notification not found")
+	// ErrViewNotFound ошибка, когда сохраненное представление не найдено.
+	ErrViewNotFound = errors.New("saved view not found")
+	// ErrTemplateNotFound ошибка, когда шаблон не найден.
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrDuplicateTemplateName ошибка, когда шаблон с таким именем уже есть.
+	ErrDuplicateTemplateName = errors.New("template name is already taken")
+	// ErrTemplateInUse ошибка, когда на шаблон ссылаются неотправленные уведомления.
+	ErrTemplateInUse = errors.New("template is used by notifications that are not sent yet")
+	// ErrSchemaNotFound ошибка, когда для источника и канала нет схемы payload.
+	ErrSchemaNotFound = errors.New("payload schema not found")
+	// ErrDuplicateIdempotencyKey ошибка, когда уведомление с таким ключом идемпотентности уже есть.
+	ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+	// ErrCacheMiss ошибка, когда ключа нет в кэше.
+	ErrCacheMiss = errors.New("cache entry not found")
 )
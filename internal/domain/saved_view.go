@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ViewFilter набор фильтров поиска уведомлений, как в GET /admin/search.
+type ViewFilter struct {
+	Query   string
+	Channel Channel
+	Status  Status
+	// Last только уведомления, созданные за последние Last, 0 — без ограничения
+	Last  time.Duration
+	Limit int
+}
+
+// Params параметры поиска по фильтру на момент now.
+func (f ViewFilter) Params(now time.Time) SearchParams {
+	p := SearchParams{Query: f.Query, Channel: f.Channel, Status: f.Status, Limit: f.Limit}
+	if f.Last > 0 {
+		p.Since = now.Add(-f.Last)
+	}
+	return p
+}
+
+// SavedView сохраненное оператором именованное представление: фильтры для быстрых ссылок
+// и повторяемых запросов.
+type SavedView struct {
+	Name      string
+	Filter    ViewFilter
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SavedViewRepository хранилище сохраненных представлений.
+type SavedViewRepository interface {
+	// SaveView создает представление или заменяет фильтры существующего с тем же именем
+	SaveView(ctx context.Context, name string, filter ViewFilter) (*SavedView, error)
+	// GetView получает представление по имени, ErrViewNotFound, если его нет
+	GetView(ctx context.Context, name string) (*SavedView, error)
+	// ListViews получает все представления в порядке имен
+	ListViews(ctx context.Context) ([]SavedView, error)
+	// DeleteView удаляет представление, ErrViewNotFound, если его нет
+	DeleteView(ctx context.Context, name string) error
+}
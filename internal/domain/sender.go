@@ -7,3 +7,15 @@ type EmailSender interface {
 	// Send отправляет email уведомление.
 	Send(ctx context.Context, n *Notification) error
 }
+
+// TelegramSender интерфейс для отправки уведомлений в Telegram.
+type TelegramSender interface {
+	// Send отправляет сообщение в чат n.Recipient.
+	Send(ctx context.Context, n *Notification) error
+}
+
+// SMSSender интерфейс для отправки SMS через провайдера.
+type SMSSender interface {
+	// Send отправляет SMS на номер n.Recipient.
+	Send(ctx context.Context, n *Notification) error
+}
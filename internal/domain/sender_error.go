@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorClass класс ошибки провайдера, по которому консьюмер выбирает реакцию.
+type ErrorClass int
+
+const (
+	// ErrorTransient временный сбой: повторить отправку
+	ErrorTransient ErrorClass = iota
+	// ErrorPermanent повтор не поможет (неверный адрес, отказ провайдера): сразу failed
+	ErrorPermanent
+	// ErrorThrottled провайдер ограничивает частоту: отложить отправку, не расходуя попытки
+	ErrorThrottled
+)
+
+// String возвращает строковое представление класса ошибки.
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorPermanent:
+		return "permanent"
+	case ErrorThrottled:
+		return "throttled"
+	default:
+		return "transient"
+	}
+}
+
+// SendError ошибка отправителя с классом.
+type SendError struct {
+	Class ErrorClass
+	Err   error
+	// RetryAfter задержка, которую советует провайдер (Retry-After, retry_after), 0 — не задана
+	RetryAfter time.Duration
+}
+
+func (e *SendError) Error() string {
+	return e.Class.String() + ": " + e.Err.Error()
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// PermanentError помечает ошибку отправки как постоянную.
+func PermanentError(err error) error {
+	return &SendError{Class: ErrorPermanent, Err: err}
+}
+
+// TransientError помечает ошибку отправки как временную.
+func TransientError(err error) error {
+	return &SendError{Class: ErrorTransient, Err: err}
+}
+
+// ThrottledError помечает ошибку отправки как ограничение частоты.
+func ThrottledError(err error) error {
+	return &SendError{Class: ErrorThrottled, Err: err}
+}
+
+// ThrottledErrorAfter помечает ошибку как ограничение частоты с задержкой, которую назвал провайдер.
+func ThrottledErrorAfter(err error, retryAfter time.Duration) error {
+	return &SendError{Class: ErrorThrottled, Err: err, RetryAfter: retryAfter}
+}
+
+// RetryAfter возвращает задержку, которую советует провайдер, если она передана в ошибке.
+func RetryAfter(err error) (time.Duration, bool) {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) && sendErr.RetryAfter > 0 {
+		return sendErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter разбирает заголовок Retry-After: число секунд или HTTP-дату.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs <= 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil || !at.After(now) {
+		return 0, false
+	}
+	return at.Sub(now), true
+}
+
+// ClassifySendError возвращает класс ошибки отправки; неклассифицированные ошибки считаются временными.
+func ClassifySendError(err error) ErrorClass {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Class
+	}
+	return ErrorTransient
+}
+
+// ClassifyHTTPStatus сопоставляет код ответа HTTP-провайдера (Telegram Bot API, webhook, SaaS) с классом ошибки:
+// 429 — ограничение частоты, 408 и 5xx — временный сбой, остальные 4xx — постоянная ошибка.
+func ClassifyHTTPStatus(code int) ErrorClass {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrorThrottled
+	case code == http.StatusRequestTimeout || code >= 500:
+		return ErrorTransient
+	case code >= 400:
+		return ErrorPermanent
+	default:
+		return ErrorTransient
+	}
+}
@@ -11,4 +11,84 @@ var (
 	ErrEmptyRecipient = errors.New("recipient is empty")
 	// ErrEmptyUpdateOptions ошибка пустых параметров обновления.
 	ErrEmptyUpdateOptions = errors.New("no update options provided")
+	// ErrScheduledTooFarInPast время отправки в прошлом дальше допустимого (без immediate).
+	ErrScheduledTooFarInPast = errors.New("scheduled_at is too far in the past")
+	// ErrScheduledTooFarInFuture время отправки дальше максимального горизонта планирования.
+	ErrScheduledTooFarInFuture = errors.New("scheduled_at is too far in the future")
+	// ErrParentNotFound исходное уведомление (parent_id) не найдено.
+	ErrParentNotFound = errors.New("parent notification not found")
+	// ErrConfirmNotExpected уведомление создано без cancel_on_confirm.
+	ErrConfirmNotExpected = errors.New("notification does not wait for confirmation")
+	// ErrConfirmTooLate подтверждение пришло после начала отправки.
+	ErrConfirmTooLate = errors.New("notification is already being sent")
+	// ErrPreconditionFailed уведомление изменилось после того, как клиент его прочитал (If-Match).
+	ErrPreconditionFailed = errors.New("notification has been modified")
+	// ErrNotDraft редактировать и планировать можно только черновик.
+	ErrNotDraft = errors.New("notification is not a draft")
+	// ErrApprovalNotRequired уведомление создано без requires_approval.
+	ErrApprovalNotRequired = errors.New("notification does not require approval")
+	// ErrNotAwaitingApproval уведомление уже не ждет одобрения (например, отменено).
+	ErrNotAwaitingApproval = errors.New("notification is not awaiting approval")
+	// ErrInvalidReceiptStatus квитанция может сообщать только о доставке, прочтении или возврате.
+	ErrInvalidReceiptStatus = errors.New("receipt status must be delivered, read or bounced")
+	// ErrInvalidTransition переход в новый статус из текущего недопустим.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrInvalidSnoozeDuration длительность паузы получателя должна быть положительной.
+	ErrInvalidSnoozeDuration = errors.New("snooze duration must be positive")
+	// ErrInvalidPriority ошибка невалидного приоритета уведомления.
+	ErrInvalidPriority = errors.New("invalid priority")
+	// ErrInvalidJob сообщение очереди не удалось разобрать или его версия не поддерживается.
+	ErrInvalidJob = errors.New("invalid job message")
+	// ErrInvalidTimeRange начало интервала не раньше его конца.
+	ErrInvalidTimeRange = errors.New("time range start must be before its end")
+	// ErrEmptySearchQuery не заданы ни строка поиска, ни фильтры.
+	ErrEmptySearchQuery = errors.New("search query and filters are empty")
+	// ErrInvalidViewName имя представления пустое, длиннее 64 символов или с недопустимыми символами.
+	ErrInvalidViewName = errors.New("view name must be 1-64 characters of a-z, 0-9, '-' or '_'")
+	// ErrSavedViewsDisabled хранилище представлений не подключено.
+	ErrSavedViewsDisabled = errors.New("saved views are not configured")
+	// ErrOverloaded очередь отправки перегружена, уведомление с этим приоритетом не принято.
+	ErrOverloaded = errors.New("service is overloaded, try again later")
+	// ErrFailureLogDisabled журнал неуспешных уведомлений не подключен.
+	ErrFailureLogDisabled = errors.New("failure log is not configured")
+	// ErrEventLogDisabled история статусов уведомлений не подключена.
+	ErrEventLogDisabled = errors.New("status history is not configured")
+	// ErrCacheInspectorDisabled диагностика кэша не подключена.
+	ErrCacheInspectorDisabled = errors.New("cache inspector is not configured")
+	// ErrInvalidCachePattern шаблон ключей кэша пустой или не начинается с известного префикса.
+	ErrInvalidCachePattern = errors.New("cache pattern must start with a known key prefix")
+	// ErrEmptyBatch в пакете нет ни одного уведомления.
+	ErrEmptyBatch = errors.New("batch is empty")
+	// ErrBatchTooLarge в пакете больше уведомлений, чем разрешено.
+	ErrBatchTooLarge = errors.New("batch is too large")
+	// ErrStatusLinksDisabled секрет подписи ссылок на страницу статуса не задан.
+	ErrStatusLinksDisabled = errors.New("status links are not configured")
+	// ErrInvalidStatusToken токен страницы статуса поврежден или подписан другим ключом.
+	ErrInvalidStatusToken = errors.New("invalid status token")
+	// ErrStatusTokenExpired срок действия ссылки на страницу статуса истек.
+	ErrStatusTokenExpired = errors.New("status token has expired")
+	// ErrInvalidStatusLinkTTL срок действия ссылки не положительный или больше допустимого.
+	ErrInvalidStatusLinkTTL = errors.New("status link ttl is out of range")
+	// ErrInvalidReschedule для переноса нужно ровно одно из нового времени и сдвига.
+	ErrInvalidReschedule = errors.New("exactly one of scheduled_at and shift must be set")
+	// ErrEmptyRescheduleFilter перенос без фильтров затронул бы все ожидающие уведомления.
+	ErrEmptyRescheduleFilter = errors.New("reschedule filter is empty")
+	// ErrMaxRetriesExceeded уведомление исчерпало предел попыток отправки.
+	ErrMaxRetriesExceeded = errors.New("max retries exceeded")
+	// ErrTemplatesDisabled хранилище шаблонов не подключено.
+	ErrTemplatesDisabled = errors.New("templates are not configured")
+	// ErrInvalidTemplate шаблон без имени или тела либо с синтаксической ошибкой text/template.
+	ErrInvalidTemplate = errors.New("invalid template")
+	// ErrTemplateRender подстановка переменных в шаблон не удалась, например не хватает переменной.
+	ErrTemplateRender = errors.New("failed to render template")
+	// ErrDuplicateEvent квитанция с этим идентификатором события уже обработана.
+	ErrDuplicateEvent = errors.New("event has already been processed")
+	// ErrSchemasDisabled хранилище схем payload не подключено.
+	ErrSchemasDisabled = errors.New("payload schemas are not configured")
+	// ErrInvalidSchema схема payload не разбирается или использует неподдерживаемое ключевое слово.
+	ErrInvalidSchema = errors.New("invalid payload schema")
+	// ErrSchemaMismatch payload не подходит под схему, зарегистрированную для источника и канала.
+	ErrSchemaMismatch = errors.New("payload does not match the source schema")
+	// ErrInvalidSchemaSource имя источника схемы пустое или слишком длинное.
+	ErrInvalidSchemaSource = errors.New("schema source must be 1-64 characters")
 )
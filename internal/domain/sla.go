@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SLATarget цель уровня обслуживания: доля уведомлений канала (и источника), отправленных
+// не позже Within после фактического времени отправки (effective_scheduled_at).
+type SLATarget struct {
+	// Source система-источник (тенант), пустой — все источники
+	Source    string
+	Channel   Channel
+	Objective float64
+	Within    time.Duration
+}
+
+// Name имя цели в метриках и событиях: "channel" или "source/channel".
+func (t SLATarget) Name() string {
+	if t.Source == "" {
+		return t.Channel.String()
+	}
+	return t.Source + "/" + t.Channel.String()
+}
+
+// ParseSLATargets разбирает цели вида "email=95%@60s;billing/telegram=99.5%@30s"
+// ([источник/]канал=доля%@срок через ";"). Пустая строка — целей нет.
+func ParseSLATargets(spec string) ([]SLATarget, error) {
+	var targets []SLATarget
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		scope, goal, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("sla target %q: want [source/]channel=percent%%@duration", item)
+		}
+		var t SLATarget
+		channel := scope
+		if source, ch, found := strings.Cut(scope, "/"); found {
+			t.Source, channel = source, ch
+		}
+		t.Channel = Channel(channel)
+		if !t.Channel.IsValid() {
+			return nil, fmt.Errorf("sla target %q: %w", item, ErrInvalidChannel)
+		}
+		percent, within, ok := strings.Cut(goal, "@")
+		if !ok {
+			return nil, fmt.Errorf("sla target %q: want percent%%@duration after '='", item)
+		}
+		p, err := strconv.ParseFloat(strings.TrimSuffix(percent, "%"), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("sla target %q: objective must be a percentage in (0, 100]", item)
+		}
+		t.Objective = p / 100
+		if t.Within, err = time.ParseDuration(within); err != nil || t.Within <= 0 {
+			return nil, fmt.Errorf("sla target %q: within must be a positive duration", item)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// SLACounts уведомления, срок SLA которых истек за окно: Met отправлены вовремя.
+type SLACounts struct {
+	Met   int
+	Total int
+}
+
+// Compliance доля отправленных вовремя, 1 если сроков в окне не было.
+func (c SLACounts) Compliance() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Met) / float64(c.Total)
+}
+
+// SLAReport соблюдение цели за окно.
+type SLAReport struct {
+	Target SLATarget
+	Window time.Duration
+	SLACounts
+	// Breached доля ниже цели при достаточном числе уведомлений
+	Breached bool
+}
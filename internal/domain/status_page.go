@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// PublicState состояние уведомления на публичной странице статуса: внутренние статусы
+// сведены к тому, что важно получателю.
+type PublicState string
+
+const (
+	// PublicStateScheduled уведомление еще не отправлено
+	PublicStateScheduled PublicState = "scheduled"
+	// PublicStateSent уведомление отправлено
+	PublicStateSent PublicState = "sent"
+	// PublicStateFailed отправить не удалось
+	PublicStateFailed PublicState = "failed"
+	// PublicStateCancelled уведомление отменено и отправлено не будет
+	PublicStateCancelled PublicState = "cancelled"
+)
+
+// PublicState возвращает состояние для публичной страницы статуса.
+func (s Status) PublicState() PublicState {
+	switch s {
+	case StatusSent, StatusDelivered, StatusRead:
+		return PublicStateSent
+	case StatusFailed, StatusBounced, StatusExpired:
+		return PublicStateFailed
+	case StatusCancelled, StatusSuppressed:
+		return PublicStateCancelled
+	default:
+		return PublicStateScheduled
+	}
+}
+
+// StatusLink подписанный токен публичной страницы статуса уведомления (GET /s/:token).
+type StatusLink struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// PublicStatus сведения об уведомлении для публичной страницы статуса: без получателя и payload.
+type PublicStatus struct {
+	State       PublicState
+	Channel     Channel
+	ScheduledAt time.Time
+	UpdatedAt   time.Time
+	// ExpiresAt когда перестанет действовать ссылка
+	ExpiresAt time.Time
+}
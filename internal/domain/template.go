@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Template переиспользуемые subject и body уведомления с подстановками text/template.
+// Переменные берутся из payload уведомления и подставляются при отправке, поэтому
+// изменение шаблона действует и на уже запланированные уведомления.
+type Template struct {
+	ID   uuid.UUID
+	Name string
+	// Subject шаблон темы; пустой оставляет subject из payload
+	Subject   string
+	Body      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TemplateParams поля шаблона при создании и изменении.
+type TemplateParams struct {
+	Name    string
+	Subject string
+	Body    string
+}
+
+// TemplateRepository хранилище шаблонов.
+type TemplateRepository interface {
+	// CreateTemplate создает шаблон, ErrDuplicateTemplateName, если имя занято
+	CreateTemplate(ctx context.Context, params TemplateParams) (*Template, error)
+	// GetTemplate получает шаблон, ErrTemplateNotFound, если его нет
+	GetTemplate(ctx context.Context, id uuid.UUID) (*Template, error)
+	// ListTemplates получает все шаблоны в порядке имен
+	ListTemplates(ctx context.Context) ([]Template, error)
+	// UpdateTemplate заменяет поля шаблона, ErrTemplateNotFound, если его нет
+	UpdateTemplate(ctx context.Context, id uuid.UUID, params TemplateParams) (*Template, error)
+	// DeleteTemplate удаляет шаблон, если на него не ссылаются неотправленные уведомления:
+	// иначе ErrTemplateInUse, ErrTemplateNotFound, если его нет
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+}
+
+// Parse разбирает subject и body шаблона. Отсутствующая переменная — ошибка подстановки,
+// а не пустая строка или "<no value>".
+func (t *Template) Parse() (subject, body *template.Template, err error) {
+	if t.Subject != "" {
+		if subject, err = template.New("subject").Option("missingkey=error").Parse(t.Subject); err != nil {
+			return nil, nil, fmt.Errorf("%w: subject: %v", ErrInvalidTemplate, err)
+		}
+	}
+	if body, err = template.New("body").Option("missingkey=error").Parse(t.Body); err != nil {
+		return nil, nil, fmt.Errorf("%w: body: %v", ErrInvalidTemplate, err)
+	}
+	return subject, body, nil
+}
+
+// Render подставляет vars в шаблон и возвращает копию vars с subject и body.
+func (t *Template) Render(vars map[string]interface{}) (map[string]interface{}, error) {
+	subject, body, err := t.Parse()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{}, len(vars)+2)
+	for k, v := range vars {
+		out[k] = v
+	}
+	var sb strings.Builder
+	if subject != nil {
+		if err := subject.Execute(&sb, vars); err != nil {
+			return nil, fmt.Errorf("%w: subject: %v", ErrTemplateRender, err)
+		}
+		out["subject"] = sb.String()
+		sb.Reset()
+	}
+	if err := body.Execute(&sb, vars); err != nil {
+		return nil, fmt.Errorf("%w: body: %v", ErrTemplateRender, err)
+	}
+	out["body"] = sb.String()
+	return out, nil
+}
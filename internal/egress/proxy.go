@@ -0,0 +1,68 @@
+// Package egress настраивает исходящие HTTP-соединения отправщиков и вебхуков:
+// прокси и транспорт.
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Direct значение переопределения прокси для канала: ходить напрямую, даже если задан общий прокси.
+const Direct = "direct"
+
+// ErrInvalidProxy неверный адрес прокси в конфигурации.
+var ErrInvalidProxy = errors.New("invalid proxy url")
+
+// Proxy исходящий прокси. Пустой URL — прокси из окружения (HTTPS_PROXY, HTTP_PROXY, NO_PROXY),
+// Direct — без прокси.
+type Proxy struct {
+	URL string
+	// NoProxy хосты и домены через запятую, к которым ходить напрямую (формат NO_PROXY)
+	NoProxy string
+}
+
+// Override возвращает прокси для канала: override заменяет общий адрес, если не пустой.
+func (p Proxy) Override(override string) Proxy {
+	if override != "" {
+		p.URL = override
+	}
+	return p
+}
+
+// Validate проверяет адрес прокси.
+func (p Proxy) Validate() error {
+	if p.URL == "" || p.URL == Direct {
+		return nil
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("%w: %q: scheme must be http, https or socks5", ErrInvalidProxy, p.URL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%w: %q: host is missing", ErrInvalidProxy, p.URL)
+	}
+	return nil
+}
+
+// ProxyFunc функция выбора прокси для http.Transport.
+func (p Proxy) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	switch p.URL {
+	case "":
+		return http.ProxyFromEnvironment
+	case Direct:
+		return nil
+	}
+	proxyFor := (&httpproxy.Config{HTTPProxy: p.URL, HTTPSProxy: p.URL, NoProxy: p.NoProxy}).ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxyFor(r.URL)
+	}
+}
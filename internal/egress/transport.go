@@ -0,0 +1,216 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress соединение с внутренним адресом запрещено.
+var ErrForbiddenAddress = errors.New("connection to non-public address is forbidden")
+
+// sharedAddressSpace диапазон CGNAT (RFC 6598), в облаках за ним часто внутренние сервисы.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Tuning параметры пула соединений и таймаутов исходящих HTTP-вызовов. Нулевые значения
+// оставляют настройки http.DefaultTransport.
+type Tuning struct {
+	// MaxIdleConnsPerHost сколько простаивающих keep-alive соединений держать на хост
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout когда закрывать простаивающее соединение
+	IdleConnTimeout time.Duration
+	// DialTimeout ограничение времени установки TCP-соединения
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout ограничение времени TLS-рукопожатия
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout сколько ждать заголовков ответа после отправки запроса
+	ResponseHeaderTimeout time.Duration
+	// DNSTTL сколько хранить разрешенные адреса, 0 отключает кеш DNS
+	DNSTTL time.Duration
+}
+
+// Transports выдает настроенные транспорты с общим кешем DNS. Транспорт для прокси создается
+// один раз, и все отправщики с тем же прокси делят его пул соединений.
+type Transports struct {
+	tuning Tuning
+	dialer *net.Dialer
+	dns    *DNSCache
+
+	mu      sync.Mutex
+	byProxy map[Proxy]*http.Transport
+	guarded *http.Transport
+}
+
+// NewTransports создает набор транспортов с параметрами tuning.
+func NewTransports(tuning Tuning) *Transports {
+	t := &Transports{
+		tuning:  tuning,
+		dialer:  &net.Dialer{Timeout: tuning.DialTimeout, KeepAlive: 30 * time.Second},
+		byProxy: make(map[Proxy]*http.Transport),
+	}
+	if tuning.DNSTTL > 0 {
+		t.dns = NewDNSCache(tuning.DNSTTL, nil)
+	}
+	return t
+}
+
+// For возвращает общий транспорт, который ходит через прокси p.
+func (t *Transports) For(p Proxy) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tr, ok := t.byProxy[p]; ok {
+		return tr
+	}
+
+	tr := t.build(p.ProxyFunc(), t.dialer)
+	t.byProxy[p] = tr
+	return tr
+}
+
+// Guarded возвращает транспорт для адресов, которые задают клиенты API (pre_send_check):
+// без прокси и с запретом соединений к внутренним адресам (PublicOnly). Адрес проверяется
+// после разрешения DNS, поэтому имя хоста, указывающее внутрь сети, не обходит запрет.
+func (t *Transports) Guarded() *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.guarded == nil {
+		dialer := *t.dialer
+		dialer.Control = PublicOnly
+		t.guarded = t.build(nil, &dialer)
+	}
+	return t.guarded
+}
+
+func (t *Transports) build(proxy func(*http.Request) (*url.URL, error), dialer *net.Dialer) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = proxy
+	tr.DialContext = dialer.DialContext
+	if t.dns != nil {
+		tr.DialContext = t.dns.DialContext(dialer)
+	}
+	if t.tuning.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = t.tuning.MaxIdleConnsPerHost
+		if tr.MaxIdleConns < t.tuning.MaxIdleConnsPerHost {
+			tr.MaxIdleConns = t.tuning.MaxIdleConnsPerHost
+		}
+	}
+	if t.tuning.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = t.tuning.IdleConnTimeout
+	}
+	if t.tuning.TLSHandshakeTimeout > 0 {
+		tr.TLSHandshakeTimeout = t.tuning.TLSHandshakeTimeout
+	}
+	if t.tuning.ResponseHeaderTimeout > 0 {
+		tr.ResponseHeaderTimeout = t.tuning.ResponseHeaderTimeout
+	}
+	return tr
+}
+
+// PublicOnly функция net.Dialer.Control, которая отклоняет соединения с loopback, частными,
+// link-local, multicast, неуказанными адресами и CGNAT.
+func PublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return nil
+}
+
+// LookupFunc разрешает имя хоста в адреса.
+type LookupFunc func(ctx context.Context, host string) ([]string, error)
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// DNSCache кеш разрешения имен для исходящих соединений. Если повторное разрешение не удалось,
+// используются устаревшие адреса, чтобы кратковременный сбой DNS не останавливал отправку.
+type DNSCache struct {
+	ttl    time.Duration
+	lookup LookupFunc
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// NewDNSCache создает кеш с временем жизни записей ttl; lookup nil — системный резолвер.
+func NewDNSCache(ttl time.Duration, lookup LookupFunc) *DNSCache {
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	return &DNSCache{ttl: ttl, lookup: lookup, entries: make(map[string]dnsEntry)}
+}
+
+// LookupHost возвращает адреса host из кеша или разрешает их заново.
+func (c *DNSCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			return e.addrs, nil
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// DialContext возвращает функцию соединения для http.Transport, которая берет адреса из кеша
+// и перебирает их до первого успешного соединения.
+func (c *DNSCache) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips, err := c.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var errs []error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, errors.Join(errs...)
+	}
+}
@@ -0,0 +1,38 @@
+// Package logger переносит логгер с полями корреляции (ID запроса, ID уведомления)
+// через context.Context, чтобы строки журнала от обработчика до отправщика были связаны.
+package logger
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/zlog"
+)
+
+type ctxKey struct{}
+
+// FromContext возвращает логгер из контекста, а если его нет, глобальный zlog.Logger.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*zerolog.Logger); ok {
+			return l
+		}
+	}
+	return &zlog.Logger
+}
+
+// WithField возвращает контекст с логгером, дополненным полем key.
+func WithField(ctx context.Context, key, value string) context.Context {
+	l := FromContext(ctx).With().Str(key, value).Logger()
+	return context.WithValue(ctx, ctxKey{}, &l)
+}
+
+// WithRequestID добавляет в логгер контекста ID HTTP-запроса.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithField(ctx, "request_id", requestID)
+}
+
+// WithNotificationID добавляет в логгер контекста ID уведомления.
+func WithNotificationID(ctx context.Context, id string) context.Context {
+	return WithField(ctx, "notification_id", id)
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFile файл журнала с ротацией по размеру. При превышении maxSizeMB текущий
+// файл переименовывается в <path>.<время>, хранится не более maxBackups копий
+// не старше maxAge (0 отключает соответствующее ограничение).
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile открывает файл журнала для дозаписи.
+func NewRotatingFile(path string, maxSizeMB, maxBackups int, maxAge time.Duration) (*RotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log file path is empty")
+	}
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxBackups: maxBackups,
+		maxAge:     maxAge,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close закрывает текущий файл.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	backup := r.path + "." + time.Now().Format(backupTimeFormat)
+	if err := os.Rename(r.path, backup); err != nil {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.cleanup()
+	return nil
+}
+
+// cleanup удаляет лишние и устаревшие копии.
+func (r *RotatingFile) cleanup() {
+	backups, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return
+	}
+	backups = filterBackups(backups, r.path)
+	// имена содержат время, поэтому сортировка по имени дает порядок создания
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i, b := range backups {
+		expired := false
+		if r.maxAge > 0 {
+			if info, err := os.Stat(b); err == nil && time.Since(info.ModTime()) > r.maxAge {
+				expired = true
+			}
+		}
+		if expired || (r.maxBackups > 0 && i >= r.maxBackups) {
+			_ = os.Remove(b)
+		}
+	}
+}
+
+// filterBackups оставляет только файлы, созданные ротацией (суффикс — время).
+func filterBackups(names []string, path string) []string {
+	result := names[:0]
+	for _, name := range names {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(name, path+".")); err == nil {
+			result = append(result, name)
+		}
+	}
+	return result
+}
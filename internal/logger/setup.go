@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// Форматы вывода журнала.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Приемники журнала.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// Options параметры глобального логгера.
+type Options struct {
+	Level  string
+	Format string
+	// Outputs список приемников, например stdout,file
+	Outputs []string
+	// DebugSampleN пишет только каждое N-е сообщение уровня debug, 0 и 1 пишут все
+	DebugSampleN uint32
+	File         FileOptions
+	Syslog       SyslogOptions
+}
+
+// FileOptions параметры записи в файл с ротацией.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAge     time.Duration
+}
+
+// SyslogOptions параметры отправки в syslog. Пустой адрес означает локальный syslog.
+type SyslogOptions struct {
+	Network string
+	Addr    string
+	Tag     string
+}
+
+// Setup настраивает глобальный zlog.Logger и возвращает функцию закрытия приемников.
+func Setup(opts Options) (io.Closer, error) {
+	level, err := zerolog.ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var writers []io.Writer
+	var closers multiCloser
+	outputs := opts.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{OutputStdout}
+	}
+	for _, out := range outputs {
+		switch strings.ToLower(strings.TrimSpace(out)) {
+		case OutputStdout, "":
+			writers = append(writers, formatWriter(os.Stdout, opts.Format))
+		case OutputStderr:
+			writers = append(writers, formatWriter(os.Stderr, opts.Format))
+		case OutputFile:
+			f, err := NewRotatingFile(opts.File.Path, opts.File.MaxSizeMB, opts.File.MaxBackups, opts.File.MaxAge)
+			if err != nil {
+				_ = closers.Close()
+				return nil, err
+			}
+			closers = append(closers, f)
+			writers = append(writers, formatWriter(f, opts.Format))
+		case OutputSyslog:
+			// в syslog всегда пишем JSON, время и уровень он добавляет сам
+			w, c, err := newSyslogSink(opts.Syslog)
+			if err != nil {
+				_ = closers.Close()
+				return nil, err
+			}
+			closers = append(closers, c)
+			writers = append(writers, w)
+		default:
+			_ = closers.Close()
+			return nil, fmt.Errorf("unknown log output %q", out)
+		}
+	}
+
+	l := zerolog.New(zerolog.MultiLevelWriter(writers...)).With().Timestamp().Logger().Level(level)
+	if opts.DebugSampleN > 1 {
+		l = l.Sample(zerolog.LevelSampler{DebugSampler: &zerolog.BasicSampler{N: opts.DebugSampleN}})
+	}
+	zlog.Logger = l
+	return closers, nil
+}
+
+func formatWriter(w io.Writer, format string) io.Writer {
+	if strings.EqualFold(format, FormatConsole) {
+		return zerolog.ConsoleWriter{Out: w, TimeFormat: "2006-01-02 15:04:05", NoColor: w != os.Stdout && w != os.Stderr}
+	}
+	return w
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var firstErr error
+	for _, c := range m {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
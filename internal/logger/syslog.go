@@ -0,0 +1,23 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"io"
+	"log/syslog"
+
+	"github.com/rs/zerolog"
+)
+
+// newSyslogSink подключается к syslog; уровень zerolog переводится в приоритет syslog.
+func newSyslogSink(opts SyslogOptions) (io.Writer, io.Closer, error) {
+	tag := opts.Tag
+	if tag == "" {
+		tag = "delayednotifier"
+	}
+	w, err := syslog.Dial(opts.Network, opts.Addr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zerolog.SyslogLevelWriter(w), w, nil
+}
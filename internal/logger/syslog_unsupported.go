@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+func newSyslogSink(SyslogOptions) (io.Writer, io.Closer, error) {
+	return nil, nil, errors.New("syslog output is not supported on this platform")
+}
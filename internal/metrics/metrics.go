@@ -0,0 +1,94 @@
+// Package metrics публикует метрики процесса через expvar.
+package metrics
+
+import (
+	"expvar"
+	"runtime"
+	"sync"
+	"time"
+)
+
+var startedAt = time.Now()
+
+// Namespace префикс окружения процесса, метка для всех метрик при сборе с общего дашборда.
+var Namespace = expvar.NewString("namespace")
+
+// InFlightDeliveries количество сообщений, обрабатываемых консьюмером прямо сейчас.
+var InFlightDeliveries = expvar.NewInt("deliveries_in_flight")
+
+// StaleProcessing количество уведомлений, зависших в processing, по последней проверке детектора.
+var StaleProcessing = expvar.NewInt("processing_stale")
+
+// OrphanedNotifications количество уведомлений без сообщения в очереди, найденных последней сверкой.
+var OrphanedNotifications = expvar.NewInt("notifications_orphaned")
+
+// OrphansReconciled действия сверки над потерянными уведомлениями: republished, expired, failed.
+var OrphansReconciled = expvar.NewMap("orphans_reconciled")
+
+// FailureRate доля неуспешных отправок по каналам по последней проверке детектора:
+// channel -> current (текущее окно) и baseline (предшествующий период).
+var FailureRate = expvar.NewMap("failure_rate")
+
+// FailureSpikes количество всплесков доли неуспешных отправок по каналам.
+var FailureSpikes = expvar.NewMap("failure_spikes")
+
+// SetFailureRate публикует текущую и базовую долю неуспешных отправок канала.
+func SetFailureRate(channel string, current, baseline float64) {
+	m := new(expvar.Map).Init()
+	cur, base := new(expvar.Float), new(expvar.Float)
+	cur.Set(current)
+	base.Set(baseline)
+	m.Set("current", cur)
+	m.Set("baseline", base)
+	FailureRate.Set(channel, m)
+}
+
+// SLACompliance доля уведомлений, отправленных в срок, по целям SLA ("channel" или "source/channel").
+var SLACompliance = expvar.NewMap("sla_compliance")
+
+// SLABreached 1 для целей SLA, нарушенных по последней проверке, иначе 0.
+var SLABreached = expvar.NewMap("sla_breached")
+
+// AdmissionRejected количество созданий, отклоненных из-за перегрузки, по приоритетам.
+var AdmissionRejected = expvar.NewMap("admission_rejected")
+
+// BySource счетчики уведомлений по системам-источникам: source -> событие -> количество.
+var BySource = expvar.NewMap("notifications_by_source")
+
+// UnknownSource метка для уведомлений без источника (созданных до появления поля source).
+const UnknownSource = "unknown"
+
+var bySourceMu sync.Mutex
+
+// CountBySource увеличивает счетчик события (created, sent, failed, cancelled) для источника.
+func CountBySource(source, event string) {
+	if source == "" {
+		source = UnknownSource
+	}
+	bySourceMu.Lock()
+	m, ok := BySource.Get(source).(*expvar.Map)
+	if !ok {
+		m = new(expvar.Map).Init()
+		BySource.Set(source, m)
+	}
+	bySourceMu.Unlock()
+	m.Add(event, 1)
+}
+
+func init() {
+	expvar.Publish("uptime_seconds", expvar.Func(func() interface{} {
+		return int64(time.Since(startedAt).Seconds())
+	}))
+	expvar.Publish("runtime", expvar.Func(func() interface{} {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return map[string]interface{}{
+			"goroutines":       runtime.NumGoroutine(),
+			"heap_alloc_bytes": m.HeapAlloc,
+			"heap_objects":     m.HeapObjects,
+			"num_gc":           m.NumGC,
+			"gc_pause_total":   time.Duration(m.PauseTotalNs).String(),
+			"last_gc":          time.Unix(0, int64(m.LastGC)).UTC(),
+		}
+	}))
+}
@@ -0,0 +1,177 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+// DefaultLockID ключ advisory lock, под которым применяются миграции.
+const DefaultLockID int64 = 0x44454c4159454400
+
+// Значения по умолчанию для ожидания блокировки.
+const (
+	defaultLockTimeout  = 5 * time.Minute
+	defaultStaleAfter   = time.Minute
+	defaultPollInterval = time.Second
+)
+
+// ErrLockTimeout блокировку миграций не удалось получить за отведенное время.
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// AdvisoryLock сессионный advisory lock PostgreSQL на отдельном соединении.
+// Пока блокировка удерживается, соединение раз в staleAfter/3 выполняет пустой запрос,
+// поэтому зависший владелец виден по давно не менявшемуся состоянию сессии:
+// ожидающий экземпляр завершает такую сессию, и PostgreSQL снимает ее блокировку.
+type AdvisoryLock struct {
+	db         *sql.DB
+	id         int64
+	timeout    time.Duration
+	staleAfter time.Duration
+	poll       time.Duration
+}
+
+// LockOption настраивает AdvisoryLock.
+type LockOption func(*AdvisoryLock)
+
+// WithLockTimeout ограничивает ожидание блокировки, 0 — ждать без ограничения.
+func WithLockTimeout(d time.Duration) LockOption {
+	return func(l *AdvisoryLock) {
+		l.timeout = d
+	}
+}
+
+// WithStaleAfter через сколько простоя сессия владельца считается зависшей, 0 отключает проверку.
+func WithStaleAfter(d time.Duration) LockOption {
+	return func(l *AdvisoryLock) {
+		l.staleAfter = d
+	}
+}
+
+// WithPollInterval как часто повторять попытку взять блокировку.
+func WithPollInterval(d time.Duration) LockOption {
+	return func(l *AdvisoryLock) {
+		l.poll = d
+	}
+}
+
+// NewAdvisoryLock создает блокировку с ключом id.
+func NewAdvisoryLock(db *sql.DB, id int64, opts ...LockOption) *AdvisoryLock {
+	l := &AdvisoryLock{
+		db:         db,
+		id:         id,
+		timeout:    defaultLockTimeout,
+		staleAfter: defaultStaleAfter,
+		poll:       defaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Acquire ждет блокировку и возвращает функцию ее снятия.
+func (l *AdvisoryLock) Acquire(ctx context.Context) (func(), error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock connection: %w", err)
+	}
+	var deadline time.Time
+	if l.timeout > 0 {
+		deadline = time.Now().Add(l.timeout)
+	}
+	for {
+		var ok bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.id).Scan(&ok); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if ok {
+			break
+		}
+		if err := l.breakStale(ctx, conn); err != nil {
+			zlog.Logger.Warn().Err(err).Msg("Failed to check migration lock holder")
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			_ = conn.Close()
+			return nil, ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(l.poll):
+		}
+	}
+
+	stop := l.heartbeat(conn)
+	return func() {
+		stop()
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, l.id); err != nil {
+			zlog.Logger.Warn().Err(err).Msg("Failed to release migration lock")
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// heartbeat обновляет состояние сессии владельца, пока блокировка удерживается.
+func (l *AdvisoryLock) heartbeat(conn *sql.Conn) func() {
+	if l.staleAfter <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(l.staleAfter / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = conn.ExecContext(ctx, `SELECT 1`)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// breakStale завершает сессию владельца блокировки, простаивающую дольше staleAfter.
+func (l *AdvisoryLock) breakStale(ctx context.Context, conn *sql.Conn) error {
+	if l.staleAfter <= 0 {
+		return nil
+	}
+	var (
+		pid   int
+		state sql.NullString
+		idle  float64
+	)
+	err := conn.QueryRowContext(ctx, `SELECT a.pid, a.state, EXTRACT(EPOCH FROM now() - a.state_change)
+    FROM pg_locks l
+    JOIN pg_stat_activity a ON a.pid = l.pid
+    WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+      AND ((l.classid::bigint << 32) | l.objid::bigint) = $1`, l.id).Scan(&pid, &state, &idle)
+	if errors.Is(err, sql.ErrNoRows) {
+		// блокировку уже сняли
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	idleFor := time.Duration(idle * float64(time.Second))
+	if state.String != "idle" || idleFor < l.staleAfter {
+		zlog.Logger.Info().Int("pid", pid).Str("state", state.String).Msg("Waiting for migration lock...")
+		return nil
+	}
+	zlog.Logger.Warn().Int("pid", pid).Dur("idle", idleFor).Msg("Migration lock holder is stale, terminating it")
+	_, err = conn.ExecContext(ctx, `SELECT pg_terminate_backend($1)`, pid)
+	return err
+}
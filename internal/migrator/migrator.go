@@ -1,14 +1,17 @@
 package migrator
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
 // простая обертка над golang-migrator для удобства использования.
@@ -16,9 +19,31 @@ import (
 // Migrator основная структура.
 type Migrator struct {
 	migrate *migrate.Migrate
+	lock    *AdvisoryLock
 }
 
-func NewMigrator(db *sql.DB, migrationsDir string) (*Migrator, error) {
+// Option настраивает Migrator.
+type Option func(*Migrator)
+
+// WithLockOptions настраивает блокировку, под которой выполняются Up, Down и MigrateTo.
+func WithLockOptions(opts ...LockOption) Option {
+	return func(m *Migrator) {
+		for _, opt := range opts {
+			opt(m.lock)
+		}
+	}
+}
+
+func newMigrator(db *sql.DB, mg *migrate.Migrate, opts []Option) *Migrator {
+	m := &Migrator{migrate: mg, lock: NewAdvisoryLock(db, DefaultLockID)}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewMigrator создает мигратор, читающий миграции из каталога migrationsDir.
+func NewMigrator(db *sql.DB, migrationsDir string, opts ...Option) (*Migrator, error) {
 	if db == nil {
 		return nil, errors.New("database connection is nil")
 	}
@@ -49,25 +74,65 @@ func NewMigrator(db *sql.DB, migrationsDir string) (*Migrator, error) {
 		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
 
-	return &Migrator{m}, nil
+	return newMigrator(db, m, opts), nil
 }
 
-// Up накатываем все непримененные миграции.
-func (m *Migrator) Up() error {
-	err := m.migrate.Up()
-	if errors.Is(err, migrate.ErrNoChange) {
-		return nil
+// NewMigratorFS создает мигратор, читающий миграции из файловой системы fsys
+// (например, встроенной через go:embed).
+func NewMigratorFS(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	if db == nil {
+		return nil, errors.New("database connection is nil")
+	}
+	if fsys == nil {
+		return nil, errors.New("migrations filesystem is nil")
+	}
+
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed to initialize postgres driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
-	return err
+
+	return newMigrator(db, m, opts), nil
+}
+
+// Up накатываем все непримененные миграции. Экземпляры, запущенные одновременно,
+// ждут блокировку, поэтому миграции выполняет только первый, остальные не находят изменений.
+func (m *Migrator) Up() error {
+	return m.UpContext(context.Background())
+}
+
+// UpContext как Up, но ожидание блокировки прерывается отменой ctx.
+func (m *Migrator) UpContext(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		err := m.migrate.Up()
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		}
+		return err
+	})
 }
 
 // Down откатываем последнюю примененную миграцию.
 func (m *Migrator) Down() error {
-	err := m.migrate.Down()
-	if errors.Is(err, migrate.ErrNoChange) {
-		return nil
-	}
-	return err
+	return m.locked(context.Background(), func() error {
+		err := m.migrate.Down()
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		}
+		return err
+	})
 }
 
 // Version возвращает текущую версию.
@@ -87,7 +152,19 @@ func (m *Migrator) Version() (uint, error) {
 
 // MigrateTo применяет миграции или откатывает их до указанной версии.
 func (m *Migrator) MigrateTo(version uint) error {
-	return m.migrate.Migrate(version)
+	return m.locked(context.Background(), func() error {
+		return m.migrate.Migrate(version)
+	})
+}
+
+// locked выполняет fn под блокировкой миграций.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	release, err := m.lock.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
 }
 
 // Close освобождаем ресурсы.
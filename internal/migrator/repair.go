@@ -0,0 +1,86 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// SchemaCheck запрос, возвращающий одно boolean-значение: есть ли в схеме
+// изменение, которое вносит миграция (столбец, индекс, значение enum).
+type SchemaCheck struct {
+	Name  string
+	Query string
+}
+
+// RepairPlan результат сверки схемы с миграцией, на которой база осталась dirty.
+type RepairPlan struct {
+	Version uint
+	Dirty   bool
+	// Applied и Missing проверки миграции Version, найденные и не найденные в схеме
+	Applied []string
+	Missing []string
+	// ForceTo версия, которую можно записать в schema_migrations; -1 — ни одной миграции
+	ForceTo int
+	// Safe миграция применена полностью или не применена совсем
+	Safe bool
+}
+
+// State возвращает текущую версию и признак dirty без ошибки на dirty.
+func (m *Migrator) State() (uint, bool, error) {
+	ver, dirty, err := m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return ver, dirty, err
+}
+
+// Force записывает версию и снимает dirty, не выполняя миграций.
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// PlanRepair сверяет схему с проверками миграции version. Если изменения миграции
+// есть целиком, безопасно записать version; если их нет совсем — предыдущую версию
+// из checks. Частично примененную миграцию нужно исправлять вручную.
+func PlanRepair(ctx context.Context, db *sql.DB, version uint, dirty bool,
+	checks map[uint][]SchemaCheck) (RepairPlan, error) {
+	plan := RepairPlan{Version: version, Dirty: dirty, ForceTo: int(version)}
+	if !dirty {
+		plan.Safe = true
+		return plan, nil
+	}
+	expected, ok := checks[version]
+	if !ok || len(expected) == 0 {
+		return plan, fmt.Errorf("no schema checks for migration %d", version)
+	}
+
+	for _, c := range expected {
+		var present bool
+		if err := db.QueryRowContext(ctx, c.Query).Scan(&present); err != nil {
+			return plan, fmt.Errorf("schema check %q: %w", c.Name, err)
+		}
+		if present {
+			plan.Applied = append(plan.Applied, c.Name)
+		} else {
+			plan.Missing = append(plan.Missing, c.Name)
+		}
+	}
+
+	switch {
+	case len(plan.Missing) == 0:
+		plan.Safe = true
+	case len(plan.Applied) == 0:
+		plan.Safe = true
+		plan.ForceTo = -1
+		for v := range checks {
+			if v < version && int(v) > plan.ForceTo {
+				plan.ForceTo = int(v)
+			}
+		}
+	}
+	return plan, nil
+}
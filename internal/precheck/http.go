@@ -0,0 +1,159 @@
+// Package precheck вызывает URL проверки (pre_send_check) перед отправкой уведомления.
+package precheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/google/uuid"
+)
+
+// maxResponseSize ограничение читаемого ответа проверки.
+const maxResponseSize = 64 << 10
+
+// ErrUnexpectedStatus проверка ответила кодом вне 2xx.
+var ErrUnexpectedStatus = errors.New("pre-send check returned unexpected status")
+
+// ErrHostNotAllowed хост URL проверки не входит в список разрешенных.
+var ErrHostNotAllowed = errors.New("pre-send check host is not allowed")
+
+// Request тело POST-запроса к URL проверки.
+type Request struct {
+	ID          uuid.UUID      `json:"id"`
+	Recipient   string         `json:"recipient"`
+	Channel     domain.Channel `json:"channel"`
+	ScheduledAt time.Time      `json:"scheduled_at"`
+}
+
+// Response ответ проверки. Пустое тело означает "отправлять".
+type Response struct {
+	Send *bool `json:"send"`
+}
+
+// HTTPChecker выполняет проверку POST-запросом на Notification.PreSendCheck.
+type HTTPChecker struct {
+	client       *http.Client
+	failOpen     bool
+	allowedHosts []string
+}
+
+// HTTPCheckerOption функция настройки HTTPChecker.
+type HTTPCheckerOption func(*HTTPChecker)
+
+// WithTransport задает транспорт вызовов проверки (например, через исходящий прокси).
+func WithTransport(rt http.RoundTripper) HTTPCheckerOption {
+	return func(c *HTTPChecker) {
+		c.client.Transport = rt
+	}
+}
+
+// WithAllowedHosts разрешает вызывать проверку только на перечисленных хостах. Имя с точкой
+// в начале (".example.com") разрешает и все поддомены. Пустой список снимает ограничение.
+func WithAllowedHosts(hosts []string) HTTPCheckerOption {
+	return func(c *HTTPChecker) {
+		c.allowedHosts = c.allowedHosts[:0]
+		for _, h := range hosts {
+			if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+				c.allowedHosts = append(c.allowedHosts, h)
+			}
+		}
+	}
+}
+
+// NewHTTPChecker создает проверку с ограничением времени timeout. Редиректы не выполняются:
+// ответ 3xx считается ошибкой проверки.
+// failOpen определяет решение при сбое: true — отправлять, false — пропускать.
+func NewHTTPChecker(timeout time.Duration, failOpen bool, opts ...HTTPCheckerOption) *HTTPChecker {
+	c := &HTTPChecker{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		failOpen: failOpen,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ShouldSend вызывает URL проверки. Уведомления без URL отправляются всегда.
+func (c *HTTPChecker) ShouldSend(ctx context.Context, n *domain.Notification) (bool, error) {
+	if n.PreSendCheck == "" {
+		return true, nil
+	}
+	send, err := c.call(ctx, n)
+	if err != nil {
+		return c.failOpen, err
+	}
+	return send, nil
+}
+
+// allowed сообщает, можно ли вызывать проверку на хосте URL.
+func (c *HTTPChecker) allowed(u *url.URL) bool {
+	if len(c.allowedHosts) == 0 {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range c.allowedHosts {
+		if host == h || (strings.HasPrefix(h, ".") && (strings.HasSuffix(host, h) || host == h[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *HTTPChecker) call(ctx context.Context, n *domain.Notification) (bool, error) {
+	body, err := json.Marshal(Request{
+		ID:          n.ID,
+		Recipient:   n.Recipient,
+		Channel:     n.Channel,
+		ScheduledAt: n.ScheduledAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.PreSendCheck, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if !c.allowed(req.URL) {
+		return false, fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Hostname())
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return true, nil
+	}
+	var r Response
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return false, fmt.Errorf("decode pre-send check response: %w", err)
+	}
+	return r.Send == nil || *r.Send, nil
+}
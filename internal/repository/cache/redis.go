@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/go-redis/redis/v8"
+	wbfredis "github.com/wb-go/wbf/redis"
+)
+
+// RedisRepo реализация domain.RedisRepository поверх клиента wbf.
+type RedisRepo struct {
+	*wbfredis.Client
+	namespace string
+}
+
+// RedisOption настраивает RedisRepo.
+type RedisOption func(*RedisRepo)
+
+// WithNamespace добавляет префикс окружения ко всем ключам, чтобы несколько окружений
+// могли работать с одним Redis.
+func WithNamespace(namespace string) RedisOption {
+	return func(r *RedisRepo) {
+		r.namespace = namespace
+	}
+}
+
+// NewRedisRepo создает новый экземпляр RedisRepo.
+func NewRedisRepo(client *wbfredis.Client, opts ...RedisOption) *RedisRepo {
+	r := &RedisRepo{Client: client}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Get получает значение по ключу.
+func (r *RedisRepo) Get(ctx context.Context, key string) (string, error) {
+	return r.Client.Get(ctx, r.key(key))
+}
+
+// SetWithExpiration устанавливает значение с временем жизни.
+func (r *RedisRepo) SetWithExpiration(ctx context.Context, key string, value interface{},
+	expiration time.Duration) error {
+	return r.Client.SetWithExpiration(ctx, r.key(key), value, expiration)
+}
+
+// SetNXWithExpiration устанавливает значение с временем жизни, только если ключа еще нет.
+func (r *RedisRepo) SetNXWithExpiration(ctx context.Context, key string, value interface{},
+	expiration time.Duration) (bool, error) {
+	return r.Client.Client.SetNX(ctx, r.key(key), value, expiration).Result()
+}
+
+// Del удаляет значение по ключу.
+func (r *RedisRepo) Del(ctx context.Context, key string) error {
+	return r.Client.Del(ctx, r.key(key))
+}
+
+// MGet получает значения нескольких ключей за один запрос.
+// В результат попадают только найденные ключи.
+func (r *RedisRepo) MGet(ctx context.Context, keys []string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	namespaced := make([]string, len(keys))
+	for i, key := range keys {
+		namespaced[i] = r.key(key)
+	}
+	values, err := r.Client.Client.MGet(ctx, namespaced...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[keys[i]] = s
+		}
+	}
+	return result, nil
+}
+
+// MSetNXWithExpiration устанавливает несколько значений с временем жизни одним конвейером
+// SET NX EX (MSETNX не поддерживает TTL и не пишет ничего, если есть хоть один ключ).
+// Существующие ключи не перезаписываются и не учитываются в результате.
+func (r *RedisRepo) MSetNXWithExpiration(ctx context.Context, values map[string]interface{},
+	expiration time.Duration) (int, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	cmds := make([]*redis.BoolCmd, 0, len(values))
+	_, err := r.Client.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			cmds = append(cmds, pipe.SetNX(ctx, r.key(key), value, expiration))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	set := 0
+	for _, cmd := range cmds {
+		if cmd.Val() {
+			set++
+		}
+	}
+	return set, nil
+}
+
+// scanBatch сколько ключей запрашивать у Redis за один шаг SCAN.
+const scanBatch = 500
+
+// Inspect получает сырое значение и оставшееся время жизни ключа.
+func (r *RedisRepo) Inspect(ctx context.Context, key string) (*domain.CacheEntry, error) {
+	value, err := r.Client.Client.Get(ctx, r.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := r.Client.Client.PTTL(ctx, r.key(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CacheEntry{Key: key, Value: value, TTL: ttl}, nil
+}
+
+// ScanKeys находит ключи по шаблону через SCAN (не блокирует Redis, как KEYS).
+// Ключи возвращаются без префикса окружения.
+func (r *RedisRepo) ScanKeys(ctx context.Context, pattern string, limit int) ([]string, bool, error) {
+	var (
+		keys   []string
+		cursor uint64
+	)
+	// SCAN может вернуть один ключ несколько раз
+	seen := make(map[string]struct{})
+	prefix := r.key("")
+	for {
+		batch, next, err := r.Client.Client.Scan(ctx, cursor, r.key(pattern), scanBatch).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, k := range batch {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			if len(keys) == limit {
+				return keys, true, nil
+			}
+			seen[k] = struct{}{}
+			keys = append(keys, strings.TrimPrefix(k, prefix))
+		}
+		if next == 0 {
+			return keys, false, nil
+		}
+		cursor = next
+	}
+}
+
+// DeleteKeys удаляет ключи пачками.
+func (r *RedisRepo) DeleteKeys(ctx context.Context, keys []string) (int, error) {
+	var deleted int64
+	for start := 0; start < len(keys); start += scanBatch {
+		end := min(start+scanBatch, len(keys))
+		batch := make([]string, 0, end-start)
+		for _, k := range keys[start:end] {
+			batch = append(batch, r.key(k))
+		}
+		n, err := r.Client.Client.Del(ctx, batch...).Result()
+		deleted += n
+		if err != nil {
+			return int(deleted), err
+		}
+	}
+	return int(deleted), nil
+}
+
+// key возвращает ключ с префиксом окружения.
+func (r *RedisRepo) key(key string) string {
+	return domain.Namespaced(r.namespace, key)
+}
@@ -0,0 +1,58 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// RecordEvent сохраняет переход статуса уведомления в историю.
+func (p *PostgresRepo) RecordEvent(ctx context.Context, e domain.NotificationEvent) error {
+	ctx, done := p.observe(ctx, "RecordEvent")
+	defer done()
+
+	sqlQuery := `INSERT INTO notification_events (notification_id, from_status, to_status, actor, error)
+    VALUES ($1, $2, $3, $4, $5)`
+
+	from := sql.NullString{String: string(e.FromStatus), Valid: e.FromStatus != ""}
+	if _, err := p.DB.ExecContext(ctx, sqlQuery, e.NotificationID, from, e.ToStatus, e.Actor, e.Error); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec record event sql")
+		return err
+	}
+	return nil
+}
+
+// ListEvents получает историю статусов уведомления в порядке переходов.
+func (p *PostgresRepo) ListEvents(ctx context.Context, notificationID uuid.UUID) ([]domain.NotificationEvent, error) {
+	ctx, done := p.observe(ctx, "ListEvents")
+	defer done()
+
+	sqlQuery := `SELECT id, notification_id, COALESCE(from_status::text, ''), to_status, actor, error, created_at
+    FROM notification_events
+    WHERE notification_id = $1
+    ORDER BY created_at, id`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, notificationID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list events sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var events []domain.NotificationEvent
+	for rows.Next() {
+		var e domain.NotificationEvent
+		if err := rows.Scan(&e.ID, &e.NotificationID, &e.FromStatus, &e.ToStatus, &e.Actor, &e.Error,
+			&e.At); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list events sql")
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
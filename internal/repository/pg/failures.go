@@ -0,0 +1,61 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// RecordFailure сохраняет запись об уведомлении, исчерпавшем предел попыток.
+func (p *PostgresRepo) RecordFailure(ctx context.Context, f domain.NotificationFailure) (*domain.NotificationFailure,
+	error) {
+	ctx, done := p.observe(ctx, "RecordFailure")
+	defer done()
+
+	sqlQuery := `INSERT INTO notification_failures (notification_id, error, attempts, scheduled_at)
+    VALUES ($1, $2, $3, $4)
+    RETURNING id, failed_at`
+
+	err := p.DB.QueryRowContext(ctx, sqlQuery, f.NotificationID, f.Error, f.Attempts, f.ScheduledAt).
+		Scan(&f.ID, &f.FailedAt)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec record failure sql")
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ListFailures получает записи о неуспешных попытках уведомления, последние первыми.
+func (p *PostgresRepo) ListFailures(ctx context.Context, notificationID uuid.UUID) ([]domain.NotificationFailure,
+	error) {
+	ctx, done := p.observe(ctx, "ListFailures")
+	defer done()
+
+	sqlQuery := `SELECT id, notification_id, error, attempts, scheduled_at, failed_at
+    FROM notification_failures
+    WHERE notification_id = $1
+    ORDER BY failed_at DESC, id DESC`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, notificationID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list failures sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var failures []domain.NotificationFailure
+	for rows.Next() {
+		var f domain.NotificationFailure
+		if err := rows.Scan(&f.ID, &f.NotificationID, &f.Error, &f.Attempts, &f.ScheduledAt, &f.FailedAt); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list failures sql")
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
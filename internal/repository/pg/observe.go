@@ -0,0 +1,46 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+// WithQueryTimeout ограничивает время выполнения каждого вызова репозитория.
+// Значение 0 отключает ограничение, остается только дедлайн вызывающего контекста.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(p *PostgresRepo) {
+		p.queryTimeout = d
+	}
+}
+
+// WithSlowQueryThreshold включает журнал медленных запросов: вызовы дольше
+// порога логируются с уровнем warn. Значение 0 отключает журнал.
+func WithSlowQueryThreshold(d time.Duration) Option {
+	return func(p *PostgresRepo) {
+		p.slowQueryThreshold = d
+	}
+}
+
+// observe применяет таймаут запроса к контексту и возвращает функцию завершения,
+// которая освобождает контекст и логирует медленные или прерванные по таймауту вызовы.
+// Функцию завершения нужно вызывать после чтения всех строк результата.
+func (p *PostgresRepo) observe(ctx context.Context, op string) (context.Context, func()) {
+	start := time.Now()
+	cancel := func() {}
+	if p.queryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.queryTimeout)
+	}
+	return ctx, func() {
+		elapsed := time.Since(start)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			zlog.Logger.Warn().Str("op", op).Dur("elapsed", elapsed).Msg("query timed out")
+		} else if p.slowQueryThreshold > 0 && elapsed >= p.slowQueryThreshold {
+			zlog.Logger.Warn().Str("op", op).Dur("elapsed", elapsed).
+				Dur("threshold", p.slowQueryThreshold).Msg("slow query")
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,144 @@
+package pg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/lib/pq"
+)
+
+// payloadRefKey ключ заглушки в notifications.payload, которая ссылается на запись notification_payloads.
+const payloadRefKey = "$payload_ref"
+
+// WithLargePayloadThreshold выносит payload, который в JSON занимает больше threshold байт,
+// в таблицу notification_payloads: в строке уведомления остается только ссылка, и горячие
+// запросы по notifications не читают крупные значения. Ключ записи — SHA-256 содержимого,
+// поэтому одинаковый payload массовой рассылки хранится один раз. 0 отключает вынос.
+func WithLargePayloadThreshold(threshold int) Option {
+	return func(p *PostgresRepo) {
+		p.largePayloadThreshold = threshold
+	}
+}
+
+// encodePayload сериализует payload для записи в notifications, вынося крупный в notification_payloads.
+// Payload, который сам выглядит как ссылка, выносится всегда, чтобы при чтении его не подменило
+// чужое содержимое.
+func (p *PostgresRepo) encodePayload(ctx context.Context, payload map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	_, isRef := payloadRef(payload)
+	if !isRef && (p.largePayloadThreshold <= 0 || len(data) <= p.largePayloadThreshold) {
+		return data, nil
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	sqlQuery := `INSERT INTO notification_payloads (key, payload, size) VALUES ($1, $2, $3)
+    ON CONFLICT (key) DO NOTHING`
+	if _, err := p.DB.ExecContext(ctx, sqlQuery, key, data, len(data)); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec insert notification payload")
+		return nil, err
+	}
+	return json.Marshal(map[string]string{payloadRefKey: key})
+}
+
+// payloadRef возвращает ключ вынесенного payload, если payload — ссылка на него.
+func payloadRef(payload map[string]interface{}) (string, bool) {
+	if len(payload) != 1 {
+		return "", false
+	}
+	key, ok := payload[payloadRefKey].(string)
+	return key, ok
+}
+
+// hydratePayloads заменяет ссылки на вынесенные payload их содержимым одним запросом.
+// Если ссылок нет, к базе не обращается.
+func (p *PostgresRepo) hydratePayloads(ctx context.Context, payloads ...*map[string]interface{}) error {
+	refs := make(map[string][]*map[string]interface{})
+	for _, pl := range payloads {
+		if key, ok := payloadRef(*pl); ok {
+			refs[key] = append(refs[key], pl)
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(refs))
+	for key := range refs {
+		keys = append(keys, key)
+	}
+
+	rows, err := p.DB.QueryContext(ctx, `SELECT key, payload FROM notification_payloads WHERE key = ANY($1)`,
+		pq.Array(keys))
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec select notification payloads")
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var (
+			key string
+			raw []byte
+		)
+		if err := rows.Scan(&key, &raw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan notification payload")
+			return err
+		}
+		// каждому уведомлению своя копия: вызывающий код может изменять payload
+		for _, pl := range refs[key] {
+			*pl = nil
+			if err := json.Unmarshal(raw, pl); err != nil {
+				logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+				return err
+			}
+		}
+		delete(refs, key)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		return fmt.Errorf("%d notification payloads not found", len(refs))
+	}
+	return nil
+}
+
+// hydrateNotifications подставляет вынесенные payload прочитанных уведомлений.
+func (p *PostgresRepo) hydrateNotifications(ctx context.Context, n []domain.Notification) ([]domain.Notification,
+	error) {
+	payloads := make([]*map[string]interface{}, len(n))
+	for i := range n {
+		payloads[i] = &n[i].Payload
+	}
+	if err := p.hydratePayloads(ctx, payloads...); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// purgeOrphanPayloads удаляет вынесенные payload, на которые больше не ссылается ни одно
+// уведомление. Записи новее before не трогаются: payload записывается раньше строки
+// уведомления, и параллельное создание еще может на него сослаться.
+func (p *PostgresRepo) purgeOrphanPayloads(ctx context.Context, before time.Time) (int64, error) {
+	sqlQuery := `DELETE FROM notification_payloads lp
+    WHERE lp.created_at < $1 AND NOT EXISTS (
+        SELECT 1 FROM notifications n
+        WHERE n.payload->>'$payload_ref' IS NOT NULL AND n.payload->>'$payload_ref' = lp.key)`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, before)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec purge orphan notification payloads")
+		return 0, err
+	}
+	rows, _ := r.RowsAffected()
+	return rows, nil
+}
@@ -6,39 +6,110 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/pkg/idgen"
 	"github.com/google/uuid"
 	"github.com/wb-go/wbf/dbpg"
-	"github.com/wb-go/wbf/zlog"
 )
 
+// notificationColumns столбцы уведомления в порядке, который ожидает scanNotification.
+const notificationColumns = `id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at,
+       parent_id, correlation_id, cancel_on_confirm, pre_send_check, effective_scheduled_at, source, reprocess_count,
+       requires_approval, approved_by, approved_at, group_id, template_id, priority`
+
 // PostgresRepo структура для работы с PostgreSQL.
 type PostgresRepo struct {
-	DB *dbpg.DB
+	DB    *dbpg.DB
+	newID idgen.Generator
+
+	queryTimeout          time.Duration
+	slowQueryThreshold    time.Duration
+	largePayloadThreshold int
+}
+
+// Option функция настройки PostgresRepo.
+type Option func(*PostgresRepo)
+
+// WithIDGenerator задает генерацию идентификаторов на стороне приложения.
+// Если генератор nil, идентификатор назначает база данных.
+func WithIDGenerator(gen idgen.Generator) Option {
+	return func(p *PostgresRepo) {
+		p.newID = gen
+	}
 }
 
 // NewPostgresRepo создает новый экземпляр PostgresRepo.
-func NewPostgresRepo(db *dbpg.DB) *PostgresRepo {
-	return &PostgresRepo{
+func NewPostgresRepo(db *dbpg.DB, opts ...Option) *PostgresRepo {
+	p := &PostgresRepo{
 		DB: db,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-// Create создает новое уведомление в базе данных.
+// Create создает новое уведомление в базе данных. Если ключ идемпотентности уже занят,
+// уведомление не создается и возвращается ErrDuplicateIdempotencyKey.
 func (p *PostgresRepo) Create(ctx context.Context, n domain.CreateParams) (*domain.Notification, error) {
-	sqlQuery := `INSERT INTO notifications (recipient,channel,payload,scheduled_at,status) VALUES ($1, $2, $3, $4, $5)
- RETURNING id, retry_count, created_at, updated_at`
-	jsonData, err := json.Marshal(n.Payload)
+	ctx, done := p.observe(ctx, "Create")
+	defer done()
+
+	jsonData, err := p.encodePayload(ctx, n.Payload)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error marshalling notification payload")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error marshalling notification payload")
 		return nil, err
 	}
+	effective := n.EffectiveScheduledAt
+	if effective.IsZero() {
+		effective = n.ScheduledAt
+	}
+	row := []interface{}{n.Recipient, n.Channel, jsonData, n.ScheduledAt, n.Status,
+		nullUUID(n.ParentID), nullUUID(n.CorrelationID), n.CancelOnConfirm, n.PreSendCheck, effective, n.Source, n.RequiresApproval}
+	var optional []string
+	withKey := n.IdempotencyKey != ""
+	if withKey {
+		row = append(row, n.IdempotencyKey)
+		optional = append(optional, "idempotency_key")
+	}
+	if n.GroupID != nil {
+		row = append(row, *n.GroupID)
+		optional = append(optional, "group_id")
+	}
+	if n.TemplateID != nil {
+		row = append(row, *n.TemplateID)
+		optional = append(optional, "template_id")
+	}
+	priority := createPriority(n.Priority)
+	if priority != domain.PriorityNormal {
+		row = append(row, priority)
+		optional = append(optional, "priority")
+	}
+	if p.newID != nil {
+		id, err := p.newID()
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error generating notification id")
+			return nil, err
+		}
+		row = append(row, id)
+		optional = append(optional, "id")
+	}
+	sqlQuery, args := buildCreateSQL([][]interface{}{row}, optional)
+	if withKey {
+		sqlQuery += skipDuplicateKey
+	}
+	sqlQuery += createReturning
 	var result domain.Notification
-	if err = p.DB.QueryRowContext(ctx, sqlQuery, n.Recipient, n.Channel, jsonData, n.ScheduledAt, n.Status).Scan(
+	if err = p.DB.QueryRowContext(ctx, sqlQuery, args...).Scan(
 		&result.ID, &result.RetryCount, &result.CreatedAt, &result.UpdatedAt); err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error scanning notification")
+		if withKey && errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrDuplicateIdempotencyKey
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scanning notification")
 		return nil, err
 	}
 	result.Recipient = n.Recipient
@@ -46,8 +117,18 @@ func (p *PostgresRepo) Create(ctx context.Context, n domain.CreateParams) (*doma
 	result.Payload = n.Payload
 	result.Status = n.Status
 	result.ScheduledAt = n.ScheduledAt
+	result.ParentID = n.ParentID
+	result.CorrelationID = n.CorrelationID
+	result.CancelOnConfirm = n.CancelOnConfirm
+	result.PreSendCheck = n.PreSendCheck
+	result.EffectiveScheduledAt = effective
+	result.Source = n.Source
+	result.RequiresApproval = n.RequiresApproval
+	result.GroupID = n.GroupID
+	result.TemplateID = n.TemplateID
+	result.Priority = priority
 
-	zlog.Logger.Debug().Msgf(
+	logger.FromContext(ctx).Debug().Msgf(
 		"Created notification id: %s to:%s, channel:%s, payload: %s, scheduledAt:, %v",
 		result.ID,
 		n.Recipient,
@@ -59,22 +140,196 @@ func (p *PostgresRepo) Create(ctx context.Context, n domain.CreateParams) (*doma
 	return &result, nil
 }
 
+// CreateBatch создает уведомления одним многострочным INSERT, то есть в одной транзакции:
+// при ошибке не создается ни одно. Идентификаторы назначает генератор, если он задан,
+// иначе база данных; RETURNING многострочного VALUES возвращает строки в порядке вставки.
+// Строки с уже занятым ключом идемпотентности пропускаются, на их месте в результате nil;
+// чтобы сопоставить остальные, RETURNING такого пакета возвращает и ключ.
+func (p *PostgresRepo) CreateBatch(ctx context.Context, params []domain.CreateParams) ([]*domain.Notification, error) {
+	ctx, done := p.observe(ctx, "CreateBatch")
+	defer done()
+
+	if len(params) == 0 {
+		return nil, nil
+	}
+	rows := make([][]interface{}, len(params))
+	result := make([]*domain.Notification, len(params))
+	byID := make(map[uuid.UUID]int, len(params))
+	// byKey источник и ключ идемпотентности -> индекс, unkeyed индексы строк без ключа по порядку
+	byKey := make(map[string]int)
+	var unkeyed []int
+	withKey, withGroup, withTemplate, withPriority := false, false, false, false
+	for _, n := range params {
+		withKey = withKey || n.IdempotencyKey != ""
+		withGroup = withGroup || n.GroupID != nil
+		withTemplate = withTemplate || n.TemplateID != nil
+		withPriority = withPriority || createPriority(n.Priority) != domain.PriorityNormal
+	}
+	var optional []string
+	if withKey {
+		optional = append(optional, "idempotency_key")
+	}
+	if withGroup {
+		optional = append(optional, "group_id")
+	}
+	if withTemplate {
+		optional = append(optional, "template_id")
+	}
+	if withPriority {
+		optional = append(optional, "priority")
+	}
+	if p.newID != nil {
+		optional = append(optional, "id")
+	}
+	for i, n := range params {
+		jsonData, err := p.encodePayload(ctx, n.Payload)
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error marshalling notification payload")
+			return nil, err
+		}
+		effective := n.EffectiveScheduledAt
+		if effective.IsZero() {
+			effective = n.ScheduledAt
+		}
+		rows[i] = []interface{}{n.Recipient, n.Channel, jsonData, n.ScheduledAt, n.Status,
+			nullUUID(n.ParentID), nullUUID(n.CorrelationID), n.CancelOnConfirm, n.PreSendCheck, effective, n.Source,
+			n.RequiresApproval}
+		if withKey {
+			rows[i] = append(rows[i], nullString(n.IdempotencyKey))
+		}
+		if withGroup {
+			rows[i] = append(rows[i], nullUUID(n.GroupID))
+		}
+		if withTemplate {
+			rows[i] = append(rows[i], nullUUID(n.TemplateID))
+		}
+		if withPriority {
+			rows[i] = append(rows[i], createPriority(n.Priority))
+		}
+		if p.newID != nil {
+			id, err := p.newID()
+			if err != nil {
+				logger.FromContext(ctx).Error().Err(err).Msg("Error generating notification id")
+				return nil, err
+			}
+			rows[i] = append(rows[i], id)
+			byID[id] = i
+		}
+		if n.IdempotencyKey == "" {
+			unkeyed = append(unkeyed, i)
+		} else if _, ok := byKey[n.Source+"\x00"+n.IdempotencyKey]; !ok {
+			byKey[n.Source+"\x00"+n.IdempotencyKey] = i
+		}
+		result[i] = &domain.Notification{
+			Recipient:            n.Recipient,
+			Channel:              n.Channel,
+			Payload:              n.Payload,
+			Status:               n.Status,
+			ScheduledAt:          n.ScheduledAt,
+			ParentID:             n.ParentID,
+			CorrelationID:        n.CorrelationID,
+			CancelOnConfirm:      n.CancelOnConfirm,
+			PreSendCheck:         n.PreSendCheck,
+			EffectiveScheduledAt: effective,
+			Source:               n.Source,
+			RequiresApproval:     n.RequiresApproval,
+			GroupID:              n.GroupID,
+			TemplateID:           n.TemplateID,
+			Priority:             createPriority(n.Priority),
+		}
+	}
+
+	sqlQuery, args := buildCreateSQL(rows, optional)
+	if withKey {
+		sqlQuery += skipDuplicateKey + createReturningKey
+	} else {
+		sqlQuery += createReturning
+	}
+	dbRows, err := p.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("count", len(params)).Msg("Error creating notifications")
+		return nil, err
+	}
+	defer dbRows.Close()
+
+	created := make([]bool, len(params))
+	i, plain := 0, 0
+	for dbRows.Next() {
+		var (
+			id                   uuid.UUID
+			retryCount           int
+			createdAt, updatedAt time.Time
+			source               string
+			key                  sql.NullString
+		)
+		dest := []interface{}{&id, &retryCount, &createdAt, &updatedAt}
+		if withKey {
+			dest = append(dest, &source, &key)
+		}
+		if err := dbRows.Scan(dest...); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scanning notification")
+			return nil, err
+		}
+		idx, ok := i, true
+		switch {
+		case p.newID != nil:
+			if idx, ok = byID[id]; !ok {
+				return nil, fmt.Errorf("unexpected notification id %s returned", id)
+			}
+		case key.Valid:
+			if idx, ok = byKey[source+"\x00"+key.String]; !ok {
+				return nil, fmt.Errorf("unexpected idempotency key %q returned", key.String)
+			}
+		case withKey:
+			if plain >= len(unkeyed) {
+				return nil, fmt.Errorf("more notifications returned than inserted")
+			}
+			idx = unkeyed[plain]
+			plain++
+		}
+		if idx >= len(result) {
+			return nil, fmt.Errorf("more notifications returned than inserted")
+		}
+		result[idx].ID = id
+		result[idx].RetryCount = retryCount
+		result[idx].CreatedAt = createdAt
+		result[idx].UpdatedAt = updatedAt
+		created[idx] = true
+		i++
+	}
+	if err := dbRows.Err(); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error iterating created notifications")
+		return nil, err
+	}
+	for idx, ok := range created {
+		if ok {
+			continue
+		}
+		if params[idx].IdempotencyKey == "" {
+			return nil, fmt.Errorf("created %d notifications, expected %d", i, len(params))
+		}
+		result[idx] = nil
+	}
+
+	logger.FromContext(ctx).Debug().Msgf("Created %d of %d notifications", i, len(params))
+	return result, nil
+}
+
 // GetByID получает уведомление по ID из базы данных.
 func (p *PostgresRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	ctx, done := p.observe(ctx, "GetByID")
+	defer done()
+
 	start := time.Now()
 
-	sqlQuery := `SELECT id, recipient, channel, 
-       payload, scheduled_at, status, 
-       retry_count, created_at, updated_at 
-	FROM notifications WHERE id = $1 LIMIT 1`
+	sqlQuery := `SELECT ` + notificationColumns + `
+	FROM notifications WHERE id = $1 AND deleted_at IS NULL LIMIT 1`
 
 	var result domain.Notification
 	var payloadRaw []byte
 
-	if err := p.DB.QueryRowContext(ctx, sqlQuery, id).Scan(&result.ID, &result.Recipient, &result.Channel,
-		&payloadRaw, &result.ScheduledAt, &result.Status,
-		&result.RetryCount, &result.CreatedAt, &result.UpdatedAt); err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error scan notification fields")
+	if err := scanNotification(p.DB.QueryRowContext(ctx, sqlQuery, id), &result, &payloadRaw); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan notification fields")
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrNotFound
 		}
@@ -83,14 +338,46 @@ func (p *PostgresRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notif
 
 	err := json.Unmarshal(payloadRaw, &result.Payload)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error unmarshalling notification payload")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+	}
+	if err := p.hydratePayloads(ctx, &result.Payload); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Debug().Msgf("Get notification by id: %s result: %v : TIME: %s", id, result, time.Since(start))
+	return &result, nil
+}
+
+// GetByIdempotencyKey получает неудаленное уведомление по системе-источнику и ключу идемпотентности.
+func (p *PostgresRepo) GetByIdempotencyKey(ctx context.Context, source, key string) (*domain.Notification, error) {
+	ctx, done := p.observe(ctx, "GetByIdempotencyKey")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `
+	FROM notifications WHERE source = $1 AND idempotency_key = $2 AND deleted_at IS NULL LIMIT 1`
+
+	var result domain.Notification
+	var payloadRaw []byte
+	if err := scanNotification(p.DB.QueryRowContext(ctx, sqlQuery, source, key), &result, &payloadRaw); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan notification fields")
+		return nil, err
+	}
+	if err := json.Unmarshal(payloadRaw, &result.Payload); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+	}
+	if err := p.hydratePayloads(ctx, &result.Payload); err != nil {
+		return nil, err
 	}
-	zlog.Logger.Debug().Msgf("Get notification by id: %s result: %v : TIME: %s", id, result, time.Since(start))
 	return &result, nil
 }
 
 // Update обновляет уведомление в базе данных с указанными параметрами.
 func (p *PostgresRepo) Update(ctx context.Context, id uuid.UUID, opts ...domain.UpdateOption) error {
+	ctx, done := p.observe(ctx, "Update")
+	defer done()
+
 	if len(opts) == 0 {
 		return errors.New("no update options provided")
 	}
@@ -100,20 +387,28 @@ func (p *PostgresRepo) Update(ctx context.Context, id uuid.UUID, opts ...domain.
 		opt(params)
 	}
 
-	query, args, err := buildUpdateSQL(id, params)
+	var payload []byte
+	if params.Payload != nil && params.Payload.Set {
+		var err error
+		if payload, err = p.encodePayload(ctx, params.Payload.Value); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error marshalling notification payload")
+			return err
+		}
+	}
+	query, args, err := buildUpdateSQL(id, params, payload)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error build update sql notification")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error build update sql notification")
 		return err
 	}
 
 	result, err := p.DB.ExecContext(ctx, query, args...)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error exec update sql notification")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec update sql notification")
 		return err
 	}
 	rowAffected, _ := result.RowsAffected()
 	if rowAffected == 0 {
-		zlog.Logger.Warn().Msgf("Update notification id: %v No rows affected", id)
+		logger.FromContext(ctx).Warn().Msgf("Update notification id: %v No rows affected", id)
 		return domain.ErrNoRowAffected
 	}
 
@@ -124,21 +419,29 @@ func (p *PostgresRepo) Update(ctx context.Context, id uuid.UUID, opts ...domain.
 // (статус pending или processing, обновленных до указанного времени).
 func (p *PostgresRepo) ListPendingAndProcessingBefore(ctx context.Context, t time.Time,
 	limit, offset int) ([]domain.Notification, error) {
-	sqlQuery := `SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at
+	ctx, done := p.observe(ctx, "ListPendingAndProcessingBefore")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `
     FROM notifications
-    WHERE scheduled_at <= $1
-      AND status = $2 OR (status = $3 AND updated_at < NOW() - INTERVAL '10 minutes')`
+    WHERE deleted_at IS NULL
+      AND ((status = $2 AND effective_scheduled_at <= $1)
+        OR (status = $3 AND updated_at < NOW() - INTERVAL '10 minutes'))
+    ORDER BY effective_scheduled_at, id`
 
+	args := []interface{}{t, domain.StatusPending, domain.StatusProcessing}
 	if limit > 0 {
-		sqlQuery += fmt.Sprintf(" LIMIT %d", limit)
+		args = append(args, limit)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
 	}
 	if offset > 0 {
-		sqlQuery += fmt.Sprintf(" OFFSET %d", offset)
+		args = append(args, offset)
+		sqlQuery += fmt.Sprintf(" OFFSET $%d", len(args))
 	}
 
-	rows, err := p.DB.QueryContext(ctx, sqlQuery, t, domain.StatusPending, domain.StatusProcessing)
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error exec list pending before sql")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list pending before sql")
 		return nil, err
 	}
 
@@ -152,49 +455,641 @@ func (p *PostgresRepo) ListPendingAndProcessingBefore(ctx context.Context, t tim
 		var val domain.Notification
 		var payloadRaw []byte
 
-		err = rows.Scan(&val.ID, &val.Recipient,
-			&val.Channel, &payloadRaw, &val.ScheduledAt,
-			&val.Status, &val.RetryCount, &val.CreatedAt, &val.UpdatedAt)
+		err = scanNotification(rows, &val, &payloadRaw)
 		if err != nil {
-			zlog.Logger.Error().Err(err).Msg("Error scan list pending before sql")
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list pending before sql")
 			return nil, err
 		}
 
 		err = json.Unmarshal(payloadRaw, &val.Payload)
 		if err != nil {
-			zlog.Logger.Error().Err(err).Msg("Error unmarshalling notification payload")
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
 			return nil, err
 		}
 
 		n = append(n, val)
 	}
 	if len(n) == 0 {
-		zlog.Logger.Debug().Msgf("No pending notifications found")
+		logger.FromContext(ctx).Debug().Msgf("No pending notifications found")
 		return n, domain.ErrNotFound
 	}
-	return n, nil
+	return p.hydrateNotifications(ctx, n)
+}
+
+// ListPendingAndProcessingAfter получает страницу зависших уведомлений по ключу
+// (effective_scheduled_at, id): в отличие от OFFSET, каждая страница читает только свои строки
+// по индексу, а строки, изменившиеся между страницами, не сдвигают выборку.
+func (p *PostgresRepo) ListPendingAndProcessingAfter(ctx context.Context, t time.Time, after *domain.OrphanCursor,
+	limit int) ([]domain.Notification, error) {
+	ctx, done := p.observe(ctx, "ListPendingAndProcessingAfter")
+	defer done()
+
+	sqlQuery, args := buildOrphansPageSQL(t, after, limit)
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list pending after sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		if err = scanNotification(rows, &val, &payloadRaw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list pending after sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
+}
+
+// ListPendingScheduledBetween получает ожидающие уведомления, запланированные в интервале [from, to).
+func (p *PostgresRepo) ListPendingScheduledBetween(ctx context.Context, from, to time.Time,
+	limit int) ([]domain.Notification, error) {
+	ctx, done := p.observe(ctx, "ListPendingScheduledBetween")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `
+    FROM notifications
+    WHERE deleted_at IS NULL AND status = $1 AND effective_scheduled_at >= $2 AND effective_scheduled_at < $3
+    ORDER BY effective_scheduled_at, id
+    LIMIT $4`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, domain.StatusPending, from, to, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list pending scheduled between sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		if err = scanNotification(rows, &val, &payloadRaw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list pending scheduled between sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
+}
+
+// ListRelated получает уведомления цепочки с корнем rootID, включая сам корень.
+func (p *PostgresRepo) ListRelated(ctx context.Context, rootID uuid.UUID) ([]domain.Notification, error) {
+	ctx, done := p.observe(ctx, "ListRelated")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `
+    FROM notifications
+    WHERE deleted_at IS NULL AND (id = $1 OR correlation_id = $1)
+    ORDER BY created_at, id`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, rootID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list related sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		if err = scanNotification(rows, &val, &payloadRaw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list related sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
+}
+
+// ListGroup получает неудаленные уведомления группы в порядке создания.
+func (p *PostgresRepo) ListGroup(ctx context.Context, groupID uuid.UUID) ([]domain.Notification, error) {
+	ctx, done := p.observe(ctx, "ListGroup")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `
+    FROM notifications
+    WHERE deleted_at IS NULL AND group_id = $1
+    ORDER BY created_at, id`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, groupID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list group sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		if err = scanNotification(rows, &val, &payloadRaw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list group sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
+}
+
+// List получает неудаленные уведомления по фильтрам, новые по scheduled_at первыми.
+func (p *PostgresRepo) List(ctx context.Context, filter domain.ListFilter, limit, offset int) ([]domain.Notification,
+	error) {
+	ctx, done := p.observe(ctx, "List")
+	defer done()
+
+	sqlQuery, args := buildListSQL(filter, limit, offset)
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		var extra []any
+		var deletedAt sql.NullTime
+		if filter.IncludeDeleted {
+			extra = append(extra, &deletedAt)
+		}
+		if err = scanNotification(rows, &val, &payloadRaw, extra...); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list sql")
+			return nil, err
+		}
+		if deletedAt.Valid {
+			val.DeletedAt = &deletedAt.Time
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
+}
+
+// Reschedule переносит ожидающие уведомления одним UPDATE, то есть в одной транзакции.
+func (p *PostgresRepo) Reschedule(ctx context.Context, params domain.RescheduleParams) ([]domain.Notification, error) {
+	ctx, done := p.observe(ctx, "Reschedule")
+	defer done()
+
+	sqlQuery, args := buildRescheduleSQL(params)
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec reschedule sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		if err = scanNotification(rows, &val, &payloadRaw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan reschedule sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
 }
 
 // PendingToProcess изменяет статус уведомления с pending на processing.
 func (p *PostgresRepo) PendingToProcess(ctx context.Context, id uuid.UUID) (bool, error) {
-	sqlQuery := `UPDATE notifications SET status = $1 WHERE id = $2 AND status = $3`
+	ctx, done := p.observe(ctx, "PendingToProcess")
+	defer done()
+
+	sqlQuery := `UPDATE notifications SET status = $1 WHERE id = $2 AND status = $3 AND deleted_at IS NULL`
 
 	r, err := p.DB.ExecContext(ctx, sqlQuery, domain.StatusProcessing, id, domain.StatusPending)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error exec pending to process notifications")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec pending to process notifications")
+		return false, err
+	}
+	rows, _ := r.RowsAffected()
+	return rows > 0, nil
+}
+
+// Approve одобряет уведомление, ожидающее одобрения, и переводит его в status.
+func (p *PostgresRepo) Approve(ctx context.Context, id uuid.UUID, approver string,
+	status domain.Status) (bool, error) {
+	ctx, done := p.observe(ctx, "Approve")
+	defer done()
+
+	sqlQuery := `UPDATE notifications SET status = $1, approved_by = $2, approved_at = NOW()
+    WHERE id = $3 AND status = $4 AND deleted_at IS NULL`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, status, approver, id, domain.StatusAwaitingApproval)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec approve notification")
 		return false, err
 	}
 	rows, _ := r.RowsAffected()
 	return rows > 0, nil
 }
 
+// FailedToProcess возвращает неуспешное уведомление в отправку.
+func (p *PostgresRepo) FailedToProcess(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, done := p.observe(ctx, "FailedToProcess")
+	defer done()
+
+	sqlQuery := `UPDATE notifications SET status = $1, retry_count = 0, reprocess_count = reprocess_count + 1
+    WHERE id = $2 AND status = $3 AND deleted_at IS NULL`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, domain.StatusProcessing, id, domain.StatusFailed)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec failed to process notifications")
+		return false, err
+	}
+	rows, _ := r.RowsAffected()
+	return rows > 0, nil
+}
+
+// Delete физически удаляет уведомление. Связанные записи удаляются каскадно
+// по внешним ключам (ON DELETE CASCADE).
+func (p *PostgresRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, done := p.observe(ctx, "Delete")
+	defer done()
+
+	sqlQuery := `DELETE FROM notifications WHERE id = $1`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, id)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec delete notification")
+		return err
+	}
+	rows, _ := r.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SoftDelete помечает уведомление удаленным.
+func (p *PostgresRepo) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	ctx, done := p.observe(ctx, "SoftDelete")
+	defer done()
+
+	sqlQuery := `UPDATE notifications SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, id)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec soft delete notification")
+		return err
+	}
+	rows, _ := r.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// GetByIDWithDeleted получает уведомление по ID, включая мягко удаленные.
+func (p *PostgresRepo) GetByIDWithDeleted(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	ctx, done := p.observe(ctx, "GetByIDWithDeleted")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `, deleted_at
+	FROM notifications WHERE id = $1 LIMIT 1`
+
+	var result domain.Notification
+	var payloadRaw []byte
+	var deletedAt sql.NullTime
+
+	if err := scanNotification(p.DB.QueryRowContext(ctx, sqlQuery, id), &result, &payloadRaw,
+		&deletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan notification fields")
+		return nil, err
+	}
+	if deletedAt.Valid {
+		result.DeletedAt = &deletedAt.Time
+	}
+
+	if err := json.Unmarshal(payloadRaw, &result.Payload); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+	}
+	if err := p.hydratePayloads(ctx, &result.Payload); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CountBacklog считает неотправленные уведомления, время отправки которых наступило до t.
+func (p *PostgresRepo) CountBacklog(ctx context.Context, t time.Time) (int, error) {
+	ctx, done := p.observe(ctx, "CountBacklog")
+	defer done()
+
+	sqlQuery := `SELECT count(*) FROM notifications
+    WHERE deleted_at IS NULL AND status IN ($1, $2) AND effective_scheduled_at < $3`
+
+	var count int
+	err := p.DB.QueryRowContext(ctx, sqlQuery, domain.StatusPending, domain.StatusProcessing, t).Scan(&count)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec count backlog sql")
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListFailedBefore получает неуспешные уведомления, которые можно вернуть в отправку.
+func (p *PostgresRepo) ListFailedBefore(ctx context.Context, t time.Time,
+	maxCycles, limit int) ([]domain.Notification, error) {
+	ctx, done := p.observe(ctx, "ListFailedBefore")
+	defer done()
+
+	sqlQuery := `SELECT ` + notificationColumns + `
+    FROM notifications
+    WHERE deleted_at IS NULL AND status = $1 AND updated_at < $2 AND reprocess_count < $3
+    ORDER BY updated_at, id
+    LIMIT $4`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, domain.StatusFailed, t, maxCycles, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list failed before sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var n []domain.Notification
+	for rows.Next() {
+		var val domain.Notification
+		var payloadRaw []byte
+		if err = scanNotification(rows, &val, &payloadRaw); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list failed before sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &val.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		n = append(n, val)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p.hydrateNotifications(ctx, n)
+}
+
+// CountProcessingBefore считает уведомления в processing, не обновлявшиеся с t.
+func (p *PostgresRepo) CountProcessingBefore(ctx context.Context, t time.Time) (int, error) {
+	ctx, done := p.observe(ctx, "CountProcessingBefore")
+	defer done()
+
+	sqlQuery := `SELECT count(*) FROM notifications WHERE deleted_at IS NULL AND status = $1 AND updated_at < $2`
+
+	var count int
+	if err := p.DB.QueryRowContext(ctx, sqlQuery, domain.StatusProcessing, t).Scan(&count); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec count processing before sql")
+		return 0, err
+	}
+	return count, nil
+}
+
+// PurgeDeletedBefore физически удаляет уведомления, мягко удаленные до указанного времени.
+// При включенном выносе крупных payload удаляются и вынесенные payload, на которые больше
+// не ссылается ни одно уведомление.
+func (p *PostgresRepo) PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	ctx, done := p.observe(ctx, "PurgeDeletedBefore")
+	defer done()
+
+	sqlQuery := `DELETE FROM notifications WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, t)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec purge deleted notifications")
+		return 0, err
+	}
+	rows, _ := r.RowsAffected()
+	if p.largePayloadThreshold > 0 {
+		if _, err := p.purgeOrphanPayloads(ctx, t); err != nil {
+			return rows, err
+		}
+	}
+	return rows, nil
+}
+
+// ListDuplicateDeliveries группирует уведомления, ушедшие провайдеру (sent_at) после since, по получателю,
+// каналу и md5 от payload. Мягко удаленные тоже учитываются: они уже были отправлены.
+func (p *PostgresRepo) ListDuplicateDeliveries(ctx context.Context, since time.Time,
+	limit int) ([]domain.DuplicateGroup, error) {
+	ctx, done := p.observe(ctx, "ListDuplicateDeliveries")
+	defer done()
+
+	sqlQuery := `SELECT recipient, channel, md5(payload::text) AS payload_hash, min(sent_at), max(sent_at),
+       string_agg(id::text, ',' ORDER BY sent_at, id)
+    FROM notifications
+    WHERE status IN ($1, $2, $3, $4) AND sent_at >= $5
+    GROUP BY recipient, channel, payload_hash
+    HAVING count(*) > 1
+    ORDER BY count(*) DESC, max(sent_at) DESC
+    LIMIT $6`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, domain.StatusSent, domain.StatusDelivered, domain.StatusRead,
+		domain.StatusBounced, since, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list duplicate deliveries sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var groups []domain.DuplicateGroup
+	for rows.Next() {
+		var g domain.DuplicateGroup
+		var ids string
+		if err = rows.Scan(&g.Recipient, &g.Channel, &g.PayloadHash, &g.FirstAt, &g.LastAt, &ids); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list duplicate deliveries sql")
+			return nil, err
+		}
+		for _, raw := range strings.Split(ids, ",") {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, fmt.Errorf("parse duplicate id %q: %w", raw, err)
+			}
+			g.IDs = append(g.IDs, id)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// Search ищет неудаленные уведомления по тексту, см. buildSearchSQL.
+func (p *PostgresRepo) Search(ctx context.Context, params domain.SearchParams) ([]domain.SearchHit, error) {
+	ctx, done := p.observe(ctx, "Search")
+	defer done()
+
+	sqlQuery, args := buildSearchSQL(params)
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec search sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var hits []domain.SearchHit
+	for rows.Next() {
+		var hit domain.SearchHit
+		var payloadRaw []byte
+		if err = scanNotification(rows, &hit.Notification, &payloadRaw, &hit.Rank); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan search sql")
+			return nil, err
+		}
+		if err = json.Unmarshal(payloadRaw, &hit.Payload); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error unmarshalling notification payload")
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	payloads := make([]*map[string]interface{}, len(hits))
+	for i := range hits {
+		payloads[i] = &hits[i].Payload
+	}
+	if err = p.hydratePayloads(ctx, payloads...); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// CountOutcomesByChannel считает по каналам успешные и неуспешные отправки, завершившиеся в [from, to).
+func (p *PostgresRepo) CountOutcomesByChannel(ctx context.Context, from, to time.Time) (map[domain.Channel]domain.OutcomeCounts,
+	error) {
+	ctx, done := p.observe(ctx, "CountOutcomesByChannel")
+	defer done()
+
+	sqlQuery := `SELECT channel,
+       count(*) FILTER (WHERE status IN ($1, $2, $3)),
+       count(*) FILTER (WHERE status IN ($4, $5))
+    FROM notifications
+    WHERE status IN ($1, $2, $3, $4, $5) AND updated_at >= $6 AND updated_at < $7
+    GROUP BY channel`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery, domain.StatusSent, domain.StatusDelivered, domain.StatusRead,
+		domain.StatusFailed, domain.StatusBounced, from, to)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec count outcomes sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	counts := make(map[domain.Channel]domain.OutcomeCounts)
+	for rows.Next() {
+		var ch domain.Channel
+		var c domain.OutcomeCounts
+		if err = rows.Scan(&ch, &c.Sent, &c.Failed); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan count outcomes sql")
+			return nil, err
+		}
+		counts[ch] = c
+	}
+	return counts, rows.Err()
+}
+
+// CountSLA считает уведомления цели со сроком SLA в [from, to) и отправленные вовремя.
+func (p *PostgresRepo) CountSLA(ctx context.Context, target domain.SLATarget, from, to time.Time) (domain.SLACounts,
+	error) {
+	ctx, done := p.observe(ctx, "CountSLA")
+	defer done()
+
+	sqlQuery := `SELECT count(*) FILTER (WHERE sent_at IS NOT NULL AND sent_at <= effective_scheduled_at + make_interval(secs => $1)),
+       count(*)
+    FROM notifications
+    WHERE channel = $2 AND status NOT IN ($3, $4, $5, $6) AND effective_scheduled_at >= $7 AND effective_scheduled_at < $8`
+	args := []interface{}{target.Within.Seconds(), target.Channel, domain.StatusCancelled, domain.StatusDraft,
+		domain.StatusAwaitingApproval, domain.StatusSuppressed, from.Add(-target.Within), to.Add(-target.Within)}
+	if target.Source != "" {
+		sqlQuery += ` AND source = $9`
+		args = append(args, target.Source)
+	}
+
+	var c domain.SLACounts
+	if err := p.DB.QueryRowContext(ctx, sqlQuery, args...).Scan(&c.Met, &c.Total); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec count sla sql")
+		return c, err
+	}
+	return c, nil
+}
+
 // IncRetryCount увеличивает счетчик попыток для уведомления.
 func (p *PostgresRepo) IncRetryCount(ctx context.Context, id uuid.UUID) error {
-	sqlQuery := `UPDATE notifications SET retry_count = retry_count + 1 WHERE id = $1`
+	ctx, done := p.observe(ctx, "IncRetryCount")
+	defer done()
+
+	sqlQuery := `UPDATE notifications SET retry_count = retry_count + 1 WHERE id = $1 AND deleted_at IS NULL`
 
 	r, err := p.DB.ExecContext(ctx, sqlQuery, id)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("Error exec retry count")
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec retry count")
 		return err
 	}
 	rows, _ := r.RowsAffected()
@@ -203,3 +1098,57 @@ func (p *PostgresRepo) IncRetryCount(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// rowScanner общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanNotification читает столбцы notificationColumns и следом extra.
+// Payload возвращается в payloadRaw без разбора.
+func scanNotification(row rowScanner, n *domain.Notification, payloadRaw *[]byte, extra ...any) error {
+	var parentID, correlationID, groupID, templateID uuid.NullUUID
+	var approvedAt sql.NullTime
+	dest := append([]any{&n.ID, &n.Recipient, &n.Channel, payloadRaw, &n.ScheduledAt, &n.Status,
+		&n.RetryCount, &n.CreatedAt, &n.UpdatedAt, &parentID, &correlationID, &n.CancelOnConfirm,
+		&n.PreSendCheck, &n.EffectiveScheduledAt, &n.Source, &n.ReprocessCount,
+		&n.RequiresApproval, &n.ApprovedBy, &approvedAt, &groupID, &templateID, &n.Priority}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	n.ParentID, n.CorrelationID, n.GroupID = uuidPtr(parentID), uuidPtr(correlationID), uuidPtr(groupID)
+	n.TemplateID = uuidPtr(templateID)
+	if approvedAt.Valid {
+		n.ApprovedAt = &approvedAt.Time
+	}
+	return nil
+}
+
+// createPriority приоритет создаваемого уведомления: пустой означает normal, значение столбца по умолчанию.
+func createPriority(p domain.Priority) domain.Priority {
+	if p == "" {
+		return domain.PriorityNormal
+	}
+	return p
+}
+
+// nullUUID преобразует необязательный идентификатор в значение для SQL.
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+// nullString преобразует необязательную строку в значение для SQL: пустая становится NULL.
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// uuidPtr преобразует NULL-идентификатор из базы в nil.
+func uuidPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -0,0 +1,112 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// schemaColumns столбцы схемы payload в порядке, который ожидает scanSchema.
+const schemaColumns = `source, channel, schema, created_at, updated_at`
+
+// SaveSchema создает схему пары источник-канал или заменяет существующую.
+func (p *PostgresRepo) SaveSchema(ctx context.Context, source string, ch domain.Channel,
+	schema map[string]interface{}) (*domain.PayloadSchema, error) {
+	ctx, done := p.observe(ctx, "SaveSchema")
+	defer done()
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	sqlQuery := `INSERT INTO payload_schemas (source, channel, schema) VALUES ($1, $2, $3)
+    ON CONFLICT (source, channel) DO UPDATE SET schema = EXCLUDED.schema, updated_at = NOW()
+    RETURNING ` + schemaColumns
+
+	s, err := scanSchema(p.DB.QueryRowContext(ctx, sqlQuery, source, ch, data))
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec save schema sql")
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSchema получает схему пары источник-канал.
+func (p *PostgresRepo) GetSchema(ctx context.Context, source string, ch domain.Channel) (*domain.PayloadSchema,
+	error) {
+	ctx, done := p.observe(ctx, "GetSchema")
+	defer done()
+
+	sqlQuery := `SELECT ` + schemaColumns + ` FROM payload_schemas WHERE source = $1 AND channel = $2`
+
+	s, err := scanSchema(p.DB.QueryRowContext(ctx, sqlQuery, source, ch))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSchemaNotFound
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan schema")
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListSchemas получает все схемы в порядке источников и каналов.
+func (p *PostgresRepo) ListSchemas(ctx context.Context) ([]domain.PayloadSchema, error) {
+	ctx, done := p.observe(ctx, "ListSchemas")
+	defer done()
+
+	sqlQuery := `SELECT ` + schemaColumns + ` FROM payload_schemas ORDER BY source, channel`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list schemas sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var schemas []domain.PayloadSchema
+	for rows.Next() {
+		s, err := scanSchema(rows)
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list schemas sql")
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, rows.Err()
+}
+
+// DeleteSchema удаляет схему пары источник-канал.
+func (p *PostgresRepo) DeleteSchema(ctx context.Context, source string, ch domain.Channel) error {
+	ctx, done := p.observe(ctx, "DeleteSchema")
+	defer done()
+
+	r, err := p.DB.ExecContext(ctx, `DELETE FROM payload_schemas WHERE source = $1 AND channel = $2`, source, ch)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec delete schema")
+		return err
+	}
+	rows, _ := r.RowsAffected()
+	if rows == 0 {
+		return domain.ErrSchemaNotFound
+	}
+	return nil
+}
+
+func scanSchema(row rowScanner) (domain.PayloadSchema, error) {
+	var s domain.PayloadSchema
+	var raw []byte
+	if err := row.Scan(&s.Source, &s.Channel, &raw, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(raw, &s.Schema); err != nil {
+		return s, err
+	}
+	return s, nil
+}
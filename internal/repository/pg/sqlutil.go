@@ -1,16 +1,17 @@
 package pg
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"DelayedNotifier/internal/domain"
 	"github.com/google/uuid"
 )
 
-// buildUpdateSQL строит SQL запрос для обновления уведомления.
-func buildUpdateSQL(id uuid.UUID, params *domain.UpdateParams) (string, []interface{}, error) {
+// buildUpdateSQL строит SQL запрос для обновления уведомления; payload — params.Payload,
+// уже сериализованный для записи (см. encodePayload).
+func buildUpdateSQL(id uuid.UUID, params *domain.UpdateParams, payload []byte) (string, []interface{}, error) {
 	var (
 		sets   []string
 		args   []interface{}
@@ -21,6 +22,9 @@ func buildUpdateSQL(id uuid.UUID, params *domain.UpdateParams) (string, []interf
 		sets = append(sets, fmt.Sprintf("status = $%d", argIdx))
 		args = append(args, *params.Status)
 		argIdx++
+		if *params.Status == domain.StatusSent {
+			sets = append(sets, "sent_at = NOW()")
+		}
 	}
 	if params.RetryCountInc != nil {
 		sets = append(sets, "retry_count = retry_count + 1")
@@ -30,26 +34,228 @@ func buildUpdateSQL(id uuid.UUID, params *domain.UpdateParams) (string, []interf
 		args = append(args, *params.ScheduledAt)
 		argIdx++
 	}
+	if params.EffectiveScheduledAt != nil {
+		sets = append(sets, fmt.Sprintf("effective_scheduled_at = $%d", argIdx))
+		args = append(args, *params.EffectiveScheduledAt)
+		argIdx++
+	}
 	if params.Channel != nil {
 		sets = append(sets, fmt.Sprintf("channel = $%d", argIdx))
 		args = append(args, *params.Channel)
 		argIdx++
 	}
+	if params.Recipient != nil {
+		sets = append(sets, fmt.Sprintf("recipient = $%d", argIdx))
+		args = append(args, *params.Recipient)
+		argIdx++
+	}
 	if params.Payload != nil && params.Payload.Set {
-		jsonData, err := json.Marshal(params.Payload.Value)
-		if err != nil {
-			return "", nil, err
-		}
 		sets = append(sets, fmt.Sprintf("payload = $%d", argIdx))
-		args = append(args, jsonData)
+		args = append(args, payload)
 		argIdx++
 	}
 	if len(sets) == 0 {
 		return "", nil, fmt.Errorf("no fields to update")
 	}
-	query := fmt.Sprintf("UPDATE notifications SET %s WHERE id = $%d",
+	if params.UpdatedAt != nil {
+		sets = append(sets, fmt.Sprintf("updated_at = $%d", argIdx))
+		args = append(args, *params.UpdatedAt)
+		argIdx++
+	}
+	query := fmt.Sprintf("UPDATE notifications SET %s WHERE id = $%d AND deleted_at IS NULL",
 		strings.Join(sets, ", "), argIdx) //nolint:nolint
 	args = append(args, id)
+	if params.IfUpdatedAt != nil {
+		argIdx++
+		query += fmt.Sprintf(" AND updated_at = $%d", argIdx)
+		args = append(args, *params.IfUpdatedAt)
+	}
 
 	return query, args, nil
 }
+
+// createColumns столбцы INSERT уведомления без необязательных idempotency_key, group_id, template_id,
+// priority и id.
+const createColumns = `recipient,channel,payload,scheduled_at,status,parent_id,correlation_id,
+ cancel_on_confirm,pre_send_check,effective_scheduled_at,source,requires_approval`
+
+// createReturning возвращаемые INSERT столбцы, которые назначает база данных.
+const createReturning = "\n RETURNING id, retry_count, created_at, updated_at"
+
+// createReturningKey возвращаемые INSERT столбцы пакета с ключами идемпотентности: по ключу
+// строка сопоставляется с параметрами, когда часть строк пропущена.
+const createReturningKey = createReturning + ", source, idempotency_key"
+
+// skipDuplicateKey пропускает вставку уведомления с уже занятым ключом идемпотентности.
+const skipDuplicateKey = "\n ON CONFLICT (source, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING"
+
+// buildCreateSQL строит INSERT уведомлений без RETURNING. Каждая строка rows содержит значения
+// столбцов в порядке createColumns, а за ними — необязательных столбцов optional
+// (idempotency_key, group_id, template_id, priority, id) в том же порядке.
+func buildCreateSQL(rows [][]interface{}, optional []string) (string, []interface{}) {
+	columns := createColumns
+	for _, c := range optional {
+		columns += "," + c
+	}
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, row := range rows {
+		placeholders := make([]string, len(row))
+		for i, v := range row {
+			args = append(args, v)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+	}
+	return "INSERT INTO notifications (" + columns + ")\n VALUES " + strings.Join(values, ",\n "), args
+}
+
+// buildSearchSQL строит запрос поиска по тексту. Совпадением считается попадание в search_document
+// (websearch_to_tsquery) или фрагмент получателя (ILIKE, индекс pg_trgm); ранг складывается из
+// ts_rank и триграммного сходства получателя со строкой поиска. Без строки поиска остаются
+// только фильтры, ранг нулевой.
+func buildSearchSQL(params domain.SearchParams) (string, []interface{}) {
+	var args []interface{}
+	rank, from, where := "0", "notifications", "deleted_at IS NULL"
+	if params.Query != "" {
+		args = append(args, params.Query, "%"+escapeLike(params.Query)+"%")
+		rank = "ts_rank(search_document, q) + similarity(recipient, $1)"
+		from += ", websearch_to_tsquery('simple', $1) q"
+		where += " AND (search_document @@ q OR recipient ILIKE $2)"
+	}
+	if params.Channel != "" {
+		args = append(args, params.Channel)
+		where += fmt.Sprintf(" AND channel = $%d", len(args))
+	}
+	if params.Status != "" {
+		args = append(args, params.Status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if !params.Since.IsZero() {
+		args = append(args, params.Since)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	args = append(args, params.Limit)
+	sqlQuery := `SELECT ` + notificationColumns + `,
+       ` + rank + ` AS rank
+    FROM ` + from + `
+    WHERE ` + where + fmt.Sprintf(`
+    ORDER BY rank DESC, created_at DESC
+    LIMIT $%d`, len(args))
+	return sqlQuery, args
+}
+
+// buildListSQL строит запрос списка уведомлений по фильтрам с постраничной выборкой.
+func buildListSQL(filter domain.ListFilter, limit, offset int) (string, []interface{}) {
+	where, args := buildListWhere(filter, nil)
+	columns := notificationColumns
+	if filter.IncludeDeleted {
+		columns += ", deleted_at"
+	}
+	sqlQuery := `SELECT ` + columns + `
+    FROM notifications
+    WHERE ` + where + `
+    ORDER BY scheduled_at DESC, id DESC`
+	if limit > 0 {
+		args = append(args, limit)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		sqlQuery += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return sqlQuery, args
+}
+
+// buildListWhere строит условие WHERE по фильтрам списка, продолжая нумерацию параметров после args.
+// Мягко удаленные строки исключаются, если не задан filter.IncludeDeleted.
+func buildListWhere(filter domain.ListFilter, args []interface{}) (string, []interface{}) {
+	var conds []string
+	if !filter.IncludeDeleted {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Channel != "" {
+		args = append(args, filter.Channel)
+		conds = append(conds, fmt.Sprintf("channel = $%d", len(args)))
+	}
+	if filter.Recipient != "" {
+		args = append(args, filter.Recipient)
+		conds = append(conds, fmt.Sprintf("recipient = $%d", len(args)))
+	}
+	if !filter.ScheduledFrom.IsZero() {
+		args = append(args, filter.ScheduledFrom)
+		conds = append(conds, fmt.Sprintf("scheduled_at >= $%d", len(args)))
+	}
+	if !filter.ScheduledTo.IsZero() {
+		args = append(args, filter.ScheduledTo)
+		conds = append(conds, fmt.Sprintf("scheduled_at < $%d", len(args)))
+	}
+	if len(conds) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// buildRescheduleSQL строит перенос ожидающих уведомлений одним UPDATE: на время params.At
+// или сдвигом обоих времен на params.Shift. Строки выбираются с FOR UPDATE в порядке отправки.
+func buildRescheduleSQL(params domain.RescheduleParams) (string, []interface{}) {
+	filter := params.Filter
+	filter.Status = domain.StatusPending
+	filter.IncludeDeleted = false
+	where, args := buildListWhere(filter, nil)
+	var set string
+	if params.At != nil {
+		args = append(args, *params.At)
+		set = fmt.Sprintf("scheduled_at = $%d, effective_scheduled_at = $%d", len(args), len(args))
+	} else {
+		args = append(args, params.Shift.Microseconds())
+		set = fmt.Sprintf(`scheduled_at = scheduled_at + $%d * INTERVAL '1 microsecond',
+        effective_scheduled_at = effective_scheduled_at + $%d * INTERVAL '1 microsecond'`, len(args), len(args))
+	}
+	sub := `SELECT id FROM notifications
+      WHERE ` + where + `
+      ORDER BY effective_scheduled_at, id`
+	if params.Limit > 0 {
+		args = append(args, params.Limit)
+		sub += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	args = append(args, domain.StatusPending)
+	sqlQuery := `UPDATE notifications SET ` + set + `
+    WHERE id IN (` + sub + ` FOR UPDATE)
+      AND status = ` + fmt.Sprintf("$%d", len(args)) + `
+    RETURNING ` + notificationColumns
+	return sqlQuery, args
+}
+
+// buildOrphansPageSQL строит запрос страницы зависших уведомлений после курсора.
+func buildOrphansPageSQL(t time.Time, after *domain.OrphanCursor, limit int) (string, []interface{}) {
+	args := []interface{}{t, domain.StatusPending, domain.StatusProcessing}
+	where := `deleted_at IS NULL
+      AND ((status = $2 AND effective_scheduled_at <= $1)
+        OR (status = $3 AND updated_at < NOW() - INTERVAL '10 minutes'))`
+	if after != nil {
+		args = append(args, after.EffectiveScheduledAt, after.ID)
+		where += fmt.Sprintf(`
+      AND (effective_scheduled_at, id) > ($%d, $%d)`, len(args)-1, len(args))
+	}
+	sqlQuery := `SELECT ` + notificationColumns + `
+    FROM notifications
+    WHERE ` + where + `
+    ORDER BY effective_scheduled_at, id`
+	if limit > 0 {
+		args = append(args, limit)
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return sqlQuery, args
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы строка искалась буквально.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,149 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// templateColumns столбцы шаблона в порядке, который ожидает scanTemplate.
+const templateColumns = `id, name, subject, body, created_at, updated_at`
+
+// uniqueViolation код ошибки PostgreSQL при нарушении уникального индекса.
+const uniqueViolation = "23505"
+
+// CreateTemplate создает шаблон.
+func (p *PostgresRepo) CreateTemplate(ctx context.Context, params domain.TemplateParams) (*domain.Template, error) {
+	ctx, done := p.observe(ctx, "CreateTemplate")
+	defer done()
+
+	sqlQuery := `INSERT INTO templates (name, subject, body) VALUES ($1, $2, $3)
+    RETURNING ` + templateColumns
+
+	t, err := scanTemplate(p.DB.QueryRowContext(ctx, sqlQuery, params.Name, params.Subject, params.Body))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateTemplateName
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec create template sql")
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTemplate получает шаблон по id.
+func (p *PostgresRepo) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error) {
+	ctx, done := p.observe(ctx, "GetTemplate")
+	defer done()
+
+	sqlQuery := `SELECT ` + templateColumns + ` FROM templates WHERE id = $1`
+
+	t, err := scanTemplate(p.DB.QueryRowContext(ctx, sqlQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrTemplateNotFound
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan template")
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTemplates получает все шаблоны в порядке имен.
+func (p *PostgresRepo) ListTemplates(ctx context.Context) ([]domain.Template, error) {
+	ctx, done := p.observe(ctx, "ListTemplates")
+	defer done()
+
+	sqlQuery := `SELECT ` + templateColumns + ` FROM templates ORDER BY name`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list templates sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var templates []domain.Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list templates sql")
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// UpdateTemplate заменяет имя, subject и body шаблона.
+func (p *PostgresRepo) UpdateTemplate(ctx context.Context, id uuid.UUID,
+	params domain.TemplateParams) (*domain.Template, error) {
+	ctx, done := p.observe(ctx, "UpdateTemplate")
+	defer done()
+
+	sqlQuery := `UPDATE templates SET name = $2, subject = $3, body = $4, updated_at = NOW()
+    WHERE id = $1
+    RETURNING ` + templateColumns
+
+	t, err := scanTemplate(p.DB.QueryRowContext(ctx, sqlQuery, id, params.Name, params.Subject, params.Body))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrTemplateNotFound
+		case isUniqueViolation(err):
+			return nil, domain.ErrDuplicateTemplateName
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec update template sql")
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteTemplate удаляет шаблон, на который не ссылаются неудаленные уведомления,
+// ожидающие отправки.
+func (p *PostgresRepo) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	ctx, done := p.observe(ctx, "DeleteTemplate")
+	defer done()
+
+	sqlQuery := `DELETE FROM templates WHERE id = $1 AND NOT EXISTS (
+        SELECT 1 FROM notifications
+        WHERE template_id = $1 AND deleted_at IS NULL
+          AND status IN ('draft', 'awaiting_approval', 'pending', 'processing', 'failed'))`
+
+	r, err := p.DB.ExecContext(ctx, sqlQuery, id)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec delete template")
+		return err
+	}
+	if rows, _ := r.RowsAffected(); rows > 0 {
+		return nil
+	}
+	var exists bool
+	if err = p.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM templates WHERE id = $1)`, id).
+		Scan(&exists); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan template exists")
+		return err
+	}
+	if exists {
+		return domain.ErrTemplateInUse
+	}
+	return domain.ErrTemplateNotFound
+}
+
+func scanTemplate(row rowScanner) (domain.Template, error) {
+	var t domain.Template
+	err := row.Scan(&t.ID, &t.Name, &t.Subject, &t.Body, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,144 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// viewFilterJSON формат столбца saved_views.filter; last хранится строкой длительности.
+type viewFilterJSON struct {
+	Query   string `json:"q,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Last    string `json:"last,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+func encodeViewFilter(f domain.ViewFilter) ([]byte, error) {
+	raw := viewFilterJSON{Query: f.Query, Channel: f.Channel.String(), Status: f.Status.String(), Limit: f.Limit}
+	if f.Last > 0 {
+		raw.Last = f.Last.String()
+	}
+	return json.Marshal(raw)
+}
+
+func decodeViewFilter(data []byte) (domain.ViewFilter, error) {
+	var raw viewFilterJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return domain.ViewFilter{}, err
+	}
+	f := domain.ViewFilter{Query: raw.Query, Channel: domain.Channel(raw.Channel), Status: domain.Status(raw.Status),
+		Limit: raw.Limit}
+	if raw.Last != "" {
+		d, err := time.ParseDuration(raw.Last)
+		if err != nil {
+			return domain.ViewFilter{}, err
+		}
+		f.Last = d
+	}
+	return f, nil
+}
+
+// SaveView создает представление или заменяет фильтры существующего.
+func (p *PostgresRepo) SaveView(ctx context.Context, name string, filter domain.ViewFilter) (*domain.SavedView, error) {
+	ctx, done := p.observe(ctx, "SaveView")
+	defer done()
+
+	data, err := encodeViewFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	sqlQuery := `INSERT INTO saved_views (name, filter) VALUES ($1, $2)
+    ON CONFLICT (name) DO UPDATE SET filter = EXCLUDED.filter, updated_at = NOW()
+    RETURNING created_at, updated_at`
+
+	v := domain.SavedView{Name: name, Filter: filter}
+	if err = p.DB.QueryRowContext(ctx, sqlQuery, name, data).Scan(&v.CreatedAt, &v.UpdatedAt); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec save view sql")
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetView получает представление по имени.
+func (p *PostgresRepo) GetView(ctx context.Context, name string) (*domain.SavedView, error) {
+	ctx, done := p.observe(ctx, "GetView")
+	defer done()
+
+	sqlQuery := `SELECT name, filter, created_at, updated_at FROM saved_views WHERE name = $1`
+
+	v, err := scanView(p.DB.QueryRowContext(ctx, sqlQuery, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrViewNotFound
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Error scan view")
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListViews получает все представления в порядке имен.
+func (p *PostgresRepo) ListViews(ctx context.Context) ([]domain.SavedView, error) {
+	ctx, done := p.observe(ctx, "ListViews")
+	defer done()
+
+	sqlQuery := `SELECT name, filter, created_at, updated_at FROM saved_views ORDER BY name`
+
+	rows, err := p.DB.QueryContext(ctx, sqlQuery)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec list views sql")
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var views []domain.SavedView
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Error scan list views sql")
+			return nil, err
+		}
+		views = append(views, v)
+	}
+	return views, rows.Err()
+}
+
+// DeleteView удаляет представление.
+func (p *PostgresRepo) DeleteView(ctx context.Context, name string) error {
+	ctx, done := p.observe(ctx, "DeleteView")
+	defer done()
+
+	r, err := p.DB.ExecContext(ctx, `DELETE FROM saved_views WHERE name = $1`, name)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Error exec delete view")
+		return err
+	}
+	rows, _ := r.RowsAffected()
+	if rows == 0 {
+		return domain.ErrViewNotFound
+	}
+	return nil
+}
+
+func scanView(row rowScanner) (domain.SavedView, error) {
+	var v domain.SavedView
+	var filterRaw []byte
+	if err := row.Scan(&v.Name, &filterRaw, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return v, err
+	}
+	f, err := decodeViewFilter(filterRaw)
+	if err != nil {
+		return v, err
+	}
+	v.Filter = f
+	return v, nil
+}
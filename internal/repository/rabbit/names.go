@@ -0,0 +1,57 @@
+package rabbit
+
+import (
+	"DelayedNotifier/internal/domain"
+	"github.com/google/uuid"
+)
+
+// deadLetterExchange имя exchange для сообщений, отклоненных консьюмером, без пространства имен.
+const deadLetterExchange = "dlx"
+
+// Names имена exchange и очередей приложения с учетом пространства имен окружения.
+// Одинаковые настройки дают одинаковые имена, поэтому объявление топологии остается идемпотентным.
+type Names struct {
+	namespace string
+	// Exchange основной exchange
+	Exchange string
+	// Queue основная очередь консьюмера
+	Queue string
+	// HighQueue очередь уведомлений с приоритетом high, у нее свои воркеры
+	HighQueue string
+	// DeadLetterExchange exchange для отклоненных сообщений
+	DeadLetterExchange string
+	// DeadLetterQueue очередь отклоненных сообщений
+	DeadLetterQueue string
+}
+
+// NewNames возвращает имена топологии для пространства имен namespace.
+func NewNames(namespace, exchange, queueName string) Names {
+	queue := domain.Namespaced(namespace, queueName)
+	return Names{
+		namespace:          namespace,
+		Exchange:           domain.Namespaced(namespace, exchange),
+		Queue:              queue,
+		HighQueue:          queue + ".high",
+		DeadLetterExchange: domain.Namespaced(namespace, deadLetterExchange),
+		DeadLetterQueue:    DeadLetterQueueName(queue),
+	}
+}
+
+// NotificationQueue возвращает имя очереди ожидания уведомления.
+func (n Names) NotificationQueue(id uuid.UUID) string {
+	return domain.Namespaced(n.namespace, "queue:"+id.String())
+}
+
+// QueueFor возвращает очередь, из которой отправляются уведомления приоритета p:
+// high — отдельная очередь, остальные (и пустой приоритет) — основная.
+func (n Names) QueueFor(p domain.Priority) string {
+	if p == domain.PriorityHigh {
+		return n.HighQueue
+	}
+	return n.Queue
+}
+
+// DeadLetterQueueName возвращает имя DLQ для основной очереди.
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
@@ -4,38 +4,46 @@ import (
 	"context"
 	"time"
 
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
 	"DelayedNotifier/pkg/rabbitmq"
 	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
-	"github.com/wb-go/wbf/zlog"
 )
 
 // Publisher структура для публикации сообщений в RabbitMQ.
 type Publisher struct {
 	client    *rabbitmq.RabbitClient
 	publisher *rabbitmq.Publisher
-	dlqName   string
-	exchange  string
+	names     Names
 }
 
-// NewPublisher создает новый экземпляр Publisher.
-func NewPublisher(client *rabbitmq.RabbitClient, exchange, contentType, dlqName string) *Publisher {
-	pub := rabbitmq.NewPublisher(client, exchange, contentType)
-	return &Publisher{publisher: pub, client: client, dlqName: dlqName, exchange: exchange}
+// NewPublisher создает новый экземпляр Publisher. Истекшие сообщения очередей ожидания
+// переадресуются в очередь приоритета уведомления: names.HighQueue или names.Queue.
+func NewPublisher(client *rabbitmq.RabbitClient, names Names, contentType string) *Publisher {
+	pub := rabbitmq.NewPublisher(client, names.Exchange, contentType)
+	return &Publisher{publisher: pub, client: client, names: names}
 }
 
-// Publish публикует уведомление в очередь с указанным TTL.
+// Publish публикует уведомление с приоритетом normal в очередь с указанным TTL.
 func (r *Publisher) Publish(ctx context.Context, id uuid.UUID, ttl time.Duration) error {
+	return r.PublishPriority(ctx, id, domain.PriorityNormal, ttl)
+}
+
+// PublishPriority публикует уведомление с указанным TTL; по истечении TTL сообщение уходит
+// в очередь приоритета p.
+func (r *Publisher) PublishPriority(ctx context.Context, id uuid.UUID, p domain.Priority,
+	ttl time.Duration) error {
 	exp := ttl + 2*time.Second
 	queueArgs := amqp091.Table{
-		"x-dead-letter-exchange":    r.exchange, // exchange для DLQ
-		"x-dead-letter-routing-key": r.dlqName,  // routing key для DLQ
+		"x-dead-letter-exchange":    r.names.Exchange,    // exchange для DLQ
+		"x-dead-letter-routing-key": r.names.QueueFor(p), // routing key для DLQ
 		"x-expires":                 exp.Milliseconds(),
 	}
-	queueName := "queue:" + id.String()
+	queueName := r.names.NotificationQueue(id)
 	err := r.client.DeclareQueue(
 		queueName,
-		r.exchange,
+		r.names.Exchange,
 		id.String(), false,
 		true,
 		false,
@@ -44,13 +52,32 @@ func (r *Publisher) Publish(ctx context.Context, id uuid.UUID, ttl time.Duration
 	if err != nil {
 		return err
 	}
-	body := []byte(`{"notification_id":"` + id.String() + `"}`)
+	body, err := domain.NewJob(id).Marshal()
+	if err != nil {
+		return err
+	}
 
 	err = r.publisher.Publish(ctx, body, id.String(), rabbitmq.WithExpiration(ttl))
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to publish notification")
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to publish notification")
 		return err
 	}
 
 	return nil
 }
+
+// Republish заменяет ожидающее сообщение уведомления с приоритетом normal.
+func (r *Publisher) Republish(ctx context.Context, id uuid.UUID, ttl time.Duration) error {
+	return r.RepublishPriority(ctx, id, domain.PriorityNormal, ttl)
+}
+
+// RepublishPriority заменяет ожидающее сообщение уведомления: очередь ожидания удаляется вместе
+// со старым сообщением (и ее прежними x-expires и очередью назначения) и объявляется заново.
+func (r *Publisher) RepublishPriority(ctx context.Context, id uuid.UUID, p domain.Priority,
+	ttl time.Duration) error {
+	if _, err := r.client.DeleteQueue(r.names.NotificationQueue(id)); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to delete notification wait queue")
+		return err
+	}
+	return r.PublishPriority(ctx, id, p, ttl)
+}
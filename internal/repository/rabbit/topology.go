@@ -0,0 +1,82 @@
+package rabbit
+
+import (
+	"context"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/pkg/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// AppTopology описывает топологию, необходимую приложению:
+// основной exchange, основная очередь и очередь приоритета high, DLX и общая DLQ для отклоненных
+// сообщений. Очереди на каждое уведомление объявляет Publisher, они сюда не входят.
+//
+// Основная очередь объявляется без аргументов, как и в ранних версиях: у брокеров, где она уже
+// есть, другие x-аргументы дали бы PRECONDITION_FAILED. DLX для нее задается политикой брокера
+// (см. README). Очередь high новая, поэтому dead-letter аргументы у нее в объявлении.
+func AppTopology(names Names) rabbitmq.Topology {
+	deadLetter := amqp091.Table{
+		"x-dead-letter-exchange":    names.DeadLetterExchange,
+		"x-dead-letter-routing-key": names.DeadLetterQueue,
+	}
+	return rabbitmq.Topology{
+		Exchanges: []rabbitmq.ExchangeSpec{
+			{Name: names.Exchange, Kind: "direct"},
+			{Name: names.DeadLetterExchange, Kind: "direct", Durable: true},
+		},
+		Queues: []rabbitmq.QueueSpec{
+			{Name: names.Queue},
+			{Name: names.HighQueue, Args: deadLetter},
+			{Name: names.DeadLetterQueue, Durable: true},
+		},
+		Bindings: []rabbitmq.BindingSpec{
+			{Queue: names.Queue, Exchange: names.Exchange, RoutingKey: names.Queue},
+			{Queue: names.HighQueue, Exchange: names.Exchange, RoutingKey: names.HighQueue},
+			{Queue: names.DeadLetterQueue, Exchange: names.DeadLetterExchange, RoutingKey: names.DeadLetterQueue},
+		},
+	}
+}
+
+// Topology синхронизирует топологию RabbitMQ.
+type Topology struct {
+	client *rabbitmq.RabbitClient
+	spec   rabbitmq.Topology
+}
+
+// NewTopology создает новый экземпляр Topology.
+func NewTopology(client *rabbitmq.RabbitClient, names Names) *Topology {
+	return &Topology{client: client, spec: AppTopology(names)}
+}
+
+// Sync идемпотентно объявляет все элементы топологии.
+func (t *Topology) Sync(_ context.Context) (domain.TopologyReport, error) {
+	report, err := t.client.ApplyTopology(t.spec)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to sync rabbitmq topology")
+	}
+	return toDomainReport(report), err
+}
+
+// Check проверяет наличие элементов топологии без их создания.
+func (t *Topology) Check(_ context.Context) (domain.TopologyReport, error) {
+	report, err := t.client.VerifyTopology(t.spec)
+	if err != nil {
+		zlog.Logger.Warn().Err(err).Msg("rabbitmq topology check failed")
+	}
+	return toDomainReport(report), err
+}
+
+func toDomainReport(r rabbitmq.TopologyReport) domain.TopologyReport {
+	items := make([]domain.TopologyItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, domain.TopologyItem{
+			Kind:  item.Kind,
+			Name:  item.Name,
+			State: string(item.State),
+			Error: item.Error,
+		})
+	}
+	return domain.TopologyReport{OK: r.OK(), Items: items}
+}
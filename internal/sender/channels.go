@@ -0,0 +1,27 @@
+// Package sender собирает для сервиса уведомлений проверки и отрисовку всех каналов отправки,
+// чтобы сервис одинаково настраивался в приложении и во встроенном режиме.
+package sender
+
+import (
+	"DelayedNotifier/internal/domain"
+	emailsender "DelayedNotifier/internal/sender/email"
+	smssender "DelayedNotifier/internal/sender/sms"
+	telegramsender "DelayedNotifier/internal/sender/telegram"
+	"DelayedNotifier/internal/service"
+)
+
+// ServiceOptions подключает к сервису проверку получателя и payload и отрисовку сообщений каналов
+// email, telegram и sms; checkMX включает проверку MX-записей домена получателя email.
+func ServiceOptions(checkMX bool) []service.Option {
+	return []service.Option{
+		service.WithRecipientValidator(domain.ChannelEmail, emailsender.NewRecipientValidator(checkMX)),
+		service.WithRecipientValidator(domain.ChannelTelegram, telegramsender.RecipientValidator),
+		service.WithRecipientValidator(domain.ChannelSMS, smssender.RecipientValidator),
+		service.WithPayloadValidator(domain.ChannelEmail, emailsender.PayloadValidator),
+		service.WithPayloadValidator(domain.ChannelTelegram, telegramsender.PayloadValidator),
+		service.WithPayloadValidator(domain.ChannelSMS, smssender.PayloadValidator),
+		service.WithRenderer(domain.ChannelEmail, emailsender.Renderer),
+		service.WithRenderer(domain.ChannelTelegram, telegramsender.Renderer),
+		service.WithRenderer(domain.ChannelSMS, smssender.Renderer),
+	}
+}
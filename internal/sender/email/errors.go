@@ -0,0 +1,36 @@
+package email_sender
+
+import (
+	"errors"
+	"net/textproto"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// smtpRateLimitCode ответ 421, которым почтовые серверы обычно сообщают о превышении частоты.
+const smtpRateLimitCode = 421
+
+// classifySMTPError относит ошибку SMTP к классу по коду ответа: 5xx — постоянная,
+// 421 — ограничение частоты, остальные (4xx, сетевые) — временные.
+func classifySMTPError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		switch {
+		case tpErr.Code == smtpRateLimitCode:
+			return domain.ThrottledError(err)
+		case tpErr.Code >= 500:
+			return domain.PermanentError(err)
+		}
+	}
+	return domain.TransientError(err)
+}
+
+// isSMTPReply сообщает, что ошибка — ответ сервера, а не обрыв соединения: после RSET
+// соединение можно использовать снова.
+func isSMTPReply(err error) bool {
+	var tpErr *textproto.Error
+	return errors.As(err, &tpErr)
+}
@@ -0,0 +1,30 @@
+package email_sender
+
+import (
+	"fmt"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// ValidatePayload проверяет поля, которые попадают в заголовки письма: subject и from_name
+// не должны содержать переводов строк, иначе через них можно дописать свои заголовки.
+func ValidatePayload(payload map[string]interface{}) error {
+	for _, key := range []string{"subject", domain.PayloadFromName} {
+		v, ok := payload[key]
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s must be a string", domain.ErrInvalidPayload, key)
+		}
+		if strings.ContainsAny(s, "\r\n") {
+			return fmt.Errorf("%w: %s must not contain line breaks", domain.ErrInvalidPayload, key)
+		}
+	}
+	return nil
+}
+
+// PayloadValidator валидатор payload писем.
+var PayloadValidator = domain.PayloadValidatorFunc(ValidatePayload)
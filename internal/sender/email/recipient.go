@@ -0,0 +1,40 @@
+package email_sender
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// RecipientValidator проверяет email получателя по RFC 5322
+// и, если включено, наличие MX-записи у домена.
+type RecipientValidator struct {
+	CheckMX  bool
+	Resolver *net.Resolver
+}
+
+// NewRecipientValidator создает валидатор адресов email.
+func NewRecipientValidator(checkMX bool) *RecipientValidator {
+	return &RecipientValidator{CheckMX: checkMX, Resolver: net.DefaultResolver}
+}
+
+// ValidateRecipient проверяет, что recipient — одиночный адрес без отображаемого имени.
+func (v *RecipientValidator) ValidateRecipient(ctx context.Context, recipient string) error {
+	addr, err := mail.ParseAddress(recipient)
+	if err != nil || addr.Name != "" || addr.Address != recipient {
+		return fmt.Errorf("%w: %q is not a valid email address", domain.ErrInvalidRecipient, recipient)
+	}
+	if !v.CheckMX {
+		return nil
+	}
+	domainPart := addr.Address[strings.LastIndex(addr.Address, "@")+1:]
+	mx, err := v.Resolver.LookupMX(ctx, domainPart)
+	if err != nil || len(mx) == 0 {
+		return fmt.Errorf("%w: domain %q has no MX records", domain.ErrInvalidRecipient, domainPart)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package email_sender
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// contentType тип содержимого отправляемых писем.
+const contentType = "text/html; charset=utf-8"
+
+// Renderer строит письмо так же, как его отправляет SMTPSender.
+var Renderer = domain.MessageRendererFunc(Render)
+
+// Render строит письмо из payload: subject и body, а при отсутствии body
+// тело собирается из полей payload в виде key=value, кроме параметров отправки.
+// Тело очищается SanitizeHTML: payload мог пройти проверку при создании, но стать опасным при показе.
+func Render(n *domain.Notification) (*domain.RenderedMessage, error) {
+	msg := &domain.RenderedMessage{
+		Channel:     n.Channel,
+		Recipient:   n.Recipient,
+		ContentType: contentType,
+	}
+	if v, ok := n.Payload["subject"]; ok {
+		msg.Subject = fmt.Sprint(v)
+	}
+	if v, ok := n.Payload["body"]; ok {
+		msg.Body = SanitizeHTML(fmt.Sprint(v))
+		return msg, nil
+	}
+
+	keys := make([]string, 0, len(n.Payload))
+	for k := range n.Payload {
+		if !domain.IsSendOption(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, n.Payload[k]))
+	}
+	msg.Body = SanitizeHTML(strings.Join(parts, ", "))
+	return msg, nil
+}
@@ -0,0 +1,140 @@
+package email_sender
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// droppedElements элементы, которые удаляются вместе с содержимым. svg и math удаляются целиком:
+// внутри них свои правила разбора и анимации атрибутов (animate, set), которые обходят проверку ссылок.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Frame: true, atom.Frameset: true, atom.Applet: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Math: true, atom.Title: true, atom.Textarea: true, atom.Select: true,
+	atom.Noembed: true, atom.Noframes: true, atom.Xmp: true, atom.Plaintext: true,
+}
+
+// allowedElements элементы разметки письма, которые сохраняются. У остальных удаляется только
+// тег, текст внутри остается.
+var allowedElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Blockquote: true, atom.Br: true, atom.Caption: true,
+	atom.Center: true, atom.Code: true, atom.Col: true, atom.Colgroup: true, atom.Dd: true, atom.Div: true,
+	atom.Dl: true, atom.Dt: true, atom.Em: true, atom.Font: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Hr: true, atom.I: true, atom.Img: true, atom.Li: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.S: true, atom.Small: true, atom.Span: true,
+	atom.Strike: true, atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.Table: true, atom.Tbody: true,
+	atom.Td: true, atom.Tfoot: true, atom.Th: true, atom.Thead: true, atom.Tr: true, atom.U: true, atom.Ul: true,
+}
+
+// allowedAttributes атрибуты оформления, допустимые у любого разрешенного элемента.
+var allowedAttributes = map[string]bool{
+	"align": true, "alt": true, "bgcolor": true, "border": true, "cellpadding": true, "cellspacing": true,
+	"class": true, "color": true, "colspan": true, "dir": true, "face": true, "height": true, "lang": true,
+	"rowspan": true, "size": true, "style": true, "title": true, "valign": true, "width": true,
+}
+
+// urlAttributes атрибуты-ссылки по элементам; значение допускается только со схемой из safeURL.
+var urlAttributes = map[atom.Atom]string{atom.A: "href", atom.Img: "src"}
+
+// SanitizeHTML оставляет в HTML тела письма только разрешенные элементы и атрибуты оформления.
+// script, style, svg, math и другие встраиваемые элементы удаляются вместе с содержимым, прочие
+// неизвестные — без содержимого; комментарии удаляются. Ссылки (href у a, src у img) допускаются
+// только http:, https:, mailto: и относительные, style — без expression() и скриптовых URL.
+func SanitizeHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+	var buf bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip := 0 // глубина внутри удаляемого элемента
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return html.EscapeString(s)
+			}
+			return buf.String()
+		}
+		tok := z.Token()
+		switch tt {
+		case html.CommentToken, html.DoctypeToken:
+			continue
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[tok.DataAtom] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !allowedElements[tok.DataAtom] {
+				continue
+			}
+			tok.Attr = sanitizeAttrs(tok.DataAtom, tok.Attr)
+		case html.EndTagToken:
+			if droppedElements[tok.DataAtom] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 || !allowedElements[tok.DataAtom] {
+				continue
+			}
+		default:
+			if skip > 0 {
+				continue
+			}
+		}
+		buf.WriteString(tok.String())
+	}
+}
+
+func sanitizeAttrs(el atom.Atom, attrs []html.Attribute) []html.Attribute {
+	out := attrs[:0]
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		switch {
+		case key == urlAttributes[el]:
+			if !safeURL(a.Val) {
+				continue
+			}
+		case !allowedAttributes[key]:
+			continue
+		case key == "style" && unsafeStyle(a.Val):
+			continue
+		}
+		a.Key = key
+		out = append(out, a)
+	}
+	return out
+}
+
+// safeURL допускает схемы http:, https:, mailto: и ссылки без схемы. Пробелы и управляющие
+// символы, которые браузеры игнорируют внутри схемы, не учитываются.
+func safeURL(v string) bool {
+	u := strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, v))
+	colon := strings.IndexByte(u, ':')
+	if colon < 0 || strings.ContainsAny(u[:colon], "/?#") {
+		return true
+	}
+	switch u[:colon] {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func unsafeStyle(v string) bool {
+	v = strings.ToLower(strings.ReplaceAll(v, " ", ""))
+	return strings.Contains(v, "expression(") || strings.Contains(v, "javascript:") ||
+		strings.Contains(v, "vbscript:") || strings.Contains(v, "url(data:text")
+}
@@ -5,15 +5,17 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"net/mail"
 	"net/smtp"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 
 	"DelayedNotifier/internal/domain"
 )
 
-// SMTPSender структура для отправки email через SMTP.
+// SMTPSender структура для отправки email через SMTP. Держит пул до PoolSize соединений,
+// чтобы письма уходили параллельно по числу воркеров, а не по одному.
 type SMTPSender struct {
 	Host     string
 	Port     int
@@ -24,12 +26,30 @@ type SMTPSender struct {
 
 	Timeout time.Duration
 
+	// slots ограничивает число соединений, занятых отправкой; idle — свободные соединения
+	slots  chan struct{}
+	idle   chan *smtp.Client
 	mu     sync.Mutex
-	client *smtp.Client
+	closed bool
 }
 
-// NewSMTPSender создает новый экземпляр SMTPSender.
-func NewSMTPSender(host string, port int, username, password, from string, ssl bool) (*SMTPSender, error) {
+// SMTPSenderOption функция настройки SMTPSender.
+type SMTPSenderOption func(*SMTPSender)
+
+// WithPoolSize задает наибольшее число одновременных SMTP-соединений, по умолчанию 1.
+func WithPoolSize(n int) SMTPSenderOption {
+	return func(s *SMTPSender) {
+		if n > 0 {
+			s.slots = make(chan struct{}, n)
+			s.idle = make(chan *smtp.Client, n)
+		}
+	}
+}
+
+// NewSMTPSender создает новый экземпляр SMTPSender. Первое соединение устанавливается сразу,
+// чтобы ошибка настроек была видна при запуске; остальные — по мере надобности.
+func NewSMTPSender(host string, port int, username, password, from string, ssl bool,
+	opts ...SMTPSenderOption) (*SMTPSender, error) {
 	s := &SMTPSender{
 		Host:     host,
 		Port:     port,
@@ -38,18 +58,25 @@ func NewSMTPSender(host string, port int, username, password, from string, ssl b
 		From:     from,
 		SSL:      ssl,
 		Timeout:  10 * time.Second,
+		slots:    make(chan struct{}, 1),
+		idle:     make(chan *smtp.Client, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
 
-	if err := s.connect(); err != nil {
+	client, err := s.connect()
+	if err != nil {
 		return nil, err
 	}
+	s.idle <- client
 
 	return s, nil
 }
 
-// connect устанавливает соединение с SMTP сервером.
-func (s *SMTPSender) connect() error {
-	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
+// connect устанавливает новое соединение с SMTP сервером.
+func (s *SMTPSender) connect() (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
 	dialer := &net.Dialer{Timeout: s.Timeout}
 
 	var conn net.Conn
@@ -61,7 +88,7 @@ func (s *SMTPSender) connect() error {
 		conn, err = dialer.Dial("tcp", addr)
 	}
 	if err != nil {
-		return fmt.Errorf("dial failed: %w", err)
+		return nil, fmt.Errorf("dial failed: %w", err)
 	}
 
 	clientChan := make(chan *smtp.Client, 1)
@@ -81,10 +108,10 @@ func (s *SMTPSender) connect() error {
 	select {
 	case client = <-clientChan:
 	case err := <-errChan:
-		return fmt.Errorf("smtp.NewClient failed: %w", err)
+		return nil, fmt.Errorf("smtp.NewClient failed: %w", err)
 	case <-time.After(s.Timeout):
 		_ = conn.Close()
-		return fmt.Errorf("smtp.NewClient timed out (server did not send banner)")
+		return nil, fmt.Errorf("smtp.NewClient timed out (server did not send banner)")
 	}
 
 	if !s.SSL {
@@ -107,73 +134,96 @@ func (s *SMTPSender) connect() error {
 		if ok, _ := client.Extension("AUTH"); ok {
 			if err := client.Auth(auth); err != nil {
 				_ = client.Close()
-				return fmt.Errorf("authentication failed: %w", err)
+				return nil, fmt.Errorf("authentication failed: %w", err)
 			}
 		} else {
 			fmt.Printf("Note: SMTP server does not support authentication, continuing without auth\n")
 		}
 	}
 
-	s.client = client
-	if err := s.ensureConnected(); err != nil {
-		_ = client.Close()
-		return fmt.Errorf("failed to connect to %s: %w", addr, err)
-	}
-
-	return nil
+	return client, nil
 }
 
-// ensureConnected проверяет и восстанавливает соединение с SMTP сервером.
-func (s *SMTPSender) ensureConnected() error {
-	if s.client != nil {
-		if err := s.client.Noop(); err == nil {
-			return nil
+// acquire берет соединение из пула: свободное, если оно отвечает на NOOP, иначе новое.
+// Ждет, пока не освободится место, если все PoolSize соединений заняты.
+func (s *SMTPSender) acquire(ctx context.Context) (*smtp.Client, error) {
+	// select выбирает готовую ветку случайно: без этой проверки письмо с уже отмененным ctx
+	// могло бы уйти при свободном слоте
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	for {
+		select {
+		case client := <-s.idle:
+			if err := client.Noop(); err == nil {
+				return client, nil
+			}
+			// сервер закрыл простаивающее соединение: берем следующее или соединяемся заново
+			_ = client.Close()
+		default:
+			client, err := s.connect()
+			if err != nil {
+				<-s.slots
+				return nil, err
+			}
+			return client, nil
 		}
 	}
-	return s.connect()
 }
 
-// Send отправляет email уведомление.
-func (s *SMTPSender) Send(ctx context.Context, n *domain.Notification) error {
+// release возвращает соединение в пул; сломанное или после Close закрывается.
+func (s *SMTPSender) release(client *smtp.Client, healthy bool) {
+	defer func() { <-s.slots }()
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if err := s.ensureConnected(); err != nil {
-		return err
+	closed := s.closed
+	s.mu.Unlock()
+	if !healthy || closed {
+		_ = client.Close()
+		return
 	}
-
-	contentType := "text/html; charset=utf-8"
-
-	subject := ""
-	if v, ok := n.Payload["subject"]; ok {
-		subject = v.(string)
+	select {
+	case s.idle <- client:
+	default:
+		_ = client.Quit()
 	}
+}
 
-	body := ""
-	if v, ok := n.Payload["body"]; ok {
-		body = v.(string)
-	} else {
-		parts := make([]string, 0, len(n.Payload))
-		for k, v := range n.Payload {
-			parts = append(parts, fmt.Sprintf("%s=%v", k, v))
-		}
-		body = strings.Join(parts, ", ")
+// Send отправляет email уведомление. Ошибки SMTP классифицируются, см. domain.ClassifySendError.
+func (s *SMTPSender) Send(ctx context.Context, n *domain.Notification) error {
+	rendered, err := Render(n)
+	if err != nil {
+		return domain.PermanentError(err)
 	}
 
+	from := s.From
+	if v, ok := n.Payload[domain.PayloadFromName]; ok && fmt.Sprint(v) != "" {
+		from = (&mail.Address{Name: fmt.Sprint(v), Address: s.From}).String()
+	}
 	msg := []byte(fmt.Sprintf(
 		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n%s",
-		s.From,
+		from,
 		n.Recipient,
-		subject,
-		contentType,
-		body,
+		rendered.Subject,
+		rendered.ContentType,
+		rendered.Body,
 	))
 
+	client, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
 	done := make(chan error, 1)
 
+	// соединение остается у горутины до конца диалога, даже если ctx уже отменен
 	go func() {
-		err := s.sendMessage(n.Recipient, msg)
-		done <- err
+		err := sendMessage(client, s.From, n.Recipient, msg)
+		s.release(client, err == nil || isSMTPReply(err) && client.Reset() == nil)
+		done <- classifySMTPError(err)
 	}()
 
 	select {
@@ -184,15 +234,15 @@ func (s *SMTPSender) Send(ctx context.Context, n *domain.Notification) error {
 	}
 }
 
-// sendMessage отправляет сообщение через установленное SMTP соединение.
-func (s *SMTPSender) sendMessage(recipient string, msg []byte) error {
-	if err := s.client.Mail(s.From); err != nil {
+// sendMessage отправляет сообщение через соединение client.
+func sendMessage(client *smtp.Client, from, recipient string, msg []byte) error {
+	if err := client.Mail(from); err != nil {
 		return err
 	}
-	if err := s.client.Rcpt(recipient); err != nil {
+	if err := client.Rcpt(recipient); err != nil {
 		return err
 	}
-	w, err := s.client.Data()
+	w, err := client.Data()
 	if err != nil {
 		return err
 	}
@@ -203,15 +253,18 @@ func (s *SMTPSender) sendMessage(recipient string, msg []byte) error {
 	return w.Close()
 }
 
-// Close закрывает SMTP соединение.
+// Close закрывает свободные SMTP соединения; занятые закрываются, когда отправка завершится.
 func (s *SMTPSender) Close() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.client != nil {
-		_ = s.client.Quit()
-		s.client = nil
+	s.closed = true
+	s.mu.Unlock()
+
+	for {
+		select {
+		case client := <-s.idle:
+			_ = client.Quit()
+		default:
+			return nil
+		}
 	}
-
-	return nil
 }
@@ -0,0 +1,28 @@
+package sms_sender
+
+import (
+	"fmt"
+	"regexp"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// senderIDRe буквенное имя отправителя: до 11 латинских букв, цифр и пробелов, хотя бы одна буква.
+var senderIDRe = regexp.MustCompile(`^[A-Za-z0-9 ]*[A-Za-z][A-Za-z0-9 ]*$`)
+
+// ValidatePayload проверяет sender_id: буквенное имя не длиннее 11 символов или номер E.164.
+func ValidatePayload(payload map[string]interface{}) error {
+	v, ok := payload[domain.PayloadSenderID]
+	if !ok {
+		return nil
+	}
+	s, ok := v.(string)
+	if ok && (e164Re.MatchString(s) || (len(s) <= 11 && senderIDRe.MatchString(s))) {
+		return nil
+	}
+	return fmt.Errorf("%w: sender_id must be up to 11 letters and digits or an E.164 number, got %v",
+		domain.ErrInvalidPayload, v)
+}
+
+// PayloadValidator валидатор payload SMS.
+var PayloadValidator = domain.PayloadValidatorFunc(ValidatePayload)
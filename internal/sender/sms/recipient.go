@@ -0,0 +1,23 @@
+package sms_sender
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// e164Re номер в формате E.164: +, код страны и до 15 цифр всего.
+var e164Re = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ValidateRecipient проверяет, что получатель — номер телефона в формате E.164 (+79991234567).
+func ValidateRecipient(_ context.Context, recipient string) error {
+	if e164Re.MatchString(recipient) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is not an E.164 phone number", domain.ErrInvalidRecipient, recipient)
+}
+
+// RecipientValidator валидатор получателей SMS.
+var RecipientValidator = domain.RecipientValidatorFunc(ValidateRecipient)
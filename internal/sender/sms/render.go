@@ -0,0 +1,28 @@
+package sms_sender
+
+import (
+	"fmt"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// Renderer строит текст SMS.
+var Renderer = domain.MessageRendererFunc(Render)
+
+// Render строит текст SMS: body, а без него subject; SMS без темы, поэтому subject
+// при наличии body не отправляется.
+func Render(n *domain.Notification) (*domain.RenderedMessage, error) {
+	msg := &domain.RenderedMessage{
+		Channel:     n.Channel,
+		Recipient:   n.Recipient,
+		ContentType: "text/plain; charset=utf-8",
+	}
+	if v, ok := n.Payload["body"]; ok {
+		msg.Body = fmt.Sprint(v)
+	} else if v, ok := n.Payload["subject"]; ok {
+		msg.Body = fmt.Sprint(v)
+	}
+	msg.Body = strings.TrimSpace(msg.Body)
+	return msg, nil
+}
@@ -0,0 +1,175 @@
+package sms_sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+)
+
+const (
+	// ProviderTwilio провайдер с Twilio-совместимым Messages API.
+	ProviderTwilio = "twilio"
+	// DefaultTwilioAPIURL адрес Twilio REST API.
+	DefaultTwilioAPIURL = "https://api.twilio.com"
+	// maxResponseSize ограничение читаемого ответа API.
+	maxResponseSize = 64 << 10
+	// maxMessageLength максимальная длина тела сообщения в Messages API (несколько сегментов).
+	maxMessageLength = 1600
+)
+
+// ErrAPI провайдер отклонил запрос.
+var ErrAPI = errors.New("sms provider api error")
+
+// TwilioSender отправляет SMS методом Messages Twilio REST API. Подходит и для провайдеров
+// с совместимым API (адрес задается WithAPIURL).
+type TwilioSender struct {
+	accountSID string
+	authToken  string
+	from       string
+	apiURL     string
+	client     *http.Client
+	clock      domain.Clock
+}
+
+// TwilioSenderOption функция настройки TwilioSender.
+type TwilioSenderOption func(*TwilioSender)
+
+// WithAPIURL задает адрес API (совместимый провайдер, тестовый стенд).
+func WithAPIURL(apiURL string) TwilioSenderOption {
+	return func(s *TwilioSender) {
+		s.apiURL = strings.TrimRight(apiURL, "/")
+	}
+}
+
+// WithTransport задает транспорт запросов к API (например, через исходящий прокси).
+func WithTransport(rt http.RoundTripper) TwilioSenderOption {
+	return func(s *TwilioSender) {
+		s.client.Transport = rt
+	}
+}
+
+// WithTimeout задает ограничение времени одного запроса к API.
+func WithTimeout(d time.Duration) TwilioSenderOption {
+	return func(s *TwilioSender) {
+		s.client.Timeout = d
+	}
+}
+
+// WithClock задает источник времени для Retry-After, по умолчанию системное время.
+func WithClock(c domain.Clock) TwilioSenderOption {
+	return func(s *TwilioSender) {
+		s.clock = c
+	}
+}
+
+// NewTwilioSender создает отправщика от имени аккаунта accountSID. from — номер отправителя
+// в E.164 или SID сервиса рассылок (MG...).
+func NewTwilioSender(accountSID, authToken, from string, opts ...TwilioSenderOption) (*TwilioSender, error) {
+	if accountSID == "" || authToken == "" {
+		return nil, errors.New("sms account sid or auth token is empty")
+	}
+	if from == "" {
+		return nil, errors.New("sms sender number is empty")
+	}
+	s := &TwilioSender{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		apiURL:     DefaultTwilioAPIURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		clock:      domain.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// apiError тело ответа с ошибкой Messages API.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Send отправляет уведомление на номер n.Recipient. Ошибки классифицируются по коду ответа,
+// см. domain.ClassifyHTTPStatus; Retry-After из ответа 429 передается в ошибке.
+func (s *TwilioSender) Send(ctx context.Context, n *domain.Notification) error {
+	rendered, err := Render(n)
+	if err != nil {
+		return domain.PermanentError(err)
+	}
+	text := rendered.Body
+	if text == "" {
+		return domain.PermanentError(errors.New("message text is empty"))
+	}
+	if r := []rune(text); len(r) > maxMessageLength {
+		text = string(r[:maxMessageLength])
+	}
+
+	form := url.Values{}
+	form.Set("To", n.Recipient)
+	form.Set("Body", text)
+	if v, ok := n.Payload[domain.PayloadSenderID]; ok && fmt.Sprint(v) != "" {
+		// буквенное имя отправителя вместо номера или сервиса рассылок
+		form.Set("From", fmt.Sprint(v))
+	} else if strings.HasPrefix(s.from, "MG") {
+		form.Set("MessagingServiceSid", s.from)
+	} else {
+		form.Set("From", s.from)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		s.apiURL+"/2010-04-01/Accounts/"+url.PathEscape(s.accountSID)+"/Messages.json",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return domain.PermanentError(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.accountSID, s.authToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return domain.TransientError(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return s.classify(resp)
+}
+
+// classify разбирает ответ API и возвращает классифицированную ошибку или nil.
+func (s *TwilioSender) classify(resp *http.Response) error {
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	if err != nil {
+		return domain.TransientError(err)
+	}
+	var r apiError
+	_ = json.Unmarshal(raw, &r)
+	apiErr := fmt.Errorf("%w: %d %d %s", ErrAPI, resp.StatusCode, r.Code, r.Message)
+
+	// неверные учетные данные — ошибка конфигурации, а не получателя: не переводим в bounced
+	if resp.StatusCode == http.StatusUnauthorized {
+		return domain.TransientError(apiErr)
+	}
+	switch domain.ClassifyHTTPStatus(resp.StatusCode) {
+	case domain.ErrorThrottled:
+		if d, ok := domain.ParseRetryAfter(resp.Header.Get("Retry-After"), s.clock.Now()); ok {
+			return domain.ThrottledErrorAfter(apiErr, d)
+		}
+		return domain.ThrottledError(apiErr)
+	case domain.ErrorPermanent:
+		return domain.PermanentError(apiErr)
+	default:
+		return domain.TransientError(apiErr)
+	}
+}
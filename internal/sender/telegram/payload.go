@@ -0,0 +1,26 @@
+package telegram_sender
+
+import (
+	"fmt"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// parseModes режимы разметки, которые принимает Bot API.
+var parseModes = map[string]bool{"HTML": true, "MarkdownV2": true, "Markdown": true}
+
+// ValidatePayload проверяет parse_mode: Bot API отклоняет сообщение с неизвестным режимом.
+func ValidatePayload(payload map[string]interface{}) error {
+	v, ok := payload[domain.PayloadParseMode]
+	if !ok {
+		return nil
+	}
+	if s, ok := v.(string); !ok || !parseModes[s] {
+		return fmt.Errorf("%w: parse_mode must be HTML, MarkdownV2 or Markdown, got %v",
+			domain.ErrInvalidPayload, v)
+	}
+	return nil
+}
+
+// PayloadValidator валидатор payload сообщений Telegram.
+var PayloadValidator = domain.PayloadValidatorFunc(ValidatePayload)
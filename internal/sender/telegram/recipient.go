@@ -0,0 +1,27 @@
+package telegram_sender
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"DelayedNotifier/internal/domain"
+)
+
+var (
+	// chatIDRe числовой ID чата, отрицательный для групп и каналов.
+	chatIDRe = regexp.MustCompile(`^-?[0-9]{1,20}$`)
+	// usernameRe публичное имя: 5–32 символа, буквы, цифры и подчеркивание, начинается с буквы.
+	usernameRe = regexp.MustCompile(`^@[A-Za-z][A-Za-z0-9_]{4,31}$`)
+)
+
+// ValidateRecipient проверяет, что получатель — числовой ID чата или @username.
+func ValidateRecipient(_ context.Context, recipient string) error {
+	if chatIDRe.MatchString(recipient) || usernameRe.MatchString(recipient) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is neither a chat id nor an @username", domain.ErrInvalidRecipient, recipient)
+}
+
+// RecipientValidator валидатор получателей Telegram.
+var RecipientValidator = domain.RecipientValidatorFunc(ValidateRecipient)
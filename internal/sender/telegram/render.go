@@ -0,0 +1,50 @@
+package telegram_sender
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// Renderer строит текст сообщения Telegram.
+var Renderer = domain.MessageRendererFunc(Render)
+
+// Render строит текст сообщения: subject первой строкой (если есть) и body,
+// а при отсутствии body — поля payload в виде key=value, кроме параметров отправки.
+func Render(n *domain.Notification) (*domain.RenderedMessage, error) {
+	msg := &domain.RenderedMessage{
+		Channel:     n.Channel,
+		Recipient:   n.Recipient,
+		ContentType: "text/plain; charset=utf-8",
+	}
+	if v, ok := n.Payload["subject"]; ok {
+		msg.Subject = fmt.Sprint(v)
+	}
+
+	var body string
+	if v, ok := n.Payload["body"]; ok {
+		body = fmt.Sprint(v)
+	} else {
+		keys := make([]string, 0, len(n.Payload))
+		for k := range n.Payload {
+			if k != "subject" && !domain.IsSendOption(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		lines := make([]string, 0, len(keys))
+		for _, k := range keys {
+			lines = append(lines, fmt.Sprintf("%s=%v", k, n.Payload[k]))
+		}
+		body = strings.Join(lines, "\n")
+	}
+
+	if msg.Subject != "" {
+		msg.Body = msg.Subject + "\n\n" + body
+	} else {
+		msg.Body = body
+	}
+	return msg, nil
+}
@@ -0,0 +1,235 @@
+package telegram_sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+)
+
+const (
+	// DefaultAPIURL адрес Telegram Bot API.
+	DefaultAPIURL = "https://api.telegram.org"
+	// maxResponseSize ограничение читаемого ответа Bot API.
+	maxResponseSize = 64 << 10
+	// maxMessageLength максимальная длина текста сообщения в Bot API.
+	maxMessageLength = 4096
+)
+
+// ErrAPI Bot API вернул ok=false.
+var ErrAPI = errors.New("telegram bot api error")
+
+// BotSender отправляет сообщения методом sendMessage Telegram Bot API.
+type BotSender struct {
+	token  string
+	apiURL string
+	client *http.Client
+	clock  domain.Clock
+
+	// chatInterval минимальный интервал между сообщениями в один чат, 0 — без ограничения
+	chatInterval time.Duration
+	mu           sync.Mutex
+	nextSend     map[string]time.Time
+}
+
+// BotSenderOption функция настройки BotSender.
+type BotSenderOption func(*BotSender)
+
+// WithAPIURL задает адрес Bot API (локальный Bot API сервер, тестовый стенд).
+func WithAPIURL(apiURL string) BotSenderOption {
+	return func(s *BotSender) {
+		s.apiURL = strings.TrimRight(apiURL, "/")
+	}
+}
+
+// WithTransport задает транспорт запросов к Bot API (например, через исходящий прокси).
+func WithTransport(rt http.RoundTripper) BotSenderOption {
+	return func(s *BotSender) {
+		s.client.Transport = rt
+	}
+}
+
+// WithTimeout задает ограничение времени одного запроса к Bot API.
+func WithTimeout(d time.Duration) BotSenderOption {
+	return func(s *BotSender) {
+		s.client.Timeout = d
+	}
+}
+
+// WithClock задает источник времени для интервала между сообщениями и Retry-After,
+// по умолчанию системное время.
+func WithClock(c domain.Clock) BotSenderOption {
+	return func(s *BotSender) {
+		s.clock = c
+	}
+}
+
+// WithChatInterval задает минимальный интервал между сообщениями в один чат.
+// Telegram ограничивает частоту сообщений в чат (около одного в секунду), превышение дает 429.
+func WithChatInterval(d time.Duration) BotSenderOption {
+	return func(s *BotSender) {
+		s.chatInterval = d
+	}
+}
+
+// NewBotSender создает отправщика для бота с токеном token.
+func NewBotSender(token string, opts ...BotSenderOption) (*BotSender, error) {
+	if token == "" {
+		return nil, errors.New("telegram bot token is empty")
+	}
+	s := &BotSender{
+		token:    token,
+		apiURL:   DefaultAPIURL,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		clock:    domain.SystemClock{},
+		nextSend: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// sendMessageRequest тело запроса sendMessage.
+type sendMessageRequest struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// apiResponse ответ Bot API.
+type apiResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// Send отправляет уведомление в чат n.Recipient. Ошибки классифицируются по коду ответа,
+// см. domain.ClassifyHTTPStatus; retry_after из ответа 429 передается в ошибке.
+func (s *BotSender) Send(ctx context.Context, n *domain.Notification) error {
+	rendered, err := Render(n)
+	if err != nil {
+		return domain.PermanentError(err)
+	}
+	text := rendered.Body
+	if strings.TrimSpace(text) == "" {
+		return domain.PermanentError(errors.New("message text is empty"))
+	}
+	if r := []rune(text); len(r) > maxMessageLength {
+		text = string(r[:maxMessageLength])
+	}
+	msg := sendMessageRequest{ChatID: n.Recipient, Text: text}
+	if v, ok := n.Payload[domain.PayloadParseMode]; ok {
+		msg.ParseMode = fmt.Sprint(v)
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return domain.PermanentError(err)
+	}
+
+	if err := s.waitChat(ctx, n.Recipient); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		s.apiURL+"/bot"+s.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return domain.PermanentError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// ошибка сети содержит URL с токеном
+		return domain.TransientError(errors.New(strings.ReplaceAll(err.Error(), s.token, "***")))
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return s.classify(resp)
+}
+
+// classify разбирает ответ Bot API и возвращает классифицированную ошибку или nil.
+func (s *BotSender) classify(resp *http.Response) error {
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return domain.TransientError(err)
+	}
+	var r apiResponse
+	if err := json.Unmarshal(raw, &r); err != nil {
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return domain.TransientError(fmt.Errorf("decode bot api response: %w", err))
+		}
+		r.ErrorCode = resp.StatusCode
+	}
+	if r.OK {
+		return nil
+	}
+	if r.ErrorCode == 0 {
+		r.ErrorCode = resp.StatusCode
+	}
+	apiErr := fmt.Errorf("%w: %d %s", ErrAPI, r.ErrorCode, r.Description)
+
+	switch domain.ClassifyHTTPStatus(r.ErrorCode) {
+	case domain.ErrorThrottled:
+		if r.Parameters.RetryAfter > 0 {
+			return domain.ThrottledErrorAfter(apiErr, time.Duration(r.Parameters.RetryAfter)*time.Second)
+		}
+		if d, ok := domain.ParseRetryAfter(resp.Header.Get("Retry-After"), s.clock.Now()); ok {
+			return domain.ThrottledErrorAfter(apiErr, d)
+		}
+		return domain.ThrottledError(apiErr)
+	case domain.ErrorPermanent:
+		return domain.PermanentError(apiErr)
+	default:
+		return domain.TransientError(apiErr)
+	}
+}
+
+// waitChat выдерживает интервал между сообщениями в один чат: резервирует ближайший
+// свободный слот и ждет его, пока не отменен ctx.
+func (s *BotSender) waitChat(ctx context.Context, chat string) error {
+	if s.chatInterval <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	now := s.clock.Now()
+	at := s.nextSend[chat]
+	if at.Before(now) {
+		at = now
+	}
+	s.nextSend[chat] = at.Add(s.chatInterval)
+	// забываем чаты, в которые давно не писали
+	if len(s.nextSend) > 10000 {
+		for k, v := range s.nextSend {
+			if v.Before(now) {
+				delete(s.nextSend, k)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
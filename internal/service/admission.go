@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
+)
+
+// admission решает, принимать ли новое уведомление при текущем отставании очереди.
+// Размер отставания берется из базы не чаще раза в refresh.
+type admission struct {
+	lowLimit    int
+	normalLimit int
+	refresh     time.Duration
+
+	mu        sync.Mutex
+	backlog   int
+	checkedAt time.Time
+}
+
+// WithAdmission включает контроль приема под нагрузкой: если неотправленных уведомлений
+// с наступившим временем отправки больше lowLimit, отклоняются low, больше normalLimit —
+// еще и normal; high принимаются всегда. 0 отключает порог.
+// Отставание пересчитывается не чаще раза в refresh.
+func WithAdmission(lowLimit, normalLimit int, refresh time.Duration) Option {
+	return func(s *NotificationService) {
+		if lowLimit <= 0 && normalLimit <= 0 {
+			s.admission = nil
+			return
+		}
+		s.admission = &admission{lowLimit: lowLimit, normalLimit: normalLimit, refresh: refresh}
+	}
+}
+
+// admit возвращает domain.ErrOverloaded, если уведомление с приоритетом p сейчас не принимается.
+func (s *NotificationService) admit(ctx context.Context, p domain.Priority) error {
+	a := s.admission
+	if a == nil || p == domain.PriorityHigh {
+		return nil
+	}
+	limit := a.normalLimit
+	if p == domain.PriorityLow {
+		limit = a.lowLimit
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	backlog, err := a.currentBacklog(ctx, s.repo, s.clock.Now())
+	if err != nil {
+		// без данных об отставании не блокируем прием
+		logger.FromContext(ctx).Warn().Msgf("admission: failed to count backlog: %v", err)
+		return nil
+	}
+	if backlog > limit {
+		metrics.AdmissionRejected.Add(p.String(), 1)
+		logger.FromContext(ctx).Warn().Msgf("admission: %s priority rejected, backlog %d > %d", p, backlog, limit)
+		return domain.ErrOverloaded
+	}
+	return nil
+}
+
+func (a *admission) currentBacklog(ctx context.Context, repo domain.NotificationRepository,
+	now time.Time) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.checkedAt.IsZero() && now.Sub(a.checkedAt) < a.refresh {
+		return a.backlog, nil
+	}
+	backlog, err := repo.CountBacklog(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	a.backlog = backlog
+	a.checkedAt = now
+	return backlog, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// DefaultMaxBatch максимум уведомлений в одном пакете, если WithMaxBatch не задан.
+const DefaultMaxBatch = 100
+
+// WithMaxBatch ограничивает число уведомлений в пакете CreateNotifications, 0 — DefaultMaxBatch.
+func WithMaxBatch(n int) Option {
+	return func(s *NotificationService) {
+		s.maxBatch = n
+	}
+}
+
+// MaxBatch возвращает действующее ограничение размера пакета.
+func (s *NotificationService) MaxBatch() int {
+	if s.maxBatch <= 0 {
+		return DefaultMaxBatch
+	}
+	return s.maxBatch
+}
+
+// CreateNotifications проверяет уведомления пакета по отдельности, создает прошедшие проверку
+// одним запросом и публикует их. Ошибки проверки возвращаются в результате элемента,
+// ошибка записи в базу — для всего пакета: в этом случае не создается ни одно уведомление.
+// Элемент с уже использованным ключом идемпотентности получает ранее созданное уведомление,
+// повтор ключа внутри пакета — тот же результат, что и первый элемент с этим ключом.
+func (s *NotificationService) CreateNotifications(ctx context.Context,
+	params []domain.CreateNotificationParams) ([]domain.BatchCreateResult, error) {
+	op := "CreateNotifications:"
+	if len(params) == 0 {
+		return nil, domain.ErrEmptyBatch
+	}
+	if len(params) > s.MaxBatch() {
+		return nil, fmt.Errorf("%w: %d > %d", domain.ErrBatchTooLarge, len(params), s.MaxBatch())
+	}
+
+	results := make([]domain.BatchCreateResult, len(params))
+	var (
+		valid   []domain.CreateParams
+		ttls    []time.Duration
+		indexes []int
+		// repeats индекс повторного элемента -> индекс первого элемента с тем же ключом
+		repeats = map[int]int{}
+		keys    = map[string]int{}
+	)
+	for i, p := range params {
+		if p.IdempotencyKey != "" {
+			k := p.Source + "\x00" + p.IdempotencyKey
+			if first, ok := keys[k]; ok {
+				repeats[i] = first
+				continue
+			}
+			keys[k] = i
+			existing, err := s.findIdempotent(ctx, p.Source, p.IdempotencyKey)
+			if err != nil || existing != nil {
+				results[i].Notification, results[i].Err = existing, err
+				continue
+			}
+		}
+		opt, ttl, err := s.prepareCreate(ctx, p)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, opt)
+		ttls = append(ttls, ttl)
+		indexes = append(indexes, i)
+	}
+	if len(valid) > 0 {
+		created, err := s.repo.CreateBatch(ctx, valid)
+		if err != nil {
+			logger.FromContext(ctx).Error().Msgf("%s failed to create %d notifications: %v", op, len(valid), err)
+			return nil, err
+		}
+		for j, n := range created {
+			i := indexes[j]
+			if n == nil {
+				// параллельный запрос с тем же ключом успел раньше: отдаем его уведомление
+				results[i].Notification, results[i].Err = s.findIdempotent(ctx, params[i].Source, params[i].IdempotencyKey)
+				if results[i].Notification == nil && results[i].Err == nil {
+					results[i].Err = domain.ErrDuplicateIdempotencyKey
+				}
+				continue
+			}
+			results[i].Notification, results[i].Err = s.dispatchCreated(ctx, n, ttls[j])
+		}
+	}
+	for i, first := range repeats {
+		results[i] = results[first]
+	}
+	logger.FromContext(ctx).Debug().Msgf("%s created %d of %d notifications", op, len(valid), len(params))
+	return results, nil
+}
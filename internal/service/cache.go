@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// redisKeyPrefix префикс ключей уведомлений в Redis.
+const redisKeyPrefix = "notification:"
+
+// CacheKey возвращает ключ Redis для уведомления. Все операции с кэшем
+// (запись, чтение, удаление) должны формировать ключ только через эту функцию.
+func CacheKey(id uuid.UUID) string {
+	return redisKeyPrefix + id.String()
+}
+
+// cachePatternPrefixes префиксы, с которых должен начинаться шаблон очистки кэша:
+// без него один запрос мог бы стереть чужие ключи в общем Redis.
+var cachePatternPrefixes = []string{redisKeyPrefix, snoozeKeyPrefix}
+
+// WithCacheInspector подключает диагностику кэша для административного API.
+func WithCacheInspector(c domain.CacheInspector) Option {
+	return func(s *NotificationService) {
+		s.cacheInspector = c
+	}
+}
+
+// WithCacheWriteThrough задает, что делать с записью кеша после изменения уведомления: true
+// (по умолчанию) — перезаписать новым состоянием, false — удалить, и следующее чтение возьмет его из базы.
+func WithCacheWriteThrough(enabled bool) Option {
+	return func(s *NotificationService) {
+		s.writeThrough = enabled
+	}
+}
+
+// syncCache приводит запись кеша к состоянию n после записи в базу. Если перезаписать не удалось,
+// запись удаляется: лучше промах, чем прежний статус у читателей.
+func (s *NotificationService) syncCache(ctx context.Context, n *domain.Notification) error {
+	if s.writeThrough {
+		err := s.marshalAndSet(ctx, n)
+		if err == nil {
+			return nil
+		}
+		logger.FromContext(ctx).Warn().Msgf("%s failed to rewrite cache, evicting: %v", n.ID, err)
+	}
+	return s.evictCached(ctx, n.ID)
+}
+
+// evictCached удаляет запись кеша после изменения, состояние которого сервису известно не полностью
+// (например, updated_at выставила база).
+func (s *NotificationService) evictCached(ctx context.Context, id uuid.UUID) error {
+	if err := s.redis.Del(ctx, CacheKey(id)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to evict stale notification from cache: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// InspectCache возвращает запись кэша уведомления как она лежит в Redis, с TTL.
+func (s *NotificationService) InspectCache(ctx context.Context, id uuid.UUID) (*domain.CacheEntry, error) {
+	if s.cacheInspector == nil {
+		return nil, domain.ErrCacheInspectorDisabled
+	}
+	return s.cacheInspector.Inspect(ctx, CacheKey(id))
+}
+
+// EvictCache удаляет запись кэша уведомления; следующее чтение возьмет его из базы.
+func (s *NotificationService) EvictCache(ctx context.Context, id uuid.UUID) error {
+	if err := s.redis.Del(ctx, CacheKey(id)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to evict notification from cache: %v", id, err)
+		return err
+	}
+	logger.FromContext(ctx).Info().Msgf("%s notification evicted from cache", id)
+	return nil
+}
+
+// FlushCache находит ключи кэша по шаблону (не больше limit) и, если это не пробный запуск, удаляет их.
+// Шаблон должен начинаться с известного префикса ключей: "notification:*" допустим, "*" и "notif*" — нет.
+func (s *NotificationService) FlushCache(ctx context.Context, pattern string, limit int,
+	dryRun bool) (domain.CacheFlushResult, error) {
+	if s.cacheInspector == nil {
+		return domain.CacheFlushResult{}, domain.ErrCacheInspectorDisabled
+	}
+	if !validCachePattern(pattern) {
+		return domain.CacheFlushResult{}, domain.ErrInvalidCachePattern
+	}
+	keys, truncated, err := s.cacheInspector.ScanKeys(ctx, pattern, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to scan cache keys %q: %v", pattern, err)
+		return domain.CacheFlushResult{}, err
+	}
+	res := domain.CacheFlushResult{Keys: keys, Truncated: truncated}
+	if dryRun || len(keys) == 0 {
+		return res, nil
+	}
+	res.Deleted, err = s.cacheInspector.DeleteKeys(ctx, keys)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to flush cache keys %q: %v", pattern, err)
+		return res, err
+	}
+	logger.FromContext(ctx).Warn().Str("pattern", pattern).Int("deleted", res.Deleted).
+		Bool("truncated", truncated).Msg("cache flushed")
+	return res, nil
+}
+
+func validCachePattern(pattern string) bool {
+	for _, prefix := range cachePatternPrefixes {
+		if strings.HasPrefix(pattern, prefix) {
+			return true
+		}
+	}
+	return false
+}
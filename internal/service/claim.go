@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// sendLeaseKeyPrefix префикс ключей аренды отправки в Redis.
+const sendLeaseKeyPrefix = "send_lease:"
+
+// sendLeaseTTL срок аренды отправки: как порог, после которого сверка считает processing зависшим,
+// чтобы аренда упавшего обработчика не пережила возврат уведомления в отправку.
+const sendLeaseTTL = 10 * time.Minute
+
+// SendLeaseKey возвращает ключ Redis, которым отмечено уведомление, отправляемое сейчас.
+func SendLeaseKey(id uuid.UUID) string {
+	return sendLeaseKeyPrefix + id.String()
+}
+
+// ClaimSend закрепляет отправку уведомления за вызывающим. На время отправки берется аренда
+// в Redis, чтобы два сообщения одного уведомления (опоздавшее исходное и опубликованное сверкой)
+// не отправлялись одновременно; pending переводится в processing условным обновлением.
+// false — уведомление уже не ждет отправки или его отправляет другой обработчик.
+// release снимает аренду, его нужно вызвать после обработки.
+func (s *NotificationService) ClaimSend(ctx context.Context, n *domain.Notification) (func(), bool, error) {
+	release := func() {}
+	ok, err := s.redis.SetNXWithExpiration(ctx, SendLeaseKey(n.ID), s.clock.Now().Format(time.RFC3339), sendLeaseTTL)
+	switch {
+	case err != nil:
+		// без Redis отправка не останавливается: от повторов защищает условный переход ниже
+		logger.FromContext(ctx).Warn().Msgf("%s failed to take send lease: %v", n.ID, err)
+	case !ok:
+		return release, false, nil
+	default:
+		release = func() {
+			if err := s.redis.Del(context.WithoutCancel(ctx), SendLeaseKey(n.ID)); err != nil {
+				logger.FromContext(ctx).Error().Msgf("%s failed to release send lease: %v", n.ID, err)
+			}
+		}
+	}
+	if n.Status != domain.StatusPending {
+		return release, true, nil
+	}
+
+	claimed, err := s.repo.PendingToProcess(ctx, n.ID)
+	if err != nil {
+		release()
+		logger.FromContext(ctx).Error().Msgf("%s failed to claim pending notification: %v", n.ID, err)
+		return func() {}, false, err
+	}
+	if !claimed {
+		release()
+		_ = s.evictCached(ctx, n.ID)
+		return func() {}, false, nil
+	}
+	s.recordTransition(ctx, n.ID, n.Status, domain.StatusProcessing, "")
+	n.Status = domain.StatusProcessing
+	n.UpdatedAt = s.clock.Now()
+	_ = s.evictCached(ctx, n.ID)
+	return release, true, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Названия кодеков записей кэша.
+const (
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+)
+
+// Заголовок записи кэша: нулевой байт (не может начинать JSON-документ)
+// и идентификатор кодека. Записи без заголовка считаются JSON,
+// так читаются данные, записанные до появления версионирования.
+const (
+	codecMagic     byte = 0x00
+	codecIDJSON    byte = 0x01
+	codecIDMsgpack byte = 0x02
+)
+
+var errUnknownCodec = errors.New("unknown cache codec")
+
+// CacheCodec кодирует уведомления для хранения в Redis.
+type CacheCodec interface {
+	// Name название кодека
+	Name() string
+	// Marshal кодирует значение вместе с заголовком версии
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// NewCacheCodec возвращает кодек по названию.
+func NewCacheCodec(name string) (CacheCodec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case CodecJSON, "":
+		return jsonCodec{}, nil
+	case CodecMsgpack:
+		return msgpackCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownCodec, name)
+	}
+}
+
+// decodeCacheEntry декодирует запись кэша по ее заголовку независимо от
+// настроенного кодека, поэтому при постепенном обновлении экземпляры
+// с разными кодеками читают записи друг друга.
+func decodeCacheEntry(data []byte, v interface{}) error {
+	if len(data) < 2 || data[0] != codecMagic {
+		return json.Unmarshal(data, v)
+	}
+	switch data[1] {
+	case codecIDJSON:
+		return json.Unmarshal(data[2:], v)
+	case codecIDMsgpack:
+		return codec.NewDecoderBytes(data[2:], msgpackHandle).Decode(v)
+	default:
+		return fmt.Errorf("%w: id %d", errUnknownCodec, data[1])
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return CodecJSON }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{codecMagic, codecIDJSON}, data...), nil
+}
+
+var msgpackHandle = newMsgpackHandle()
+
+func newMsgpackHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return CodecMsgpack }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(v); err != nil {
+		return nil, err
+	}
+	return append([]byte{codecMagic, codecIDMsgpack}, data...), nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// WithEventLog подключает историю статусов уведомлений (notification_events).
+func WithEventLog(repo domain.EventRepository) Option {
+	return func(s *NotificationService) {
+		s.events = repo
+	}
+}
+
+// recordTransition записывает переход статуса в историю от имени инициатора из контекста;
+// reason — причина перехода, пустая означает причину из контекста (WithTransitionError).
+// История вспомогательная: ошибка записи логируется и не прерывает обработку уведомления.
+func (s *NotificationService) recordTransition(ctx context.Context, id uuid.UUID, from, to domain.Status,
+	reason string) {
+	if s.events == nil || from == to {
+		return
+	}
+	if reason == "" {
+		reason = domain.TransitionErrorFromContext(ctx)
+	}
+	err := s.events.RecordEvent(ctx, domain.NotificationEvent{
+		NotificationID: id,
+		FromStatus:     from,
+		ToStatus:       to,
+		Actor:          domain.ActorFromContext(ctx),
+		Error:          reason,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to record status %s -> %s: %v", id, from, to, err)
+	}
+}
+
+// History возвращает историю статусов уведомления в порядке переходов.
+func (s *NotificationService) History(ctx context.Context, id uuid.UUID) ([]domain.NotificationEvent, error) {
+	if s.events == nil {
+		return nil, domain.ErrEventLogDisabled
+	}
+	if _, err := s.GetNotificationByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.events.ListEvents(ctx, id)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// WithMaxRetries ограничивает число попыток отправки уведомления за все время: после max
+// уведомление помечается failed и записывается в журнал неуспешных. 0 — без ограничения.
+func WithMaxRetries(max int) Option {
+	return func(s *NotificationService) {
+		s.maxRetries = max
+	}
+}
+
+// WithFailureLog подключает журнал уведомлений, исчерпавших попытки (notification_failures).
+func WithFailureLog(repo domain.FailureRepository) Option {
+	return func(s *NotificationService) {
+		s.failures = repo
+	}
+}
+
+// RetriesExhausted сообщает, исчерпало ли уведомление предел попыток отправки.
+func (s *NotificationService) RetriesExhausted(n *domain.Notification) bool {
+	return s.maxRetries > 0 && n.RetryCount >= s.maxRetries
+}
+
+// FailExhausted помечает уведомление failed и записывает в журнал последнюю ошибку и число попыток.
+func (s *NotificationService) FailExhausted(ctx context.Context, n *domain.Notification, cause error) error {
+	if cause == nil {
+		cause = domain.ErrMaxRetriesExceeded
+	}
+	if err := s.updateNotification(ctx, n, cause.Error(), domain.WithStatus(domain.StatusFailed)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to mark exhausted notification failed: %v", n.ID, err)
+		return err
+	}
+	logger.FromContext(ctx).Warn().Err(cause).Int("attempts", n.RetryCount).Msg("max retries exceeded")
+	if s.failures == nil {
+		return nil
+	}
+	_, err := s.failures.RecordFailure(ctx, domain.NotificationFailure{
+		NotificationID: n.ID,
+		Error:          cause.Error(),
+		Attempts:       n.RetryCount,
+		ScheduledAt:    n.ScheduledAt,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to record notification failure: %v", n.ID, err)
+		return err
+	}
+	return nil
+}
+
+// ListFailures возвращает записи журнала неуспешных для уведомления, последние первыми.
+func (s *NotificationService) ListFailures(ctx context.Context, id uuid.UUID) ([]domain.NotificationFailure, error) {
+	if s.failures == nil {
+		return nil, domain.ErrFailureLogDisabled
+	}
+	return s.failures.ListFailures(ctx, id)
+}
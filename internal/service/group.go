@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// groupNamespace пространство имен UUID групп, выводимых из ключа идемпотентности.
+var groupNamespace = uuid.MustParse("6f1d2c3e-4b5a-4c7d-9e8f-0a1b2c3d4e5f")
+
+// CreateGroup создает по уведомлению на каждого получателя пакетом CreateNotifications.
+// Повторы получателей в запросе отбрасываются. С ключом идемпотентности группа и ключи
+// уведомлений выводятся из него, поэтому повтор запроса возвращает ту же группу.
+func (s *NotificationService) CreateGroup(ctx context.Context, params domain.CreateNotificationParams,
+	recipients []string) (*domain.GroupCreateResult, error) {
+	if len(recipients) == 0 {
+		return nil, domain.ErrEmptyRecipient
+	}
+	seen := make(map[string]bool, len(recipients))
+	unique := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		if !seen[r] {
+			seen[r] = true
+			unique = append(unique, r)
+		}
+	}
+	if len(unique) > s.MaxBatch() {
+		return nil, fmt.Errorf("%w: %d > %d", domain.ErrBatchTooLarge, len(unique), s.MaxBatch())
+	}
+
+	groupID := uuid.New()
+	if params.IdempotencyKey != "" {
+		groupID = uuid.NewSHA1(groupNamespace, []byte(params.Source+"\x00"+params.IdempotencyKey))
+	}
+	items := make([]domain.CreateNotificationParams, len(unique))
+	for i, r := range unique {
+		items[i] = params
+		items[i].Recipient = r
+		items[i].GroupID = &groupID
+		if params.IdempotencyKey != "" {
+			items[i].IdempotencyKey = params.IdempotencyKey + "/" + r
+		}
+	}
+	results, err := s.CreateNotifications(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Debug().Msgf("group %s: %d recipients", groupID, len(unique))
+	return &domain.GroupCreateResult{GroupID: groupID, Recipients: unique, Results: results}, nil
+}
+
+// GroupStatus возвращает сводное состояние уведомлений группы.
+func (s *NotificationService) GroupStatus(ctx context.Context, groupID uuid.UUID) (*domain.GroupStatus, error) {
+	ns, err := s.repo.ListGroup(ctx, groupID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to list group %s: %v", groupID, err)
+		return nil, err
+	}
+	if len(ns) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return domain.NewGroupStatus(groupID, ns), nil
+}
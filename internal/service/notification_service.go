@@ -2,19 +2,18 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"time"
 
 	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
-	"github.com/wb-go/wbf/zlog"
-)
-
-const (
-	redisKeyPrefix = "notification:"
 )
 
 type NotificationService struct {
@@ -22,69 +21,338 @@ type NotificationService struct {
 	publisher       domain.MessageQueuePublisher
 	redis           domain.RedisRepository
 	redisExpiration time.Duration
+	codec           CacheCodec
+	maxPast         time.Duration
+	maxFuture       time.Duration
+	recipients      map[domain.Channel]domain.RecipientValidator
+	payloads        map[domain.Channel]domain.PayloadValidator
+	renderers       map[domain.Channel]domain.MessageRenderer
+	smoothWindow    time.Duration
+	admission       *admission
+	views           domain.SavedViewRepository
+	templates       domain.TemplateRepository
+	schemas         domain.SchemaRepository
+	failures        domain.FailureRepository
+	events          domain.EventRepository
+	channelDefaults domain.ChannelDefaults
+	cacheInspector  domain.CacheInspector
+	writeThrough    bool
+	receiptDedupTTL time.Duration
+	maxRetries      int
+	maxBatch        int
+	statusSecret    []byte
+	statusMaxTTL    time.Duration
+	sla             slaPolicy
+	clock           domain.Clock
+}
+
+// Option функция настройки NotificationService.
+type Option func(*NotificationService)
+
+// WithCacheCodec задает кодек записей кэша, по умолчанию JSON.
+func WithCacheCodec(c CacheCodec) Option {
+	return func(s *NotificationService) {
+		s.codec = c
+	}
+}
+
+// WithScheduleLimits ограничивает время отправки: не раньше maxPast назад
+// (если не указан Immediate) и не позже maxFuture вперед. 0 отключает ограничение.
+func WithScheduleLimits(maxPast, maxFuture time.Duration) Option {
+	return func(s *NotificationService) {
+		s.maxPast = maxPast
+		s.maxFuture = maxFuture
+	}
+}
+
+// WithRecipientValidator регистрирует проверку формата получателя для канала.
+func WithRecipientValidator(ch domain.Channel, v domain.RecipientValidator) Option {
+	return func(s *NotificationService) {
+		if s.recipients == nil {
+			s.recipients = make(map[domain.Channel]domain.RecipientValidator)
+		}
+		s.recipients[ch] = v
+	}
+}
+
+// WithChannelDefaults задает значения payload по умолчанию для каналов (имя отправителя,
+// режим разметки и т.п.): они подставляются при создании уведомления, если в payload их нет.
+func WithChannelDefaults(d domain.ChannelDefaults) Option {
+	return func(s *NotificationService) {
+		s.channelDefaults = d
+	}
+}
+
+// WithRenderer регистрирует построение итогового сообщения канала для предпросмотра.
+func WithRenderer(ch domain.Channel, r domain.MessageRenderer) Option {
+	return func(s *NotificationService) {
+		if s.renderers == nil {
+			s.renderers = make(map[domain.Channel]domain.MessageRenderer)
+		}
+		s.renderers[ch] = r
+	}
+}
+
+// WithClock задает источник времени, по умолчанию системное время.
+func WithClock(c domain.Clock) Option {
+	return func(s *NotificationService) {
+		s.clock = c
+	}
+}
+
+// WithSmoothing задает окно сглаживания: уведомления с Smooth отправляются
+// в случайный момент [scheduled_at, scheduled_at+window). 0 отключает сглаживание.
+func WithSmoothing(window time.Duration) Option {
+	return func(s *NotificationService) {
+		s.smoothWindow = window
+	}
 }
 
 func NewNotificationService(
 	repo domain.NotificationRepository,
 	publisher domain.MessageQueuePublisher,
 	redis domain.RedisRepository,
-	redisExpiration time.Duration) *NotificationService {
-	return &NotificationService{repo: repo, publisher: publisher, redis: redis, redisExpiration: redisExpiration}
+	redisExpiration time.Duration,
+	opts ...Option) *NotificationService {
+	s := &NotificationService{repo: repo, publisher: publisher, redis: redis, redisExpiration: redisExpiration,
+		codec: jsonCodec{}, clock: domain.SystemClock{}, writeThrough: true}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *NotificationService) CreateNotification(ctx context.Context,
 	params domain.CreateNotificationParams) (*domain.Notification, error) {
 	op := "CreateNotification:"
+	if params.IdempotencyKey != "" {
+		existing, err := s.findIdempotent(ctx, params.Source, params.IdempotencyKey)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+	}
+	opt, ttl, err := s.prepareCreate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.repo.Create(ctx, opt)
+	if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+		// параллельный запрос с тем же ключом успел раньше: отдаем его уведомление
+		existing, ferr := s.findIdempotent(ctx, params.Source, params.IdempotencyKey)
+		if ferr == nil && existing != nil {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to create notification: %v", op, err)
+		return nil, err
+	}
+	return s.dispatchCreated(ctx, n, ttl)
+}
+
+// findIdempotent ищет уведомление, уже созданное с ключом идемпотентности; nil, если его нет.
+func (s *NotificationService) findIdempotent(ctx context.Context, source, key string) (*domain.Notification, error) {
+	n, err := s.repo.GetByIdempotencyKey(ctx, source, key)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("CreateNotification: failed to look up idempotency key: %v", err)
+		return nil, err
+	}
+	logger.FromContext(ctx).Debug().Msgf("CreateNotification: idempotency key replayed, returning %s", n.ID)
+	return n, nil
+}
+
+// prepareCreate проверяет параметры нового уведомления и рассчитывает его статус и
+// задержку публикации; возвращает параметры записи в репозиторий.
+func (s *NotificationService) prepareCreate(ctx context.Context,
+	params domain.CreateNotificationParams) (domain.CreateParams, time.Duration, error) {
+	op := "CreateNotification:"
 	if !params.Channel.IsValid() {
-		zlog.Logger.Warn().Msgf("%s notification (channel = %s) is invalid", op, params.Channel.String())
-		return nil, domain.ErrInvalidChannel
+		logger.FromContext(ctx).Warn().Msgf("%s notification (channel = %s) is invalid", op, params.Channel.String())
+		return domain.CreateParams{}, 0, domain.ErrInvalidChannel
 	}
 	if params.Recipient == "" {
-		zlog.Logger.Warn().Msgf("%s recipient is empty", op)
-		return nil, domain.ErrEmptyRecipient
+		logger.FromContext(ctx).Warn().Msgf("%s recipient is empty", op)
+		return domain.CreateParams{}, 0, domain.ErrEmptyRecipient
+	}
+	if v, ok := s.recipients[params.Channel]; ok {
+		if err := v.ValidateRecipient(ctx, params.Recipient); err != nil {
+			logger.FromContext(ctx).Warn().Msgf("%s %v", op, err)
+			return domain.CreateParams{}, 0, err
+		}
+	}
+	if params.Priority == "" {
+		params.Priority = domain.PriorityNormal
+	}
+	if !params.Priority.IsValid() {
+		logger.FromContext(ctx).Warn().Msgf("%s priority %s is invalid", op, params.Priority)
+		return domain.CreateParams{}, 0, domain.ErrInvalidPriority
+	}
+	// черновик не отправляется: время и нагрузка проверяются при планировании
+	if !params.Draft {
+		if err := s.validateSchedule(params); err != nil {
+			logger.FromContext(ctx).Warn().Msgf("%s %v: %s", op, err, params.ScheduledAt)
+			return domain.CreateParams{}, 0, err
+		}
+		if err := s.admit(ctx, params.Priority); err != nil {
+			return domain.CreateParams{}, 0, err
+		}
+	}
+	if err := s.checkSchema(ctx, params.Source, params.Channel, params.Payload); err != nil {
+		logger.FromContext(ctx).Warn().Msgf("%s %v", op, err)
+		return domain.CreateParams{}, 0, err
+	}
+	payload := s.channelDefaults.Apply(params.Channel, params.Payload)
+	if err := s.checkPayload(ctx, params.Channel, params.TemplateID, payload); err != nil {
+		logger.FromContext(ctx).Warn().Msgf("%s %v", op, err)
+		return domain.CreateParams{}, 0, err
 	}
 	opt := domain.CreateParams{
-		Recipient:   params.Recipient,
-		Channel:     params.Channel,
-		Payload:     params.Payload,
-		ScheduledAt: params.ScheduledAt,
+		Recipient:        params.Recipient,
+		Channel:          params.Channel,
+		Payload:          payload,
+		ScheduledAt:      params.ScheduledAt,
+		CancelOnConfirm:  params.CancelOnConfirm,
+		PreSendCheck:     params.PreSendCheck,
+		Source:           params.Source,
+		RequiresApproval: params.RequiresApproval,
+		IdempotencyKey:   params.IdempotencyKey,
+		GroupID:          params.GroupID,
+		TemplateID:       params.TemplateID,
+		Priority:         params.Priority,
+	}
+	opt.EffectiveScheduledAt = params.ScheduledAt
+	if params.Smooth && s.smoothWindow > 0 {
+		opt.EffectiveScheduledAt = params.ScheduledAt.Add(time.Duration(rand.Int64N(int64(s.smoothWindow))))
+	}
+	if params.ParentID != nil {
+		parent, err := s.GetNotificationByID(ctx, *params.ParentID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.CreateParams{}, 0, fmt.Errorf("%w: %s", domain.ErrParentNotFound, params.ParentID)
+			}
+			return domain.CreateParams{}, 0, err
+		}
+		rootID := parent.RootID()
+		opt.ParentID = &parent.ID
+		opt.CorrelationID = &rootID
 	}
-	currentTime := time.Now().Add(2 * time.Second)
 	var ttl time.Duration
-	if params.ScheduledAt.Before(currentTime) {
-		ttl = 2 * time.Second
-		opt.Status = domain.StatusProcessing
-	} else {
-		opt.Status = domain.StatusPending
-		ttl = params.ScheduledAt.Sub(currentTime)
+	opt.Status, ttl = s.dispatchPlan(opt.EffectiveScheduledAt)
+	if params.RequiresApproval {
+		opt.Status = domain.StatusAwaitingApproval
 	}
-
-	n, err := s.repo.Create(ctx, opt)
-	if err != nil {
-		zlog.Logger.Error().Msgf("%s failed to create notification: %v", op, err)
-		return nil, err
+	if params.Draft {
+		opt.Status = domain.StatusDraft
 	}
+	return opt, ttl, nil
+}
 
-	if err := s.marshalAndSet(ctx, n); err != nil {
-		return nil, err
+// dispatchCreated кладет созданное уведомление в кеш и публикует его в очередь с задержкой ttl;
+// если публикация не удалась, уведомление остается pending до сверки.
+func (s *NotificationService) dispatchCreated(ctx context.Context, n *domain.Notification,
+	ttl time.Duration) (*domain.Notification, error) {
+	op := "CreateNotification:"
+	if s.writeThrough {
+		if err := s.marshalAndSet(ctx, n); err != nil {
+			return nil, err
+		}
 	}
+	metrics.CountBySource(n.Source, "created")
+	s.recordTransition(ctx, n.ID, "", n.Status, "")
 
-	zlog.Logger.Debug().Msgf("%s notification created, ttl:%v", op, ttl)
-	err = s.publisher.Publish(ctx, n.ID, ttl)
+	if n.Status == domain.StatusAwaitingApproval || n.Status == domain.StatusDraft {
+		logger.FromContext(ctx).Debug().Msgf("%s notification created, status %s", op, n.Status)
+		return n, nil
+	}
+	logger.FromContext(ctx).Debug().Msgf("%s notification created, ttl:%v", op, ttl)
+	err := s.publish(ctx, n, ttl)
 	if err != nil {
-		zlog.Logger.Error().Msgf("%s failed to send notification: %v", op, err)
-		err = s.repo.Update(ctx, n.ID, domain.WithStatus(domain.StatusPending))
-		if err != nil {
-			zlog.Logger.Error().Msgf("%s failed to update status: %v", op, err)
+		logger.FromContext(ctx).Error().Msgf("%s failed to send notification: %v", op, err)
+		if err := s.updateNotification(ctx, n, err.Error(), domain.WithStatus(domain.StatusPending)); err != nil {
+			logger.FromContext(ctx).Error().Msgf("%s failed to update status: %v", op, err)
 			return nil, err
 		}
-		n.Status = domain.StatusPending
 	}
 
 	return n, nil
 }
 
+// dispatchLead запас на публикацию и маршрутизацию сообщения до консьюмера: время отправки
+// ближе него считается наступившим, и с такой же задержкой публикуется отправка «сразу».
+const dispatchLead = 2 * time.Second
+
+// dispatchPlan возвращает статус и задержку публикации для времени отправки effective:
+// наступившее время отправляется сразу (processing), будущее ждет в очереди (pending).
+func (s *NotificationService) dispatchPlan(effective time.Time) (domain.Status, time.Duration) {
+	currentTime := s.clock.Now().Add(dispatchLead)
+	if effective.Before(currentTime) {
+		return domain.StatusProcessing, dispatchLead
+	}
+	return domain.StatusPending, effective.Sub(currentTime)
+}
+
+// CloneNotification создает копию уведомления с новыми получателем и временем отправки.
+// Без нового времени копия наследует исходное: прошедшее время означает отправку сразу.
+func (s *NotificationService) CloneNotification(ctx context.Context, id uuid.UUID,
+	params domain.CloneNotificationParams) (*domain.Notification, error) {
+	src, err := s.GetNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	create := domain.CreateNotificationParams{
+		Recipient:   src.Recipient,
+		Channel:     src.Channel,
+		Payload:     src.Payload,
+		ScheduledAt: src.ScheduledAt,
+		Immediate:   params.Immediate,
+		ParentID:    &src.ID,
+		Source:      src.Source,
+		TemplateID:  src.TemplateID,
+		Priority:    src.Priority,
+	}
+	if params.Recipient != "" {
+		create.Recipient = params.Recipient
+	}
+	if params.ScheduledAt != nil {
+		create.ScheduledAt = *params.ScheduledAt
+	} else {
+		create.Immediate = true
+	}
+
+	n, err := s.CreateNotification(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("notification %s cloned from %s", n.ID, id)
+	return n, nil
+}
+
+// GetRelatedNotifications получает цепочку уведомлений, к которой относится id.
+func (s *NotificationService) GetRelatedNotifications(ctx context.Context, id uuid.UUID) ([]domain.Notification, error) {
+	n, err := s.GetNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	related, err := s.repo.ListRelated(ctx, n.RootID())
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to list related notifications for %s: %v", id, err)
+		return nil, err
+	}
+	return related, nil
+}
+
 func (s *NotificationService) UpdateNotification(ctx context.Context, n *domain.Notification,
+	opts ...domain.UpdateOption) error {
+	return s.updateNotification(ctx, n, "", opts...)
+}
+
+// updateNotification обновляет уведомление и записывает смену статуса в историю с причиной reason.
+func (s *NotificationService) updateNotification(ctx context.Context, n *domain.Notification, reason string,
 	opts ...domain.UpdateOption) error {
 	op := "UpdateNotification:"
 	if len(opts) == 0 {
@@ -94,32 +362,51 @@ func (s *NotificationService) UpdateNotification(ctx context.Context, n *domain.
 	for _, opt := range opts {
 		opt(params)
 	}
+	from := n.Status
 	if params.Status != nil {
 		if !params.Status.IsValid() {
-			zlog.Logger.Warn().Msgf("%s notification (status = %s) is invalid", op, params.Status.String())
+			logger.FromContext(ctx).Warn().Msgf("%s notification (status = %s) is invalid", op, params.Status.String())
 			return domain.ErrInvalidStatus
 		}
 		n.Status = *params.Status
 	}
 	if params.Channel != nil {
-		if params.Channel.IsValid() {
-			zlog.Logger.Warn().Msgf("%s channel (channel = %s) is invalid", op, params.Channel.String())
+		if !params.Channel.IsValid() {
+			logger.FromContext(ctx).Warn().Msgf("%s channel (channel = %s) is invalid", op, params.Channel.String())
 			return domain.ErrInvalidChannel
 		}
 		n.Channel = *params.Channel
 	}
+	if params.RetryCountInc != nil {
+		n.RetryCount++
+	}
 
+	// updated_at в кеше и в базе одинаковый: по нему строится ETag уведомления;
+	// база хранит микросекунды
+	updatedAt := s.clock.Now().Truncate(time.Microsecond)
+	opts = append(opts, domain.WithUpdatedAt(updatedAt))
+	locked := domain.PreconditionFromContext(ctx) != nil
+	if locked {
+		opts = append(opts, domain.WithIfUpdatedAt(n.UpdatedAt))
+	}
 	if err := s.repo.Update(ctx, n.ID, opts...); err != nil {
 		if errors.Is(err, domain.ErrNoRowAffected) {
-			zlog.Logger.Warn().Msgf("%s Notification Update: %v", op, err)
+			logger.FromContext(ctx).Warn().Msgf("%s Notification Update: %v", op, err)
+			// строку изменили или удалили в обход этого экземпляра: кеш о ней уже неверен
+			_ = s.evictCached(ctx, n.ID)
+			if locked {
+				return domain.ErrPreconditionFailed
+			}
 			return nil
 		}
-		zlog.Logger.Error().Msgf("%s failed to update notification: %v", op, err)
+		logger.FromContext(ctx).Error().Msgf("%s failed to update notification: %v", op, err)
 		return err
 	}
-	err := s.marshalAndSet(ctx, n)
+	s.recordTransition(ctx, n.ID, from, n.Status, reason)
+	n.UpdatedAt = updatedAt
+	err := s.syncCache(ctx, n)
 	if err != nil {
-		zlog.Logger.Error().Msgf("%s failed to update notification: %v", op, err)
+		logger.FromContext(ctx).Error().Msgf("%s failed to update notification: %v", op, err)
 		return err
 	}
 	return nil
@@ -127,90 +414,756 @@ func (s *NotificationService) UpdateNotification(ctx context.Context, n *domain.
 
 func (s *NotificationService) GetNotificationByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
 	var n *domain.Notification
-	redisData, err := s.redis.Get(ctx, id.String())
-	zlog.Logger.Debug().Err(err).Msgf("Get notification by id not found %v", errors.Is(err, redis.Nil))
+	redisData, err := s.redis.Get(ctx, CacheKey(id))
+	logger.FromContext(ctx).Debug().Err(err).Msgf("Get notification by id not found %v", errors.Is(err, redis.Nil))
 	if err != nil && !errors.Is(err, redis.Nil) {
-		zlog.Logger.Error().Err(err).Msgf("failed to fetch notification: %v", err)
+		logger.FromContext(ctx).Error().Err(err).Msgf("failed to fetch notification: %v", err)
 		return nil, err
 	}
 
-	if errors.Is(err, redis.Nil) {
-		zlog.Logger.Debug().Msgf("%s: notification not found fetch to database", id)
-		n, err = s.repo.GetByID(ctx, id)
-		if err != nil {
-			if errors.Is(err, domain.ErrNotFound) {
-				zlog.Logger.Warn().Msgf("notification (id = %s) not found", id)
-				return nil, domain.ErrNotFound
-			}
-			return nil, err
+	if err == nil {
+		// запись не логируется: в payload могут быть секреты
+		logger.FromContext(ctx).Debug().Msgf("%s: notification found in cache", id.String())
+		if err = decodeCacheEntry([]byte(redisData), &n); err == nil && n != nil {
+			return n, nil
 		}
+		// битая запись кеша: читаем из базы и перезаписываем ее
+		logger.FromContext(ctx).Error().Err(err).Msgf("%s: failed to unmarshal notification, fetch to database", id)
+	} else {
+		logger.FromContext(ctx).Debug().Msgf("%s: notification not found fetch to database", id)
+	}
 
-		err := s.marshalAndSet(ctx, n)
-		if err != nil {
-			zlog.Logger.Error().Msgf("%s failed to update to redis notification info: %v", id, err)
-			return nil, err
+	n, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.FromContext(ctx).Warn().Msgf("notification (id = %s) not found", id)
+			return nil, domain.ErrNotFound
 		}
+		return nil, err
+	}
 
-		return n, nil
+	err = s.marshalAndSet(ctx, n)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to update to redis notification info: %v", id, err)
+		return nil, err
 	}
 
-	zlog.Logger.Debug().Msgf("%s: notification found: %s", id.String(), redisData)
-	err = json.Unmarshal([]byte(redisData), &n)
+	return n, nil
+}
+
+// PreviewNotification строит сообщение, которое будет отправлено для уведомления.
+func (s *NotificationService) PreviewNotification(ctx context.Context, id uuid.UUID) (*domain.RenderedMessage, error) {
+	n, err := s.GetNotificationByID(ctx, id)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msgf("%s: failed to unmarshal notification: %v", id, err)
+		return nil, err
+	}
+	if n, err = s.ResolveTemplate(ctx, n); err != nil {
+		return nil, err
+	}
+	r, ok := s.renderers[n.Channel]
+	if !ok {
+		logger.FromContext(ctx).Warn().Msgf("no renderer for channel %s", n.Channel)
+		return nil, fmt.Errorf("%w: preview for %s is not supported", domain.ErrInvalidChannel, n.Channel)
+	}
+	return r.Render(n)
+}
+
+// validateSchedule проверяет время отправки по горизонту планирования.
+func (s *NotificationService) validateSchedule(params domain.CreateNotificationParams) error {
+	now := s.clock.Now()
+	if s.maxPast > 0 && !params.Immediate && params.ScheduledAt.Before(now.Add(-s.maxPast)) {
+		return domain.ErrScheduledTooFarInPast
+	}
+	if s.maxFuture > 0 && params.ScheduledAt.After(now.Add(s.maxFuture)) {
+		return domain.ErrScheduledTooFarInFuture
+	}
+	return nil
+}
+
+// RefreshNotificationByID читает уведомление из базы в обход кеша и перезаписывает кеш.
+func (s *NotificationService) RefreshNotificationByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.FromContext(ctx).Warn().Msgf("notification (id = %s) not found", id)
+		}
+		return nil, err
+	}
+	if err := s.marshalAndSet(ctx, n); err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to refresh notification in redis: %v", id, err)
+		return nil, err
 	}
 	return n, nil
 }
 
+// GetNotificationsByIDs получает несколько уведомлений: кэш читается одним
+// запросом MGET, промахи загружаются из базы и записываются в кэш одним конвейером.
+// Не найденные идентификаторы пропускаются.
+func (s *NotificationService) GetNotificationsByIDs(ctx context.Context,
+	ids []uuid.UUID) ([]*domain.Notification, error) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = CacheKey(id)
+	}
+	cached, err := s.redis.MGet(ctx, keys)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to fetch notifications from cache")
+		return nil, err
+	}
+
+	result := make([]*domain.Notification, 0, len(ids))
+	var missed []*domain.Notification
+	for i, id := range ids {
+		if data, ok := cached[keys[i]]; ok {
+			var n *domain.Notification
+			if err := decodeCacheEntry([]byte(data), &n); err == nil && n != nil {
+				result = append(result, n)
+				continue
+			}
+			logger.FromContext(ctx).Warn().Msgf("%s: failed to decode cached notification", id)
+		}
+		n, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, n)
+		missed = append(missed, n)
+	}
+
+	if _, err := s.cacheMany(ctx, missed); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to cache notifications")
+	}
+	return result, nil
+}
+
 func (s *NotificationService) transitionStatus(
 	ctx context.Context,
 	id uuid.UUID,
-	allowedStatus domain.Status,
+	allowed []domain.Status,
 	statusUpdater domain.Status,
 	actionName string,
 ) error {
-	n, err := s.GetNotificationByID(ctx, id)
+	get := s.GetNotificationByID
+	if domain.PreconditionFromContext(ctx) != nil {
+		// условие проверяется по базе: кеш мог отстать от другого экземпляра
+		get = s.RefreshNotificationByID
+	}
+	n, err := get(ctx, id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
-			zlog.Logger.Warn().Msgf("notification (id = %s) not found", id)
+			logger.FromContext(ctx).Warn().Msgf("notification (id = %s) not found", id)
 			return err
 		}
 		return err
 	}
 
-	if n.Status != allowedStatus {
+	if err := checkPrecondition(ctx, n); err != nil {
+		return err
+	}
+	if !slices.Contains(allowed, n.Status) {
 		return fmt.Errorf("notification id=%s status=%s", id.String(), n.Status)
 	}
 
 	if err = s.UpdateNotification(ctx, n, domain.WithStatus(statusUpdater)); err != nil {
-		zlog.Logger.Error().Msgf("failed to %s notification: %v", actionName, err)
+		logger.FromContext(ctx).Error().Msgf("failed to %s notification: %v", actionName, err)
 		return err
 	}
 
 	return nil
 }
 
+// DeferNotification откладывает отправку на delay, например при ограничении частоты у провайдера.
+func (s *NotificationService) DeferNotification(ctx context.Context, n *domain.Notification,
+	delay time.Duration) error {
+	effective := s.clock.Now().Add(delay)
+	n.EffectiveScheduledAt = effective
+	if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusPending),
+		domain.WithEffectiveScheduledAt(effective)); err != nil {
+		return err
+	}
+	// очередь ожидания только что сработавшего сообщения еще может существовать с прежним x-expires,
+	// повторное объявление с другими аргументами брокер отклонит, поэтому она пересоздается
+	if err := s.republish(ctx, n, delay); err != nil {
+		// останется pending и будет подобрано восстановлением зависших уведомлений
+		logger.FromContext(ctx).Error().Msgf("%s failed to publish deferred notification: %v", n.ID, err)
+		return err
+	}
+	logger.FromContext(ctx).Info().Msgf("notification %s deferred for %s", n.ID, delay)
+	return nil
+}
+
+// Cancel отменяет уведомление, ожидающее отправки, или черновик.
 func (s *NotificationService) Cancel(ctx context.Context, id uuid.UUID) error {
-	return s.transitionStatus(ctx, id, domain.StatusPending, domain.StatusCancelled, "cancel")
+	return s.transitionStatus(ctx, id, []domain.Status{domain.StatusPending, domain.StatusDraft},
+		domain.StatusCancelled, "cancel")
+}
+
+// Confirm отменяет уведомление "отправить, если не подтверждено".
+// Статус читается из базы, чтобы не опираться на устаревший кеш.
+func (s *NotificationService) Confirm(ctx context.Context, id uuid.UUID) error {
+	n, err := s.RefreshNotificationByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !n.CancelOnConfirm {
+		return domain.ErrConfirmNotExpected
+	}
+	if n.Status == domain.StatusCancelled {
+		return nil
+	}
+	if n.Status != domain.StatusPending || !s.clock.Now().Before(n.ScheduledAt) {
+		logger.FromContext(ctx).Info().Msgf("confirmation for %s arrived too late, status=%s", id, n.Status)
+		return domain.ErrConfirmTooLate
+	}
+
+	if err = s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusCancelled)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to cancel confirmed notification: %v", err)
+		return err
+	}
+	logger.FromContext(ctx).Info().Msgf("notification %s confirmed and cancelled", id)
+	return nil
+}
+
+// Approve одобряет уведомление и публикует его в очередь. Если время отправки уже прошло,
+// уведомление отправляется сразу.
+func (s *NotificationService) Approve(ctx context.Context, id uuid.UUID,
+	approver string) (*domain.Notification, error) {
+	n, err := s.RefreshNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !n.RequiresApproval {
+		return nil, domain.ErrApprovalNotRequired
+	}
+	if n.ApprovedAt != nil {
+		return n, nil
+	}
+	if n.Status != domain.StatusAwaitingApproval {
+		return nil, domain.ErrNotAwaitingApproval
+	}
+
+	status, ttl := s.dispatchPlan(n.EffectiveScheduledAt)
+	ok, err := s.repo.Approve(ctx, id, approver, status)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to approve notification: %v", err)
+		return nil, err
+	}
+	if !ok {
+		// одобрено или отменено параллельно
+		return nil, domain.ErrNotAwaitingApproval
+	}
+	s.recordTransition(ctx, id, n.Status, status, "")
+	now := s.clock.Now()
+	n.Status, n.ApprovedBy, n.ApprovedAt, n.UpdatedAt = status, approver, &now, now
+	// updated_at выставила база, поэтому кеш не перезаписывается, а сбрасывается
+	if err := s.evictCached(ctx, id); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("notification %s approved by %s", id, approver)
+
+	if err := s.publish(ctx, n, ttl); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to publish approved notification: %v", err)
+		if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusPending)); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// checkPrecondition проверяет прочитанное уведомление условием изменения из контекста.
+// Что уведомление не изменится до записи, проверяет уже updateNotification.
+func checkPrecondition(ctx context.Context, n *domain.Notification) error {
+	if match := domain.PreconditionFromContext(ctx); match != nil && !match(n) {
+		return domain.ErrPreconditionFailed
+	}
+	return nil
+}
+
+// UpdateDraft изменяет получателя, канал, payload или время отправки черновика.
+func (s *NotificationService) UpdateDraft(ctx context.Context, id uuid.UUID,
+	params domain.DraftUpdateParams) (*domain.Notification, error) {
+	n, err := s.RefreshNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPrecondition(ctx, n); err != nil {
+		return nil, err
+	}
+	if n.Status != domain.StatusDraft {
+		return nil, domain.ErrNotDraft
+	}
+
+	channel, recipient := n.Channel, n.Recipient
+	var opts []domain.UpdateOption
+	if params.Channel != nil {
+		channel = *params.Channel
+		opts = append(opts, domain.WithChannel(channel))
+	}
+	if params.Recipient != nil {
+		recipient = *params.Recipient
+		if recipient == "" {
+			return nil, domain.ErrEmptyRecipient
+		}
+		opts = append(opts, domain.WithRecipient(recipient))
+	}
+	if params.Channel != nil || params.Recipient != nil {
+		if v, ok := s.recipients[channel]; ok {
+			if err := v.ValidateRecipient(ctx, recipient); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if params.Payload != nil {
+		n.Payload = s.channelDefaults.Apply(channel, params.Payload)
+		opts = append(opts, domain.WithPayload(n.Payload))
+	}
+	if params.Channel != nil || params.Payload != nil {
+		if err := s.checkPayload(ctx, channel, n.TemplateID, n.Payload); err != nil {
+			return nil, err
+		}
+	}
+	if params.ScheduledAt != nil {
+		n.ScheduledAt, n.EffectiveScheduledAt = *params.ScheduledAt, *params.ScheduledAt
+		opts = append(opts, domain.WithScheduledAt(*params.ScheduledAt),
+			domain.WithEffectiveScheduledAt(*params.ScheduledAt))
+	}
+	if len(opts) == 0 {
+		return n, nil
+	}
+	n.Recipient = recipient
+
+	if err := s.UpdateNotification(ctx, n, opts...); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// ScheduleDraft переводит черновик в отправку с теми же проверками времени
+// и нагрузки, что и при создании обычного уведомления.
+func (s *NotificationService) ScheduleDraft(ctx context.Context, id uuid.UUID,
+	params domain.ScheduleParams) (*domain.Notification, error) {
+	n, err := s.RefreshNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n.Status != domain.StatusDraft {
+		return nil, domain.ErrNotDraft
+	}
+	scheduledAt := n.ScheduledAt
+	if params.ScheduledAt != nil {
+		scheduledAt = *params.ScheduledAt
+	}
+	if err := s.validateSchedule(domain.CreateNotificationParams{
+		ScheduledAt: scheduledAt,
+		Immediate:   params.Immediate,
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.admit(ctx, n.EffectivePriority()); err != nil {
+		return nil, err
+	}
+
+	effective := scheduledAt
+	if params.Smooth && s.smoothWindow > 0 {
+		effective = scheduledAt.Add(time.Duration(rand.Int64N(int64(s.smoothWindow))))
+	}
+	status, ttl := s.dispatchPlan(effective)
+	if n.RequiresApproval {
+		status = domain.StatusAwaitingApproval
+	}
+	n.ScheduledAt, n.EffectiveScheduledAt = scheduledAt, effective
+	if err := s.UpdateNotification(ctx, n, domain.WithStatus(status), domain.WithScheduledAt(scheduledAt),
+		domain.WithEffectiveScheduledAt(effective)); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("draft %s scheduled, status %s", id, status)
+	if status == domain.StatusAwaitingApproval {
+		return n, nil
+	}
+
+	if err := s.publish(ctx, n, ttl); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to publish scheduled draft: %v", err)
+		if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusPending)); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// RecordReceipt переводит уведомление в delivered, read или bounced по квитанции канала.
+// Квитанции приходят не по порядку: доставка после прочтения и повтор игнорируются.
+// Событие с уже обработанным eventID возвращает ErrDuplicateEvent, не читая уведомление.
+func (s *NotificationService) RecordReceipt(ctx context.Context, id uuid.UUID,
+	status domain.Status, eventID string) (n *domain.Notification, err error) {
+	if status != domain.StatusDelivered && status != domain.StatusRead && status != domain.StatusBounced {
+		return nil, domain.ErrInvalidReceiptStatus
+	}
+	fresh, err := s.claimReceiptEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		logger.FromContext(ctx).Info().Msgf("receipt event %s for notification %s already processed", eventID, id)
+		return nil, domain.ErrDuplicateEvent
+	}
+	defer func() {
+		if err != nil {
+			s.releaseReceiptEvent(ctx, eventID)
+		}
+	}()
+
+	n, err = s.RefreshNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n.Status == status || (n.Status == domain.StatusRead && status == domain.StatusDelivered) {
+		return n, nil
+	}
+	if !n.Status.CanTransitionTo(status) {
+		logger.FromContext(ctx).Warn().Msgf("receipt %s for notification %s in status %s", status, id, n.Status)
+		return nil, domain.ErrInvalidTransition
+	}
+
+	if err := s.UpdateNotification(ctx, n, domain.WithStatus(status)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to record receipt: %v", err)
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("notification %s marked %s", id, status)
+	return n, nil
 }
 
 func (s *NotificationService) Failed(ctx context.Context, id uuid.UUID) error {
-	return s.transitionStatus(ctx, id, domain.StatusProcessing, domain.StatusFailed, "failed")
+	return s.transitionStatus(ctx, id, []domain.Status{domain.StatusProcessing}, domain.StatusFailed, "failed")
+}
+
+func (s *NotificationService) Bounced(ctx context.Context, id uuid.UUID) error {
+	return s.transitionStatus(ctx, id, []domain.Status{domain.StatusProcessing}, domain.StatusBounced, "bounce")
 }
 
 func (s *NotificationService) IncRetryCount(ctx context.Context, n *domain.Notification) error {
 	return s.UpdateNotification(ctx, n, domain.WithRetryCountInc())
 }
 
+func (s *NotificationService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.FromContext(ctx).Warn().Msgf("notification (id = %s) not found", id)
+			return err
+		}
+		logger.FromContext(ctx).Error().Msgf("failed to delete notification: %v", err)
+		return err
+	}
+	if err := s.redis.Del(ctx, CacheKey(id)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to delete notification from redis: %v", id, err)
+		return err
+	}
+	logger.FromContext(ctx).Info().Msgf("%s notification hard deleted", id)
+	return nil
+}
+
+func (s *NotificationService) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.SoftDelete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.FromContext(ctx).Warn().Msgf("notification (id = %s) not found", id)
+			return err
+		}
+		logger.FromContext(ctx).Error().Msgf("failed to soft delete notification: %v", err)
+		return err
+	}
+	if err := s.redis.Del(ctx, CacheKey(id)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to delete notification from redis: %v", id, err)
+		return err
+	}
+	logger.FromContext(ctx).Info().Msgf("%s notification soft deleted", id)
+	return nil
+}
+
+func (s *NotificationService) GetNotificationByIDWithDeleted(ctx context.Context,
+	id uuid.UUID) (*domain.Notification, error) {
+	n, err := s.repo.GetByIDWithDeleted(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.FromContext(ctx).Warn().Msgf("notification (id = %s) not found", id)
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+// WarmCache заранее загружает в кеш уведомления, которые скоро сработают,
+// чтобы чтение консьюмером в момент отправки попадало в кеш. Заполняются только промахи,
+// возвращается число загруженных.
+func (s *NotificationService) WarmCache(ctx context.Context, horizon time.Duration, limit int) (int, error) {
+	now := s.clock.Now()
+	list, err := s.repo.ListPendingScheduledBetween(ctx, now, now.Add(horizon), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to list imminent notifications: %v", err)
+		return 0, err
+	}
+	ns := make([]*domain.Notification, len(list))
+	for i := range list {
+		ns[i] = &list[i]
+	}
+	warmed, err := s.cacheMany(ctx, ns)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to warm cache: %v", err)
+		return 0, err
+	}
+	if warmed > 0 {
+		logger.FromContext(ctx).Debug().Msgf("warmed cache with %d notifications", warmed)
+	}
+	return warmed, nil
+}
+
+func (s *NotificationService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.repo.PurgeDeletedBefore(ctx, s.clock.Now().Add(-retention))
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to purge deleted notifications: %v", err)
+		return 0, err
+	}
+	if purged > 0 {
+		logger.FromContext(ctx).Info().Msgf("purged %d soft deleted notifications", purged)
+	}
+	return purged, nil
+}
+
+// ReprocessFailed возвращает в отправку неуспешные уведомления, пролежавшие в failed дольше after.
+// Уведомления, исчерпавшие maxCycles возвратов, остаются failed окончательно.
+func (s *NotificationService) ReprocessFailed(ctx context.Context, after time.Duration,
+	maxCycles, limit int) (int, error) {
+	list, err := s.repo.ListFailedBefore(ctx, s.clock.Now().Add(-after), maxCycles, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to list failed notifications: %v", err)
+		return 0, err
+	}
+
+	reprocessed := 0
+	for i := range list {
+		n := &list[i]
+		ok, err := s.repo.FailedToProcess(ctx, n.ID)
+		if err != nil {
+			logger.FromContext(ctx).Error().Msgf("%s failed to reprocess notification: %v", n.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		s.recordTransition(ctx, n.ID, n.Status, domain.StatusProcessing, "")
+		n.Status = domain.StatusProcessing
+		n.RetryCount = 0
+		n.ReprocessCount++
+		n.UpdatedAt = s.clock.Now()
+		_ = s.evictCached(ctx, n.ID)
+		if err := s.publish(ctx, n, dispatchLead); err != nil {
+			// вернется в отправку при следующем цикле
+			logger.FromContext(ctx).Error().Msgf("%s failed to publish reprocessed notification: %v", n.ID, err)
+			if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusFailed)); err != nil {
+				logger.FromContext(ctx).Error().Msgf("%s failed to restore failed status: %v", n.ID, err)
+			}
+			continue
+		}
+		logger.FromContext(ctx).Info().Msgf("notification %s reprocessed, cycle %d of %d", n.ID, n.ReprocessCount, maxCycles)
+		reprocessed++
+	}
+	return reprocessed, nil
+}
+
+// ReconcileOrphans сверяет базу с очередью: очередь уведомления живет до срока отправки (x-expires),
+// и если сообщение не дошло до консьюмера, уведомление навсегда остается pending или processing.
+// Такие pending сначала забираются условным переходом в processing (строку, которую уже взял
+// консьюмер или другой экземпляр, сверка не трогает) и публикуются заново; исходное сообщение,
+// если оно лишь опоздало, консьюмер отбросит по аренде отправки и статусу (см. ClaimSend).
+// Слишком старые pending истекают; processing без обновлений дольше 10 минут переводятся в failed.
+// Обрабатывается одна страница, курсор следующей возвращается в report.Next.
+func (s *NotificationService) ReconcileOrphans(ctx context.Context, grace, maxAge time.Duration,
+	after *domain.OrphanCursor, limit int) (domain.OrphanReport, error) {
+	var report domain.OrphanReport
+	now := s.clock.Now()
+	list, err := s.repo.ListPendingAndProcessingAfter(ctx, now.Add(-grace), after, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to list orphaned notifications: %v", err)
+		return report, err
+	}
+	report.Found = len(list)
+	if limit > 0 && len(list) == limit {
+		last := list[len(list)-1]
+		report.Next = &domain.OrphanCursor{EffectiveScheduledAt: last.EffectiveScheduledAt, ID: last.ID}
+	}
+
+	for i := range list {
+		n := &list[i]
+		switch {
+		case n.Status == domain.StatusProcessing:
+			if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusFailed)); err != nil {
+				logger.FromContext(ctx).Error().Msgf("%s failed to fail orphaned notification: %v", n.ID, err)
+				continue
+			}
+			metrics.CountBySource(n.Source, "failed")
+			report.Failed++
+		case maxAge > 0 && n.EffectiveScheduledAt.Before(now.Add(-maxAge)):
+			if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusExpired)); err != nil {
+				logger.FromContext(ctx).Error().Msgf("%s failed to expire orphaned notification: %v", n.ID, err)
+				continue
+			}
+			metrics.CountBySource(n.Source, "expired")
+			report.Expired++
+		default:
+			ok, err := s.repo.PendingToProcess(ctx, n.ID)
+			if err != nil {
+				logger.FromContext(ctx).Error().Msgf("%s failed to claim orphaned notification: %v", n.ID, err)
+				continue
+			}
+			if !ok {
+				// уведомление успели отправить, отменить или перенести
+				continue
+			}
+			s.recordTransition(ctx, n.ID, n.Status, domain.StatusProcessing, "")
+			n.Status = domain.StatusProcessing
+			n.UpdatedAt = s.clock.Now()
+			_ = s.evictCached(ctx, n.ID)
+			if err := s.republish(ctx, n, dispatchLead); err != nil {
+				// вернется в pending и будет подобрано следующей сверкой
+				logger.FromContext(ctx).Error().Msgf("%s failed to republish orphaned notification: %v", n.ID, err)
+				if err := s.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusPending)); err != nil {
+					logger.FromContext(ctx).Error().Msgf("%s failed to restore pending status: %v", n.ID, err)
+				}
+				continue
+			}
+			report.Republished++
+		}
+	}
+
+	if report.Found > 0 {
+		logger.FromContext(ctx).Debug().Msgf("orphaned notifications page: found %d, republished %d, expired %d, "+
+			"failed %d", report.Found, report.Republished, report.Expired, report.Failed)
+	}
+	return report, nil
+}
+
+// CountStaleProcessing считает уведомления, зависшие в processing дольше olderThan.
+func (s *NotificationService) CountStaleProcessing(ctx context.Context, olderThan time.Duration) (int, error) {
+	count, err := s.repo.CountProcessingBefore(ctx, s.clock.Now().Add(-olderThan))
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to count stale processing notifications: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// DuplicateReport находит вероятные повторные доставки за последние window.
+func (s *NotificationService) DuplicateReport(ctx context.Context, window time.Duration,
+	limit int) ([]domain.DuplicateGroup, error) {
+	groups, err := s.repo.ListDuplicateDeliveries(ctx, s.clock.Now().Add(-window), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to build duplicate report: %v", err)
+		return nil, err
+	}
+	return groups, nil
+}
+
+// CountOutcomes считает по каналам успешные и неуспешные отправки, завершившиеся в [from, to).
+func (s *NotificationService) CountOutcomes(ctx context.Context, from,
+	to time.Time) (map[domain.Channel]domain.OutcomeCounts, error) {
+	counts, err := s.repo.CountOutcomesByChannel(ctx, from, to)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to count outcomes: %v", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+// defaultSearchLimit число результатов поиска, если лимит не задан.
+const defaultSearchLimit = 20
+
+// SearchNotifications ищет уведомления по тексту и фильтрам в обход кеша.
+func (s *NotificationService) SearchNotifications(ctx context.Context,
+	params domain.SearchParams) ([]domain.SearchHit, error) {
+	params.Query = strings.TrimSpace(params.Query)
+	if params.IsEmpty() {
+		return nil, domain.ErrEmptySearchQuery
+	}
+	if params.Channel != "" && !params.Channel.IsValid() {
+		return nil, domain.ErrInvalidChannel
+	}
+	if params.Status != "" && !params.Status.IsValid() {
+		return nil, domain.ErrInvalidStatus
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultSearchLimit
+	}
+
+	hits, err := s.repo.Search(ctx, params)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to search notifications: %v", err)
+		return nil, err
+	}
+	return hits, nil
+}
+
+// defaultListLimit размер страницы списка уведомлений, если лимит не задан.
+const defaultListLimit = 20
+
+// ListNotifications возвращает страницу уведомлений по фильтрам в обход кеша.
+// Запрашивает на одно уведомление больше limit, чтобы узнать, есть ли следующая страница.
+func (s *NotificationService) ListNotifications(ctx context.Context, filter domain.ListFilter,
+	limit, offset int) ([]domain.Notification, bool, error) {
+	if filter.Channel != "" && !filter.Channel.IsValid() {
+		return nil, false, domain.ErrInvalidChannel
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, false, domain.ErrInvalidStatus
+	}
+	if !filter.ScheduledFrom.IsZero() && !filter.ScheduledTo.IsZero() && !filter.ScheduledFrom.Before(filter.ScheduledTo) {
+		return nil, false, domain.ErrInvalidTimeRange
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	ns, err := s.repo.List(ctx, filter, limit+1, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to list notifications: %v", err)
+		return nil, false, err
+	}
+	if len(ns) > limit {
+		return ns[:limit], true, nil
+	}
+	return ns, false, nil
+}
+
+// cacheMany записывает уведомления в кэш одним конвейером, только отсутствующие ключи: снимок
+// из базы не должен затирать более новую запись, которую успели положить отмена, перенос или
+// смена статуса. Возвращает число записанных.
+func (s *NotificationService) cacheMany(ctx context.Context, ns []*domain.Notification) (int, error) {
+	if len(ns) == 0 {
+		return 0, nil
+	}
+	values := make(map[string]interface{}, len(ns))
+	for _, n := range ns {
+		data, err := s.codec.Marshal(n)
+		if err != nil {
+			return 0, err
+		}
+		values[CacheKey(n.ID)] = data
+	}
+	return s.redis.MSetNXWithExpiration(ctx, values, s.redisExpiration)
+}
+
 func (s *NotificationService) marshalAndSet(ctx context.Context, n *domain.Notification) error {
-	data, err := json.Marshal(n)
+	data, err := s.codec.Marshal(n)
 	if err != nil {
-		zlog.Logger.Error().Msgf("%s failed to marshal notification: %v", n.ID, err)
+		logger.FromContext(ctx).Error().Msgf("%s failed to marshal notification: %v", n.ID, err)
 		return err
 	}
-	err = s.redis.SetWithExpiration(ctx, redisKeyPrefix+n.ID.String(), data, s.redisExpiration)
+	err = s.redis.SetWithExpiration(ctx, CacheKey(n.ID), data, s.redisExpiration)
 	if err != nil {
-		zlog.Logger.Error().Msgf("%s failed to set notification expiry: %v", n.ID, err)
+		logger.FromContext(ctx).Error().Msgf("%s failed to set notification expiry: %v", n.ID, err)
 		return err
 	}
 	return nil
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"DelayedNotifier/internal/domain"
+)
+
+// WithPayloadValidator регистрирует проверку payload для канала. Проверка выполняется при
+// создании и изменении черновика, а также повторно перед отправкой (ValidatePayload).
+func WithPayloadValidator(ch domain.Channel, v domain.PayloadValidator) Option {
+	return func(s *NotificationService) {
+		if s.payloads == nil {
+			s.payloads = make(map[domain.Channel]domain.PayloadValidator)
+		}
+		s.payloads[ch] = v
+	}
+}
+
+// ValidatePayload повторно проверяет сохраненный payload уведомления по текущим правилам канала.
+// Вызывается обработчиком очереди перед отправкой: уведомление могло быть создано до
+// ужесточения проверки или записано в базу в обход API.
+func (s *NotificationService) ValidatePayload(n *domain.Notification) error {
+	return s.validatePayload(n.Channel, n.Payload)
+}
+
+func (s *NotificationService) validatePayload(ch domain.Channel, payload map[string]interface{}) error {
+	v, ok := s.payloads[ch]
+	if !ok {
+		return nil
+	}
+	return v.ValidatePayload(payload)
+}
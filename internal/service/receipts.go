@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/logger"
+)
+
+// receiptEventKeyPrefix префикс ключей обработанных событий квитанций в Redis.
+const receiptEventKeyPrefix = "receipt_event:"
+
+// ReceiptEventKey возвращает ключ Redis, которым отмечено обработанное событие квитанции.
+func ReceiptEventKey(eventID string) string {
+	return receiptEventKeyPrefix + eventID
+}
+
+// WithReceiptDedup задает, сколько помнить идентификаторы событий квитанций: повторная доставка
+// вебхука в этот срок не меняет статус. 0 отключает защиту от повторов.
+func WithReceiptDedup(ttl time.Duration) Option {
+	return func(s *NotificationService) {
+		s.receiptDedupTTL = ttl
+	}
+}
+
+// claimReceiptEvent отмечает событие обработанным; false — его уже обработал этот или другой экземпляр.
+// Без идентификатора события или с отключенной защитой событие всегда новое.
+func (s *NotificationService) claimReceiptEvent(ctx context.Context, eventID string) (bool, error) {
+	if eventID == "" || s.receiptDedupTTL <= 0 {
+		return true, nil
+	}
+	ok, err := s.redis.SetNXWithExpiration(ctx, ReceiptEventKey(eventID), s.clock.Now().Format(time.RFC3339),
+		s.receiptDedupTTL)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to claim receipt event %s: %v", eventID, err)
+		return false, err
+	}
+	return ok, nil
+}
+
+// releaseReceiptEvent снимает отметку, если квитанцию не удалось применить: повтор провайдера
+// должен пройти заново, а не считаться дубликатом.
+func (s *NotificationService) releaseReceiptEvent(ctx context.Context, eventID string) {
+	if eventID == "" || s.receiptDedupTTL <= 0 {
+		return
+	}
+	if err := s.redis.Del(ctx, ReceiptEventKey(eventID)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to release receipt event %s: %v", eventID, err)
+	}
+}
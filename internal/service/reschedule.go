@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// RescheduleNotifications переносит ожидающие уведомления по фильтру одним запросом к базе
+// и публикует их заново. Новое время проверяется теми же ограничениями, что и при создании;
+// при сдвиге каждое уведомление сохраняет свое смещение сглаживания.
+func (s *NotificationService) RescheduleNotifications(ctx context.Context,
+	params domain.RescheduleParams) (domain.RescheduleResult, error) {
+	op := "RescheduleNotifications:"
+	if (params.At == nil) == (params.Shift == 0) {
+		return domain.RescheduleResult{}, domain.ErrInvalidReschedule
+	}
+	f := params.Filter
+	if f.Channel == "" && f.Recipient == "" && f.ScheduledFrom.IsZero() && f.ScheduledTo.IsZero() {
+		return domain.RescheduleResult{}, domain.ErrEmptyRescheduleFilter
+	}
+	if f.Channel != "" && !f.Channel.IsValid() {
+		return domain.RescheduleResult{}, domain.ErrInvalidChannel
+	}
+	if !f.ScheduledFrom.IsZero() && !f.ScheduledTo.IsZero() && !f.ScheduledFrom.Before(f.ScheduledTo) {
+		return domain.RescheduleResult{}, domain.ErrInvalidTimeRange
+	}
+	if params.At != nil {
+		if err := s.validateSchedule(domain.CreateNotificationParams{ScheduledAt: *params.At}); err != nil {
+			return domain.RescheduleResult{}, err
+		}
+	}
+
+	ns, err := s.repo.Reschedule(ctx, params)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("%s failed to reschedule notifications: %v", op, err)
+		return domain.RescheduleResult{}, err
+	}
+
+	res := domain.RescheduleResult{
+		Rescheduled: len(ns),
+		IDs:         make([]uuid.UUID, 0, len(ns)),
+		Truncated:   params.Limit > 0 && len(ns) == params.Limit,
+	}
+	for i := range ns {
+		n := &ns[i]
+		res.IDs = append(res.IDs, n.ID)
+		if err := s.syncCache(ctx, n); err != nil {
+			logger.FromContext(ctx).Warn().Msgf("%s %s: failed to update cache: %v", op, n.ID, err)
+		}
+		_, ttl := s.dispatchPlan(n.EffectiveScheduledAt)
+		if err := s.republish(ctx, n, ttl); err != nil {
+			// останется pending и будет подобрано сверкой потерянных уведомлений
+			logger.FromContext(ctx).Error().Msgf("%s %s: failed to republish: %v", op, n.ID, err)
+			continue
+		}
+		res.Republished++
+	}
+	logger.FromContext(ctx).Info().Int("rescheduled", res.Rescheduled).Int("republished", res.Republished).
+		Msg("notifications rescheduled")
+	return res, nil
+}
+
+// republish публикует уведомление заново, заменяя ожидающее сообщение, если публикатор это умеет.
+func (s *NotificationService) republish(ctx context.Context, n *domain.Notification, ttl time.Duration) error {
+	if r, ok := s.publisher.(domain.MessageQueuePriorityPublisher); ok {
+		return r.RepublishPriority(ctx, n.ID, n.EffectivePriority(), ttl)
+	}
+	if r, ok := s.publisher.(domain.MessageQueueRepublisher); ok {
+		return r.Republish(ctx, n.ID, ttl)
+	}
+	return s.publisher.Publish(ctx, n.ID, ttl)
+}
+
+// publish публикует уведомление с задержкой ttl в очередь его приоритета, если публикатор
+// разделяет очереди по приоритетам, иначе в общую.
+func (s *NotificationService) publish(ctx context.Context, n *domain.Notification, ttl time.Duration) error {
+	if p, ok := s.publisher.(domain.MessageQueuePriorityPublisher); ok {
+		return p.PublishPriority(ctx, n.ID, n.EffectivePriority(), ttl)
+	}
+	return s.publisher.Publish(ctx, n.ID, ttl)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// PreviewSchedule рассчитывает время отправки так же, как CreateNotification и консьюмер:
+// прошедшее время (с Immediate) означает отправку сразу, сглаживание сдвигает ее внутри окна,
+// а пауза получателя переносит на свое окончание. Ограничение нагрузки не учитывается.
+func (s *NotificationService) PreviewSchedule(ctx context.Context,
+	params domain.SchedulePreviewParams) (*domain.SchedulePreview, error) {
+	if params.Channel != "" && !params.Channel.IsValid() {
+		return nil, domain.ErrInvalidChannel
+	}
+	if err := s.validateSchedule(domain.CreateNotificationParams{
+		ScheduledAt: params.ScheduledAt,
+		Immediate:   params.Immediate,
+	}); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	preview := &domain.SchedulePreview{ScheduledAt: params.ScheduledAt}
+	if params.Smooth && s.smoothWindow > 0 {
+		preview.SmoothWindow = s.smoothWindow
+	}
+	preview.EarliestAt = latest(params.ScheduledAt, now)
+	preview.LatestAt = latest(params.ScheduledAt.Add(preview.SmoothWindow), now)
+
+	if params.Channel != "" && params.Recipient != "" {
+		until, ok, err := s.RecipientSnoozedUntil(ctx, params.Channel, params.Recipient)
+		if err != nil {
+			// консьюмер в этом случае отправляет без учета паузы
+			logger.FromContext(ctx).Warn().Err(err).Msg("failed to check recipient snooze for preview")
+		} else if ok && until.After(preview.EarliestAt) {
+			preview.SnoozedUntil = &until
+			preview.EarliestAt = until
+			preview.LatestAt = latest(preview.LatestAt, until)
+		}
+	}
+
+	if params.Location != nil {
+		preview.ScheduledAt = preview.ScheduledAt.In(params.Location)
+		preview.EarliestAt = preview.EarliestAt.In(params.Location)
+		preview.LatestAt = preview.LatestAt.In(params.Location)
+		if preview.SnoozedUntil != nil {
+			until := preview.SnoozedUntil.In(params.Location)
+			preview.SnoozedUntil = &until
+		}
+	}
+	return preview, nil
+}
+
+// latest возвращает более позднее из двух времен.
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
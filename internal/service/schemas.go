@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// maxSchemaSource наибольшая длина имени источника, как у поля source уведомления.
+const maxSchemaSource = 64
+
+// WithSchemas подключает хранилище схем payload по источникам и каналам.
+func WithSchemas(repo domain.SchemaRepository) Option {
+	return func(s *NotificationService) {
+		s.schemas = repo
+	}
+}
+
+// SaveSchema проверяет схему и регистрирует ее для пары источник-канал, заменяя прежнюю.
+// Уже созданные уведомления новой схемой не проверяются.
+func (s *NotificationService) SaveSchema(ctx context.Context, source string, ch domain.Channel,
+	schema map[string]interface{}) (*domain.PayloadSchema, error) {
+	if s.schemas == nil {
+		return nil, domain.ErrSchemasDisabled
+	}
+	source = strings.TrimSpace(source)
+	if source == "" || len(source) > maxSchemaSource {
+		return nil, domain.ErrInvalidSchemaSource
+	}
+	if !ch.IsValid() {
+		return nil, domain.ErrInvalidChannel
+	}
+	if _, err := domain.CompileSchema(schema); err != nil {
+		return nil, err
+	}
+
+	ps, err := s.schemas.SaveSchema(ctx, source, ch, schema)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to save schema %s/%s: %v", source, ch, err)
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("payload schema for %s/%s saved", source, ch)
+	return ps, nil
+}
+
+// GetSchema возвращает схему пары источник-канал.
+func (s *NotificationService) GetSchema(ctx context.Context, source string,
+	ch domain.Channel) (*domain.PayloadSchema, error) {
+	if s.schemas == nil {
+		return nil, domain.ErrSchemasDisabled
+	}
+	return s.schemas.GetSchema(ctx, source, ch)
+}
+
+// ListSchemas возвращает все зарегистрированные схемы.
+func (s *NotificationService) ListSchemas(ctx context.Context) ([]domain.PayloadSchema, error) {
+	if s.schemas == nil {
+		return nil, domain.ErrSchemasDisabled
+	}
+	return s.schemas.ListSchemas(ctx)
+}
+
+// DeleteSchema снимает схему пары: источник снова создает уведомления без проверки контракта.
+func (s *NotificationService) DeleteSchema(ctx context.Context, source string, ch domain.Channel) error {
+	if s.schemas == nil {
+		return domain.ErrSchemasDisabled
+	}
+	return s.schemas.DeleteSchema(ctx, source, ch)
+}
+
+// checkSchema проверяет payload, присланный источником, по его схеме для канала; без схемы
+// или без подключенного хранилища проверки нет. Значения канала по умолчанию не проверяются:
+// схема описывает то, что присылает источник.
+func (s *NotificationService) checkSchema(ctx context.Context, source string, ch domain.Channel,
+	payload map[string]interface{}) error {
+	if s.schemas == nil || source == "" {
+		return nil
+	}
+	ps, err := s.schemas.GetSchema(ctx, source, ch)
+	if errors.Is(err, domain.ErrSchemaNotFound) {
+		return nil
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to load schema %s/%s: %v", source, ch, err)
+		return err
+	}
+	schema, err := domain.CompileSchema(ps.Schema)
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	return schema.Validate(payload)
+}
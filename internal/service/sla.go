@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// slaPolicy цели SLA и окно, за которое оценивается их соблюдение.
+type slaPolicy struct {
+	targets    []domain.SLATarget
+	window     time.Duration
+	minSamples int
+}
+
+// WithSLA задает цели SLA, оцениваемые по уведомлениям со сроком в последние window;
+// при меньше чем minSamples уведомлениях цель не считается нарушенной.
+func WithSLA(targets []domain.SLATarget, window time.Duration, minSamples int) Option {
+	return func(s *NotificationService) {
+		s.sla = slaPolicy{targets: targets, window: window, minSamples: minSamples}
+	}
+}
+
+// SLACompliance оценивает соблюдение каждой цели SLA за окно, заканчивающееся сейчас.
+func (s *NotificationService) SLACompliance(ctx context.Context) ([]domain.SLAReport, error) {
+	now := s.clock.Now()
+	reports := make([]domain.SLAReport, 0, len(s.sla.targets))
+	for _, t := range s.sla.targets {
+		counts, err := s.repo.CountSLA(ctx, t, now.Add(-s.sla.window), now)
+		if err != nil {
+			logger.FromContext(ctx).Error().Msgf("failed to count sla %s: %v", t.Name(), err)
+			return nil, err
+		}
+		reports = append(reports, domain.SLAReport{
+			Target:    t,
+			Window:    s.sla.window,
+			SLACounts: counts,
+			Breached:  counts.Total >= s.sla.minSamples && counts.Compliance() < t.Objective,
+		})
+	}
+	return reports, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/go-redis/redis/v8"
+)
+
+// snoozeKeyPrefix префикс ключей паузы получателя в Redis.
+const snoozeKeyPrefix = "snooze:"
+
+// SnoozeKey возвращает ключ Redis паузы получателя в канале.
+func SnoozeKey(ch domain.Channel, recipient string) string {
+	return snoozeKeyPrefix + ch.String() + ":" + recipient
+}
+
+// SnoozeRecipient приостанавливает отправку получателю в канале на d. Пауза хранится в Redis
+// и истекает сама; повторный вызов заменяет время окончания.
+func (s *NotificationService) SnoozeRecipient(ctx context.Context, ch domain.Channel, recipient string,
+	d time.Duration) (time.Time, error) {
+	if !ch.IsValid() {
+		return time.Time{}, domain.ErrInvalidChannel
+	}
+	if recipient == "" {
+		return time.Time{}, domain.ErrEmptyRecipient
+	}
+	if d <= 0 {
+		return time.Time{}, domain.ErrInvalidSnoozeDuration
+	}
+	until := s.clock.Now().Add(d).UTC()
+	if err := s.redis.SetWithExpiration(ctx, SnoozeKey(ch, recipient), until.Format(time.RFC3339Nano), d); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to snooze recipient: %v", err)
+		return time.Time{}, err
+	}
+	logger.FromContext(ctx).Info().Msgf("recipient %s (%s) snoozed until %s", recipient, ch, until)
+	return until, nil
+}
+
+// UnsnoozeRecipient снимает паузу получателя.
+func (s *NotificationService) UnsnoozeRecipient(ctx context.Context, ch domain.Channel, recipient string) error {
+	if err := s.redis.Del(ctx, SnoozeKey(ch, recipient)); err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to unsnooze recipient: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RecipientSnoozedUntil возвращает окончание паузы получателя; false, если паузы нет.
+func (s *NotificationService) RecipientSnoozedUntil(ctx context.Context, ch domain.Channel,
+	recipient string) (time.Time, bool, error) {
+	val, err := s.redis.Get(ctx, SnoozeKey(ch, recipient))
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	until, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil || !until.After(s.clock.Now()) {
+		return time.Time{}, false, err
+	}
+	return until, true, nil
+}
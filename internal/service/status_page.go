@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// Токен страницы статуса: base64url(id | срок в секундах Unix | усеченная HMAC-SHA256).
+const (
+	statusTokenPayload = 16 + 8
+	statusTokenSigSize = 16
+)
+
+// statusTokenContext отделяет подписи токенов страницы статуса от других подписей тем же ключом.
+const statusTokenContext = "status-page:"
+
+// WithStatusLinks включает подписанные ссылки на публичную страницу статуса: secret — ключ
+// подписи, общий для всех экземпляров, maxTTL — срок ссылки по умолчанию и наибольший допустимый.
+// Пустой secret оставляет ссылки выключенными.
+func WithStatusLinks(secret []byte, maxTTL time.Duration) Option {
+	return func(s *NotificationService) {
+		s.statusSecret = secret
+		s.statusMaxTTL = maxTTL
+	}
+}
+
+// StatusLink выдает токен страницы статуса для существующего уведомления.
+func (s *NotificationService) StatusLink(ctx context.Context, id uuid.UUID,
+	ttl time.Duration) (*domain.StatusLink, error) {
+	if len(s.statusSecret) == 0 {
+		return nil, domain.ErrStatusLinksDisabled
+	}
+	if ttl == 0 {
+		ttl = s.statusMaxTTL
+	}
+	if ttl <= 0 || (s.statusMaxTTL > 0 && ttl > s.statusMaxTTL) {
+		return nil, domain.ErrInvalidStatusLinkTTL
+	}
+	if _, err := s.GetNotificationByID(ctx, id); err != nil {
+		return nil, err
+	}
+	expiresAt := s.clock.Now().Add(ttl).Truncate(time.Second)
+	payload := make([]byte, statusTokenPayload, statusTokenPayload+statusTokenSigSize)
+	copy(payload, id[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	token := append(payload, s.signStatusToken(payload)...)
+	logger.FromContext(ctx).Debug().Msgf("%s: status link issued, expires %s", id, expiresAt)
+	return &domain.StatusLink{
+		Token:     base64.RawURLEncoding.EncodeToString(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PublicStatus проверяет токен и возвращает состояние уведомления без получателя и содержимого.
+// Удаленное уведомление дает ErrNotFound.
+func (s *NotificationService) PublicStatus(ctx context.Context, token string) (*domain.PublicStatus, error) {
+	if len(s.statusSecret) == 0 {
+		return nil, domain.ErrStatusLinksDisabled
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != statusTokenPayload+statusTokenSigSize {
+		return nil, domain.ErrInvalidStatusToken
+	}
+	payload, sig := raw[:statusTokenPayload], raw[statusTokenPayload:]
+	if !hmac.Equal(sig, s.signStatusToken(payload)) {
+		return nil, domain.ErrInvalidStatusToken
+	}
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	if !s.clock.Now().Before(expiresAt) {
+		return nil, domain.ErrStatusTokenExpired
+	}
+	id, _ := uuid.FromBytes(payload[:16])
+
+	n, err := s.GetNotificationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PublicStatus{
+		State:       n.Status.PublicState(),
+		Channel:     n.Channel,
+		ScheduledAt: n.ScheduledAt,
+		UpdatedAt:   n.UpdatedAt,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (s *NotificationService) signStatusToken(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.statusSecret)
+	mac.Write([]byte(statusTokenContext))
+	mac.Write(payload)
+	return mac.Sum(nil)[:statusTokenSigSize]
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"github.com/google/uuid"
+)
+
+// maxTemplateName наибольшая длина имени шаблона.
+const maxTemplateName = 128
+
+// WithTemplates подключает хранилище шаблонов subject и body.
+func WithTemplates(repo domain.TemplateRepository) Option {
+	return func(s *NotificationService) {
+		s.templates = repo
+	}
+}
+
+// CreateTemplate проверяет синтаксис шаблона и сохраняет его.
+func (s *NotificationService) CreateTemplate(ctx context.Context,
+	params domain.TemplateParams) (*domain.Template, error) {
+	if s.templates == nil {
+		return nil, domain.ErrTemplatesDisabled
+	}
+	params, err := validateTemplate(params)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.templates.CreateTemplate(ctx, params)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to create template %s: %v", params.Name, err)
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("template %s (%s) created", t.ID, t.Name)
+	return t, nil
+}
+
+// GetTemplate возвращает шаблон по id.
+func (s *NotificationService) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error) {
+	if s.templates == nil {
+		return nil, domain.ErrTemplatesDisabled
+	}
+	return s.templates.GetTemplate(ctx, id)
+}
+
+// ListTemplates возвращает шаблоны в порядке имен.
+func (s *NotificationService) ListTemplates(ctx context.Context) ([]domain.Template, error) {
+	if s.templates == nil {
+		return nil, domain.ErrTemplatesDisabled
+	}
+	return s.templates.ListTemplates(ctx)
+}
+
+// UpdateTemplate заменяет шаблон. Уже запланированные уведомления получат новый текст при отправке.
+func (s *NotificationService) UpdateTemplate(ctx context.Context, id uuid.UUID,
+	params domain.TemplateParams) (*domain.Template, error) {
+	if s.templates == nil {
+		return nil, domain.ErrTemplatesDisabled
+	}
+	params, err := validateTemplate(params)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.templates.UpdateTemplate(ctx, id, params)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to update template %s: %v", id, err)
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Msgf("template %s (%s) updated", t.ID, t.Name)
+	return t, nil
+}
+
+// DeleteTemplate удаляет шаблон, если его не ждут неотправленные уведомления.
+func (s *NotificationService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	if s.templates == nil {
+		return domain.ErrTemplatesDisabled
+	}
+	return s.templates.DeleteTemplate(ctx, id)
+}
+
+// ResolveTemplate подставляет переменные из payload в шаблон уведомления и возвращает копию
+// уведомления с готовыми subject и body. Уведомление без шаблона возвращается как есть.
+// Вызывается при отправке и предпросмотре, поэтому всегда используется текущая версия шаблона.
+func (s *NotificationService) ResolveTemplate(ctx context.Context, n *domain.Notification) (*domain.Notification,
+	error) {
+	if n.TemplateID == nil {
+		return n, nil
+	}
+	payload, err := s.renderTemplate(ctx, *n.TemplateID, n.Payload)
+	if err != nil {
+		return nil, err
+	}
+	out := *n
+	out.Payload = payload
+	return &out, nil
+}
+
+// checkPayload проверяет payload по правилам канала; для уведомления по шаблону — результат
+// подстановки, чтобы ошибки шаблона и переменных обнаруживались при создании, а не при отправке.
+func (s *NotificationService) checkPayload(ctx context.Context, ch domain.Channel, templateID *uuid.UUID,
+	payload map[string]interface{}) error {
+	if templateID != nil {
+		rendered, err := s.renderTemplate(ctx, *templateID, payload)
+		if err != nil {
+			return err
+		}
+		payload = rendered
+	}
+	return s.validatePayload(ch, payload)
+}
+
+func (s *NotificationService) renderTemplate(ctx context.Context, id uuid.UUID,
+	vars map[string]interface{}) (map[string]interface{}, error) {
+	if s.templates == nil {
+		return nil, domain.ErrTemplatesDisabled
+	}
+	t, err := s.templates.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return t.Render(vars)
+}
+
+func validateTemplate(params domain.TemplateParams) (domain.TemplateParams, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || len(params.Name) > maxTemplateName {
+		return params, fmt.Errorf("%w: name must be 1-%d characters", domain.ErrInvalidTemplate, maxTemplateName)
+	}
+	if strings.TrimSpace(params.Body) == "" {
+		return params, fmt.Errorf("%w: body is empty", domain.ErrInvalidTemplate)
+	}
+	t := domain.Template{Subject: params.Subject, Body: params.Body}
+	if _, _, err := t.Parse(); err != nil {
+		return params, err
+	}
+	return params, nil
+}
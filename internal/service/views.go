@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// viewNamePattern допустимое имя представления: оно входит в URL быстрой ссылки.
+var viewNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// WithSavedViews подключает хранилище сохраненных представлений.
+func WithSavedViews(repo domain.SavedViewRepository) Option {
+	return func(s *NotificationService) {
+		s.views = repo
+	}
+}
+
+// SaveView проверяет фильтры так же, как поиск, и сохраняет их под именем name.
+func (s *NotificationService) SaveView(ctx context.Context, name string,
+	filter domain.ViewFilter) (*domain.SavedView, error) {
+	if s.views == nil {
+		return nil, domain.ErrSavedViewsDisabled
+	}
+	if !viewNamePattern.MatchString(name) {
+		return nil, domain.ErrInvalidViewName
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Params(s.clock.Now()).IsEmpty() {
+		return nil, domain.ErrEmptySearchQuery
+	}
+	if filter.Channel != "" && !filter.Channel.IsValid() {
+		return nil, domain.ErrInvalidChannel
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, domain.ErrInvalidStatus
+	}
+
+	v, err := s.views.SaveView(ctx, name, filter)
+	if err != nil {
+		logger.FromContext(ctx).Error().Msgf("failed to save view %s: %v", name, err)
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListViews возвращает сохраненные представления в порядке имен.
+func (s *NotificationService) ListViews(ctx context.Context) ([]domain.SavedView, error) {
+	if s.views == nil {
+		return nil, domain.ErrSavedViewsDisabled
+	}
+	return s.views.ListViews(ctx)
+}
+
+// DeleteView удаляет сохраненное представление.
+func (s *NotificationService) DeleteView(ctx context.Context, name string) error {
+	if s.views == nil {
+		return domain.ErrSavedViewsDisabled
+	}
+	return s.views.DeleteView(ctx, name)
+}
+
+// RunView выполняет поиск по фильтрам представления; last отсчитывается от момента вызова.
+func (s *NotificationService) RunView(ctx context.Context, name string) ([]domain.SearchHit, error) {
+	if s.views == nil {
+		return nil, domain.ErrSavedViewsDisabled
+	}
+	v, err := s.views.GetView(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.SearchNotifications(ctx, v.Filter.Params(s.clock.Now()))
+}
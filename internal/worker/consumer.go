@@ -2,38 +2,126 @@ package worker
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"time"
 
 	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
+	"DelayedNotifier/internal/repository/rabbit"
 	"DelayedNotifier/pkg/rabbitmq"
 	"DelayedNotifier/pkg/retry"
-	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
-	"github.com/wb-go/wbf/zlog"
 )
 
+// defaultThrottleDelay задержка отправки при ограничении частоты у провайдера по умолчанию.
+const defaultThrottleDelay = time.Minute
+
+// rescheduledTolerance насколько раньше effective_scheduled_at может прийти сообщение ожидающего
+// уведомления; более раннее осталось от времени до переноса и пропускается.
+const rescheduledTolerance = 30 * time.Second
+
 type Consumer struct {
 	service       domain.NotificationService
 	rabbitClient  *rabbitmq.RabbitClient
 	emailSender   domain.EmailSender
+	tgSender      domain.TelegramSender
+	smsSender     domain.SMSSender
 	retryStrategy retry.Strategy
+	preSend       domain.PreSendChecker
+	throttleDelay time.Duration
+	throttle      *channelThrottle
+	limiter       *RateLimiter
+	adaptive      *rabbitmq.AdaptivePrefetch
+	clock         domain.Clock
+}
+
+// ConsumerOption функция настройки Consumer.
+type ConsumerOption func(*Consumer)
+
+// WithPreSendChecker включает проверку pre_send_check перед отправкой.
+func WithPreSendChecker(checker domain.PreSendChecker) ConsumerOption {
+	return func(c *Consumer) {
+		c.preSend = checker
+	}
+}
+
+// WithTelegramSender включает отправку в канал telegram; без него такие уведомления завершаются failed.
+func WithTelegramSender(sender domain.TelegramSender) ConsumerOption {
+	return func(c *Consumer) {
+		c.tgSender = sender
+	}
+}
+
+// WithSMSSender включает отправку в канал sms; без него такие уведомления завершаются failed.
+func WithSMSSender(sender domain.SMSSender) ConsumerOption {
+	return func(c *Consumer) {
+		c.smsSender = sender
+	}
+}
+
+// WithThrottleDelay задает, на сколько откладывать отправку, когда провайдер ограничивает частоту
+// и не назвал задержку сам (Retry-After).
+func WithThrottleDelay(d time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		c.throttleDelay = d
+	}
+}
+
+// WithRateLimiter включает ограничение частоты отправки по каналам.
+func WithRateLimiter(l *RateLimiter) ConsumerOption {
+	return func(c *Consumer) {
+		c.limiter = l
+	}
+}
+
+// WithAdaptivePrefetch включает автоподбор prefetch по задержке и ошибкам обработки.
+func WithAdaptivePrefetch(cfg rabbitmq.AdaptivePrefetch) ConsumerOption {
+	return func(c *Consumer) {
+		c.adaptive = &cfg
+	}
+}
+
+// WithConsumerClock задает источник времени, по умолчанию системное время; должен совпадать
+// с часами сервиса, по которым рассчитывается effective_scheduled_at.
+func WithConsumerClock(clock domain.Clock) ConsumerOption {
+	return func(c *Consumer) {
+		c.clock = clock
+	}
 }
 
 func NewConsumer(service domain.NotificationService, client *rabbitmq.RabbitClient,
-	emailSender domain.EmailSender, strategy retry.Strategy) (*Consumer, error) {
-	return &Consumer{
+	emailSender domain.EmailSender, strategy retry.Strategy, opts ...ConsumerOption) (*Consumer, error) {
+	c := &Consumer{
 		service:       service,
 		rabbitClient:  client,
 		emailSender:   emailSender,
 		retryStrategy: strategy,
-	}, nil
+		throttleDelay: defaultThrottleDelay,
+		throttle:      newChannelThrottle(),
+		clock:         domain.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Start обрабатывает основную очередь names.Queue и блокируется до отмены ctx.
+func (c *Consumer) Start(ctx context.Context, names rabbit.Names, workerNum int, PrefetchCount int) {
+	c.consume(ctx, names, names.Queue, workerNum, PrefetchCount)
+}
+
+// StartHigh обрабатывает очередь приоритета high names.HighQueue своими воркерами,
+// чтобы срочные уведомления не ждали за массовыми рассылками; блокируется до отмены ctx.
+func (c *Consumer) StartHigh(ctx context.Context, names rabbit.Names, workerNum int, PrefetchCount int) {
+	c.consume(ctx, names, names.HighQueue, workerNum, PrefetchCount)
 }
 
-func (c *Consumer) Start(ctx context.Context, queueName string, workerNum int, PrefetchCount int) {
+func (c *Consumer) consume(ctx context.Context, names rabbit.Names, queue string, workerNum int, PrefetchCount int) {
 	queueArgs := amqp091.Table{
-		"x-dead-letter-exchange":    "dlx",              // exchange для DLQ
-		"x-dead-letter-routing-key": queueName + ".dlq", // routing key для DLQ
+		"x-dead-letter-exchange":    names.DeadLetterExchange, // exchange для DLQ
+		"x-dead-letter-routing-key": names.DeadLetterQueue,    // routing key для DLQ
 	}
 	if workerNum <= 0 {
 		workerNum = 1
@@ -42,10 +130,11 @@ func (c *Consumer) Start(ctx context.Context, queueName string, workerNum int, P
 		PrefetchCount = 1
 	}
 	consumer := rabbitmq.NewConsumer(c.rabbitClient, rabbitmq.ConsumerConfig{
-		Queue:         queueName,
+		Queue:         queue,
 		Args:          queueArgs,
 		Workers:       workerNum,
 		PrefetchCount: PrefetchCount,
+		Adaptive:      c.adaptive,
 	}, c.consumerHandler)
 
 	err := consumer.Start(ctx)
@@ -55,6 +144,9 @@ func (c *Consumer) Start(ctx context.Context, queueName string, workerNum int, P
 }
 
 func (c *Consumer) consumerHandler(ctx context.Context, msg amqp091.Delivery) error {
+	metrics.InFlightDeliveries.Add(1)
+	defer metrics.InFlightDeliveries.Add(-1)
+
 	err := c.sender(ctx, msg.Body)
 	if err != nil {
 		return err
@@ -63,66 +155,221 @@ func (c *Consumer) consumerHandler(ctx context.Context, msg amqp091.Delivery) er
 }
 
 func (c *Consumer) sender(ctx context.Context, body []byte) error {
-	zlog.Logger.Debug().Str("body", string(body)).Msg("start send")
-	j := domain.Job{}
-	if err := json.Unmarshal(body, &j); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to unmarshal body")
-		return err
-	}
-
-	id, err := uuid.Parse(j.NotificationID)
+	logger.FromContext(ctx).Debug().Str("body", string(body)).Msg("start send")
+	j, id, err := domain.ParseJob(body)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to parse notification id")
+		// NACK без повтора: сообщение уходит в DLQ, где его можно разобрать
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to parse job")
 		return err
 	}
+	if j.SchemaVersion < domain.JobSchemaVersion {
+		logger.FromContext(ctx).Debug().Int("schema_version", j.SchemaVersion).Msg("legacy job format")
+	}
+	ctx = logger.WithNotificationID(ctx, id.String())
+	ctx = domain.WithActor(ctx, domain.ActorWorker)
 
 	n, err := c.service.GetNotificationByID(ctx, id)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to get notification")
+		if errors.Is(err, domain.ErrNotFound) {
+			// уведомление удалено, отправлять нечего
+			logger.FromContext(ctx).Warn().Str("id", id.String()).Msg("notification not found, skip")
+			return nil
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to get notification")
+		return err
 	}
 
 	if n.Status == domain.StatusCancelled {
-		zlog.Logger.Debug().Msg("notification already cancelled")
+		logger.FromContext(ctx).Debug().Msg("notification already cancelled")
+		return err
+	}
+	if n.Status != domain.StatusPending && n.Status != domain.StatusProcessing {
+		// уведомление уже не ждет отправки: завершено, неуспешно (повторную обработку публикует
+		// ReprocessFailed) или снято с отправки; повторное сообщение второй раз не отправляет
+		logger.FromContext(ctx).Debug().Str("status", n.Status.String()).Msg("notification is not awaiting send, skip")
+		return nil
+	}
+	if n.Status == domain.StatusPending && n.EffectiveScheduledAt.Sub(c.clock.Now()) > rescheduledTolerance {
+		// сообщение опубликовано до переноса на более позднее время, отправит новое
+		logger.FromContext(ctx).Debug().Time("effective_scheduled_at", n.EffectiveScheduledAt).
+			Msg("notification was rescheduled, skip early message")
+		return nil
+	}
+
+	release, ok, err := c.service.ClaimSend(ctx, n)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		// уведомление отправляет другой обработчик или его успели забрать из pending
+		logger.FromContext(ctx).Debug().Msg("notification is claimed by another message, skip")
+		return nil
+	}
+	defer release()
+
+	if c.service.RetriesExhausted(n) {
+		// попытки исчерпаны раньше (например, сообщение доставлено повторно), не отправляем
+		metrics.CountBySource(n.Source, "failed")
+		return c.service.FailExhausted(ctx, n, domain.ErrMaxRetriesExceeded)
+	}
+
+	if until, ok, err := c.service.RecipientSnoozedUntil(ctx, n.Channel, n.Recipient); err != nil {
+		logger.FromContext(ctx).Warn().Err(err).Msg("failed to check recipient snooze, sending")
+	} else if ok {
+		logger.FromContext(ctx).Info().Time("until", until).Msg("recipient is snoozed, deferring")
+		return c.service.DeferNotification(ctx, n, until.Sub(c.clock.Now()))
+	}
+
+	if c.preSend != nil {
+		send, err := c.preSend.ShouldSend(ctx, n)
+		if err != nil {
+			logger.FromContext(ctx).Warn().Err(err).Bool("send", send).Msg("pre-send check failed")
+		}
+		if !send {
+			logger.FromContext(ctx).Info().Msg("pre-send check declined, notification suppressed")
+			metrics.CountBySource(n.Source, "suppressed")
+			return c.service.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusSuppressed))
+		}
+	}
+
+	// msg — уведомление с текстом из шаблона; статусы и попытки по-прежнему пишутся в n
+	msg, err := c.service.ResolveTemplate(ctx, n)
+	if err != nil {
+		if !isTemplateError(err) {
+			logger.FromContext(ctx).Error().Err(err).Msg("failed to load template")
+			return err
+		}
+		// шаблон удален или не подходит к переменным уведомления: повторы не помогут
+		logger.FromContext(ctx).Warn().Err(err).Msg("failed to render template, notification failed")
+		metrics.CountBySource(n.Source, "failed")
+		return c.service.FailExhausted(ctx, n, err)
+	}
+
+	if err := c.service.ValidatePayload(msg); err != nil {
+		// payload мог быть допустим при создании, но не проходит текущие правила канала:
+		// повторная отправка не поможет
+		logger.FromContext(ctx).Warn().Err(err).Msg("stored payload is invalid, notification failed")
+		metrics.CountBySource(n.Source, "failed")
+		return c.service.FailExhausted(ctx, n, err)
+	}
+
+	if wait := c.throttle.remaining(n.Channel); wait > 0 {
+		logger.FromContext(ctx).Debug().Dur("delay", wait).Msg("channel is throttled, deferring")
+		return c.service.DeferNotification(ctx, n, wait)
+	}
 
+	var send func(context.Context, *domain.Notification) error
 	switch n.Channel {
 	case domain.ChannelEmail:
-		zlog.Logger.Debug().Msgf(`sending email: id:%s recipient:%s channel:%s payload:%v`,
-			n.ID, n.Recipient, n.Channel, n.Payload)
-		sendEmail := func() error {
-			err := c.emailSender.Send(ctx, n)
-			if err != nil {
-				zlog.Logger.Debug().Err(err).Msg("failed to send email")
-				errInc := c.service.IncRetryCount(ctx, n)
-				if errInc != nil {
-					return errInc
-				}
+		if c.emailSender == nil {
+			logger.FromContext(ctx).Error().Msg("email sender is not configured")
+			metrics.CountBySource(n.Source, "failed")
+			return c.service.Failed(ctx, n.ID)
+		}
+		send = c.emailSender.Send
+	case domain.ChannelTelegram:
+		if c.tgSender == nil {
+			logger.FromContext(ctx).Error().Msg("telegram sender is not configured")
+			metrics.CountBySource(n.Source, "failed")
+			return c.service.Failed(ctx, n.ID)
+		}
+		send = c.tgSender.Send
+	case domain.ChannelSMS:
+		if c.smsSender == nil {
+			logger.FromContext(ctx).Error().Msg("sms sender is not configured")
+			metrics.CountBySource(n.Source, "failed")
+			return c.service.Failed(ctx, n.ID)
+		}
+		send = c.smsSender.Send
+	default:
+		logger.FromContext(ctx).Debug().Msg("unknown channel")
+		return errors.New("unknown channel " + n.Channel.String())
+	}
+
+	// лимит канала: короткое ожидание держит сообщение, длинное откладывает уведомление
+	wait, ok := c.limiter.Reserve(n.Channel, maxRateWait)
+	if !ok {
+		logger.FromContext(ctx).Debug().Dur("delay", wait).Msg("channel rate limit reached, deferring")
+		return c.service.DeferNotification(ctx, n, wait)
+	}
+	if wait > 0 {
+		logger.FromContext(ctx).Debug().Dur("delay", wait).Msg("waiting for channel rate limit")
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	logger.FromContext(ctx).Debug().Msgf(`sending %s: id:%s recipient:%s payload:%v`,
+		n.Channel, n.ID, n.Recipient, msg.Payload)
+	sendOnce := func() error {
+		err := send(ctx, msg)
+		if err != nil {
+			logger.FromContext(ctx).Debug().Err(err).Str("channel", n.Channel.String()).Msg("failed to send")
+			if domain.ClassifySendError(err) == domain.ErrorThrottled {
+				// ограничение частоты не расходует попытки
+				return retry.Stop(err)
+			}
+			errInc := c.service.IncRetryCount(ctx, n)
+			if errInc != nil {
+				return errInc
+			}
+			if domain.ClassifySendError(err) == domain.ErrorPermanent || c.service.RetriesExhausted(n) {
+				return retry.Stop(err)
+			}
+			return err
+		}
+		return nil
+	}
+	err = retry.Do(sendOnce, c.retryStrategy)
+	if err != nil && domain.ClassifySendError(err) == domain.ErrorThrottled {
+		delay := c.throttleDelay
+		if d, ok := domain.RetryAfter(err); ok {
+			delay = d
+		}
+		c.throttle.pause(n.Channel, delay)
+		logger.FromContext(ctx).Warn().Err(err).Str("channel", n.Channel.String()).Dur("delay", delay).
+			Msg("provider throttled, deferring")
+		return c.service.DeferNotification(ctx, n, delay)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("channel", n.Channel.String()).Str("source", n.Source).
+			Str("class", domain.ClassifySendError(err).String()).Msg("failed to send with retry")
+		ctx := domain.WithTransitionError(ctx, err)
+		if domain.ClassifySendError(err) == domain.ErrorPermanent {
+			// провайдер отверг получателя, повторная обработка не поможет
+			metrics.CountBySource(n.Source, "bounced")
+			if err := c.service.Bounced(ctx, n.ID); err != nil {
+				logger.FromContext(ctx).Error().Err(err).Msg("set status bounced")
 				return err
 			}
 			return nil
 		}
-		err := retry.Do(sendEmail, c.retryStrategy)
+		metrics.CountBySource(n.Source, "failed")
+		if c.service.RetriesExhausted(n) {
+			return c.service.FailExhausted(ctx, n, err)
+		}
+		err := c.service.Failed(ctx, n.ID)
 		if err != nil {
-			zlog.Logger.Error().Err(err).Msg("failed to send email with retry")
-			err := c.service.Failed(ctx, n.ID)
-			if err != nil {
-				zlog.Logger.Error().Err(err).Msg("set status failed")
-			}
-			return err
+			logger.FromContext(ctx).Error().Err(err).Msg("set status failed")
 		}
-
-	case domain.ChannelTelegram:
-		zlog.Logger.Debug().Msgf("sending telegram: id:%s recipient:%s, channel:%s, payload:%v",
-			n.ID, n.Recipient, n.Channel, n.Payload)
-		// if err set failed status
-	default:
-		zlog.Logger.Debug().Msg("unknown channel")
-		return errors.New("unknown channel " + n.Channel.String())
+		return err
 	}
+
 	err = c.service.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusSent))
 	if err != nil {
 		return err
 	}
+	metrics.CountBySource(n.Source, "sent")
 	return nil
 }
+
+// isTemplateError сообщает, что шаблон уведомления нельзя применить: удален, не разбирается
+// или не подходит к переменным. Ошибки чтения из базы сюда не относятся.
+func isTemplateError(err error) bool {
+	return errors.Is(err, domain.ErrTemplateNotFound) || errors.Is(err, domain.ErrInvalidTemplate) ||
+		errors.Is(err, domain.ErrTemplateRender) || errors.Is(err, domain.ErrTemplatesDisabled)
+}
@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
+)
+
+// FailureRatePolicy когда доля неуспешных отправок канала считается всплеском.
+type FailureRatePolicy struct {
+	// Window текущее окно, по которому считается доля
+	Window time.Duration
+	// Baseline длина предшествующего окну периода, с которым оно сравнивается
+	Baseline time.Duration
+	// Factor во сколько раз текущая доля должна превысить базовую
+	Factor float64
+	// MinRate доля ниже этой не считается всплеском, даже если базовая нулевая
+	MinRate float64
+	// MinSamples сколько отправок нужно в окне, чтобы судить о доле
+	MinSamples int
+}
+
+// FailureRateDetector периодически сравнивает долю неуспешных отправок каждого канала за последнее
+// окно с долей за предшествующий период, публикует обе в метрике failure_rate и при всплеске
+// отправляет оповещение через сам сервис, чтобы сбой провайдера был замечен за минуты.
+type FailureRateDetector struct {
+	service  domain.NotificationService
+	interval time.Duration
+	policy   FailureRatePolicy
+	alert    StaleAlert
+
+	lastAlert map[domain.Channel]time.Time
+}
+
+func NewFailureRateDetector(service domain.NotificationService, interval time.Duration, policy FailureRatePolicy,
+	alert StaleAlert) *FailureRateDetector {
+	return &FailureRateDetector{
+		service:   service,
+		interval:  interval,
+		policy:    policy,
+		alert:     alert,
+		lastAlert: make(map[domain.Channel]time.Time),
+	}
+}
+
+func (d *FailureRateDetector) Start(ctx context.Context) {
+	if d.interval <= 0 || d.policy.Window <= 0 || d.policy.Baseline <= 0 {
+		logger.FromContext(ctx).Info().Msg("failure rate detector disabled")
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Check(ctx, time.Now())
+		}
+	}
+}
+
+// Check сравнивает окно, заканчивающееся в now, с базовым периодом и возвращает каналы со всплеском.
+func (d *FailureRateDetector) Check(ctx context.Context, now time.Time) []domain.Channel {
+	windowStart := now.Add(-d.policy.Window)
+	current, err := d.service.CountOutcomes(ctx, windowStart, now)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failure rate check failed")
+		return nil
+	}
+	baseline, err := d.service.CountOutcomes(ctx, windowStart.Add(-d.policy.Baseline), windowStart)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failure rate baseline check failed")
+		return nil
+	}
+
+	var spiking []domain.Channel
+	for _, ch := range []domain.Channel{domain.ChannelEmail, domain.ChannelTelegram, domain.ChannelSMS} {
+		cur, base := current[ch], baseline[ch]
+		metrics.SetFailureRate(ch.String(), cur.FailureRate(), base.FailureRate())
+		if !d.isSpike(cur, base) {
+			continue
+		}
+		spiking = append(spiking, ch)
+		metrics.FailureSpikes.Add(ch.String(), 1)
+		logger.FromContext(ctx).Warn().Msgf("%s failure rate %.1f%% (%d of %d) over %s, baseline %.1f%%",
+			ch, 100*cur.FailureRate(), cur.Failed, cur.Total(), d.policy.Window, 100*base.FailureRate())
+		d.notify(ctx, ch, cur, base, now)
+	}
+	return spiking
+}
+
+func (d *FailureRateDetector) isSpike(cur, base domain.OutcomeCounts) bool {
+	if cur.Total() < d.policy.MinSamples || cur.Total() == 0 {
+		return false
+	}
+	rate := cur.FailureRate()
+	return rate >= d.policy.MinRate && rate >= d.policy.Factor*base.FailureRate()
+}
+
+func (d *FailureRateDetector) notify(ctx context.Context, ch domain.Channel, cur, base domain.OutcomeCounts,
+	now time.Time) {
+	if d.alert.Recipient == "" || now.Sub(d.lastAlert[ch]) < d.alert.Cooldown {
+		return
+	}
+	_, err := d.service.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient: d.alert.Recipient,
+		Channel:   d.alert.Channel,
+		Payload: map[string]interface{}{
+			"subject": fmt.Sprintf("DelayedNotifier: всплеск ошибок канала %s", ch),
+			"body": fmt.Sprintf("За последние %s неуспешны %d из %d отправок (%.1f%%), обычно %.1f%%. "+
+				"Проверьте провайдера канала.", d.policy.Window, cur.Failed, cur.Total(),
+				100*cur.FailureRate(), 100*base.FailureRate()),
+		},
+		ScheduledAt: now,
+		Immediate:   true,
+		Source:      StaleAlertSource,
+		Priority:    domain.PriorityHigh,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to send failure rate alert")
+		return
+	}
+	d.lastAlert[ch] = now
+}
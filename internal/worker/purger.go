@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// Purger периодически физически удаляет мягко удаленные уведомления,
+// у которых истек срок хранения.
+type Purger struct {
+	service   domain.NotificationService
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewPurger(service domain.NotificationService, interval, retention time.Duration) *Purger {
+	return &Purger{
+		service:   service,
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+func (p *Purger) Start(ctx context.Context) {
+	if p.interval <= 0 {
+		logger.FromContext(ctx).Info().Msg("purger disabled")
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.service.PurgeDeleted(ctx, p.retention); err != nil {
+				logger.FromContext(ctx).Error().Err(err).Msg("purge deleted notifications failed")
+			}
+		}
+	}
+}
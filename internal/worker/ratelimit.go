@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// maxRateWait сколько отправка может ждать токен, удерживая сообщение; при большем ожидании
+// уведомление откладывается, как при ограничении частоты у провайдера.
+const maxRateWait = 30 * time.Second
+
+// RateLimiter ограничивает частоту отправки по каналам алгоритмом token bucket, чтобы отправки
+// шли равномерно, а не пачкой, после которой провайдер блокирует отправителя. Ограничение
+// действует в пределах одного процесса.
+type RateLimiter struct {
+	mu      sync.Mutex
+	clock   domain.Clock
+	buckets map[domain.Channel]*tokenBucket
+}
+
+// tokenBucket корзина канала: tokens уходит в минус на зарезервированные, но еще не наступившие отправки.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter создает ограничитель с лимитами отправок в секунду по каналам; канал без лимита
+// или с лимитом 0 не ограничивается. Запас равен секунде отправок, но не меньше одной.
+func NewRateLimiter(limits map[domain.Channel]float64, clock domain.Clock) *RateLimiter {
+	if clock == nil {
+		clock = domain.SystemClock{}
+	}
+	l := &RateLimiter{clock: clock, buckets: make(map[domain.Channel]*tokenBucket)}
+	now := clock.Now()
+	for ch, rate := range limits {
+		if rate <= 0 {
+			continue
+		}
+		burst := math.Max(1, math.Floor(rate))
+		l.buckets[ch] = &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+	}
+	return l
+}
+
+// Reserve берет токен канала и возвращает, сколько ждать до отправки. Если ждать дольше maxWait,
+// токен не берется: ok = false, wait — когда токен освободится.
+func (l *RateLimiter) Reserve(ch domain.Channel, maxWait time.Duration) (wait time.Duration, ok bool) {
+	if l == nil {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, limited := l.buckets[ch]
+	if !limited {
+		return 0, true
+	}
+	now := l.clock.Now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if wait > maxWait {
+		return wait, false
+	}
+	b.tokens--
+	return wait, true
+}
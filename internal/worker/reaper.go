@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
+)
+
+// ReaperPolicy что считать потерянным уведомлением и как быстро их обходить.
+type ReaperPolicy struct {
+	// Grace сколько после срока отправки ждать консьюмера, прежде чем считать сообщение потерянным
+	Grace time.Duration
+	// MaxAge старше этого pending не переотправляются, а истекают (0 — всегда переотправлять)
+	MaxAge time.Duration
+	// BatchSize размер страницы
+	BatchSize int
+	// BatchPause пауза между страницами, чтобы не занимать таблицу и брокер целиком
+	BatchPause time.Duration
+	// Budget сколько времени может занять один проход, 0 — без ограничения; необойденное
+	// продолжается со следующего прохода
+	Budget time.Duration
+}
+
+// Reaper периодически сверяет базу с очередью: находит уведомления, чье сообщение
+// не дошло до консьюмера (очередь уведомления истекла, публикация не удалась),
+// и публикует их заново или завершает. Количество найденных — метрика notifications_orphaned.
+type Reaper struct {
+	service  domain.NotificationService
+	interval time.Duration
+	policy   ReaperPolicy
+	clock    domain.Clock
+
+	// cursor место, где остановился проход, исчерпавший бюджет
+	cursor *domain.OrphanCursor
+}
+
+// ReaperOption функция настройки Reaper.
+type ReaperOption func(*Reaper)
+
+// WithReaperClock задает источник времени для бюджета прохода, по умолчанию системное время.
+func WithReaperClock(c domain.Clock) ReaperOption {
+	return func(r *Reaper) {
+		r.clock = c
+	}
+}
+
+func NewReaper(service domain.NotificationService, interval time.Duration, policy ReaperPolicy,
+	opts ...ReaperOption) *Reaper {
+	r := &Reaper{
+		service:  service,
+		interval: interval,
+		policy:   policy,
+		clock:    domain.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Reaper) Start(ctx context.Context) {
+	if r.interval <= 0 {
+		logger.FromContext(ctx).Info().Msg("orphan reaper disabled")
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx)
+		}
+	}
+}
+
+// Check выполняет один проход сверки постранично, пока страницы не кончатся или не истечет бюджет,
+// и обновляет метрики.
+func (r *Reaper) Check(ctx context.Context) {
+	var total domain.OrphanReport
+	var deadline time.Time
+	if r.policy.Budget > 0 {
+		deadline = r.clock.Now().Add(r.policy.Budget)
+	}
+	pages := 0
+	for {
+		report, err := r.service.ReconcileOrphans(ctx, r.policy.Grace, r.policy.MaxAge, r.cursor, r.policy.BatchSize)
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("reconcile orphaned notifications failed")
+			break
+		}
+		pages++
+		total.Found += report.Found
+		total.Republished += report.Republished
+		total.Expired += report.Expired
+		total.Failed += report.Failed
+		r.cursor = report.Next
+		if r.cursor == nil {
+			break
+		}
+		if !deadline.IsZero() && r.clock.Now().Add(r.policy.BatchPause).After(deadline) {
+			logger.FromContext(ctx).Warn().Int("pages", pages).
+				Msg("orphan reaper time budget exhausted, continuing on the next run")
+			break
+		}
+		if !sleepCtx(ctx, r.policy.BatchPause) {
+			break
+		}
+	}
+
+	if total.Found > 0 {
+		logger.FromContext(ctx).Warn().Msgf("orphaned notifications: found %d, republished %d, expired %d, failed %d",
+			total.Found, total.Republished, total.Expired, total.Failed)
+	}
+	metrics.OrphanedNotifications.Set(int64(total.Found))
+	metrics.OrphansReconciled.Add("republished", int64(total.Republished))
+	metrics.OrphansReconciled.Add("expired", int64(total.Expired))
+	metrics.OrphansReconciled.Add("failed", int64(total.Failed))
+}
+
+// sleepCtx ждет d или отмены контекста; false, если контекст отменен.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// Reprocessor периодически возвращает в отправку неуспешные уведомления,
+// чтобы временные сбои провайдеров проходили без ручной переотправки.
+// После maxCycles возвратов уведомление остается failed окончательно.
+type Reprocessor struct {
+	service   domain.NotificationService
+	interval  time.Duration
+	maxCycles int
+	batchSize int
+}
+
+func NewReprocessor(service domain.NotificationService, interval time.Duration, maxCycles, batchSize int) *Reprocessor {
+	return &Reprocessor{
+		service:   service,
+		interval:  interval,
+		maxCycles: maxCycles,
+		batchSize: batchSize,
+	}
+}
+
+func (r *Reprocessor) Start(ctx context.Context) {
+	if r.interval <= 0 || r.maxCycles <= 0 {
+		logger.FromContext(ctx).Info().Msg("failed notifications reprocessor disabled")
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// уведомление возвращается не раньше, чем через interval после последней неудачи
+			if _, err := r.service.ReprocessFailed(ctx, r.interval, r.maxCycles, r.batchSize); err != nil {
+				logger.FromContext(ctx).Error().Err(err).Msg("reprocess failed notifications failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
+)
+
+// SLAEvent тело события о нарушении или восстановлении цели SLA для ops-вебхука.
+type SLAEvent struct {
+	// Event sla_breach или sla_recovered
+	Event      string    `json:"event"`
+	Target     string    `json:"target"`
+	Source     string    `json:"source,omitempty"`
+	Channel    string    `json:"channel"`
+	Objective  float64   `json:"objective"`
+	Within     string    `json:"within"`
+	Compliance float64   `json:"compliance"`
+	Met        int       `json:"met"`
+	Total      int       `json:"total"`
+	Window     string    `json:"window"`
+	At         time.Time `json:"at"`
+}
+
+// SLAMonitor периодически оценивает цели SLA, публикует соблюдение в метриках sla_compliance
+// и sla_breached и при смене состояния цели (нарушена / восстановлена) отправляет событие в ops-вебхук.
+type SLAMonitor struct {
+	service    domain.NotificationService
+	interval   time.Duration
+	webhookURL string
+	client     *http.Client
+
+	breached map[string]bool
+}
+
+// SLAMonitorOption функция настройки SLAMonitor.
+type SLAMonitorOption func(*SLAMonitor)
+
+// WithWebhookTransport задает транспорт вызовов ops-вебхука (например, через исходящий прокси).
+func WithWebhookTransport(rt http.RoundTripper) SLAMonitorOption {
+	return func(m *SLAMonitor) {
+		m.client.Transport = rt
+	}
+}
+
+func NewSLAMonitor(service domain.NotificationService, interval time.Duration, webhookURL string,
+	opts ...SLAMonitorOption) *SLAMonitor {
+	m := &SLAMonitor{
+		service:    service,
+		interval:   interval,
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		breached:   make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *SLAMonitor) Start(ctx context.Context) {
+	if m.interval <= 0 {
+		logger.FromContext(ctx).Info().Msg("sla monitor disabled")
+		return
+	}
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check оценивает цели и отправляет события для тех, чье состояние изменилось.
+func (m *SLAMonitor) Check(ctx context.Context) {
+	reports, err := m.service.SLACompliance(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("sla check failed")
+		return
+	}
+	for _, r := range reports {
+		name := r.Target.Name()
+		compliance := new(expvar.Float)
+		compliance.Set(r.Compliance())
+		metrics.SLACompliance.Set(name, compliance)
+		breached := new(expvar.Int)
+		if r.Breached {
+			breached.Set(1)
+		}
+		metrics.SLABreached.Set(name, breached)
+
+		if r.Breached == m.breached[name] {
+			continue
+		}
+		event := "sla_recovered"
+		if r.Breached {
+			event = "sla_breach"
+			logger.FromContext(ctx).Warn().Msgf("sla %s breached: %.2f%% of %d sent within %s, objective %.2f%%",
+				name, 100*r.Compliance(), r.Total, r.Target.Within, 100*r.Target.Objective)
+		}
+		if err := m.post(ctx, newSLAEvent(event, r)); err != nil {
+			// состояние не запоминается, событие повторится при следующей проверке
+			logger.FromContext(ctx).Error().Err(err).Msgf("failed to send %s event for %s", event, name)
+			continue
+		}
+		m.breached[name] = r.Breached
+	}
+}
+
+func newSLAEvent(event string, r domain.SLAReport) SLAEvent {
+	return SLAEvent{
+		Event:      event,
+		Target:     r.Target.Name(),
+		Source:     r.Target.Source,
+		Channel:    r.Target.Channel.String(),
+		Objective:  r.Target.Objective,
+		Within:     r.Target.Within.String(),
+		Compliance: r.Compliance(),
+		Met:        r.Met,
+		Total:      r.Total,
+		Window:     r.Window.String(),
+		At:         time.Now().UTC(),
+	}
+}
+
+func (m *SLAMonitor) post(ctx context.Context, event SLAEvent) error {
+	if m.webhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ops webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
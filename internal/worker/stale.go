@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+	"DelayedNotifier/internal/metrics"
+)
+
+// StaleAlertSource источник служебных уведомлений детекторов (зависшие, всплески ошибок).
+const StaleAlertSource = "delayednotifier"
+
+// StaleAlert получатель оповещения о зависших уведомлениях.
+type StaleAlert struct {
+	Recipient string
+	Channel   domain.Channel
+	// Cooldown минимальный интервал между оповещениями
+	Cooldown time.Duration
+}
+
+// StaleDetector периодически считает уведомления, зависшие в processing дольше threshold,
+// публикует их количество в метрике processing_stale и, если задан получатель,
+// отправляет оповещение через сам сервис, чтобы молчаливые сбои консьюмера не оставались незамеченными.
+type StaleDetector struct {
+	service   domain.NotificationService
+	interval  time.Duration
+	threshold time.Duration
+	alert     StaleAlert
+
+	lastAlert time.Time
+}
+
+func NewStaleDetector(service domain.NotificationService, interval, threshold time.Duration,
+	alert StaleAlert) *StaleDetector {
+	return &StaleDetector{
+		service:   service,
+		interval:  interval,
+		threshold: threshold,
+		alert:     alert,
+	}
+}
+
+func (d *StaleDetector) Start(ctx context.Context) {
+	if d.interval <= 0 || d.threshold <= 0 {
+		logger.FromContext(ctx).Info().Msg("stale processing detector disabled")
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.check(ctx)
+		}
+	}
+}
+
+func (d *StaleDetector) check(ctx context.Context) {
+	count, err := d.service.CountStaleProcessing(ctx, d.threshold)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("stale processing check failed")
+		return
+	}
+	metrics.StaleProcessing.Set(int64(count))
+	if count == 0 {
+		return
+	}
+	logger.FromContext(ctx).Warn().Msgf("%d notifications are stuck in processing longer than %s", count, d.threshold)
+
+	if d.alert.Recipient == "" || time.Since(d.lastAlert) < d.alert.Cooldown {
+		return
+	}
+	_, err = d.service.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient: d.alert.Recipient,
+		Channel:   d.alert.Channel,
+		Payload: map[string]interface{}{
+			"subject": "DelayedNotifier: зависшие уведомления",
+			"body": fmt.Sprintf("%d уведомлений находятся в processing дольше %s. Проверьте консьюмер.",
+				count, d.threshold),
+		},
+		ScheduledAt: time.Now(),
+		Immediate:   true,
+		Source:      StaleAlertSource,
+		Priority:    domain.PriorityHigh,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("failed to send stale processing alert")
+		return
+	}
+	d.lastAlert = time.Now()
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+)
+
+// channelThrottle временно приостанавливает отправку по каналу после ограничения частоты
+// у провайдера, чтобы остальные сообщения канала откладывались сразу, не обращаясь к провайдеру.
+type channelThrottle struct {
+	mu    sync.Mutex
+	until map[domain.Channel]time.Time
+}
+
+func newChannelThrottle() *channelThrottle {
+	return &channelThrottle{until: make(map[domain.Channel]time.Time)}
+}
+
+// pause приостанавливает канал на d; более ранняя пауза продлевается, более поздняя сохраняется.
+func (t *channelThrottle) pause(ch domain.Channel, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.until[ch]) {
+		t.until[ch] = until
+	}
+}
+
+// remaining возвращает, сколько еще канал приостановлен; 0 — отправлять можно.
+func (t *channelThrottle) remaining(ch domain.Channel) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d := time.Until(t.until[ch])
+	if d <= 0 {
+		delete(t.until, ch)
+		return 0
+	}
+	return d
+}
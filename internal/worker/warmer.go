@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/logger"
+)
+
+// Warmer периодически загружает в кеш уведомления, которые сработают
+// в ближайшие horizon, сглаживая нагрузку на базу в популярные моменты отправки.
+type Warmer struct {
+	service   domain.NotificationService
+	interval  time.Duration
+	horizon   time.Duration
+	batchSize int
+}
+
+func NewWarmer(service domain.NotificationService, interval, horizon time.Duration, batchSize int) *Warmer {
+	return &Warmer{
+		service:   service,
+		interval:  interval,
+		horizon:   horizon,
+		batchSize: batchSize,
+	}
+}
+
+func (w *Warmer) Start(ctx context.Context) {
+	if w.interval <= 0 || w.horizon <= 0 {
+		logger.FromContext(ctx).Info().Msg("cache warmer disabled")
+		return
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.service.WarmCache(ctx, w.horizon, w.batchSize); err != nil {
+				logger.FromContext(ctx).Error().Err(err).Msg("warm cache failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_deleted_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS deleted_at;
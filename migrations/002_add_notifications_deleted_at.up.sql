@@ -0,0 +1,6 @@
+ALTER TABLE notifications ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Для фоновой очистки мягко удаленных уведомлений
+CREATE INDEX idx_notifications_deleted_at
+    ON notifications (deleted_at)
+    WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_recipient_created;
+DROP INDEX IF EXISTS idx_notifications_status_scheduled;
@@ -0,0 +1,7 @@
+-- Выборка зависших уведомлений: status = ... AND scheduled_at <= ...
+CREATE INDEX IF NOT EXISTS idx_notifications_status_scheduled
+    ON notifications (status, scheduled_at);
+
+-- История уведомлений получателя
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient_created
+    ON notifications (recipient, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_correlation_id;
+ALTER TABLE notifications DROP COLUMN IF EXISTS correlation_id;
+ALTER TABLE notifications DROP COLUMN IF EXISTS parent_id;
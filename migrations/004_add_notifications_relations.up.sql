@@ -0,0 +1,7 @@
+-- Связь уведомления с исходным (клон, повтор, эскалация) и с корнем всей цепочки
+ALTER TABLE notifications ADD COLUMN parent_id UUID REFERENCES notifications (id) ON DELETE SET NULL;
+ALTER TABLE notifications ADD COLUMN correlation_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_correlation_id
+    ON notifications (correlation_id)
+    WHERE correlation_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- "Отправить, если не подтверждено": подтверждение до scheduled_at отменяет уведомление
+ALTER TABLE notifications ADD COLUMN cancel_on_confirm BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE notifications DROP COLUMN IF EXISTS pre_send_check;
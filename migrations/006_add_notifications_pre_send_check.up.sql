@@ -0,0 +1,2 @@
+-- URL проверки перед отправкой: пустая строка означает отправку без проверки
+ALTER TABLE notifications ADD COLUMN pre_send_check TEXT NOT NULL DEFAULT '';
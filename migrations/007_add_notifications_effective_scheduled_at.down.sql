@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_status_effective_scheduled;
+ALTER TABLE notifications DROP COLUMN IF EXISTS effective_scheduled_at;
@@ -0,0 +1,7 @@
+-- Фактическое время отправки: scheduled_at или сдвиг внутри окна сглаживания
+ALTER TABLE notifications ADD COLUMN effective_scheduled_at TIMESTAMPTZ;
+UPDATE notifications SET effective_scheduled_at = scheduled_at;
+ALTER TABLE notifications ALTER COLUMN effective_scheduled_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_status_effective_scheduled
+    ON notifications (status, effective_scheduled_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_source_status;
+ALTER TABLE notifications DROP COLUMN IF EXISTS source;
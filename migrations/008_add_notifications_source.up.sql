@@ -0,0 +1,4 @@
+-- Система-источник уведомления (имя сервиса), пустая строка у созданных до появления поля
+ALTER TABLE notifications ADD COLUMN source TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_source_status ON notifications (source, status);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_failed_updated;
+ALTER TABLE notifications DROP COLUMN IF EXISTS reprocess_count;
@@ -0,0 +1,6 @@
+-- Сколько раз неуспешное уведомление автоматически возвращалось в отправку
+ALTER TABLE notifications ADD COLUMN reprocess_count INT NOT NULL DEFAULT 0 CHECK (reprocess_count >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_failed_updated
+    ON notifications (updated_at)
+    WHERE status = 'failed';
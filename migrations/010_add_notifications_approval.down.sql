@@ -0,0 +1,6 @@
+ALTER TABLE notifications DROP COLUMN IF EXISTS approved_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS approved_by;
+ALTER TABLE notifications DROP COLUMN IF EXISTS requires_approval;
+
+-- значение из enum удалить нельзя: неодобренные уведомления отменяются, 'awaiting_approval' остается неиспользуемым
+UPDATE notifications SET status = 'cancelled' WHERE status = 'awaiting_approval';
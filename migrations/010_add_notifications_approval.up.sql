@@ -0,0 +1,6 @@
+-- Уведомления, требующие одобрения, не публикуются в очередь до POST /notify/:id/approve
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'awaiting_approval';
+
+ALTER TABLE notifications ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE notifications ADD COLUMN approved_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE notifications ADD COLUMN approved_at TIMESTAMPTZ;
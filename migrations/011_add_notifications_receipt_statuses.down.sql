@@ -0,0 +1,2 @@
+-- значения из enum удалить нельзя: подтвержденные квитанциями уведомления возвращаются в 'sent'
+UPDATE notifications SET status = 'sent' WHERE status IN ('delivered', 'read');
@@ -0,0 +1,3 @@
+-- Статусы по квитанциям каналов: sent -> delivered -> read
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'delivered';
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'read';
@@ -0,0 +1,3 @@
+-- значения из enum удалить нельзя: возвращаем уведомления в статусы, которые использовались до них
+UPDATE notifications SET status = 'failed' WHERE status IN ('bounced', 'expired');
+UPDATE notifications SET status = 'cancelled' WHERE status = 'suppressed';
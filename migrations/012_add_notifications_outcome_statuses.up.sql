@@ -0,0 +1,6 @@
+-- Исходы, которые раньше попадали в failed или cancelled:
+-- bounced — провайдер отверг получателя, expired — уведомление устарело до отправки,
+-- suppressed — отправку отклонила проверка перед отправкой
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'bounced';
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'expired';
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'suppressed';
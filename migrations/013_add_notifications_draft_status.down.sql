@@ -0,0 +1,2 @@
+-- значение из enum удалить нельзя: незапланированные черновики отменяются, 'draft' остается неиспользуемым
+UPDATE notifications SET status = 'cancelled' WHERE status = 'draft';
@@ -0,0 +1,2 @@
+-- Черновики не публикуются в очередь до POST /notify/:id/schedule
+ALTER TYPE notification_status ADD VALUE IF NOT EXISTS 'draft';
@@ -0,0 +1,4 @@
+-- расширение pg_trgm не удаляется: им могут пользоваться другие таблицы базы
+DROP INDEX IF EXISTS idx_notifications_recipient_trgm;
+DROP INDEX IF EXISTS idx_notifications_search_document;
+ALTER TABLE notifications DROP COLUMN IF EXISTS search_document;
@@ -0,0 +1,16 @@
+-- Поиск по тексту: получатель (целиком и до/после @) и строковые значения payload
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS search_document tsvector
+    GENERATED ALWAYS AS (
+        to_tsvector('simple', recipient)
+            || to_tsvector('simple', replace(recipient, '@', ' '))
+            || jsonb_to_tsvector('simple', payload, '["string"]')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_search_document
+    ON notifications USING GIN (search_document);
+
+-- Поиск по фрагменту получателя: recipient ILIKE '%...%'
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient_trgm
+    ON notifications USING GIN (recipient gin_trgm_ops);
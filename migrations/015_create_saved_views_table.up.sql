@@ -0,0 +1,7 @@
+-- Сохраненные операторами наборы фильтров поиска (GET /admin/views/:name/results)
+CREATE TABLE IF NOT EXISTS saved_views (
+    name TEXT PRIMARY KEY,
+    filter JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- Время перехода в sent: по нему считается соблюдение SLA (отправлено не позже effective_scheduled_at + цель)
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS sent_at TIMESTAMPTZ;
+-- для уже отправленных лучшая оценка — последнее обновление
+UPDATE notifications SET sent_at = updated_at WHERE status = 'sent' AND sent_at IS NULL;
@@ -0,0 +1,4 @@
+-- Список уведомлений GET /notify: новые по scheduled_at первыми, стабильный порядок для пагинации
+CREATE INDEX IF NOT EXISTS idx_notifications_scheduled_id
+    ON notifications (scheduled_at DESC, id DESC)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- Постраничный обход зависших уведомлений сверкой: ключ (effective_scheduled_at, id)
+CREATE INDEX IF NOT EXISTS idx_notifications_effective_scheduled_id
+    ON notifications (effective_scheduled_at, id)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,12 @@
+-- Уведомления, исчерпавшие предел попыток отправки (max_retries): последняя ошибка и число попыток
+CREATE TABLE IF NOT EXISTS notification_failures (
+    id BIGSERIAL PRIMARY KEY,
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_failures_notification_id
+    ON notification_failures (notification_id, failed_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_source_idempotency_key;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,6 @@
+-- Ключ идемпотентности создания (заголовок Idempotency-Key): повтор запроса с тем же ключом
+-- от той же системы-источника возвращает уже созданное уведомление
+ALTER TABLE notifications ADD COLUMN idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_source_idempotency_key
+    ON notifications (source, idempotency_key) WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,13 @@
+-- История статусов уведомления: каждый переход с временем, инициатором и ошибкой
+CREATE TABLE IF NOT EXISTS notification_events (
+    id BIGSERIAL PRIMARY KEY,
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    from_status notification_status,
+    to_status notification_status NOT NULL,
+    actor TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_events_notification_id
+    ON notification_events (notification_id, created_at, id);
@@ -0,0 +1,7 @@
+-- Возвращаем вынесенные payload в строки уведомлений, иначе в них останутся ссылки
+UPDATE notifications n SET payload = lp.payload
+    FROM notification_payloads lp
+    WHERE n.payload->>'$payload_ref' = lp.key;
+
+DROP INDEX IF EXISTS idx_notifications_payload_ref;
+DROP TABLE IF EXISTS notification_payloads;
@@ -0,0 +1,13 @@
+-- Крупные payload хранятся отдельно от строки уведомления, которая ссылается на них
+-- заглушкой {"$payload_ref": key}; key — SHA-256 содержимого
+CREATE TABLE IF NOT EXISTS notification_payloads (
+    key TEXT PRIMARY KEY,
+    payload JSONB NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Поиск ссылок при удалении payload, на которые больше не ссылается ни одно уведомление
+CREATE INDEX IF NOT EXISTS idx_notifications_payload_ref
+    ON notifications ((payload->>'$payload_ref'))
+    WHERE payload->>'$payload_ref' IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_group_id;
+ALTER TABLE notifications DROP COLUMN IF EXISTS group_id;
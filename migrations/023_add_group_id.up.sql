@@ -0,0 +1,5 @@
+-- Группа уведомлений, созданных одним запросом для нескольких получателей
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS group_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_group_id
+    ON notifications (group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Шаблоны subject/body с подстановками text/template (POST /templates)
+CREATE TABLE IF NOT EXISTS templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Уведомление по шаблону: payload хранит переменные, текст подставляется при отправке
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS template_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_template_id
+    ON notifications (template_id) WHERE template_id IS NOT NULL;
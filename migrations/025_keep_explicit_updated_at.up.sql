@@ -0,0 +1,11 @@
+-- updated_at, заданный в UPDATE явно, сохраняется: сервис пишет то же значение в кеш,
+-- и ETag уведомления совпадает для кеша и базы (условные запросы с If-Match)
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.updated_at IS NOT DISTINCT FROM OLD.updated_at THEN
+        NEW.updated_at = NOW();
+    END IF;
+RETURN NEW;
+END;
+$$ language 'plpgsql';
@@ -0,0 +1,9 @@
+-- Контракты payload по источнику и каналу (PUT /admin/schemas/:source/:channel)
+CREATE TABLE IF NOT EXISTS payload_schemas (
+    source TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    schema JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, channel)
+);
@@ -0,0 +1,2 @@
+-- Приоритет отправки: уведомления high идут через отдельную очередь со своими воркерами
+ALTER TABLE notifications ADD COLUMN priority TEXT NOT NULL DEFAULT 'normal';
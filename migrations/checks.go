@@ -0,0 +1,71 @@
+package migrations
+
+import "DelayedNotifier/internal/migrator"
+
+// Checks проверки схемы по версиям миграций для migrate repair: по ним видно,
+// применилась ли прерванная миграция целиком, не применилась или застряла посередине.
+// Новой миграции нужна запись здесь.
+var Checks = map[uint][]migrator.SchemaCheck{
+	1: {table("notifications"), enumValue("pending"), index("idx_notifications_pending_scheduled"),
+		{Name: "trigger update_notifications_updated_at",
+			Query: `SELECT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'update_notifications_updated_at')`}},
+	2:  {column("deleted_at"), index("idx_notifications_deleted_at")},
+	3:  {index("idx_notifications_status_scheduled"), index("idx_notifications_recipient_created")},
+	4:  {column("parent_id"), column("correlation_id"), index("idx_notifications_correlation_id")},
+	5:  {column("cancel_on_confirm")},
+	6:  {column("pre_send_check")},
+	7:  {column("effective_scheduled_at"), index("idx_notifications_status_effective_scheduled")},
+	8:  {column("source"), index("idx_notifications_source_status")},
+	9:  {column("reprocess_count"), index("idx_notifications_failed_updated")},
+	10: {enumValue("awaiting_approval"), column("requires_approval"), column("approved_by"), column("approved_at")},
+	11: {enumValue("delivered"), enumValue("read")},
+	12: {enumValue("bounced"), enumValue("expired"), enumValue("suppressed")},
+	13: {enumValue("draft")},
+	14: {column("search_document"), index("idx_notifications_search_document"),
+		index("idx_notifications_recipient_trgm")},
+	15: {table("saved_views")},
+	16: {column("sent_at")},
+	17: {index("idx_notifications_scheduled_id")},
+	18: {index("idx_notifications_effective_scheduled_id")},
+	19: {table("notification_failures"), index("idx_notification_failures_notification_id")},
+	20: {column("idempotency_key"), index("idx_notifications_source_idempotency_key")},
+	21: {table("notification_events"), index("idx_notification_events_notification_id")},
+	22: {table("notification_payloads"), index("idx_notifications_payload_ref")},
+	23: {column("group_id"), index("idx_notifications_group_id")},
+	24: {table("templates"), column("template_id"), index("idx_notifications_template_id")},
+	25: {{Name: "function update_updated_at_column keeps explicit updated_at",
+		Query: `SELECT EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'update_updated_at_column'
+ AND prosrc LIKE '%IS NOT DISTINCT FROM OLD.updated_at%')`}},
+	26: {table("payload_schemas")},
+	27: {column("priority")},
+}
+
+func table(name string) migrator.SchemaCheck {
+	return migrator.SchemaCheck{
+		Name:  "table " + name,
+		Query: `SELECT to_regclass('` + name + `') IS NOT NULL`,
+	}
+}
+
+func column(name string) migrator.SchemaCheck {
+	return migrator.SchemaCheck{
+		Name: "column notifications." + name,
+		Query: `SELECT EXISTS (SELECT 1 FROM information_schema.columns
+ WHERE table_name = 'notifications' AND column_name = '` + name + `')`,
+	}
+}
+
+func index(name string) migrator.SchemaCheck {
+	return migrator.SchemaCheck{
+		Name:  "index " + name,
+		Query: `SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = '` + name + `')`,
+	}
+}
+
+func enumValue(value string) migrator.SchemaCheck {
+	return migrator.SchemaCheck{
+		Name: "status " + value,
+		Query: `SELECT EXISTS (SELECT 1 FROM pg_enum e JOIN pg_type t ON t.oid = e.enumtypid
+ WHERE t.typname = 'notification_status' AND e.enumlabel = '` + value + `')`,
+	}
+}
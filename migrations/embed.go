@@ -0,0 +1,9 @@
+// Package migrations содержит SQL-миграции, встроенные в бинарник.
+package migrations
+
+import "embed"
+
+// FS встроенные файлы миграций (NNN_name.up.sql / NNN_name.down.sql).
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,285 @@
+// Package client Go-клиент HTTP API DelayedNotifier: создание, получение, отмена и список уведомлений
+// без ручной сборки запросов. Создание всегда идет с ключом идемпотентности, поэтому повтор после
+// таймаута или сбоя сети не создает дубликат.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"DelayedNotifier/pkg/retry"
+	"github.com/google/uuid"
+)
+
+// DefaultRetry стратегия повторов по умолчанию.
+var DefaultRetry = retry.Strategy{Attempts: 3, Delay: 200 * time.Millisecond, Backoff: 2}
+
+// Client клиент API уведомлений, безопасен для использования из нескольких горутин.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retry      retry.Strategy
+	header     http.Header
+	newKey     func() string
+}
+
+// Option настраивает Client.
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP-клиент (таймауты, транспорт, прокси), по умолчанию клиент с таймаутом 10s.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithRetry задает стратегию повторов; Attempts = 1 отключает повторы.
+func WithRetry(strategy retry.Strategy) Option {
+	return func(c *Client) {
+		c.retry = strategy
+	}
+}
+
+// WithHeader добавляет заголовок ко всем запросам (например, авторизацию на шлюзе перед сервисом).
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithIdempotencyKeys задает генератор ключей идемпотентности для созданий без своего ключа,
+// по умолчанию случайный UUID.
+func WithIdempotencyKeys(gen func() string) Option {
+	return func(c *Client) {
+		c.newKey = gen
+	}
+}
+
+// New создает клиент для сервиса по адресу baseURL (например, http://notifier:8080).
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retry:      DefaultRetry,
+		header:     make(http.Header),
+		newKey:     func() string { return uuid.NewString() },
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.Attempts < 1 {
+		c.retry.Attempts = 1
+	}
+	return c
+}
+
+// Create создает уведомление. Если IdempotencyKey не задан, клиент генерирует его сам: все попытки
+// одного вызова идут с одним ключом, и сервис вернет уже созданное уведомление, а не второе.
+func (c *Client) Create(ctx context.Context, req CreateRequest) (*Notification, error) {
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = c.newKey()
+	}
+	body, err := req.body()
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Result createdNotification `json:"result"`
+	}
+	header := http.Header{idempotencyKeyHeader: []string{req.IdempotencyKey}}
+	if _, err := c.do(ctx, http.MethodPost, "/notify/", nil, header, body, &out); err != nil {
+		return nil, err
+	}
+	n := Notification(out.Result)
+	return &n, nil
+}
+
+// Get получает уведомление; ETag ответа сохраняется в Notification.ETag для CancelIfMatch.
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (*Notification, error) {
+	var out struct {
+		Result Notification `json:"result"`
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/notify/"+id.String(), nil, nil, nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	out.Result.ETag = resp.Header.Get("ETag")
+	return &out.Result, nil
+}
+
+// Cancel отменяет уведомление.
+func (c *Client) Cancel(ctx context.Context, id uuid.UUID) error {
+	return c.CancelIfMatch(ctx, id, "")
+}
+
+// CancelIfMatch отменяет уведомление, только если оно не менялось с получения ETag
+// (иначе *APIError с кодом 412). Пустой etag — отмена без условия.
+func (c *Client) CancelIfMatch(ctx context.Context, id uuid.UUID, etag string) error {
+	var header http.Header
+	if etag != "" {
+		header = http.Header{"If-Match": []string{etag}}
+	}
+	_, err := c.do(ctx, http.MethodDelete, "/notify/"+id.String(), nil, header, nil, nil)
+	return err
+}
+
+// List получает страницу уведомлений по фильтру; следующая страница — Offset+Limit, пока HasMore.
+func (c *Client) List(ctx context.Context, filter ListFilter) (*NotificationList, error) {
+	var out struct {
+		Result NotificationList `json:"result"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/notify/", filter.query(), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out.Result, nil
+}
+
+// do выполняет запрос с повторами и разбирает поле ответа в out. Тело запроса собирается заново
+// на каждую попытку.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header,
+	body []byte, out interface{}) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var resp *http.Response
+	var data []byte
+	err := retry.DoContext(ctx, c.retry, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return retry.Stop(err)
+		}
+		for k, v := range c.header {
+			req.Header[k] = v
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return retry.Stop(ctx.Err())
+			}
+			return err
+		}
+		data, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 300 {
+			apiErr := newAPIError(resp.StatusCode, data)
+			if !retryable(resp.StatusCode) {
+				return retry.Stop(apiErr)
+			}
+			return apiErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("decode %s %s response: %w", method, path, err)
+		}
+	}
+	return resp, nil
+}
+
+// retryable коды, после которых запрос стоит повторить: перегрузка и недоступность сервиса или
+// прокси перед ним. 500 не повторяется: сервис отвечает им и на постоянные ошибки.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// APIError ответ сервиса с кодом ошибки.
+type APIError struct {
+	StatusCode int
+	// Message текст ошибки из поля error или message ответа
+	Message string
+	// Fields ошибки валидации по полям запроса (Recipient, ScheduledAt, Payload...)
+	Fields map[string]string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("notifier: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if len(e.Fields) > 0 {
+		fields := make([]string, 0, len(e.Fields))
+		for f, m := range e.Fields {
+			fields = append(fields, f+": "+m)
+		}
+		msg += " (" + strings.Join(fields, "; ") + ")"
+	}
+	return msg
+}
+
+func newAPIError(status int, data []byte) *APIError {
+	e := &APIError{StatusCode: status}
+	var body struct {
+		Error   string            `json:"error"`
+		Message string            `json:"message"`
+		Errors  map[string]string `json:"errors"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		e.Message, e.Fields = body.Error, body.Errors
+		if e.Message == "" {
+			e.Message = body.Message
+		}
+	}
+	return e
+}
+
+// IsStatus сообщает, что err — ответ сервиса с кодом status.
+func IsStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func (f ListFilter) query() url.Values {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("status", f.Status)
+	set("channel", f.Channel)
+	set("recipient", f.Recipient)
+	if !f.ScheduledFrom.IsZero() {
+		q.Set("scheduled_from", f.ScheduledFrom.Format(time.RFC3339))
+	}
+	if !f.ScheduledTo.IsZero() {
+		q.Set("scheduled_to", f.ScheduledTo.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Offset > 0 {
+		q.Set("offset", strconv.Itoa(f.Offset))
+	}
+	return q
+}
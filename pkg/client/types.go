@@ -0,0 +1,189 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyHeader заголовок с ключом идемпотентности POST /notify.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Каналы отправки.
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelSMS      = "sms"
+)
+
+// Приоритеты: при перегрузке сервис первым отклоняет low.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// CreateRequest параметры создания уведомления, поля те же, что у тела POST /notify.
+type CreateRequest struct {
+	Recipient string
+	Channel   string
+	// Payload содержимое уведомления (subject, body и параметры канала); с TemplateID необязательно
+	Payload     map[string]interface{}
+	ScheduledAt time.Time
+	// Source имя сервиса-источника, обязательно
+	Source string
+	// Immediate разрешает ScheduledAt в прошлом: уведомление отправляется сразу
+	Immediate bool
+	// ParentID исходное уведомление (повтор, эскалация)
+	ParentID *uuid.UUID
+	// CancelOnConfirm отменить уведомление, если до ScheduledAt придет подтверждение
+	CancelOnConfirm bool
+	// PreSendCheck URL, который сервис вызывает перед отправкой
+	PreSendCheck string
+	// Smooth разрешает сдвинуть отправку внутри окна сглаживания
+	Smooth bool
+	// RequiresApproval не отправлять до одобрения
+	RequiresApproval bool
+	// Priority PriorityHigh, PriorityNormal (по умолчанию) или PriorityLow
+	Priority string
+	// Draft создать черновик
+	Draft bool
+	// IdempotencyKey ключ идемпотентности в пределах Source; пустой — клиент генерирует свой
+	IdempotencyKey string
+	// TemplateID шаблон subject и body, Variables — его подстановки
+	TemplateID *uuid.UUID
+	Variables  map[string]interface{}
+}
+
+// createBody тело POST /notify: payload передается строкой с JSON-объектом.
+type createBody struct {
+	Recipient        string                 `json:"recipient"`
+	Channel          string                 `json:"channel"`
+	Payload          string                 `json:"payload,omitempty"`
+	ScheduledAt      string                 `json:"scheduled_at"`
+	Source           string                 `json:"source"`
+	Immediate        bool                   `json:"immediate,omitempty"`
+	ParentID         string                 `json:"parent_id,omitempty"`
+	CancelOnConfirm  bool                   `json:"cancel_on_confirm,omitempty"`
+	PreSendCheck     string                 `json:"pre_send_check,omitempty"`
+	Smooth           bool                   `json:"smooth,omitempty"`
+	RequiresApproval bool                   `json:"requires_approval,omitempty"`
+	Priority         string                 `json:"priority,omitempty"`
+	Draft            bool                   `json:"draft,omitempty"`
+	IdempotencyKey   string                 `json:"idempotency_key,omitempty"`
+	TemplateID       string                 `json:"template_id,omitempty"`
+	Variables        map[string]interface{} `json:"variables,omitempty"`
+}
+
+func (r CreateRequest) body() ([]byte, error) {
+	b := createBody{
+		Recipient:        r.Recipient,
+		Channel:          r.Channel,
+		ScheduledAt:      r.ScheduledAt.Format(time.RFC3339),
+		Source:           r.Source,
+		Immediate:        r.Immediate,
+		CancelOnConfirm:  r.CancelOnConfirm,
+		PreSendCheck:     r.PreSendCheck,
+		Smooth:           r.Smooth,
+		RequiresApproval: r.RequiresApproval,
+		Priority:         r.Priority,
+		Draft:            r.Draft,
+		IdempotencyKey:   r.IdempotencyKey,
+		Variables:        r.Variables,
+	}
+	if r.Payload != nil {
+		payload, err := json.Marshal(r.Payload)
+		if err != nil {
+			return nil, err
+		}
+		b.Payload = string(payload)
+	}
+	if r.ParentID != nil {
+		b.ParentID = r.ParentID.String()
+	}
+	if r.TemplateID != nil {
+		b.TemplateID = r.TemplateID.String()
+	}
+	return json.Marshal(b)
+}
+
+// Notification уведомление в том виде, в котором его возвращает API.
+type Notification struct {
+	ID                   uuid.UUID              `json:"id"`
+	Recipient            string                 `json:"recipient"`
+	Channel              string                 `json:"channel"`
+	Payload              map[string]interface{} `json:"payload"`
+	ScheduledAt          time.Time              `json:"scheduled_at"`
+	Status               string                 `json:"status"`
+	RetryCount           int                    `json:"retry_count"`
+	CreatedAt            time.Time              `json:"created_at"`
+	UpdatedAt            time.Time              `json:"updated_at"`
+	DeletedAt            *time.Time             `json:"deleted_at,omitempty"`
+	ParentID             *uuid.UUID             `json:"parent_id,omitempty"`
+	CorrelationID        *uuid.UUID             `json:"correlation_id,omitempty"`
+	CancelOnConfirm      bool                   `json:"cancel_on_confirm,omitempty"`
+	PreSendCheck         string                 `json:"pre_send_check,omitempty"`
+	EffectiveScheduledAt time.Time              `json:"effective_scheduled_at"`
+	Source               string                 `json:"source,omitempty"`
+	ReprocessCount       int                    `json:"reprocess_count,omitempty"`
+	RequiresApproval     bool                   `json:"requires_approval,omitempty"`
+	ApprovedBy           string                 `json:"approved_by,omitempty"`
+	ApprovedAt           *time.Time             `json:"approved_at,omitempty"`
+	GroupID              *uuid.UUID             `json:"group_id,omitempty"`
+	TemplateID           *uuid.UUID             `json:"template_id,omitempty"`
+	// Priority приоритет отправки: PriorityHigh идут через отдельную очередь
+	Priority string `json:"priority"`
+	// ETag версия уведомления из ответа Get, для CancelIfMatch
+	ETag string `json:"-"`
+}
+
+// createdNotification Notification в ответе POST /notify: сервис отдает его с именами полей
+// без snake_case (ID, ScheduledAt...), поэтому поля те же, но без тегов.
+type createdNotification struct {
+	ID                   uuid.UUID
+	Recipient            string
+	Channel              string
+	Payload              map[string]interface{}
+	ScheduledAt          time.Time
+	Status               string
+	RetryCount           int
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            *time.Time
+	ParentID             *uuid.UUID
+	CorrelationID        *uuid.UUID
+	CancelOnConfirm      bool
+	PreSendCheck         string
+	EffectiveScheduledAt time.Time
+	Source               string
+	ReprocessCount       int
+	RequiresApproval     bool
+	ApprovedBy           string
+	ApprovedAt           *time.Time
+	GroupID              *uuid.UUID
+	TemplateID           *uuid.UUID
+	Priority             string
+	ETag                 string `json:"-"`
+}
+
+// ListFilter параметры GET /notify, пустые поля не фильтруют.
+type ListFilter struct {
+	Status    string
+	Channel   string
+	Recipient string
+	// ScheduledFrom и ScheduledTo границы scheduled_at [from, to)
+	ScheduledFrom time.Time
+	ScheduledTo   time.Time
+	// Limit размер страницы, 0 — по умолчанию сервиса, не больше 100
+	Limit  int
+	Offset int
+}
+
+// NotificationList страница списка уведомлений.
+type NotificationList struct {
+	Items   []Notification `json:"items"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+	HasMore bool           `json:"has_more"`
+}
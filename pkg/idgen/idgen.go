@@ -0,0 +1,47 @@
+package idgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Поддерживаемые стратегии генерации идентификаторов.
+const (
+	// KindV7 UUIDv7, упорядоченные по времени создания.
+	KindV7 = "v7"
+	// KindV4 случайные UUIDv4.
+	KindV4 = "v4"
+	// KindDB идентификатор генерирует база данных (DEFAULT столбца).
+	KindDB = "db"
+)
+
+// Generator функция генерации нового идентификатора.
+type Generator func() (uuid.UUID, error)
+
+// V7 генерирует UUIDv7: старшие биты содержат время в миллисекундах,
+// поэтому идентификаторы сортируются по времени создания.
+func V7() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+// V4 генерирует случайный UUIDv4.
+func V4() (uuid.UUID, error) {
+	return uuid.NewRandom()
+}
+
+// New возвращает генератор по названию стратегии.
+// Для KindDB возвращается nil: идентификатор назначит база данных.
+func New(kind string) (Generator, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case KindV7, "":
+		return V7, nil
+	case KindV4:
+		return V4, nil
+	case KindDB:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown id generator %q", kind)
+	}
+}
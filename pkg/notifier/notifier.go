@@ -0,0 +1,272 @@
+// Package notifier встраивает планировщик уведомлений в другую Go-программу: сервис и воркеры
+// отправки собираются на подключениях к PostgreSQL, Redis и RabbitMQ, которые передает программа,
+// без HTTP-сервера и конфигурации из окружения.
+//
+//	n, err := notifier.New(db, redisClient, rabbitClient, notifier.WithEmailSender(smtp))
+//	go n.Run(ctx)
+//	created, err := n.Service().CreateNotification(ctx, notifier.CreateParams{...})
+//
+// Встроенный режим запускает только консьюмер очереди и сверку зависших уведомлений; сервис
+// работает с той же схемой базы и топологией RabbitMQ, что и приложение, поэтому встроенный
+// экземпляр и обычные экземпляры могут обслуживать одну очередь.
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"DelayedNotifier/internal/migrator"
+	"DelayedNotifier/internal/repository/cache"
+	"DelayedNotifier/internal/repository/pg"
+	"DelayedNotifier/internal/repository/rabbit"
+	"DelayedNotifier/internal/sender"
+	"DelayedNotifier/internal/service"
+	"DelayedNotifier/internal/worker"
+	"DelayedNotifier/migrations"
+	"DelayedNotifier/pkg/idgen"
+	"DelayedNotifier/pkg/rabbitmq"
+	"DelayedNotifier/pkg/retry"
+	"github.com/wb-go/wbf/dbpg"
+	"github.com/wb-go/wbf/redis"
+)
+
+// Значения по умолчанию те же, что у приложения.
+const (
+	defaultExchange       = "DelayedNotifier"
+	defaultQueue          = "notification"
+	defaultCacheTTL       = 24 * time.Hour
+	defaultWorkers        = 10
+	defaultPrefetch       = 5
+	defaultMaxRetries     = 10
+	defaultThrottleDelay  = time.Minute
+	defaultReaperInterval = 5 * time.Minute
+)
+
+var (
+	defaultConsumerRetry = retry.Strategy{Attempts: 3, Delay: 3 * time.Second, Backoff: 3}
+	defaultReaperPolicy  = worker.ReaperPolicy{
+		Grace:      15 * time.Minute,
+		MaxAge:     24 * time.Hour,
+		BatchSize:  100,
+		BatchPause: 200 * time.Millisecond,
+		Budget:     time.Minute,
+	}
+)
+
+// Notifier встроенный планировщик уведомлений.
+type Notifier struct {
+	service     *service.NotificationService
+	topology    *rabbit.Topology
+	consumer    *worker.Consumer
+	reaper      *worker.Reaper
+	names       rabbit.Names
+	workers     int
+	highWorkers int
+	prefetch    int
+}
+
+type options struct {
+	namespace      string
+	exchange       string
+	queue          string
+	cacheTTL       time.Duration
+	workers        int
+	highWorkers    int
+	prefetch       int
+	consumerRetry  retry.Strategy
+	maxRetries     int
+	throttleDelay  time.Duration
+	reaperInterval time.Duration
+	checkMX        bool
+	email          EmailSender
+	telegram       TelegramSender
+	sms            SMSSender
+}
+
+// Option настраивает встроенный планировщик.
+type Option func(*options)
+
+// WithNamespace задает префикс exchange, очередей и ключей Redis, как DELAYED_NOTIFIER_NAMESPACE.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithNames задает имена exchange и очереди, по умолчанию DelayedNotifier и notification.
+func WithNames(exchange, queue string) Option {
+	return func(o *options) {
+		o.exchange, o.queue = exchange, queue
+	}
+}
+
+// WithCacheTTL задает время жизни уведомлений в кеше Redis, по умолчанию 24h.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.cacheTTL = ttl
+	}
+}
+
+// WithWorkers задает число воркеров отправки и prefetch очереди, по умолчанию 10 и 5.
+func WithWorkers(workers, prefetch int) Option {
+	return func(o *options) {
+		o.workers, o.prefetch = workers, prefetch
+	}
+}
+
+// WithHighWorkers задает число воркеров очереди уведомлений с приоритетом high, по умолчанию
+// столько же, сколько WithWorkers.
+func WithHighWorkers(workers int) Option {
+	return func(o *options) {
+		o.highWorkers = workers
+	}
+}
+
+// WithConsumerRetry задает стратегию повторов отправки внутри одной доставки сообщения.
+func WithConsumerRetry(strategy retry.Strategy) Option {
+	return func(o *options) {
+		o.consumerRetry = strategy
+	}
+}
+
+// WithMaxRetries задает, сколько раз уведомление переотправляется до failed, по умолчанию 10.
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.maxRetries = n
+	}
+}
+
+// WithThrottleDelay задает паузу канала после ответа провайдера об ограничении частоты.
+func WithThrottleDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.throttleDelay = d
+	}
+}
+
+// WithReaperInterval задает период сверки зависших уведомлений, 0 отключает сверку.
+func WithReaperInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.reaperInterval = d
+	}
+}
+
+// WithCheckMX включает проверку MX-записей домена получателя email при создании.
+func WithCheckMX(check bool) Option {
+	return func(o *options) {
+		o.checkMX = check
+	}
+}
+
+// WithEmailSender подключает отправщика email; без него уведомления email переходят в failed.
+func WithEmailSender(s EmailSender) Option {
+	return func(o *options) {
+		o.email = s
+	}
+}
+
+// WithTelegramSender подключает отправщика Telegram; без него уведомления telegram переходят в failed.
+func WithTelegramSender(s TelegramSender) Option {
+	return func(o *options) {
+		o.telegram = s
+	}
+}
+
+// WithSMSSender подключает отправщика SMS; без него уведомления sms переходят в failed.
+func WithSMSSender(s SMSSender) Option {
+	return func(o *options) {
+		o.sms = s
+	}
+}
+
+// New собирает планировщик на подключениях программы. Подключения остаются за программой:
+// Notifier их не закрывает. Схему базы создает Migrate.
+func New(db *sql.DB, redisClient *redis.Client, rabbitClient *rabbitmq.RabbitClient,
+	opts ...Option) (*Notifier, error) {
+	if db == nil || redisClient == nil || rabbitClient == nil {
+		return nil, errors.New("notifier: database, redis and rabbitmq clients are required")
+	}
+	o := options{
+		exchange:       defaultExchange,
+		queue:          defaultQueue,
+		cacheTTL:       defaultCacheTTL,
+		workers:        defaultWorkers,
+		prefetch:       defaultPrefetch,
+		consumerRetry:  defaultConsumerRetry,
+		maxRetries:     defaultMaxRetries,
+		throttleDelay:  defaultThrottleDelay,
+		reaperInterval: defaultReaperInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	names := rabbit.NewNames(o.namespace, o.exchange, o.queue)
+	pgRepo := pg.NewPostgresRepo(&dbpg.DB{Master: db}, pg.WithIDGenerator(idgen.V7))
+	publisher := rabbit.NewPublisher(rabbitClient, names, "application/json")
+	redisRepo := cache.NewRedisRepo(redisClient, cache.WithNamespace(o.namespace))
+
+	svcOpts := append(sender.ServiceOptions(o.checkMX),
+		service.WithTemplates(pgRepo),
+		service.WithSchemas(pgRepo),
+		service.WithFailureLog(pgRepo),
+		service.WithEventLog(pgRepo),
+		service.WithCacheInspector(redisRepo),
+		service.WithMaxRetries(o.maxRetries))
+	svc := service.NewNotificationService(pgRepo, publisher, redisRepo, o.cacheTTL, svcOpts...)
+
+	consumerOpts := []worker.ConsumerOption{worker.WithThrottleDelay(o.throttleDelay)}
+	if o.telegram != nil {
+		consumerOpts = append(consumerOpts, worker.WithTelegramSender(o.telegram))
+	}
+	if o.sms != nil {
+		consumerOpts = append(consumerOpts, worker.WithSMSSender(o.sms))
+	}
+	consumer, err := worker.NewConsumer(svc, rabbitClient, o.email, o.consumerRetry, consumerOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	highWorkers := o.highWorkers
+	if highWorkers <= 0 {
+		highWorkers = o.workers
+	}
+	return &Notifier{
+		service:     svc,
+		topology:    rabbit.NewTopology(rabbitClient, names),
+		consumer:    consumer,
+		reaper:      worker.NewReaper(svc, o.reaperInterval, defaultReaperPolicy),
+		names:       names,
+		workers:     o.workers,
+		highWorkers: highWorkers,
+		prefetch:    o.prefetch,
+	}, nil
+}
+
+// Service возвращает сервис уведомлений. Создавать уведомления можно и без Run: их отправит
+// любой запущенный экземпляр, читающий ту же очередь.
+func (n *Notifier) Service() Service {
+	return n.service
+}
+
+// Run объявляет топологию RabbitMQ и обрабатывает основную очередь и очередь приоритета high
+// до отмены ctx.
+func (n *Notifier) Run(ctx context.Context) error {
+	if _, err := n.topology.Sync(ctx); err != nil {
+		return err
+	}
+	go n.reaper.Start(ctx)
+	go n.consumer.StartHigh(ctx, n.names, n.highWorkers, n.prefetch)
+	n.consumer.Start(ctx, n.names, n.workers, n.prefetch)
+	return nil
+}
+
+// Migrate накатывает на базу встроенные миграции сервиса.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	m, err := migrator.NewMigratorFS(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+	return m.UpContext(ctx)
+}
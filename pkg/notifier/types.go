@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"DelayedNotifier/internal/domain"
+	emailsender "DelayedNotifier/internal/sender/email"
+	smssender "DelayedNotifier/internal/sender/sms"
+	telegramsender "DelayedNotifier/internal/sender/telegram"
+)
+
+// Типы сервиса, которыми пользуется встраивающая программа.
+type (
+	// Service сервис уведомлений: создание, чтение, отмена, перенос
+	Service = domain.NotificationService
+	// Notification уведомление
+	Notification = domain.Notification
+	// CreateParams параметры создания уведомления
+	CreateParams = domain.CreateNotificationParams
+	// ListFilter фильтры списка уведомлений
+	ListFilter = domain.ListFilter
+	Channel    = domain.Channel
+	Status     = domain.Status
+	Priority   = domain.Priority
+
+	// EmailSender, TelegramSender и SMSSender отправщики каналов; свою реализацию можно
+	// подключить вместо встроенных SMTP, Bot API и Twilio
+	EmailSender    = domain.EmailSender
+	TelegramSender = domain.TelegramSender
+	SMSSender      = domain.SMSSender
+
+	SMTPSender   = emailsender.SMTPSender
+	BotSender    = telegramsender.BotSender
+	TwilioSender = smssender.TwilioSender
+)
+
+const (
+	ChannelEmail    = domain.ChannelEmail
+	ChannelTelegram = domain.ChannelTelegram
+	ChannelSMS      = domain.ChannelSMS
+
+	PriorityHigh   = domain.PriorityHigh
+	PriorityNormal = domain.PriorityNormal
+	PriorityLow    = domain.PriorityLow
+
+	StatusPending          = domain.StatusPending
+	StatusProcessing       = domain.StatusProcessing
+	StatusSent             = domain.StatusSent
+	StatusFailed           = domain.StatusFailed
+	StatusCancelled        = domain.StatusCancelled
+	StatusAwaitingApproval = domain.StatusAwaitingApproval
+	StatusDelivered        = domain.StatusDelivered
+	StatusRead             = domain.StatusRead
+	StatusBounced          = domain.StatusBounced
+	StatusExpired          = domain.StatusExpired
+	StatusSuppressed       = domain.StatusSuppressed
+	StatusDraft            = domain.StatusDraft
+)
+
+// Ошибки сервиса для errors.Is.
+var (
+	ErrNotFound                = domain.ErrNotFound
+	ErrInvalidChannel          = domain.ErrInvalidChannel
+	ErrEmptyRecipient          = domain.ErrEmptyRecipient
+	ErrInvalidRecipient        = domain.ErrInvalidRecipient
+	ErrInvalidPayload          = domain.ErrInvalidPayload
+	ErrScheduledTooFarInPast   = domain.ErrScheduledTooFarInPast
+	ErrScheduledTooFarInFuture = domain.ErrScheduledTooFarInFuture
+	ErrDuplicateIdempotencyKey = domain.ErrDuplicateIdempotencyKey
+	ErrOverloaded              = domain.ErrOverloaded
+	ErrPreconditionFailed      = domain.ErrPreconditionFailed
+)
+
+// NewSMTPSender создает отправщика email через SMTP с пулом из poolSize соединений (0 — одно).
+func NewSMTPSender(host string, port int, username, password, from string, useTLS bool,
+	poolSize int) (*SMTPSender, error) {
+	return emailsender.NewSMTPSender(host, port, username, password, from, useTLS,
+		emailsender.WithPoolSize(poolSize))
+}
+
+// NewBotSender создает отправщика в Telegram через Bot API.
+func NewBotSender(token string) (*BotSender, error) {
+	return telegramsender.NewBotSender(token)
+}
+
+// NewTwilioSender создает отправщика SMS через Twilio.
+func NewTwilioSender(accountSID, authToken, from string) (*TwilioSender, error) {
+	return smssender.NewTwilioSender(accountSID, authToken, from)
+}
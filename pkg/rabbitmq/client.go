@@ -216,6 +216,20 @@ func (c *RabbitClient) DeclareExchange(name, kind string, durable, autoDelete,
 	return ch.ExchangeDeclare(name, kind, durable, autoDelete, internal, false, args)
 }
 
+// DeleteQueue удаляет очередь вместе с сообщениями и возвращает их число.
+// Отсутствующая очередь не является ошибкой.
+func (c *RabbitClient) DeleteQueue(name string) (int, error) {
+	ch, err := c.GetChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer func(ch *amqp091.Channel) {
+		_ = ch.Close()
+	}(ch)
+
+	return ch.QueueDelete(name, false, false, false)
+}
+
 // DeclareQueue объявляет очередь и привязывает её к exchange.
 func (c *RabbitClient) DeclareQueue(
 	queueName, exchangeName, routingKey string,
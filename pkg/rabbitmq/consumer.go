@@ -3,7 +3,9 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 	"github.com/wb-go/wbf/zlog"
@@ -14,6 +16,7 @@ type Consumer struct {
 	client  *RabbitClient
 	config  ConsumerConfig
 	handler MessageHandler
+	tuner   *PrefetchTuner
 }
 
 // NewConsumer конструктор Consumer.
@@ -24,11 +27,15 @@ func NewConsumer(client *RabbitClient, cfg ConsumerConfig, handler MessageHandle
 	if cfg.Workers <= 0 {
 		cfg.Workers = 1
 	}
-	return &Consumer{
+	c := &Consumer{
 		client:  client,
 		config:  cfg,
 		handler: handler,
 	}
+	if cfg.Adaptive != nil && cfg.Adaptive.Interval > 0 {
+		c.tuner = NewPrefetchTuner(*cfg.Adaptive, cfg.PrefetchCount)
+	}
+	return c
 }
 
 // Start запуск чтения сообщений.
@@ -63,8 +70,13 @@ func (c *Consumer) consumeOnce(ctx context.Context) error {
 		_ = ch.Close()
 	}(ch)
 
-	if c.config.PrefetchCount > 0 {
-		if err := ch.Qos(c.config.PrefetchCount, 0, false); err != nil {
+	prefetch := c.config.PrefetchCount
+	if c.tuner != nil {
+		// после переподключения продолжаем с подобранного значения
+		prefetch = c.tuner.Current()
+	}
+	if prefetch > 0 {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
 			return fmt.Errorf("failed to set QoS: %w", err)
 		}
 	}
@@ -86,6 +98,13 @@ func (c *Consumer) consumeOnce(ctx context.Context) error {
 	defer cancel()
 
 	var wg sync.WaitGroup
+	if c.tuner != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.tunePrefetch(workerCtx, ch)
+		}()
+	}
 	for i := 0; i < c.config.Workers; i++ {
 		wg.Add(1)
 		go func() {
@@ -106,9 +125,59 @@ func (c *Consumer) consumeOnce(ctx context.Context) error {
 	}
 }
 
+// safeHandle вызывает обработчик и превращает панику в ошибку, чтобы сообщение
+// получило NACK, а горутина воркера продолжила работу.
+func (c *Consumer) safeHandle(ctx context.Context, msg amqp091.Delivery) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			zlog.Logger.Error().
+				Str("consumer", c.config.ConsumerTag).
+				Str("message_id", msg.MessageId).
+				Interface("panic", rec).
+				Bytes("stack", debug.Stack()).
+				Msg("handler panic recovered")
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, rec)
+		}
+	}()
+	return c.handler(ctx, msg)
+}
+
+// tunePrefetch раз в интервал применяет к каналу prefetch, подобранный по обработанным сообщениям.
+func (c *Consumer) tunePrefetch(ctx context.Context, ch *amqp091.Channel) {
+	ticker := time.NewTicker(c.config.Adaptive.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prefetch, changed := c.tuner.Next()
+			if !changed {
+				continue
+			}
+			if err := ch.Qos(prefetch, 0, false); err != nil {
+				zlog.Logger.Warn().Err(err).Str("consumer", c.config.ConsumerTag).Msg("failed to update QoS")
+				continue
+			}
+			zlog.Logger.Debug().Str("consumer", c.config.ConsumerTag).Int("prefetch", prefetch).Msg("prefetch adjusted")
+		}
+	}
+}
+
+// handle вызывает обработчик и учитывает время и результат обработки для подбора prefetch.
+func (c *Consumer) handle(ctx context.Context, msg amqp091.Delivery) error {
+	if c.tuner == nil {
+		return c.safeHandle(ctx, msg)
+	}
+	start := time.Now()
+	err := c.safeHandle(ctx, msg)
+	c.tuner.Observe(time.Since(start), err)
+	return err
+}
+
 func (c *Consumer) processDelivery(ctx context.Context, msg amqp091.Delivery) {
 	if c.config.AutoAck {
-		if err := c.handler(ctx, msg); err != nil {
+		if err := c.handle(ctx, msg); err != nil {
 			zlog.Logger.Warn().
 				Err(err).
 				Str("consumer", c.config.ConsumerTag).
@@ -118,7 +187,7 @@ func (c *Consumer) processDelivery(ctx context.Context, msg amqp091.Delivery) {
 	}
 
 	// Режим ручного подтверждения
-	if err := c.handler(ctx, msg); err != nil {
+	if err := c.handle(ctx, msg); err != nil {
 		if nackErr := msg.Nack(c.config.Nack.Multiple, c.config.Nack.Requeue); nackErr != nil {
 			zlog.Logger.Error().Err(nackErr).Msg("NACK failed")
 		}
@@ -12,4 +12,9 @@ var (
 	// ErrChannelClosedUnexpectedly возвращается, когда канал доставки сообщений
 	// был закрыт неожиданно (например, из-за потери соединения).
 	ErrChannelClosedUnexpectedly = errors.New("message channel closed unexpectedly")
+	// ErrTopologyMismatch возвращается, если часть топологии не удалось объявить
+	// или она не совпадает с описанием.
+	ErrTopologyMismatch = errors.New("rabbitmq topology mismatch")
+	// ErrHandlerPanic возвращается, если обработчик сообщения завершился паникой.
+	ErrHandlerPanic = errors.New("message handler panic")
 )
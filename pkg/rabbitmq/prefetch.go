@@ -0,0 +1,84 @@
+package rabbitmq
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptivePrefetch настройки автоматического подбора prefetch (AIMD): пока обработка укладывается
+// в TargetLatency и ошибок не больше MaxErrorRate, prefetch растет на 1 за интервал,
+// иначе уменьшается вдвое.
+type AdaptivePrefetch struct {
+	Min int
+	Max int
+	// TargetLatency допустимое среднее время обработки сообщения
+	TargetLatency time.Duration
+	// MaxErrorRate допустимая доля сообщений, завершившихся ошибкой (NACK), от 0 до 1
+	MaxErrorRate float64
+	// Interval период пересчета
+	Interval time.Duration
+}
+
+// PrefetchTuner считает задержку и ошибки обработки за интервал и подбирает prefetch.
+type PrefetchTuner struct {
+	cfg AdaptivePrefetch
+
+	mu       sync.Mutex
+	current  int
+	count    int
+	errors   int
+	duration time.Duration
+}
+
+// NewPrefetchTuner создает подборщик, начинающий с initial в пределах [Min, Max].
+func NewPrefetchTuner(cfg AdaptivePrefetch, initial int) *PrefetchTuner {
+	if cfg.Min <= 0 {
+		cfg.Min = 1
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	return &PrefetchTuner{cfg: cfg, current: min(max(initial, cfg.Min), cfg.Max)}
+}
+
+// Current возвращает текущее значение prefetch.
+func (t *PrefetchTuner) Current() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// Observe учитывает обработку одного сообщения.
+func (t *PrefetchTuner) Observe(d time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count++
+	t.duration += d
+	if err != nil {
+		t.errors++
+	}
+}
+
+// Next завершает интервал и возвращает новый prefetch; false, если значение не изменилось.
+// Интервал без сообщений ничего не меняет.
+func (t *PrefetchTuner) Next() (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.count == 0 {
+		return t.current, false
+	}
+	avg := t.duration / time.Duration(t.count)
+	errRate := float64(t.errors) / float64(t.count)
+	t.count, t.errors, t.duration = 0, 0, 0
+
+	next := t.current + 1
+	if avg > t.cfg.TargetLatency || errRate > t.cfg.MaxErrorRate {
+		next = t.current / 2
+	}
+	next = min(max(next, t.cfg.Min), t.cfg.Max)
+	if next == t.current {
+		return next, false
+	}
+	t.current = next
+	return next, true
+}
@@ -0,0 +1,155 @@
+package rabbitmq
+
+import (
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ExchangeSpec описание exchange.
+type ExchangeSpec struct {
+	Name       string
+	Kind       string
+	Durable    bool
+	AutoDelete bool
+	Internal   bool
+	Args       amqp091.Table
+}
+
+// QueueSpec описание очереди.
+type QueueSpec struct {
+	Name       string
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       amqp091.Table
+}
+
+// BindingSpec описание привязки очереди к exchange.
+type BindingSpec struct {
+	Queue      string
+	Exchange   string
+	RoutingKey string
+	Args       amqp091.Table
+}
+
+// Topology декларативное описание exchange, очередей и привязок.
+type Topology struct {
+	Exchanges []ExchangeSpec
+	Queues    []QueueSpec
+	Bindings  []BindingSpec
+}
+
+// TopologyItemState состояние элемента топологии после синхронизации или проверки.
+type TopologyItemState string
+
+const (
+	// TopologyItemOK элемент существует и совпадает с описанием.
+	TopologyItemOK TopologyItemState = "ok"
+	// TopologyItemDeclared элемент объявлен (idempotent).
+	TopologyItemDeclared TopologyItemState = "declared"
+	// TopologyItemMissing элемент отсутствует или не совпадает с описанием.
+	TopologyItemMissing TopologyItemState = "missing"
+	// TopologyItemFailed объявление элемента завершилось ошибкой.
+	TopologyItemFailed TopologyItemState = "failed"
+)
+
+// TopologyItem результат по одному элементу топологии.
+type TopologyItem struct {
+	Kind  string // exchange, queue, binding
+	Name  string
+	State TopologyItemState
+	Error string
+}
+
+// TopologyReport результат синхронизации или проверки топологии.
+type TopologyReport struct {
+	Items []TopologyItem
+}
+
+// OK возвращает true, если все элементы в порядке.
+func (r TopologyReport) OK() bool {
+	for _, item := range r.Items {
+		if item.State == TopologyItemMissing || item.State == TopologyItemFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyTopology объявляет все exchange, очереди и привязки (idempotent).
+// Каждый элемент объявляется в своем канале, так как ошибка AMQP закрывает канал.
+func (c *RabbitClient) ApplyTopology(t Topology) (TopologyReport, error) {
+	var report TopologyReport
+	for _, ex := range t.Exchanges {
+		err := c.withChannel(func(ch *amqp091.Channel) error {
+			return ch.ExchangeDeclare(ex.Name, ex.Kind, ex.Durable, ex.AutoDelete, ex.Internal, false, ex.Args)
+		})
+		report.add("exchange", ex.Name, TopologyItemDeclared, err)
+	}
+	for _, q := range t.Queues {
+		err := c.withChannel(func(ch *amqp091.Channel) error {
+			_, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.Args)
+			return err
+		})
+		report.add("queue", q.Name, TopologyItemDeclared, err)
+	}
+	for _, b := range t.Bindings {
+		err := c.withChannel(func(ch *amqp091.Channel) error {
+			return ch.QueueBind(b.Queue, b.RoutingKey, b.Exchange, false, b.Args)
+		})
+		report.add("binding", bindingName(b), TopologyItemDeclared, err)
+	}
+	if !report.OK() {
+		return report, ErrTopologyMismatch
+	}
+	return report, nil
+}
+
+// VerifyTopology проверяет наличие exchange и очередей пассивным объявлением,
+// ничего не создавая. Привязки через AMQP проверить нельзя, они не включаются в отчет.
+func (c *RabbitClient) VerifyTopology(t Topology) (TopologyReport, error) {
+	var report TopologyReport
+	for _, ex := range t.Exchanges {
+		err := c.withChannel(func(ch *amqp091.Channel) error {
+			return ch.ExchangeDeclarePassive(ex.Name, ex.Kind, ex.Durable, ex.AutoDelete, ex.Internal, false, ex.Args)
+		})
+		report.add("exchange", ex.Name, TopologyItemOK, err)
+	}
+	for _, q := range t.Queues {
+		err := c.withChannel(func(ch *amqp091.Channel) error {
+			_, err := ch.QueueDeclarePassive(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.Args)
+			return err
+		})
+		report.add("queue", q.Name, TopologyItemOK, err)
+	}
+	if !report.OK() {
+		return report, ErrTopologyMismatch
+	}
+	return report, nil
+}
+
+func (r *TopologyReport) add(kind, name string, okState TopologyItemState, err error) {
+	item := TopologyItem{Kind: kind, Name: name, State: okState}
+	if err != nil {
+		item.State = TopologyItemFailed
+		if okState == TopologyItemOK {
+			item.State = TopologyItemMissing
+		}
+		item.Error = err.Error()
+	}
+	r.Items = append(r.Items, item)
+}
+
+func (c *RabbitClient) withChannel(fn func(ch *amqp091.Channel) error) error {
+	ch, err := c.GetChannel()
+	if err != nil {
+		return err
+	}
+	defer func(ch *amqp091.Channel) {
+		_ = ch.Close()
+	}(ch)
+	return fn(ch)
+}
+
+func bindingName(b BindingSpec) string {
+	return b.Exchange + " -> " + b.Queue + " (" + b.RoutingKey + ")"
+}
@@ -52,6 +52,9 @@ type ConsumerConfig struct {
 	Args          amqp091.Table
 	Workers       int
 	PrefetchCount int
+	// Adaptive подбирает prefetch по задержке и ошибкам обработки, начиная с PrefetchCount;
+	// nil оставляет PrefetchCount постоянным
+	Adaptive *AdaptivePrefetch
 }
 
 // AskConfig - настройки Ask.
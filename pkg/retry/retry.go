@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -11,6 +12,36 @@ type Strategy struct {
 	Backoff  float64       // Множитель для увеличения задержки.
 }
 
+// Stop оборачивает ошибку, после которой повторять попытки бессмысленно:
+// Do и DoContext сразу возвращают исходную ошибку.
+func Stop(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stopError{err: err}
+}
+
+type stopError struct {
+	err error
+}
+
+func (e *stopError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stopError) Unwrap() error {
+	return e.err
+}
+
+// stopped возвращает исходную ошибку, если она обернута Stop.
+func stopped(err error) (error, bool) {
+	var s *stopError
+	if errors.As(err, &s) {
+		return s.err, true
+	}
+	return err, false
+}
+
 // Do выполняет функцию с заданной стратегией повторных попыток.
 func Do(fn func() error, strategy Strategy) error {
 	delay := strategy.Delay
@@ -20,6 +51,9 @@ func Do(fn func() error, strategy Strategy) error {
 		if err == nil {
 			return nil
 		}
+		if orig, ok := stopped(err); ok {
+			return orig
+		}
 		time.Sleep(delay)
 		delay = time.Duration(float64(delay) * strategy.Backoff)
 	}
@@ -36,6 +70,9 @@ func DoContext(ctx context.Context, strategy Strategy, fn func() error) error {
 		if err == nil {
 			return nil
 		}
+		if orig, ok := stopped(err); ok {
+			return orig
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
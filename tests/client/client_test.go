@@ -0,0 +1,189 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/delivery/handlers"
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/pkg/client"
+	"DelayedNotifier/pkg/retry"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeService реализует только то, что вызывают обработчики клиента; остальные методы
+// интерфейса паникуют на nil.
+type fakeService struct {
+	domain.NotificationService
+
+	mu            sync.Mutex
+	notifications map[uuid.UUID]*domain.Notification
+	byKey         map[string]uuid.UUID
+	creates       int
+	lastFilter    domain.ListFilter
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{notifications: map[uuid.UUID]*domain.Notification{}, byKey: map[string]uuid.UUID{}}
+}
+
+func (f *fakeService) CreateNotification(_ context.Context,
+	p domain.CreateNotificationParams) (*domain.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.creates++
+	if id, ok := f.byKey[p.Source+"/"+p.IdempotencyKey]; ok {
+		return f.notifications[id], nil
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	n := &domain.Notification{ID: uuid.New(), Recipient: p.Recipient, Channel: p.Channel, Payload: p.Payload,
+		ScheduledAt: p.ScheduledAt, Status: domain.StatusPending, Source: p.Source, CreatedAt: now,
+		UpdatedAt: now, EffectiveScheduledAt: p.ScheduledAt}
+	f.notifications[n.ID] = n
+	f.byKey[p.Source+"/"+p.IdempotencyKey] = n.ID
+	return n, nil
+}
+
+func (f *fakeService) GetNotificationByID(_ context.Context, id uuid.UUID) (*domain.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.notifications[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return n, nil
+}
+
+func (f *fakeService) Cancel(ctx context.Context, id uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := f.notifications[id]
+	if match := domain.PreconditionFromContext(ctx); match != nil && !match(n) {
+		return domain.ErrPreconditionFailed
+	}
+	n.Status, n.UpdatedAt = domain.StatusCancelled, n.UpdatedAt.Add(time.Second)
+	return nil
+}
+
+func (f *fakeService) ListNotifications(_ context.Context, filter domain.ListFilter, limit,
+	offset int) ([]domain.Notification, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastFilter = filter
+	var ns []domain.Notification
+	for _, n := range f.notifications {
+		ns = append(ns, *n)
+	}
+	return ns, false, nil
+}
+
+// newServer поднимает настоящие обработчики API. Первые lost запросов POST /notify доходят
+// до сервиса, но клиент вместо ответа получает 503, как от балансировщика.
+func newServer(t *testing.T, svc *fakeService, lost int) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewHandlersSet(svc)
+	r := gin.New()
+	r.POST("/notify/", h.CreateNotificationHandler)
+	r.GET("/notify/", h.ListNotificationsHandler)
+	r.GET("/notify/:id", h.GetNotificationHandler)
+	r.DELETE("/notify/:id", h.DeleteNotificationHandler)
+
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		drop := req.Method == http.MethodPost && lost > 0
+		if drop {
+			lost--
+		}
+		mu.Unlock()
+		if drop {
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		r.ServeHTTP(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var fastRetry = client.WithRetry(retry.Strategy{Attempts: 3, Delay: time.Millisecond, Backoff: 1})
+
+// TestClient_CreateRetry проверяет, что повтор после потерянного ответа идет с тем же ключом
+// идемпотентности и не создает второе уведомление
+func TestClient_CreateRetry(t *testing.T) {
+	svc := newFakeService()
+	c := client.New(newServer(t, svc, 1).URL, fastRetry)
+	at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	n, err := c.Create(context.Background(), client.CreateRequest{
+		Recipient:   "user@example.com",
+		Channel:     client.ChannelEmail,
+		Payload:     map[string]interface{}{"subject": "Заказ", "body": "Заказ 42 оформлен"},
+		ScheduledAt: at,
+		Source:      "billing",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, svc.creates)
+	assert.Len(t, svc.notifications, 1)
+	if assert.NotNil(t, n) {
+		assert.NotEqual(t, uuid.Nil, n.ID)
+		assert.Equal(t, "user@example.com", n.Recipient)
+		assert.Equal(t, "pending", n.Status)
+		assert.True(t, at.Equal(n.ScheduledAt))
+		assert.Equal(t, "Заказ 42 оформлен", n.Payload["body"])
+	}
+}
+
+// TestClient_GetCancelList проверяет разбор ответов чтения и условную отмену по ETag
+func TestClient_GetCancelList(t *testing.T) {
+	svc := newFakeService()
+	c := client.New(newServer(t, svc, 0).URL, fastRetry)
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, client.CreateRequest{Recipient: "12345", Channel: client.ChannelTelegram,
+		Payload: map[string]interface{}{"body": "привет"}, ScheduledAt: time.Now().Add(time.Hour),
+		Source: "shop", IdempotencyKey: "order-42"})
+	assert.NoError(t, err)
+
+	got, err := c.Get(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+	assert.Equal(t, "shop", got.Source)
+	assert.NotEmpty(t, got.ETag)
+
+	assert.NoError(t, c.CancelIfMatch(ctx, created.ID, got.ETag))
+	err = c.CancelIfMatch(ctx, created.ID, got.ETag)
+	assert.True(t, client.IsStatus(err, http.StatusPreconditionFailed), err)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	list, err := c.List(ctx, client.ListFilter{Channel: client.ChannelTelegram, ScheduledFrom: from, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, list.Limit)
+	assert.Len(t, list.Items, 1)
+	assert.Equal(t, "cancelled", list.Items[0].Status)
+	assert.Equal(t, domain.ChannelTelegram, svc.lastFilter.Channel)
+	assert.True(t, from.Equal(svc.lastFilter.ScheduledFrom))
+}
+
+// TestClient_ValidationError проверяет, что ошибка валидации не повторяется и несет поля
+func TestClient_ValidationError(t *testing.T) {
+	svc := newFakeService()
+	c := client.New(newServer(t, svc, 0).URL, fastRetry)
+
+	_, err := c.Create(context.Background(), client.CreateRequest{Channel: client.ChannelEmail,
+		Payload: map[string]interface{}{"body": "x"}, ScheduledAt: time.Now().Add(time.Hour)})
+	var apiErr *client.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Contains(t, apiErr.Fields, "Recipient")
+		assert.Contains(t, apiErr.Fields, "Source")
+	}
+	assert.Zero(t, svc.creates)
+}
@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"DelayedNotifier/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+	encoded, err := config.GenerateKey()
+	require.NoError(t, err)
+	key, err := config.ParseKey(encoded)
+	require.NoError(t, err)
+	return key
+}
+
+func TestEncryptValue_RoundTrip(t *testing.T) {
+	key := newKey(t)
+
+	value, err := config.EncryptValue(key, "smtp-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, config.EncryptedPrefix))
+	assert.NotContains(t, value, "smtp-secret")
+
+	plain, err := config.DecryptValue(key, value)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp-secret", plain)
+
+	plain, err = config.DecryptValue(key, "not-encrypted")
+	require.NoError(t, err)
+	assert.Equal(t, "not-encrypted", plain)
+}
+
+func TestDecryptValue_Errors(t *testing.T) {
+	value, err := config.EncryptValue(newKey(t), "token")
+	require.NoError(t, err)
+
+	_, err = config.DecryptValue(nil, value)
+	assert.ErrorIs(t, err, config.ErrNoConfigKey)
+
+	_, err = config.DecryptValue(newKey(t), value)
+	assert.Error(t, err)
+
+	_, err = config.DecryptValue(newKey(t), config.EncryptedPrefix+"%%%")
+	assert.Error(t, err)
+
+	_, err = config.ParseKey("c2hvcnQ=")
+	assert.ErrorIs(t, err, config.ErrInvalidConfigKey)
+}
+
+func TestDecryptSecrets(t *testing.T) {
+	key := newKey(t)
+	password, err := config.EncryptValue(key, "smtp-secret")
+	require.NoError(t, err)
+	token, err := config.EncryptValue(key, "123:bot-token")
+	require.NoError(t, err)
+
+	cfg := &config.Config{}
+	cfg.Email.Password = password
+	cfg.Email.Host = "smtp.example.com"
+	cfg.Telegram.Token = token
+
+	require.NoError(t, config.DecryptSecrets(cfg, key))
+	assert.Equal(t, "smtp-secret", cfg.Email.Password)
+	assert.Equal(t, "smtp.example.com", cfg.Email.Host)
+	assert.Equal(t, "123:bot-token", cfg.Telegram.Token)
+
+	cfg.SMS.AuthToken = password
+	err = config.DecryptSecrets(cfg, nil)
+	assert.ErrorIs(t, err, config.ErrNoConfigKey)
+	assert.Contains(t, err.Error(), "sms.authtoken")
+}
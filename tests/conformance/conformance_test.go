@@ -0,0 +1,760 @@
+package conformance_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/repository/pg"
+	"DelayedNotifier/internal/repository/rabbit"
+	email_sender "DelayedNotifier/internal/sender/email"
+	"DelayedNotifier/pkg/rabbitmq"
+	"DelayedNotifier/pkg/retry"
+	"DelayedNotifier/tests/conformance"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+const (
+	// testDSNEnv база PostgreSQL с примененными миграциями; таблица notifications очищается.
+	testDSNEnv = "DELAYED_NOTIFIER_TEST_DSN"
+	// testRabbitMQEnv AMQP URL брокера; каждый прогон объявляет топологию в своем namespace и удаляет ее.
+	testRabbitMQEnv = "DELAYED_NOTIFIER_TEST_RABBITMQ_URL"
+	// testSMTPEnv адрес SMTP MailHog (host:port), testMailHogAPIEnv его HTTP API;
+	// входящие MailHog очищаются.
+	testSMTPEnv       = "DELAYED_NOTIFIER_TEST_SMTP_ADDR"
+	testMailHogAPIEnv = "DELAYED_NOTIFIER_TEST_MAILHOG_API"
+)
+
+func TestPostgresRepo_Conformance(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	db, err := dbpg.New(dsn, nil, &dbpg.Options{MaxOpenConns: 2, MaxIdleConns: 1})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	conformance.RunRepositorySuite(t, func(t *testing.T) domain.NotificationRepository {
+		if _, err := db.Master.Exec(`TRUNCATE notifications CASCADE`); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return pg.NewPostgresRepo(db)
+	})
+}
+
+func TestRabbitPublisher_Conformance(t *testing.T) {
+	url := os.Getenv(testRabbitMQEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testRabbitMQEnv)
+	}
+	client, err := rabbitmq.NewClient(rabbitmq.ClientConfig{
+		URL:            url,
+		ConnectionName: "delayed-notifier-conformance",
+		PublishRetry:   retry.Strategy{Attempts: 3, Delay: 100 * time.Millisecond, Backoff: 2},
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	conformance.RunPublisherSuite(t, func(t *testing.T) (domain.MessageQueuePublisher, conformance.Receiver) {
+		names := rabbit.NewNames("conformance-"+uuid.NewString()[:8], "DelayedNotifier", "notification")
+		if _, err := rabbit.NewTopology(client, names).Sync(context.Background()); err != nil {
+			t.Fatalf("sync topology: %v", err)
+		}
+		ch, err := client.GetChannel()
+		if err != nil {
+			t.Fatalf("channel: %v", err)
+		}
+		t.Cleanup(func() {
+			for _, q := range []string{names.Queue, names.HighQueue, names.DeadLetterQueue} {
+				_, _ = ch.QueueDelete(q, false, false, false)
+			}
+			_ = ch.ExchangeDelete(names.Exchange, false, false)
+			_ = ch.ExchangeDelete(names.DeadLetterExchange, false, false)
+			_ = ch.Close()
+		})
+		deliveries, err := ch.Consume(names.Queue, "", true, false, false, false, nil)
+		if err != nil {
+			t.Fatalf("consume: %v", err)
+		}
+
+		return rabbit.NewPublisher(client, names, "application/json"), func(ctx context.Context) (uuid.UUID, error) {
+			select {
+			case d, ok := <-deliveries:
+				if !ok {
+					return uuid.Nil, fmt.Errorf("consumer channel closed")
+				}
+				_, id, err := domain.ParseJob(d.Body)
+				return id, err
+			case <-ctx.Done():
+				return uuid.Nil, ctx.Err()
+			}
+		}
+	})
+}
+
+func TestSMTPSender_Conformance(t *testing.T) {
+	addr, api := os.Getenv(testSMTPEnv), os.Getenv(testMailHogAPIEnv)
+	if addr == "" || api == "" {
+		t.Skipf("%s and %s are not set", testSMTPEnv, testMailHogAPIEnv)
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("%s: %v", testSMTPEnv, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("%s: %v", testSMTPEnv, err)
+	}
+	mailhog := &mailhogInbox{api: strings.TrimRight(api, "/")}
+
+	conformance.RunSenderSuite(t, func(t *testing.T) (domain.EmailSender, conformance.Inbox) {
+		mailhog.clear(t)
+		s, err := email_sender.NewSMTPSender(host, port, "", "", "conformance@delayed-notifier.test", false)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s, func() []conformance.SentMessage { return mailhog.messages(t) }
+	})
+}
+
+func TestMemoryRepo_Conformance(t *testing.T) {
+	conformance.RunRepositorySuite(t, func(t *testing.T) domain.NotificationRepository {
+		return &memoryRepo{rows: map[uuid.UUID]domain.Notification{}}
+	})
+}
+
+func TestMemoryPublisher_Conformance(t *testing.T) {
+	conformance.RunPublisherSuite(t, func(t *testing.T) (domain.MessageQueuePublisher, conformance.Receiver) {
+		pub := &memoryPublisher{out: make(chan uuid.UUID, 16)}
+		return pub, func(ctx context.Context) (uuid.UUID, error) {
+			select {
+			case id := <-pub.out:
+				return id, nil
+			case <-ctx.Done():
+				return uuid.Nil, ctx.Err()
+			}
+		}
+	})
+}
+
+func TestMemorySender_Conformance(t *testing.T) {
+	conformance.RunSenderSuite(t, func(t *testing.T) (domain.EmailSender, conformance.Inbox) {
+		s := &memorySender{}
+		return s, s.inbox
+	})
+}
+
+// memoryRepo эталонная реализация NotificationRepository в памяти.
+type memoryRepo struct {
+	mu   sync.Mutex
+	rows map[uuid.UUID]domain.Notification
+	// sentAt время перехода в sent, в Notification его нет
+	sentAt map[uuid.UUID]time.Time
+	// keys ключи идемпотентности: source + "\x00" + key -> id
+	keys map[string]uuid.UUID
+}
+
+func (r *memoryRepo) Create(_ context.Context, p domain.CreateParams) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := p.Source + "\x00" + p.IdempotencyKey
+	if _, ok := r.keys[key]; ok && p.IdempotencyKey != "" {
+		return nil, domain.ErrDuplicateIdempotencyKey
+	}
+	now := time.Now()
+	effective := p.EffectiveScheduledAt
+	if effective.IsZero() {
+		effective = p.ScheduledAt
+	}
+	n := domain.Notification{
+		ID:                   uuid.New(),
+		Recipient:            p.Recipient,
+		Channel:              p.Channel,
+		Payload:              p.Payload,
+		ScheduledAt:          p.ScheduledAt,
+		Status:               p.Status,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+		ParentID:             p.ParentID,
+		CorrelationID:        p.CorrelationID,
+		CancelOnConfirm:      p.CancelOnConfirm,
+		PreSendCheck:         p.PreSendCheck,
+		Source:               p.Source,
+		RequiresApproval:     p.RequiresApproval,
+		EffectiveScheduledAt: effective,
+		GroupID:              p.GroupID,
+		TemplateID:           p.TemplateID,
+	}
+	r.rows[n.ID] = n
+	if p.IdempotencyKey != "" {
+		if r.keys == nil {
+			r.keys = make(map[string]uuid.UUID)
+		}
+		r.keys[key] = n.ID
+	}
+	return &n, nil
+}
+
+func (r *memoryRepo) CreateBatch(ctx context.Context, params []domain.CreateParams) ([]*domain.Notification, error) {
+	res := make([]*domain.Notification, 0, len(params))
+	for _, p := range params {
+		n, err := r.Create(ctx, p)
+		if errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			n, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+func (r *memoryRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	n, err := r.GetByIDWithDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n.DeletedAt != nil {
+		return nil, domain.ErrNotFound
+	}
+	return n, nil
+}
+
+func (r *memoryRepo) GetByIdempotencyKey(ctx context.Context, source, key string) (*domain.Notification, error) {
+	r.mu.Lock()
+	id, ok := r.keys[source+"\x00"+key]
+	r.mu.Unlock()
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *memoryRepo) Update(_ context.Context, id uuid.UUID, opts ...domain.UpdateOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.rows[id]
+	if !ok || n.DeletedAt != nil {
+		return domain.ErrNoRowAffected
+	}
+	var p domain.UpdateParams
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.IfUpdatedAt != nil && !n.UpdatedAt.Equal(*p.IfUpdatedAt) {
+		return domain.ErrNoRowAffected
+	}
+	if p.Status != nil {
+		n.Status = *p.Status
+	}
+	if p.ScheduledAt != nil {
+		n.ScheduledAt = *p.ScheduledAt
+	}
+	if p.EffectiveScheduledAt != nil {
+		n.EffectiveScheduledAt = *p.EffectiveScheduledAt
+	}
+	if p.Channel != nil {
+		n.Channel = *p.Channel
+	}
+	if p.Recipient != nil {
+		n.Recipient = *p.Recipient
+	}
+	if p.Payload != nil && p.Payload.Set {
+		n.Payload = p.Payload.Value
+	}
+	if p.RetryCountInc != nil && *p.RetryCountInc {
+		n.RetryCount++
+	}
+	n.UpdatedAt = time.Now()
+	if p.UpdatedAt != nil {
+		n.UpdatedAt = *p.UpdatedAt
+	}
+	if p.Status != nil && *p.Status == domain.StatusSent {
+		if r.sentAt == nil {
+			r.sentAt = make(map[uuid.UUID]time.Time)
+		}
+		r.sentAt[id] = n.UpdatedAt
+	}
+	r.rows[id] = n
+	return nil
+}
+
+func (r *memoryRepo) ListPendingAndProcessingBefore(_ context.Context, t time.Time,
+	limit, offset int) ([]domain.Notification, error) {
+	return r.list(func(n domain.Notification) bool {
+		return (n.Status == domain.StatusPending && !n.EffectiveScheduledAt.After(t)) ||
+			(n.Status == domain.StatusProcessing && n.UpdatedAt.Before(t))
+	}, limit, offset), nil
+}
+
+func (r *memoryRepo) ListPendingAndProcessingAfter(_ context.Context, t time.Time, after *domain.OrphanCursor,
+	limit int) ([]domain.Notification, error) {
+	stale := time.Now().Add(-10 * time.Minute)
+	res := r.list(func(n domain.Notification) bool {
+		if !(n.Status == domain.StatusPending && !n.EffectiveScheduledAt.After(t)) &&
+			!(n.Status == domain.StatusProcessing && n.UpdatedAt.Before(stale)) {
+			return false
+		}
+		return after == nil || n.EffectiveScheduledAt.After(after.EffectiveScheduledAt) ||
+			(n.EffectiveScheduledAt.Equal(after.EffectiveScheduledAt) && bytes.Compare(n.ID[:], after.ID[:]) > 0)
+	}, 0, 0)
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].EffectiveScheduledAt.Equal(res[j].EffectiveScheduledAt) {
+			return res[i].EffectiveScheduledAt.Before(res[j].EffectiveScheduledAt)
+		}
+		return bytes.Compare(res[i].ID[:], res[j].ID[:]) < 0
+	})
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (r *memoryRepo) ListPendingScheduledBetween(_ context.Context, from, to time.Time,
+	limit int) ([]domain.Notification, error) {
+	return r.list(func(n domain.Notification) bool {
+		return n.Status == domain.StatusPending && !n.EffectiveScheduledAt.Before(from) && n.EffectiveScheduledAt.Before(to)
+	}, limit, 0), nil
+}
+
+func (r *memoryRepo) CountBacklog(_ context.Context, t time.Time) (int, error) {
+	res := r.list(func(n domain.Notification) bool {
+		return (n.Status == domain.StatusPending || n.Status == domain.StatusProcessing) &&
+			n.EffectiveScheduledAt.Before(t)
+	}, 0, 0)
+	return len(res), nil
+}
+
+func (r *memoryRepo) CountProcessingBefore(_ context.Context, t time.Time) (int, error) {
+	res := r.list(func(n domain.Notification) bool {
+		return n.Status == domain.StatusProcessing && n.UpdatedAt.Before(t)
+	}, 0, 0)
+	return len(res), nil
+}
+
+func (r *memoryRepo) ListRelated(_ context.Context, rootID uuid.UUID) ([]domain.Notification, error) {
+	res := r.list(func(n domain.Notification) bool {
+		return n.RootID() == rootID
+	}, 0, 0)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].CreatedAt.Before(res[j].CreatedAt) })
+	return res, nil
+}
+
+func (r *memoryRepo) ListGroup(_ context.Context, groupID uuid.UUID) ([]domain.Notification, error) {
+	res := r.list(func(n domain.Notification) bool {
+		return n.GroupID != nil && *n.GroupID == groupID
+	}, 0, 0)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].CreatedAt.Before(res[j].CreatedAt) })
+	return res, nil
+}
+
+func (r *memoryRepo) ListDuplicateDeliveries(_ context.Context, since time.Time,
+	limit int) ([]domain.DuplicateGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rows []domain.Notification
+	for _, n := range r.rows {
+		switch n.Status {
+		case domain.StatusSent, domain.StatusDelivered, domain.StatusRead, domain.StatusBounced:
+			if sent, ok := r.sentAt[n.ID]; ok && !sent.Before(since) {
+				rows = append(rows, n)
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return r.sentAt[rows[i].ID].Before(r.sentAt[rows[j].ID]) })
+
+	index := map[string]int{}
+	var groups []domain.DuplicateGroup
+	for _, n := range rows {
+		payload, err := json.Marshal(n.Payload)
+		if err != nil {
+			return nil, err
+		}
+		hash := fmt.Sprintf("%x", md5.Sum(payload))
+		key := n.Recipient + "\x00" + n.Channel.String() + "\x00" + hash
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, domain.DuplicateGroup{Recipient: n.Recipient, Channel: n.Channel,
+				PayloadHash: hash, FirstAt: r.sentAt[n.ID]})
+		}
+		groups[i].LastAt = r.sentAt[n.ID]
+		groups[i].IDs = append(groups[i].IDs, n.ID)
+	}
+
+	var res []domain.DuplicateGroup
+	for _, g := range groups {
+		if len(g.IDs) > 1 {
+			res = append(res, g)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return len(res[i].IDs) > len(res[j].IDs) })
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// Search упрощенный поиск: фрагмент получателя или все слова запроса среди слов
+// получателя и строковых значений payload; ранг — число совпавших слов, среди равных новые первыми.
+func (r *memoryRepo) Search(_ context.Context, params domain.SearchParams) ([]domain.SearchHit, error) {
+	query := strings.ToLower(params.Query)
+	words := strings.Fields(query)
+	res := r.list(func(n domain.Notification) bool {
+		return (params.Channel == "" || n.Channel == params.Channel) &&
+			(params.Status == "" || n.Status == params.Status) && !n.CreatedAt.Before(params.Since)
+	}, 0, 0)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].CreatedAt.After(res[j].CreatedAt) })
+
+	var hits []domain.SearchHit
+	for _, n := range res {
+		text := strings.ToLower(strings.ReplaceAll(n.Recipient, "@", " "))
+		for _, v := range n.Payload {
+			if s, ok := v.(string); ok {
+				text += " " + strings.ToLower(s)
+			}
+		}
+		tokens := strings.Fields(text)
+		matched := 0
+		for _, w := range words {
+			if slices.Contains(tokens, w) {
+				matched++
+			}
+		}
+		if query == "" || matched == len(words) || strings.Contains(strings.ToLower(n.Recipient), query) {
+			hits = append(hits, domain.SearchHit{Notification: n, Rank: float64(matched)})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	if params.Limit > 0 && len(hits) > params.Limit {
+		hits = hits[:params.Limit]
+	}
+	return hits, nil
+}
+
+func (r *memoryRepo) CountOutcomesByChannel(_ context.Context, from,
+	to time.Time) (map[domain.Channel]domain.OutcomeCounts, error) {
+	counts := make(map[domain.Channel]domain.OutcomeCounts)
+	for _, n := range r.list(func(n domain.Notification) bool {
+		return !n.UpdatedAt.Before(from) && n.UpdatedAt.Before(to)
+	}, 0, 0) {
+		c := counts[n.Channel]
+		switch n.Status {
+		case domain.StatusSent, domain.StatusDelivered, domain.StatusRead:
+			c.Sent++
+		case domain.StatusFailed, domain.StatusBounced:
+			c.Failed++
+		default:
+			continue
+		}
+		counts[n.Channel] = c
+	}
+	return counts, nil
+}
+
+func (r *memoryRepo) CountSLA(_ context.Context, target domain.SLATarget, from,
+	to time.Time) (domain.SLACounts, error) {
+	var c domain.SLACounts
+	for _, n := range r.list(func(n domain.Notification) bool {
+		deadline := n.EffectiveScheduledAt.Add(target.Within)
+		return n.Channel == target.Channel && (target.Source == "" || n.Source == target.Source) &&
+			!deadline.Before(from) && deadline.Before(to)
+	}, 0, 0) {
+		switch n.Status {
+		case domain.StatusCancelled, domain.StatusDraft, domain.StatusAwaitingApproval, domain.StatusSuppressed:
+			continue
+		}
+		c.Total++
+		r.mu.Lock()
+		sent, ok := r.sentAt[n.ID]
+		r.mu.Unlock()
+		if ok && !sent.After(n.EffectiveScheduledAt.Add(target.Within)) {
+			c.Met++
+		}
+	}
+	return c, nil
+}
+
+func (r *memoryRepo) List(_ context.Context, filter domain.ListFilter, limit, offset int) ([]domain.Notification, error) {
+	res := r.listRows(filter.IncludeDeleted, func(n domain.Notification) bool {
+		return (filter.Status == "" || n.Status == filter.Status) &&
+			(filter.Channel == "" || n.Channel == filter.Channel) &&
+			(filter.Recipient == "" || n.Recipient == filter.Recipient) &&
+			!n.ScheduledAt.Before(filter.ScheduledFrom) &&
+			(filter.ScheduledTo.IsZero() || n.ScheduledAt.Before(filter.ScheduledTo))
+	}, 0, 0)
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].ScheduledAt.Equal(res[j].ScheduledAt) {
+			return res[i].ScheduledAt.After(res[j].ScheduledAt)
+		}
+		return res[i].ID.String() > res[j].ID.String()
+	})
+	if offset > 0 {
+		res = res[min(offset, len(res)):]
+	}
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res, nil
+}
+
+func (r *memoryRepo) Reschedule(ctx context.Context, params domain.RescheduleParams) ([]domain.Notification, error) {
+	filter := params.Filter
+	filter.Status = domain.StatusPending
+	res, err := r.List(ctx, filter, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].EffectiveScheduledAt.Equal(res[j].EffectiveScheduledAt) {
+			return res[i].EffectiveScheduledAt.Before(res[j].EffectiveScheduledAt)
+		}
+		return res[i].ID.String() < res[j].ID.String()
+	})
+	if params.Limit > 0 {
+		res = res[:min(params.Limit, len(res))]
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range res {
+		n := &res[i]
+		if params.At != nil {
+			n.ScheduledAt, n.EffectiveScheduledAt = *params.At, *params.At
+		} else {
+			n.ScheduledAt = n.ScheduledAt.Add(params.Shift)
+			n.EffectiveScheduledAt = n.EffectiveScheduledAt.Add(params.Shift)
+		}
+		n.UpdatedAt = time.Now()
+		r.rows[n.ID] = *n
+	}
+	return res, nil
+}
+
+func (r *memoryRepo) list(match func(domain.Notification) bool, limit, offset int) []domain.Notification {
+	return r.listRows(false, match, limit, offset)
+}
+
+func (r *memoryRepo) listRows(includeDeleted bool, match func(domain.Notification) bool,
+	limit, offset int) []domain.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []domain.Notification
+	for _, n := range r.rows {
+		if (includeDeleted || n.DeletedAt == nil) && match(n) {
+			res = append(res, n)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].EffectiveScheduledAt.Before(res[j].EffectiveScheduledAt) })
+	if offset > 0 {
+		res = res[min(offset, len(res)):]
+	}
+	if limit > 0 {
+		res = res[:min(limit, len(res))]
+	}
+	return res
+}
+
+func (r *memoryRepo) PendingToProcess(_ context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.rows[id]
+	if !ok || n.DeletedAt != nil || n.Status != domain.StatusPending {
+		return false, nil
+	}
+	n.Status = domain.StatusProcessing
+	r.rows[id] = n
+	return true, nil
+}
+
+func (r *memoryRepo) Approve(_ context.Context, id uuid.UUID, approver string, status domain.Status) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.rows[id]
+	if !ok || n.DeletedAt != nil || n.Status != domain.StatusAwaitingApproval {
+		return false, nil
+	}
+	now := time.Now()
+	n.Status, n.ApprovedBy, n.ApprovedAt = status, approver, &now
+	n.UpdatedAt = now
+	r.rows[id] = n
+	return true, nil
+}
+
+func (r *memoryRepo) FailedToProcess(_ context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.rows[id]
+	if !ok || n.DeletedAt != nil || n.Status != domain.StatusFailed {
+		return false, nil
+	}
+	n.Status = domain.StatusProcessing
+	n.RetryCount = 0
+	n.ReprocessCount++
+	n.UpdatedAt = time.Now()
+	r.rows[id] = n
+	return true, nil
+}
+
+func (r *memoryRepo) ListFailedBefore(_ context.Context, t time.Time, maxCycles, limit int) ([]domain.Notification, error) {
+	return r.list(func(n domain.Notification) bool {
+		return n.Status == domain.StatusFailed && n.UpdatedAt.Before(t) && n.ReprocessCount < maxCycles
+	}, limit, 0), nil
+}
+
+func (r *memoryRepo) IncRetryCount(ctx context.Context, id uuid.UUID) error {
+	return r.Update(ctx, id, domain.WithRetryCountInc())
+}
+
+func (r *memoryRepo) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rows[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.rows, id)
+	return nil
+}
+
+func (r *memoryRepo) SoftDelete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.rows[id]
+	if !ok || n.DeletedAt != nil {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	n.DeletedAt = &now
+	r.rows[id] = n
+	return nil
+}
+
+func (r *memoryRepo) GetByIDWithDeleted(_ context.Context, id uuid.UUID) (*domain.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.rows[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &n, nil
+}
+
+func (r *memoryRepo) PurgeDeletedBefore(_ context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged int64
+	for id, n := range r.rows {
+		if n.DeletedAt != nil && n.DeletedAt.Before(t) {
+			delete(r.rows, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// memoryPublisher доставляет ID в канал по истечении TTL.
+type memoryPublisher struct {
+	out chan uuid.UUID
+}
+
+func (p *memoryPublisher) Publish(_ context.Context, id uuid.UUID, ttl time.Duration) error {
+	time.AfterFunc(ttl, func() { p.out <- id })
+	return nil
+}
+
+// memorySender складывает письма во входящие.
+type memorySender struct {
+	mu   sync.Mutex
+	msgs []conformance.SentMessage
+}
+
+func (s *memorySender) Send(ctx context.Context, n *domain.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	subject, _ := n.Payload["subject"].(string)
+	body, _ := n.Payload["body"].(string)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs = append(s.msgs, conformance.SentMessage{Recipient: n.Recipient, Subject: subject, Body: body})
+	return nil
+}
+
+func (s *memorySender) inbox() []conformance.SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]conformance.SentMessage(nil), s.msgs...)
+}
+
+// mailhogInbox читает входящие MailHog через его HTTP API.
+type mailhogInbox struct {
+	api string
+}
+
+func (m *mailhogInbox) clear(t *testing.T) {
+	req, err := http.NewRequest(http.MethodDelete, m.api+"/api/v1/messages", nil)
+	if err != nil {
+		t.Fatalf("mailhog: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("mailhog clear: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func (m *mailhogInbox) messages(t *testing.T) []conformance.SentMessage {
+	resp, err := http.Get(m.api + "/api/v2/messages")
+	if err != nil {
+		t.Fatalf("mailhog messages: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var page struct {
+		Items []struct {
+			Raw struct {
+				To []string
+			}
+			Content struct {
+				Headers map[string][]string
+				Body    string
+			}
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("mailhog decode: %v", err)
+	}
+	msgs := make([]conformance.SentMessage, 0, len(page.Items))
+	for _, item := range page.Items {
+		msg := conformance.SentMessage{Body: strings.TrimSpace(item.Content.Body)}
+		if len(item.Raw.To) > 0 {
+			msg.Recipient = item.Raw.To[0]
+		}
+		if subject := item.Content.Headers["Subject"]; len(subject) > 0 {
+			msg.Subject = subject[0]
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
@@ -0,0 +1,60 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Receiver получает следующий доставленный ID уведомления или ошибку по истечении ctx.
+type Receiver func(ctx context.Context) (uuid.UUID, error)
+
+// NewPublisher создает издателя и функцию получения доставленных сообщений.
+type NewPublisher func(t *testing.T) (domain.MessageQueuePublisher, Receiver)
+
+// RunPublisherSuite проверяет семантику MessageQueuePublisher:
+// сообщение доставляется не раньше TTL и содержит исходный ID.
+func RunPublisherSuite(t *testing.T, newPublisher NewPublisher) {
+	t.Helper()
+
+	t.Run("DeliversAfterTTL", func(t *testing.T) {
+		pub, receive := newPublisher(t)
+		id := uuid.New()
+		ttl := 200 * time.Millisecond
+
+		start := time.Now()
+		mustNoError(t, pub.Publish(context.Background(), id, ttl), "Publish")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		got, err := receive(ctx)
+		mustNoError(t, err, "receive")
+		if got != id {
+			t.Fatalf("received %s, want %s", got, id)
+		}
+		if elapsed := time.Since(start); elapsed < ttl {
+			t.Fatalf("delivered after %s, before ttl %s", elapsed, ttl)
+		}
+	})
+
+	t.Run("KeepsOrderByTTL", func(t *testing.T) {
+		pub, receive := newPublisher(t)
+		late, early := uuid.New(), uuid.New()
+
+		mustNoError(t, pub.Publish(context.Background(), late, 600*time.Millisecond), "Publish late")
+		mustNoError(t, pub.Publish(context.Background(), early, 100*time.Millisecond), "Publish early")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		first, err := receive(ctx)
+		mustNoError(t, err, "receive")
+		second, err := receive(ctx)
+		mustNoError(t, err, "receive")
+		if first != early || second != late {
+			t.Fatalf("received %s then %s, want %s then %s", first, second, early, late)
+		}
+	})
+}
@@ -0,0 +1,727 @@
+// Package conformance содержит переиспользуемые наборы тестов, которые любая
+// реализация NotificationRepository, MessageQueuePublisher или EmailSender
+// запускает против себя, чтобы подтвердить ту же семантику, что и встроенные.
+//
+// Пример для нового хранилища:
+//
+//	func TestMySQLRepo(t *testing.T) {
+//		conformance.RunRepositorySuite(t, func(t *testing.T) domain.NotificationRepository {
+//			return newCleanMySQLRepo(t)
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/google/uuid"
+)
+
+// NewRepository создает пустое хранилище для одного подтеста.
+type NewRepository func(t *testing.T) domain.NotificationRepository
+
+// RunRepositorySuite проверяет семантику NotificationRepository.
+func RunRepositorySuite(t *testing.T, newRepo NewRepository) {
+	t.Helper()
+
+	t.Run("CreateThenGet", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		params := newCreateParams(time.Now().Add(time.Hour))
+		params.CancelOnConfirm = true
+		params.PreSendCheck = "https://example.com/check"
+		params.Source = "billing"
+
+		created, err := repo.Create(ctx, params)
+		mustNoError(t, err, "Create")
+		if created.ID == uuid.Nil {
+			t.Fatal("Create returned nil id")
+		}
+
+		got, err := repo.GetByID(ctx, created.ID)
+		mustNoError(t, err, "GetByID")
+		if got.Recipient != params.Recipient || got.Channel != params.Channel || got.Status != params.Status ||
+			got.CancelOnConfirm != params.CancelOnConfirm || got.PreSendCheck != params.PreSendCheck ||
+			got.Source != params.Source {
+			t.Fatalf("GetByID returned %+v, want fields of %+v", got, params)
+		}
+		if got.Payload["subject"] != params.Payload["subject"] {
+			t.Fatalf("payload not round-tripped: %v", got.Payload)
+		}
+		if !got.ScheduledAt.Equal(params.ScheduledAt) {
+			t.Fatalf("scheduled_at %v, want %v", got.ScheduledAt, params.ScheduledAt)
+		}
+		if !got.EffectiveScheduledAt.Equal(params.ScheduledAt) {
+			t.Fatalf("effective_scheduled_at %v, want scheduled_at %v by default", got.EffectiveScheduledAt, params.ScheduledAt)
+		}
+	})
+
+	t.Run("CreateBatchKeepsOrder", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		now := time.Now()
+		params := []domain.CreateParams{
+			newCreateParams(now.Add(time.Hour)),
+			newCreateParams(now.Add(2 * time.Hour)),
+			newCreateParams(now.Add(3 * time.Hour)),
+		}
+		for i := range params {
+			params[i].Recipient = fmt.Sprintf("batch-%d@example.com", i)
+		}
+
+		created, err := repo.CreateBatch(ctx, params)
+		mustNoError(t, err, "CreateBatch")
+		if len(created) != len(params) {
+			t.Fatalf("CreateBatch returned %d notifications, want %d", len(created), len(params))
+		}
+		for i, n := range created {
+			if n.ID == uuid.Nil || n.Recipient != params[i].Recipient {
+				t.Fatalf("item %d: got id %s recipient %q, want recipient %q", i, n.ID, n.Recipient, params[i].Recipient)
+			}
+			got, err := repo.GetByID(ctx, n.ID)
+			mustNoError(t, err, "GetByID")
+			if got.Recipient != params[i].Recipient || !got.ScheduledAt.Equal(params[i].ScheduledAt) {
+				t.Fatalf("item %d stored as %+v, want fields of %+v", i, got, params[i])
+			}
+		}
+	})
+
+	t.Run("IdempotencyKeyIsUniquePerSource", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		p := newCreateParams(time.Now().Add(time.Hour))
+		p.Source, p.IdempotencyKey = "billing", "order-42"
+
+		first, err := repo.Create(ctx, p)
+		mustNoError(t, err, "Create")
+		if _, err := repo.Create(ctx, p); !errors.Is(err, domain.ErrDuplicateIdempotencyKey) {
+			t.Fatalf("Create with the same key: got %v, want ErrDuplicateIdempotencyKey", err)
+		}
+		got, err := repo.GetByIdempotencyKey(ctx, "billing", "order-42")
+		mustNoError(t, err, "GetByIdempotencyKey")
+		if got.ID != first.ID {
+			t.Fatalf("GetByIdempotencyKey returned %s, want %s", got.ID, first.ID)
+		}
+
+		p.Source = "crm"
+		_, err = repo.Create(ctx, p)
+		mustNoError(t, err, "Create with the same key from another source")
+		if _, err := repo.GetByIdempotencyKey(ctx, "billing", "order-43"); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("GetByIdempotencyKey unknown key: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CreateBatchSkipsTakenKey", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		taken := newCreateParams(time.Now().Add(time.Hour))
+		taken.Source, taken.IdempotencyKey = "billing", "order-42"
+		_, err := repo.Create(ctx, taken)
+		mustNoError(t, err, "Create")
+
+		fresh := newCreateParams(time.Now().Add(time.Hour))
+		fresh.Source, fresh.IdempotencyKey = "billing", "order-43"
+		params := []domain.CreateParams{newCreateParams(time.Now().Add(time.Hour)), taken, fresh}
+		created, err := repo.CreateBatch(ctx, params)
+		mustNoError(t, err, "CreateBatch with a taken key")
+		if len(created) != len(params) {
+			t.Fatalf("CreateBatch returned %d notifications, want %d", len(created), len(params))
+		}
+		if created[0] == nil || created[1] != nil || created[2] == nil {
+			t.Fatalf("CreateBatch returned %v, want nil only for the taken key", created)
+		}
+		got, err := repo.GetByIdempotencyKey(ctx, "billing", "order-43")
+		mustNoError(t, err, "GetByIdempotencyKey")
+		if got.ID != created[2].ID {
+			t.Fatalf("GetByIdempotencyKey returned %s, want %s", got.ID, created[2].ID)
+		}
+	})
+
+	t.Run("RescheduleOnlyPending", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		now := time.Now().Truncate(time.Millisecond)
+		first := mustCreate(t, repo, now.Add(time.Hour))
+		second := mustCreate(t, repo, now.Add(2*time.Hour))
+		sent := mustCreate(t, repo, now.Add(30*time.Minute))
+		mustNoError(t, repo.Update(ctx, sent.ID, domain.WithStatus(domain.StatusProcessing)), "Update processing")
+		mustNoError(t, repo.Update(ctx, sent.ID, domain.WithStatus(domain.StatusSent)), "Update sent")
+
+		moved, err := repo.Reschedule(ctx, domain.RescheduleParams{
+			Filter: domain.ListFilter{ScheduledFrom: now}, Shift: 2 * time.Hour, Limit: 1})
+		mustNoError(t, err, "Reschedule shift")
+		if len(moved) != 1 || moved[0].ID != first.ID {
+			t.Fatalf("Reschedule with limit 1 moved %v, want only the earliest pending %s", moved, first.ID)
+		}
+		got, err := repo.GetByID(ctx, first.ID)
+		mustNoError(t, err, "GetByID")
+		if !got.ScheduledAt.Equal(now.Add(3*time.Hour)) || !got.EffectiveScheduledAt.Equal(now.Add(3*time.Hour)) {
+			t.Fatalf("shifted times %v/%v, want %v", got.ScheduledAt, got.EffectiveScheduledAt, now.Add(3*time.Hour))
+		}
+
+		at := now.Add(5 * time.Hour)
+		moved, err = repo.Reschedule(ctx, domain.RescheduleParams{
+			Filter: domain.ListFilter{ScheduledFrom: now}, At: &at})
+		mustNoError(t, err, "Reschedule at")
+		if len(moved) != 2 {
+			t.Fatalf("Reschedule moved %d notifications, want 2 pending", len(moved))
+		}
+		got, err = repo.GetByID(ctx, second.ID)
+		mustNoError(t, err, "GetByID")
+		if !got.ScheduledAt.Equal(at) {
+			t.Fatalf("scheduled_at %v, want %v", got.ScheduledAt, at)
+		}
+		got, err = repo.GetByID(ctx, sent.ID)
+		mustNoError(t, err, "GetByID sent")
+		if !got.ScheduledAt.Equal(now.Add(30 * time.Minute)) {
+			t.Fatalf("sent notification was rescheduled to %v", got.ScheduledAt)
+		}
+	})
+
+	t.Run("GetUnknownReturnsErrNotFound", func(t *testing.T) {
+		_, err := newRepo(t).GetByID(context.Background(), uuid.New())
+		mustBeError(t, err, domain.ErrNotFound, "GetByID unknown id")
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		created := mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		mustNoError(t, repo.Update(ctx, created.ID, domain.WithStatus(domain.StatusCancelled)), "Update")
+		got, err := repo.GetByID(ctx, created.ID)
+		mustNoError(t, err, "GetByID")
+		if got.Status != domain.StatusCancelled {
+			t.Fatalf("status %s, want %s", got.Status, domain.StatusCancelled)
+		}
+
+		err = repo.Update(ctx, uuid.New(), domain.WithStatus(domain.StatusCancelled))
+		mustBeError(t, err, domain.ErrNoRowAffected, "Update unknown id")
+	})
+
+	t.Run("UpdateDraftFields", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		created := mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		mustNoError(t, repo.Update(ctx, created.ID, domain.WithRecipient("other@example.com"),
+			domain.WithPayload(map[string]interface{}{"subject": "changed"})), "Update")
+		got, err := repo.GetByID(ctx, created.ID)
+		mustNoError(t, err, "GetByID")
+		if got.Recipient != "other@example.com" || got.Payload["subject"] != "changed" {
+			t.Fatalf("recipient %q payload %v not updated", got.Recipient, got.Payload)
+		}
+	})
+
+	t.Run("PendingToProcessOnce", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		created := mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		ok, err := repo.PendingToProcess(ctx, created.ID)
+		mustNoError(t, err, "PendingToProcess")
+		if !ok {
+			t.Fatal("first PendingToProcess must succeed")
+		}
+		ok, err = repo.PendingToProcess(ctx, created.ID)
+		mustNoError(t, err, "PendingToProcess")
+		if ok {
+			t.Fatal("second PendingToProcess must not change status")
+		}
+	})
+
+	t.Run("IncRetryCount", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		created := mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		mustNoError(t, repo.IncRetryCount(ctx, created.ID), "IncRetryCount")
+		got, err := repo.GetByID(ctx, created.ID)
+		mustNoError(t, err, "GetByID")
+		if got.RetryCount != created.RetryCount+1 {
+			t.Fatalf("retry_count %d, want %d", got.RetryCount, created.RetryCount+1)
+		}
+	})
+
+	t.Run("ListPendingScheduledBetween", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		now := time.Now()
+		soon := mustCreate(t, repo, now.Add(time.Minute))
+		mustCreate(t, repo, now.Add(time.Hour))
+
+		// сдвинутое сглаживанием за пределы интервала не попадает в выборку
+		smoothed := newCreateParams(now.Add(2 * time.Minute))
+		smoothed.EffectiveScheduledAt = smoothed.ScheduledAt.Add(time.Hour)
+		_, err := repo.Create(ctx, smoothed)
+		mustNoError(t, err, "Create smoothed")
+
+		list, err := repo.ListPendingScheduledBetween(ctx, now, now.Add(10*time.Minute), 10)
+		mustNoError(t, err, "ListPendingScheduledBetween")
+		if len(list) != 1 || list[0].ID != soon.ID {
+			t.Fatalf("got %d notifications, want only %s", len(list), soon.ID)
+		}
+	})
+
+	t.Run("ListPendingAndProcessingAfter", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		now := time.Now()
+		// одинаковое время: порядок страниц держится на id
+		due := now.Add(-time.Hour)
+		want := map[uuid.UUID]bool{}
+		for i := 0; i < 3; i++ {
+			want[mustCreate(t, repo, due).ID] = true
+		}
+		mustCreate(t, repo, now.Add(time.Hour))
+
+		var cursor *domain.OrphanCursor
+		seen := map[uuid.UUID]bool{}
+		for page := 0; page < 3; page++ {
+			list, err := repo.ListPendingAndProcessingAfter(ctx, now, cursor, 2)
+			mustNoError(t, err, "ListPendingAndProcessingAfter")
+			for _, n := range list {
+				if !want[n.ID] || seen[n.ID] {
+					t.Fatalf("page %d returned unexpected or repeated notification %s", page, n.ID)
+				}
+				seen[n.ID] = true
+			}
+			if len(list) < 2 {
+				break
+			}
+			last := list[len(list)-1]
+			cursor = &domain.OrphanCursor{EffectiveScheduledAt: last.EffectiveScheduledAt, ID: last.ID}
+		}
+		if len(seen) != len(want) {
+			t.Fatalf("pages returned %d notifications, want %d", len(seen), len(want))
+		}
+	})
+
+	t.Run("CountBacklog", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		now := time.Now()
+
+		due := mustCreate(t, repo, now.Add(-time.Minute))
+		mustCreate(t, repo, now.Add(-2*time.Minute))
+		mustCreate(t, repo, now.Add(time.Hour))
+		mustNoError(t, repo.Update(ctx, due.ID, domain.WithStatus(domain.StatusSent)), "Update")
+
+		count, err := repo.CountBacklog(ctx, now)
+		mustNoError(t, err, "CountBacklog")
+		if count != 1 {
+			t.Fatalf("CountBacklog = %d, want 1", count)
+		}
+	})
+
+	t.Run("CountProcessingBefore", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		n := mustCreate(t, repo, time.Now())
+		mustNoError(t, repo.Update(ctx, n.ID, domain.WithStatus(domain.StatusProcessing)), "Update")
+		mustCreate(t, repo, time.Now())
+
+		count, err := repo.CountProcessingBefore(ctx, time.Now().Add(time.Hour))
+		mustNoError(t, err, "CountProcessingBefore")
+		if count != 1 {
+			t.Fatalf("CountProcessingBefore = %d, want 1", count)
+		}
+		count, err = repo.CountProcessingBefore(ctx, time.Now().Add(-time.Hour))
+		mustNoError(t, err, "CountProcessingBefore")
+		if count != 0 {
+			t.Fatalf("CountProcessingBefore in the past = %d, want 0", count)
+		}
+	})
+
+	t.Run("ListDuplicateDeliveries", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		since := time.Now().Add(-time.Minute)
+
+		first := mustCreate(t, repo, time.Now())
+		second := mustCreate(t, repo, time.Now())
+		mustCreate(t, repo, time.Now())
+		other := newCreateParams(time.Now())
+		other.Payload = map[string]interface{}{"subject": "another"}
+		unique, err := repo.Create(ctx, other)
+		mustNoError(t, err, "Create")
+		for _, id := range []uuid.UUID{first.ID, second.ID, unique.ID} {
+			mustNoError(t, repo.Update(ctx, id, domain.WithStatus(domain.StatusSent)), "Update")
+		}
+
+		groups, err := repo.ListDuplicateDeliveries(ctx, since, 10)
+		mustNoError(t, err, "ListDuplicateDeliveries")
+		if len(groups) != 1 {
+			t.Fatalf("ListDuplicateDeliveries returned %d groups, want 1 (pending copy and unique payload excluded)",
+				len(groups))
+		}
+		g := groups[0]
+		if len(g.IDs) != 2 || g.Recipient != first.Recipient || g.PayloadHash == "" {
+			t.Fatalf("duplicate group %+v, want both sent copies of %s", g, first.Recipient)
+		}
+
+		groups, err = repo.ListDuplicateDeliveries(ctx, time.Now().Add(time.Hour), 10)
+		mustNoError(t, err, "ListDuplicateDeliveries")
+		if len(groups) != 0 {
+			t.Fatalf("ListDuplicateDeliveries after the window = %d groups, want 0", len(groups))
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		invoice := newCreateParams(time.Now())
+		invoice.Recipient = "bob@example.com"
+		invoice.Payload = map[string]interface{}{"subject": "Invoice 4521", "body": "Payment is due"}
+		want, err := repo.Create(ctx, invoice)
+		mustNoError(t, err, "Create")
+		mustCreate(t, repo, time.Now())
+
+		hits, err := repo.Search(ctx, domain.SearchParams{Query: "bob invoice 4521", Limit: 10})
+		mustNoError(t, err, "Search")
+		if len(hits) != 1 || hits[0].ID != want.ID {
+			t.Fatalf("Search by words returned %+v, want only %s", hits, want.ID)
+		}
+		hits, err = repo.Search(ctx, domain.SearchParams{Query: "bob@example", Limit: 10})
+		mustNoError(t, err, "Search")
+		if len(hits) != 1 || hits[0].ID != want.ID {
+			t.Fatalf("Search by recipient fragment returned %+v, want only %s", hits, want.ID)
+		}
+		hits, err = repo.Search(ctx, domain.SearchParams{Query: "invoice", Channel: domain.ChannelTelegram, Limit: 10})
+		mustNoError(t, err, "Search")
+		if len(hits) != 0 {
+			t.Fatalf("Search with another channel returned %d hits, want 0", len(hits))
+		}
+		hits, err = repo.Search(ctx, domain.SearchParams{Status: domain.StatusPending, Since: want.CreatedAt, Limit: 10})
+		mustNoError(t, err, "Search")
+		if len(hits) != 2 || hits[0].ID == want.ID {
+			t.Fatalf("Search by filters only returned %d hits, want both, newest first", len(hits))
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		base := time.Now().Add(time.Hour).Truncate(time.Second)
+		var ids []uuid.UUID
+		for i := 0; i < 3; i++ {
+			ids = append(ids, mustCreate(t, repo, base.Add(time.Duration(i)*time.Minute)).ID)
+		}
+		other := newCreateParams(base)
+		other.Channel = domain.ChannelTelegram
+		other.Recipient = "123456"
+		_, err := repo.Create(ctx, other)
+		mustNoError(t, err, "Create")
+		deleted := mustCreate(t, repo, base.Add(10*time.Minute))
+		mustNoError(t, repo.SoftDelete(ctx, deleted.ID), "SoftDelete")
+
+		filter := domain.ListFilter{Channel: domain.ChannelEmail}
+		page, err := repo.List(ctx, filter, 2, 0)
+		mustNoError(t, err, "List")
+		if len(page) != 2 || page[0].ID != ids[2] || page[1].ID != ids[1] {
+			t.Fatalf("List first page = %v, want the two latest email notifications, newest first", page)
+		}
+		page, err = repo.List(ctx, filter, 2, 2)
+		mustNoError(t, err, "List")
+		if len(page) != 1 || page[0].ID != ids[0] {
+			t.Fatalf("List second page = %v, want only %s", page, ids[0])
+		}
+
+		page, err = repo.List(ctx, domain.ListFilter{
+			ScheduledFrom: base.Add(time.Minute),
+			ScheduledTo:   base.Add(2 * time.Minute),
+		}, 0, 0)
+		mustNoError(t, err, "List")
+		if len(page) != 1 || page[0].ID != ids[1] {
+			t.Fatalf("List by scheduled_at range = %v, want only %s", page, ids[1])
+		}
+		page, err = repo.List(ctx, domain.ListFilter{Recipient: "123456", Status: domain.StatusPending}, 0, 0)
+		mustNoError(t, err, "List")
+		if len(page) != 1 || page[0].Channel != domain.ChannelTelegram {
+			t.Fatalf("List by recipient = %v, want the telegram notification", page)
+		}
+
+		page, err = repo.List(ctx, domain.ListFilter{Channel: domain.ChannelEmail, IncludeDeleted: true}, 1, 0)
+		mustNoError(t, err, "List")
+		if len(page) != 1 || page[0].ID != deleted.ID || page[0].DeletedAt == nil {
+			t.Fatalf("List with IncludeDeleted = %v, want the soft-deleted %s with deleted_at", page, deleted.ID)
+		}
+	})
+
+	t.Run("CountSLA", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		now := time.Now()
+		target := domain.SLATarget{Channel: domain.ChannelEmail, Objective: 0.95, Within: time.Hour}
+
+		onTime := mustCreate(t, repo, now)
+		mustNoError(t, repo.Update(ctx, onTime.ID, domain.WithStatus(domain.StatusSent)), "Update")
+		mustCreate(t, repo, now) // еще не отправлено — срок истечет в окне, значит промах
+		cancelled := mustCreate(t, repo, now)
+		mustNoError(t, repo.Update(ctx, cancelled.ID, domain.WithStatus(domain.StatusCancelled)), "Update")
+		mustCreate(t, repo, now.Add(3*time.Hour)) // срок за пределами окна
+
+		counts, err := repo.CountSLA(ctx, target, now.Add(-time.Minute), now.Add(2*time.Hour))
+		mustNoError(t, err, "CountSLA")
+		if counts != (domain.SLACounts{Met: 1, Total: 2}) {
+			t.Fatalf("CountSLA = %+v, want 1 of 2 (cancelled and out-of-window excluded)", counts)
+		}
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		params := newCreateParams(time.Now().Add(time.Hour))
+		params.Status = domain.StatusAwaitingApproval
+		params.RequiresApproval = true
+		n, err := repo.Create(ctx, params)
+		mustNoError(t, err, "Create")
+
+		ok, err := repo.Approve(ctx, n.ID, "compliance", domain.StatusPending)
+		mustNoError(t, err, "Approve")
+		if !ok {
+			t.Fatal("Approve = false for notification awaiting approval")
+		}
+		ok, err = repo.Approve(ctx, n.ID, "someone else", domain.StatusPending)
+		mustNoError(t, err, "Approve again")
+		if ok {
+			t.Fatal("Approve = true for already approved notification")
+		}
+
+		got, err := repo.GetByID(ctx, n.ID)
+		mustNoError(t, err, "GetByID")
+		if got.Status != domain.StatusPending || !got.RequiresApproval || got.ApprovedBy != "compliance" ||
+			got.ApprovedAt == nil {
+			t.Fatalf("after Approve status=%s requires_approval=%v approved_by=%q approved_at=%v",
+				got.Status, got.RequiresApproval, got.ApprovedBy, got.ApprovedAt)
+		}
+	})
+
+	t.Run("FailedToProcess", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+
+		n := mustCreate(t, repo, time.Now())
+		mustNoError(t, repo.IncRetryCount(ctx, n.ID), "IncRetryCount")
+		mustNoError(t, repo.Update(ctx, n.ID, domain.WithStatus(domain.StatusFailed)), "Update")
+		mustCreate(t, repo, time.Now())
+
+		list, err := repo.ListFailedBefore(ctx, time.Now().Add(time.Hour), 1, 10)
+		mustNoError(t, err, "ListFailedBefore")
+		if len(list) != 1 || list[0].ID != n.ID {
+			t.Fatalf("ListFailedBefore returned %d notifications, want only %s", len(list), n.ID)
+		}
+
+		ok, err := repo.FailedToProcess(ctx, n.ID)
+		mustNoError(t, err, "FailedToProcess")
+		if !ok {
+			t.Fatal("FailedToProcess = false for failed notification")
+		}
+		ok, err = repo.FailedToProcess(ctx, n.ID)
+		mustNoError(t, err, "FailedToProcess again")
+		if ok {
+			t.Fatal("FailedToProcess = true for notification that is already processing")
+		}
+		got, err := repo.GetByID(ctx, n.ID)
+		mustNoError(t, err, "GetByID")
+		if got.Status != domain.StatusProcessing || got.RetryCount != 0 || got.ReprocessCount != 1 {
+			t.Fatalf("after FailedToProcess status=%s retry_count=%d reprocess_count=%d",
+				got.Status, got.RetryCount, got.ReprocessCount)
+		}
+
+		// исчерпавшие maxCycles не возвращаются
+		mustNoError(t, repo.Update(ctx, n.ID, domain.WithStatus(domain.StatusFailed)), "Update")
+		list, err = repo.ListFailedBefore(ctx, time.Now().Add(time.Hour), 1, 10)
+		mustNoError(t, err, "ListFailedBefore")
+		if len(list) != 0 {
+			t.Fatalf("ListFailedBefore returned %d notifications over maxCycles", len(list))
+		}
+	})
+
+	t.Run("ListRelated", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		root := mustCreate(t, repo, time.Now().Add(time.Hour))
+		mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		params := newCreateParams(time.Now().Add(time.Hour))
+		params.ParentID, params.CorrelationID = &root.ID, &root.ID
+		child, err := repo.Create(ctx, params)
+		mustNoError(t, err, "Create child")
+
+		got, err := repo.GetByID(ctx, child.ID)
+		mustNoError(t, err, "GetByID child")
+		if got.ParentID == nil || *got.ParentID != root.ID || got.CorrelationID == nil || *got.CorrelationID != root.ID {
+			t.Fatalf("parent/correlation not round-tripped: %v %v", got.ParentID, got.CorrelationID)
+		}
+
+		related, err := repo.ListRelated(ctx, root.ID)
+		mustNoError(t, err, "ListRelated")
+		if len(related) != 2 || related[0].ID != root.ID || related[1].ID != child.ID {
+			t.Fatalf("got %d related notifications, want root and child", len(related))
+		}
+	})
+
+	t.Run("IfUpdatedAt", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		n := mustCreate(t, repo, time.Now().Add(time.Hour))
+		read, err := repo.GetByID(ctx, n.ID)
+		mustNoError(t, err, "GetByID")
+
+		updatedAt := read.UpdatedAt.Add(time.Second).Truncate(time.Microsecond)
+		mustNoError(t, repo.Update(ctx, n.ID, domain.WithRecipient("first@example.com"),
+			domain.WithUpdatedAt(updatedAt), domain.WithIfUpdatedAt(read.UpdatedAt)), "Update with matching updated_at")
+		got, err := repo.GetByID(ctx, n.ID)
+		mustNoError(t, err, "GetByID after update")
+		if !got.UpdatedAt.Equal(updatedAt) {
+			t.Fatalf("updated_at = %v, want explicit %v", got.UpdatedAt, updatedAt)
+		}
+
+		err = repo.Update(ctx, n.ID, domain.WithRecipient("second@example.com"),
+			domain.WithIfUpdatedAt(read.UpdatedAt))
+		if !errors.Is(err, domain.ErrNoRowAffected) {
+			t.Fatalf("stale Update: got %v, want ErrNoRowAffected", err)
+		}
+		got, err = repo.GetByID(ctx, n.ID)
+		mustNoError(t, err, "GetByID after stale update")
+		if got.Recipient != "first@example.com" {
+			t.Fatalf("stale Update changed recipient to %q", got.Recipient)
+		}
+	})
+
+	t.Run("TemplateID", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		templateID := uuid.New()
+		at := time.Now().Add(time.Hour)
+		single, batched := newCreateParams(at), newCreateParams(at)
+		single.TemplateID, batched.TemplateID = &templateID, &templateID
+		a, err := repo.Create(ctx, single)
+		mustNoError(t, err, "Create with template")
+		created, err := repo.CreateBatch(ctx, []domain.CreateParams{batched, newCreateParams(at)})
+		mustNoError(t, err, "CreateBatch with template")
+
+		for _, id := range []uuid.UUID{a.ID, created[0].ID} {
+			got, err := repo.GetByID(ctx, id)
+			mustNoError(t, err, "GetByID templated")
+			if got.TemplateID == nil || *got.TemplateID != templateID {
+				t.Fatalf("template_id not round-tripped: %v", got.TemplateID)
+			}
+		}
+		got, err := repo.GetByID(ctx, created[1].ID)
+		mustNoError(t, err, "GetByID plain")
+		if got.TemplateID != nil {
+			t.Fatalf("template_id = %v, want nil", got.TemplateID)
+		}
+	})
+
+	t.Run("ListGroup", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		groupID := uuid.New()
+		at := time.Now().Add(time.Hour)
+		a, b := newCreateParams(at), newCreateParams(at)
+		a.GroupID, b.GroupID = &groupID, &groupID
+		b.Recipient = "other@example.com"
+		created, err := repo.CreateBatch(ctx, []domain.CreateParams{a, b})
+		mustNoError(t, err, "CreateBatch group")
+		mustCreate(t, repo, at)
+
+		got, err := repo.GetByID(ctx, created[0].ID)
+		mustNoError(t, err, "GetByID group member")
+		if got.GroupID == nil || *got.GroupID != groupID {
+			t.Fatalf("group_id not round-tripped: %v", got.GroupID)
+		}
+
+		group, err := repo.ListGroup(ctx, groupID)
+		mustNoError(t, err, "ListGroup")
+		if len(group) != 2 {
+			t.Fatalf("got %d group notifications, want 2", len(group))
+		}
+		mustNoError(t, repo.SoftDelete(ctx, created[1].ID), "SoftDelete group member")
+		group, err = repo.ListGroup(ctx, groupID)
+		mustNoError(t, err, "ListGroup after delete")
+		if len(group) != 1 || group[0].ID != created[0].ID {
+			t.Fatalf("got %d group notifications after delete, want 1", len(group))
+		}
+		empty, err := repo.ListGroup(ctx, uuid.New())
+		mustNoError(t, err, "ListGroup unknown")
+		if len(empty) != 0 {
+			t.Fatalf("unknown group returned %d notifications", len(empty))
+		}
+	})
+
+	t.Run("SoftDeleteHidesAndPurges", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		created := mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		mustNoError(t, repo.SoftDelete(ctx, created.ID), "SoftDelete")
+		_, err := repo.GetByID(ctx, created.ID)
+		mustBeError(t, err, domain.ErrNotFound, "GetByID after SoftDelete")
+		mustBeError(t, repo.SoftDelete(ctx, created.ID), domain.ErrNotFound, "second SoftDelete")
+
+		got, err := repo.GetByIDWithDeleted(ctx, created.ID)
+		mustNoError(t, err, "GetByIDWithDeleted")
+		if got.DeletedAt == nil {
+			t.Fatal("GetByIDWithDeleted must return deleted_at")
+		}
+
+		purged, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Minute))
+		mustNoError(t, err, "PurgeDeletedBefore")
+		if purged != 1 {
+			t.Fatalf("purged %d, want 1", purged)
+		}
+		_, err = repo.GetByIDWithDeleted(ctx, created.ID)
+		mustBeError(t, err, domain.ErrNotFound, "GetByIDWithDeleted after purge")
+	})
+
+	t.Run("HardDelete", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newRepo(t)
+		created := mustCreate(t, repo, time.Now().Add(time.Hour))
+
+		mustNoError(t, repo.Delete(ctx, created.ID), "Delete")
+		_, err := repo.GetByIDWithDeleted(ctx, created.ID)
+		mustBeError(t, err, domain.ErrNotFound, "GetByIDWithDeleted after Delete")
+		mustBeError(t, repo.Delete(ctx, created.ID), domain.ErrNotFound, "second Delete")
+	})
+}
+
+func newCreateParams(scheduledAt time.Time) domain.CreateParams {
+	return domain.CreateParams{
+		Recipient:   "conformance@example.com",
+		Channel:     domain.ChannelEmail,
+		Status:      domain.StatusPending,
+		Payload:     map[string]interface{}{"subject": "conformance"},
+		ScheduledAt: scheduledAt.UTC().Truncate(time.Microsecond),
+	}
+}
+
+func mustCreate(t *testing.T, repo domain.NotificationRepository, scheduledAt time.Time) *domain.Notification {
+	t.Helper()
+	n, err := repo.Create(context.Background(), newCreateParams(scheduledAt))
+	mustNoError(t, err, "Create")
+	return n
+}
+
+func mustNoError(t *testing.T, err error, op string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", op, err)
+	}
+}
+
+func mustBeError(t *testing.T, err, target error, op string) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: got error %v, want %v", op, err, target)
+	}
+}
@@ -0,0 +1,69 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SentMessage сообщение, принятое получающей стороной (например, тестовым SMTP).
+type SentMessage struct {
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Inbox возвращает сообщения, принятые с момента создания отправщика.
+type Inbox func() []SentMessage
+
+// NewSender создает отправщика и функцию чтения принятых сообщений.
+type NewSender func(t *testing.T) (domain.EmailSender, Inbox)
+
+// RunSenderSuite проверяет семантику EmailSender: доставку subject/body
+// получателю и отказ при отмененном контексте.
+func RunSenderSuite(t *testing.T, newSender NewSender) {
+	t.Helper()
+
+	t.Run("DeliversSubjectAndBody", func(t *testing.T) {
+		sender, inbox := newSender(t)
+		n := &domain.Notification{
+			ID:        uuid.New(),
+			Recipient: "conformance@example.com",
+			Channel:   domain.ChannelEmail,
+			Payload:   map[string]interface{}{"subject": "Hello", "body": "World"},
+		}
+
+		mustNoError(t, sender.Send(context.Background(), n), "Send")
+
+		msgs := inbox()
+		if len(msgs) != 1 {
+			t.Fatalf("inbox has %d messages, want 1", len(msgs))
+		}
+		if msgs[0].Recipient != n.Recipient || msgs[0].Subject != "Hello" || msgs[0].Body != "World" {
+			t.Fatalf("unexpected message %+v", msgs[0])
+		}
+	})
+
+	t.Run("CancelledContext", func(t *testing.T) {
+		sender, inbox := newSender(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+
+		err := sender.Send(ctx, &domain.Notification{
+			ID:        uuid.New(),
+			Recipient: "conformance@example.com",
+			Channel:   domain.ChannelEmail,
+			Payload:   map[string]interface{}{"subject": "Hello", "body": "World"},
+		})
+		if err == nil {
+			t.Fatal("Send with cancelled context must fail")
+		}
+		if len(inbox()) != 0 {
+			t.Fatal("message must not be delivered with cancelled context")
+		}
+	})
+}
@@ -0,0 +1,626 @@
+package delivery_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/delivery/handlers"
+	"DelayedNotifier/internal/delivery/middleware"
+	"DelayedNotifier/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTopologyManager мок для TopologyManager
+type MockTopologyManager struct {
+	mock.Mock
+}
+
+func (m *MockTopologyManager) Sync(ctx context.Context) (domain.TopologyReport, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.TopologyReport), args.Error(1)
+}
+
+func (m *MockTopologyManager) Check(ctx context.Context) (domain.TopologyReport, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.TopologyReport), args.Error(1)
+}
+
+// TestSyncTopologyHandler_Success проверяет успешную синхронизацию топологии
+func TestSyncTopologyHandler_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	topology := new(MockTopologyManager)
+	h := handlers.NewAdminHandlersSet(new(MockNotificationService), topology)
+
+	topology.On("Sync", mock.Anything).Return(domain.TopologyReport{
+		OK:    true,
+		Items: []domain.TopologyItem{{Kind: "queue", Name: "notification", State: "declared"}},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/admin/topology/sync", nil)
+
+	h.SyncTopologyHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Result handlers.TopologyResponse `json:"result"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Result.OK)
+	assert.Len(t, response.Result.Items, 1)
+
+	topology.AssertExpectations(t)
+}
+
+// TestCheckTopologyHandler_Missing проверяет ответ при отсутствующих элементах топологии
+func TestCheckTopologyHandler_Missing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	topology := new(MockTopologyManager)
+	h := handlers.NewAdminHandlersSet(new(MockNotificationService), topology)
+
+	topology.On("Check", mock.Anything).Return(domain.TopologyReport{
+		Items: []domain.TopologyItem{{Kind: "queue", Name: "notification.dlq", State: "missing", Error: "NOT_FOUND"}},
+	}, assert.AnError)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/topology", nil)
+
+	h.CheckTopologyHandler(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	topology.AssertExpectations(t)
+}
+
+// TestAdminAuthMiddleware проверяет доступ к административному API по ключу
+func TestAdminAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		apiKey   string
+		header   string
+		expected int
+	}{
+		{"disabled", "", "", http.StatusForbidden},
+		{"wrong key", "secret", "wrong", http.StatusUnauthorized},
+		{"valid key", "secret", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin/ping", middleware.AdminAuthMiddleware(tt.apiKey), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/admin/ping", nil)
+			req.Header.Set("X-Admin-Key", tt.header)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+// TestAdminDeleteNotificationHandler проверяет физическое удаление уведомления
+func TestAdminDeleteNotificationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	notificationID := uuid.New()
+
+	tests := []struct {
+		name       string
+		query      string
+		method     string
+		serviceErr error
+		expected   int
+	}{
+		{"hard delete", "?hard=true", "Delete", nil, http.StatusOK},
+		{"soft delete by default", "", "SoftDelete", nil, http.StatusOK},
+		{"not found", "?hard=true", "Delete", domain.ErrNotFound, http.StatusNotFound},
+		{"service error", "", "SoftDelete", assert.AnError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockNotificationService)
+			mockService.On(tt.method, mock.Anything, notificationID).Return(tt.serviceErr)
+			h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("DELETE", "/admin/notify/"+notificationID.String()+tt.query, nil)
+			c.Params = []gin.Param{{Key: "id", Value: notificationID.String()}}
+
+			h.DeleteNotificationHandler(c)
+
+			assert.Equal(t, tt.expected, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestAdminGetNotificationHandler_IncludeDeleted проверяет чтение мягко удаленного уведомления
+func TestAdminGetNotificationHandler_IncludeDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deletedAt := time.Now()
+	notification := &domain.Notification{
+		ID:        uuid.New(),
+		Recipient: "test@example.com",
+		Channel:   domain.ChannelEmail,
+		Status:    domain.StatusPending,
+		DeletedAt: &deletedAt,
+	}
+
+	mockService := new(MockNotificationService)
+	mockService.On("GetNotificationByIDWithDeleted", mock.Anything, notification.ID).Return(notification, nil)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/notify/"+notification.ID.String()+"?include_deleted=true", nil)
+	c.Params = []gin.Param{{Key: "id", Value: notification.ID.String()}}
+
+	h.GetNotificationHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.NotificationResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotNil(t, response.Result.DeletedAt)
+	mockService.AssertNotCalled(t, "GetNotificationByID")
+}
+
+// TestAdminListNotificationsHandler проверяет, что мягко удаленные попадают только в административный список
+func TestAdminListNotificationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	mockService.On("ListNotifications", mock.Anything,
+		domain.ListFilter{Channel: domain.ChannelEmail, IncludeDeleted: true}, 20, 0).
+		Return([]domain.Notification{}, false, nil).Once()
+	mockService.On("ListNotifications", mock.Anything, domain.ListFilter{Channel: domain.ChannelEmail}, 20, 0).
+		Return([]domain.Notification{}, false, nil).Twice()
+	admin := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+	public := handlers.NewHandlersSet(mockService)
+
+	for _, tc := range []struct {
+		handler gin.HandlerFunc
+		query   string
+	}{
+		{admin.ListNotificationsHandler, "channel=email&include_deleted=true"},
+		{admin.ListNotificationsHandler, "channel=email"},
+		{public.ListNotificationsHandler, "channel=email&include_deleted=true"},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/notify?"+tc.query, nil)
+		tc.handler(c)
+		assert.Equal(t, http.StatusOK, w.Code, tc.query)
+	}
+	mockService.AssertExpectations(t)
+}
+
+// TestDuplicatesReportHandler проверяет окно по умолчанию и формат отчета
+func TestDuplicatesReportHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now()
+	group := domain.DuplicateGroup{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		PayloadHash: "d41d8cd98f00b204e9800998ecf8427e",
+		FirstAt:     now.Add(-time.Minute),
+		LastAt:      now,
+		IDs:         []uuid.UUID{uuid.New(), uuid.New()},
+	}
+	mockService := new(MockNotificationService)
+	mockService.On("DuplicateReport", mock.Anything, 24*time.Hour, 100).Return([]domain.DuplicateGroup{group}, nil)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/reports/duplicates", nil)
+
+	h.DuplicatesReportHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.DuplicateReportResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "24h0m0s", response.Result.Window)
+	assert.Len(t, response.Result.Groups, 1)
+	assert.Equal(t, 2, response.Result.Groups[0].Count)
+	assert.Equal(t, group.IDs, response.Result.Groups[0].IDs)
+	mockService.AssertExpectations(t)
+}
+
+// TestDuplicatesReportHandler_InvalidWindow проверяет отказ для окна вне допустимого диапазона
+func TestDuplicatesReportHandler_InvalidWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	for _, query := range []string{"window=yesterday", "window=-1h", "window=200h", "limit=0", "limit=5000"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/admin/reports/duplicates?"+query, nil)
+
+		h.DuplicatesReportHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+	mockService.AssertNotCalled(t, "DuplicateReport")
+}
+
+// TestSearchNotificationsHandler проверяет передачу параметров поиска и ошибку пустого запроса
+func TestSearchNotificationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hit := domain.SearchHit{
+		Notification: domain.Notification{ID: uuid.New(), Recipient: "bob@example.com", Channel: domain.ChannelEmail},
+		Rank:         0.6,
+	}
+	mockService := new(MockNotificationService)
+	mockService.On("SearchNotifications", mock.Anything, domain.SearchParams{
+		Query: "invoice 4521", Channel: domain.ChannelEmail, Limit: 5,
+	}).Return([]domain.SearchHit{hit}, nil)
+	mockService.On("SearchNotifications", mock.Anything, domain.SearchParams{}).
+		Return(nil, domain.ErrEmptySearchQuery)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/search?q=invoice+4521&channel=email&limit=5", nil)
+	h.SearchNotificationsHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result []handlers.SearchHitResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Result, 1)
+	assert.Equal(t, hit.ID, response.Result[0].ID)
+	assert.Equal(t, 0.6, response.Result[0].Rank)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/search", nil)
+	h.SearchNotificationsHandler(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"Q"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestSaveViewHandler проверяет разбор фильтров и ссылку на поиск в ответе
+func TestSaveViewHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	filter := domain.ViewFilter{Status: domain.StatusFailed, Channel: domain.ChannelEmail, Last: 24 * time.Hour}
+	mockService := new(MockNotificationService)
+	mockService.On("SaveView", mock.Anything, "failed-email", filter).
+		Return(&domain.SavedView{Name: "failed-email", Filter: filter}, nil)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PUT", "/admin/views/failed-email",
+		strings.NewReader(`{"status":"failed","channel":"email","last":"24h"}`))
+	c.Params = []gin.Param{{Key: "name", Value: "failed-email"}}
+	h.SaveViewHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.SavedViewResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "24h0m0s", response.Result.Last)
+	assert.Equal(t, "/admin/search?channel=email&last=24h0m0s&status=failed", response.Result.Link)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PUT", "/admin/views/bad", strings.NewReader(`{"last":"yesterday","limit":500}`))
+	c.Params = []gin.Param{{Key: "name", Value: "bad"}}
+	h.SaveViewHandler(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"Last"`)
+	assert.Contains(t, w.Body.String(), `"Limit"`)
+	mockService.AssertExpectations(t)
+}
+
+// TestRunViewHandler_NotFound проверяет ответ для неизвестного представления
+func TestRunViewHandler_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	mockService.On("RunView", mock.Anything, "missing").Return(nil, domain.ErrViewNotFound)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/admin/views/missing/results", nil)
+	c.Params = []gin.Param{{Key: "name", Value: "missing"}}
+	h.RunViewHandler(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestSchemaHandlers проверяет регистрацию схемы и ответы на ошибки в ней
+func TestSchemaHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	doc := map[string]interface{}{"type": "object"}
+	mockService := new(MockNotificationService)
+	mockService.On("SaveSchema", mock.Anything, "billing", domain.ChannelEmail, doc).
+		Return(&domain.PayloadSchema{Source: "billing", Channel: domain.ChannelEmail, Schema: doc}, nil)
+	mockService.On("SaveSchema", mock.Anything, "billing", domain.ChannelSMS, mock.Anything).
+		Return(nil, fmt.Errorf("%w: schema.type: unknown type", domain.ErrInvalidSchema))
+	mockService.On("GetSchema", mock.Anything, "shop", domain.ChannelEmail).Return(nil, domain.ErrSchemaNotFound)
+	h := handlers.NewAdminHandlersSet(mockService, new(MockTopologyManager))
+
+	r := gin.New()
+	r.PUT("/admin/schemas/:source/:channel", h.SaveSchemaHandler)
+	r.GET("/admin/schemas/:source/:channel", h.GetSchemaHandler)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/admin/schemas/billing/email",
+		strings.NewReader(`{"schema":{"type":"object"}}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.PayloadSchemaResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "billing", response.Result.Source)
+	assert.Equal(t, "email", response.Result.Channel)
+	assert.Equal(t, doc, response.Result.Schema)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/admin/schemas/billing/sms", strings.NewReader(`{"schema":{"type":"x"}}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"Schema"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/admin/schemas/billing/email", strings.NewReader(`{}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"Schema"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/admin/schemas/shop/email", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestDebugVarsHandler проверяет выдачу метрик процесса
+func TestDebugVarsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/debug/vars", handlers.VarsHandler)
+	r.GET("/admin/debug/pprof/*name", handlers.PprofHandler)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/admin/debug/vars", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var vars map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &vars))
+	assert.Contains(t, vars, "uptime_seconds")
+	assert.Contains(t, vars, "runtime")
+	assert.Contains(t, vars, "deliveries_in_flight")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/admin/debug/pprof/goroutine?debug=1", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine profile")
+}
+
+// TestRecoveryMiddleware проверяет, что паника в обработчике превращается в 500 problem+json
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestIDMiddleware(), middleware.RecoveryMiddleware())
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, float64(http.StatusInternalServerError), problem["status"])
+	assert.Equal(t, "req-1", problem["request_id"])
+	assert.NotContains(t, w.Body.String(), "boom")
+}
+
+// TestListFailuresHandler проверяет выдачу журнала неуспешных уведомления
+func TestListFailuresHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := new(MockNotificationService)
+	h := handlers.NewAdminHandlersSet(svc, nil)
+	id := uuid.New()
+	failedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	svc.On("ListFailures", mock.Anything, id).Return([]domain.NotificationFailure{
+		{ID: 1, NotificationID: id, Error: "smtp timeout", Attempts: 10, FailedAt: failedAt},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("GET", "/admin/notify/"+id.String()+"/failures", nil)
+
+	h.ListFailuresHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result []handlers.NotificationFailureResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Result, 1) {
+		assert.Equal(t, "smtp timeout", response.Result[0].Error)
+		assert.Equal(t, 10, response.Result[0].Attempts)
+		assert.Equal(t, failedAt, response.Result[0].FailedAt)
+	}
+	svc.AssertExpectations(t)
+}
+
+// TestInspectCacheHandler проверяет выдачу сырого значения и TTL
+func TestInspectCacheHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := new(MockNotificationService)
+	h := handlers.NewAdminHandlersSet(svc, nil)
+	id, binary := uuid.New(), uuid.New()
+
+	svc.On("InspectCache", mock.Anything, id).Return(&domain.CacheEntry{
+		Key: "notification:" + id.String(), Value: []byte(`{"id":"x"}`), TTL: 90 * time.Second,
+	}, nil)
+	svc.On("InspectCache", mock.Anything, binary).Return(&domain.CacheEntry{
+		Key: "notification:" + binary.String(), Value: []byte{0x82, 0xff}, TTL: -1,
+	}, nil)
+
+	get := func(id uuid.UUID) handlers.CacheEntryResponse {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: id.String()}}
+		c.Request, _ = http.NewRequest("GET", "/admin/cache/"+id.String(), nil)
+		h.InspectCacheHandler(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Result handlers.CacheEntryResponse `json:"result"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Result
+	}
+
+	text := get(id)
+	assert.Equal(t, `{"id":"x"}`, text.Value)
+	assert.Equal(t, "1m30s", text.TTL)
+	assert.False(t, text.Persistent)
+
+	raw := get(binary)
+	assert.Empty(t, raw.Value)
+	assert.Equal(t, "gv8=", raw.ValueBase64)
+	assert.True(t, raw.Persistent)
+}
+
+// TestFlushCacheHandler проверяет, что без confirm=true ключи не удаляются
+func TestFlushCacheHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := new(MockNotificationService)
+	h := handlers.NewAdminHandlersSet(svc, nil)
+
+	svc.On("FlushCache", mock.Anything, "notification:*", 1000, true).
+		Return(domain.CacheFlushResult{Keys: []string{"notification:a", "notification:b"}}, nil)
+	svc.On("FlushCache", mock.Anything, "notification:*", 50, false).
+		Return(domain.CacheFlushResult{Keys: []string{"notification:a"}, Deleted: 1, Truncated: true}, nil)
+	svc.On("FlushCache", mock.Anything, "*", 1000, true).
+		Return(domain.CacheFlushResult{}, domain.ErrInvalidCachePattern)
+
+	call := func(query string) (int, handlers.CacheFlushResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/admin/cache?"+query, nil)
+		h.FlushCacheHandler(c)
+		var response struct {
+			Result handlers.CacheFlushResponse `json:"result"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Result
+	}
+
+	code, res := call("pattern=notification:*")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, 2, res.Matched)
+	assert.Zero(t, res.Deleted)
+
+	code, res = call("pattern=notification:*&confirm=true&limit=50")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, res.DryRun)
+	assert.Equal(t, 1, res.Deleted)
+	assert.True(t, res.Truncated)
+
+	code, _ = call("pattern=*")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call("pattern=notification:*&limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+	svc.AssertExpectations(t)
+}
+
+// TestRescheduleHandler проверяет разбор фильтра и сдвига и ответ на неполный запрос
+func TestRescheduleHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := new(MockNotificationService)
+	h := handlers.NewAdminHandlersSet(svc, nil)
+	id := uuid.New()
+	svc.On("RescheduleNotifications", mock.Anything, mock.MatchedBy(func(p domain.RescheduleParams) bool {
+		return p.Filter.Channel == domain.ChannelEmail && p.Shift == 2*time.Hour && p.At == nil &&
+			p.Limit == 1000 && !p.Filter.ScheduledFrom.IsZero()
+	})).Return(domain.RescheduleResult{Rescheduled: 1, Republished: 1, IDs: []uuid.UUID{id}}, nil)
+	svc.On("RescheduleNotifications", mock.Anything, mock.Anything).
+		Return(domain.RescheduleResult{}, domain.ErrInvalidReschedule)
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/admin/notify/reschedule", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.RescheduleHandler(c)
+		return w
+	}
+
+	w := serve(`{"filter":{"channel":"email","scheduled_from":"2024-12-25T10:00:00Z"},"shift":"+2h"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.RescheduleResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Result.Rescheduled)
+	assert.Equal(t, []uuid.UUID{id}, response.Result.IDs)
+
+	w = serve(`{"filter":{"channel":"email"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "ScheduledAt")
+
+	w = serve(`{"filter":{"channel":"fax"},"shift":"1h"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Channel")
+}
@@ -0,0 +1,151 @@
+package delivery_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"DelayedNotifier/internal/delivery/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDebugRouter(rec *middleware.DebugRecorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(rec.Middleware())
+	r.POST("/echo", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": "ok", "token": "response-secret"})
+	})
+	return r
+}
+
+// TestDebugRecorder_XDebugWithAdminKey проверяет запись запроса по X-Debug и очистку чувствительных полей
+func TestDebugRecorder_XDebugWithAdminKey(t *testing.T) {
+	rec := middleware.NewDebugRecorder(10, 0, "secret")
+	r := newDebugRouter(rec)
+
+	body := `{"recipient":"a@example.com","password":"p@ss","nested":{"api_key":"k"}}`
+	req, _ := http.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	req.Header.Set("X-Debug", "1")
+	req.Header.Set("X-Admin-Key", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	entries := rec.Entries()
+	if assert.Len(t, entries, 1) {
+		e := entries[0]
+		assert.Equal(t, "/echo", e.Path)
+		assert.Equal(t, http.StatusOK, e.Status)
+		assert.NotContains(t, e.RequestBody, "p@ss")
+		assert.Contains(t, e.RequestBody, "a@example.com")
+		assert.NotContains(t, e.RequestBody, `"k"`)
+		assert.NotContains(t, e.ResponseBody, "response-secret")
+		assert.Equal(t, "***", e.Headers["X-Admin-Key"])
+	}
+
+	// тело ответа клиенту не изменяется
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "response-secret", resp["token"])
+}
+
+// TestDebugRecorder_RedactsEmbeddedPayload проверяет очистку секретов внутри payload, переданного строкой
+func TestDebugRecorder_RedactsEmbeddedPayload(t *testing.T) {
+	rec := middleware.NewDebugRecorder(10, 1, "secret")
+	r := newDebugRouter(rec)
+
+	body := `{"recipient":"a@example.com",` +
+		`"payload":"{\"subject\":\"Invoice\",\"meta\":{\"reset_token\":\"tok-123\"},\"items\":[{\"password\":\"p@ss\"}]}",` +
+		`"note":"{not json with secret s3cr3t"}`
+	req, _ := http.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := rec.Entries()
+	if assert.Len(t, entries, 1) {
+		e := entries[0]
+		assert.NotContains(t, e.RequestBody, "tok-123")
+		assert.NotContains(t, e.RequestBody, "p@ss")
+		assert.NotContains(t, e.RequestBody, "s3cr3t")
+		assert.Contains(t, e.RequestBody, "Invoice")
+		assert.Contains(t, e.RequestBody, "a@example.com")
+	}
+}
+
+// TestDebugRecorder_LargeBodies проверяет очистку до обрезки и ограничение читаемого тела запроса
+func TestDebugRecorder_LargeBodies(t *testing.T) {
+	rec := middleware.NewDebugRecorder(10, 1, "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(rec.Middleware())
+	items := make([]gin.H, 200)
+	for i := range items {
+		items[i] = gin.H{"id": i, "reset_token": "tok-secret"}
+	}
+	r.GET("/list", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"result": items}) })
+	r.GET("/text", func(c *gin.Context) { c.String(http.StatusOK, `{"token":"tok-secret"`) })
+	var received int
+	r.POST("/upload", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = len(body)
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/list", "/text"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	big := strings.Repeat("x", 2<<20)
+	req, _ := http.NewRequest(http.MethodPost, "/upload", strings.NewReader(big))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := rec.Entries()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, len(big), received, "handler must see the whole request body")
+		assert.Contains(t, entries[0].RequestBody, "too large")
+		for _, e := range entries[1:] {
+			assert.NotContains(t, e.ResponseBody, "tok-secret")
+			assert.NotEmpty(t, e.ResponseBody)
+		}
+		assert.Contains(t, entries[2].ResponseBody, "(truncated)")
+	}
+}
+
+// TestDebugRecorder_NotSampled проверяет, что без X-Debug и с нулевой долей запросы не сохраняются
+func TestDebugRecorder_NotSampled(t *testing.T) {
+	rec := middleware.NewDebugRecorder(10, 0, "secret")
+	r := newDebugRouter(rec)
+
+	for _, key := range []string{"", "wrong"} {
+		req, _ := http.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{}`))
+		req.Header.Set("X-Debug", "1")
+		req.Header.Set("X-Admin-Key", key)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Empty(t, rec.Entries())
+}
+
+// TestDebugRecorder_RingBuffer проверяет, что буфер хранит только последние записи
+func TestDebugRecorder_RingBuffer(t *testing.T) {
+	rec := middleware.NewDebugRecorder(2, 1, "")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(rec.Middleware())
+	r.GET("/:n", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, n := range []string{"1", "2", "3"} {
+		req, _ := http.NewRequest(http.MethodGet, "/"+n, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries := rec.Entries()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "/3", entries[0].Path)
+		assert.Equal(t, "/2", entries[1].Path)
+	}
+}
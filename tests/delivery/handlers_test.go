@@ -3,8 +3,10 @@ package delivery_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -30,6 +32,48 @@ func (m *MockNotificationService) CreateNotification(ctx context.Context, params
 	return args.Get(0).(*domain.Notification), args.Error(1)
 }
 
+func (m *MockNotificationService) CreateNotifications(ctx context.Context,
+	params []domain.CreateNotificationParams) ([]domain.BatchCreateResult, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.BatchCreateResult), args.Error(1)
+}
+
+func (m *MockNotificationService) CreateGroup(ctx context.Context, params domain.CreateNotificationParams,
+	recipients []string) (*domain.GroupCreateResult, error) {
+	args := m.Called(ctx, params, recipients)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GroupCreateResult), args.Error(1)
+}
+
+func (m *MockNotificationService) GroupStatus(ctx context.Context, groupID uuid.UUID) (*domain.GroupStatus, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.GroupStatus), args.Error(1)
+}
+
+func (m *MockNotificationService) CloneNotification(ctx context.Context, id uuid.UUID, params domain.CloneNotificationParams) (*domain.Notification, error) {
+	args := m.Called(ctx, id, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) GetRelatedNotifications(ctx context.Context, id uuid.UUID) ([]domain.Notification, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
 func (m *MockNotificationService) UpdateNotification(ctx context.Context, n *domain.Notification, opts ...domain.UpdateOption) error {
 	args := m.Called(ctx, n, opts)
 	return args.Error(0)
@@ -43,21 +87,391 @@ func (m *MockNotificationService) GetNotificationByID(ctx context.Context, id uu
 	return args.Get(0).(*domain.Notification), args.Error(1)
 }
 
+func (m *MockNotificationService) RefreshNotificationByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) PreviewNotification(ctx context.Context, id uuid.UUID) (*domain.RenderedMessage, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RenderedMessage), args.Error(1)
+}
+
+func (m *MockNotificationService) ValidatePayload(n *domain.Notification) error {
+	args := m.Called(n)
+	return args.Error(0)
+}
+
 func (m *MockNotificationService) Cancel(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockNotificationService) Confirm(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockNotificationService) Failed(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockNotificationService) Bounced(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockNotificationService) IncRetryCount(ctx context.Context, n *domain.Notification) error {
 	args := m.Called(ctx, n)
 	return args.Error(0)
 }
 
+func (m *MockNotificationService) RetriesExhausted(n *domain.Notification) bool {
+	args := m.Called(n)
+	return args.Bool(0)
+}
+
+func (m *MockNotificationService) FailExhausted(ctx context.Context, n *domain.Notification, cause error) error {
+	args := m.Called(ctx, n, cause)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) ListFailures(ctx context.Context, id uuid.UUID) ([]domain.NotificationFailure,
+	error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.NotificationFailure), args.Error(1)
+}
+
+func (m *MockNotificationService) History(ctx context.Context, id uuid.UUID) ([]domain.NotificationEvent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.NotificationEvent), args.Error(1)
+}
+
+func (m *MockNotificationService) InspectCache(ctx context.Context, id uuid.UUID) (*domain.CacheEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CacheEntry), args.Error(1)
+}
+
+func (m *MockNotificationService) EvictCache(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) FlushCache(ctx context.Context, pattern string, limit int,
+	dryRun bool) (domain.CacheFlushResult, error) {
+	args := m.Called(ctx, pattern, limit, dryRun)
+	return args.Get(0).(domain.CacheFlushResult), args.Error(1)
+}
+
+func (m *MockNotificationService) StatusLink(ctx context.Context, id uuid.UUID,
+	ttl time.Duration) (*domain.StatusLink, error) {
+	args := m.Called(ctx, id, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.StatusLink), args.Error(1)
+}
+
+func (m *MockNotificationService) PublicStatus(ctx context.Context, token string) (*domain.PublicStatus, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PublicStatus), args.Error(1)
+}
+
+func (m *MockNotificationService) RescheduleNotifications(ctx context.Context,
+	params domain.RescheduleParams) (domain.RescheduleResult, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(domain.RescheduleResult), args.Error(1)
+}
+
+func (m *MockNotificationService) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) GetNotificationByIDWithDeleted(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) WarmCache(ctx context.Context, horizon time.Duration, limit int) (int, error) {
+	args := m.Called(ctx, horizon, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationService) Approve(ctx context.Context, id uuid.UUID,
+	approver string) (*domain.Notification, error) {
+	args := m.Called(ctx, id, approver)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) UpdateDraft(ctx context.Context, id uuid.UUID,
+	params domain.DraftUpdateParams) (*domain.Notification, error) {
+	args := m.Called(ctx, id, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) ScheduleDraft(ctx context.Context, id uuid.UUID,
+	params domain.ScheduleParams) (*domain.Notification, error) {
+	args := m.Called(ctx, id, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) RecordReceipt(ctx context.Context, id uuid.UUID,
+	status domain.Status, eventID string) (*domain.Notification, error) {
+	args := m.Called(ctx, id, status, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) SnoozeRecipient(ctx context.Context, ch domain.Channel, recipient string,
+	d time.Duration) (time.Time, error) {
+	args := m.Called(ctx, ch, recipient, d)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockNotificationService) UnsnoozeRecipient(ctx context.Context, ch domain.Channel, recipient string) error {
+	args := m.Called(ctx, ch, recipient)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) ClaimSend(ctx context.Context, n *domain.Notification) (func(), bool, error) {
+	args := m.Called(ctx, n)
+	return func() {}, args.Bool(0), args.Error(1)
+}
+
+func (m *MockNotificationService) RecipientSnoozedUntil(ctx context.Context, ch domain.Channel,
+	recipient string) (time.Time, bool, error) {
+	args := m.Called(ctx, ch, recipient)
+	return args.Get(0).(time.Time), args.Bool(1), args.Error(2)
+}
+
+func (m *MockNotificationService) DeferNotification(ctx context.Context, n *domain.Notification,
+	delay time.Duration) error {
+	args := m.Called(ctx, n, delay)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) ReprocessFailed(ctx context.Context, after time.Duration,
+	maxCycles, limit int) (int, error) {
+	args := m.Called(ctx, after, maxCycles, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationService) CountStaleProcessing(ctx context.Context, olderThan time.Duration) (int, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationService) DuplicateReport(ctx context.Context, window time.Duration,
+	limit int) ([]domain.DuplicateGroup, error) {
+	args := m.Called(ctx, window, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DuplicateGroup), args.Error(1)
+}
+
+func (m *MockNotificationService) PreviewSchedule(ctx context.Context,
+	params domain.SchedulePreviewParams) (*domain.SchedulePreview, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SchedulePreview), args.Error(1)
+}
+
+func (m *MockNotificationService) ReconcileOrphans(ctx context.Context, grace, maxAge time.Duration,
+	after *domain.OrphanCursor, limit int) (domain.OrphanReport, error) {
+	args := m.Called(ctx, grace, maxAge, after, limit)
+	return args.Get(0).(domain.OrphanReport), args.Error(1)
+}
+
+func (m *MockNotificationService) ListNotifications(ctx context.Context, filter domain.ListFilter,
+	limit, offset int) ([]domain.Notification, bool, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).([]domain.Notification), args.Bool(1), args.Error(2)
+}
+
+func (m *MockNotificationService) SearchNotifications(ctx context.Context,
+	params domain.SearchParams) ([]domain.SearchHit, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SearchHit), args.Error(1)
+}
+
+func (m *MockNotificationService) SaveView(ctx context.Context, name string,
+	filter domain.ViewFilter) (*domain.SavedView, error) {
+	args := m.Called(ctx, name, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SavedView), args.Error(1)
+}
+
+func (m *MockNotificationService) ListViews(ctx context.Context) ([]domain.SavedView, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SavedView), args.Error(1)
+}
+
+func (m *MockNotificationService) DeleteView(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) CreateTemplate(ctx context.Context,
+	params domain.TemplateParams) (*domain.Template, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Template), args.Error(1)
+}
+
+func (m *MockNotificationService) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Template), args.Error(1)
+}
+
+func (m *MockNotificationService) ListTemplates(ctx context.Context) ([]domain.Template, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Template), args.Error(1)
+}
+
+func (m *MockNotificationService) UpdateTemplate(ctx context.Context, id uuid.UUID,
+	params domain.TemplateParams) (*domain.Template, error) {
+	args := m.Called(ctx, id, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Template), args.Error(1)
+}
+
+func (m *MockNotificationService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) ResolveTemplate(ctx context.Context,
+	n *domain.Notification) (*domain.Notification, error) {
+	args := m.Called(ctx, n)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockNotificationService) SaveSchema(ctx context.Context, source string, ch domain.Channel,
+	schema map[string]interface{}) (*domain.PayloadSchema, error) {
+	args := m.Called(ctx, source, ch, schema)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PayloadSchema), args.Error(1)
+}
+
+func (m *MockNotificationService) GetSchema(ctx context.Context, source string,
+	ch domain.Channel) (*domain.PayloadSchema, error) {
+	args := m.Called(ctx, source, ch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PayloadSchema), args.Error(1)
+}
+
+func (m *MockNotificationService) ListSchemas(ctx context.Context) ([]domain.PayloadSchema, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.PayloadSchema), args.Error(1)
+}
+
+func (m *MockNotificationService) DeleteSchema(ctx context.Context, source string, ch domain.Channel) error {
+	return m.Called(ctx, source, ch).Error(0)
+}
+
+func (m *MockNotificationService) RunView(ctx context.Context, name string) ([]domain.SearchHit, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SearchHit), args.Error(1)
+}
+
+func (m *MockNotificationService) CountOutcomes(ctx context.Context, from,
+	to time.Time) (map[domain.Channel]domain.OutcomeCounts, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[domain.Channel]domain.OutcomeCounts), args.Error(1)
+}
+
+func (m *MockNotificationService) SLACompliance(ctx context.Context) ([]domain.SLAReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SLAReport), args.Error(1)
+}
+
+func (m *MockNotificationService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(ctx, retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // TestCreateNotificationHandler_Success проверяет успешное создание уведомления через HTTP
 func TestCreateNotificationHandler_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -88,6 +502,7 @@ func TestCreateNotificationHandler_Success(t *testing.T) {
 	reqBody := `{
 		"recipient": "test@example.com",
 		"channel": "email",
+		"source": "billing",
 		"payload": "{\"subject\":\"Test Email\",\"body\":\"Hello World\"}",
 		"scheduled_at": "` + scheduledAt + `"
 	}`
@@ -176,6 +591,7 @@ func TestCreateNotificationHandler_InvalidChannel(t *testing.T) {
 	reqBody := `{
 		"recipient": "test@example.com",
 		"channel": "invalid_channel",
+		"source": "billing",
 		"payload": "{\"subject\":\"Test\"}",
 		"scheduled_at": "` + scheduledAt + `"
 	}`
@@ -212,6 +628,7 @@ func TestCreateNotificationHandler_ServiceError(t *testing.T) {
 	reqBody := `{
 		"recipient": "test@example.com",
 		"channel": "email",
+		"source": "billing",
 		"payload": "{\"subject\":\"Test\"}",
 		"scheduled_at": "` + scheduledAt + `"
 	}`
@@ -233,7 +650,130 @@ func TestCreateNotificationHandler_ServiceError(t *testing.T) {
 	assert.Contains(t, response, "error")
 }
 
-// TestCreateNotificationHandler_InvalidScheduledAt проверяет обработку некорректного времени
+// TestCreateNotificationHandler_Overloaded проверяет ответ 503 с Retry-After
+// для уведомления с низким приоритетом при перегрузке
+func TestCreateNotificationHandler_Overloaded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	scheduledAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	mockService.On("CreateNotification", mock.Anything, mock.MatchedBy(func(params domain.CreateNotificationParams) bool {
+		return params.Priority == domain.PriorityLow
+	})).Return(nil, domain.ErrOverloaded)
+
+	reqBody := `{
+		"recipient": "test@example.com",
+		"channel": "email",
+		"source": "billing",
+		"payload": "{\"subject\":\"Test\"}",
+		"scheduled_at": "` + scheduledAt + `",
+		"priority": "low"
+	}`
+
+	req, _ := http.NewRequest("POST", "/notifications", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.CreateNotificationHandler(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	mockService.AssertExpectations(t)
+}
+
+// TestCreateNotificationHandler_IdempotencyKey проверяет передачу ключа из заголовка
+// и отказ, если ключ в теле с ним не совпадает
+func TestCreateNotificationHandler_IdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	scheduledAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	mockService.On("CreateNotification", mock.Anything, mock.MatchedBy(func(params domain.CreateNotificationParams) bool {
+		return params.IdempotencyKey == "order-42"
+	})).Return(&domain.Notification{ID: uuid.New()}, nil).Once()
+
+	send := func(key, bodyKey string) *httptest.ResponseRecorder {
+		reqBody := `{
+			"recipient": "test@example.com",
+			"channel": "email",
+			"source": "billing",
+			"payload": "{\"subject\":\"Test\"}",
+			"scheduled_at": "` + scheduledAt + `",
+			"idempotency_key": "` + bodyKey + `"
+		}`
+		req, _ := http.NewRequest("POST", "/notifications", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.CreateNotificationHandler(c)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send("order-42", "").Code)
+
+	w := send("order-42", "order-43")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "IdempotencyKey")
+
+	w = send("", strings.Repeat("k", 256))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "IdempotencyKey")
+
+	mockService.AssertExpectations(t)
+}
+
+// TestCreateNotificationHandler_ScheduleOutOfRange проверяет ответ 400 с ошибкой поля
+// при выходе scheduled_at за горизонт планирования
+func TestCreateNotificationHandler_ScheduleOutOfRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	scheduledAt := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	mockService.On("CreateNotification", mock.Anything, mock.MatchedBy(func(p domain.CreateNotificationParams) bool {
+		return !p.Immediate
+	})).Return(nil, domain.ErrScheduledTooFarInPast)
+
+	reqBody := `{
+		"recipient": "test@example.com",
+		"channel": "email",
+		"source": "billing",
+		"payload": "{\"subject\":\"Test\"}",
+		"scheduled_at": "` + scheduledAt + `"
+	}`
+
+	req, _ := http.NewRequest("POST", "/notifications", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.CreateNotificationHandler(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Contains(t, response["errors"], "ScheduledAt")
+}
+
+// TestCreateNotificationHandler_InvalidScheduledAt проверяет обработку некорректного времени
 func TestCreateNotificationHandler_InvalidScheduledAt(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -243,6 +783,7 @@ func TestCreateNotificationHandler_InvalidScheduledAt(t *testing.T) {
 	reqBody := `{
 		"recipient": "test@example.com",
 		"channel": "email",
+		"source": "billing",
 		"payload": "{\"subject\":\"Test\"}",
 		"scheduled_at": "invalid-time"
 	}`
@@ -280,6 +821,7 @@ func TestCreateNotificationHandler_InvalidPayloadJSON(t *testing.T) {
 	reqBody := `{
 		"recipient": "test@example.com",
 		"channel": "email",
+		"source": "billing",
 		"payload": "not-valid-json",
 		"scheduled_at": "` + scheduledAt + `"
 	}`
@@ -306,6 +848,179 @@ func TestCreateNotificationHandler_InvalidPayloadJSON(t *testing.T) {
 }
 
 // TestGetNotificationHandler_Success проверяет успешное получение уведомления через HTTP
+func TestCreateBatchHandler_PartialFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	scheduledAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	created := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		Status: domain.StatusPending}
+
+	// элемент 1 не проходит проверку запроса и до сервиса не доходит,
+	// элемент 2 отклоняет сервис
+	mockService.On("CreateNotifications", mock.Anything, mock.MatchedBy(func(ps []domain.CreateNotificationParams) bool {
+		return len(ps) == 2 && ps[0].Recipient == "a@example.com" && ps[1].Recipient == "bad"
+	})).Return([]domain.BatchCreateResult{
+		{Notification: created},
+		{Err: domain.ErrInvalidRecipient},
+	}, nil)
+
+	item := func(recipient, payload string) string {
+		return `{"recipient":"` + recipient + `","channel":"email","source":"billing","payload":"` + payload +
+			`","scheduled_at":"` + scheduledAt + `"}`
+	}
+	reqBody := `{"notifications":[` + item("a@example.com", `{\"subject\":\"A\"}`) + `,` +
+		item("b@example.com", "not json") + `,` + item("bad", `{}`) + `]}`
+
+	req, _ := http.NewRequest("POST", "/notify/batch", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.CreateBatchHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.BatchCreateResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Result.Created)
+	assert.Equal(t, 2, response.Result.Failed)
+	if assert.Len(t, response.Result.Items, 3) {
+		assert.Equal(t, created.ID, response.Result.Items[0].Notification.ID)
+		assert.Contains(t, response.Result.Items[1].Errors, "Payload")
+		assert.Equal(t, 2, response.Result.Items[2].Index)
+		assert.Contains(t, response.Result.Items[2].Errors, "Recipient")
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateBatchHandler_TooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+	mockService.On("CreateNotifications", mock.Anything, mock.Anything).Return(nil, domain.ErrBatchTooLarge)
+
+	reqBody := `{"notifications":[{"recipient":"a@example.com","channel":"email","source":"billing",
+		"payload":"{}","scheduled_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}]}`
+	req, _ := http.NewRequest("POST", "/notify/batch", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.CreateBatchHandler(c)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+// TestCreateNotificationHandler_Recipients проверяет создание уведомления для нескольких
+// получателей: результат по каждому получателю и общий group_id
+func TestCreateNotificationHandler_Recipients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	groupID := uuid.New()
+	created := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		Status: domain.StatusPending, GroupID: &groupID}
+	mockService.On("CreateGroup", mock.Anything, mock.MatchedBy(func(p domain.CreateNotificationParams) bool {
+		return p.Recipient == "" && p.Source == "billing"
+	}), []string{"a@example.com", "bad"}).Return(&domain.GroupCreateResult{
+		GroupID:    groupID,
+		Recipients: []string{"a@example.com", "bad"},
+		Results:    []domain.BatchCreateResult{{Notification: created}, {Err: domain.ErrInvalidRecipient}},
+	}, nil)
+
+	reqBody := `{"recipients":["a@example.com","bad"],"channel":"email","source":"billing","payload":"{}",
+		"scheduled_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+	req, _ := http.NewRequest("POST", "/notify", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	h.CreateNotificationHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.GroupCreateResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, groupID, response.Result.GroupID)
+	assert.Equal(t, 1, response.Result.Created)
+	assert.Equal(t, 1, response.Result.Failed)
+	if assert.Len(t, response.Result.Items, 2) {
+		assert.Equal(t, groupID, *response.Result.Items[0].Notification.GroupID)
+		assert.Equal(t, "bad", response.Result.Items[1].Recipient)
+		assert.Contains(t, response.Result.Items[1].Errors, "Recipient")
+	}
+	mockService.AssertExpectations(t)
+
+	// recipient и recipients вместе недопустимы
+	reqBody = `{"recipient":"a@example.com","recipients":["b@example.com"],"channel":"email","source":"billing",
+		"payload":"{}","scheduled_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+	req, _ = http.NewRequest("POST", "/notify", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = req
+
+	h.CreateNotificationHandler(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Recipients")
+}
+
+func TestGroupStatusHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	groupID, unknown := uuid.New(), uuid.New()
+	mockService.On("GroupStatus", mock.Anything, groupID).Return(domain.NewGroupStatus(groupID, []domain.Notification{
+		{ID: uuid.New(), Recipient: "a@example.com", Status: domain.StatusSent},
+		{ID: uuid.New(), Recipient: "b@example.com", Status: domain.StatusPending},
+	}), nil)
+	mockService.On("GroupStatus", mock.Anything, unknown).Return(nil, domain.ErrNotFound)
+
+	get := func(id string, etag ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/notify/group/"+id, nil)
+		if len(etag) > 0 {
+			c.Request.Header.Set("If-None-Match", etag[0])
+		}
+		c.Params = []gin.Param{{Key: "group_id", Value: id}}
+		h.GroupStatusHandler(c)
+		return w
+	}
+
+	w := get(groupID.String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.GroupStatusResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Result.Total)
+	assert.Equal(t, 1, response.Result.Sent)
+	assert.Equal(t, 1, response.Result.Scheduled)
+	assert.False(t, response.Result.Completed)
+	assert.Equal(t, 1, response.Result.ByStatus["pending"])
+	assert.Len(t, response.Result.Items, 2)
+
+	assert.Equal(t, http.StatusNotModified, get(groupID.String(), w.Header().Get("ETag")).Code)
+
+	assert.Equal(t, http.StatusNotFound, get(unknown.String()).Code)
+	assert.Equal(t, http.StatusBadRequest, get("invalid").Code)
+}
+
 func TestGetNotificationHandler_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -343,6 +1058,78 @@ func TestGetNotificationHandler_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestGetNotificationHandler_ETag проверяет ответ 304 на If-None-Match с неизменившимся ETag
+func TestGetNotificationHandler_ETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	notification := &domain.Notification{ID: uuid.New(), Recipient: "test@example.com",
+		Channel: domain.ChannelEmail, Status: domain.StatusPending, UpdatedAt: time.Now()}
+	mockService.On("GetNotificationByID", mock.Anything, notification.ID).Return(notification, nil)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/notify/"+notification.ID.String(), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: notification.ID.String()}}
+		h.GetNotificationHandler(c)
+		return w
+	}
+
+	w := get("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = get(`"other", ` + etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	notification.UpdatedAt = notification.UpdatedAt.Add(time.Millisecond)
+	w = get(etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+// TestGetNotificationHandler_NoCache проверяет, что Cache-Control: no-cache читает из базы в обход кеша
+func TestGetNotificationHandler_NoCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	notificationID := uuid.New()
+	notification := &domain.Notification{
+		ID:        notificationID,
+		Recipient: "test@example.com",
+		Channel:   domain.ChannelEmail,
+		Status:    domain.StatusPending,
+	}
+
+	mockService.On("RefreshNotificationByID", mock.Anything, notificationID).Return(notification, nil)
+
+	req, _ := http.NewRequest("GET", "/notifications/"+notificationID.String(), nil)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "id", Value: notificationID.String()}}
+
+	h.GetNotificationHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "GetNotificationByID", mock.Anything, mock.Anything)
+}
+
 // TestGetNotificationHandler_InvalidID проверяет обработку некорректного ID
 func TestGetNotificationHandler_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -490,3 +1277,669 @@ func TestDeleteNotificationHandler_ServiceError(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, response, "error")
 }
+
+// TestPreviewNotificationHandler_Formats проверяет предпросмотр в форматах json, html и text
+func TestPreviewNotificationHandler_Formats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	notificationID := uuid.New()
+	msg := &domain.RenderedMessage{
+		Channel:     domain.ChannelEmail,
+		Recipient:   "test@example.com",
+		Subject:     "<Привет>",
+		Body:        "<p>Как дела?</p>",
+		ContentType: "text/html; charset=utf-8",
+	}
+	mockService.On("PreviewNotification", mock.Anything, notificationID).Return(msg, nil)
+
+	preview := func(format string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/notify/"+notificationID.String()+"/preview?format="+format, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: notificationID.String()}}
+		h.PreviewNotificationHandler(c)
+		return w
+	}
+
+	w := preview("json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result domain.RenderedMessage `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, *msg, response.Result)
+
+	w = preview("html")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "&lt;Привет&gt;")
+	// тело письма показывается только внутри iframe без скриптов
+	assert.Contains(t, w.Body.String(), `<iframe sandbox srcdoc="&lt;p&gt;Как дела?&lt;/p&gt;"`)
+	assert.NotContains(t, w.Body.String(), "<p>Как дела?</p>")
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'none'")
+
+	w = preview("text")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Subject: <Привет>\n\n<p>Как дела?</p>", w.Body.String())
+
+	w = preview("xml")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestPreviewNotificationHandler_NotFound проверяет 404 для неизвестного уведомления
+func TestPreviewNotificationHandler_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	notificationID := uuid.New()
+	mockService.On("PreviewNotification", mock.Anything, notificationID).Return(nil, domain.ErrNotFound)
+
+	req, _ := http.NewRequest("GET", "/notify/"+notificationID.String()+"/preview", nil)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "id", Value: notificationID.String()}}
+
+	h.PreviewNotificationHandler(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestCloneNotificationHandler проверяет клонирование с переопределением получателя и без тела запроса
+func TestCloneNotificationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	sourceID := uuid.New()
+	clone := &domain.Notification{ID: uuid.New(), Recipient: "other@example.com", Channel: domain.ChannelEmail}
+	mockService.On("CloneNotification", mock.Anything, sourceID,
+		domain.CloneNotificationParams{Recipient: "other@example.com"}).Return(clone, nil)
+	mockService.On("CloneNotification", mock.Anything, sourceID,
+		domain.CloneNotificationParams{}).Return(clone, nil)
+	unknownID := uuid.New()
+	mockService.On("CloneNotification", mock.Anything, unknownID,
+		domain.CloneNotificationParams{}).Return(nil, domain.ErrNotFound)
+
+	cloneReq := func(id uuid.UUID, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/notify/"+id.String()+"/clone", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		h.CloneNotificationHandler(c)
+		return w
+	}
+
+	w := cloneReq(sourceID, `{"recipient":"other@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), clone.ID.String())
+
+	w = cloneReq(sourceID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = cloneReq(sourceID, `{"scheduled_at":"tomorrow"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = cloneReq(unknownID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestGetRelatedNotificationsHandler проверяет построение дерева связанных уведомлений
+func TestGetRelatedNotificationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	rootID, cloneID, retryID := uuid.New(), uuid.New(), uuid.New()
+	chain := []domain.Notification{
+		{ID: rootID, Channel: domain.ChannelEmail, Status: domain.StatusSent},
+		{ID: cloneID, Channel: domain.ChannelEmail, Status: domain.StatusSent, ParentID: &rootID, CorrelationID: &rootID},
+		{ID: retryID, Channel: domain.ChannelEmail, Status: domain.StatusPending, ParentID: &cloneID, CorrelationID: &rootID},
+	}
+	mockService.On("GetRelatedNotifications", mock.Anything, retryID).Return(chain, nil)
+
+	req, _ := http.NewRequest("GET", "/notify/"+retryID.String()+"/related", nil)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = []gin.Param{{Key: "id", Value: retryID.String()}}
+
+	h.GetRelatedNotificationsHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.RelatedNode `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	root := response.Result
+	assert.Equal(t, rootID, root.ID)
+	assert.Len(t, root.Children, 1)
+	assert.Equal(t, cloneID, root.Children[0].ID)
+	assert.Len(t, root.Children[0].Children, 1)
+	assert.Equal(t, retryID, root.Children[0].Children[0].ID)
+}
+
+// TestHistoryHandler проверяет выдачу истории статусов и 404 для неизвестного уведомления
+func TestHistoryHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	id, missing := uuid.New(), uuid.New()
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockService.On("History", mock.Anything, id).Return([]domain.NotificationEvent{
+		{NotificationID: id, ToStatus: domain.StatusPending, Actor: domain.ActorAPI, At: at},
+		{NotificationID: id, FromStatus: domain.StatusPending, ToStatus: domain.StatusFailed,
+			Actor: domain.ActorWorker, Error: "smtp timeout", At: at.Add(time.Hour)},
+	}, nil)
+	mockService.On("History", mock.Anything, missing).Return(nil, domain.ErrNotFound)
+
+	get := func(id uuid.UUID) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/notify/"+id.String()+"/history", nil)
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		h.HistoryHandler(c)
+		return w
+	}
+
+	w := get(id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result []handlers.NotificationEventResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Result, 2) {
+		assert.Equal(t, "", response.Result[0].From)
+		assert.Equal(t, "api", response.Result[0].Actor)
+		assert.Equal(t, "pending", response.Result[1].From)
+		assert.Equal(t, "failed", response.Result[1].To)
+		assert.Equal(t, "smtp timeout", response.Result[1].Error)
+	}
+
+	assert.Equal(t, http.StatusNotFound, get(missing).Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestConfirmNotificationHandler проверяет коды ответа подтверждения
+func TestConfirmNotificationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	okID, lateID, plainID := uuid.New(), uuid.New(), uuid.New()
+	mockService.On("Confirm", mock.Anything, okID).Return(nil)
+	mockService.On("Confirm", mock.Anything, lateID).Return(domain.ErrConfirmTooLate)
+	mockService.On("Confirm", mock.Anything, plainID).Return(domain.ErrConfirmNotExpected)
+
+	confirm := func(id uuid.UUID) int {
+		req, _ := http.NewRequest("PUT", "/notify/"+id.String()+"/confirm", nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		h.ConfirmNotificationHandler(c)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, confirm(okID))
+	assert.Equal(t, http.StatusConflict, confirm(lateID))
+	assert.Equal(t, http.StatusConflict, confirm(plainID))
+	mockService.AssertExpectations(t)
+}
+
+// TestApproveNotificationHandler проверяет коды ответа одобрения и обязательность approver
+func TestApproveNotificationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	okID, cancelledID := uuid.New(), uuid.New()
+	mockService.On("Approve", mock.Anything, okID, "compliance@example.com").
+		Return(&domain.Notification{ID: okID, Status: domain.StatusPending, ApprovedBy: "compliance@example.com"}, nil)
+	mockService.On("Approve", mock.Anything, cancelledID, "compliance@example.com").
+		Return(nil, domain.ErrNotAwaitingApproval)
+
+	approve := func(id uuid.UUID, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/notify/"+id.String()+"/approve", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		h.ApproveNotificationHandler(c)
+		return w
+	}
+
+	w := approve(okID, `{"approver": "compliance@example.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"approved_by":"compliance@example.com"`)
+	assert.Equal(t, http.StatusConflict, approve(cancelledID, `{"approver": "compliance@example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, approve(okID, `{}`).Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestDraftHandlers проверяет изменение черновика и его планирование
+func TestDraftHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	draftID, sentID := uuid.New(), uuid.New()
+	recipient := "new@example.com"
+	mockService.On("UpdateDraft", mock.Anything, draftID, mock.MatchedBy(func(p domain.DraftUpdateParams) bool {
+		return p.Recipient != nil && *p.Recipient == recipient && p.Payload["subject"] == "Hi" &&
+			p.Channel == nil && p.ScheduledAt == nil
+	})).Return(&domain.Notification{ID: draftID, Status: domain.StatusDraft, Recipient: recipient}, nil)
+	mockService.On("ScheduleDraft", mock.Anything, draftID, domain.ScheduleParams{}).
+		Return(&domain.Notification{ID: draftID, Status: domain.StatusPending}, nil)
+	mockService.On("ScheduleDraft", mock.Anything, sentID, domain.ScheduleParams{Immediate: true}).
+		Return(nil, domain.ErrNotDraft)
+
+	call := func(method, path string, id uuid.UUID, body string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		handler(c)
+		return w
+	}
+
+	w := call("PATCH", "/notify/"+draftID.String(), draftID,
+		`{"recipient": "new@example.com", "payload": "{\"subject\":\"Hi\"}"}`, h.UpdateDraftHandler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"draft"`)
+	assert.Equal(t, http.StatusBadRequest, call("PATCH", "/notify/"+draftID.String(), draftID,
+		`{"channel": "fax"}`, h.UpdateDraftHandler).Code)
+
+	// тело необязательно: используется время из черновика
+	w = call("POST", "/notify/"+draftID.String()+"/schedule", draftID, ``, h.ScheduleDraftHandler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+	assert.Equal(t, http.StatusConflict, call("POST", "/notify/"+sentID.String()+"/schedule", sentID,
+		`{"immediate": true}`, h.ScheduleDraftHandler).Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestReceiptNotificationHandler проверяет прием квитанций и допустимые статусы
+func TestReceiptNotificationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	sentID, pendingID := uuid.New(), uuid.New()
+	mockService.On("RecordReceipt", mock.Anything, sentID, domain.StatusDelivered, "").
+		Return(&domain.Notification{ID: sentID, Status: domain.StatusDelivered}, nil)
+	mockService.On("RecordReceipt", mock.Anything, pendingID, domain.StatusRead, "").
+		Return(nil, domain.ErrInvalidTransition)
+	mockService.On("RecordReceipt", mock.Anything, sentID, domain.StatusDelivered, "evt-1").
+		Return(nil, domain.ErrDuplicateEvent)
+
+	receipt := func(id uuid.UUID, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/notify/"+id.String()+"/receipt", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		h.ReceiptNotificationHandler(c)
+		return w
+	}
+
+	w := receipt(sentID, `{"status": "delivered"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"delivered"`)
+	assert.Equal(t, http.StatusConflict, receipt(pendingID, `{"status": "read"}`).Code)
+	w = receipt(sentID, `{"status": "delivered", "event_id": "evt-1"}`)
+	assert.Equal(t, http.StatusOK, w.Code, "повтор события подтверждается, чтобы провайдер не слал его снова")
+	assert.Contains(t, w.Body.String(), "already processed")
+	assert.Equal(t, http.StatusBadRequest, receipt(sentID, `{"status": "sent"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, receipt(sentID, `{"status": "expired"}`).Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestSnoozeRecipientHandler проверяет разбор длительности паузы и параметров пути
+func TestSnoozeRecipientHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	until := time.Now().Add(2 * time.Hour)
+	mockService.On("SnoozeRecipient", mock.Anything, domain.ChannelEmail, "user@example.com", 2*time.Hour).
+		Return(until, nil)
+
+	snooze := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/recipients/email/user@example.com/snooze", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "channel", Value: "email"}, {Key: "recipient", Value: "user@example.com"}}
+		h.SnoozeRecipientHandler(c)
+		return w
+	}
+
+	w := snooze(`{"duration": "2h"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "snoozed_until")
+	assert.Equal(t, http.StatusBadRequest, snooze(`{"duration": "-5m"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, snooze(`{"duration": "tomorrow"}`).Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestListNotificationsHandler проверяет разбор фильтров, страницу по умолчанию и ошибки параметров
+func TestListNotificationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	n := domain.Notification{ID: uuid.New(), Recipient: "user@example.com", Channel: domain.ChannelEmail,
+		Status: domain.StatusPending}
+	mockService.On("ListNotifications", mock.Anything, domain.ListFilter{
+		Status: domain.StatusPending, Channel: domain.ChannelEmail, Recipient: "user@example.com",
+		ScheduledFrom: from, ScheduledTo: to,
+	}, 20, 0).Return([]domain.Notification{n}, true, nil)
+	mockService.On("ListNotifications", mock.Anything, domain.ListFilter{Status: "lost"}, 5, 10).
+		Return(nil, false, domain.ErrInvalidStatus)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/notify/?"+query, nil)
+		h.ListNotificationsHandler(c)
+		return w
+	}
+
+	w := list("status=pending&channel=email&recipient=user@example.com" +
+		"&scheduled_from=2025-01-01T00:00:00Z&scheduled_to=2025-01-02T00:00:00Z")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.NotificationListResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Result.Items, 1)
+	assert.Equal(t, n.ID, response.Result.Items[0].ID)
+	assert.Equal(t, 20, response.Result.Limit)
+	assert.True(t, response.Result.HasMore)
+
+	w = list("status=lost&limit=5&offset=10")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"Status"`)
+
+	for _, query := range []string{"limit=0x", "limit=101", "offset=-1", "scheduled_from=yesterday"} {
+		assert.Equal(t, http.StatusBadRequest, list(query).Code, query)
+	}
+	mockService.AssertExpectations(t)
+}
+
+// TestPreviewScheduleHandler проверяет разбор времени в часовом поясе и ошибки валидации
+func TestPreviewScheduleHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	at := time.Date(2030, 3, 1, 9, 0, 0, 0, moscow)
+	mockService.On("PreviewSchedule", mock.Anything, mock.MatchedBy(func(p domain.SchedulePreviewParams) bool {
+		return p.ScheduledAt.Equal(at) && p.Location.String() == "Europe/Moscow" && p.Smooth
+	})).Return(&domain.SchedulePreview{ScheduledAt: at, EarliestAt: at, LatestAt: at.Add(30 * time.Minute),
+		SmoothWindow: 30 * time.Minute}, nil)
+	mockService.On("PreviewSchedule", mock.Anything, mock.MatchedBy(func(p domain.SchedulePreviewParams) bool {
+		return p.Location == nil
+	})).Return(nil, domain.ErrScheduledTooFarInFuture)
+
+	preview := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/schedule/preview", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.PreviewScheduleHandler(c)
+		return w
+	}
+
+	w := preview(`{"scheduled_at": "2030-03-01T09:00:00", "timezone": "Europe/Moscow", "smooth": true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.SchedulePreviewResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, at.Add(30*time.Minute).Equal(response.Result.LatestAt))
+	assert.Equal(t, "30m0s", response.Result.SmoothWindow)
+
+	w = preview(`{"scheduled_at": "2090-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"ScheduledAt"`)
+
+	for _, body := range []string{`{}`, `{"scheduled_at": "tomorrow"}`,
+		`{"scheduled_at": "2030-03-01T09:00:00Z", "timezone": "Mars/Olympus"}`,
+		`{"scheduled_at": "2030-03-01T09:00:00Z", "channel": "pigeon"}`} {
+		assert.Equal(t, http.StatusBadRequest, preview(body).Code, body)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestStatusLinkHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService, handlers.WithPublicBaseURL("https://notify.example.com/"))
+	id := uuid.New()
+	expiresAt := time.Now().Add(24 * time.Hour)
+	mockService.On("StatusLink", mock.Anything, id, 24*time.Hour).
+		Return(&domain.StatusLink{Token: "tok", ExpiresAt: expiresAt}, nil)
+
+	req, _ := http.NewRequest("POST", "/notify/"+id.String()+"/status-link", strings.NewReader(`{"ttl":"24h"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: id.String()}}
+
+	h.StatusLinkHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.StatusLinkResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "https://notify.example.com/s/tok", response.Result.URL)
+	mockService.AssertExpectations(t)
+}
+
+func TestPublicStatusHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+	mockService.On("PublicStatus", mock.Anything, "good").Return(&domain.PublicStatus{
+		State:       domain.PublicStateSent,
+		Channel:     domain.ChannelEmail,
+		ScheduledAt: time.Now(),
+		UpdatedAt:   time.Now(),
+	}, nil)
+	mockService.On("PublicStatus", mock.Anything, "old").Return(nil, domain.ErrStatusTokenExpired)
+	mockService.On("PublicStatus", mock.Anything, "forged").Return(nil, domain.ErrInvalidStatusToken)
+
+	serve := func(token, query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/s/"+token+query, nil)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "token", Value: token}}
+		h.PublicStatusHandler(c)
+		return w
+	}
+
+	w := serve("good", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), "Отправлено")
+
+	w = serve("good", "?format=json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"sent"`)
+
+	assert.Equal(t, http.StatusGone, serve("old", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("forged", "?format=json").Code)
+}
+
+// TestCreateNotificationHandler_Template проверяет создание по шаблону: payload необязателен,
+// variables дополняют его, а variables без template_id отклоняются
+func TestCreateNotificationHandler_Template(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+
+	templateID := uuid.New()
+	created := &domain.Notification{ID: uuid.New(), Recipient: "test@example.com", Channel: domain.ChannelEmail,
+		Status: domain.StatusPending, TemplateID: &templateID}
+	mockService.On("CreateNotification", mock.Anything, mock.MatchedBy(func(p domain.CreateNotificationParams) bool {
+		return p.TemplateID != nil && *p.TemplateID == templateID &&
+			p.Payload["name"] == "Анна" && p.Payload["lang"] == "ru"
+	})).Return(created, nil).Once()
+	mockService.On("CreateNotification", mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("%w: body: missing key", domain.ErrTemplateRender)).Once()
+
+	post := func(extra string) *httptest.ResponseRecorder {
+		body := `{"recipient":"test@example.com","channel":"email","source":"billing",` + extra +
+			`,"scheduled_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/notify", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.CreateNotificationHandler(c)
+		return w
+	}
+
+	w := post(`"template_id":"` + templateID.String() + `","payload":"{\"lang\":\"ru\",\"name\":\"x\"}",` +
+		`"variables":{"name":"Анна"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = post(`"template_id":"` + templateID.String() + `"`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Variables")
+
+	w = post(`"variables":{"name":"Анна"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Payload")
+	assert.Contains(t, w.Body.String(), "Variables")
+	mockService.AssertNumberOfCalls(t, "CreateNotification", 2)
+}
+
+// TestTemplateHandlers проверяет коды ответов CRUD шаблонов
+func TestTemplateHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService)
+	router := gin.New()
+	router.POST("/templates", h.CreateTemplateHandler)
+	router.GET("/templates/:id", h.GetTemplateHandler)
+	router.DELETE("/templates/:id", h.DeleteTemplateHandler)
+
+	id, used := uuid.New(), uuid.New()
+	params := domain.TemplateParams{Name: "welcome", Body: "Привет, {{.name}}"}
+	mockService.On("CreateTemplate", mock.Anything, params).
+		Return(&domain.Template{ID: id, Name: params.Name, Body: params.Body}, nil).Once()
+	mockService.On("CreateTemplate", mock.Anything, params).Return(nil, domain.ErrDuplicateTemplateName).Once()
+	mockService.On("GetTemplate", mock.Anything, id).Return(nil, domain.ErrTemplateNotFound)
+	mockService.On("DeleteTemplate", mock.Anything, used).Return(domain.ErrTemplateInUse)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	create := `{"name":"welcome","body":"Привет, {{.name}}"}`
+	w := do("POST", "/templates", create)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result handlers.TemplateResponse `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, id, response.Result.ID)
+
+	assert.Equal(t, http.StatusConflict, do("POST", "/templates", create).Code)
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/templates", `{"name":"empty"}`).Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/templates/"+id.String(), "").Code)
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/templates/bad", "").Code)
+	assert.Equal(t, http.StatusConflict, do("DELETE", "/templates/"+used.String(), "").Code)
+}
+
+// TestIfMatchHandlers проверяет перенос If-Match в условие сервиса, 412 при изменении
+// уведомления и 428 без заголовка, когда он обязателен
+func TestIfMatchHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockNotificationService)
+	h := handlers.NewHandlersSet(mockService, handlers.WithRequireIfMatch(true))
+
+	n := &domain.Notification{ID: uuid.New(), Status: domain.StatusDraft, UpdatedAt: time.Now()}
+	changed, draftID := uuid.New(), uuid.New()
+	var match domain.Precondition
+	mockService.On("Cancel", mock.Anything, n.ID).Run(func(args mock.Arguments) {
+		match = domain.PreconditionFromContext(args.Get(0).(context.Context))
+	}).Return(nil)
+	mockService.On("Cancel", mock.Anything, changed).Return(domain.ErrPreconditionFailed)
+	mockService.On("UpdateDraft", mock.Anything, draftID, mock.Anything).
+		Return(&domain.Notification{ID: draftID, Status: domain.StatusDraft, UpdatedAt: time.Now()}, nil)
+
+	call := func(method string, id uuid.UUID, ifMatch string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/notify/"+id.String(), strings.NewReader(`{"recipient":"a@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = []gin.Param{{Key: "id", Value: id.String()}}
+		handler(c)
+		return w
+	}
+
+	etag := `W/"` + strconv.FormatInt(n.UpdatedAt.UnixNano(), 36) + `"`
+	assert.Equal(t, http.StatusOK, call("DELETE", n.ID, etag, h.DeleteNotificationHandler).Code)
+	if assert.NotNil(t, match) {
+		assert.True(t, match(n))
+		assert.False(t, match(&domain.Notification{UpdatedAt: n.UpdatedAt.Add(time.Microsecond)}))
+	}
+
+	assert.Equal(t, http.StatusPreconditionFailed, call("DELETE", changed, `"stale"`, h.DeleteNotificationHandler).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, call("DELETE", n.ID, "", h.DeleteNotificationHandler).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, call("PATCH", draftID, "", h.UpdateDraftHandler).Code)
+	mockService.AssertNumberOfCalls(t, "Cancel", 2)
+
+	w := call("PATCH", draftID, "*", h.UpdateDraftHandler)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+}
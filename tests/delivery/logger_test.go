@@ -0,0 +1,44 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"DelayedNotifier/internal/delivery/middleware"
+	"DelayedNotifier/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// TestRequestIDMiddleware_ContextLogger проверяет, что логгер из контекста запроса
+// содержит request_id, а ID уведомления добавляется к нему
+func TestRequestIDMiddleware_ContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	prev := zlog.Logger
+	zlog.Logger = zerolog.New(&buf)
+	defer func() { zlog.Logger = prev }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestIDMiddleware())
+	r.GET("/", func(c *gin.Context) {
+		ctx := logger.WithNotificationID(c.Request.Context(), "n-1")
+		logger.FromContext(ctx).Info().Msg("hello")
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, buf.String(), `"request_id":"req-42"`)
+	assert.Contains(t, buf.String(), `"notification_id":"n-1"`)
+
+	// без логгера в контексте используется глобальный
+	assert.Equal(t, &zlog.Logger, logger.FromContext(context.Background()))
+}
@@ -0,0 +1,65 @@
+package delivery_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"DelayedNotifier/internal/delivery/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMaintenance проверяет, что в режиме обслуживания GET работают, изменения получают 503,
+// а переключатель доступен всегда
+func TestMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := middleware.NewMaintenance(false, "", "/admin/maintenance")
+	r := gin.New()
+	r.Use(m.Middleware())
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"result": "ok"}) }
+	r.GET("/notify/:id", ok)
+	r.POST("/notify/", ok)
+	r.DELETE("/notify/:id", ok)
+	r.GET("/admin/maintenance", m.Handler())
+	r.PUT("/admin/maintenance", m.UpdateHandler())
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/notify/", "{}").Code)
+
+	w := do(http.MethodPut, "/admin/maintenance", `{"enabled":true,"message":"db failover"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Result middleware.MaintenanceState `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Result.Enabled)
+	assert.NotNil(t, response.Result.Since)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/notify/1", "").Code)
+	for _, req := range [][2]string{{http.MethodPost, "/notify/"}, {http.MethodDelete, "/notify/1"}} {
+		w = do(req[0], req[1], "{}")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, req[0])
+		assert.Contains(t, w.Body.String(), "db failover", req[0])
+		assert.Equal(t, "30", w.Header().Get("Retry-After"), req[0])
+	}
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/admin/maintenance", "").Code)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/admin/maintenance", `{}`).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/admin/maintenance", `{"enabled":false}`).Code)
+	assert.False(t, m.State().Enabled)
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/notify/1", "").Code)
+
+	// включенный при запуске режим использует сообщение по умолчанию
+	assert.Equal(t, middleware.DefaultMaintenanceMessage, middleware.NewMaintenance(true, "").State().Message)
+}
@@ -0,0 +1,69 @@
+package delivery_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"DelayedNotifier/internal/delivery/handlers"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwaggerHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/swagger/*any", handlers.SwaggerHandler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/swagger/openapi.json")
+	require.Equal(t, http.StatusOK, w.Code)
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	paths := spec["paths"].(map[string]interface{})
+	for path, methods := range map[string][]string{"/notify/": {"post", "get"}, "/notify/{id}": {"get", "delete"},
+		"/notify/group/{group_id}": {"get"}} {
+		for _, m := range methods {
+			op, ok := paths[path].(map[string]interface{})[m].(map[string]interface{})
+			require.True(t, ok, "%s %s", m, path)
+			assert.Contains(t, op["responses"], "500", "%s %s", m, path)
+		}
+	}
+	// каждая ссылка $ref указывает на существующий компонент
+	components := spec["components"].(map[string]interface{})
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+				require.Len(t, parts, 2, ref)
+				assert.Contains(t, components[parts[0]], parts[1], ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+
+	w = get("/swagger/index.html")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url: "openapi.json"`)
+	assert.Equal(t, http.StatusNotFound, get("/swagger/missing").Code)
+}
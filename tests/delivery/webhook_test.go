@@ -0,0 +1,63 @@
+package delivery_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/delivery/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWebhookSignatureMiddleware проверяет подпись и окно времени вебхука квитанций,
+// а также что обработчик получает тело после проверки
+func TestWebhookSignatureMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "receipt-secret"
+	body := `{"status":"delivered","event_id":"evt-1"}`
+
+	newRouter := func(secret string) *gin.Engine {
+		r := gin.New()
+		r.POST("/receipt", middleware.WebhookSignatureMiddleware(secret, 5*time.Minute), func(c *gin.Context) {
+			data, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(data))
+		})
+		return r
+	}
+	do := func(r *gin.Engine, ts time.Time, signature string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/receipt", strings.NewReader(body))
+		req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(ts.Unix(), 10))
+		req.Header.Set("X-Signature", signature)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	sign := func(ts time.Time) string {
+		return middleware.WebhookSignature(secret, strconv.FormatInt(ts.Unix(), 10), []byte(body))
+	}
+	r := newRouter(secret)
+
+	now := time.Now()
+	w := do(r, now, sign(now))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, http.StatusOK, do(r, now, "sha256="+sign(now)).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, do(r, now, sign(now.Add(time.Second))).Code, "подпись другого времени")
+	assert.Equal(t, http.StatusUnauthorized, do(r, now, "deadbeef").Code)
+	old := now.Add(-10 * time.Minute)
+	assert.Equal(t, http.StatusUnauthorized, do(r, old, sign(old)).Code, "запрос за пределами окна")
+
+	req, _ := http.NewRequest(http.MethodPost, "/receipt", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "без заголовков подписи")
+
+	// без ключа проверка отключена
+	assert.Equal(t, http.StatusOK, do(newRouter(""), old, "").Code)
+}
@@ -38,6 +38,12 @@ func TestStatus_IsValid(t *testing.T) {
 		{domain.StatusSent, true},
 		{domain.StatusFailed, true},
 		{domain.StatusCancelled, true},
+		{domain.StatusDelivered, true},
+		{domain.StatusRead, true},
+		{domain.StatusBounced, true},
+		{domain.StatusExpired, true},
+		{domain.StatusSuppressed, true},
+		{domain.StatusAwaitingApproval, true},
 		{"invalid_status", false},
 		{"", false},
 	}
@@ -49,6 +55,22 @@ func TestStatus_IsValid(t *testing.T) {
 	}
 }
 
+func TestStatus_CanTransitionTo(t *testing.T) {
+	assert.True(t, domain.StatusSent.CanTransitionTo(domain.StatusDelivered))
+	assert.True(t, domain.StatusSent.CanTransitionTo(domain.StatusRead))
+	assert.True(t, domain.StatusDelivered.CanTransitionTo(domain.StatusRead))
+	assert.False(t, domain.StatusRead.CanTransitionTo(domain.StatusDelivered))
+	assert.False(t, domain.StatusPending.CanTransitionTo(domain.StatusDelivered))
+	assert.False(t, domain.StatusFailed.CanTransitionTo(domain.StatusRead))
+	assert.False(t, domain.StatusCancelled.CanTransitionTo(domain.StatusPending))
+	assert.True(t, domain.StatusProcessing.CanTransitionTo(domain.StatusBounced))
+	assert.True(t, domain.StatusSent.CanTransitionTo(domain.StatusBounced))
+	assert.True(t, domain.StatusProcessing.CanTransitionTo(domain.StatusSuppressed))
+	assert.True(t, domain.StatusFailed.CanTransitionTo(domain.StatusExpired))
+	assert.False(t, domain.StatusBounced.CanTransitionTo(domain.StatusProcessing))
+	assert.False(t, domain.StatusExpired.CanTransitionTo(domain.StatusPending))
+}
+
 func TestChannel_String(t *testing.T) {
 	tests := []struct {
 		channel  domain.Channel
@@ -103,12 +125,37 @@ func TestNotification_Create(t *testing.T) {
 }
 
 func TestJob_Serialization(t *testing.T) {
-	job := domain.Job{
-		NotificationID: uuid.New().String(),
-	}
+	id := uuid.New()
+	body, err := domain.NewJob(id).Marshal()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version":1,"notification_id":"`+id.String()+`"}`, string(body))
 
-	assert.NotEmpty(t, job.NotificationID)
-	assert.Len(t, job.NotificationID, 36) // UUID length
+	job, parsed, err := domain.ParseJob(body)
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+	assert.Equal(t, domain.JobSchemaVersion, job.SchemaVersion)
+}
+
+func TestParseJob(t *testing.T) {
+	id := uuid.New()
+
+	// старый формат без версии
+	job, parsed, err := domain.ParseJob([]byte(`{"notification_id":"` + id.String() + `"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+	assert.Equal(t, 0, job.SchemaVersion)
+
+	for name, body := range map[string]string{
+		"malformed":      `{"notification_id":`,
+		"missing id":     `{"schema_version":1}`,
+		"not uuid":       `{"schema_version":1,"notification_id":"42"}`,
+		"future version": `{"schema_version":2,"notification_id":"` + id.String() + `"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := domain.ParseJob([]byte(body))
+			assert.ErrorIs(t, err, domain.ErrInvalidJob)
+		})
+	}
 }
 
 func TestCreateParams_Validation(t *testing.T) {
@@ -153,3 +200,62 @@ func TestOptionalPayload(t *testing.T) {
 	assert.True(t, optPayload.Set)
 	assert.Equal(t, payload, optPayload.Value)
 }
+
+// TestChannelDefaults_Apply проверяет, что значения payload важнее значений канала по умолчанию
+func TestChannelDefaults_Apply(t *testing.T) {
+	d := domain.ChannelDefaults{
+		domain.ChannelEmail: {domain.PayloadFromName: "Shop", "footer": "bye"},
+	}
+	payload := map[string]interface{}{"subject": "Hi", domain.PayloadFromName: "Support"}
+
+	got := d.Apply(domain.ChannelEmail, payload)
+	assert.Equal(t, map[string]interface{}{"subject": "Hi", domain.PayloadFromName: "Support", "footer": "bye"}, got)
+	assert.Len(t, payload, 2, "source payload must not change")
+
+	assert.Equal(t, payload, d.Apply(domain.ChannelSMS, payload))
+	assert.Equal(t, map[string]interface{}{domain.PayloadFromName: "Shop", "footer": "bye"},
+		d.Apply(domain.ChannelEmail, nil))
+	assert.True(t, domain.IsSendOption(domain.PayloadParseMode))
+	assert.False(t, domain.IsSendOption("body"))
+}
+
+// TestNewGroupStatus проверяет сводку группы по статусам и признак завершения
+func TestNewGroupStatus(t *testing.T) {
+	groupID := uuid.New()
+	g := domain.NewGroupStatus(groupID, []domain.Notification{
+		{Status: domain.StatusSent},
+		{Status: domain.StatusDelivered},
+		{Status: domain.StatusFailed},
+		{Status: domain.StatusPending},
+	})
+	assert.Equal(t, 4, g.Total())
+	assert.Equal(t, 1, g.ByStatus[domain.StatusSent])
+	assert.Equal(t, 2, g.ByState[domain.PublicStateSent])
+	assert.Equal(t, 1, g.ByState[domain.PublicStateFailed])
+	assert.False(t, g.Completed())
+
+	g = domain.NewGroupStatus(groupID, []domain.Notification{{Status: domain.StatusSent}, {Status: domain.StatusCancelled}})
+	assert.True(t, g.Completed())
+}
+
+// TestTemplateRender проверяет подстановку, необязательный subject и ошибку на отсутствующей переменной
+func TestTemplateRender(t *testing.T) {
+	tpl := domain.Template{Body: "Здравствуйте, {{.name}}"}
+	vars := map[string]interface{}{"name": "Анна", "subject": "Тема из payload"}
+	got, err := tpl.Render(vars)
+	assert.NoError(t, err)
+	assert.Equal(t, "Здравствуйте, Анна", got["body"])
+	assert.Equal(t, "Тема из payload", got["subject"])
+	assert.NotContains(t, vars, "body", "source payload must not change")
+
+	tpl.Subject = "Для {{.name}}"
+	got, err = tpl.Render(vars)
+	assert.NoError(t, err)
+	assert.Equal(t, "Для Анна", got["subject"])
+
+	_, err = tpl.Render(map[string]interface{}{"nickname": "Анна"})
+	assert.ErrorIs(t, err, domain.ErrTemplateRender)
+
+	_, _, err = (&domain.Template{Body: "{{if .name}}"}).Parse()
+	assert.ErrorIs(t, err, domain.ErrInvalidTemplate)
+}
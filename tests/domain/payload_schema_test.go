@@ -0,0 +1,77 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeJSON(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestCompileSchema(t *testing.T) {
+	_, err := domain.CompileSchema(decodeJSON(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "Заказ",
+		"type": "object",
+		"required": ["order"],
+		"properties": {"order": {"type": "string", "pattern": "^[0-9]+$"}},
+		"additionalProperties": false
+	}`))
+	assert.NoError(t, err)
+
+	for doc, at := range map[string]string{
+		`{"type": "text"}`: "schema.type",
+		`{"properties": {"order": {"minLength": -1}}}`: "schema.properties.order.minLength",
+		`{"properties": {"order": {"pattern": "("}}}`:  "schema.properties.order.pattern",
+		`{"oneOf": [{"type": "string"}]}`:              "schema.oneOf",
+		`{"items": true}`:                              "schema.items",
+		`{"required": "order"}`:                        "schema.required",
+	} {
+		_, err := domain.CompileSchema(decodeJSON(t, doc))
+		assert.ErrorIs(t, err, domain.ErrInvalidSchema, doc)
+		assert.ErrorContains(t, err, at+":", doc)
+	}
+}
+
+func TestJSONSchema_Validate(t *testing.T) {
+	schema, err := domain.CompileSchema(decodeJSON(t, `{
+		"type": "object",
+		"required": ["order", "amount"],
+		"properties": {
+			"order": {"type": "string", "minLength": 1},
+			"amount": {"type": "integer", "minimum": 1},
+			"currency": {"enum": ["RUB", "USD"]},
+			"items": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+		},
+		"additionalProperties": false
+	}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, schema.Validate(decodeJSON(t, `{"order": "42", "amount": 100, "currency": "RUB"}`)))
+	assert.NoError(t, schema.Validate(map[string]interface{}{"order": "42", "amount": 3}),
+		"числа из Go-кода сравниваются по значению")
+
+	for payload, at := range map[string]string{
+		`{"amount": 1}`:                                          "payload.order: required",
+		`{"order": "", "amount": 1}`:                             "payload.order: shorter than 1",
+		`{"order": "42", "amount": 1.5}`:                         "payload.amount: expected integer, got number",
+		`{"order": "42", "amount": 0}`:                           "payload.amount: less than 1",
+		`{"order": "42", "amount": 1, "currency": "EUR"}`:        "payload.currency: must be one of",
+		`{"order": "42", "amount": 1, "items": [1]}`:             "payload.items[0]: expected string",
+		`{"order": "42", "amount": 1, "items": ["a", "b", "c"]}`: "payload.items: more than 2 items",
+		`{"order": "42", "amount": 1, "note": "x"}`:              "payload.note: property is not allowed",
+	} {
+		err := schema.Validate(decodeJSON(t, payload))
+		assert.ErrorIs(t, err, domain.ErrSchemaMismatch, payload)
+		assert.ErrorContains(t, err, at, payload)
+	}
+}
@@ -0,0 +1,92 @@
+package domain_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifySendError(t *testing.T) {
+	base := errors.New("provider error")
+	tests := []struct {
+		name     string
+		err      error
+		expected domain.ErrorClass
+	}{
+		{"unclassified", base, domain.ErrorTransient},
+		{"transient", domain.TransientError(base), domain.ErrorTransient},
+		{"permanent", domain.PermanentError(base), domain.ErrorPermanent},
+		{"throttled", domain.ThrottledError(base), domain.ErrorThrottled},
+		{"wrapped", fmt.Errorf("send: %w", domain.PermanentError(base)), domain.ErrorPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, domain.ClassifySendError(tt.err))
+			assert.ErrorIs(t, tt.err, base)
+		})
+	}
+}
+
+func TestClassifyHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected domain.ErrorClass
+	}{
+		{http.StatusTooManyRequests, domain.ErrorThrottled},
+		{http.StatusServiceUnavailable, domain.ErrorTransient},
+		{http.StatusBadGateway, domain.ErrorTransient},
+		{http.StatusRequestTimeout, domain.ErrorTransient},
+		{http.StatusBadRequest, domain.ErrorPermanent},
+		{http.StatusForbidden, domain.ErrorPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			assert.Equal(t, tt.expected, domain.ClassifyHTTPStatus(tt.code))
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	base := errors.New("429 Too Many Requests")
+
+	d, ok := domain.RetryAfter(fmt.Errorf("send: %w", domain.ThrottledErrorAfter(base, 30*time.Second)))
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	_, ok = domain.RetryAfter(domain.ThrottledError(base))
+	assert.False(t, ok)
+	_, ok = domain.RetryAfter(base)
+	assert.False(t, ok)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"seconds", "120", 2 * time.Minute, true},
+		{"http_date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{"past_date", now.Add(-time.Minute).Format(http.TimeFormat), 0, false},
+		{"zero", "0", 0, false},
+		{"empty", "", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := domain.ParseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
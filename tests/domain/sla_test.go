@@ -0,0 +1,35 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSLATargets(t *testing.T) {
+	targets, err := domain.ParseSLATargets(" email=95%@60s; billing/telegram=99.5@30s ;")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.SLATarget{
+		{Channel: domain.ChannelEmail, Objective: 0.95, Within: time.Minute},
+		{Source: "billing", Channel: domain.ChannelTelegram, Objective: 0.995, Within: 30 * time.Second},
+	}, targets)
+	assert.Equal(t, "email", targets[0].Name())
+	assert.Equal(t, "billing/telegram", targets[1].Name())
+
+	targets, err = domain.ParseSLATargets("")
+	assert.NoError(t, err)
+	assert.Empty(t, targets)
+
+	for _, spec := range []string{"email", "fax=95%@60s", "email=95%", "email=0%@60s", "email=101%@60s",
+		"email=95%@soon", "email=95%@-1s"} {
+		_, err := domain.ParseSLATargets(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSLACounts_Compliance(t *testing.T) {
+	assert.Equal(t, 1.0, domain.SLACounts{}.Compliance())
+	assert.InDelta(t, 0.9, domain.SLACounts{Met: 9, Total: 10}.Compliance(), 1e-9)
+}
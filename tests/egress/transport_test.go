@@ -0,0 +1,141 @@
+package egress_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/egress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSCache_LookupHost(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	cache := egress.NewDNSCache(50*time.Millisecond, func(ctx context.Context, host string) ([]string, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return nil, errors.New("dns is down")
+		}
+		return []string{"10.0.0.1"}, nil
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		addrs, err := cache.LookupHost(ctx, "api.telegram.org")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "cached within ttl")
+
+	time.Sleep(60 * time.Millisecond)
+	fail.Store(true)
+	addrs, err := cache.LookupHost(ctx, "api.telegram.org")
+	require.NoError(t, err, "stale addresses are served when lookup fails")
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	assert.Equal(t, int32(2), calls.Load())
+
+	_, err = cache.LookupHost(ctx, "unknown.example")
+	assert.Error(t, err)
+}
+
+func TestDNSCache_DialContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	cache := egress.NewDNSCache(time.Minute, func(ctx context.Context, host string) ([]string, error) {
+		calls.Add(1)
+		// первый адрес недоступен, соединение переходит ко второму
+		return []string{"127.0.0.2", "127.0.0.1"}, nil
+	})
+	client := &http.Client{Transport: &http.Transport{
+		DialContext:       cache.DialContext(&net.Dialer{Timeout: time.Second}),
+		DisableKeepAlives: true,
+	}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://hooks.test:" + port + "/")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestTransports_For(t *testing.T) {
+	transports := egress.NewTransports(egress.Tuning{
+		MaxIdleConnsPerHost:   64,
+		IdleConnTimeout:       time.Minute,
+		ResponseHeaderTimeout: 3 * time.Second,
+		DNSTTL:                time.Minute,
+	})
+	direct := egress.Proxy{URL: egress.Direct}
+	proxied := egress.Proxy{URL: "http://proxy.corp:3128"}
+
+	tr := transports.For(direct)
+	assert.Same(t, tr, transports.For(direct), "senders with the same proxy share one pool")
+	assert.NotSame(t, tr, transports.For(proxied))
+	assert.Equal(t, 64, tr.MaxIdleConnsPerHost)
+	assert.GreaterOrEqual(t, tr.MaxIdleConns, 64)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+	assert.Equal(t, 3*time.Second, tr.ResponseHeaderTimeout)
+	assert.Nil(t, tr.Proxy)
+	assert.NotNil(t, transports.For(proxied).Proxy)
+}
+
+func TestPublicOnly(t *testing.T) {
+	cases := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"0.0.0.0:80", false},
+		{"100.64.0.1:80", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.address, func(t *testing.T) {
+			err := egress.PublicOnly("tcp", tc.address, nil)
+			if tc.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, egress.ErrForbiddenAddress)
+			}
+		})
+	}
+}
+
+func TestTransports_Guarded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	transports := egress.NewTransports(egress.Tuning{})
+
+	tr := transports.Guarded()
+	assert.Same(t, tr, transports.Guarded())
+	assert.Nil(t, tr.Proxy, "the guard must see the destination address, not a proxy")
+
+	_, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	assert.ErrorIs(t, err, egress.ErrForbiddenAddress)
+}
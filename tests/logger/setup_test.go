@@ -0,0 +1,50 @@
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"DelayedNotifier/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// TestLoggerSetup_FileSinkWithRotation проверяет запись в файл и ротацию по размеру
+func TestLoggerSetup_FileSinkWithRotation(t *testing.T) {
+	prev := zlog.Logger
+	defer func() { zlog.Logger = prev }()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	closer, err := logger.Setup(logger.Options{
+		Level:   "info",
+		Format:  logger.FormatJSON,
+		Outputs: []string{logger.OutputFile},
+		File:    logger.FileOptions{Path: path, MaxSizeMB: 1, MaxBackups: 1},
+	})
+	assert.NoError(t, err)
+
+	msg := strings.Repeat("x", 64<<10)
+	for i := 0; i < 40; i++ {
+		zlog.Logger.Info().Msg(msg)
+	}
+	zlog.Logger.Debug().Msg("filtered by level")
+	assert.NoError(t, closer.Close())
+
+	backups, _ := filepath.Glob(path + ".*")
+	assert.Len(t, backups, 1)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"level":"info"`)
+	assert.NotContains(t, string(data), "filtered by level")
+}
+
+// TestLoggerSetup_UnknownOutput проверяет ошибку для неизвестного приемника
+func TestLoggerSetup_UnknownOutput(t *testing.T) {
+	prev := zlog.Logger
+	defer func() { zlog.Logger = prev }()
+
+	_, err := logger.Setup(logger.Options{Level: "info", Outputs: []string{"kafka"}})
+	assert.Error(t, err)
+}
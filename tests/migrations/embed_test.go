@@ -0,0 +1,38 @@
+package migrations_test
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"DelayedNotifier/migrations"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations_UpDownPairs(t *testing.T) {
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	set := make(map[string]bool, len(files))
+	for _, f := range files {
+		set[f] = true
+	}
+	for _, f := range files {
+		if base, ok := strings.CutSuffix(f, ".up.sql"); ok {
+			assert.True(t, set[base+".down.sql"], "missing down migration for %s", f)
+		}
+	}
+}
+
+func TestEmbeddedMigrations_ReadableByMigrate(t *testing.T) {
+	src, err := iofs.New(migrations.FS, ".")
+	require.NoError(t, err)
+	defer src.Close()
+
+	first, err := src.First()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), first)
+}
@@ -0,0 +1,57 @@
+package migrations_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/migrator"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLock_BreaksStaleHolder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	lockID := migrator.DefaultLockID
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(lockID).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(false))
+	mock.ExpectQuery(`FROM pg_locks`).WithArgs(lockID).
+		WillReturnRows(sqlmock.NewRows([]string{"pid", "state", "idle"}).AddRow(4242, "idle", 600.0))
+	mock.ExpectExec(`SELECT pg_terminate_backend\(\$1\)`).WithArgs(4242).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(lockID).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(lockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	lock := migrator.NewAdvisoryLock(db, lockID, migrator.WithStaleAfter(time.Minute),
+		migrator.WithPollInterval(time.Millisecond))
+	release, err := lock.Acquire(context.Background())
+	require.NoError(t, err)
+	release()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdvisoryLock_WaitsForActiveHolder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	lockID := migrator.DefaultLockID
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(lockID).
+			WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(false))
+		mock.ExpectQuery(`FROM pg_locks`).WithArgs(lockID).
+			WillReturnRows(sqlmock.NewRows([]string{"pid", "state", "idle"}).AddRow(4242, "active", 600.0))
+	}
+
+	lock := migrator.NewAdvisoryLock(db, lockID, migrator.WithStaleAfter(time.Minute),
+		migrator.WithLockTimeout(5*time.Millisecond), migrator.WithPollInterval(3*time.Millisecond))
+	_, err = lock.Acquire(context.Background())
+	assert.ErrorIs(t, err, migrator.ErrLockTimeout)
+}
@@ -0,0 +1,71 @@
+package migrations_test
+
+import (
+	"context"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+
+	"DelayedNotifier/internal/migrator"
+	"DelayedNotifier/migrations"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecks_CoverEveryMigration(t *testing.T) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	require.NoError(t, err)
+
+	for _, f := range files {
+		version, err := strconv.ParseUint(strings.SplitN(f, "_", 2)[0], 10, 64)
+		require.NoError(t, err, f)
+		assert.NotEmpty(t, migrations.Checks[uint(version)], "missing schema checks for %s", f)
+	}
+}
+
+func TestPlanRepair(t *testing.T) {
+	checks := map[uint][]migrator.SchemaCheck{
+		1: {{Name: "table", Query: "SELECT true"}},
+		2: {{Name: "column a", Query: "SELECT a"}, {Name: "column b", Query: "SELECT b"}},
+	}
+	run := func(t *testing.T, a, b bool) migrator.RepairPlan {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("SELECT a").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(a))
+		mock.ExpectQuery("SELECT b").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(b))
+
+		plan, err := migrator.PlanRepair(context.Background(), db, 2, true, checks)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		return plan
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		plan := run(t, true, true)
+		assert.True(t, plan.Safe)
+		assert.Equal(t, 2, plan.ForceTo)
+	})
+	t.Run("not applied", func(t *testing.T) {
+		plan := run(t, false, false)
+		assert.True(t, plan.Safe)
+		assert.Equal(t, 1, plan.ForceTo)
+	})
+	t.Run("partially applied", func(t *testing.T) {
+		plan := run(t, true, false)
+		assert.False(t, plan.Safe)
+		assert.Equal(t, []string{"column b"}, plan.Missing)
+	})
+	t.Run("clean", func(t *testing.T) {
+		plan, err := migrator.PlanRepair(context.Background(), nil, 2, false, checks)
+		require.NoError(t, err)
+		assert.True(t, plan.Safe)
+		assert.False(t, plan.Dirty)
+	})
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := migrator.PlanRepair(context.Background(), nil, 7, true, checks)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,49 @@
+package notifier_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"DelayedNotifier/pkg/notifier"
+	"DelayedNotifier/pkg/rabbitmq"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wb-go/wbf/redis"
+)
+
+// TestNew проверяет, что встроенный сервис собирается без HTTP-сервера и проверяет
+// получателя и payload по правилам каналов до обращения к базе
+func TestNew(t *testing.T) {
+	_, err := notifier.New(nil, nil, nil)
+	assert.Error(t, err)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	n, err := notifier.New(db, redis.New("localhost:0", "", 0), &rabbitmq.RabbitClient{},
+		notifier.WithNamespace("test"), notifier.WithWorkers(2, 1))
+	assert.NoError(t, err)
+
+	params := notifier.CreateParams{
+		Recipient:   "not-an-email",
+		Channel:     notifier.ChannelEmail,
+		Payload:     map[string]interface{}{"subject": "Тема", "body": "Текст"},
+		ScheduledAt: time.Now().Add(time.Hour),
+		Source:      "embedded",
+	}
+	_, err = n.Service().CreateNotification(context.Background(), params)
+	assert.ErrorIs(t, err, notifier.ErrInvalidRecipient)
+
+	params.Channel, params.Recipient = notifier.ChannelTelegram, "12345"
+	params.Payload = map[string]interface{}{"body": "Текст", "parse_mode": "BBCode"}
+	// схемы источника нет, payload проверяется правилами канала
+	mock.ExpectQuery(`FROM payload_schemas WHERE source = \$1 AND channel = \$2`).
+		WithArgs("embedded", notifier.ChannelTelegram).
+		WillReturnError(sql.ErrNoRows)
+	_, err = n.Service().CreateNotification(context.Background(), params)
+	assert.ErrorIs(t, err, notifier.ErrInvalidPayload)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
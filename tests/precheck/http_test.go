@@ -0,0 +1,122 @@
+package precheck_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/precheck"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPChecker_ShouldSend(t *testing.T) {
+	cases := []struct {
+		name     string
+		handler  http.HandlerFunc
+		failOpen bool
+		want     bool
+		wantErr  bool
+	}{
+		{"send false", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"send":false}`))
+		}, true, false, false},
+		{"send true", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"send":true}`))
+		}, false, true, false},
+		{"empty body", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, false, true, false},
+		{"error fail-open", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, true, true, true},
+		{"error fail-closed", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, false, false, true},
+		{"timeout fail-closed", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}, false, false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+			checker := precheck.NewHTTPChecker(50*time.Millisecond, tc.failOpen)
+
+			send, err := checker.ShouldSend(context.Background(), &domain.Notification{
+				ID:           uuid.New(),
+				PreSendCheck: srv.URL,
+			})
+
+			assert.Equal(t, tc.want, send)
+			assert.Equal(t, tc.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestHTTPChecker_RequestBody(t *testing.T) {
+	n := &domain.Notification{ID: uuid.New(), Recipient: "user@example.com", Channel: domain.ChannelEmail}
+	var got precheck.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	n.PreSendCheck = srv.URL
+
+	send, err := precheck.NewHTTPChecker(time.Second, false).ShouldSend(context.Background(), n)
+
+	assert.NoError(t, err)
+	assert.True(t, send)
+	assert.Equal(t, n.ID, got.ID)
+	assert.Equal(t, n.Recipient, got.Recipient)
+}
+
+func TestHTTPChecker_NoURL(t *testing.T) {
+	send, err := precheck.NewHTTPChecker(time.Second, false).ShouldSend(context.Background(), &domain.Notification{})
+
+	assert.NoError(t, err)
+	assert.True(t, send)
+}
+
+func TestHTTPChecker_RefusesRedirect(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer srv.Close()
+
+	send, err := precheck.NewHTTPChecker(time.Second, false).ShouldSend(context.Background(),
+		&domain.Notification{ID: uuid.New(), PreSendCheck: srv.URL})
+
+	assert.ErrorIs(t, err, precheck.ErrUnexpectedStatus)
+	assert.False(t, send)
+	assert.False(t, followed)
+}
+
+func TestHTTPChecker_AllowedHosts(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	denied := precheck.NewHTTPChecker(time.Second, false, precheck.WithAllowedHosts([]string{"hooks.example.com", ".corp.example"}))
+	send, err := denied.ShouldSend(ctx, &domain.Notification{ID: uuid.New(), PreSendCheck: srv.URL})
+	assert.ErrorIs(t, err, precheck.ErrHostNotAllowed)
+	assert.False(t, send)
+	assert.Zero(t, calls)
+
+	allowed := precheck.NewHTTPChecker(time.Second, false, precheck.WithAllowedHosts([]string{" 127.0.0.1 ", ""}))
+	send, err = allowed.ShouldSend(ctx, &domain.Notification{ID: uuid.New(), PreSendCheck: srv.URL})
+	assert.NoError(t, err)
+	assert.True(t, send)
+	assert.Equal(t, 1, calls)
+}
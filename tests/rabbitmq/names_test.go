@@ -0,0 +1,54 @@
+package rabbitmq_test
+
+import (
+	"testing"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/repository/rabbit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNames(t *testing.T) {
+	id := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+
+	plain := rabbit.NewNames("", "DelayedNotifier", "notification")
+	assert.Equal(t, "DelayedNotifier", plain.Exchange)
+	assert.Equal(t, "notification", plain.Queue)
+	assert.Equal(t, "notification.high", plain.HighQueue)
+	assert.Equal(t, "dlx", plain.DeadLetterExchange)
+	assert.Equal(t, "notification.dlq", plain.DeadLetterQueue)
+	assert.Equal(t, "queue:"+id.String(), plain.NotificationQueue(id))
+
+	staging := rabbit.NewNames("staging", "DelayedNotifier", "notification")
+	assert.Equal(t, "staging.DelayedNotifier", staging.Exchange)
+	assert.Equal(t, "staging.notification", staging.Queue)
+	assert.Equal(t, "staging.notification.high", staging.HighQueue)
+	assert.Equal(t, "staging.dlx", staging.DeadLetterExchange)
+	assert.Equal(t, "staging.notification.dlq", staging.DeadLetterQueue)
+	assert.Equal(t, "staging.queue:"+id.String(), staging.NotificationQueue(id))
+
+	assert.Equal(t, staging.HighQueue, staging.QueueFor(domain.PriorityHigh))
+	assert.Equal(t, staging.Queue, staging.QueueFor(domain.PriorityNormal))
+	assert.Equal(t, staging.Queue, staging.QueueFor(domain.PriorityLow))
+	assert.Equal(t, staging.Queue, staging.QueueFor(""))
+}
+
+func TestAppTopology_Namespace(t *testing.T) {
+	spec := rabbit.AppTopology(rabbit.NewNames("staging", "DelayedNotifier", "notification"))
+
+	for _, ex := range spec.Exchanges {
+		assert.Contains(t, []string{"staging.DelayedNotifier", "staging.dlx"}, ex.Name)
+	}
+	for _, q := range spec.Queues {
+		assert.Contains(t, []string{"staging.notification", "staging.notification.high", "staging.notification.dlq"}, q.Name)
+	}
+	for _, b := range spec.Bindings {
+		assert.Contains(t, []string{"staging.DelayedNotifier", "staging.dlx"}, b.Exchange)
+		assert.Equal(t, b.Queue, b.RoutingKey)
+	}
+	assert.Nil(t, spec.Queues[0].Args, "main queue must keep its original declaration")
+	assert.Equal(t, "staging.dlx", spec.Queues[1].Args["x-dead-letter-exchange"])
+	assert.Equal(t, "staging.notification.high", spec.Queues[1].Name)
+	assert.Equal(t, "staging.notification.dlq", spec.Queues[1].Args["x-dead-letter-routing-key"])
+}
@@ -0,0 +1,52 @@
+package rabbitmq_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"DelayedNotifier/pkg/rabbitmq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetchTuner_AIMD(t *testing.T) {
+	tuner := rabbitmq.NewPrefetchTuner(rabbitmq.AdaptivePrefetch{
+		Min:           1,
+		Max:           4,
+		TargetLatency: 100 * time.Millisecond,
+		MaxErrorRate:  0.2,
+	}, 2)
+
+	// без сообщений значение не меняется
+	_, changed := tuner.Next()
+	assert.False(t, changed)
+
+	tuner.Observe(10*time.Millisecond, nil)
+	next, changed := tuner.Next()
+	assert.True(t, changed)
+	assert.Equal(t, 3, next)
+
+	tuner.Observe(10*time.Millisecond, nil)
+	tuner.Next()
+	tuner.Observe(10*time.Millisecond, nil)
+	next, changed = tuner.Next()
+	assert.False(t, changed, "prefetch must not exceed Max")
+	assert.Equal(t, 4, next)
+
+	// медленная обработка — уменьшение вдвое
+	tuner.Observe(time.Second, nil)
+	next, _ = tuner.Next()
+	assert.Equal(t, 2, next)
+
+	// быстрая, но с ошибками — тоже уменьшение
+	tuner.Observe(time.Millisecond, errors.New("nack"))
+	tuner.Observe(time.Millisecond, nil)
+	next, _ = tuner.Next()
+	assert.Equal(t, 1, next)
+	assert.Equal(t, 1, tuner.Current())
+}
+
+func TestPrefetchTuner_ClampsInitial(t *testing.T) {
+	tuner := rabbitmq.NewPrefetchTuner(rabbitmq.AdaptivePrefetch{Min: 2, Max: 8}, 20)
+	assert.Equal(t, 8, tuner.Current())
+}
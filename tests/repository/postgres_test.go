@@ -2,13 +2,17 @@ package repository_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	"DelayedNotifier/internal/domain"
 	"DelayedNotifier/internal/repository/pg"
+	"DelayedNotifier/pkg/idgen"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -33,7 +37,8 @@ func TestPostgresRepo_Create_Success(t *testing.T) {
 	// Mock the INSERT query and RETURNING clause
 	jsonPayload, _ := json.Marshal(map[string]interface{}{"subject": "test"})
 	mock.ExpectQuery(`INSERT INTO notifications`).
-		WithArgs("test@example.com", domain.ChannelEmail, jsonPayload, sqlmock.AnyArg(), domain.StatusPending).
+		WithArgs("test@example.com", domain.ChannelEmail, jsonPayload, sqlmock.AnyArg(), domain.StatusPending,
+			uuid.NullUUID{}, uuid.NullUUID{}, false, "", sqlmock.AnyArg(), "", false).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}).
 			AddRow(notificationID, 0, now, now))
 
@@ -74,8 +79,8 @@ func TestPostgresRepo_GetByID_Success(t *testing.T) {
 
 	mock.ExpectQuery(`SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at`).
 		WithArgs(notificationID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at"}).
-			AddRow(notificationID, "test@example.com", domain.ChannelEmail, payload, now, domain.StatusPending, 0, now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(notificationID, "test@example.com", domain.ChannelEmail, payload, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
 
 	// Execute
 	result, err := repo.GetByID(context.Background(), notificationID)
@@ -160,6 +165,27 @@ func TestPostgresRepo_Update_NoRowsAffected(t *testing.T) {
 	assert.Equal(t, domain.ErrNoRowAffected, err)
 }
 
+// TestPostgresRepo_Update_IfUpdatedAt проверяет явный updated_at и условие оптимистичной блокировки
+func TestPostgresRepo_Update_IfUpdatedAt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := pg.NewPostgresRepo(&dbpg.DB{Master: db})
+	notificationID := uuid.New()
+	read := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	updatedAt := read.Add(time.Minute)
+
+	mock.ExpectExec(`UPDATE notifications SET status = \$1, updated_at = \$2 WHERE id = \$3 AND deleted_at IS NULL AND updated_at = \$4`).
+		WithArgs(domain.StatusCancelled, updatedAt, notificationID, read).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.Update(context.Background(), notificationID, domain.WithStatus(domain.StatusCancelled),
+		domain.WithUpdatedAt(updatedAt), domain.WithIfUpdatedAt(read))
+	assert.ErrorIs(t, err, domain.ErrNoRowAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgresRepo_Update_EmptyOptions(t *testing.T) {
 	// Setup
 	db, mock, err := sqlmock.New()
@@ -225,9 +251,9 @@ func TestPostgresRepo_ListPendingAndProcessingBefore_Success(t *testing.T) {
 
 	mock.ExpectQuery(`SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at`).
 		WithArgs(stuckTime, domain.StatusPending, domain.StatusProcessing).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at"}).
-			AddRow(notificationID1, "test1@example.com", domain.ChannelEmail, payload1, now, domain.StatusPending, 0, now, now).
-			AddRow(notificationID2, "test2@example.com", domain.ChannelTelegram, payload2, now, domain.StatusProcessing, 1, now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(notificationID1, "test1@example.com", domain.ChannelEmail, payload1, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal).
+			AddRow(notificationID2, "test2@example.com", domain.ChannelTelegram, payload2, now, domain.StatusProcessing, 1, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
 
 	// Execute
 	result, err := repo.ListPendingAndProcessingBefore(context.Background(), stuckTime, 0, 0)
@@ -253,7 +279,7 @@ func TestPostgresRepo_ListPendingAndProcessingBefore_Empty(t *testing.T) {
 
 	mock.ExpectQuery(`SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at`).
 		WithArgs(stuckTime, domain.StatusPending, domain.StatusProcessing).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at"}))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}))
 
 	// Execute
 	result, err := repo.ListPendingAndProcessingBefore(context.Background(), stuckTime, 0, 0)
@@ -374,10 +400,10 @@ func TestPostgresRepo_Update_WithLimit(t *testing.T) {
 
 	payload, _ := json.Marshal(map[string]interface{}{"subject": "test"})
 
-	mock.ExpectQuery(`SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at`).
-		WithArgs(stuckTime, domain.StatusPending, domain.StatusProcessing).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at"}).
-			AddRow(notificationID, "test@example.com", domain.ChannelEmail, payload, time.Now(), domain.StatusPending, 0, time.Now(), time.Now()))
+	mock.ExpectQuery(`SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at, parent_id, correlation_id, cancel_on_confirm, pre_send_check, effective_scheduled_at, source, reprocess_count, requires_approval, approved_by, approved_at, group_id, template_id, priority .* LIMIT \$4`).
+		WithArgs(stuckTime, domain.StatusPending, domain.StatusProcessing, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(notificationID, "test@example.com", domain.ChannelEmail, payload, time.Now(), domain.StatusPending, 0, time.Now(), time.Now(), nil, nil, false, "", time.Now(), "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
 
 	// Execute with limit
 	result, err := repo.ListPendingAndProcessingBefore(context.Background(), stuckTime, 10, 0)
@@ -386,3 +412,900 @@ func TestPostgresRepo_Update_WithLimit(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, result, 1)
 }
+
+func TestPostgresRepo_Delete_Success(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	notificationID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM notifications WHERE id = \$1`).
+		WithArgs(notificationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Execute
+	err = repo.Delete(context.Background(), notificationID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Delete_NotFound(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	notificationID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM notifications WHERE id = \$1`).
+		WithArgs(notificationID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Execute
+	err = repo.Delete(context.Background(), notificationID)
+
+	// Assertions
+	assert.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestPostgresRepo_SoftDelete_Success(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	notificationID := uuid.New()
+
+	mock.ExpectExec(`UPDATE notifications SET deleted_at = NOW\(\) WHERE id = \$1 AND deleted_at IS NULL`).
+		WithArgs(notificationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Execute
+	err = repo.SoftDelete(context.Background(), notificationID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_PurgeDeletedBefore(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	before := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectExec(`DELETE FROM notifications WHERE deleted_at IS NOT NULL AND deleted_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// Execute
+	purged, err := repo.PurgeDeletedBefore(context.Background(), before)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestPostgresRepo_CountBacklog(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM notifications WHERE deleted_at IS NULL AND status IN \(\$1, \$2\) AND effective_scheduled_at < \$3`).
+		WithArgs(domain.StatusPending, domain.StatusProcessing, now).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	// Execute
+	count, err := repo.CountBacklog(context.Background(), now)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_ListDuplicateDeliveries(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	since := time.Now().Add(-24 * time.Hour)
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT recipient, channel, md5\(payload::text\) AS payload_hash, min\(sent_at\), max\(sent_at\),.+ FROM notifications WHERE status IN \(\$1, \$2, \$3, \$4\) AND sent_at >= \$5 GROUP BY recipient, channel, payload_hash HAVING count\(\*\) > 1`).
+		WithArgs(domain.StatusSent, domain.StatusDelivered, domain.StatusRead, domain.StatusBounced, since, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"recipient", "channel", "payload_hash", "min", "max", "ids"}).
+			AddRow("test@example.com", "email", "abc", since, time.Now(), first.String()+","+second.String()))
+
+	// Execute
+	groups, err := repo.ListDuplicateDeliveries(context.Background(), since, 100)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, domain.ChannelEmail, groups[0].Channel)
+	assert.Equal(t, []uuid.UUID{first, second}, groups[0].IDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Search(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	now := time.Now()
+	id := uuid.New()
+	payload, _ := json.Marshal(map[string]interface{}{"subject": "Invoice 4521"})
+
+	mock.ExpectQuery(`SELECT id, recipient, .+ts_rank\(search_document, q\) \+ similarity\(recipient, \$1\) AS rank FROM notifications, websearch_to_tsquery\('simple', \$1\) q WHERE deleted_at IS NULL AND \(search_document @@ q OR recipient ILIKE \$2\) AND channel = \$3 ORDER BY rank DESC, created_at DESC LIMIT \$4`).
+		WithArgs("bob_1 100%", `%bob\_1 100\%%`, domain.ChannelEmail, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority", "rank"}).
+			AddRow(id, "bob@example.com", domain.ChannelEmail, payload, now, domain.StatusSent, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal, 0.75))
+
+	// Execute
+	hits, err := repo.Search(context.Background(), domain.SearchParams{Query: "bob_1 100%", Channel: domain.ChannelEmail, Limit: 20})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, id, hits[0].ID)
+	assert.Equal(t, "Invoice 4521", hits[0].Payload["subject"])
+	assert.InDelta(t, 0.75, hits[0].Rank, 1e-9)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_List(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	id := uuid.New()
+	payload, _ := json.Marshal(map[string]interface{}{"subject": "Hi"})
+
+	mock.ExpectQuery(`SELECT id, recipient, .+ FROM notifications WHERE deleted_at IS NULL AND status = \$1 AND recipient = \$2 AND scheduled_at >= \$3 ORDER BY scheduled_at DESC, id DESC LIMIT \$4 OFFSET \$5`).
+		WithArgs(domain.StatusPending, "user@example.com", from, 21, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(id, "user@example.com", domain.ChannelEmail, payload, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
+
+	// Execute
+	ns, err := repo.List(context.Background(), domain.ListFilter{
+		Status: domain.StatusPending, Recipient: "user@example.com", ScheduledFrom: from,
+	}, 21, 40)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, ns, 1)
+	assert.Equal(t, id, ns[0].ID)
+	assert.Equal(t, "Hi", ns[0].Payload["subject"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_List_IncludeDeleted(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	now := time.Now()
+	id := uuid.New()
+	payload, _ := json.Marshal(map[string]interface{}{"subject": "Hi"})
+
+	mock.ExpectQuery(`SELECT id, recipient, .+, priority, deleted_at FROM notifications WHERE channel = \$1 ORDER BY`).
+		WithArgs(domain.ChannelEmail, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority", "deleted_at"}).
+			AddRow(id, "user@example.com", domain.ChannelEmail, payload, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal, now))
+
+	// Execute
+	ns, err := repo.List(context.Background(), domain.ListFilter{Channel: domain.ChannelEmail, IncludeDeleted: true}, 10, 0)
+
+	// Assertions
+	assert.NoError(t, err)
+	if assert.Len(t, ns, 1) {
+		assert.NotNil(t, ns[0].DeletedAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_RecordFailure(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	id := uuid.New()
+	now := time.Now()
+	scheduled := now.Add(-time.Hour)
+
+	mock.ExpectQuery(`INSERT INTO notification_failures \(notification_id, error, attempts, scheduled_at\)`).
+		WithArgs(id, "smtp timeout", 10, scheduled).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "failed_at"}).AddRow(int64(7), now))
+	mock.ExpectQuery(`SELECT id, notification_id, error, attempts, scheduled_at, failed_at\s+FROM notification_failures`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "notification_id", "error", "attempts", "scheduled_at",
+			"failed_at"}).AddRow(int64(7), id, "smtp timeout", 10, scheduled, now))
+
+	// Execute
+	f, err := repo.RecordFailure(context.Background(), domain.NotificationFailure{
+		NotificationID: id, Error: "smtp timeout", Attempts: 10, ScheduledAt: scheduled,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), f.ID)
+	assert.Equal(t, now, f.FailedAt)
+
+	got, err := repo.ListFailures(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.NotificationFailure{*f}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_RecordEvent(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	id := uuid.New()
+	now := time.Now()
+
+	// у записи о создании from_status NULL
+	mock.ExpectExec(`INSERT INTO notification_events \(notification_id, from_status, to_status, actor, error\)`).
+		WithArgs(id, sql.NullString{}, domain.StatusPending, domain.ActorAPI, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO notification_events`).
+		WithArgs(id, sql.NullString{String: "pending", Valid: true}, domain.StatusFailed, domain.ActorWorker,
+			"smtp timeout").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectQuery(`SELECT id, notification_id, COALESCE\(from_status::text, ''\), to_status, actor, error, created_at\s+` +
+		`FROM notification_events\s+WHERE notification_id = \$1\s+ORDER BY created_at, id`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "notification_id", "from_status", "to_status", "actor", "error",
+			"created_at"}).
+			AddRow(int64(1), id, "", "pending", "api", "", now).
+			AddRow(int64(2), id, "pending", "failed", "worker", "smtp timeout", now))
+
+	// Execute
+	assert.NoError(t, repo.RecordEvent(context.Background(), domain.NotificationEvent{
+		NotificationID: id, ToStatus: domain.StatusPending, Actor: domain.ActorAPI,
+	}))
+	assert.NoError(t, repo.RecordEvent(context.Background(), domain.NotificationEvent{
+		NotificationID: id, FromStatus: domain.StatusPending, ToStatus: domain.StatusFailed,
+		Actor: domain.ActorWorker, Error: "smtp timeout",
+	}))
+
+	got, err := repo.ListEvents(context.Background(), id)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, domain.Status(""), got[0].FromStatus)
+		assert.Equal(t, domain.StatusFailed, got[1].ToStatus)
+		assert.Equal(t, domain.ActorWorker, got[1].Actor)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_SaveView(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	now := time.Now()
+	filter := domain.ViewFilter{Status: domain.StatusFailed, Channel: domain.ChannelEmail, Last: 24 * time.Hour}
+
+	mock.ExpectQuery(`INSERT INTO saved_views \(name, filter\) VALUES \(\$1, \$2\) ON CONFLICT \(name\) DO UPDATE`).
+		WithArgs("failed-email", []byte(`{"channel":"email","status":"failed","last":"24h0m0s"}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+	mock.ExpectQuery(`SELECT name, filter, created_at, updated_at FROM saved_views WHERE name = \$1`).
+		WithArgs("failed-email").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "filter", "created_at", "updated_at"}).
+			AddRow("failed-email", []byte(`{"channel":"email","status":"failed","last":"24h0m0s"}`), now, now))
+	mock.ExpectQuery(`SELECT name, filter, created_at, updated_at FROM saved_views WHERE name = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	// Execute
+	saved, err := repo.SaveView(context.Background(), "failed-email", filter)
+	assert.NoError(t, err)
+	assert.Equal(t, now, saved.CreatedAt)
+
+	got, err := repo.GetView(context.Background(), "failed-email")
+	assert.NoError(t, err)
+	assert.Equal(t, filter, got.Filter)
+
+	_, err = repo.GetView(context.Background(), "missing")
+	assert.ErrorIs(t, err, domain.ErrViewNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Schemas(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	now := time.Now()
+	columns := []string{"source", "channel", "schema", "created_at", "updated_at"}
+	doc := map[string]interface{}{"type": "object"}
+
+	mock.ExpectQuery(`INSERT INTO payload_schemas \(source, channel, schema\) VALUES \(\$1, \$2, \$3\) `+
+		`ON CONFLICT \(source, channel\) DO UPDATE`).
+		WithArgs("billing", domain.ChannelEmail, []byte(`{"type":"object"}`)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("billing", "email", []byte(`{"type":"object"}`), now, now))
+	mock.ExpectQuery(`SELECT source, channel, schema, created_at, updated_at FROM payload_schemas `+
+		`WHERE source = \$1 AND channel = \$2`).
+		WithArgs("shop", domain.ChannelEmail).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`DELETE FROM payload_schemas WHERE source = \$1 AND channel = \$2`).
+		WithArgs("shop", domain.ChannelEmail).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Execute
+	saved, err := repo.SaveSchema(context.Background(), "billing", domain.ChannelEmail, doc)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ChannelEmail, saved.Channel)
+	assert.Equal(t, doc, saved.Schema)
+
+	_, err = repo.GetSchema(context.Background(), "shop", domain.ChannelEmail)
+	assert.ErrorIs(t, err, domain.ErrSchemaNotFound)
+	assert.ErrorIs(t, repo.DeleteSchema(context.Background(), "shop", domain.ChannelEmail), domain.ErrSchemaNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_CountOutcomesByChannel(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	to := time.Now()
+	from := to.Add(-10 * time.Minute)
+
+	mock.ExpectQuery(`SELECT channel, count\(\*\) FILTER \(WHERE status IN \(\$1, \$2, \$3\)\), count\(\*\) FILTER \(WHERE status IN \(\$4, \$5\)\) FROM notifications WHERE status IN \(\$1, \$2, \$3, \$4, \$5\) AND updated_at >= \$6 AND updated_at < \$7 GROUP BY channel`).
+		WithArgs(domain.StatusSent, domain.StatusDelivered, domain.StatusRead, domain.StatusFailed, domain.StatusBounced, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"channel", "sent", "failed"}).
+			AddRow("email", 70, 30).
+			AddRow("telegram", 10, 0))
+
+	// Execute
+	counts, err := repo.CountOutcomesByChannel(context.Background(), from, to)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OutcomeCounts{Sent: 70, Failed: 30}, counts[domain.ChannelEmail])
+	assert.InDelta(t, 0.3, counts[domain.ChannelEmail].FailureRate(), 1e-9)
+	assert.Zero(t, counts[domain.ChannelTelegram].FailureRate())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_CountSLA(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	target := domain.SLATarget{Source: "billing", Channel: domain.ChannelEmail, Objective: 0.95, Within: time.Minute}
+
+	mock.ExpectQuery(`SELECT count\(\*\) FILTER \(WHERE sent_at IS NOT NULL AND sent_at <= effective_scheduled_at \+ make_interval\(secs => \$1\)\), count\(\*\) FROM notifications WHERE channel = \$2 AND status NOT IN \(\$3, \$4, \$5, \$6\) AND effective_scheduled_at >= \$7 AND effective_scheduled_at < \$8 AND source = \$9`).
+		WithArgs(60.0, domain.ChannelEmail, domain.StatusCancelled, domain.StatusDraft, domain.StatusAwaitingApproval,
+			domain.StatusSuppressed, from.Add(-time.Minute), to.Add(-time.Minute), "billing").
+		WillReturnRows(sqlmock.NewRows([]string{"met", "total"}).AddRow(95, 100))
+
+	// Execute
+	counts, err := repo.CountSLA(context.Background(), target, from, to)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SLACounts{Met: 95, Total: 100}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_CountProcessingBefore(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	before := time.Now().Add(-10 * time.Minute)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM notifications WHERE deleted_at IS NULL AND status = \$1 AND updated_at < \$2`).
+		WithArgs(domain.StatusProcessing, before).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	// Execute
+	count, err := repo.CountProcessingBefore(context.Background(), before)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_FailedToProcess(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	notificationID := uuid.New()
+
+	mock.ExpectExec(`UPDATE notifications SET status = \$1, retry_count = 0, reprocess_count = reprocess_count \+ 1\s+WHERE id = \$2 AND status = \$3`).
+		WithArgs(domain.StatusProcessing, notificationID, domain.StatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Execute
+	ok, err := repo.FailedToProcess(context.Background(), notificationID)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_ListPendingAndProcessingBefore_LimitOffset(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations
+	stuckTime := time.Now().Add(-10 * time.Minute)
+	payload, _ := json.Marshal(map[string]interface{}{"subject": "test"})
+
+	// Условия по статусу должны быть сгруппированы явно, а limit/offset передаваться параметрами
+	mock.ExpectQuery(`WHERE deleted_at IS NULL AND \(\(status = \$2 AND effective_scheduled_at <= \$1\) OR \(status = \$3 .*\)\) ORDER BY effective_scheduled_at, id LIMIT \$4 OFFSET \$5`).
+		WithArgs(stuckTime, domain.StatusPending, domain.StatusProcessing, 50, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(uuid.New(), "test@example.com", domain.ChannelEmail, payload, time.Now(), domain.StatusPending, 0, time.Now(), time.Now(), nil, nil, false, "", time.Now(), "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
+
+	// Execute
+	result, err := repo.ListPendingAndProcessingBefore(context.Background(), stuckTime, 50, 100)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_ListPendingAndProcessingAfter_Cursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := pg.NewPostgresRepo(&dbpg.DB{Master: db})
+	stuckTime := time.Now().Add(-15 * time.Minute)
+	cursor := &domain.OrphanCursor{EffectiveScheduledAt: stuckTime.Add(-time.Hour), ID: uuid.New()}
+
+	// страница начинается строго после курсора по (effective_scheduled_at, id), без OFFSET
+	mock.ExpectQuery(`AND \(effective_scheduled_at, id\) > \(\$4, \$5\)\s+ORDER BY effective_scheduled_at, id LIMIT \$6$`).
+		WithArgs(stuckTime, domain.StatusPending, domain.StatusProcessing, cursor.EffectiveScheduledAt, cursor.ID, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}))
+
+	result, err := repo.ListPendingAndProcessingAfter(context.Background(), stuckTime, cursor, 100)
+
+	// пустая страница не ошибка, в отличие от ListPendingAndProcessingBefore
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Create_WithIDGenerator(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	notificationID, err := idgen.V7()
+	assert.NoError(t, err)
+	repo := pg.NewPostgresRepo(dbpgDB, pg.WithIDGenerator(func() (uuid.UUID, error) {
+		return notificationID, nil
+	}))
+
+	// Setup mock expectations
+	now := time.Now()
+	jsonPayload, _ := json.Marshal(map[string]interface{}{"subject": "test"})
+	mock.ExpectQuery(`INSERT INTO notifications \(recipient,channel,payload,scheduled_at,status,parent_id,correlation_id,\s*cancel_on_confirm,pre_send_check,effective_scheduled_at,source,requires_approval,id\)`).
+		WithArgs("test@example.com", domain.ChannelEmail, jsonPayload, sqlmock.AnyArg(), domain.StatusPending,
+			uuid.NullUUID{}, uuid.NullUUID{}, false, "", sqlmock.AnyArg(), "", false, notificationID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}).
+			AddRow(notificationID, 0, now, now))
+
+	// Execute
+	result, err := repo.Create(context.Background(), domain.CreateParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		Status:      domain.StatusPending,
+		Payload:     map[string]interface{}{"subject": "test"},
+		ScheduledAt: now,
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, notificationID, result.ID)
+	assert.Equal(t, uuid.Version(7), result.ID.Version())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Create_DuplicateIdempotencyKey(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// ON CONFLICT DO NOTHING не возвращает строку, если ключ уже занят
+	mock.ExpectQuery(`INSERT INTO notifications \(.*,requires_approval,idempotency_key\)\s+VALUES \(\$1, .*\$13\)\s+`+
+		`ON CONFLICT \(source, idempotency_key\) WHERE idempotency_key IS NOT NULL DO NOTHING\s+RETURNING id`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "billing",
+			sqlmock.AnyArg(), "order-42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}))
+
+	// Execute
+	result, err := repo.Create(context.Background(), domain.CreateParams{
+		Recipient:      "test@example.com",
+		Channel:        domain.ChannelEmail,
+		Status:         domain.StatusPending,
+		ScheduledAt:    time.Now(),
+		Source:         "billing",
+		IdempotencyKey: "order-42",
+	})
+
+	// Assertions
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, domain.ErrDuplicateIdempotencyKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Create_Priority(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// столбец priority пишется только для приоритета, отличного от normal по умолчанию
+	now := time.Now()
+	id := uuid.New()
+	mock.ExpectQuery(`INSERT INTO notifications \(.*,requires_approval,priority\)\s+VALUES \(\$1, .*\$13\)\s+RETURNING id`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), domain.PriorityHigh).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}).
+			AddRow(id, 0, now, now))
+	mock.ExpectQuery(`INSERT INTO notifications \(.*,requires_approval\)\s+VALUES \(\$1, .*\$12\)\s+RETURNING id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}).
+			AddRow(uuid.New(), 0, now, now))
+
+	// Execute
+	params := domain.CreateParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		Status:      domain.StatusPending,
+		ScheduledAt: now,
+		Priority:    domain.PriorityHigh,
+	}
+	high, err := repo.Create(context.Background(), params)
+	assert.NoError(t, err)
+	params.Priority = ""
+	normal, err := repo.Create(context.Background(), params)
+	assert.NoError(t, err)
+
+	// Assertions
+	assert.Equal(t, domain.PriorityHigh, high.Priority)
+	assert.Equal(t, domain.PriorityNormal, normal.Priority)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_GetByIdempotencyKey(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	now := time.Now()
+	notificationID := uuid.New()
+	payload, _ := json.Marshal(map[string]interface{}{"subject": "test"})
+	mock.ExpectQuery(`SELECT id, recipient, .* WHERE source = \$1 AND idempotency_key = \$2 AND deleted_at IS NULL`).
+		WithArgs("billing", "order-42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(notificationID, "test@example.com", domain.ChannelEmail, payload, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "billing", 0, false, "", nil, nil, nil, domain.PriorityNormal))
+	mock.ExpectQuery(`SELECT id, recipient, .* WHERE source = \$1 AND idempotency_key = \$2`).
+		WithArgs("billing", "order-43").
+		WillReturnError(sql.ErrNoRows)
+
+	// Execute
+	result, err := repo.GetByIdempotencyKey(context.Background(), "billing", "order-42")
+	assert.NoError(t, err)
+	assert.Equal(t, notificationID, result.ID)
+	assert.Equal(t, "test", result.Payload["subject"])
+
+	_, err = repo.GetByIdempotencyKey(context.Background(), "billing", "order-43")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_GetByID_QueryTimeout(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB, pg.WithQueryTimeout(20*time.Millisecond))
+
+	// Запрос отвечает дольше таймаута репозитория
+	mock.ExpectQuery(`SELECT id, recipient, channel`).
+		WithArgs(sqlmock.AnyArg()).
+		WillDelayFor(200 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// Execute
+	start := time.Now()
+	result, err := repo.GetByID(context.Background(), uuid.New())
+
+	// Assertions
+	assert.Nil(t, result)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestPostgresRepo_CreateBatch_SingleInsert(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	next := 0
+	repo := pg.NewPostgresRepo(dbpgDB, pg.WithIDGenerator(func() (uuid.UUID, error) {
+		id := ids[next]
+		next++
+		return id, nil
+	}))
+
+	// Setup mock expectations: один INSERT на оба уведомления, строки возвращаются в другом порядке
+	now := time.Now()
+	jsonPayload, _ := json.Marshal(map[string]interface{}{"subject": "test"})
+	mock.ExpectQuery(`INSERT INTO notifications \(.*,id\)\s+VALUES \(\$1, .*\$13\),\s+\(\$14, .*\$26\)\s+RETURNING id`).
+		WithArgs("a@example.com", domain.ChannelEmail, jsonPayload, sqlmock.AnyArg(), domain.StatusPending,
+			uuid.NullUUID{}, uuid.NullUUID{}, false, "", sqlmock.AnyArg(), "", false, ids[0],
+			"b@example.com", domain.ChannelEmail, jsonPayload, sqlmock.AnyArg(), domain.StatusPending,
+			uuid.NullUUID{}, uuid.NullUUID{}, false, "", sqlmock.AnyArg(), "", false, ids[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}).
+			AddRow(ids[1], 0, now, now).
+			AddRow(ids[0], 0, now, now))
+
+	// Execute
+	params := []domain.CreateParams{
+		{Recipient: "a@example.com", Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			Payload: map[string]interface{}{"subject": "test"}, ScheduledAt: now},
+		{Recipient: "b@example.com", Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			Payload: map[string]interface{}{"subject": "test"}, ScheduledAt: now},
+	}
+	result, err := repo.CreateBatch(context.Background(), params)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, ids[0], result[0].ID)
+	assert.Equal(t, "a@example.com", result[0].Recipient)
+	assert.Equal(t, ids[1], result[1].ID)
+	assert.Equal(t, "b@example.com", result[1].Recipient)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_CreateBatch_SkipsTakenKey(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations: строка с занятым ключом пропускается, остальные сопоставляются по ключу
+	now := time.Now()
+	first, third := uuid.New(), uuid.New()
+	mock.ExpectQuery(`INSERT INTO notifications \(.*,idempotency_key\)\s+VALUES .*\s+ON CONFLICT \(source, idempotency_key\) WHERE idempotency_key IS NOT NULL DO NOTHING\s+RETURNING id, retry_count, created_at, updated_at, source, idempotency_key`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at", "source", "idempotency_key"}).
+			AddRow(third, 0, now, now, "billing", "order-43").
+			AddRow(first, 0, now, now, "billing", nil))
+
+	// Execute
+	params := []domain.CreateParams{
+		{Recipient: "a@example.com", Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			Payload: map[string]interface{}{"subject": "test"}, ScheduledAt: now, Source: "billing"},
+		{Recipient: "b@example.com", Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			Payload: map[string]interface{}{"subject": "test"}, ScheduledAt: now, Source: "billing", IdempotencyKey: "order-42"},
+		{Recipient: "c@example.com", Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			Payload: map[string]interface{}{"subject": "test"}, ScheduledAt: now, Source: "billing", IdempotencyKey: "order-43"},
+	}
+	result, err := repo.CreateBatch(context.Background(), params)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, first, result[0].ID)
+	assert.Nil(t, result[1])
+	assert.Equal(t, third, result[2].ID)
+	assert.Equal(t, "c@example.com", result[2].Recipient)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_Reschedule_Shift(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB)
+
+	// Setup mock expectations: один UPDATE по подзапросу ожидающих с блокировкой строк
+	now := time.Now()
+	id := uuid.New()
+	payload, _ := json.Marshal(map[string]interface{}{"subject": "Hi"})
+	mock.ExpectQuery(`UPDATE notifications SET scheduled_at = scheduled_at \+ \$3 \* INTERVAL '1 microsecond',\s+effective_scheduled_at = effective_scheduled_at \+ \$3 \* INTERVAL '1 microsecond'\s+WHERE id IN \(SELECT id FROM notifications\s+WHERE deleted_at IS NULL AND status = \$1 AND channel = \$2\s+ORDER BY effective_scheduled_at, id LIMIT \$4 FOR UPDATE\)\s+AND status = \$5\s+RETURNING id, recipient`).
+		WithArgs(domain.StatusPending, domain.ChannelEmail, (2 * time.Hour).Microseconds(), 100, domain.StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(id, "user@example.com", domain.ChannelEmail, payload, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
+
+	// Execute
+	ns, err := repo.Reschedule(context.Background(), domain.RescheduleParams{
+		Filter: domain.ListFilter{Channel: domain.ChannelEmail},
+		Shift:  2 * time.Hour,
+		Limit:  100,
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, ns, 1)
+	assert.Equal(t, id, ns[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_LargePayload(t *testing.T) {
+	// Setup
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbpgDB := &dbpg.DB{Master: db}
+	repo := pg.NewPostgresRepo(dbpgDB, pg.WithLargePayloadThreshold(32))
+
+	// Setup mock expectations: payload выносится до вставки строки, в строке остается ссылка
+	now := time.Now()
+	id := uuid.New()
+	payload := map[string]interface{}{"subject": "Invoice", "body": strings.Repeat("x", 64)}
+	raw, _ := json.Marshal(payload)
+	sum := sha256.Sum256(raw)
+	key := hex.EncodeToString(sum[:])
+	ref, _ := json.Marshal(map[string]string{"$payload_ref": key})
+
+	mock.ExpectExec(`INSERT INTO notification_payloads \(key, payload, size\) VALUES \(\$1, \$2, \$3\)\s+ON CONFLICT \(key\) DO NOTHING`).
+		WithArgs(key, raw, len(raw)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO notifications`).
+		WithArgs("test@example.com", domain.ChannelEmail, ref, sqlmock.AnyArg(), domain.StatusPending,
+			uuid.NullUUID{}, uuid.NullUUID{}, false, "", sqlmock.AnyArg(), "", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "retry_count", "created_at", "updated_at"}).
+			AddRow(id, 0, now, now))
+	mock.ExpectQuery(`SELECT id, recipient, channel, payload, scheduled_at, status, retry_count, created_at, updated_at`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "recipient", "channel", "payload", "scheduled_at", "status", "retry_count", "created_at", "updated_at", "parent_id", "correlation_id", "cancel_on_confirm", "pre_send_check", "effective_scheduled_at", "source", "reprocess_count", "requires_approval", "approved_by", "approved_at", "group_id", "template_id", "priority"}).
+			AddRow(id, "test@example.com", domain.ChannelEmail, ref, now, domain.StatusPending, 0, now, now, nil, nil, false, "", now, "", 0, false, "", nil, nil, nil, domain.PriorityNormal))
+	mock.ExpectQuery(`SELECT key, payload FROM notification_payloads WHERE key = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "payload"}).AddRow(key, raw))
+
+	// Execute
+	created, err := repo.Create(context.Background(), domain.CreateParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		Status:      domain.StatusPending,
+		Payload:     payload,
+		ScheduledAt: now,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, payload, created.Payload)
+	got, err := repo.GetByID(context.Background(), id)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, payload, got.Payload)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
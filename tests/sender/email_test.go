@@ -0,0 +1,223 @@
+package sender_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	emailsender "DelayedNotifier/internal/sender/email"
+	smssender "DelayedNotifier/internal/sender/sms"
+	telegramsender "DelayedNotifier/internal/sender/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	cases := map[string]string{
+		"Просто текст":                                                                                    "Просто текст",
+		`<p>Привет, <b>мир</b></p>`:                                                                       `<p>Привет, <b>мир</b></p>`,
+		`<p>a</p><script>alert(1)</script><p>b</p>`:                                                       `<p>a</p><p>b</p>`,
+		`<style>p{}</style><iframe src="x"><b>in</b></iframe>ok`:                                          `ok`,
+		`<img src="x.png" onerror="alert(1)" alt="x">`:                                                    `<img src="x.png" alt="x">`,
+		`<a href="java&#x09;script:alert(1)" title="t">link</a>`:                                          `<a title="t">link</a>`,
+		`<a HREF=" JavaScript:alert(1)">link</a>`:                                                         `<a>link</a>`,
+		`<img src="data:image/png;base64,AAAA">`:                                                          `<img>`,
+		`<a href="https://example.com/?a=1&amp;b=2">x</a>`:                                                `<a href="https://example.com/?a=1&amp;b=2">x</a>`,
+		`<a href="mailto:a@example.com" target="_blank">x</a>`:                                            `<a href="mailto:a@example.com">x</a>`,
+		`<svg><a><animate attributeName=href values=javascript:alert(1) /><text>Click</text></a></svg>ok`: `ok`,
+		`<svg><set attributeName=href to=javascript:alert(1) /></svg>`:                                    ``,
+		`<math><mtext><a href="x">m</a></mtext></math>ok`:                                                 `ok`,
+		`<form action="https://evil/"><input name="p"><button formaction="x">go</button></form>`:          `go`,
+		`<custom-tag data-x="1">text</custom-tag>`:                                                        `text`,
+		`<img src="x.png" srcset="javascript:alert(1)" usemap="#m">`:                                      `<img src="x.png">`,
+		`<a href="data:text/html,<script>">x</a>`:                                                         `<a>x</a>`,
+		`<div style="width: expression(alert(1))">x</div>`:                                                `<div>x</div>`,
+		`<!-- comment --><base href="http://evil/"><a href="/p">x</a>`:                                    `<a href="/p">x</a>`,
+		`1 &lt; 2`: `1 &lt; 2`,
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, emailsender.SanitizeHTML(in), in)
+	}
+}
+
+func TestEmailRender_Sanitizes(t *testing.T) {
+	msg, err := emailsender.Render(&domain.Notification{Channel: domain.ChannelEmail,
+		Payload: map[string]interface{}{"subject": "s", "body": `<p onclick="x()">hi</p><script>x()</script>`}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<p>hi</p>`, msg.Body)
+}
+
+func TestPayloadValidators(t *testing.T) {
+	valid := []struct {
+		v       domain.PayloadValidator
+		payload map[string]interface{}
+	}{
+		{emailsender.PayloadValidator, map[string]interface{}{"subject": "Hi", domain.PayloadFromName: "Shop"}},
+		{telegramsender.PayloadValidator, map[string]interface{}{domain.PayloadParseMode: "MarkdownV2"}},
+		{smssender.PayloadValidator, map[string]interface{}{domain.PayloadSenderID: "Shop 24"}},
+		{smssender.PayloadValidator, map[string]interface{}{domain.PayloadSenderID: "+15005550006"}},
+	}
+	for _, c := range valid {
+		assert.NoError(t, c.v.ValidatePayload(c.payload), c.payload)
+	}
+	invalid := []struct {
+		v       domain.PayloadValidator
+		payload map[string]interface{}
+	}{
+		{emailsender.PayloadValidator, map[string]interface{}{"subject": "Hi\r\nBcc: x@example.com"}},
+		{emailsender.PayloadValidator, map[string]interface{}{domain.PayloadFromName: 42}},
+		{telegramsender.PayloadValidator, map[string]interface{}{domain.PayloadParseMode: "html"}},
+		{smssender.PayloadValidator, map[string]interface{}{domain.PayloadSenderID: "VeryLongShopName"}},
+		{smssender.PayloadValidator, map[string]interface{}{domain.PayloadSenderID: "12345"}},
+	}
+	for _, c := range invalid {
+		assert.ErrorIs(t, c.v.ValidatePayload(c.payload), domain.ErrInvalidPayload, c.payload)
+	}
+}
+
+// fakeSMTP минимальный SMTP-сервер: считает соединения и одновременные DATA.
+type fakeSMTP struct {
+	ln    net.Listener
+	delay time.Duration
+
+	mu                sync.Mutex
+	conns, messages   int
+	active, maxActive int
+	dropOnNoop        bool
+}
+
+func newFakeSMTP(t *testing.T, delay time.Duration) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f := &fakeSMTP{ln: ln, delay: delay}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-fake")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "NOOP"):
+			f.mu.Lock()
+			drop := f.dropOnNoop
+			f.mu.Unlock()
+			if drop {
+				return
+			}
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+			}
+			f.mu.Lock()
+			f.active++
+			f.maxActive = max(f.maxActive, f.active)
+			f.mu.Unlock()
+			time.Sleep(f.delay)
+			f.mu.Lock()
+			f.active--
+			f.messages++
+			f.mu.Unlock()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (f *fakeSMTP) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) stats() (conns, messages, maxActive int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.conns, f.messages, f.maxActive
+}
+
+func emailNotification() *domain.Notification {
+	return &domain.Notification{Channel: domain.ChannelEmail, Recipient: "user@example.com",
+		Payload: map[string]interface{}{"subject": "Hi", "body": "text"}}
+}
+
+// TestSMTPSender_Pool проверяет, что письма уходят параллельно, но не больше чем по PoolSize соединениям
+func TestSMTPSender_Pool(t *testing.T) {
+	srv := newFakeSMTP(t, 50*time.Millisecond)
+	s, err := emailsender.NewSMTPSender("127.0.0.1", srv.port(), "", "", "noreply@example.com", false,
+		emailsender.WithPoolSize(3))
+	require.NoError(t, err)
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Send(context.Background(), emailNotification()))
+		}()
+	}
+	wg.Wait()
+
+	conns, messages, maxActive := srv.stats()
+	assert.Equal(t, 9, messages)
+	assert.LessOrEqual(t, conns, 3)
+	assert.LessOrEqual(t, maxActive, 3)
+	assert.Greater(t, maxActive, 1, "отправки не должны идти по одной")
+}
+
+// TestSMTPSender_Redial проверяет, что соединение, не ответившее на NOOP, заменяется новым
+func TestSMTPSender_Redial(t *testing.T) {
+	srv := newFakeSMTP(t, 0)
+	s, err := emailsender.NewSMTPSender("127.0.0.1", srv.port(), "", "", "noreply@example.com", false)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Send(context.Background(), emailNotification()))
+	srv.mu.Lock()
+	srv.dropOnNoop = true
+	srv.mu.Unlock()
+	require.NoError(t, s.Send(context.Background(), emailNotification()))
+
+	conns, messages, _ := srv.stats()
+	assert.Equal(t, 2, messages)
+	assert.Equal(t, 2, conns)
+}
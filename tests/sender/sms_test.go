@@ -0,0 +1,151 @@
+package sender_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	smssender "DelayedNotifier/internal/sender/sms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilioSender_Send(t *testing.T) {
+	var (
+		path       string
+		form       url.Values
+		user, pass string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sid":"SM1","status":"queued"}`))
+	}))
+	defer srv.Close()
+
+	s, err := smssender.NewTwilioSender("AC123", "secret", "+15005550006", smssender.WithAPIURL(srv.URL+"/"))
+	require.NoError(t, err)
+
+	err = s.Send(context.Background(), &domain.Notification{
+		Channel:   domain.ChannelSMS,
+		Recipient: "+79991234567",
+		Payload:   map[string]interface{}{"subject": "Code", "body": strings.Repeat("я", 2000)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", path)
+	assert.Equal(t, "AC123", user)
+	assert.Equal(t, "secret", pass)
+	assert.Equal(t, "+79991234567", form.Get("To"))
+	assert.Equal(t, "+15005550006", form.Get("From"))
+	assert.Len(t, []rune(form.Get("Body")), 1600)
+}
+
+func TestTwilioSender_MessagingService(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	s, err := smssender.NewTwilioSender("AC123", "secret", "MG42", smssender.WithAPIURL(srv.URL))
+	require.NoError(t, err)
+	require.NoError(t, s.Send(context.Background(), &domain.Notification{Recipient: "+79991234567",
+		Payload: map[string]interface{}{"subject": "only subject"}}))
+	assert.Equal(t, "MG42", form.Get("MessagingServiceSid"))
+	assert.Empty(t, form.Get("From"))
+	assert.Equal(t, "only subject", form.Get("Body"))
+}
+
+func TestTwilioSender_SenderID(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	s, err := smssender.NewTwilioSender("AC123", "secret", "MG42", smssender.WithAPIURL(srv.URL))
+	require.NoError(t, err)
+	require.NoError(t, s.Send(context.Background(), &domain.Notification{Recipient: "+79991234567",
+		Payload: map[string]interface{}{"body": "code 1234", domain.PayloadSenderID: "MyShop"}}))
+	assert.Equal(t, "MyShop", form.Get("From"))
+	assert.Empty(t, form.Get("MessagingServiceSid"))
+	assert.Equal(t, "code 1234", form.Get("Body"))
+}
+
+func TestTwilioSender_SendErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     int
+		header     string
+		body       string
+		class      domain.ErrorClass
+		retryAfter time.Duration
+	}{
+		{"rate limited", http.StatusTooManyRequests, "3",
+			`{"code":20429,"message":"Too Many Requests"}`, domain.ErrorThrottled, 3 * time.Second},
+		{"invalid number", http.StatusBadRequest, "",
+			`{"code":21211,"message":"The 'To' number is not a valid phone number."}`, domain.ErrorPermanent, 0},
+		{"bad credentials", http.StatusUnauthorized, "",
+			`{"code":20003,"message":"Authenticate"}`, domain.ErrorTransient, 0},
+		{"unavailable", http.StatusServiceUnavailable, "", `<html>503</html>`, domain.ErrorTransient, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.header != "" {
+					w.Header().Set("Retry-After", tc.header)
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			s, err := smssender.NewTwilioSender("AC123", "secret", "+15005550006", smssender.WithAPIURL(srv.URL))
+			require.NoError(t, err)
+			err = s.Send(context.Background(), &domain.Notification{Recipient: "+79991234567",
+				Payload: map[string]interface{}{"body": "x"}})
+			require.Error(t, err)
+			assert.Equal(t, tc.class, domain.ClassifySendError(err))
+			d, _ := domain.RetryAfter(err)
+			assert.Equal(t, tc.retryAfter, d)
+		})
+	}
+}
+
+func TestSMSRecipientValidator(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, smssender.ValidateRecipient(ctx, "+79991234567"))
+	for _, r := range []string{"89991234567", "+0123456789", "+7 999 123-45-67", "+1234567890123456"} {
+		assert.ErrorIs(t, smssender.ValidateRecipient(ctx, r), domain.ErrInvalidRecipient, r)
+	}
+}
+
+func TestTwilioSender_RetryAfterDate(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", now.Add(90*time.Second).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	s, err := smssender.NewTwilioSender("AC123", "secret", "+15005550006", smssender.WithAPIURL(srv.URL),
+		smssender.WithClock(domain.ClockFunc(func() time.Time { return now })))
+	require.NoError(t, err)
+	err = s.Send(context.Background(), &domain.Notification{Recipient: "+79991234567",
+		Payload: map[string]interface{}{"body": "x"}})
+	d, ok := domain.RetryAfter(err)
+	require.True(t, ok)
+	assert.Equal(t, 90*time.Second, d)
+}
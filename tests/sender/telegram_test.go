@@ -0,0 +1,166 @@
+package sender_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	telegramsender "DelayedNotifier/internal/sender/telegram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotSender_Send(t *testing.T) {
+	var got struct {
+		Path   string
+		ChatID string `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	s, err := telegramsender.NewBotSender("123:abc", telegramsender.WithAPIURL(srv.URL+"/"))
+	require.NoError(t, err)
+
+	err = s.Send(context.Background(), &domain.Notification{
+		Channel:   domain.ChannelTelegram,
+		Recipient: "-100200300",
+		Payload:   map[string]interface{}{"subject": "Deploy", "body": "done"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/bot123:abc/sendMessage", got.Path)
+	assert.Equal(t, "-100200300", got.ChatID)
+	assert.Equal(t, "Deploy\n\ndone", got.Text)
+}
+
+// TestBotSender_ParseMode проверяет передачу parse_mode и то, что он не попадает в текст
+func TestBotSender_ParseMode(t *testing.T) {
+	var got struct {
+		Text      string `json:"text"`
+		ParseMode string `json:"parse_mode"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	s, err := telegramsender.NewBotSender("123:abc", telegramsender.WithAPIURL(srv.URL))
+	require.NoError(t, err)
+
+	err = s.Send(context.Background(), &domain.Notification{
+		Channel:   domain.ChannelTelegram,
+		Recipient: "-100200300",
+		Payload:   map[string]interface{}{"order": "<b>42</b>", domain.PayloadParseMode: "HTML"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "HTML", got.ParseMode)
+	assert.Equal(t, "order=<b>42</b>", got.Text)
+}
+
+func TestBotSender_SendErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     int
+		body       string
+		class      domain.ErrorClass
+		retryAfter time.Duration
+	}{
+		{"flood", http.StatusTooManyRequests,
+			`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`,
+			domain.ErrorThrottled, 7 * time.Second},
+		{"blocked", http.StatusForbidden,
+			`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`,
+			domain.ErrorPermanent, 0},
+		{"chat not found", http.StatusBadRequest,
+			`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`,
+			domain.ErrorPermanent, 0},
+		{"bad gateway", http.StatusBadGateway, `<html>502</html>`, domain.ErrorTransient, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			s, err := telegramsender.NewBotSender("token", telegramsender.WithAPIURL(srv.URL))
+			require.NoError(t, err)
+			err = s.Send(context.Background(), &domain.Notification{Recipient: "42",
+				Payload: map[string]interface{}{"body": "x"}})
+			require.Error(t, err)
+			assert.Equal(t, tc.class, domain.ClassifySendError(err))
+			d, _ := domain.RetryAfter(err)
+			assert.Equal(t, tc.retryAfter, d)
+		})
+	}
+}
+
+func TestBotSender_EmptyText(t *testing.T) {
+	s, err := telegramsender.NewBotSender("token", telegramsender.WithAPIURL("http://127.0.0.1:1"))
+	require.NoError(t, err)
+	err = s.Send(context.Background(), &domain.Notification{Recipient: "42", Payload: map[string]interface{}{}})
+	assert.Equal(t, domain.ErrorPermanent, domain.ClassifySendError(err))
+}
+
+func TestBotSender_ChatInterval(t *testing.T) {
+	var mu sync.Mutex
+	sent := make(map[string][]time.Time)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ChatID string `json:"chat_id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent[req.ChatID] = append(sent[req.ChatID], time.Now())
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	const interval = 100 * time.Millisecond
+	s, err := telegramsender.NewBotSender("token", telegramsender.WithAPIURL(srv.URL),
+		telegramsender.WithChatInterval(interval))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, chat := range []string{"1", "1", "1", "2"} {
+		wg.Add(1)
+		go func(chat string) {
+			defer wg.Done()
+			assert.NoError(t, s.Send(context.Background(), &domain.Notification{Recipient: chat,
+				Payload: map[string]interface{}{"body": "x"}}))
+		}(chat)
+	}
+	wg.Wait()
+
+	require.Len(t, sent["1"], 3)
+	require.Len(t, sent["2"], 1)
+	first, last := sent["1"][0], sent["1"][0]
+	for _, at := range sent["1"] {
+		if at.Before(first) {
+			first = at
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	assert.GreaterOrEqual(t, last.Sub(first), 2*interval-10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	msg := &domain.Notification{Recipient: "3", Payload: map[string]interface{}{"body": "x"}}
+	require.NoError(t, s.Send(context.Background(), msg))
+	err = s.Send(ctx, msg)
+	assert.ErrorIs(t, err, context.Canceled)
+}
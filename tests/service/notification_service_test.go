@@ -3,11 +3,15 @@ package service_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"path"
 	"testing"
 	"time"
 
 	"DelayedNotifier/internal/domain"
+	emailsender "DelayedNotifier/internal/sender/email"
+	telegramsender "DelayedNotifier/internal/sender/telegram"
 	"DelayedNotifier/internal/service"
 	rd "github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
@@ -28,6 +32,14 @@ func (m *MockRepository) Create(ctx context.Context, n domain.CreateParams) (*do
 	return args.Get(0).(*domain.Notification), args.Error(1)
 }
 
+func (m *MockRepository) CreateBatch(ctx context.Context, params []domain.CreateParams) ([]*domain.Notification, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Notification), args.Error(1)
+}
+
 func (m *MockRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -36,6 +48,14 @@ func (m *MockRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Not
 	return args.Get(0).(*domain.Notification), args.Error(1)
 }
 
+func (m *MockRepository) GetByIdempotencyKey(ctx context.Context, source, key string) (*domain.Notification, error) {
+	args := m.Called(ctx, source, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
 func (m *MockRepository) Update(ctx context.Context, id uuid.UUID, opts ...domain.UpdateOption) error {
 	args := m.Called(ctx, id, opts)
 	return args.Error(0)
@@ -46,6 +66,69 @@ func (m *MockRepository) ListPendingAndProcessingBefore(ctx context.Context, t t
 	return args.Get(0).([]domain.Notification), args.Error(1)
 }
 
+func (m *MockRepository) ListPendingAndProcessingAfter(ctx context.Context, t time.Time, after *domain.OrphanCursor,
+	limit int) ([]domain.Notification, error) {
+	args := m.Called(ctx, t, after, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) ListPendingScheduledBetween(ctx context.Context, from, to time.Time, limit int) ([]domain.Notification, error) {
+	args := m.Called(ctx, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) ListGroup(ctx context.Context, groupID uuid.UUID) ([]domain.Notification, error) {
+	args := m.Called(ctx, groupID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) ListRelated(ctx context.Context, rootID uuid.UUID) ([]domain.Notification, error) {
+	args := m.Called(ctx, rootID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) CountBacklog(ctx context.Context, t time.Time) (int, error) {
+	args := m.Called(ctx, t)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ListFailedBefore(ctx context.Context, t time.Time,
+	maxCycles, limit int) ([]domain.Notification, error) {
+	args := m.Called(ctx, t, maxCycles, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) Approve(ctx context.Context, id uuid.UUID, approver string,
+	status domain.Status) (bool, error) {
+	args := m.Called(ctx, id, approver, status)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) FailedToProcess(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CountProcessingBefore(ctx context.Context, t time.Time) (int, error) {
+	args := m.Called(ctx, t)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockRepository) PendingToProcess(ctx context.Context, id uuid.UUID) (bool, error) {
 	args := m.Called(ctx, id)
 	return args.Bool(0), args.Error(1)
@@ -56,6 +139,78 @@ func (m *MockRepository) IncRetryCount(ctx context.Context, id uuid.UUID) error
 	return args.Error(0)
 }
 
+func (m *MockRepository) Reschedule(ctx context.Context, params domain.RescheduleParams) ([]domain.Notification, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetByIDWithDeleted(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) ListDuplicateDeliveries(ctx context.Context, since time.Time,
+	limit int) ([]domain.DuplicateGroup, error) {
+	args := m.Called(ctx, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.DuplicateGroup), args.Error(1)
+}
+
+func (m *MockRepository) Search(ctx context.Context, params domain.SearchParams) ([]domain.SearchHit, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.SearchHit), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context, filter domain.ListFilter, limit,
+	offset int) ([]domain.Notification, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+func (m *MockRepository) CountOutcomesByChannel(ctx context.Context, from,
+	to time.Time) (map[domain.Channel]domain.OutcomeCounts, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[domain.Channel]domain.OutcomeCounts), args.Error(1)
+}
+
+func (m *MockRepository) CountSLA(ctx context.Context, target domain.SLATarget, from,
+	to time.Time) (domain.SLACounts, error) {
+	args := m.Called(ctx, target, from, to)
+	return args.Get(0).(domain.SLACounts), args.Error(1)
+}
+
+func (m *MockRepository) PurgeDeletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	args := m.Called(ctx, t)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // MockPublisher мок для MessageQueuePublisher
 type MockPublisher struct {
 	mock.Mock
@@ -81,6 +236,31 @@ func (m *MockRedis) SetWithExpiration(ctx context.Context, key string, value int
 	return args.Error(0)
 }
 
+func (m *MockRedis) SetNXWithExpiration(ctx context.Context, key string, value interface{},
+	expiration time.Duration) (bool, error) {
+	args := m.Called(ctx, key, value, expiration)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedis) Del(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockRedis) MGet(ctx context.Context, keys []string) (map[string]string, error) {
+	args := m.Called(ctx, keys)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (m *MockRedis) MSetNXWithExpiration(ctx context.Context, values map[string]interface{},
+	expiration time.Duration) (int, error) {
+	args := m.Called(ctx, values, expiration)
+	return args.Int(0), args.Error(1)
+}
+
 // TestCreateNotification_Success проверяет успешное создание уведомления
 func TestCreateNotification_Success(t *testing.T) {
 	ctx := context.Background()
@@ -122,6 +302,47 @@ func TestCreateNotification_Success(t *testing.T) {
 	redis.AssertExpectations(t)
 }
 
+// TestCreateNotification_Clock проверяет статус и задержку публикации по заданным часам:
+// время ближе запаса на публикацию отправляется сразу, будущее ждет ровно до срока
+func TestCreateNotification_Clock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		at     time.Time
+		status domain.Status
+		ttl    time.Duration
+	}{
+		{"due", now.Add(time.Second), domain.StatusProcessing, 2 * time.Second},
+		{"future", now.Add(time.Hour), domain.StatusPending, time.Hour - 2*time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			publisher := new(MockPublisher)
+			redis := new(MockRedis)
+			svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+				service.WithClock(domain.ClockFunc(func() time.Time { return now })))
+
+			id := uuid.New()
+			repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+				return p.Status == tc.status
+			})).Return(&domain.Notification{ID: id, Status: tc.status}, nil)
+			redis.On("SetWithExpiration", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			publisher.On("Publish", ctx, id, tc.ttl).Return(nil)
+
+			_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+				Recipient:   "test@example.com",
+				Channel:     domain.ChannelEmail,
+				ScheduledAt: tc.at,
+			})
+			assert.NoError(t, err)
+			repo.AssertExpectations(t)
+			publisher.AssertExpectations(t)
+		})
+	}
+}
+
 // TestCreateNotification_InvalidChannel проверяет обработку некорректного канала
 func TestCreateNotification_InvalidChannel(t *testing.T) {
 	ctx := context.Background()
@@ -291,7 +512,7 @@ func TestGetNotificationByID_FromDatabase(t *testing.T) {
 	}
 
 	// Redis возвращает ошибку redis nil
-	redis.On("Get", ctx, notification.ID.String()).Return("", rd.Nil)
+	redis.On("Get", ctx, service.CacheKey(notification.ID)).Return("", rd.Nil)
 	repo.On("GetByID", ctx, notification.ID).Return(notification, nil)
 	redis.On("SetWithExpiration", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -323,7 +544,7 @@ func TestGetNotificationByID_FromRedis(t *testing.T) {
 
 	// Данные есть в Redis
 	notificationData, _ := json.Marshal(notification)
-	redis.On("Get", ctx, notification.ID.String()).Return(string(notificationData), nil)
+	redis.On("Get", ctx, service.CacheKey(notification.ID)).Return(string(notificationData), nil)
 
 	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
 
@@ -337,6 +558,36 @@ func TestGetNotificationByID_FromRedis(t *testing.T) {
 	redis.AssertExpectations(t)
 }
 
+// TestGetNotificationByID_CorruptCache проверяет, что битая запись кеша читается из базы и перезаписывается
+func TestGetNotificationByID_CorruptCache(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := new(MockRedis)
+
+	notification := &domain.Notification{
+		ID:          uuid.New(),
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		ScheduledAt: time.Now().Add(time.Hour),
+		Status:      domain.StatusPending,
+	}
+
+	redis.On("Get", ctx, service.CacheKey(notification.ID)).Return("{not json", nil)
+	repo.On("GetByID", ctx, notification.ID).Return(notification, nil)
+	redis.On("SetWithExpiration", mock.Anything, service.CacheKey(notification.ID), mock.Anything, mock.Anything).Return(nil)
+
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	result, err := svc.GetNotificationByID(ctx, notification.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, notification.ID, result.ID)
+	}
+
+	repo.AssertExpectations(t)
+	redis.AssertExpectations(t)
+}
+
 // TestGetNotificationByID_NotFound проверяет обработку отсутствующего уведомления
 func TestGetNotificationByID_NotFound(t *testing.T) {
 	ctx := context.Background()
@@ -344,7 +595,7 @@ func TestGetNotificationByID_NotFound(t *testing.T) {
 	redis := new(MockRedis)
 
 	notificationID := uuid.New()
-	redis.On("Get", ctx, notificationID.String()).Return("", rd.Nil)
+	redis.On("Get", ctx, service.CacheKey(notificationID)).Return("", rd.Nil)
 	repo.On("GetByID", ctx, notificationID).Return(nil, domain.ErrNotFound)
 	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
 	result, err := svc.GetNotificationByID(ctx, notificationID)
@@ -381,6 +632,7 @@ func TestUpdateNotification_Success(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, domain.StatusProcessing, notification.Status)
+	assert.False(t, notification.UpdatedAt.IsZero(), "updated_at in cache must follow the change")
 
 	repo.AssertExpectations(t)
 }
@@ -400,7 +652,7 @@ func TestCancel_Success(t *testing.T) {
 		Status:      domain.StatusPending,
 	}
 
-	redis.On("Get", ctx, notification.ID.String()).Return("", rd.Nil) // Данные не найдены в Redis
+	redis.On("Get", ctx, service.CacheKey(notification.ID)).Return("", rd.Nil) // Данные не найдены в Redis
 	repo.On("GetByID", ctx, notification.ID).Return(notification, nil)
 	repo.On("Update", ctx, notification.ID, mock.Anything).Return(nil)
 	redis.On("SetWithExpiration", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -430,7 +682,7 @@ func TestFailed_Success(t *testing.T) {
 		Status:      domain.StatusProcessing,
 	}
 
-	redis.On("Get", ctx, notification.ID.String()).Return("", rd.Nil) // Данные не найдены в Redis
+	redis.On("Get", ctx, service.CacheKey(notification.ID)).Return("", rd.Nil) // Данные не найдены в Redis
 	repo.On("GetByID", ctx, notification.ID).Return(notification, nil)
 	repo.On("Update", ctx, notification.ID, mock.Anything).Return(nil)
 	redis.On("SetWithExpiration", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -469,7 +721,2113 @@ func TestIncRetryCount_Success(t *testing.T) {
 	err := svc.IncRetryCount(ctx, notification)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 1, notification.RetryCount)
+	// счетчик в памяти следует за базой: по нему проверяется предел попыток
+	assert.Equal(t, 2, notification.RetryCount)
+
+	repo.AssertExpectations(t)
+}
+
+// TestDelete_Success проверяет удаление уведомления из базы и кеша
+func TestDelete_Success(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := new(MockRedis)
+
+	notificationID := uuid.New()
+
+	repo.On("Delete", ctx, notificationID).Return(nil)
+	redis.On("Del", ctx, service.CacheKey(notificationID)).Return(nil)
+
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	err := svc.Delete(ctx, notificationID)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	redis.AssertExpectations(t)
+}
+
+// TestDelete_NotFound проверяет, что кеш не трогается, если уведомления нет
+func TestDelete_NotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := new(MockRedis)
+
+	notificationID := uuid.New()
+
+	repo.On("Delete", ctx, notificationID).Return(domain.ErrNotFound)
+
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	err := svc.Delete(ctx, notificationID)
+
+	assert.Equal(t, domain.ErrNotFound, err)
+	redis.AssertNotCalled(t, "Del")
+}
+
+// memoryRedis простая реализация RedisRepository в памяти
+type memoryRedis struct {
+	data      map[string]string
+	mgetCalls int
+	msetCalls int
+}
+
+func (m *memoryRedis) Get(_ context.Context, key string) (string, error) {
+	v, ok := m.data[key]
+	if !ok {
+		return "", rd.Nil
+	}
+	return v, nil
+}
+
+func (m *memoryRedis) SetWithExpiration(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	switch v := value.(type) {
+	case []byte:
+		m.data[key] = string(v)
+	case string:
+		m.data[key] = v
+	}
+	return nil
+}
+
+func (m *memoryRedis) SetNXWithExpiration(ctx context.Context, key string, value interface{},
+	exp time.Duration) (bool, error) {
+	if _, ok := m.data[key]; ok {
+		return false, nil
+	}
+	return true, m.SetWithExpiration(ctx, key, value, exp)
+}
+
+func (m *memoryRedis) Del(_ context.Context, key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memoryRedis) MGet(_ context.Context, keys []string) (map[string]string, error) {
+	m.mgetCalls++
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if v, ok := m.data[key]; ok {
+			result[key] = v
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryRedis) MSetNXWithExpiration(ctx context.Context, values map[string]interface{},
+	exp time.Duration) (int, error) {
+	m.msetCalls++
+	set := 0
+	for key, value := range values {
+		if ok, _ := m.SetNXWithExpiration(ctx, key, value, exp); ok {
+			set++
+		}
+	}
+	return set, nil
+}
+
+// TestCreateThenGet_ReadsFromCache проверяет, что созданное уведомление сразу
+// читается из кэша без обращения к базе данных
+func TestCreateThenGet_ReadsFromCache(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+
+	notification := &domain.Notification{
+		ID:          uuid.New(),
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		Payload:     map[string]interface{}{"subject": "Test"},
+		ScheduledAt: time.Now().Add(time.Hour),
+		Status:      domain.StatusPending,
+	}
+
+	repo.On("Create", ctx, mock.Anything).Return(notification, nil)
+	publisher.On("Publish", ctx, notification.ID, mock.Anything).Return(nil)
+
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour)
+
+	created, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient:   notification.Recipient,
+		Channel:     notification.Channel,
+		Payload:     notification.Payload,
+		ScheduledAt: notification.ScheduledAt,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, redis.data, service.CacheKey(created.ID))
+
+	result, err := svc.GetNotificationByID(ctx, created.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, result.ID)
+	assert.Equal(t, created.Recipient, result.Recipient)
+	repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+// TestCacheCodec_MixedDeploy проверяет, что записи msgpack читаются экземпляром
+// с кодеком JSON и наоборот
+func TestCacheCodec_MixedDeploy(t *testing.T) {
+	ctx := context.Background()
+	redis := &memoryRedis{data: map[string]string{}}
+
+	msgpackCodec, err := service.NewCacheCodec(service.CodecMsgpack)
+	assert.NoError(t, err)
+	jsonCodec, err := service.NewCacheCodec(service.CodecJSON)
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		writer service.CacheCodec
+		reader service.CacheCodec
+	}{
+		{"msgpack to json", msgpackCodec, jsonCodec},
+		{"json to msgpack", jsonCodec, msgpackCodec},
+		{"msgpack to msgpack", msgpackCodec, msgpackCodec},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			publisher := new(MockPublisher)
+			notification := &domain.Notification{
+				ID:          uuid.New(),
+				Recipient:   "test@example.com",
+				Channel:     domain.ChannelEmail,
+				Payload:     map[string]interface{}{"subject": "Test", "meta": map[string]interface{}{"a": "b"}},
+				ScheduledAt: time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond),
+				Status:      domain.StatusPending,
+			}
+			repo.On("Create", ctx, mock.Anything).Return(notification, nil)
+			publisher.On("Publish", ctx, notification.ID, mock.Anything).Return(nil)
+
+			writer := service.NewNotificationService(repo, publisher, redis, time.Hour, service.WithCacheCodec(tc.writer))
+			reader := service.NewNotificationService(repo, publisher, redis, time.Hour, service.WithCacheCodec(tc.reader))
+
+			_, err := writer.CreateNotification(ctx, domain.CreateNotificationParams{
+				Recipient:   notification.Recipient,
+				Channel:     notification.Channel,
+				Payload:     notification.Payload,
+				ScheduledAt: notification.ScheduledAt,
+			})
+			assert.NoError(t, err)
+
+			result, err := reader.GetNotificationByID(ctx, notification.ID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, notification.ID, result.ID)
+			assert.Equal(t, notification.Payload, result.Payload)
+			assert.True(t, notification.ScheduledAt.Equal(result.ScheduledAt))
+			repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestGetNotificationsByIDs проверяет пакетное чтение: один MGET, промахи из базы
+// и одна пакетная запись в кэш
+func TestGetNotificationsByIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	cached := &domain.Notification{ID: uuid.New(), Recipient: "cached@example.com", Status: domain.StatusPending}
+	missed := &domain.Notification{ID: uuid.New(), Recipient: "missed@example.com", Status: domain.StatusPending}
+	unknownID := uuid.New()
+
+	data, _ := json.Marshal(cached)
+	redis.data[service.CacheKey(cached.ID)] = string(data)
+	repo.On("GetByID", ctx, missed.ID).Return(missed, nil)
+	repo.On("GetByID", ctx, unknownID).Return(nil, domain.ErrNotFound)
+
+	result, err := svc.GetNotificationsByIDs(ctx, []uuid.UUID{cached.ID, missed.ID, unknownID})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, cached.ID, result[0].ID)
+	assert.Equal(t, missed.ID, result[1].ID)
+	assert.Equal(t, 1, redis.mgetCalls)
+	assert.Equal(t, 1, redis.msetCalls)
+	assert.Contains(t, redis.data, service.CacheKey(missed.ID))
+	repo.AssertNotCalled(t, "GetByID", ctx, cached.ID)
+}
+
+// TestWarmCache проверяет загрузку скоро срабатывающих уведомлений в кеш
+func TestWarmCache(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	due := []domain.Notification{
+		{ID: uuid.New(), Recipient: "a@example.com", Status: domain.StatusPending},
+		{ID: uuid.New(), Recipient: "b@example.com", Status: domain.StatusPending},
+	}
+	repo.On("ListPendingScheduledBetween", ctx, mock.Anything, mock.Anything, 100).Return(due, nil)
+	// запись новее снимка из базы: уведомление успели отменить
+	redis.data[service.CacheKey(due[1].ID)] = "cancelled"
+
+	warmed, err := svc.WarmCache(ctx, 5*time.Minute, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, warmed)
+	assert.Equal(t, 1, redis.msetCalls)
+	assert.Contains(t, redis.data, service.CacheKey(due[0].ID))
+	assert.Equal(t, "cancelled", redis.data[service.CacheKey(due[1].ID)])
+}
+
+// TestCreateNotification_ScheduleLimits проверяет ограничения горизонта планирования
+func TestCreateNotification_ScheduleLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheduledAt time.Time
+		immediate   bool
+		wantErr     error
+	}{
+		{"too far in past", time.Now().Add(-time.Hour), false, domain.ErrScheduledTooFarInPast},
+		{"too far in future", time.Now().Add(2 * 365 * 24 * time.Hour), false, domain.ErrScheduledTooFarInFuture},
+		{"past with immediate", time.Now().Add(-time.Hour), true, nil},
+		{"slightly in past", time.Now().Add(-time.Minute), false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(MockRepository)
+			publisher := new(MockPublisher)
+			redis := new(MockRedis)
+			notification := &domain.Notification{ID: uuid.New(), ScheduledAt: tt.scheduledAt}
+			repo.On("Create", ctx, mock.Anything).Return(notification, nil)
+			redis.On("SetWithExpiration", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			publisher.On("Publish", ctx, notification.ID, mock.Anything).Return(nil)
+
+			svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+				service.WithScheduleLimits(5*time.Minute, 365*24*time.Hour))
+
+			_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+				Recipient:   "test@example.com",
+				Channel:     domain.ChannelEmail,
+				ScheduledAt: tt.scheduledAt,
+				Immediate:   tt.immediate,
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// TestCreateNotification_RecipientValidation проверяет проверку формата получателя по каналу
+func TestCreateNotification_RecipientValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		channel   domain.Channel
+		recipient string
+		valid     bool
+	}{
+		{"email ok", domain.ChannelEmail, "user@example.com", true},
+		{"email with display name", domain.ChannelEmail, "User <user@example.com>", false},
+		{"email without domain", domain.ChannelEmail, "user@", false},
+		{"telegram chat id", domain.ChannelTelegram, "123456789", true},
+		{"telegram group id", domain.ChannelTelegram, "-1001234567890", true},
+		{"telegram username", domain.ChannelTelegram, "@delayed_bot", true},
+		{"telegram short username", domain.ChannelTelegram, "@abc", false},
+		{"telegram email", domain.ChannelTelegram, "user@example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(MockRepository)
+			publisher := new(MockPublisher)
+			redis := new(MockRedis)
+			notification := &domain.Notification{ID: uuid.New()}
+			repo.On("Create", ctx, mock.Anything).Return(notification, nil)
+			redis.On("SetWithExpiration", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			publisher.On("Publish", ctx, notification.ID, mock.Anything).Return(nil)
+
+			svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+				service.WithRecipientValidator(domain.ChannelEmail, emailsender.NewRecipientValidator(false)),
+				service.WithRecipientValidator(domain.ChannelTelegram, telegramsender.RecipientValidator))
+
+			_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+				Recipient:   tt.recipient,
+				Channel:     tt.channel,
+				ScheduledAt: time.Now().Add(time.Hour),
+			})
+
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, domain.ErrInvalidRecipient)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestPreviewNotification проверяет построение итогового сообщения по каналу уведомления
+func TestPreviewNotification(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour,
+		service.WithRenderer(domain.ChannelEmail, emailsender.Renderer))
+
+	email := &domain.Notification{ID: uuid.New(), Recipient: "user@example.com", Channel: domain.ChannelEmail,
+		Payload: map[string]interface{}{"subject": "Привет", "body": "Как дела?"}}
+	tg := &domain.Notification{ID: uuid.New(), Recipient: "123456", Channel: domain.ChannelTelegram,
+		Payload: map[string]interface{}{"body": "Как дела?"}}
+	repo.On("GetByID", ctx, email.ID).Return(email, nil)
+	repo.On("GetByID", ctx, tg.ID).Return(tg, nil)
+
+	msg, err := svc.PreviewNotification(ctx, email.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Привет", msg.Subject)
+	assert.Equal(t, "Как дела?", msg.Body)
+	assert.Equal(t, "user@example.com", msg.Recipient)
 
+	_, err = svc.PreviewNotification(ctx, tg.ID)
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+}
+
+// TestCloneNotification проверяет, что копия прошедшего уведомления отправляется сразу
+// новому получателю с исходным payload
+func TestCloneNotification(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+		service.WithScheduleLimits(5*time.Minute, 0))
+
+	source := &domain.Notification{
+		ID:          uuid.New(),
+		Recipient:   "first@example.com",
+		Channel:     domain.ChannelEmail,
+		Payload:     map[string]interface{}{"subject": "Test"},
+		ScheduledAt: time.Now().Add(-24 * time.Hour),
+		Status:      domain.StatusSent,
+		Source:      "billing",
+	}
+	clone := &domain.Notification{ID: uuid.New(), Recipient: "second@example.com", Channel: domain.ChannelEmail}
+
+	repo.On("GetByID", ctx, source.ID).Return(source, nil)
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.Recipient == "second@example.com" && p.Payload["subject"] == "Test" &&
+			p.Status == domain.StatusProcessing && *p.ParentID == source.ID && *p.CorrelationID == source.ID &&
+			p.Source == "billing"
+	})).Return(clone, nil)
+	publisher.On("Publish", ctx, clone.ID, mock.Anything).Return(nil)
+
+	result, err := svc.CloneNotification(ctx, source.ID, domain.CloneNotificationParams{Recipient: "second@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, clone.ID, result.ID)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.CloneNotification(ctx, source.ID, domain.CloneNotificationParams{ScheduledAt: &past})
+	assert.ErrorIs(t, err, domain.ErrScheduledTooFarInPast)
+}
+
+// TestGetRelatedNotifications проверяет, что цепочка ищется от корня, а не от запрошенного уведомления
+func TestGetRelatedNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	rootID := uuid.New()
+	child := &domain.Notification{ID: uuid.New(), ParentID: &rootID, CorrelationID: &rootID}
+	chain := []domain.Notification{{ID: rootID}, *child}
+	repo.On("GetByID", ctx, child.ID).Return(child, nil)
+	repo.On("ListRelated", ctx, rootID).Return(chain, nil)
+
+	result, err := svc.GetRelatedNotifications(ctx, child.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, chain, result)
+}
+
+// TestCreateNotification_UnknownParent проверяет ошибку при несуществующем parent_id
+func TestCreateNotification_UnknownParent(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	parentID := uuid.New()
+	repo.On("GetByID", ctx, parentID).Return(nil, domain.ErrNotFound)
+
+	_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		ScheduledAt: time.Now().Add(time.Hour),
+		ParentID:    &parentID,
+	})
+
+	assert.ErrorIs(t, err, domain.ErrParentNotFound)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestConfirm проверяет отмену по подтверждению до наступления scheduled_at
+func TestConfirm(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		n       domain.Notification
+		wantErr error
+		cancel  bool
+	}{
+		{"before scheduled_at", domain.Notification{CancelOnConfirm: true, Status: domain.StatusPending,
+			ScheduledAt: time.Now().Add(time.Hour)}, nil, true},
+		{"already confirmed", domain.Notification{CancelOnConfirm: true, Status: domain.StatusCancelled,
+			ScheduledAt: time.Now().Add(time.Hour)}, nil, false},
+		{"already sending", domain.Notification{CancelOnConfirm: true, Status: domain.StatusProcessing,
+			ScheduledAt: time.Now()}, domain.ErrConfirmTooLate, false},
+		{"no condition", domain.Notification{Status: domain.StatusPending,
+			ScheduledAt: time.Now().Add(time.Hour)}, domain.ErrConfirmNotExpected, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			redis := &memoryRedis{data: map[string]string{}}
+			svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+			n := tc.n
+			n.ID = uuid.New()
+			repo.On("GetByID", ctx, n.ID).Return(&n, nil)
+			if tc.cancel {
+				repo.On("Update", ctx, n.ID, mock.Anything).Return(nil)
+			}
+
+			err := svc.Confirm(ctx, n.ID)
+
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.cancel {
+				assert.Equal(t, domain.StatusCancelled, n.Status)
+			} else {
+				repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// TestCreateNotification_Smoothing проверяет сдвиг отправки внутри окна и расчет TTL от него
+func TestCreateNotification_Smoothing(t *testing.T) {
+	ctx := context.Background()
+	window := 30 * time.Minute
+	scheduledAt := time.Now().Add(time.Hour)
+
+	for _, smooth := range []bool{false, true} {
+		repo := new(MockRepository)
+		publisher := new(MockPublisher)
+		redis := &memoryRedis{data: map[string]string{}}
+		svc := service.NewNotificationService(repo, publisher, redis, time.Hour, service.WithSmoothing(window))
+
+		var effective time.Time
+		repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+			effective = p.EffectiveScheduledAt
+			return true
+		})).Return(&domain.Notification{ID: uuid.New()}, nil)
+		publisher.On("Publish", ctx, mock.Anything, mock.MatchedBy(func(ttl time.Duration) bool {
+			return ttl > time.Until(effective)-5*time.Second && ttl <= time.Until(effective)
+		})).Return(nil)
+
+		_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+			Recipient:   "test@example.com",
+			Channel:     domain.ChannelEmail,
+			ScheduledAt: scheduledAt,
+			Smooth:      smooth,
+		})
+
+		assert.NoError(t, err)
+		if smooth {
+			assert.False(t, effective.Before(scheduledAt))
+			assert.True(t, effective.Before(scheduledAt.Add(window)))
+		} else {
+			assert.Equal(t, scheduledAt, effective)
+		}
+		publisher.AssertExpectations(t)
+	}
+}
+
+// TestCreateNotification_Admission проверяет отклонение низких приоритетов при отставании очереди
+func TestCreateNotification_Admission(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+		service.WithAdmission(10, 100, time.Minute))
+
+	// отставание считается один раз и кешируется на refresh
+	repo.On("CountBacklog", ctx, mock.Anything).Return(50, nil).Once()
+	repo.On("Create", ctx, mock.Anything).Return(&domain.Notification{ID: uuid.New()}, nil)
+	publisher.On("Publish", ctx, mock.Anything, mock.Anything).Return(nil)
+
+	create := func(p domain.Priority) error {
+		_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+			Recipient:   "test@example.com",
+			Channel:     domain.ChannelEmail,
+			ScheduledAt: time.Now().Add(time.Hour),
+			Priority:    p,
+		})
+		return err
+	}
+
+	assert.ErrorIs(t, create(domain.PriorityLow), domain.ErrOverloaded)
+	assert.NoError(t, create(domain.PriorityNormal))
+	assert.NoError(t, create(""))
+	assert.NoError(t, create(domain.PriorityHigh))
+	assert.ErrorIs(t, create("urgent"), domain.ErrInvalidPriority)
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "Create", 3)
+}
+
+// TestCountStaleProcessing проверяет, что граница считается от текущего времени
+func TestCountStaleProcessing(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	repo.On("CountProcessingBefore", ctx, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 10*time.Minute && time.Since(before) < 11*time.Minute
+	})).Return(2, nil)
+
+	count, err := svc.CountStaleProcessing(ctx, 10*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	repo.AssertExpectations(t)
+}
+
+// TestReprocessFailed проверяет, что переотправляются только уведомления, которые удалось вернуть из failed
+func TestReprocessFailed(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	cache := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, publisher, cache, time.Hour)
+
+	failed := []domain.Notification{
+		{ID: uuid.New(), Status: domain.StatusFailed, RetryCount: 3, ReprocessCount: 1},
+		{ID: uuid.New(), Status: domain.StatusFailed, RetryCount: 3},
+	}
+	repo.On("ListFailedBefore", ctx, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 30*time.Minute
+	}), 5, 100).Return(failed, nil)
+	cache.data[service.CacheKey(failed[0].ID)] = `{"status":"failed"}`
+	repo.On("FailedToProcess", ctx, failed[0].ID).Return(true, nil)
+	// второе уже подхватил другой экземпляр
+	repo.On("FailedToProcess", ctx, failed[1].ID).Return(false, nil)
+	publisher.On("Publish", ctx, failed[0].ID, mock.Anything).Return(nil)
+
+	count, err := svc.ReprocessFailed(ctx, 30*time.Minute, 5, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+	publisher.AssertNumberOfCalls(t, "Publish", 1)
+
+	// updated_at выставила база, поэтому прежняя запись кеша удалена, а не перезаписана
+	assert.NotContains(t, cache.data, service.CacheKey(failed[0].ID))
+}
+
+// TestUpdateNotification_CacheSync проверяет, что после изменения в кеше не остается прежнего состояния
+func TestUpdateNotification_CacheSync(t *testing.T) {
+	ctx := context.Background()
+	newNotification := func() *domain.Notification {
+		return &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail, Status: domain.StatusPending}
+	}
+
+	t.Run("write-through rewrites entry", func(t *testing.T) {
+		repo := new(MockRepository)
+		cache := &memoryRedis{data: map[string]string{}}
+		svc := service.NewNotificationService(repo, nil, cache, time.Hour)
+		n := newNotification()
+		repo.On("Update", ctx, n.ID, mock.Anything).Return(nil)
+
+		assert.NoError(t, svc.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusCancelled)))
+		assert.Contains(t, cache.data[service.CacheKey(n.ID)], `"cancelled"`)
+	})
+
+	t.Run("invalidate mode evicts entry", func(t *testing.T) {
+		repo := new(MockRepository)
+		cache := &memoryRedis{data: map[string]string{}}
+		svc := service.NewNotificationService(repo, nil, cache, time.Hour, service.WithCacheWriteThrough(false))
+		n := newNotification()
+		cache.data[service.CacheKey(n.ID)] = `{"status":"pending"}`
+		repo.On("Update", ctx, n.ID, mock.Anything).Return(nil)
+
+		assert.NoError(t, svc.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusCancelled)))
+		assert.NotContains(t, cache.data, service.CacheKey(n.ID))
+	})
+
+	t.Run("failed rewrite falls back to eviction", func(t *testing.T) {
+		repo := new(MockRepository)
+		redis := new(MockRedis)
+		svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+		n := newNotification()
+		repo.On("Update", ctx, n.ID, mock.Anything).Return(nil)
+		redis.On("SetWithExpiration", ctx, service.CacheKey(n.ID), mock.Anything, time.Hour).
+			Return(errors.New("redis timeout"))
+		redis.On("Del", ctx, service.CacheKey(n.ID)).Return(nil)
+
+		assert.NoError(t, svc.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusFailed)))
+		redis.AssertExpectations(t)
+	})
+
+	t.Run("row changed elsewhere evicts entry", func(t *testing.T) {
+		repo := new(MockRepository)
+		cache := &memoryRedis{data: map[string]string{}}
+		svc := service.NewNotificationService(repo, nil, cache, time.Hour)
+		n := newNotification()
+		cache.data[service.CacheKey(n.ID)] = `{"status":"pending"}`
+		repo.On("Update", ctx, n.ID, mock.Anything).Return(domain.ErrNoRowAffected)
+
+		assert.NoError(t, svc.UpdateNotification(ctx, n, domain.WithStatus(domain.StatusCancelled)))
+		assert.NotContains(t, cache.data, service.CacheKey(n.ID))
+	})
+}
+
+// TestReconcileOrphans проверяет переотправку, истечение и завершение потерянных уведомлений;
+// переотправляется только строка, которую сверка забрала из pending
+func TestReconcileOrphans(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := new(MockRedis)
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour)
+
+	now := time.Now()
+	recent := domain.Notification{ID: uuid.New(), Status: domain.StatusPending, EffectiveScheduledAt: now.Add(-time.Hour)}
+	ancient := domain.Notification{ID: uuid.New(), Status: domain.StatusPending, EffectiveScheduledAt: now.Add(-48 * time.Hour)}
+	stuck := domain.Notification{ID: uuid.New(), Status: domain.StatusProcessing, EffectiveScheduledAt: now.Add(-time.Hour)}
+	taken := domain.Notification{ID: uuid.New(), Status: domain.StatusPending, EffectiveScheduledAt: now.Add(-time.Hour)}
+	unpublished := domain.Notification{ID: uuid.New(), Status: domain.StatusPending, EffectiveScheduledAt: now.Add(-time.Hour)}
+
+	repo.On("ListPendingAndProcessingAfter", ctx, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 15*time.Minute
+	}), (*domain.OrphanCursor)(nil), 5).Return([]domain.Notification{recent, ancient, stuck, taken, unpublished}, nil)
+	repo.On("PendingToProcess", ctx, recent.ID).Return(true, nil)
+	// исходное сообщение опоздало, и консьюмер уже забрал уведомление: повторно не публикуется
+	repo.On("PendingToProcess", ctx, taken.ID).Return(false, nil)
+	repo.On("PendingToProcess", ctx, unpublished.ID).Return(true, nil)
+	publisher.On("Publish", ctx, recent.ID, 2*time.Second).Return(nil)
+	publisher.On("Publish", ctx, unpublished.ID, 2*time.Second).Return(errors.New("broker is down"))
+	repo.On("Update", ctx, ancient.ID, mock.Anything).Return(nil)
+	repo.On("Update", ctx, stuck.ID, mock.Anything).Return(nil)
+	repo.On("Update", ctx, unpublished.ID, mock.Anything).Return(nil).Once()
+	redis.On("SetWithExpiration", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	redis.On("Del", ctx, mock.Anything).Return(nil)
+
+	report, err := svc.ReconcileOrphans(ctx, 15*time.Minute, 24*time.Hour, nil, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrphanReport{Found: 5, Republished: 1, Expired: 1, Failed: 1,
+		Next: &domain.OrphanCursor{EffectiveScheduledAt: unpublished.EffectiveScheduledAt, ID: unpublished.ID}}, report)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+	publisher.AssertNotCalled(t, "Publish", ctx, taken.ID, mock.Anything)
+}
+
+// TestClaimSend проверяет, что из двух сообщений одного уведомления отправку получает одно
+func TestClaimSend(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	pending := &domain.Notification{ID: uuid.New(), Status: domain.StatusPending}
+	repo.On("PendingToProcess", ctx, pending.ID).Return(true, nil).Once()
+
+	release, ok, err := svc.ClaimSend(ctx, pending)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, domain.StatusProcessing, pending.Status)
+	assert.Contains(t, redis.data, service.SendLeaseKey(pending.ID))
+
+	// второе сообщение, пока первое отправляется
+	_, ok, err = svc.ClaimSend(ctx, &domain.Notification{ID: pending.ID, Status: domain.StatusProcessing})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	release()
+	assert.NotContains(t, redis.data, service.SendLeaseKey(pending.ID))
+
+	// уведомление забрали из pending раньше: сообщение отбрасывается, аренда снимается
+	stale := &domain.Notification{ID: uuid.New(), Status: domain.StatusPending}
+	repo.On("PendingToProcess", ctx, stale.ID).Return(false, nil).Once()
+	_, ok, err = svc.ClaimSend(ctx, stale)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NotContains(t, redis.data, service.SendLeaseKey(stale.ID))
+	repo.AssertExpectations(t)
+}
+
+// TestDeferNotification проверяет перенос отправки без изменения запрошенного времени
+func TestDeferNotification(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	scheduledAt := time.Now().Add(-time.Minute)
+	n := &domain.Notification{ID: uuid.New(), Status: domain.StatusProcessing, ScheduledAt: scheduledAt, RetryCount: 1}
+	repo.On("Update", ctx, n.ID, mock.Anything, mock.Anything).Return(nil)
+	publisher.On("Publish", ctx, n.ID, time.Minute).Return(nil)
+
+	err := svc.DeferNotification(ctx, n, time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, n.Status)
+	assert.Equal(t, scheduledAt, n.ScheduledAt)
+	assert.Equal(t, 1, n.RetryCount)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), n.EffectiveScheduledAt, time.Second)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+// waitQueuePublisher ведет себя как очереди ожидания RabbitMQ: повторное объявление существующей
+// очереди с другим TTL отклоняется, Republish удаляет очередь и объявляет заново.
+type waitQueuePublisher struct {
+	queues map[uuid.UUID]time.Duration
+}
+
+func (p *waitQueuePublisher) Publish(_ context.Context, id uuid.UUID, ttl time.Duration) error {
+	if existing, ok := p.queues[id]; ok && existing != ttl {
+		return errors.New("PRECONDITION_FAILED - inequivalent arg 'x-expires'")
+	}
+	p.queues[id] = ttl
+	return nil
+}
+
+func (p *waitQueuePublisher) Republish(ctx context.Context, id uuid.UUID, ttl time.Duration) error {
+	delete(p.queues, id)
+	return p.Publish(ctx, id, ttl)
+}
+
+// TestDeferNotification_ExistingWaitQueue проверяет откладывание, пока очередь ожидания
+// сработавшего сообщения еще не удалена брокером
+func TestDeferNotification_ExistingWaitQueue(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	id := uuid.New()
+	publisher := &waitQueuePublisher{queues: map[uuid.UUID]time.Duration{id: time.Hour}}
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	n := &domain.Notification{ID: id, Status: domain.StatusProcessing, ScheduledAt: time.Now()}
+	repo.On("Update", ctx, n.ID, mock.Anything, mock.Anything).Return(nil)
+
+	err := svc.DeferNotification(ctx, n, time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, publisher.queues[id])
+	repo.AssertExpectations(t)
+}
+
+// TestApprove проверяет, что уведомление с requires_approval публикуется только после одобрения
+func TestApprove(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour)
+
+	scheduledAt := time.Now().Add(time.Hour)
+	created := &domain.Notification{ID: uuid.New(), Status: domain.StatusAwaitingApproval,
+		RequiresApproval: true, ScheduledAt: scheduledAt, EffectiveScheduledAt: scheduledAt}
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.RequiresApproval && p.Status == domain.StatusAwaitingApproval
+	})).Return(created, nil)
+
+	n, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient:        "test@example.com",
+		Channel:          domain.ChannelEmail,
+		ScheduledAt:      scheduledAt,
+		RequiresApproval: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusAwaitingApproval, n.Status)
+	publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+
+	stored := *created
+	repo.On("GetByID", ctx, created.ID).Return(&stored, nil)
+	repo.On("Approve", ctx, created.ID, "compliance", domain.StatusPending).Return(true, nil)
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil)
+
+	approved, err := svc.Approve(ctx, created.ID, "compliance")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, approved.Status)
+	assert.Equal(t, "compliance", approved.ApprovedBy)
+	assert.NotNil(t, approved.ApprovedAt)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	plain := &domain.Notification{ID: uuid.New(), Status: domain.StatusPending}
+	repo.On("GetByID", ctx, plain.ID).Return(plain, nil)
+	_, err = svc.Approve(ctx, plain.ID, "compliance")
+	assert.ErrorIs(t, err, domain.ErrApprovalNotRequired)
+}
+
+// TestDraftLifecycle проверяет, что черновик не публикуется до планирования
+func TestDraftLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+		service.WithScheduleLimits(5*time.Minute, 0))
+
+	// время отправки черновика не проверяется: его зададут при планировании
+	past := time.Now().Add(-time.Hour)
+	draft := &domain.Notification{ID: uuid.New(), Status: domain.StatusDraft, Channel: domain.ChannelEmail,
+		Recipient: "test@example.com", ScheduledAt: past, EffectiveScheduledAt: past}
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.Status == domain.StatusDraft
+	})).Return(draft, nil)
+
+	n, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		ScheduledAt: past,
+		Draft:       true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusDraft, n.Status)
+	publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+
+	stored := *draft
+	repo.On("GetByID", ctx, draft.ID).Return(&stored, nil)
+	repo.On("Update", ctx, draft.ID, mock.Anything).Return(nil)
+
+	recipient := "other@example.com"
+	n, err = svc.UpdateDraft(ctx, draft.ID, domain.DraftUpdateParams{Recipient: &recipient})
+	assert.NoError(t, err)
+	assert.Equal(t, recipient, n.Recipient)
+
+	_, err = svc.ScheduleDraft(ctx, draft.ID, domain.ScheduleParams{})
+	assert.ErrorIs(t, err, domain.ErrScheduledTooFarInPast)
+
+	at := time.Now().Add(time.Hour)
+	publisher.On("Publish", ctx, draft.ID, mock.Anything).Return(nil)
+	n, err = svc.ScheduleDraft(ctx, draft.ID, domain.ScheduleParams{ScheduledAt: &at})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, n.Status)
+	assert.Equal(t, at, n.ScheduledAt)
+	publisher.AssertExpectations(t)
+
+	sent := &domain.Notification{ID: uuid.New(), Status: domain.StatusSent}
+	repo.On("GetByID", ctx, sent.ID).Return(sent, nil)
+	_, err = svc.UpdateDraft(ctx, sent.ID, domain.DraftUpdateParams{Recipient: &recipient})
+	assert.ErrorIs(t, err, domain.ErrNotDraft)
+}
+
+// TestScheduleDraft_Admission проверяет, что планирование черновика учитывает его приоритет
+func TestScheduleDraft_Admission(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+		service.WithAdmission(10, 100, time.Minute))
+
+	at := time.Now().Add(time.Hour)
+	low := &domain.Notification{ID: uuid.New(), Status: domain.StatusDraft, Priority: domain.PriorityLow,
+		Channel: domain.ChannelEmail, Recipient: "test@example.com", ScheduledAt: at}
+	high := &domain.Notification{ID: uuid.New(), Status: domain.StatusDraft, Priority: domain.PriorityHigh,
+		Channel: domain.ChannelEmail, Recipient: "test@example.com", ScheduledAt: at}
+	repo.On("CountBacklog", ctx, mock.Anything).Return(50, nil).Once()
+	repo.On("GetByID", ctx, low.ID).Return(low, nil)
+	repo.On("GetByID", ctx, high.ID).Return(high, nil)
+	repo.On("Update", ctx, high.ID, mock.Anything).Return(nil)
+	publisher.On("Publish", ctx, high.ID, mock.Anything).Return(nil)
+
+	_, err := svc.ScheduleDraft(ctx, low.ID, domain.ScheduleParams{})
+	assert.ErrorIs(t, err, domain.ErrOverloaded)
+
+	n, err := svc.ScheduleDraft(ctx, high.ID, domain.ScheduleParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, n.Status)
+	repo.AssertNotCalled(t, "Update", ctx, low.ID, mock.Anything)
+	publisher.AssertExpectations(t)
+}
+
+// TestRecordReceipt проверяет переходы по квитанциям и игнорирование устаревших
+func TestRecordReceipt(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour)
+
+	sent := &domain.Notification{ID: uuid.New(), Status: domain.StatusSent}
+	repo.On("GetByID", ctx, sent.ID).Return(sent, nil)
+	repo.On("Update", ctx, sent.ID, mock.Anything).Return(nil)
+
+	n, err := svc.RecordReceipt(ctx, sent.ID, domain.StatusRead, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusRead, n.Status)
+
+	// доставка пришла после прочтения — статус не откатывается
+	n, err = svc.RecordReceipt(ctx, sent.ID, domain.StatusDelivered, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusRead, n.Status)
+	repo.AssertNumberOfCalls(t, "Update", 1)
+
+	pending := &domain.Notification{ID: uuid.New(), Status: domain.StatusPending}
+	repo.On("GetByID", ctx, pending.ID).Return(pending, nil)
+	_, err = svc.RecordReceipt(ctx, pending.ID, domain.StatusDelivered, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+
+	_, err = svc.RecordReceipt(ctx, sent.ID, domain.StatusFailed, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidReceiptStatus)
+}
+
+// TestRecordReceipt_Dedup проверяет, что повторно доставленное событие не меняет статус,
+// а событие, которое не удалось применить, можно доставить снова
+func TestRecordReceipt_Dedup(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(repo, nil, redis, time.Hour, service.WithReceiptDedup(time.Hour))
+
+	sent := &domain.Notification{ID: uuid.New(), Status: domain.StatusSent}
+	repo.On("GetByID", ctx, sent.ID).Return(sent, nil)
+	repo.On("Update", ctx, sent.ID, mock.Anything).Return(nil)
+
+	n, err := svc.RecordReceipt(ctx, sent.ID, domain.StatusBounced, "evt-1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusBounced, n.Status)
+	_, err = svc.RecordReceipt(ctx, sent.ID, domain.StatusBounced, "evt-1")
+	assert.ErrorIs(t, err, domain.ErrDuplicateEvent)
+	repo.AssertNumberOfCalls(t, "GetByID", 1)
+
+	missing := uuid.New()
+	repo.On("GetByID", ctx, missing).Return(nil, domain.ErrNotFound).Twice()
+	_, err = svc.RecordReceipt(ctx, missing, domain.StatusDelivered, "evt-2")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NotContains(t, redis.data, service.ReceiptEventKey("evt-2"))
+	_, err = svc.RecordReceipt(ctx, missing, domain.StatusDelivered, "evt-2")
+	assert.ErrorIs(t, err, domain.ErrNotFound, "неудавшееся событие не считается дубликатом")
+}
+
+// TestSnoozeRecipient проверяет паузу получателя: установку, чтение и снятие
+func TestSnoozeRecipient(t *testing.T) {
+	ctx := context.Background()
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(new(MockRepository), nil, redis, time.Hour)
+
+	_, ok, err := svc.RecipientSnoozedUntil(ctx, domain.ChannelEmail, "user@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	until, err := svc.SnoozeRecipient(ctx, domain.ChannelEmail, "user@example.com", 2*time.Hour)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), until, time.Second)
+
+	got, ok, err := svc.RecipientSnoozedUntil(ctx, domain.ChannelEmail, "user@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, until.Equal(got))
+
+	// пауза действует только в своем канале
+	_, ok, _ = svc.RecipientSnoozedUntil(ctx, domain.ChannelTelegram, "user@example.com")
+	assert.False(t, ok)
+
+	assert.NoError(t, svc.UnsnoozeRecipient(ctx, domain.ChannelEmail, "user@example.com"))
+	_, ok, _ = svc.RecipientSnoozedUntil(ctx, domain.ChannelEmail, "user@example.com")
+	assert.False(t, ok)
+
+	_, err = svc.SnoozeRecipient(ctx, domain.ChannelEmail, "user@example.com", 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidSnoozeDuration)
+	_, err = svc.SnoozeRecipient(ctx, "fax", "user@example.com", time.Hour)
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+}
+
+// TestPreviewSchedule проверяет расчет времени отправки: сглаживание, пауза получателя,
+// часовой пояс и ограничения времени
+func TestPreviewSchedule(t *testing.T) {
+	ctx := context.Background()
+	redis := &memoryRedis{data: map[string]string{}}
+	svc := service.NewNotificationService(new(MockRepository), nil, redis, time.Hour,
+		service.WithSmoothing(30*time.Minute), service.WithScheduleLimits(time.Hour, 24*time.Hour))
+
+	at := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	preview, err := svc.PreviewSchedule(ctx, domain.SchedulePreviewParams{ScheduledAt: at})
+	assert.NoError(t, err)
+	assert.True(t, at.Equal(preview.EarliestAt))
+	assert.True(t, at.Equal(preview.LatestAt))
+
+	preview, err = svc.PreviewSchedule(ctx, domain.SchedulePreviewParams{ScheduledAt: at, Smooth: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, preview.SmoothWindow)
+	assert.True(t, at.Add(30*time.Minute).Equal(preview.LatestAt))
+
+	// пауза до середины окна сглаживания сдвигает только начало
+	until, err := svc.SnoozeRecipient(ctx, domain.ChannelEmail, "user@example.com", 2*time.Hour+10*time.Minute)
+	assert.NoError(t, err)
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	preview, err = svc.PreviewSchedule(ctx, domain.SchedulePreviewParams{ScheduledAt: at, Smooth: true,
+		Channel: domain.ChannelEmail, Recipient: "user@example.com", Location: moscow})
+	assert.NoError(t, err)
+	assert.NotNil(t, preview.SnoozedUntil)
+	assert.True(t, until.Equal(preview.EarliestAt))
+	assert.True(t, at.Add(30*time.Minute).Equal(preview.LatestAt))
+	assert.Equal(t, moscow, preview.EarliestAt.Location())
+
+	// прошедшее время с immediate — отправка сразу
+	preview, err = svc.PreviewSchedule(ctx, domain.SchedulePreviewParams{ScheduledAt: time.Now().Add(-2 * time.Hour),
+		Immediate: true})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), preview.EarliestAt, time.Second)
+
+	_, err = svc.PreviewSchedule(ctx, domain.SchedulePreviewParams{ScheduledAt: time.Now().Add(-2 * time.Hour)})
+	assert.ErrorIs(t, err, domain.ErrScheduledTooFarInPast)
+	_, err = svc.PreviewSchedule(ctx, domain.SchedulePreviewParams{ScheduledAt: at, Channel: "fax"})
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+}
+
+// TestSearchNotifications проверяет проверку параметров и лимит по умолчанию
+func TestSearchNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	repo.On("Search", ctx, domain.SearchParams{Query: "invoice 4521", Limit: 20}).
+		Return([]domain.SearchHit{{Rank: 0.5}}, nil)
+
+	hits, err := svc.SearchNotifications(ctx, domain.SearchParams{Query: "  invoice 4521 "})
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	_, err = svc.SearchNotifications(ctx, domain.SearchParams{Query: " "})
+	assert.ErrorIs(t, err, domain.ErrEmptySearchQuery)
+	_, err = svc.SearchNotifications(ctx, domain.SearchParams{Query: "x", Channel: "fax"})
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+	_, err = svc.SearchNotifications(ctx, domain.SearchParams{Query: "x", Status: "lost"})
+	assert.ErrorIs(t, err, domain.ErrInvalidStatus)
+	repo.AssertNumberOfCalls(t, "Search", 1)
+}
+
+// TestListNotifications проверяет фильтры, размер страницы по умолчанию и признак следующей страницы
+func TestListNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	filter := domain.ListFilter{Channel: domain.ChannelEmail}
+	repo.On("List", ctx, filter, 21, 0).Return(make([]domain.Notification, 21), nil)
+	repo.On("List", ctx, filter, 3, 40).Return(make([]domain.Notification, 2), nil)
+
+	ns, hasMore, err := svc.ListNotifications(ctx, filter, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 20)
+	assert.True(t, hasMore)
+
+	ns, hasMore, err = svc.ListNotifications(ctx, filter, 2, 40)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 2)
+	assert.False(t, hasMore)
+
+	now := time.Now()
+	_, _, err = svc.ListNotifications(ctx, domain.ListFilter{Channel: "fax"}, 10, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+	_, _, err = svc.ListNotifications(ctx, domain.ListFilter{Status: "lost"}, 10, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidStatus)
+	_, _, err = svc.ListNotifications(ctx, domain.ListFilter{ScheduledFrom: now, ScheduledTo: now}, 10, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidTimeRange)
+	repo.AssertNumberOfCalls(t, "List", 2)
+}
+
+// MockViewRepository мок для SavedViewRepository
+type MockViewRepository struct {
+	mock.Mock
+}
+
+func (m *MockViewRepository) SaveView(ctx context.Context, name string,
+	filter domain.ViewFilter) (*domain.SavedView, error) {
+	args := m.Called(ctx, name, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SavedView), args.Error(1)
+}
+
+func (m *MockViewRepository) GetView(ctx context.Context, name string) (*domain.SavedView, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SavedView), args.Error(1)
+}
+
+func (m *MockViewRepository) ListViews(ctx context.Context) ([]domain.SavedView, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.SavedView), args.Error(1)
+}
+
+func (m *MockViewRepository) DeleteView(ctx context.Context, name string) error {
+	return m.Called(ctx, name).Error(0)
+}
+
+// TestSaveView проверяет проверку имени и фильтров перед сохранением
+func TestSaveView(t *testing.T) {
+	ctx := context.Background()
+	views := new(MockViewRepository)
+	svc := service.NewNotificationService(new(MockRepository), nil, &memoryRedis{data: map[string]string{}},
+		time.Hour, service.WithSavedViews(views))
+
+	filter := domain.ViewFilter{Status: domain.StatusFailed, Channel: domain.ChannelEmail, Last: 24 * time.Hour}
+	views.On("SaveView", ctx, "failed-email", filter).Return(&domain.SavedView{Name: "failed-email", Filter: filter}, nil)
+
+	v, err := svc.SaveView(ctx, "failed-email", filter)
+	assert.NoError(t, err)
+	assert.Equal(t, filter, v.Filter)
+
+	_, err = svc.SaveView(ctx, "Failed Email", filter)
+	assert.ErrorIs(t, err, domain.ErrInvalidViewName)
+	_, err = svc.SaveView(ctx, "empty", domain.ViewFilter{Limit: 10})
+	assert.ErrorIs(t, err, domain.ErrEmptySearchQuery)
+	_, err = svc.SaveView(ctx, "sms", domain.ViewFilter{Channel: "fax"})
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+	views.AssertNumberOfCalls(t, "SaveView", 1)
+
+	_, err = service.NewNotificationService(new(MockRepository), nil, nil, time.Hour).ListViews(ctx)
+	assert.ErrorIs(t, err, domain.ErrSavedViewsDisabled)
+}
+
+// TestRunView проверяет, что last отсчитывается от момента выполнения
+func TestRunView(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	views := new(MockViewRepository)
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithSavedViews(views))
+
+	views.On("GetView", ctx, "failed-email").Return(&domain.SavedView{Name: "failed-email", Filter: domain.ViewFilter{
+		Status: domain.StatusFailed, Last: time.Hour,
+	}}, nil)
+	views.On("GetView", ctx, "missing").Return(nil, domain.ErrViewNotFound)
+	repo.On("Search", ctx, mock.MatchedBy(func(p domain.SearchParams) bool {
+		return p.Status == domain.StatusFailed && p.Limit == 20 &&
+			time.Since(p.Since) >= time.Hour && time.Since(p.Since) < time.Hour+time.Minute
+	})).Return([]domain.SearchHit{{}}, nil)
+
+	hits, err := svc.RunView(ctx, "failed-email")
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	_, err = svc.RunView(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrViewNotFound)
+	repo.AssertExpectations(t)
+}
+
+// memoryFailures журнал неуспешных уведомлений в памяти.
+type memoryFailures struct {
+	records []domain.NotificationFailure
+}
+
+func (m *memoryFailures) RecordFailure(_ context.Context, f domain.NotificationFailure) (*domain.NotificationFailure,
+	error) {
+	f.ID = int64(len(m.records) + 1)
+	f.FailedAt = time.Now()
+	m.records = append(m.records, f)
+	return &f, nil
+}
+
+func (m *memoryFailures) ListFailures(_ context.Context, id uuid.UUID) ([]domain.NotificationFailure, error) {
+	var out []domain.NotificationFailure
+	for _, f := range m.records {
+		if f.NotificationID == id {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// TestFailExhausted проверяет предел попыток и запись в журнал неуспешных
+func TestFailExhausted(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	failures := &memoryFailures{}
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithMaxRetries(3), service.WithFailureLog(failures))
+
+	n := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail, Status: domain.StatusProcessing,
+		ScheduledAt: time.Now().Add(-time.Minute), RetryCount: 2}
+	repo.On("Update", ctx, n.ID, mock.Anything).Return(nil)
+
+	assert.False(t, svc.RetriesExhausted(n))
+	assert.NoError(t, svc.IncRetryCount(ctx, n))
+	assert.True(t, svc.RetriesExhausted(n))
+
+	cause := errors.New("smtp: 451 temporary failure")
+	assert.NoError(t, svc.FailExhausted(ctx, n, cause))
+	assert.Equal(t, domain.StatusFailed, n.Status)
+
+	got, err := svc.ListFailures(ctx, n.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, cause.Error(), got[0].Error)
+		assert.Equal(t, 3, got[0].Attempts)
+		assert.Equal(t, n.ScheduledAt, got[0].ScheduledAt)
+	}
+
+	unlimited := service.NewNotificationService(repo, nil, nil, time.Hour)
+	assert.False(t, unlimited.RetriesExhausted(&domain.Notification{RetryCount: 1000}))
+	_, err = unlimited.ListFailures(ctx, n.ID)
+	assert.ErrorIs(t, err, domain.ErrFailureLogDisabled)
+}
+
+// memoryEvents история статусов в памяти.
+type memoryEvents struct {
+	records []domain.NotificationEvent
+}
+
+func (m *memoryEvents) RecordEvent(_ context.Context, e domain.NotificationEvent) error {
+	e.ID = int64(len(m.records) + 1)
+	e.At = time.Now()
+	m.records = append(m.records, e)
+	return nil
+}
+
+func (m *memoryEvents) ListEvents(_ context.Context, id uuid.UUID) ([]domain.NotificationEvent, error) {
+	var out []domain.NotificationEvent
+	for _, e := range m.records {
+		if e.NotificationID == id {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// TestHistory проверяет запись переходов статуса с инициатором и причиной
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	events := &memoryEvents{}
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithEventLog(events))
+
+	at := time.Now().Add(time.Hour)
+	n := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		ScheduledAt: at, EffectiveScheduledAt: at, Status: domain.StatusPending}
+	repo.On("Create", mock.Anything, mock.Anything).Return(n, nil).Once()
+	repo.On("Update", mock.Anything, n.ID, mock.Anything).Return(nil)
+	publisher.On("Publish", mock.Anything, n.ID, mock.Anything).Return(nil).Once()
+
+	_, err := svc.CreateNotification(domain.WithActor(ctx, domain.ActorAPI), domain.CreateNotificationParams{
+		Recipient: "a@example.com", Channel: domain.ChannelEmail, ScheduledAt: at})
+	assert.NoError(t, err)
+
+	workerCtx := domain.WithActor(ctx, domain.ActorWorker)
+	assert.NoError(t, svc.IncRetryCount(workerCtx, n))
+	n.Status = domain.StatusProcessing
+	cause := errors.New("smtp: 550 mailbox unavailable")
+	assert.NoError(t, svc.UpdateNotification(domain.WithTransitionError(workerCtx, cause), n,
+		domain.WithStatus(domain.StatusFailed)))
+
+	got, err := svc.History(ctx, n.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, domain.Status(""), got[0].FromStatus)
+		assert.Equal(t, domain.StatusPending, got[0].ToStatus)
+		assert.Equal(t, domain.ActorAPI, got[0].Actor)
+		assert.Equal(t, domain.StatusProcessing, got[1].FromStatus)
+		assert.Equal(t, domain.StatusFailed, got[1].ToStatus)
+		assert.Equal(t, domain.ActorWorker, got[1].Actor)
+		assert.Equal(t, cause.Error(), got[1].Error)
+	}
+
+	repo.On("GetByID", mock.Anything, mock.Anything).Return(nil, domain.ErrNotFound).Once()
+	_, err = svc.History(ctx, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = service.NewNotificationService(repo, nil, nil, time.Hour).History(ctx, n.ID)
+	assert.ErrorIs(t, err, domain.ErrEventLogDisabled)
+}
+
+// memoryCacheInspector диагностика кэша поверх memoryRedis.
+type memoryCacheInspector struct {
+	redis   *memoryRedis
+	deleted []string
+}
+
+func (m *memoryCacheInspector) Inspect(_ context.Context, key string) (*domain.CacheEntry, error) {
+	v, ok := m.redis.data[key]
+	if !ok {
+		return nil, domain.ErrCacheMiss
+	}
+	return &domain.CacheEntry{Key: key, Value: []byte(v), TTL: time.Hour}, nil
+}
+
+func (m *memoryCacheInspector) ScanKeys(_ context.Context, pattern string, limit int) ([]string, bool, error) {
+	var keys []string
+	for k := range m.redis.data {
+		if ok, _ := path.Match(pattern, k); ok {
+			if len(keys) == limit {
+				return keys, true, nil
+			}
+			keys = append(keys, k)
+		}
+	}
+	return keys, false, nil
+}
+
+func (m *memoryCacheInspector) DeleteKeys(_ context.Context, keys []string) (int, error) {
+	for _, k := range keys {
+		delete(m.redis.data, k)
+		m.deleted = append(m.deleted, k)
+	}
+	return len(keys), nil
+}
+
+// TestFlushCache проверяет защиту шаблона, пробный запуск и удаление
+func TestFlushCache(t *testing.T) {
+	ctx := context.Background()
+	redis := &memoryRedis{data: map[string]string{
+		service.CacheKey(uuid.New()): "{}",
+		service.CacheKey(uuid.New()): "{}",
+		service.CacheKey(uuid.New()): "{}",
+		"other-app:session":          "x",
+	}}
+	inspector := &memoryCacheInspector{redis: redis}
+	svc := service.NewNotificationService(new(MockRepository), nil, redis, time.Hour,
+		service.WithCacheInspector(inspector))
+
+	for _, pattern := range []string{"", "*", "notif*", "other-app:*"} {
+		_, err := svc.FlushCache(ctx, pattern, 10, false)
+		assert.ErrorIs(t, err, domain.ErrInvalidCachePattern, pattern)
+	}
+
+	res, err := svc.FlushCache(ctx, "notification:*", 10, true)
+	assert.NoError(t, err)
+	assert.Len(t, res.Keys, 3)
+	assert.Zero(t, res.Deleted)
+	assert.Empty(t, inspector.deleted)
+
+	res, err = svc.FlushCache(ctx, "notification:*", 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Deleted)
+	assert.True(t, res.Truncated)
+	assert.Len(t, redis.data, 2)
+	assert.Contains(t, redis.data, "other-app:session")
+
+	_, err = service.NewNotificationService(new(MockRepository), nil, redis, time.Hour).
+		FlushCache(ctx, "notification:*", 10, true)
+	assert.ErrorIs(t, err, domain.ErrCacheInspectorDisabled)
+}
+
+// TestCreateNotifications проверяет, что пакет записывается одним вызовом репозитория
+// только из прошедших проверку элементов, а ошибки остаются у своих элементов
+func TestCreateNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithMaxBatch(3))
+
+	at := time.Now().Add(time.Hour)
+	params := []domain.CreateNotificationParams{
+		{Recipient: "a@example.com", Channel: domain.ChannelEmail, ScheduledAt: at},
+		{Recipient: "", Channel: domain.ChannelEmail, ScheduledAt: at},
+		{Recipient: "c@example.com", Channel: domain.ChannelEmail, ScheduledAt: at, Priority: "urgent"},
+	}
+	created := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		ScheduledAt: at, Status: domain.StatusPending}
+	repo.On("CreateBatch", ctx, mock.MatchedBy(func(ps []domain.CreateParams) bool {
+		return len(ps) == 1 && ps[0].Recipient == "a@example.com"
+	})).Return([]*domain.Notification{created}, nil).Once()
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil).Once()
+
+	results, err := svc.CreateNotifications(ctx, params)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, created.ID, results[0].Notification.ID)
+		assert.ErrorIs(t, results[1].Err, domain.ErrEmptyRecipient)
+		assert.ErrorIs(t, results[2].Err, domain.ErrInvalidPriority)
+	}
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	_, err = svc.CreateNotifications(ctx, append(params, params[0]))
+	assert.ErrorIs(t, err, domain.ErrBatchTooLarge)
+	_, err = svc.CreateNotifications(ctx, nil)
+	assert.ErrorIs(t, err, domain.ErrEmptyBatch)
+}
+
+// TestCreateNotifications_ConcurrentDuplicate проверяет, что элемент, ключ которого параллельный
+// запрос занял между проверкой и записью, получает уведомление этого запроса, а не ошибку пакета
+func TestCreateNotifications_ConcurrentDuplicate(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	at := time.Now().Add(time.Hour)
+	params := []domain.CreateNotificationParams{
+		{Recipient: "a@example.com", Channel: domain.ChannelEmail, ScheduledAt: at},
+		{Recipient: "b@example.com", Channel: domain.ChannelEmail, ScheduledAt: at, Source: "billing",
+			IdempotencyKey: "order-42"},
+	}
+	created := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Status: domain.StatusPending}
+	existing := &domain.Notification{ID: uuid.New(), Recipient: "b@example.com", Status: domain.StatusPending}
+	repo.On("GetByIdempotencyKey", ctx, "billing", "order-42").Return(nil, domain.ErrNotFound).Once()
+	repo.On("CreateBatch", ctx, mock.Anything).Return([]*domain.Notification{created, nil}, nil).Once()
+	repo.On("GetByIdempotencyKey", ctx, "billing", "order-42").Return(existing, nil).Once()
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil).Once()
+
+	results, err := svc.CreateNotifications(ctx, params)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, created.ID, results[0].Notification.ID)
+		assert.NoError(t, results[1].Err)
+		assert.Equal(t, existing.ID, results[1].Notification.ID)
+	}
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+// TestCreateGroup проверяет, что группа создается одним пакетом без повторов получателей,
+// а с ключом идемпотентности получает тот же group_id и ключи по получателям
+func TestCreateGroup(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithMaxBatch(2))
+
+	at := time.Now().Add(time.Hour)
+	params := domain.CreateNotificationParams{Channel: domain.ChannelEmail, ScheduledAt: at, Source: "billing",
+		IdempotencyKey: "promo-7"}
+	var groupID uuid.UUID
+	repo.On("GetByIdempotencyKey", ctx, "billing", mock.Anything).Return(nil, domain.ErrNotFound).Twice()
+	repo.On("CreateBatch", ctx, mock.MatchedBy(func(ps []domain.CreateParams) bool {
+		if len(ps) != 2 || ps[0].Recipient != "a@example.com" || ps[1].Recipient != "b@example.com" {
+			return false
+		}
+		groupID = *ps[0].GroupID
+		return *ps[1].GroupID == groupID && ps[0].IdempotencyKey == "promo-7/a@example.com"
+	})).Return([]*domain.Notification{
+		{ID: uuid.New(), Recipient: "a@example.com", Status: domain.StatusPending},
+		{ID: uuid.New(), Recipient: "b@example.com", Status: domain.StatusPending},
+	}, nil).Once()
+	publisher.On("Publish", ctx, mock.Anything, mock.Anything).Return(nil).Twice()
+
+	res, err := svc.CreateGroup(ctx, params, []string{"a@example.com", "b@example.com", "a@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, groupID, res.GroupID)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, res.Recipients)
+	if assert.Len(t, res.Results, 2) {
+		assert.NoError(t, res.Results[1].Err)
+		assert.Equal(t, "b@example.com", res.Results[1].Notification.Recipient)
+	}
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	existing := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", GroupID: &groupID}
+	repo.On("GetByIdempotencyKey", ctx, "billing", "promo-7/a@example.com").Return(existing, nil).Once()
+	replay, err := svc.CreateGroup(ctx, params, []string{"a@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, groupID, replay.GroupID)
+	assert.Equal(t, existing.ID, replay.Results[0].Notification.ID)
+
+	_, err = svc.CreateGroup(ctx, params, nil)
+	assert.ErrorIs(t, err, domain.ErrEmptyRecipient)
+	_, err = svc.CreateGroup(ctx, params, []string{"a", "b", "c"})
+	assert.ErrorIs(t, err, domain.ErrBatchTooLarge)
+}
+
+// TestGroupStatus проверяет сводку группы и ErrNotFound для неизвестной группы
+func TestGroupStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := service.NewNotificationService(repo, new(MockPublisher), &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	groupID := uuid.New()
+	repo.On("ListGroup", ctx, groupID).Return([]domain.Notification{
+		{ID: uuid.New(), Status: domain.StatusSent}, {ID: uuid.New(), Status: domain.StatusPending},
+	}, nil).Once()
+	g, err := svc.GroupStatus(ctx, groupID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, g.Total())
+	assert.Equal(t, 1, g.ByState[domain.PublicStateSent])
+
+	unknown := uuid.New()
+	repo.On("ListGroup", ctx, unknown).Return([]domain.Notification{}, nil).Once()
+	_, err = svc.GroupStatus(ctx, unknown)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// TestCreateNotification_ChannelDefaults проверяет подстановку значений канала по умолчанию в payload
+func TestCreateNotification_ChannelDefaults(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithChannelDefaults(domain.ChannelDefaults{
+			domain.ChannelTelegram: {domain.PayloadParseMode: "HTML"},
+		}))
+
+	at := time.Now().Add(time.Hour)
+	created := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelTelegram, Status: domain.StatusPending}
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.Payload[domain.PayloadParseMode] == "MarkdownV2" && p.Payload["body"] == "hi"
+	})).Return(created, nil).Once()
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.Payload[domain.PayloadParseMode] == "HTML"
+	})).Return(created, nil).Once()
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil)
+
+	_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{Recipient: "42",
+		Channel: domain.ChannelTelegram, ScheduledAt: at,
+		Payload: map[string]interface{}{"body": "hi", domain.PayloadParseMode: "MarkdownV2"}})
+	assert.NoError(t, err)
+	_, err = svc.CreateNotification(ctx, domain.CreateNotificationParams{Recipient: "42",
+		Channel: domain.ChannelTelegram, ScheduledAt: at, Payload: map[string]interface{}{"body": "hi"}})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// TestCreateNotification_PayloadValidator проверяет, что payload проверяется после подстановки
+// значений по умолчанию, а ValidatePayload повторяет проверку для сохраненного уведомления
+func TestCreateNotification_PayloadValidator(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithChannelDefaults(domain.ChannelDefaults{
+			domain.ChannelTelegram: {domain.PayloadParseMode: "html"},
+		}),
+		service.WithPayloadValidator(domain.ChannelTelegram, telegramsender.PayloadValidator),
+		service.WithPayloadValidator(domain.ChannelEmail, emailsender.PayloadValidator))
+
+	_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{Recipient: "42",
+		Channel: domain.ChannelTelegram, ScheduledAt: time.Now().Add(time.Hour),
+		Payload: map[string]interface{}{"body": "hi"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidPayload)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	stored := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail,
+		Payload: map[string]interface{}{"subject": "Hi\r\nBcc: victim@example.com"}}
+	assert.ErrorIs(t, svc.ValidatePayload(stored), domain.ErrInvalidPayload)
+	stored.Payload["subject"] = "Hi"
+	assert.NoError(t, svc.ValidatePayload(stored))
+}
+
+// TestCreateNotification_IdempotencyKey проверяет, что повтор ключа возвращает
+// уже созданное уведомление, в том числе после проигранной гонки вставки
+func TestCreateNotification_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	params := domain.CreateNotificationParams{Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		ScheduledAt: time.Now().Add(time.Hour), Source: "billing", IdempotencyKey: "order-42"}
+	existing := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		Source: "billing", Status: domain.StatusPending}
+
+	repo.On("GetByIdempotencyKey", ctx, "billing", "order-42").Return(existing, nil).Once()
+	n, err := svc.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, n.ID)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// параллельный запрос вставил уведомление между поиском и вставкой
+	repo.On("GetByIdempotencyKey", ctx, "billing", "order-42").Return(nil, domain.ErrNotFound).Once()
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.IdempotencyKey == "order-42" && p.Source == "billing"
+	})).Return(nil, domain.ErrDuplicateIdempotencyKey).Twice()
+	repo.On("GetByIdempotencyKey", ctx, "billing", "order-42").Return(existing, nil).Once()
+	n, err = svc.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, n.ID)
+
+	// ключ занят удаленным уведомлением
+	repo.On("GetByIdempotencyKey", ctx, "billing", "order-42").Return(nil, domain.ErrNotFound).Twice()
+	_, err = svc.CreateNotification(ctx, params)
+	assert.ErrorIs(t, err, domain.ErrDuplicateIdempotencyKey)
+
+	repo.AssertExpectations(t)
+	publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCreateNotifications_IdempotencyKey проверяет ключи идемпотентности в пакете:
+// уже использованный ключ и повтор ключа внутри пакета не создают уведомлений
+func TestCreateNotifications_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour)
+
+	at := time.Now().Add(time.Hour)
+	params := []domain.CreateNotificationParams{
+		{Recipient: "a@example.com", Channel: domain.ChannelEmail, ScheduledAt: at, Source: "crm", IdempotencyKey: "k1"},
+		{Recipient: "b@example.com", Channel: domain.ChannelEmail, ScheduledAt: at, Source: "crm", IdempotencyKey: "k2"},
+		{Recipient: "a@example.com", Channel: domain.ChannelEmail, ScheduledAt: at, Source: "crm", IdempotencyKey: "k1"},
+	}
+	existing := &domain.Notification{ID: uuid.New(), Recipient: "b@example.com", Source: "crm"}
+	created := &domain.Notification{ID: uuid.New(), Recipient: "a@example.com", Channel: domain.ChannelEmail,
+		ScheduledAt: at, Status: domain.StatusPending, Source: "crm"}
+	repo.On("GetByIdempotencyKey", ctx, "crm", "k1").Return(nil, domain.ErrNotFound).Once()
+	repo.On("GetByIdempotencyKey", ctx, "crm", "k2").Return(existing, nil).Once()
+	repo.On("CreateBatch", ctx, mock.MatchedBy(func(ps []domain.CreateParams) bool {
+		return len(ps) == 1 && ps[0].IdempotencyKey == "k1"
+	})).Return([]*domain.Notification{created}, nil).Once()
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil).Once()
+
+	results, err := svc.CreateNotifications(ctx, params)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, created.ID, results[0].Notification.ID)
+		assert.Equal(t, existing.ID, results[1].Notification.ID)
+		assert.NoError(t, results[2].Err)
+		assert.Equal(t, created.ID, results[2].Notification.ID)
+	}
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+// TestStatusLink проверяет подпись, срок и отказ для чужих токенов страницы статуса
+func TestStatusLink(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	now := time.Now()
+	clock := domain.ClockFunc(func() time.Time { return now })
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithClock(clock), service.WithStatusLinks([]byte("secret"), 48*time.Hour))
+
+	n := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail, Status: domain.StatusDelivered,
+		Recipient: "user@example.com", ScheduledAt: now.Add(-time.Hour), UpdatedAt: now}
+	repo.On("GetByID", ctx, n.ID).Return(n, nil)
+
+	_, err := svc.StatusLink(ctx, n.ID, 72*time.Hour)
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusLinkTTL)
+
+	link, err := svc.StatusLink(ctx, n.ID, 0)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, now.Add(48*time.Hour), link.ExpiresAt, time.Second)
+
+	st, err := svc.PublicStatus(ctx, link.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PublicStateSent, st.State)
+	assert.Equal(t, domain.ChannelEmail, st.Channel)
+
+	// токен другого ключа и измененный токен не принимаются
+	other := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithClock(clock), service.WithStatusLinks([]byte("other"), 48*time.Hour))
+	_, err = other.PublicStatus(ctx, link.Token)
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusToken)
+	tampered := []byte(link.Token)
+	tampered[3] ^= 1
+	_, err = svc.PublicStatus(ctx, string(tampered))
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusToken)
+
+	now = now.Add(49 * time.Hour)
+	_, err = svc.PublicStatus(ctx, link.Token)
+	assert.ErrorIs(t, err, domain.ErrStatusTokenExpired)
+
+	disabled := service.NewNotificationService(repo, nil, nil, time.Hour)
+	_, err = disabled.StatusLink(ctx, n.ID, 0)
+	assert.ErrorIs(t, err, domain.ErrStatusLinksDisabled)
+}
+
+// republishingPublisher публикатор, который умеет заменять ожидающее сообщение
+type republishingPublisher struct {
+	MockPublisher
+}
+
+func (m *republishingPublisher) Republish(ctx context.Context, id uuid.UUID, ttl time.Duration) error {
+	args := m.Called(ctx, id, ttl)
+	return args.Error(0)
+}
+
+// TestRescheduleNotifications проверяет проверку параметров, повторную публикацию
+// с новой задержкой и обновление кеша
+func TestRescheduleNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(republishingPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	now := time.Now()
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+		service.WithClock(domain.ClockFunc(func() time.Time { return now })),
+		service.WithScheduleLimits(5*time.Minute, 24*time.Hour))
+
+	filter := domain.ListFilter{Channel: domain.ChannelEmail}
+	_, err := svc.RescheduleNotifications(ctx, domain.RescheduleParams{Filter: filter})
+	assert.ErrorIs(t, err, domain.ErrInvalidReschedule)
+	_, err = svc.RescheduleNotifications(ctx, domain.RescheduleParams{Shift: time.Hour})
+	assert.ErrorIs(t, err, domain.ErrEmptyRescheduleFilter)
+	tooFar := now.Add(48 * time.Hour)
+	_, err = svc.RescheduleNotifications(ctx, domain.RescheduleParams{Filter: filter, At: &tooFar})
+	assert.ErrorIs(t, err, domain.ErrScheduledTooFarInFuture)
+
+	params := domain.RescheduleParams{Filter: filter, Shift: 2 * time.Hour, Limit: 2}
+	moved := []domain.Notification{
+		{ID: uuid.New(), Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			EffectiveScheduledAt: now.Add(3 * time.Hour)},
+		{ID: uuid.New(), Channel: domain.ChannelEmail, Status: domain.StatusPending,
+			EffectiveScheduledAt: now.Add(4 * time.Hour)},
+	}
+	repo.On("Reschedule", ctx, params).Return(moved, nil).Once()
+	// задержка публикации на dispatchLead (2s) меньше времени до отправки
+	publisher.On("Republish", ctx, moved[0].ID, 3*time.Hour-2*time.Second).Return(nil).Once()
+	publisher.On("Republish", ctx, moved[1].ID, 4*time.Hour-2*time.Second).Return(errors.New("broker is down")).Once()
+
+	res, err := svc.RescheduleNotifications(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Rescheduled)
+	assert.Equal(t, 1, res.Republished)
+	assert.True(t, res.Truncated)
+	assert.Equal(t, []uuid.UUID{moved[0].ID, moved[1].ID}, res.IDs)
+	assert.Contains(t, redis.data, service.CacheKey(moved[0].ID))
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+// priorityPublisher публикатор с отдельной очередью для приоритета high
+type priorityPublisher struct {
+	MockPublisher
+}
+
+func (m *priorityPublisher) PublishPriority(ctx context.Context, id uuid.UUID, p domain.Priority,
+	ttl time.Duration) error {
+	return m.Called(ctx, id, p, ttl).Error(0)
+}
+
+func (m *priorityPublisher) RepublishPriority(ctx context.Context, id uuid.UUID, p domain.Priority,
+	ttl time.Duration) error {
+	return m.Called(ctx, id, p, ttl).Error(0)
+}
+
+// TestCreateNotification_PriorityQueue проверяет, что приоритет сохраняется в уведомлении
+// и выбирает очередь при публикации и повторной публикации
+func TestCreateNotification_PriorityQueue(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(priorityPublisher)
+	redis := &memoryRedis{data: map[string]string{}}
+	now := time.Now()
+	svc := service.NewNotificationService(repo, publisher, redis, time.Hour,
+		service.WithClock(domain.ClockFunc(func() time.Time { return now })))
+
+	otp := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail, Status: domain.StatusPending,
+		Priority: domain.PriorityHigh}
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		return p.Priority == domain.PriorityHigh
+	})).Return(otp, nil).Once()
+	publisher.On("PublishPriority", ctx, otp.ID, domain.PriorityHigh, mock.Anything).Return(nil).Once()
+
+	_, err := svc.CreateNotification(ctx, domain.CreateNotificationParams{
+		Recipient:   "user@example.com",
+		Channel:     domain.ChannelEmail,
+		Payload:     map[string]interface{}{"subject": "Код", "body": "123456"},
+		ScheduledAt: now.Add(time.Minute),
+		Priority:    domain.PriorityHigh,
+	})
+	assert.NoError(t, err)
+
+	// уведомление без приоритета (из кеша до появления поля) уходит в основную очередь
+	params := domain.RescheduleParams{Filter: domain.ListFilter{Channel: domain.ChannelEmail}, Shift: time.Hour}
+	moved := []domain.Notification{
+		{ID: uuid.New(), Status: domain.StatusPending, EffectiveScheduledAt: now.Add(time.Hour)},
+		{ID: otp.ID, Status: domain.StatusPending, EffectiveScheduledAt: now.Add(time.Hour),
+			Priority: domain.PriorityHigh},
+	}
+	repo.On("Reschedule", ctx, params).Return(moved, nil).Once()
+	publisher.On("RepublishPriority", ctx, moved[0].ID, domain.PriorityNormal, mock.Anything).Return(nil).Once()
+	publisher.On("RepublishPriority", ctx, otp.ID, domain.PriorityHigh, mock.Anything).Return(nil).Once()
+
+	res, err := svc.RescheduleNotifications(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Republished)
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+	publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// MockTemplateRepository мок для TemplateRepository
+type MockTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockTemplateRepository) CreateTemplate(ctx context.Context,
+	params domain.TemplateParams) (*domain.Template, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Template), args.Error(1)
+}
+
+func (m *MockTemplateRepository) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Template), args.Error(1)
+}
+
+func (m *MockTemplateRepository) ListTemplates(ctx context.Context) ([]domain.Template, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Template), args.Error(1)
+}
+
+func (m *MockTemplateRepository) UpdateTemplate(ctx context.Context, id uuid.UUID,
+	params domain.TemplateParams) (*domain.Template, error) {
+	args := m.Called(ctx, id, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Template), args.Error(1)
+}
+
+func (m *MockTemplateRepository) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+// TestCreateTemplate проверяет имя и синтаксис шаблона до сохранения
+func TestCreateTemplate(t *testing.T) {
+	ctx := context.Background()
+	templates := new(MockTemplateRepository)
+	svc := service.NewNotificationService(new(MockRepository), nil, nil, time.Hour,
+		service.WithTemplates(templates))
+
+	params := domain.TemplateParams{Name: "welcome", Subject: "Привет, {{.name}}", Body: "Код: {{.code}}"}
+	templates.On("CreateTemplate", ctx, params).Return(&domain.Template{ID: uuid.New(), Name: "welcome"}, nil)
+
+	_, err := svc.CreateTemplate(ctx, domain.TemplateParams{Name: " welcome ", Subject: params.Subject,
+		Body: params.Body})
+	assert.NoError(t, err)
+
+	_, err = svc.CreateTemplate(ctx, domain.TemplateParams{Name: "  ", Body: "x"})
+	assert.ErrorIs(t, err, domain.ErrInvalidTemplate)
+	_, err = svc.CreateTemplate(ctx, domain.TemplateParams{Name: "empty"})
+	assert.ErrorIs(t, err, domain.ErrInvalidTemplate)
+	_, err = svc.CreateTemplate(ctx, domain.TemplateParams{Name: "broken", Body: "{{.name"})
+	assert.ErrorIs(t, err, domain.ErrInvalidTemplate)
+	templates.AssertNumberOfCalls(t, "CreateTemplate", 1)
+
+	_, err = service.NewNotificationService(new(MockRepository), nil, nil, time.Hour).ListTemplates(ctx)
+	assert.ErrorIs(t, err, domain.ErrTemplatesDisabled)
+}
+
+// TestCreateNotification_Template проверяет, что при создании шаблон собирается с переменными,
+// а в базу попадают переменные без подставленного текста
+func TestCreateNotification_Template(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	templates := new(MockTemplateRepository)
+	templateID := uuid.New()
+	templates.On("GetTemplate", ctx, templateID).
+		Return(&domain.Template{ID: templateID, Body: "Здравствуйте, {{.name}}"}, nil)
+
+	created := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail, TemplateID: &templateID,
+		Payload: map[string]interface{}{"name": "Анна"}, ScheduledAt: time.Now().Add(time.Hour)}
+	repo.On("Create", ctx, mock.MatchedBy(func(p domain.CreateParams) bool {
+		_, rendered := p.Payload["body"]
+		return p.TemplateID != nil && *p.TemplateID == templateID && !rendered
+	})).Return(created, nil)
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil)
+
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithTemplates(templates))
+	params := domain.CreateNotificationParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		Payload:     map[string]interface{}{"name": "Анна"},
+		ScheduledAt: time.Now().Add(time.Hour),
+		TemplateID:  &templateID,
+	}
+	_, err := svc.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+
+	params.Payload = map[string]interface{}{"nickname": "Анна"}
+	_, err = svc.CreateNotification(ctx, params)
+	assert.ErrorIs(t, err, domain.ErrTemplateRender)
+
+	missing := uuid.New()
+	templates.On("GetTemplate", ctx, missing).Return(nil, domain.ErrTemplateNotFound)
+	params.TemplateID = &missing
+	_, err = svc.CreateNotification(ctx, params)
+	assert.ErrorIs(t, err, domain.ErrTemplateNotFound)
+	repo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// TestResolveTemplate проверяет, что при отправке берется текущая версия шаблона,
+// а исходное уведомление не меняется
+func TestResolveTemplate(t *testing.T) {
+	ctx := context.Background()
+	templates := new(MockTemplateRepository)
+	templateID := uuid.New()
+	templates.On("GetTemplate", ctx, templateID).Return(&domain.Template{ID: templateID,
+		Subject: "Заказ {{.order}}", Body: "Заказ {{.order}} доставлен"}, nil)
+	svc := service.NewNotificationService(new(MockRepository), nil, nil, time.Hour,
+		service.WithTemplates(templates))
+
+	n := &domain.Notification{ID: uuid.New(), TemplateID: &templateID,
+		Payload: map[string]interface{}{"order": "42", "subject": "старая тема"}}
+	got, err := svc.ResolveTemplate(ctx, n)
+	assert.NoError(t, err)
+	assert.Equal(t, "Заказ 42", got.Payload["subject"])
+	assert.Equal(t, "Заказ 42 доставлен", got.Payload["body"])
+	assert.Equal(t, "старая тема", n.Payload["subject"])
+	assert.NotContains(t, n.Payload, "body")
+
+	plain := &domain.Notification{ID: uuid.New(), Payload: map[string]interface{}{"body": "текст"}}
+	got, err = svc.ResolveTemplate(ctx, plain)
+	assert.NoError(t, err)
+	assert.Same(t, plain, got)
+}
+
+// MockSchemaRepository мок для SchemaRepository
+type MockSchemaRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchemaRepository) SaveSchema(ctx context.Context, source string, ch domain.Channel,
+	schema map[string]interface{}) (*domain.PayloadSchema, error) {
+	args := m.Called(ctx, source, ch, schema)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PayloadSchema), args.Error(1)
+}
+
+func (m *MockSchemaRepository) GetSchema(ctx context.Context, source string,
+	ch domain.Channel) (*domain.PayloadSchema, error) {
+	args := m.Called(ctx, source, ch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PayloadSchema), args.Error(1)
+}
+
+func (m *MockSchemaRepository) ListSchemas(ctx context.Context) ([]domain.PayloadSchema, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.PayloadSchema), args.Error(1)
+}
+
+func (m *MockSchemaRepository) DeleteSchema(ctx context.Context, source string, ch domain.Channel) error {
+	return m.Called(ctx, source, ch).Error(0)
+}
+
+// TestSaveSchema проверяет источник, канал и саму схему до сохранения
+func TestSaveSchema(t *testing.T) {
+	ctx := context.Background()
+	schemas := new(MockSchemaRepository)
+	svc := service.NewNotificationService(new(MockRepository), nil, nil, time.Hour,
+		service.WithSchemas(schemas))
+
+	doc := map[string]interface{}{"type": "object", "required": []interface{}{"order"}}
+	schemas.On("SaveSchema", ctx, "billing", domain.ChannelEmail, doc).
+		Return(&domain.PayloadSchema{Source: "billing", Channel: domain.ChannelEmail, Schema: doc}, nil)
+
+	_, err := svc.SaveSchema(ctx, " billing ", domain.ChannelEmail, doc)
+	assert.NoError(t, err)
+
+	_, err = svc.SaveSchema(ctx, " ", domain.ChannelEmail, doc)
+	assert.ErrorIs(t, err, domain.ErrInvalidSchemaSource)
+	_, err = svc.SaveSchema(ctx, "billing", domain.Channel("fax"), doc)
+	assert.ErrorIs(t, err, domain.ErrInvalidChannel)
+	_, err = svc.SaveSchema(ctx, "billing", domain.ChannelEmail, map[string]interface{}{"type": "text"})
+	assert.ErrorIs(t, err, domain.ErrInvalidSchema)
+	schemas.AssertNumberOfCalls(t, "SaveSchema", 1)
+
+	_, err = service.NewNotificationService(new(MockRepository), nil, nil, time.Hour).ListSchemas(ctx)
+	assert.ErrorIs(t, err, domain.ErrSchemasDisabled)
+}
+
+// TestCreateNotification_Schema проверяет, что payload источника со схемой проверяется до записи,
+// а источники и каналы без схемы создаются как раньше
+func TestCreateNotification_Schema(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	publisher := new(MockPublisher)
+	schemas := new(MockSchemaRepository)
+	schemas.On("GetSchema", ctx, "billing", domain.ChannelEmail).Return(&domain.PayloadSchema{
+		Source: "billing", Channel: domain.ChannelEmail,
+		Schema: map[string]interface{}{
+			"type":       "object",
+			"required":   []interface{}{"order"},
+			"properties": map[string]interface{}{"order": map[string]interface{}{"type": "string"}},
+		},
+	}, nil)
+	schemas.On("GetSchema", ctx, "billing", domain.ChannelTelegram).Return(nil, domain.ErrSchemaNotFound)
+
+	created := &domain.Notification{ID: uuid.New(), Channel: domain.ChannelEmail,
+		ScheduledAt: time.Now().Add(time.Hour)}
+	repo.On("Create", ctx, mock.Anything).Return(created, nil)
+	publisher.On("Publish", ctx, created.ID, mock.Anything).Return(nil)
+
+	svc := service.NewNotificationService(repo, publisher, &memoryRedis{data: map[string]string{}}, time.Hour,
+		service.WithSchemas(schemas))
+	params := domain.CreateNotificationParams{
+		Recipient:   "test@example.com",
+		Channel:     domain.ChannelEmail,
+		Source:      "billing",
+		Payload:     map[string]interface{}{"order": "42", "body": "Заказ оформлен"},
+		ScheduledAt: time.Now().Add(time.Hour),
+	}
+	_, err := svc.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+
+	params.Payload = map[string]interface{}{"order": 42.0, "body": "Заказ оформлен"}
+	_, err = svc.CreateNotification(ctx, params)
+	assert.ErrorIs(t, err, domain.ErrSchemaMismatch)
+	assert.ErrorContains(t, err, "payload.order")
+	repo.AssertNumberOfCalls(t, "Create", 1)
+
+	params.Channel, params.Recipient = domain.ChannelTelegram, "12345"
+	_, err = svc.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+
+	params.Channel, params.Source = domain.ChannelEmail, ""
+	params.Recipient = "test@example.com"
+	_, err = svc.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "Create", 3)
+	schemas.AssertNumberOfCalls(t, "GetSchema", 3)
+}
+
+// TestCancel_Precondition проверяет условие из контекста: несовпадение отклоняется до записи,
+// а изменение между чтением и записью — по updated_at в условии обновления
+func TestCancel_Precondition(t *testing.T) {
+	repo := new(MockRepository)
+	svc := service.NewNotificationService(repo, nil, &memoryRedis{data: map[string]string{}}, time.Hour)
+	read := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	n := &domain.Notification{ID: uuid.New(), Status: domain.StatusPending, UpdatedAt: read}
+	repo.On("GetByID", mock.Anything, n.ID).Return(n, nil)
+
+	stale := domain.WithPrecondition(context.Background(), func(*domain.Notification) bool { return false })
+	assert.ErrorIs(t, svc.Cancel(stale, n.ID), domain.ErrPreconditionFailed)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	fresh := domain.WithPrecondition(context.Background(), func(got *domain.Notification) bool {
+		return got.UpdatedAt.Equal(read)
+	})
+	repo.On("Update", fresh, n.ID, mock.MatchedBy(func(opts []domain.UpdateOption) bool {
+		var p domain.UpdateParams
+		for _, opt := range opts {
+			opt(&p)
+		}
+		return p.IfUpdatedAt != nil && p.IfUpdatedAt.Equal(read) && p.UpdatedAt != nil
+	})).Return(domain.ErrNoRowAffected)
+	assert.ErrorIs(t, svc.Cancel(fresh, n.ID), domain.ErrPreconditionFailed)
 	repo.AssertExpectations(t)
 }
@@ -0,0 +1,92 @@
+package worker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+// outcomeService отдает итоги отправки по окнам и запоминает созданные оповещения;
+// остальные методы NotificationService не вызываются детектором.
+type outcomeService struct {
+	domain.NotificationService
+	current, baseline map[domain.Channel]domain.OutcomeCounts
+	alerts            []domain.CreateNotificationParams
+}
+
+func (s *outcomeService) CountOutcomes(_ context.Context, _, to time.Time) (map[domain.Channel]domain.OutcomeCounts,
+	error) {
+	if time.Since(to) < time.Minute {
+		return s.current, nil
+	}
+	return s.baseline, nil
+}
+
+func (s *outcomeService) CreateNotification(_ context.Context,
+	params domain.CreateNotificationParams) (*domain.Notification, error) {
+	s.alerts = append(s.alerts, params)
+	return &domain.Notification{}, nil
+}
+
+var testPolicy = worker.FailureRatePolicy{
+	Window:     10 * time.Minute,
+	Baseline:   6 * time.Hour,
+	Factor:     3,
+	MinRate:    0.05,
+	MinSamples: 20,
+}
+
+// TestFailureRateDetector_Spike проверяет оповещение при всплеске и паузу между оповещениями
+func TestFailureRateDetector_Spike(t *testing.T) {
+	svc := &outcomeService{
+		current: map[domain.Channel]domain.OutcomeCounts{
+			domain.ChannelEmail:    {Sent: 70, Failed: 30},
+			domain.ChannelTelegram: {Sent: 98, Failed: 2},
+		},
+		baseline: map[domain.Channel]domain.OutcomeCounts{
+			domain.ChannelEmail:    {Sent: 980, Failed: 20},
+			domain.ChannelTelegram: {Sent: 990, Failed: 10},
+		},
+	}
+	d := worker.NewFailureRateDetector(svc, time.Minute, testPolicy, worker.StaleAlert{
+		Recipient: "oncall@example.com",
+		Channel:   domain.ChannelTelegram,
+		Cooldown:  30 * time.Minute,
+	})
+
+	now := time.Now()
+	assert.Equal(t, []domain.Channel{domain.ChannelEmail}, d.Check(context.Background(), now))
+	assert.Len(t, svc.alerts, 1)
+	assert.Equal(t, domain.PriorityHigh, svc.alerts[0].Priority)
+	assert.Equal(t, worker.StaleAlertSource, svc.alerts[0].Source)
+
+	assert.Equal(t, []domain.Channel{domain.ChannelEmail}, d.Check(context.Background(), now.Add(time.Minute)))
+	assert.Len(t, svc.alerts, 1, "second alert within cooldown")
+}
+
+// TestFailureRateDetector_NoSpike проверяет пороги: мало отправок, низкая доля, высокая базовая доля
+func TestFailureRateDetector_NoSpike(t *testing.T) {
+	cases := map[string]struct {
+		current, baseline domain.OutcomeCounts
+	}{
+		"too few samples":       {current: domain.OutcomeCounts{Sent: 5, Failed: 5}},
+		"below min rate":        {current: domain.OutcomeCounts{Sent: 97, Failed: 3}},
+		"usual for the channel": {current: domain.OutcomeCounts{Sent: 60, Failed: 40}, baseline: domain.OutcomeCounts{Sent: 700, Failed: 300}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc := &outcomeService{
+				current:  map[domain.Channel]domain.OutcomeCounts{domain.ChannelEmail: tc.current},
+				baseline: map[domain.Channel]domain.OutcomeCounts{domain.ChannelEmail: tc.baseline},
+			}
+			d := worker.NewFailureRateDetector(svc, time.Minute, testPolicy, worker.StaleAlert{Recipient: "oncall@example.com"})
+
+			assert.Empty(t, d.Check(context.Background(), time.Now()))
+			assert.Empty(t, svc.alerts)
+		})
+	}
+}
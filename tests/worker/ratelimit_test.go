@@ -0,0 +1,67 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := domain.ClockFunc(func() time.Time { return now })
+
+	t.Run("burst then paced", func(t *testing.T) {
+		l := worker.NewRateLimiter(map[domain.Channel]float64{domain.ChannelEmail: 2}, clock)
+
+		for i := 0; i < 2; i++ {
+			wait, ok := l.Reserve(domain.ChannelEmail, time.Minute)
+			assert.True(t, ok)
+			assert.Zero(t, wait)
+		}
+		wait, ok := l.Reserve(domain.ChannelEmail, time.Minute)
+		assert.True(t, ok)
+		assert.Equal(t, 500*time.Millisecond, wait)
+		// следующий токен уже зарезервирован, очередь растет
+		wait, ok = l.Reserve(domain.ChannelEmail, time.Minute)
+		assert.True(t, ok)
+		assert.Equal(t, time.Second, wait)
+
+		now = now.Add(10 * time.Second)
+		wait, ok = l.Reserve(domain.ChannelEmail, time.Minute)
+		assert.True(t, ok)
+		assert.Zero(t, wait, "запас восстанавливается, но не выше burst")
+	})
+
+	t.Run("refuses long wait without taking token", func(t *testing.T) {
+		l := worker.NewRateLimiter(map[domain.Channel]float64{domain.ChannelSMS: 0.1}, clock)
+
+		_, ok := l.Reserve(domain.ChannelSMS, time.Second)
+		assert.True(t, ok)
+		wait, ok := l.Reserve(domain.ChannelSMS, time.Second)
+		assert.False(t, ok)
+		assert.Equal(t, 10*time.Second, wait)
+		// отказ не расходует токен: ожидание не выросло
+		wait, ok = l.Reserve(domain.ChannelSMS, time.Second)
+		assert.False(t, ok)
+		assert.Equal(t, 10*time.Second, wait)
+	})
+
+	t.Run("unlimited channels", func(t *testing.T) {
+		l := worker.NewRateLimiter(map[domain.Channel]float64{domain.ChannelTelegram: 0}, clock)
+		for i := 0; i < 100; i++ {
+			wait, ok := l.Reserve(domain.ChannelTelegram, 0)
+			assert.True(t, ok)
+			assert.Zero(t, wait)
+		}
+		wait, ok := l.Reserve(domain.ChannelEmail, 0)
+		assert.True(t, ok)
+		assert.Zero(t, wait)
+
+		var nilLimiter *worker.RateLimiter
+		_, ok = nilLimiter.Reserve(domain.ChannelEmail, 0)
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,65 @@
+package worker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/metrics"
+	"DelayedNotifier/internal/worker"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// orphanService отдает заготовленные страницы сверки и запоминает переданные курсоры.
+type orphanService struct {
+	domain.NotificationService
+	pages   []domain.OrphanReport
+	cursors []*domain.OrphanCursor
+	limit   int
+}
+
+func (s *orphanService) ReconcileOrphans(_ context.Context, _, _ time.Duration, after *domain.OrphanCursor,
+	limit int) (domain.OrphanReport, error) {
+	s.cursors = append(s.cursors, after)
+	s.limit = limit
+	page := s.pages[0]
+	s.pages = s.pages[1:]
+	return page, nil
+}
+
+// TestReaper_Check проверяет обход всех страниц за проход и метрику найденных уведомлений
+func TestReaper_Check(t *testing.T) {
+	next := &domain.OrphanCursor{EffectiveScheduledAt: time.Now().Add(-time.Hour), ID: uuid.New()}
+	svc := &orphanService{pages: []domain.OrphanReport{
+		{Found: 2, Republished: 2, Next: next},
+		{Found: 1, Failed: 1},
+	}}
+	reaper := worker.NewReaper(svc, time.Minute, worker.ReaperPolicy{Grace: 15 * time.Minute, BatchSize: 2})
+
+	reaper.Check(context.Background())
+
+	assert.Equal(t, []*domain.OrphanCursor{nil, next}, svc.cursors)
+	assert.Equal(t, 2, svc.limit)
+	assert.Equal(t, int64(3), metrics.OrphanedNotifications.Value())
+}
+
+// TestReaper_Budget проверяет, что исчерпавший бюджет проход продолжается со следующего
+func TestReaper_Budget(t *testing.T) {
+	first := &domain.OrphanCursor{EffectiveScheduledAt: time.Now().Add(-2 * time.Hour), ID: uuid.New()}
+	second := &domain.OrphanCursor{EffectiveScheduledAt: time.Now().Add(-time.Hour), ID: uuid.New()}
+	svc := &orphanService{pages: []domain.OrphanReport{
+		{Found: 1, Republished: 1, Next: first},
+		{Found: 1, Republished: 1, Next: second},
+		{Found: 1, Republished: 1},
+	}}
+	reaper := worker.NewReaper(svc, time.Minute, worker.ReaperPolicy{BatchSize: 1,
+		BatchPause: 20 * time.Millisecond, Budget: 30 * time.Millisecond})
+
+	reaper.Check(context.Background())
+	assert.Equal(t, []*domain.OrphanCursor{nil, first}, svc.cursors)
+
+	reaper.Check(context.Background())
+	assert.Equal(t, []*domain.OrphanCursor{nil, first, second}, svc.cursors)
+}
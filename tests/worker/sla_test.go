@@ -0,0 +1,54 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"DelayedNotifier/internal/domain"
+	"DelayedNotifier/internal/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+// slaService отдает заранее заданные отчеты SLA.
+type slaService struct {
+	domain.NotificationService
+	reports []domain.SLAReport
+}
+
+func (s *slaService) SLACompliance(context.Context) ([]domain.SLAReport, error) {
+	return s.reports, nil
+}
+
+// TestSLAMonitor_Events проверяет, что события уходят только при смене состояния цели
+func TestSLAMonitor_Events(t *testing.T) {
+	var events []worker.SLAEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e worker.SLAEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		events = append(events, e)
+	}))
+	defer srv.Close()
+
+	target := domain.SLATarget{Source: "billing", Channel: domain.ChannelEmail, Objective: 0.95, Within: time.Minute}
+	svc := &slaService{reports: []domain.SLAReport{{
+		Target: target, Window: time.Hour, SLACounts: domain.SLACounts{Met: 80, Total: 100}, Breached: true,
+	}}}
+	m := worker.NewSLAMonitor(svc, time.Minute, srv.URL)
+
+	m.Check(context.Background())
+	m.Check(context.Background())
+	assert.Len(t, events, 1, "breach is reported once")
+	assert.Equal(t, "sla_breach", events[0].Event)
+	assert.Equal(t, "billing/email", events[0].Target)
+	assert.InDelta(t, 0.8, events[0].Compliance, 1e-9)
+
+	svc.reports[0].SLACounts = domain.SLACounts{Met: 99, Total: 100}
+	svc.reports[0].Breached = false
+	m.Check(context.Background())
+	assert.Len(t, events, 2)
+	assert.Equal(t, "sla_recovered", events[1].Event)
+}
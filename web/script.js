@@ -36,6 +36,12 @@ function isValidTelegram(str) {
     return re.test(str);
 }
 
+function isValidPhone(str) {
+    // Номер в формате E.164: +, код страны, всего до 15 цифр
+    const re = /^\+[1-9][0-9]{6,14}$/;
+    return re.test(str);
+}
+
 // Обновление метки и валидации при смене канала
 const channelSelect = document.getElementById('channel');
 const recipientInput = document.getElementById('recipient');
@@ -50,6 +56,8 @@ function updateRecipientValidation() {
         recipientInput.type = 'text'; // остаётся text, но валидируем как email
     } else if (channel === 'telegram') {
         recipientInput.placeholder = '@your_username';
+    } else if (channel === 'sms') {
+        recipientInput.placeholder = '+79991234567';
     }
     validateRecipient();
 }
@@ -85,6 +93,9 @@ function validateRecipient() {
     } else if (channel === 'telegram') {
         isValid = isValidTelegram(value);
         if (!isValid) recipientError.textContent = 'Telegram: должен начинаться с @, 5–32 символа (латиница, цифры, _)';
+    } else if (channel === 'sms') {
+        isValid = isValidPhone(value);
+        if (!isValid) recipientError.textContent = 'SMS: номер в формате +79991234567';
     }
 
     recipientInput.style.borderColor = isValid ? '#ddd' : '#e74c3c';
@@ -164,9 +175,11 @@ document.getElementById('create-form').addEventListener('submit', async (e) => {
 
     // Валидация получателя
     if (!validateRecipient()) {
-        recipientError.textContent = channelSelect.value === 'email'
-            ? 'Проверьте email'
-            : 'Проверьте Telegram @username';
+        recipientError.textContent = {
+            email: 'Проверьте email',
+            telegram: 'Проверьте Telegram @username',
+            sms: 'Проверьте номер телефона',
+        }[channelSelect.value];
         return;
     }
 